@@ -29,6 +29,7 @@ import (
 	"github.com/valyala/fasthttp"
 	"go.uber.org/automaxprocs/maxprocs"
 
+	_ "github.com/bhojpur/application/pkg/components/schemas" // Register the built-in component metadata schemas
 	"github.com/bhojpur/application/pkg/runtime"
 	"github.com/bhojpur/service/pkg/utils/logger"
 
@@ -73,6 +74,7 @@ import (
 	"github.com/bhojpur/service/pkg/state/zookeeper"
 
 	state_loader "github.com/bhojpur/application/pkg/components/state"
+	state_embedded "github.com/bhojpur/application/pkg/components/state/embedded"
 
 	// Pub/Sub.
 	configuration_loader "github.com/bhojpur/application/pkg/components/configuration"
@@ -212,6 +214,9 @@ func main() {
 			state_loader.New("redis", func() state.Store {
 				return state_redis.NewRedisStateStore(logService)
 			}),
+			state_loader.New("embedded", func() state.Store {
+				return state_embedded.NewEmbeddedStateStore(logService)
+			}),
 			state_loader.New("consul", func() state.Store {
 				return consul.NewConsulStateStore(logService)
 			}),
@@ -25,13 +25,18 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/bhojpur/application/pkg/components"
+	_ "github.com/bhojpur/application/pkg/components/schemas" // Register the built-in component metadata schemas
+	"github.com/bhojpur/application/pkg/config/modes"
 	"github.com/bhojpur/application/pkg/kubernetes"
+	"github.com/bhojpur/application/pkg/standalone"
 	"github.com/bhojpur/application/pkg/utils"
 )
 
 var (
 	componentsName         string
 	componentsOutputFormat string
+	componentsValidatePath string
 )
 
 var ComponentsCmd = &cobra.Command{
@@ -52,12 +57,45 @@ var ComponentsCmd = &cobra.Command{
 	Example: `
 # List Kubernetes components
 appctl components -k
+
+# List Kubernetes components with their namespace, dependencies and status
+appctl components -k -o wide
+
+# List Kubernetes components with custom columns
+appctl components -k -o custom-columns=NAME:.metadata.name,TYPE:.spec.type
+`,
+}
+
+var ComponentsValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the metadata of the runtime components in a directory. Supported platforms: Self-hosted",
+	Run: func(cmd *cobra.Command, args []string) {
+		loader := components.NewStandaloneComponents(modes.StandaloneConfig{ComponentsPath: componentsValidatePath})
+		comps, errs := loader.ValidateComponents()
+		for _, err := range errs {
+			utils.FailureStatusEvent(os.Stderr, err.Error())
+		}
+		if len(errs) > 0 {
+			os.Exit(1)
+		}
+		utils.SuccessStatusEvent(os.Stdout, "%d components in %s are valid", len(comps), componentsValidatePath)
+	},
+	Example: `
+# Validate the components in the default components directory
+appctl components validate
+
+# Validate the components in a directory
+appctl components validate --components-path ./components
 `,
 }
 
 func init() {
+	ComponentsValidateCmd.Flags().StringVarP(&componentsValidatePath, "components-path", "d", standalone.DefaultComponentsDirPath(), "The path for components directory")
+	ComponentsValidateCmd.Flags().BoolP("help", "h", false, "Print this help message")
+	ComponentsCmd.AddCommand(ComponentsValidateCmd)
+
 	ComponentsCmd.Flags().StringVarP(&componentsName, "name", "n", "", "The components name to be printed (optional)")
-	ComponentsCmd.Flags().StringVarP(&componentsOutputFormat, "output", "o", "list", "Output format (options: json or yaml or list)")
+	ComponentsCmd.Flags().StringVarP(&componentsOutputFormat, "output", "o", "list", "Output format (options: json or yaml or list or wide or custom-columns=<header>:<json-path-expr>,...)")
 	ComponentsCmd.Flags().BoolVarP(&kubernetesMode, "kubernetes", "k", false, "List all Bhojpur Application components in a Kubernetes cluster")
 	ComponentsCmd.Flags().BoolP("help", "h", false, "Print this help message")
 	ComponentsCmd.MarkFlagRequired("kubernetes")
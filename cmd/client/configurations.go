@@ -52,12 +52,15 @@ var ConfigurationsCmd = &cobra.Command{
 	Example: `
 # List Kubernetes Bhojpur Application runtime configurations
 appctl configurations -k
+
+# List Kubernetes Bhojpur Application runtime configurations with custom columns
+appctl configurations -k -o custom-columns=NAME:.metadata.name,TRACING:.spec.tracing.samplingRate
 `,
 }
 
 func init() {
 	ConfigurationsCmd.Flags().StringVarP(&configurationName, "name", "n", "", "The configuration name to be printed (optional)")
-	ConfigurationsCmd.Flags().StringVarP(&configurationOutputFormat, "output", "o", "list", "Output format (options: json or yaml or list)")
+	ConfigurationsCmd.Flags().StringVarP(&configurationOutputFormat, "output", "o", "list", "Output format (options: json or yaml or list or wide or custom-columns=<header>:<json-path-expr>,...)")
 	ConfigurationsCmd.Flags().BoolVarP(&kubernetesMode, "kubernetes", "k", false, "List all Bhojpur Application configurations in a Kubernetes cluster")
 	ConfigurationsCmd.Flags().BoolP("help", "h", false, "Print this help message")
 	ConfigurationsCmd.MarkFlagRequired("kubernetes")
@@ -28,6 +28,7 @@ import (
 
 	"github.com/bhojpur/service/pkg/utils/logger"
 
+	_ "github.com/bhojpur/application/pkg/components/schemas" // Register the built-in component metadata schemas
 	"github.com/bhojpur/application/pkg/metrics"
 	"github.com/bhojpur/application/pkg/operator"
 	"github.com/bhojpur/application/pkg/operator/monitoring"
@@ -55,7 +56,7 @@ func main() {
 	ctx := signals.Context()
 	go operator.NewOperator(config, certChainPath, !disableLeaderElection).Run(ctx)
 	// The webhooks use their own controller context and stops on SIGTERM and SIGINT.
-	go operator.RunWebhooks(!disableLeaderElection)
+	go operator.RunWebhooks(config, !disableLeaderElection)
 
 	<-ctx.Done() // Wait for SIGTERM and SIGINT.
 
@@ -86,6 +86,7 @@ type Actors interface {
 	DeleteTimer(ctx context.Context, req *DeleteTimerRequest) error
 	IsActorHosted(ctx context.Context, req *ActorHostedRequest) bool
 	GetActiveActorsCount(ctx context.Context) []ActiveActorsCount
+	Checkpoint(ctx context.Context) error
 }
 
 type actorsRuntime struct {
@@ -98,6 +99,7 @@ type actorsRuntime struct {
 	actorsTable              *sync.Map
 	activeTimers             *sync.Map
 	activeTimersLock         *sync.RWMutex
+	timerStates              *sync.Map
 	activeReminders          *sync.Map
 	remindersLock            *sync.RWMutex
 	remindersMigrationLock   *sync.Mutex
@@ -168,6 +170,7 @@ func NewActors(
 		actorsTable:              &sync.Map{},
 		activeTimers:             &sync.Map{},
 		activeTimersLock:         &sync.RWMutex{},
+		timerStates:              &sync.Map{},
 		activeReminders:          &sync.Map{},
 		remindersLock:            &sync.RWMutex{},
 		remindersMigrationLock:   &sync.Mutex{},
@@ -372,7 +375,12 @@ func (a *actorsRuntime) getOrCreateActor(actorType, actorID string) *actor {
 	// call newActor, but this is trivial.
 	val, ok := a.actorsTable.Load(key)
 	if !ok {
-		val, _ = a.actorsTable.LoadOrStore(key, newActor(actorType, actorID, a.config.GetReentrancyForType(actorType).MaxStackDepth))
+		var loaded bool
+		val, loaded = a.actorsTable.LoadOrStore(key, newActor(actorType, actorID, a.config.GetReentrancyForType(actorType).MaxStackDepth))
+		if !loaded {
+			// timers checkpointed by the previous host of the actor are restored on activation
+			go a.restoreTimers(actorType, actorID)
+		}
 	}
 
 	return val.(*actor)
@@ -1109,8 +1117,19 @@ func (a *actorsRuntime) CreateTimer(ctx context.Context, req *CreateTimerRequest
 
 	log.Debugf("create timer %q dueTime:%s period:%s repeats:%d ttl:%s",
 		req.Name, dueTime.String(), period.String(), repeats, ttl.String())
+	a.startTimer(ctx, req, dueTime, ttl, years, months, days, period, repeats)
+	return nil
+}
+
+// startTimer runs a parsed timer until it completes, expires or is deleted, activeTimersLock must be held.
+func (a *actorsRuntime) startTimer(ctx context.Context, req *CreateTimerRequest, dueTime, ttl time.Time, years, months, days int, period time.Duration, repeats int) {
+	actorKey := constructCompositeKey(req.ActorType, req.ActorID)
+	timerKey := constructCompositeKey(actorKey, req.Name)
+
 	stop := make(chan bool, 1)
 	a.activeTimers.Store(timerKey, stop)
+	progress := &timerState{req: *req, nextTime: dueTime, repeats: repeats, ttl: ttl}
+	a.timerStates.Store(timerKey, progress)
 
 	go func(stop chan bool, req *CreateTimerRequest) {
 		var (
@@ -1156,6 +1175,7 @@ func (a *actorsRuntime) CreateTimer(ctx context.Context, req *CreateTimerRequest
 				}
 			} else {
 				log.Errorf("could not find active timer %s", timerKey)
+				a.timerStates.Delete(timerKey)
 				return
 			}
 			if repeats == 0 || (years == 0 && months == 0 && days == 0 && period == 0) {
@@ -1163,6 +1183,7 @@ func (a *actorsRuntime) CreateTimer(ctx context.Context, req *CreateTimerRequest
 				break L
 			}
 			nextTime = nextTime.AddDate(years, months, days).Add(period)
+			progress.advance(nextTime, repeats)
 			if nextTimer.Stop() {
 				<-nextTimer.C
 			}
@@ -1177,7 +1198,6 @@ func (a *actorsRuntime) CreateTimer(ctx context.Context, req *CreateTimerRequest
 			log.Errorf("error deleting timer %s: %v", timerKey, err)
 		}
 	}(stop, req)
-	return nil
 }
 
 func (a *actorsRuntime) executeTimer(actorType, actorID, name, dueTime, period, callback string, data interface{}) error {
@@ -1709,6 +1729,7 @@ func (a *actorsRuntime) DeleteTimer(ctx context.Context, req *DeleteTimerRequest
 		close(stopChan.(chan bool))
 		a.activeTimers.Delete(timerKey)
 	}
+	a.timerStates.Delete(timerKey)
 
 	return nil
 }
@@ -2122,3 +2122,41 @@ func TestReentrancyStackLimitPerActor(t *testing.T) {
 	assert.Nil(t, resp)
 	assert.Error(t, err)
 }
+
+func TestCheckpointTimers(t *testing.T) {
+	testActorsRuntime := newTestActorsRuntime()
+	actorType, actorID := getTestActorTypeAndID()
+	ctx := context.Background()
+	actorKey := constructCompositeKey(actorType, actorID)
+	fakeCallAndActivateActor(testActorsRuntime, actorType, actorID)
+
+	timer := createTimerData(actorID, actorType, "timer1", "1h", "1h", "", "callback", "data")
+	err := testActorsRuntime.CreateTimer(ctx, &timer)
+	assert.Nil(t, err)
+
+	timerKey := constructCompositeKey(actorKey, timer.Name)
+
+	err = testActorsRuntime.Checkpoint(ctx)
+	assert.Nil(t, err)
+
+	_, ok := testActorsRuntime.activeTimers.Load(timerKey)
+	assert.False(t, ok)
+
+	resp, err := testActorsRuntime.store.Get(&state.GetRequest{Key: timerCheckpointKey(actorType, actorID)})
+	assert.Nil(t, err)
+	var checkpoints []TimerCheckpoint
+	assert.Nil(t, json.Unmarshal(resp.Data, &checkpoints))
+	assert.Len(t, checkpoints, 1)
+	assert.Equal(t, "timer1", checkpoints[0].Name)
+	assert.Equal(t, "callback", checkpoints[0].Callback)
+	assert.Equal(t, -1, checkpoints[0].RepetitionLeft)
+
+	// Activating the actor again restores its timers.
+	testActorsRuntime.actorsTable.Delete(actorKey)
+	testActorsRuntime.getOrCreateActor(actorType, actorID)
+
+	assert.Eventually(t, func() bool {
+		_, ok := testActorsRuntime.activeTimers.Load(timerKey)
+		return ok
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,234 @@
+package actors
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/bhojpur/service/pkg/state"
+)
+
+// TimerCheckpoint is the persisted progress of an actor timer, saved on shutdown and restored when the actor
+// is activated again, possibly on another host.
+type TimerCheckpoint struct {
+	Name     string      `json:"name"`
+	Callback string      `json:"callback"`
+	Data     interface{} `json:"data,omitempty"`
+	Period   string      `json:"period,omitempty"`
+	// NextTime is the time the timer fires next, in RFC3339 format.
+	NextTime string `json:"nextTime"`
+	// RepetitionLeft is the number of remaining executions, -1 for timers repeating until deleted.
+	RepetitionLeft int `json:"repetitionLeft"`
+	// TTL is the time the timer expires, in RFC3339 format, empty for timers without TTL.
+	TTL string `json:"ttl,omitempty"`
+}
+
+// timerState tracks the progress of a running timer, so it can be checkpointed.
+type timerState struct {
+	lock     sync.Mutex
+	req      CreateTimerRequest
+	nextTime time.Time
+	repeats  int
+	ttl      time.Time
+}
+
+func (s *timerState) advance(nextTime time.Time, repeats int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.nextTime, s.repeats = nextTime, repeats
+}
+
+func (s *timerState) checkpoint() TimerCheckpoint {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	checkpoint := TimerCheckpoint{
+		Name:           s.req.Name,
+		Callback:       s.req.Callback,
+		Data:           s.req.Data,
+		Period:         s.req.Period,
+		NextTime:       s.nextTime.UTC().Format(time.RFC3339Nano),
+		RepetitionLeft: s.repeats,
+	}
+	if !s.ttl.IsZero() {
+		checkpoint.TTL = s.ttl.UTC().Format(time.RFC3339Nano)
+	}
+	return checkpoint
+}
+
+func timerCheckpointKey(actorType, actorID string) string {
+	return constructCompositeKey("actors", "timers", actorType, actorID)
+}
+
+// Checkpoint stops the timers and reminders of the host before it shuts down. The progress of the timers is saved
+// in the state store, and restored in timer name order when their actor is next activated. Reminders are already
+// persisted, they are started again by the host their actor is placed on.
+func (a *actorsRuntime) Checkpoint(ctx context.Context) error {
+	a.activeRemindersLock.Lock()
+	a.activeReminders.Range(func(key, stop interface{}) bool {
+		close(stop.(chan bool))
+		a.activeReminders.Delete(key)
+		return true
+	})
+	a.activeRemindersLock.Unlock()
+
+	a.activeTimersLock.Lock()
+	byActor := map[string][]TimerCheckpoint{}
+	a.timerStates.Range(func(key, value interface{}) bool {
+		timerKey := key.(string)
+		if stop, ok := a.activeTimers.Load(timerKey); ok {
+			close(stop.(chan bool))
+			a.activeTimers.Delete(timerKey)
+		}
+		a.timerStates.Delete(timerKey)
+
+		progress := value.(*timerState)
+		actorKey := constructCompositeKey(progress.req.ActorType, progress.req.ActorID)
+		// the timers of deactivated actors are not restored
+		if _, active := a.actorsTable.Load(actorKey); active {
+			byActor[actorKey] = append(byActor[actorKey], progress.checkpoint())
+		}
+		return true
+	})
+	a.activeTimersLock.Unlock()
+
+	if len(byActor) == 0 {
+		return nil
+	}
+	if a.store == nil {
+		return errors.New("actors: state store does not exist or incorrectly configured")
+	}
+
+	var failed []string
+	for actorKey, checkpoints := range byActor {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		sort.Slice(checkpoints, func(i, j int) bool {
+			return checkpoints[i].Name < checkpoints[j].Name
+		})
+		actorType, actorID := a.getActorTypeAndIDFromKey(actorKey)
+		if err := a.store.Set(&state.SetRequest{Key: timerCheckpointKey(actorType, actorID), Value: checkpoints}); err != nil {
+			log.Errorf("error checkpointing timers of actor %s: %s", actorKey, err)
+			failed = append(failed, actorKey)
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return errors.Errorf("failed to checkpoint timers of actors %s", strings.Join(failed, ", "))
+	}
+	log.Infof("checkpointed timers of %d actor(s)", len(byActor))
+	return nil
+}
+
+// restoreTimers restarts the timers checkpointed for an actor, the timers which were due while the actor was not
+// hosted fire immediately.
+func (a *actorsRuntime) restoreTimers(actorType, actorID string) {
+	if a.store == nil {
+		return
+	}
+
+	key := timerCheckpointKey(actorType, actorID)
+	resp, err := a.store.Get(&state.GetRequest{Key: key})
+	if err != nil {
+		log.Warnf("error getting checkpointed timers of actor %s: %s", constructCompositeKey(actorType, actorID), err)
+		return
+	}
+	if resp == nil || len(resp.Data) == 0 {
+		return
+	}
+
+	var checkpoints []TimerCheckpoint
+	if err := json.Unmarshal(resp.Data, &checkpoints); err != nil {
+		log.Warnf("error decoding checkpointed timers of actor %s: %s", constructCompositeKey(actorType, actorID), err)
+		return
+	}
+	if err := a.store.Delete(&state.DeleteRequest{Key: key}); err != nil {
+		log.Warnf("error deleting checkpointed timers of actor %s: %s", constructCompositeKey(actorType, actorID), err)
+		return
+	}
+
+	a.activeTimersLock.Lock()
+	defer a.activeTimersLock.Unlock()
+
+	now := time.Now()
+	for _, checkpoint := range checkpoints {
+		if err := a.restoreTimer(actorType, actorID, checkpoint, now); err != nil {
+			log.Warnf("error restoring timer %s of actor %s: %s", checkpoint.Name, constructCompositeKey(actorType, actorID), err)
+		}
+	}
+}
+
+// restoreTimer restarts a checkpointed timer, activeTimersLock must be held.
+func (a *actorsRuntime) restoreTimer(actorType, actorID string, checkpoint TimerCheckpoint, now time.Time) error {
+	timerKey := constructCompositeKey(actorType, actorID, checkpoint.Name)
+	if _, exists := a.activeTimers.Load(timerKey); exists {
+		// the timer was created again since the actor was activated
+		return nil
+	}
+
+	dueTime, err := time.Parse(time.RFC3339Nano, checkpoint.NextTime)
+	if err != nil {
+		return errors.Wrap(err, "error parsing checkpointed due time")
+	}
+	if dueTime.Before(now) {
+		dueTime = now
+	}
+
+	var ttl time.Time
+	if checkpoint.TTL != "" {
+		if ttl, err = time.Parse(time.RFC3339Nano, checkpoint.TTL); err != nil {
+			return errors.Wrap(err, "error parsing checkpointed TTL")
+		}
+		if now.After(ttl) {
+			return nil
+		}
+	}
+
+	var (
+		years, months, days int
+		period              time.Duration
+	)
+	if checkpoint.Period != "" {
+		if years, months, days, period, _, err = parseDuration(checkpoint.Period); err != nil {
+			return errors.Wrap(err, "error parsing checkpointed period")
+		}
+	}
+
+	req := &CreateTimerRequest{
+		Name:      checkpoint.Name,
+		ActorType: actorType,
+		ActorID:   actorID,
+		Period:    checkpoint.Period,
+		Callback:  checkpoint.Callback,
+		Data:      checkpoint.Data,
+	}
+	a.startTimer(context.Background(), req, dueTime, ttl, years, months, days, period, checkpoint.RepetitionLeft)
+	return nil
+}
@@ -0,0 +1,265 @@
+package announcements
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.announcements")
+
+// Severities of announcements
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+var (
+	// ErrNotFound is returned when an announcement doesn't exist.
+	ErrNotFound = errors.New("announcement not found")
+	// ErrNotDismissible is returned when dismissing an announcement which can't be dismissed.
+	ErrNotDismissible = errors.New("announcement is not dismissible")
+)
+
+// Announcement is a banner broadcast to the users of the frontends, managed at runtime with NewResource.
+type Announcement struct {
+	ID       uint   `orm:"primary_key" json:"id"`
+	Title    string `orm:"size:256" json:"title"`
+	Message  string `orm:"type:text" json:"message"`
+	Severity string `orm:"size:32" json:"severity"`
+	// Roles are the comma separated roles of the audience, every user if empty.
+	Roles string `orm:"size:512" json:"-"`
+	// StartsAt is when the announcement is published, immediately if nil.
+	StartsAt *time.Time `orm:"index" json:"startsAt,omitempty"`
+	// EndsAt is when the announcement expires, never if nil.
+	EndsAt      *time.Time `orm:"index" json:"endsAt,omitempty"`
+	Dismissible bool       `json:"dismissible"`
+	// Notify sends the announcement through the notifiers too, once it is published.
+	Notify     bool       `json:"-"`
+	NotifiedAt *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"-"`
+}
+
+// TableName table name of announcements
+func (Announcement) TableName() string {
+	return "announcements"
+}
+
+// Active returns true if the announcement is published at now.
+func (announcement *Announcement) Active(now time.Time) bool {
+	if announcement.StartsAt != nil && now.Before(*announcement.StartsAt) {
+		return false
+	}
+	return announcement.EndsAt == nil || now.Before(*announcement.EndsAt)
+}
+
+// AudienceRoles returns the roles of the audience, nil for every user.
+func (announcement *Announcement) AudienceRoles() []string {
+	var roles []string
+	for _, role := range strings.Split(announcement.Roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// VisibleTo returns true if a user with roles is in the audience of the announcement.
+func (announcement *Announcement) VisibleTo(roles []string) bool {
+	audience := announcement.AudienceRoles()
+	if len(audience) == 0 {
+		return true
+	}
+
+	for _, role := range audience {
+		for _, userRole := range roles {
+			if role == userRole {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Dismissal records that a user dismissed an announcement.
+type Dismissal struct {
+	ID             uint   `orm:"primary_key"`
+	AnnouncementID uint   `orm:"unique_index:idx_announcement_dismissal"`
+	UserID         string `orm:"size:128;unique_index:idx_announcement_dismissal"`
+	CreatedAt      time.Time
+}
+
+// TableName table name of dismissals
+func (Dismissal) TableName() string {
+	return "announcement_dismissals"
+}
+
+// Notifier sends published announcements through a notification channel, like emails or chat.
+type Notifier interface {
+	Notify(ctx context.Context, announcement *Announcement) error
+}
+
+// NotifierFunc is a function implementing Notifier.
+type NotifierFunc func(ctx context.Context, announcement *Announcement) error
+
+// Notify implements Notifier.
+func (fc NotifierFunc) Notify(ctx context.Context, announcement *Announcement) error {
+	return fc(ctx, announcement)
+}
+
+// Config configures the announcements.
+type Config struct {
+	// Notifiers are the channels the announcements with Notify are sent through.
+	Notifiers []Notifier
+	// PollInterval is the interval the published announcements are notified at, 1 minute by default.
+	PollInterval time.Duration
+}
+
+// Service serves the announcements to the users, and notifies them once published.
+// The tables of Announcement and Dismissal have to be migrated.
+type Service struct {
+	db     *orm.DB
+	config Config
+}
+
+// New returns a service storing announcements and dismissals in db.
+func New(db *orm.DB, config Config) *Service {
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Minute
+	}
+	return &Service{db: db, config: config}
+}
+
+// Active returns the published announcements visible to the user with roles, which the user didn't dismiss,
+// the most severe first.
+func (s *Service) Active(user string, roles []string) ([]Announcement, error) {
+	now := time.Now()
+
+	var announcements []Announcement
+	err := s.db.Where("(starts_at IS NULL OR starts_at <= ?) AND (ends_at IS NULL OR ends_at > ?)", now, now).
+		Order("created_at DESC").Find(&announcements).Error
+	if err != nil {
+		return nil, err
+	}
+
+	dismissed := map[uint]bool{}
+	if user != "" {
+		var dismissals []Dismissal
+		if err := s.db.Where("user_id = ?", user).Find(&dismissals).Error; err != nil {
+			return nil, err
+		}
+		for _, dismissal := range dismissals {
+			dismissed[dismissal.AnnouncementID] = true
+		}
+	}
+
+	var visible []Announcement
+	for _, announcement := range announcements {
+		if announcement.VisibleTo(roles) && !(announcement.Dismissible && dismissed[announcement.ID]) {
+			visible = append(visible, announcement)
+		}
+	}
+	sort.SliceStable(visible, func(i, j int) bool {
+		return severityRank[visible[i].Severity] > severityRank[visible[j].Severity]
+	})
+	return visible, nil
+}
+
+// Dismiss records that the user dismissed the announcement, dismissing it twice is a no-op.
+func (s *Service) Dismiss(id uint, user string) error {
+	var announcement Announcement
+	if err := s.db.First(&announcement, id).Error; err != nil {
+		if orm.IsRecordNotFoundError(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	if !announcement.Dismissible {
+		return ErrNotDismissible
+	}
+
+	return s.db.Where(Dismissal{AnnouncementID: id, UserID: user}).FirstOrCreate(&Dismissal{}).Error
+}
+
+// Run notifies the published announcements until ctx is done.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.NotifyPublished(ctx); err != nil {
+			log.Errorf("failed to notify announcements: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// NotifyPublished sends the published announcements with Notify which weren't notified yet through the notifiers.
+// An announcement is notified once, even if some of the notifiers fail.
+func (s *Service) NotifyPublished(ctx context.Context) error {
+	if len(s.config.Notifiers) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var announcements []Announcement
+	err := s.db.Where("notify = ? AND notified_at IS NULL AND (starts_at IS NULL OR starts_at <= ?) AND (ends_at IS NULL OR ends_at > ?)", true, now, now).
+		Order("id").Find(&announcements).Error
+	if err != nil {
+		return err
+	}
+
+	for idx := range announcements {
+		announcement := &announcements[idx]
+		for _, notifier := range s.config.Notifiers {
+			if err := notifier.Notify(ctx, announcement); err != nil {
+				log.Warnf("failed to notify announcement %d: %s", announcement.ID, err)
+			}
+		}
+
+		if err := s.db.Model(announcement).UpdateColumn("notified_at", now).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var severityRank = map[string]int{SeverityInfo: 1, SeverityWarning: 2, SeverityCritical: 3}
+
+func knownSeverity(severity string) bool {
+	_, ok := severityRank[severity]
+	return ok
+}
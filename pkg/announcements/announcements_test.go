@@ -0,0 +1,52 @@
+package announcements_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bhojpur/application/pkg/announcements"
+)
+
+func TestAnnouncementActive(t *testing.T) {
+	now := time.Now()
+	yesterday, tomorrow := now.Add(-24*time.Hour), now.Add(24*time.Hour)
+
+	assert.True(t, (&announcements.Announcement{}).Active(now))
+	assert.True(t, (&announcements.Announcement{StartsAt: &yesterday, EndsAt: &tomorrow}).Active(now))
+	assert.False(t, (&announcements.Announcement{StartsAt: &tomorrow}).Active(now))
+	assert.False(t, (&announcements.Announcement{EndsAt: &yesterday}).Active(now))
+}
+
+func TestAnnouncementVisibleTo(t *testing.T) {
+	everyone := &announcements.Announcement{}
+	assert.True(t, everyone.VisibleTo(nil))
+	assert.True(t, everyone.VisibleTo([]string{"admin"}))
+
+	admins := &announcements.Announcement{Roles: "admin, operator"}
+	assert.Equal(t, []string{"admin", "operator"}, admins.AudienceRoles())
+	assert.True(t, admins.VisibleTo([]string{"user", "operator"}))
+	assert.False(t, admins.VisibleTo([]string{"user"}))
+	assert.False(t, admins.VisibleTo(nil))
+}
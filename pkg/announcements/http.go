@@ -0,0 +1,89 @@
+package announcements
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// UserExtractor returns the user of a request and their roles, an empty user for anonymous requests.
+type UserExtractor func(req *http.Request) (user string, roles []string)
+
+// Handler returns the HTTP API consumed by the frontends:
+//
+//	GET  /                 returns the active announcements of the user
+//	POST /{id}/dismiss     dismisses an announcement for the user
+func (s *Service) Handler(extractor UserExtractor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user, roles := extractor(req)
+		path := strings.Trim(req.URL.Path, "/")
+
+		switch {
+		case req.Method == http.MethodGet && !strings.HasSuffix(path, "/dismiss"):
+			announcements, err := s.Active(user, roles)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if announcements == nil {
+				announcements = []Announcement{}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(announcements)
+		case req.Method == http.MethodPost && strings.HasSuffix(path, "/dismiss"):
+			if user == "" {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			segments := strings.Split(path, "/")
+			if len(segments) < 2 {
+				http.Error(w, "invalid announcement id", http.StatusBadRequest)
+				return
+			}
+			id, err := strconv.ParseUint(segments[len(segments)-2], 10, 64)
+			if err != nil {
+				http.Error(w, "invalid announcement id", http.StatusBadRequest)
+				return
+			}
+
+			if err := s.Dismiss(uint(id), user); err != nil {
+				switch {
+				case errors.Is(err, ErrNotFound):
+					http.Error(w, err.Error(), http.StatusNotFound)
+				case errors.Is(err, ErrNotDismissible):
+					http.Error(w, err.Error(), http.StatusConflict)
+				default:
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package announcements
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"strings"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/validations"
+)
+
+// NewResource returns the Announcement resource, to manage announcements at runtime, like from the admin.
+// Its records are validated: the message is required, the severity has to be a known one, info by default,
+// and the announcement has to end after it starts.
+func NewResource() *resource.Resource {
+	res := resource.New(&Announcement{})
+
+	res.AddValidator(&resource.Validator{
+		Name: "announcement",
+		Handler: func(record interface{}, metaValues *resource.MetaValues, context *appsvr.Context) error {
+			var (
+				announcement = record.(*Announcement)
+				errs         validations.Errors
+			)
+
+			if strings.TrimSpace(announcement.Message) == "" {
+				errs.Add(validations.NewFieldError(announcement, "Message", validations.CodeRequired, "Message can't be blank", nil))
+			}
+
+			if announcement.Severity == "" {
+				announcement.Severity = SeverityInfo
+			} else if !knownSeverity(announcement.Severity) {
+				errs.Add(validations.NewFieldError(announcement, "Severity", validations.CodeInclusion, "Unknown severity "+announcement.Severity, map[string]interface{}{"value": announcement.Severity}))
+			}
+
+			if announcement.StartsAt != nil && announcement.EndsAt != nil && !announcement.EndsAt.After(*announcement.StartsAt) {
+				errs.Add(validations.NewFieldError(announcement, "EndsAt", validations.CodeInvalid, "Announcement should end after it starts", nil))
+			}
+
+			return errs.ErrorOrNil()
+		},
+	})
+	return res
+}
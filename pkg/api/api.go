@@ -0,0 +1,270 @@
+package api
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/pkg/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// DefaultPrefix is the path the API is mounted at by default
+const DefaultPrefix = "/api/v1"
+
+// API is a JSON REST API generated from registered resources, see ServeHTTP for its routes
+type API struct {
+	Config *appsvr.Config
+	// Prefix is the path the API is mounted at, DefaultPrefix by default
+	Prefix string
+	// CurrentUser returns the user of a request, roles are matched against the request and user
+	CurrentUser func(req *http.Request) appsvr.CurrentUser
+	// PerPage is the default number of records of a page, 20 by default
+	PerPage int
+	// MaxPerPage caps the number of records of a page, 100 by default
+	MaxPerPage int
+	// CursorKey signs the cursors of lists, a random key by default, so that cursors are only valid for the instance
+	// which issued them, set the same key to all the instances of the API
+	CursorKey []byte
+	// OpenAPIPath is the path the OpenAPI document of the API is served at, DefaultOpenAPIPath by default
+	OpenAPIPath string
+	// Info is the title, description and version of the OpenAPI document
+	Info Info
+	// SecurityScheme authenticates the operations which need roles in the OpenAPI document, a bearer token by default
+	SecurityScheme *SecurityScheme
+	resources      map[string]*Resource
+	document       *Document
+	mutex          sync.RWMutex
+}
+
+// New initialize an API of the resources registered with AddResource
+func New(config *appsvr.Config) *API {
+	cursorKey := make([]byte, 32)
+	if _, err := rand.Read(cursorKey); err != nil {
+		panic(err)
+	}
+
+	return &API{
+		Config:      config,
+		Prefix:      DefaultPrefix,
+		PerPage:     20,
+		MaxPerPage:  100,
+		OpenAPIPath: DefaultOpenAPIPath,
+		Info:        Info{Title: "API", Version: "1.0.0"},
+		CursorKey:   cursorKey,
+		resources:   map[string]*Resource{},
+	}
+}
+
+// Resource is a resource of the API. Its metas are the fields of the records, they are decoded from request bodies
+// by their setters and encoded to responses by their valuers, and their permissions decide which roles can read and
+// write them
+type Resource struct {
+	*resource.Resource
+	// Param is the path segment of the resource
+	Param string
+	metas []*resource.Meta
+	api   *API
+}
+
+// AddResource registers a resource, its path segment is the param string of its name, like `product_variation`.
+// Metas are added for the fields of its model except ignored, `json:"-"` and association fields
+func (api *API) AddResource(res *resource.Resource) *Resource {
+	apiRes := &Resource{Resource: res, Param: utils.ToParamString(res.Name), api: api}
+
+	for _, field := range (&orm.Scope{Value: res.Value}).GetStructFields() {
+		if field.IsIgnored || field.Relationship != nil || field.Struct.Tag.Get("json") == "-" {
+			continue
+		}
+		apiRes.Meta(&resource.Meta{Name: field.Name})
+	}
+
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	api.resources[apiRes.Param] = apiRes
+	api.document = nil
+	return apiRes
+}
+
+// GetResource returns a registered resource by its path segment
+func (api *API) GetResource(param string) *Resource {
+	api.mutex.RLock()
+	defer api.mutex.RUnlock()
+	return api.resources[param]
+}
+
+// Meta adds a meta to the resource, or replaces the one with the same name. Set its Permission to restrict the
+// roles which can read and write the field, or its Computed func to add a read-only field
+//
+//	products.Meta(&resource.Meta{Name: "Cost", Permission: roles.Allow(roles.CRUD, "admin")})
+func (res *Resource) Meta(meta *resource.Meta) *resource.Meta {
+	meta.BaseResource = res
+	if err := meta.PreInitialize(); err != nil {
+		panic(err)
+	}
+	if err := meta.Initialize(); err != nil {
+		panic(err)
+	}
+
+	if res.api != nil {
+		defer res.api.Refresh()
+	}
+
+	for idx, m := range res.metas {
+		if m.Name == meta.Name {
+			res.metas[idx] = meta
+			return meta
+		}
+	}
+	res.metas = append(res.metas, meta)
+	return meta
+}
+
+// GetMeta returns a meta of the resource by name
+func (res *Resource) GetMeta(name string) *resource.Meta {
+	for _, meta := range res.metas {
+		if meta.Name == name {
+			return meta
+		}
+	}
+	return nil
+}
+
+// GetMetas returns the metas of the resource, all of them if names is empty, to match interface `Resourcer`
+func (res *Resource) GetMetas(names []string) []resource.Metaor {
+	var metas []resource.Metaor
+	for _, m := range res.metas {
+		if len(names) == 0 {
+			metas = append(metas, meta{m})
+			continue
+		}
+		for _, name := range names {
+			if m.Name == name {
+				metas = append(metas, meta{m})
+			}
+		}
+	}
+	return metas
+}
+
+// meta is a meta of the API to match interface `Metaor`, fields of records are not nested
+type meta struct {
+	*resource.Meta
+}
+
+func (meta) GetMetas() []resource.Metaor {
+	return nil
+}
+
+func (meta) GetResource() resource.Resourcer {
+	return nil
+}
+
+// readableMetas returns the metas the roles of the context can read, limited to fields if not empty, the sparse
+// fieldset of a request. Unknown or unreadable fields of the fieldset are an error, so that they are not mistaken
+// for empty values
+func (res *Resource) readableMetas(fields []string, context *appsvr.Context) ([]*resource.Meta, error) {
+	var metas []*resource.Meta
+	if len(fields) == 0 {
+		for _, meta := range res.metas {
+			if meta.HasPermission(roles.Read, context) {
+				metas = append(metas, meta)
+			}
+		}
+		return metas, nil
+	}
+
+	var unknown []string
+	for _, name := range fields {
+		if meta := res.GetMeta(name); meta != nil && meta.HasPermission(roles.Read, context) {
+			metas = append(metas, meta)
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown fields %s of resource %s", strings.Join(unknown, ", "), res.Param)
+	}
+	return metas, nil
+}
+
+// sortFields returns the fields of the readable metas of the order, like "-Price,Name", for resource.CallFindManyAfter
+func (res *Resource) sortFields(order string, context *appsvr.Context) ([]string, error) {
+	if order == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, name := range strings.Split(order, ",") {
+		field := strings.TrimPrefix(name, "-")
+		metas, err := res.readableMetas([]string{field}, context)
+		if err != nil {
+			return nil, err
+		}
+		meta := metas[0]
+		if meta.FieldStruct == nil || !meta.FieldStruct.IsNormal {
+			return nil, fmt.Errorf("field %s of resource %s can't be sorted", meta.Name, res.Param)
+		}
+		if field != name {
+			fields = append(fields, "-"+meta.FieldStruct.Name)
+		} else {
+			fields = append(fields, meta.FieldStruct.Name)
+		}
+	}
+	return fields, nil
+}
+
+// project sets the field projection of the context to the fields of the metas of a sparse fieldset, so that only their
+// columns are selected. Nothing is projected if a meta is computed or not a column, whose valuer may read other fields
+func project(metas []*resource.Meta, fields []string, context *appsvr.Context) {
+	if len(fields) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(metas))
+	for _, meta := range metas {
+		if meta.Computed != nil || meta.FieldStruct == nil || !meta.FieldStruct.IsNormal {
+			return
+		}
+		names = append(names, meta.FieldStruct.Name)
+	}
+	context.SetFields(names...)
+}
+
+// encode returns the values of the metas of the record, except the ones hidden for it, see resource.Meta.ShowIf
+func encode(record interface{}, metas []*resource.Meta, context *appsvr.Context) map[string]interface{} {
+	values := make(map[string]interface{}, len(metas))
+	for _, meta := range metas {
+		if meta.IsShown(record, context) {
+			values[meta.Name] = meta.GetValuer()(record, context)
+		}
+	}
+	return values
+}
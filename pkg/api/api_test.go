@@ -0,0 +1,198 @@
+package api_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bhojpur/application/pkg/api"
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ProductVariation struct {
+	ID     uint
+	Name   string
+	Cost   float64
+	Secret string `json:"-"`
+	Tags   []Tag
+}
+
+type Tag struct {
+	ID                 uint
+	ProductVariationID uint
+	Name               string
+}
+
+type admin struct{}
+
+func (*admin) DisplayName() string { return "admin" }
+
+func newAPI(t *testing.T) (*api.API, *api.Resource) {
+	roles.Reset()
+	roles.Register("admin", func(req *http.Request, user interface{}) bool {
+		_, ok := user.(*admin)
+		return ok
+	})
+	t.Cleanup(roles.Reset)
+
+	a := api.New(&appsvr.Config{})
+	a.CurrentUser = func(req *http.Request) appsvr.CurrentUser {
+		if req.Header.Get("X-Admin") != "" {
+			return &admin{}
+		}
+		return nil
+	}
+
+	res := a.AddResource(resource.New(&ProductVariation{}))
+	res.Meta(&resource.Meta{Name: "Cost", Permission: roles.Allow(roles.CRUD, "admin")})
+	return a, res
+}
+
+func TestAddResource(t *testing.T) {
+	a, res := newAPI(t)
+
+	assert.Equal(t, "product_variation", res.Param)
+	assert.Equal(t, res, a.GetResource("product_variation"))
+	assert.Nil(t, a.GetResource("product"))
+
+	var names []string
+	for _, meta := range res.GetMetas(nil) {
+		names = append(names, meta.GetName())
+	}
+	assert.Equal(t, []string{"ID", "Name", "Cost"}, names)
+	assert.NotNil(t, res.GetMeta("Cost").Permission, "Meta should replace the meta with the same name")
+}
+
+func TestReadableMetas(t *testing.T) {
+	_, res := newAPI(t)
+
+	names := func(metas []*resource.Meta) (names []string) {
+		for _, meta := range metas {
+			names = append(names, meta.Name)
+		}
+		return names
+	}
+
+	metas, err := api.ReadableMetas(res, nil, &appsvr.Context{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ID", "Name"}, names(metas))
+
+	metas, err = api.ReadableMetas(res, nil, &appsvr.Context{Roles: []string{"admin"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ID", "Name", "Cost"}, names(metas))
+
+	metas, err = api.ReadableMetas(res, []string{"Name"}, &appsvr.Context{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Name"}, names(metas))
+
+	_, err = api.ReadableMetas(res, []string{"Name", "Cost", "Secret"}, &appsvr.Context{})
+	assert.EqualError(t, err, "unknown fields Cost, Secret of resource product_variation")
+}
+
+func TestServeHTTPErrors(t *testing.T) {
+	a, _ := newAPI(t)
+
+	tests := []struct {
+		method, target string
+		status         int
+		allow          string
+	}{
+		{http.MethodGet, "/api/v1/product", http.StatusNotFound, ""},
+		{http.MethodGet, "/api/v1", http.StatusNotFound, ""},
+		{http.MethodGet, "/api/v1/product_variation/1/tags", http.StatusNotFound, ""},
+		{http.MethodGet, "/admin/product_variation", http.StatusNotFound, ""},
+		{http.MethodDelete, "/api/v1/product_variation", http.StatusMethodNotAllowed, "GET, POST"},
+		{http.MethodPost, "/api/v1/product_variation/1", http.StatusMethodNotAllowed, "GET, PUT, PATCH, DELETE"},
+		{http.MethodGet, "/api/v1/product_variation?page=0", http.StatusBadRequest, ""},
+		{http.MethodGet, "/api/v1/product_variation?per_page=many", http.StatusBadRequest, ""},
+		{http.MethodGet, "/api/v1/product_variation?fields=Name,Cost", http.StatusBadRequest, ""},
+		{http.MethodGet, "/api/v1/product_variation/1?fields=Unknown", http.StatusBadRequest, ""},
+		{http.MethodGet, "/api/v1/product_variation?cursor=&sort=-Cost", http.StatusBadRequest, ""},
+		{http.MethodGet, "/api/v1/product_variation?cursor=&sort=Name,Unknown", http.StatusBadRequest, ""},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, httptest.NewRequest(test.method, test.target, nil))
+
+		assert.Equal(t, test.status, w.Code, "%s %s", test.method, test.target)
+		assert.Equal(t, test.allow, w.Header().Get("Allow"), "%s %s", test.method, test.target)
+
+		var response api.ErrorResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Len(t, response.Errors, 1)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	a, res := newAPI(t)
+
+	req := httptest.NewRequest(http.MethodGet, api.DefaultOpenAPIPath, nil)
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var document api.Document
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &document))
+	assert.Equal(t, "3.1.0", document.OpenAPI)
+
+	collection, record := document.Paths["/api/v1/product_variation"], document.Paths["/api/v1/product_variation/{id}"]
+	require.NotNil(t, collection)
+	require.NotNil(t, record)
+	assert.Equal(t, "listProductVariation", collection.Get.OperationID)
+	assert.Equal(t, "#/components/schemas/ProductVariation", collection.Post.RequestBody.Content["application/json"].Schema.Ref)
+	assert.NotNil(t, record.Patch)
+	assert.Equal(t, "validateUpdateProductVariation", document.Paths["/api/v1/product_variation/{id}/validate"].Post.OperationID)
+	assert.Empty(t, record.Delete.Security)
+	assert.Empty(t, document.Components.SecuritySchemes)
+
+	schema := document.Components.Schemas["ProductVariation"]
+	require.NotNil(t, schema)
+	assert.Len(t, schema.Properties, 3)
+	assert.True(t, schema.Properties["ID"].ReadOnly)
+	assert.Equal(t, "number", schema.Properties["Cost"].Type)
+	assert.Equal(t, []string{"admin"}, schema.Properties["Cost"].Roles[roles.Read])
+
+	res.Permission = roles.Allow(roles.Delete, "admin")
+	assert.Empty(t, a.OpenAPI().Paths["/api/v1/product_variation/{id}"].Delete.Security)
+	a.Refresh()
+	document = *a.OpenAPI()
+	assert.Equal(t, []map[string][]string{{"roles": {"admin"}}}, document.Paths["/api/v1/product_variation/{id}"].Delete.Security)
+	assert.Contains(t, document.Paths["/api/v1/product_variation/{id}"].Delete.Responses, "403")
+	// no role is allowed to read once the allowed roles of a mode are defined
+	assert.Equal(t, []map[string][]string{{"roles": {}}}, document.Paths["/api/v1/product_variation/{id}"].Get.Security)
+	assert.Equal(t, "bearer", document.Components.SecuritySchemes["roles"].Scheme)
+
+	res.Meta(&resource.Meta{Name: "Label", Computed: func(record interface{}, context *appsvr.Context) interface{} { return "" }})
+	assert.True(t, a.OpenAPI().Components.Schemas["ProductVariation"].Properties["Label"].ReadOnly)
+
+	req = httptest.NewRequest(http.MethodPost, api.DefaultOpenAPIPath, nil)
+	w = httptest.NewRecorder()
+	a.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,497 @@
+package api
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bhojpur/application/pkg/csrf"
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/pkg/validations"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// ListResponse is the body of a list response
+type ListResponse struct {
+	Data []map[string]interface{} `json:"data"`
+	Meta Pagination               `json:"meta"`
+}
+
+// Pagination is the page of a list response
+type Pagination struct {
+	Page    int `json:"page"`
+	PerPage int `json:"perPage"`
+	Total   int `json:"total"`
+}
+
+// CursorListResponse is the body of a list response paginated by cursor
+type CursorListResponse struct {
+	Data []map[string]interface{} `json:"data"`
+	Meta CursorPagination         `json:"meta"`
+}
+
+// CursorPagination is the page of a list response paginated by cursor, NextCursor is empty for the last page
+type CursorPagination struct {
+	PerPage    int    `json:"perPage"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// RecordResponse is the body of a record response
+type RecordResponse struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// ErrorResponse is the body of an error response
+type ErrorResponse struct {
+	Errors []Error `json:"errors"`
+}
+
+// ValidationResponse is the body of a validate response, Errors are the validation errors of the record
+type ValidationResponse struct {
+	Valid  bool    `json:"valid"`
+	Errors []Error `json:"errors"`
+}
+
+// Error is an error of an error response, Field is the path of the field of validation errors, Code and Params the
+// code of their failure and the params of their message, see validations.Error
+type Error struct {
+	Field   string                 `json:"field,omitempty"`
+	Code    string                 `json:"code,omitempty"`
+	Message string                 `json:"message"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// ServeHTTP serves the API, `fields=Name,Price` selects the fields of the records of the responses, and only their
+// columns are read by lists and returned records, see project:
+//
+//	GET    {prefix}/{resource}?page=1&per_page=20   lists the records, the keyword, scopes and filters of the query apply
+//	GET    {prefix}/{resource}?cursor=&per_page=20  lists the records by cursor, from the first page, then the nextCursor
+//	                                                of the previous page, see resource.CallFindManyAfter. They are
+//	                                                ordered by the fields of sort=-Price,Name, "-" for descending
+//	POST   {prefix}/{resource}                      creates a record from the fields of the JSON body
+//	GET    {prefix}/{resource}/{id}                 returns a record, see resource.JoinPrimaryKey for composite ids
+//	PUT    {prefix}/{resource}/{id}                 updates the fields of the JSON body of a record, PATCH too
+//	DELETE {prefix}/{resource}/{id}                 deletes a record
+//	POST   {prefix}/{resource}/validate             validates a record created from the fields of the JSON body
+//	POST   {prefix}/{resource}/{id}/validate        validates a record updated with the fields of the JSON body
+//
+// Bodies are decoded with the validators and processors of the resource, in a transaction. Validations save nothing,
+// see resource.CallValidate. Unsafe requests with
+// cookies have to send the CSRF token in the X-CSRF-Token header, see csrf.Verify. The OpenAPI document of these
+// routes is served at OpenAPIPath
+func (api *API) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if api.OpenAPIPath != "" && req.URL.Path == api.OpenAPIPath {
+		api.serveOpenAPI(w, req)
+		return
+	}
+
+	if !strings.HasPrefix(req.URL.EscapedPath(), api.Prefix) {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	// segments are split before they are unescaped, so that primary keys may contain "/" escaped as "%2F"
+	path := strings.Trim(strings.TrimPrefix(req.URL.EscapedPath(), api.Prefix), "/")
+	segments := strings.Split(path, "/")
+	// POST {prefix}/{resource}/validate isn't ambiguous, records can't be posted
+	var validate bool
+	if len(segments) > 1 && segments[len(segments)-1] == "validate" && (len(segments) == 3 || req.Method == http.MethodPost) {
+		segments, validate = segments[:len(segments)-1], true
+	}
+	if path == "" || len(segments) > 2 {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	for idx, segment := range segments {
+		var err error
+		if segments[idx], err = url.PathUnescape(segment); err != nil {
+			writeError(w, http.StatusNotFound, errors.New("not found"))
+			return
+		}
+	}
+
+	res := api.GetResource(segments[0])
+	if res == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown resource %s", segments[0]))
+		return
+	}
+
+	var fields []string
+	for _, value := range req.URL.Query()["fields"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				fields = append(fields, name)
+			}
+		}
+	}
+
+	// requests with the cookies of browsers are verified, like the ones of the session of auth
+	if err := csrf.Verify(req); err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+
+	context := api.newContext(w, req)
+	if validate {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			writeError(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+			return
+		}
+		if len(segments) == 2 {
+			context.ResourceID = segments[1]
+		}
+		api.validate(w, res, context)
+		return
+	}
+
+	if len(segments) == 1 {
+		switch req.Method {
+		case http.MethodGet:
+			api.list(w, res, fields, context)
+		case http.MethodPost:
+			api.create(w, res, fields, context)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeError(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+		}
+		return
+	}
+
+	context.ResourceID = segments[1]
+	switch req.Method {
+	case http.MethodGet:
+		api.show(w, res, fields, context)
+	case http.MethodPut, http.MethodPatch:
+		api.update(w, res, fields, context)
+	case http.MethodDelete:
+		if err := res.CallDelete(res.NewStruct(), context); err != nil {
+			writeHandlerError(w, err, context)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, PATCH, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+	}
+}
+
+// newContext returns the context of a request, with its user and the roles matching them
+func (api *API) newContext(w http.ResponseWriter, req *http.Request) *appsvr.Context {
+	context := &appsvr.Context{Request: req, Writer: w, Config: api.Config}
+
+	var user interface{}
+	if api.CurrentUser != nil {
+		if currentUser := api.CurrentUser(req); currentUser != nil {
+			context.CurrentUser, user = currentUser, currentUser
+		}
+	}
+	context.Roles = roles.MatchedRoles(req, user)
+	return context
+}
+
+func (api *API) list(w http.ResponseWriter, res *Resource, fields []string, context *appsvr.Context) {
+	page, perPage, err := api.pagination(context.Request.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	metas, err := res.readableMetas(fields, context)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	project(metas, fields, context)
+
+	if cursor, ok := context.Request.URL.Query()["cursor"]; ok {
+		if context.Request.URL.Query().Get("page") != "" {
+			writeError(w, http.StatusBadRequest, errors.New("page and cursor are exclusive"))
+			return
+		}
+		api.listAfter(w, res, metas, cursor[0], perPage, context)
+		return
+	}
+
+	var total int
+	countContext := context.Clone()
+	countContext.SetDB(context.GetReadDB().Model(res.Value).Set("bhojpur:getting_total_count", true))
+	if err := res.CallFindMany(&total, countContext); err != nil {
+		writeHandlerError(w, err, context)
+		return
+	}
+
+	records := res.NewSlice()
+	pageContext := context.Clone()
+	pageContext.SetDB(context.GetReadDB().Offset((page - 1) * perPage).Limit(perPage))
+	if err := res.CallFindMany(records, pageContext); err != nil {
+		writeHandlerError(w, err, context)
+		return
+	}
+
+	values := reflect.Indirect(reflect.ValueOf(records))
+	response := ListResponse{
+		Data: make([]map[string]interface{}, 0, values.Len()),
+		Meta: Pagination{Page: page, PerPage: perPage, Total: total},
+	}
+	for idx := 0; idx < values.Len(); idx++ {
+		response.Data = append(response.Data, encode(values.Index(idx).Interface(), metas, context))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (api *API) listAfter(w http.ResponseWriter, res *Resource, metas []*resource.Meta, cursor string, perPage int, context *appsvr.Context) {
+	records := res.NewSlice()
+	orderBy, err := res.sortFields(context.Request.URL.Query().Get("sort"), context)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	next, err := res.CallFindManyAfter(records, cursor, perPage, api.CursorKey, context, orderBy...)
+	if errors.Is(err, resource.ErrInvalidCursor) || errors.Is(err, resource.ErrInvalidOrder) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		writeHandlerError(w, err, context)
+		return
+	}
+
+	values := reflect.Indirect(reflect.ValueOf(records))
+	response := CursorListResponse{
+		Data: make([]map[string]interface{}, 0, values.Len()),
+		Meta: CursorPagination{PerPage: perPage, NextCursor: next},
+	}
+	for idx := 0; idx < values.Len(); idx++ {
+		response.Data = append(response.Data, encode(values.Index(idx).Interface(), metas, context))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (api *API) show(w http.ResponseWriter, res *Resource, fields []string, context *appsvr.Context) {
+	metas, err := res.readableMetas(fields, context)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result := res.NewStruct()
+	project(metas, fields, context)
+	if err := res.CallFindOne(result, nil, context); err != nil {
+		writeHandlerError(w, err, context)
+		return
+	}
+	writeJSON(w, http.StatusOK, RecordResponse{Data: encode(result, metas, context)})
+}
+
+func (api *API) create(w http.ResponseWriter, res *Resource, fields []string, context *appsvr.Context) {
+	metas, err := res.readableMetas(fields, context)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	metaValues, err := resource.ConvertJSONToMetaValues(context.Request.Body, res.GetMetas(nil))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+
+	result := res.NewStruct()
+	if !api.save(w, res, result, metaValues, context) {
+		return
+	}
+	writeJSON(w, http.StatusCreated, RecordResponse{Data: encode(result, metas, context)})
+}
+
+func (api *API) update(w http.ResponseWriter, res *Resource, fields []string, context *appsvr.Context) {
+	metas, err := res.readableMetas(fields, context)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result := res.NewStruct()
+	if err := res.CallFindOne(result, nil, context); err != nil {
+		writeHandlerError(w, err, context)
+		return
+	}
+
+	metaValues, err := resource.ConvertJSONToMetaValues(context.Request.Body, res.GetMetas(nil))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+
+	withoutPrimaryFields(res, metaValues)
+	if !api.save(w, res, result, metaValues, context) {
+		return
+	}
+	writeJSON(w, http.StatusOK, RecordResponse{Data: encode(result, metas, context)})
+}
+
+// validate decodes the body to a new record, or to the record of the context if any, like create and update, but
+// saves nothing and responds the validation errors of the record
+func (api *API) validate(w http.ResponseWriter, res *Resource, context *appsvr.Context) {
+	result, mode := res.NewStruct(), roles.Create
+	if context.ResourceID != "" {
+		mode = roles.Update
+		if err := res.CallFindOne(result, nil, context); err != nil {
+			writeHandlerError(w, err, context)
+			return
+		}
+	}
+	if !res.HasRecordPermission(mode, result, context) {
+		writeHandlerError(w, roles.ErrPermissionDenied, context)
+		return
+	}
+
+	metaValues, err := resource.ConvertJSONToMetaValues(context.Request.Body, res.GetMetas(nil))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	if context.ResourceID != "" {
+		withoutPrimaryFields(res, metaValues)
+	}
+
+	validationErrs, err := res.CallValidate(result, metaValues, context)
+	if err != nil {
+		writeHandlerError(w, err, context)
+		return
+	}
+
+	response := ValidationResponse{Valid: len(validationErrs) == 0, Errors: []Error{}}
+	for _, validationErr := range validationErrs {
+		response.Errors = append(response.Errors, validationError(validationErr, context))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// withoutPrimaryFields removes the values of the primary fields, the record is the one of the path, primary keys of
+// the body would find another one
+func withoutPrimaryFields(res *Resource, metaValues *resource.MetaValues) {
+	values := metaValues.Values[:0]
+	for _, metaValue := range metaValues.Values {
+		if !isPrimaryField(res, metaValue.Name) {
+			values = append(values, metaValue)
+		}
+	}
+	metaValues.Values = values
+}
+
+// save decodes the meta values to result and saves it in a transaction, it writes the error response and returns
+// false if it fails
+func (api *API) save(w http.ResponseWriter, res *Resource, result interface{}, metaValues *resource.MetaValues, context *appsvr.Context) bool {
+	err := resource.RunInTransaction(context, func(tx *resource.Txn) error {
+		if err := tx.Decode(res, result, metaValues); err != nil {
+			return err
+		}
+		return tx.Save(res, result)
+	})
+	if err != nil {
+		writeHandlerError(w, err, context)
+		return false
+	}
+	return true
+}
+
+func (api *API) pagination(query url.Values) (int, int, error) {
+	page, perPage := 1, api.PerPage
+	for name, value := range map[string]*int{"page": &page, "per_page": &perPage} {
+		if param := query.Get(name); param != "" {
+			parsed, err := strconv.Atoi(param)
+			if err != nil || parsed < 1 {
+				return 0, 0, fmt.Errorf("invalid %s %q", name, param)
+			}
+			*value = parsed
+		}
+	}
+
+	if perPage > api.MaxPerPage {
+		perPage = api.MaxPerPage
+	}
+	return page, perPage, nil
+}
+
+func isPrimaryField(res *Resource, name string) bool {
+	for _, field := range res.PrimaryFields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// writeHandlerError writes the response of an error of the handlers, validators or processors of a resource, its
+// messages translated to the locale of the context
+func writeHandlerError(w http.ResponseWriter, err error, context *appsvr.Context) {
+	errs := []error{err}
+	if multiple, ok := err.(interface{ GetErrors() []error }); ok {
+		errs = multiple.GetErrors()
+	}
+
+	var (
+		status   = http.StatusUnprocessableEntity
+		response = ErrorResponse{Errors: []Error{}}
+	)
+	for _, e := range errs {
+		var validationErr *validations.Error
+		switch {
+		case errors.Is(e, roles.ErrPermissionDenied):
+			status = http.StatusForbidden
+		case orm.IsRecordNotFoundError(e):
+			status = http.StatusNotFound
+		case errors.As(e, &validationErr):
+			response.Errors = append(response.Errors, validationError(validationErr, context))
+			continue
+		case status == http.StatusUnprocessableEntity && len(errs) == 1:
+			status = http.StatusInternalServerError
+		}
+		response.Errors = append(response.Errors, Error{Message: context.T(e.Error())})
+	}
+	writeJSON(w, status, response)
+}
+
+// validationError returns the error of a response of a validation error, its message translated to the locale of the
+// context
+func validationError(err *validations.Error, context *appsvr.Context) Error {
+	return Error{Field: err.Column, Code: err.Code, Message: err.Translate(context), Params: err.Params}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Errors: []Error{{Message: err.Error()}}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
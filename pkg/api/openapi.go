@@ -0,0 +1,412 @@
+package api
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/application/pkg/roles"
+)
+
+// DefaultOpenAPIPath is the path the OpenAPI document of the API is served at by default
+const DefaultOpenAPIPath = "/openapi.json"
+
+// securitySchemeName is the name of the security scheme of the document, operations which need roles require it
+const securitySchemeName = "roles"
+
+// Document is an OpenAPI 3.1 document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info is the metadata of an OpenAPI document
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem is the operations of a path
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+}
+
+// Operation is an operation of a path
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// Security lists the roles allowed to run the operation, it is empty if anyone can
+	Security []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter of an operation
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body of an operation
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is a response of an operation
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components is the reusable schemas and security schemes of a document
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// Schema is a JSON schema
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	// Roles are the roles allowed to read and write a field by permission mode, for fields with a permission
+	Roles map[roles.PermissionMode][]string `json:"x-roles,omitempty"`
+}
+
+// SecurityScheme is how requests are authenticated, the roles of operations are matched against the user
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// OpenAPI returns the OpenAPI document of the API. It is generated when resources or metas change, call Refresh
+// after changing the permissions of registered resources or metas
+func (api *API) OpenAPI() *Document {
+	api.mutex.RLock()
+	document := api.document
+	api.mutex.RUnlock()
+	if document != nil {
+		return document
+	}
+
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	if api.document == nil {
+		api.document = api.newDocument()
+	}
+	return api.document
+}
+
+// Refresh discards the generated OpenAPI document, the next one reflects the current resources and permissions
+func (api *API) Refresh() {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	api.document = nil
+}
+
+func (api *API) serveOpenAPI(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+		return
+	}
+	writeJSON(w, http.StatusOK, api.OpenAPI())
+}
+
+// newDocument generates the document of the routes of ServeHTTP, it is called with the mutex locked
+func (api *API) newDocument() *Document {
+	document := &Document{
+		OpenAPI: "3.1.0",
+		Info:    api.Info,
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{
+				"Pagination": {Type: "object", Properties: map[string]*Schema{
+					"page": {Type: "integer"}, "perPage": {Type: "integer"}, "total": {Type: "integer"},
+				}},
+				"CursorPagination": {Type: "object", Properties: map[string]*Schema{
+					"perPage": {Type: "integer"}, "nextCursor": {Type: "string", Description: "The cursor of the next page, none for the last page"},
+				}},
+				"Error": {Type: "object", Properties: map[string]*Schema{
+					"field":   {Type: "string", Description: "The path of the field of validation errors, nested fields are joined by ."},
+					"code":    {Type: "string", Description: "The code of the failure of validation errors, like required"},
+					"message": {Type: "string"},
+					"params":  {Type: "object", Description: "The params of the message of validation errors"},
+				}},
+				"ErrorResponse": {Type: "object", Properties: map[string]*Schema{
+					"errors": {Type: "array", Items: ref("Error")},
+				}},
+				"ValidationResponse": {Type: "object", Properties: map[string]*Schema{
+					"valid": {Type: "boolean"}, "errors": {Type: "array", Items: ref("Error")},
+				}},
+			},
+		},
+	}
+
+	var secured bool
+	for _, res := range api.resources {
+		name := schemaName(res.Name)
+		document.Components.Schemas[name] = res.schema()
+
+		var (
+			collection = api.Prefix + "/" + res.Param
+			fields     = &Parameter{Name: "fields", In: "query", Description: "Comma separated names of the fields of the records of the response", Schema: &Schema{Type: "string"}}
+			record     = jsonContent(&Schema{Type: "object", Properties: map[string]*Schema{"data": ref(name)}})
+			validation = jsonContent(ref("ValidationResponse"))
+			body       = &RequestBody{Required: true, Content: jsonContent(ref(name))}
+			operation  = func(id string, summary string, mode roles.PermissionMode, responses map[string]*Response) *Operation {
+				op := &Operation{OperationID: id + name, Summary: summary, Tags: []string{name}, Responses: responses}
+				if roleNames, ok := allowedRoles(res.Permission, mode); ok {
+					op.Security, secured = []map[string][]string{{securitySchemeName: roleNames}}, true
+					op.Responses["403"] = errorResponse("Permission denied")
+				}
+				return op
+			}
+		)
+
+		document.Paths[collection] = &PathItem{
+			Get: operation("list", "Lists the records, the keyword, scopes and filters of the query apply", roles.Read, map[string]*Response{
+				"200": {Description: "A page of records", Content: jsonContent(&Schema{Type: "object", Properties: map[string]*Schema{
+					"data": {Type: "array", Items: ref(name)},
+					"meta": {OneOf: []*Schema{ref("Pagination"), ref("CursorPagination")}},
+				}})},
+				"400": errorResponse("Invalid pagination, cursor, sort or fields"),
+			}),
+			Post: operation("create", "Creates a record", roles.Create, map[string]*Response{
+				"201": {Description: "The created record", Content: record},
+				"400": errorResponse("Invalid body or fields"),
+				"422": errorResponse("Invalid record"),
+			}),
+		}
+		document.Paths[collection].Get.Parameters = []*Parameter{
+			{Name: "page", In: "query", Schema: &Schema{Type: "integer", Minimum: float(1)}},
+			{Name: "per_page", In: "query", Schema: &Schema{Type: "integer", Minimum: float(1)}},
+			{Name: "cursor", In: "query", Description: "Paginates by cursor, empty for the first page, then the nextCursor of the previous page", Schema: &Schema{Type: "string"}},
+			{Name: "sort", In: "query", Description: "Orders the records paginated by cursor by fields, like -Price,Name, \"-\" for descending", Schema: &Schema{Type: "string"}},
+			{Name: "keyword", In: "query", Schema: &Schema{Type: "string"}},
+			fields,
+		}
+		document.Paths[collection].Post.Parameters = []*Parameter{fields}
+		document.Paths[collection].Post.RequestBody = body
+
+		item := &PathItem{
+			Parameters: []*Parameter{{Name: "id", In: "path", Required: true, Description: "Primary key of the record, the values of composite primary keys are joined by ++", Schema: &Schema{Type: "string"}}},
+			Get: operation("get", "Returns a record", roles.Read, map[string]*Response{
+				"200": {Description: "The record", Content: record},
+				"400": errorResponse("Invalid fields"),
+				"404": errorResponse("Record not found"),
+			}),
+			Put: operation("update", "Updates the fields of the body of a record", roles.Update, map[string]*Response{
+				"200": {Description: "The updated record", Content: record},
+				"400": errorResponse("Invalid body or fields"),
+				"404": errorResponse("Record not found"),
+				"422": errorResponse("Invalid record"),
+			}),
+			Delete: operation("delete", "Deletes a record", roles.Delete, map[string]*Response{
+				"204": {Description: "The record is deleted"},
+				"404": errorResponse("Record not found"),
+			}),
+		}
+		item.Get.Parameters, item.Put.Parameters, item.Put.RequestBody = []*Parameter{fields}, []*Parameter{fields}, body
+		patch := *item.Put
+		patch.OperationID = "patch" + name
+		item.Patch = &patch
+		document.Paths[collection+"/{id}"] = item
+
+		document.Paths[collection+"/validate"] = &PathItem{
+			Post: operation("validateCreate", "Validates a record created from the body, without saving it", roles.Create, map[string]*Response{
+				"200": {Description: "The validation errors of the record", Content: validation},
+				"400": errorResponse("Invalid body"),
+			}),
+		}
+		document.Paths[collection+"/validate"].Post.RequestBody = body
+		document.Paths[collection+"/{id}/validate"] = &PathItem{
+			Parameters: item.Parameters,
+			Post: operation("validateUpdate", "Validates a record updated with the fields of the body, without saving it", roles.Update, map[string]*Response{
+				"200": {Description: "The validation errors of the record", Content: validation},
+				"400": errorResponse("Invalid body"),
+				"404": errorResponse("Record not found"),
+			}),
+		}
+		document.Paths[collection+"/{id}/validate"].Post.RequestBody = body
+	}
+
+	if secured {
+		scheme := api.SecurityScheme
+		if scheme == nil {
+			scheme = &SecurityScheme{Type: "http", Scheme: "bearer"}
+		}
+		document.Components.SecuritySchemes = map[string]*SecurityScheme{securitySchemeName: scheme}
+	}
+	return document
+}
+
+// schema returns the schema of the records of the resource, primary keys and computed fields are read only
+func (res *Resource) schema() *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, meta := range res.metas {
+		var property *Schema
+		if meta.FieldStruct != nil {
+			property = typeSchema(meta.FieldStruct.Struct.Type)
+			property.ReadOnly = meta.FieldStruct.IsPrimaryKey
+		} else {
+			property = &Schema{}
+		}
+		if meta.IsComputed() || meta.GetSetter() == nil {
+			property.ReadOnly = true
+		}
+
+		for _, mode := range []roles.PermissionMode{roles.Read, roles.Create, roles.Update} {
+			if roleNames, ok := allowedRoles(meta.Permission, mode); ok {
+				if property.Roles == nil {
+					property.Roles = map[roles.PermissionMode][]string{}
+				}
+				property.Roles[mode] = roleNames
+			}
+		}
+		schema.Properties[meta.Name] = property
+	}
+	return schema
+}
+
+// allowedRoles returns the roles allowed for mode by the permission, false if anyone is allowed. Denied roles
+// and conditions of `AllowIf` are not described
+func allowedRoles(permission *roles.Permission, mode roles.PermissionMode) ([]string, bool) {
+	if permission == nil || len(permission.AllowedRoles) == 0 {
+		return nil, false
+	}
+
+	roleNames := []string{}
+	for _, m := range []roles.PermissionMode{mode, roles.AnyMode} {
+		for _, role := range permission.AllowedRoles[m] {
+			if role == roles.Anyone {
+				return nil, false
+			}
+			roleNames = append(roleNames, role)
+		}
+	}
+	sort.Strings(roleNames)
+	return roleNames, true
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// typeSchema returns the schema of the JSON values of a field type
+func typeSchema(typ reflect.Type) *Schema {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case typ.Implements(valuerType) || reflect.PtrTo(typ).Implements(valuerType):
+		// like sql.NullString, its JSON value depends on its own encoding
+		return &Schema{}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: float(0)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: typeSchema(typ.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: typeSchema(typ.Elem())}
+	case reflect.Struct:
+		return &Schema{Type: "object"}
+	}
+	return &Schema{}
+}
+
+// schemaName returns the name of the schema of a resource, like `ProductVariation` for `Product Variation`
+func schemaName(name string) string {
+	return strings.Replace(strings.Title(name), " ", "", -1)
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+func errorResponse(description string) *Response {
+	return &Response{Description: description, Content: jsonContent(ref("ErrorResponse"))}
+}
+
+func float(value float64) *float64 {
+	return &value
+}
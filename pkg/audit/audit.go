@@ -0,0 +1,160 @@
+package audit
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bhojpur/application/pkg/events"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.audit")
+
+// Entry is a record of the audit log. Every entry is chained to the previous one by PrevHash, so an
+// altered, inserted or removed entry breaks the chain of all the following entries.
+type Entry struct {
+	ID         uint   `orm:"primary_key" json:"-"`
+	Sequence   uint64 `orm:"unique_index" json:"sequence"`
+	Resource   string `orm:"size:128;index" json:"resource"`
+	Action     string `orm:"size:64" json:"action"`
+	PrimaryKey string `orm:"size:128" json:"primaryKey"`
+	Actor      string `orm:"size:256" json:"actor,omitempty"`
+	Payload    string `orm:"type:text" json:"payload,omitempty"`
+	// Timestamp is the time of the entry in unix nanoseconds, kept as an integer as databases truncate times.
+	Timestamp int64  `json:"timestamp"`
+	PrevHash  string `orm:"size:64" json:"prevHash"`
+	Hash      string `orm:"size:64" json:"hash"`
+}
+
+// TableName table name of audit entries
+func (Entry) TableName() string {
+	return "audit_entries"
+}
+
+// Digest returns the hex encoded SHA-256 of the entry content chained to PrevHash, which is the expected Hash.
+func (entry *Entry) Digest() string {
+	hash := sha256.New()
+	for _, field := range []string{
+		entry.PrevHash,
+		strconv.FormatUint(entry.Sequence, 10),
+		entry.Resource,
+		entry.Action,
+		entry.PrimaryKey,
+		entry.Actor,
+		entry.Payload,
+		strconv.FormatInt(entry.Timestamp, 10),
+	} {
+		// fields are length prefixed so that moving bytes between fields changes the digest
+		hash.Write([]byte(strconv.Itoa(len(field))))
+		hash.Write([]byte{':'})
+		hash.Write([]byte(field))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Config configures the audit log.
+type Config struct {
+	// PrivateKey signs the exported segments, the exports are refused without it.
+	PrivateKey ed25519.PrivateKey
+	// KeyID identifies the key in the exported segments, so that auditors can pick the public key to verify with.
+	KeyID string
+	// MaxExport caps the number of entries of an exported segment, 10000 by default.
+	MaxExport int
+}
+
+// Log appends entries to the hash chained audit log and exports signed segments of it.
+// The table of Entry has to be migrated.
+type Log struct {
+	db     *orm.DB
+	config Config
+	mu     sync.Mutex
+}
+
+// New returns an audit log storing entries in db.
+func New(db *orm.DB, config Config) *Log {
+	if config.MaxExport <= 0 {
+		config.MaxExport = 10000
+	}
+	return &Log{db: db, config: config}
+}
+
+// Record appends the entry to the log, its Sequence, Timestamp, PrevHash and Hash are set on success.
+func (l *Log) Record(entry *Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.db.Transaction(func(db *orm.DB) error {
+		var last Entry
+		if err := db.Order("sequence DESC").First(&last).Error; err != nil && !errors.Is(err, orm.ErrRecordNotFound) {
+			return err
+		}
+
+		entry.ID = 0
+		entry.Sequence = last.Sequence + 1
+		entry.PrevHash = last.Hash
+		if entry.Timestamp == 0 {
+			entry.Timestamp = time.Now().UnixNano()
+		}
+		entry.Hash = entry.Digest()
+		return db.Create(entry).Error
+	})
+}
+
+// RecordEvent appends a resource event to the log.
+func (l *Log) RecordEvent(ctx context.Context, event events.Event) error {
+	entry := &Entry{
+		Resource:   event.Resource,
+		Action:     string(event.Action),
+		PrimaryKey: event.PrimaryKey,
+		Actor:      event.Actor,
+	}
+	if !event.Timestamp.IsZero() {
+		entry.Timestamp = event.Timestamp.UnixNano()
+	}
+	if event.Record != nil {
+		payload, err := json.Marshal(event.Record)
+		if err != nil {
+			return err
+		}
+		entry.Payload = string(payload)
+	}
+
+	if err := l.Record(entry); err != nil {
+		log.Errorf("failed to record audit entry of %v %v: %s", event.Resource, event.PrimaryKey, err)
+		return err
+	}
+	return nil
+}
+
+// Subscribe records the events of the bus, it returns a function to unsubscribe.
+func (l *Log) Subscribe(bus *events.Bus) func() {
+	return bus.Subscribe(l.RecordEvent)
+}
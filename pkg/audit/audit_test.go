@@ -0,0 +1,124 @@
+package audit_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bhojpur/application/pkg/audit"
+)
+
+func chain(prevHash string, from uint64, count int) []audit.Entry {
+	entries := make([]audit.Entry, count)
+	for idx := range entries {
+		entry := &entries[idx]
+		entry.Sequence = from + uint64(idx)
+		entry.Resource = "Order"
+		entry.Action = "update"
+		entry.PrimaryKey = "1"
+		entry.Timestamp = int64(idx + 1)
+		entry.PrevHash = prevHash
+		entry.Hash = entry.Digest()
+		prevHash = entry.Hash
+	}
+	return entries
+}
+
+func TestSealAndVerify(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	segment, err := audit.Seal(chain("", 1, 5), privateKey, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), segment.From)
+	assert.Equal(t, uint64(5), segment.To)
+	assert.NoError(t, audit.Verify(segment, publicKey))
+
+	otherKey, _, _ := ed25519.GenerateKey(nil)
+	assert.True(t, errors.Is(audit.Verify(segment, otherKey), audit.ErrInvalidSignature))
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	seal := func() *audit.Segment {
+		segment, err := audit.Seal(chain("", 1, 5), privateKey, "")
+		require.NoError(t, err)
+		return segment
+	}
+
+	altered := seal()
+	altered.Entries[2].Actor = "mallory"
+	assert.True(t, errors.Is(audit.Verify(altered, publicKey), audit.ErrHashMismatch))
+
+	rehashed := seal()
+	rehashed.Entries[2].Actor = "mallory"
+	rehashed.Entries[2].Hash = rehashed.Entries[2].Digest()
+	assert.True(t, errors.Is(audit.Verify(rehashed, publicKey), audit.ErrBrokenChain))
+
+	removed := seal()
+	removed.Entries = append(removed.Entries[:2], removed.Entries[3:]...)
+	assert.True(t, errors.Is(audit.Verify(removed, publicKey), audit.ErrMissingEntry))
+
+	truncated := seal()
+	truncated.Entries = truncated.Entries[:4]
+	assert.True(t, errors.Is(audit.Verify(truncated, publicKey), audit.ErrMissingEntry))
+}
+
+func TestVerifyContinuity(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	entries := chain("", 1, 6)
+	first, err := audit.Seal(entries[:3], privateKey, "")
+	require.NoError(t, err)
+	second, err := audit.Seal(entries[3:], privateKey, "")
+	require.NoError(t, err)
+	assert.NoError(t, audit.VerifyContinuity(first, second))
+
+	gap, err := audit.Seal(entries[4:], privateKey, "")
+	require.NoError(t, err)
+	assert.True(t, errors.Is(audit.VerifyContinuity(first, gap), audit.ErrMissingEntry))
+}
+
+func TestInclusionProof(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	segment, err := audit.Seal(chain("", 10, 7), privateKey, "")
+	require.NoError(t, err)
+
+	for _, entry := range segment.Entries {
+		proof, err := segment.Proof(entry.Sequence)
+		require.NoError(t, err)
+		assert.True(t, audit.VerifyInclusion(entry.Hash, proof, segment.Root), "entry %d", entry.Sequence)
+		assert.False(t, audit.VerifyInclusion(entry.PrevHash+"x", proof, segment.Root))
+	}
+
+	_, err = segment.Proof(17)
+	assert.Error(t, err)
+}
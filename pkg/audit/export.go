@@ -0,0 +1,257 @@
+package audit
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Errors returned by Export and the verifiers
+var (
+	ErrNoSigningKey     = errors.New("audit: no signing key configured")
+	ErrEmptySegment     = errors.New("audit: segment has no entries")
+	ErrInvalidSignature = errors.New("audit: invalid segment signature")
+	ErrMissingEntry     = errors.New("audit: entries are missing")
+	ErrBrokenChain      = errors.New("audit: hash chain is broken")
+	ErrHashMismatch     = errors.New("audit: entry hash does not match its content")
+	ErrRootMismatch     = errors.New("audit: merkle root does not match the entries")
+)
+
+// Segment is a signed export of consecutive audit entries. Anchor is the hash the first entry is chained to,
+// Head the hash of the last entry and Root the Merkle root of the entry hashes.
+type Segment struct {
+	KeyID      string    `json:"keyId,omitempty"`
+	From       uint64    `json:"from"`
+	To         uint64    `json:"to"`
+	Anchor     string    `json:"anchor"`
+	Head       string    `json:"head"`
+	Root       string    `json:"root"`
+	ExportedAt time.Time `json:"exportedAt"`
+	Entries    []Entry   `json:"entries"`
+	Signature  string    `json:"signature"`
+}
+
+// ProofStep is a sibling hash of an inclusion proof, Left is true if the sibling is the left node.
+type ProofStep struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left,omitempty"`
+}
+
+// Export returns the signed segment of the entries from the sequence from to the sequence to included.
+// A zero to exports up to the last entry, segments are capped to MaxExport entries so that auditors page
+// with the To of the previous segment.
+func (l *Log) Export(from, to uint64) (*Segment, error) {
+	if len(l.config.PrivateKey) == 0 {
+		return nil, ErrNoSigningKey
+	}
+	if from == 0 {
+		from = 1
+	}
+
+	maxTo := from + uint64(l.config.MaxExport) - 1
+	if to == 0 || to > maxTo {
+		to = maxTo
+	}
+
+	var entries []Entry
+	if err := l.db.Where("sequence >= ? AND sequence <= ?", from, to).Order("sequence").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return Seal(entries, l.config.PrivateKey, l.config.KeyID)
+}
+
+// Seal returns the segment of the entries signed with key.
+func Seal(entries []Entry, key ed25519.PrivateKey, keyID string) (*Segment, error) {
+	if len(entries) == 0 {
+		return nil, ErrEmptySegment
+	}
+
+	first, last := entries[0], entries[len(entries)-1]
+	segment := &Segment{
+		KeyID:      keyID,
+		From:       first.Sequence,
+		To:         last.Sequence,
+		Anchor:     first.PrevHash,
+		Head:       last.Hash,
+		Root:       MerkleRoot(entryHashes(entries)),
+		ExportedAt: time.Now().UTC(),
+		Entries:    entries,
+	}
+	segment.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, segment.signedContent()))
+	return segment, nil
+}
+
+// Verify confirms the segment was signed with the private key of publicKey, and that none of its entries were
+// altered, reordered or removed.
+func Verify(segment *Segment, publicKey ed25519.PublicKey) error {
+	if segment == nil || len(segment.Entries) == 0 {
+		return ErrEmptySegment
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(segment.Signature)
+	if err != nil || !ed25519.Verify(publicKey, segment.signedContent(), signature) {
+		return ErrInvalidSignature
+	}
+
+	if segment.Entries[0].PrevHash != segment.Anchor {
+		return fmt.Errorf("%w: first entry is not chained to the anchor", ErrBrokenChain)
+	}
+
+	for idx := range segment.Entries {
+		entry := &segment.Entries[idx]
+		if expected := segment.From + uint64(idx); entry.Sequence != expected {
+			return fmt.Errorf("%w: expected entry %d, got %d", ErrMissingEntry, expected, entry.Sequence)
+		}
+		if idx > 0 && entry.PrevHash != segment.Entries[idx-1].Hash {
+			return fmt.Errorf("%w: at entry %d", ErrBrokenChain, entry.Sequence)
+		}
+		if entry.Digest() != entry.Hash {
+			return fmt.Errorf("%w: at entry %d", ErrHashMismatch, entry.Sequence)
+		}
+	}
+
+	if last := segment.Entries[len(segment.Entries)-1]; last.Sequence != segment.To || last.Hash != segment.Head {
+		return fmt.Errorf("%w: last entry is not the head", ErrMissingEntry)
+	}
+
+	if MerkleRoot(entryHashes(segment.Entries)) != segment.Root {
+		return ErrRootMismatch
+	}
+	return nil
+}
+
+// VerifyContinuity confirms next directly follows prev, so that no entries were removed between two exports.
+// Both segments should have been verified with Verify.
+func VerifyContinuity(prev, next *Segment) error {
+	if next.From != prev.To+1 {
+		return fmt.Errorf("%w: expected entry %d, got %d", ErrMissingEntry, prev.To+1, next.From)
+	}
+	if next.Anchor != prev.Head {
+		return fmt.Errorf("%w: between entries %d and %d", ErrBrokenChain, prev.To, next.From)
+	}
+	return nil
+}
+
+// Proof returns the Merkle inclusion proof of the entry with sequence in the segment.
+func (segment *Segment) Proof(sequence uint64) ([]ProofStep, error) {
+	if sequence < segment.From || sequence > segment.To || int(sequence-segment.From) >= len(segment.Entries) {
+		return nil, fmt.Errorf("%w: entry %d is not part of the segment", ErrMissingEntry, sequence)
+	}
+	return InclusionProof(entryHashes(segment.Entries), int(sequence-segment.From)), nil
+}
+
+// signedContent is the content covered by the signature of a segment. The entries are covered by Root.
+func (segment *Segment) signedContent() []byte {
+	return []byte(fmt.Sprintf("bhojpur-audit-segment/v1\n%s\n%d\n%d\n%s\n%s\n%s\n%d",
+		segment.KeyID, segment.From, segment.To, segment.Anchor, segment.Head, segment.Root, segment.ExportedAt.UnixNano()))
+}
+
+func entryHashes(entries []Entry) []string {
+	hashes := make([]string, len(entries))
+	for idx := range entries {
+		hashes[idx] = entries[idx].Hash
+	}
+	return hashes
+}
+
+// MerkleRoot returns the hex encoded Merkle root of hashes. Leaves and inner nodes are hashed with distinct
+// prefixes, and the last node of a level with an odd number of nodes is promoted to the next level.
+func MerkleRoot(hashes []string) string {
+	if len(hashes) == 0 {
+		return ""
+	}
+
+	level := make([][]byte, len(hashes))
+	for idx, hash := range hashes {
+		level[idx] = merkleLeaf(hash)
+	}
+	for len(level) > 1 {
+		level = merkleLevel(level)
+	}
+	return hex.EncodeToString(level[0])
+}
+
+// InclusionProof returns the sibling hashes proving that the hash at index is part of the Merkle root of hashes.
+func InclusionProof(hashes []string, index int) []ProofStep {
+	if index < 0 || index >= len(hashes) {
+		return nil
+	}
+
+	level := make([][]byte, len(hashes))
+	for idx, hash := range hashes {
+		level[idx] = merkleLeaf(hash)
+	}
+
+	var proof []ProofStep
+	for len(level) > 1 {
+		if sibling := index ^ 1; sibling < len(level) {
+			proof = append(proof, ProofStep{Hash: hex.EncodeToString(level[sibling]), Left: sibling < index})
+		}
+		level = merkleLevel(level)
+		index /= 2
+	}
+	return proof
+}
+
+// VerifyInclusion returns true if proof proves hash is part of the Merkle root.
+func VerifyInclusion(hash string, proof []ProofStep, root string) bool {
+	node := merkleLeaf(hash)
+	for _, step := range proof {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil {
+			return false
+		}
+		if step.Left {
+			node = merkleNode(sibling, node)
+		} else {
+			node = merkleNode(node, sibling)
+		}
+	}
+	return hex.EncodeToString(node) == root
+}
+
+func merkleLevel(level [][]byte) [][]byte {
+	next := make([][]byte, 0, (len(level)+1)/2)
+	for idx := 0; idx < len(level); idx += 2 {
+		if idx+1 == len(level) {
+			next = append(next, level[idx])
+			continue
+		}
+		next = append(next, merkleNode(level[idx], level[idx+1]))
+	}
+	return next
+}
+
+func merkleLeaf(hash string) []byte {
+	sum := sha256.Sum256(append([]byte{0}, hash...))
+	return sum[:]
+}
+
+func merkleNode(left, right []byte) []byte {
+	sum := sha256.Sum256(append(append([]byte{1}, left...), right...))
+	return sum[:]
+}
@@ -0,0 +1,78 @@
+package audit
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// PublicKey returns the public key auditors verify the exported segments with, nil if no key is configured.
+func (l *Log) PublicKey() ed25519.PublicKey {
+	if len(l.config.PrivateKey) == 0 {
+		return nil
+	}
+	return l.config.PrivateKey.Public().(ed25519.PublicKey)
+}
+
+// Handler returns the HTTP API exporting signed segments to auditors, it should be mounted behind an
+// authorization of the auditors:
+//
+//	GET /?from=1&to=100    returns the segment of the entries 1 to 100 included, to is optional
+func (l *Log) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		var from, to uint64
+		for name, value := range map[string]*uint64{"from": &from, "to": &to} {
+			if param := req.URL.Query().Get(name); param != "" {
+				parsed, err := strconv.ParseUint(param, 10, 64)
+				if err != nil {
+					http.Error(w, "invalid "+name, http.StatusBadRequest)
+					return
+				}
+				*value = parsed
+			}
+		}
+
+		segment, err := l.Export(from, to)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrEmptySegment):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.Is(err, ErrNoSigningKey):
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(segment)
+	})
+}
@@ -0,0 +1,280 @@
+package auth
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
+	"github.com/bhojpur/application/pkg/roles"
+)
+
+var log = applog.New("app.auth")
+
+var (
+	// ErrInvalidCredentials is returned by providers when credentials don't match a user
+	ErrInvalidCredentials = errors.New("auth: invalid credentials")
+	// ErrUnauthenticated is returned when a request has no valid session or token
+	ErrUnauthenticated = errors.New("auth: unauthenticated")
+	// ErrUnknownProvider is returned when logging in with a provider not registered, or of another kind
+	ErrUnknownProvider = errors.New("auth: unknown provider")
+)
+
+// User is an authenticated user, it is the current user of the contexts of its requests
+type User struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name,omitempty"`
+	Email    string   `json:"email,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+	Provider string   `json:"provider"`
+	// SecondFactor is the name of the second factor the user verified, if any
+	SecondFactor string `json:"secondFactor,omitempty"`
+}
+
+// DisplayName returns the name of the user, its ID if it has none
+func (user *User) DisplayName() string {
+	if user.Name != "" {
+		return user.Name
+	}
+	return user.ID
+}
+
+// GetRoles returns the roles of the user
+func (user *User) GetRoles() []string {
+	return user.Roles
+}
+
+// UniqueID returns the ID of the user, qualified by its provider, so that it is unique among providers, e.g. to store
+// the second factors of users
+func (user *User) UniqueID() string {
+	return user.Provider + ":" + user.ID
+}
+
+// HasRole returns true if the user has the role
+func (user *User) HasRole(role string) bool {
+	for _, r := range user.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Provider is an identity provider, see CredentialsProvider and RedirectProvider
+type Provider interface {
+	Name() string
+}
+
+// CredentialsProvider authenticates users with a username and a password, like a password database or LDAP
+type CredentialsProvider interface {
+	Provider
+	Authenticate(ctx context.Context, username, password string) (*User, error)
+}
+
+// RedirectProvider authenticates users by redirecting them to an external provider, like OAuth2 or OIDC
+type RedirectProvider interface {
+	Provider
+	// AuthCodeURL returns the URL users are redirected to, state has to be passed back to the callback, at
+	// /<provider>/callback of the handler of Auth
+	AuthCodeURL(state string) string
+	// Exchange authenticates the user of the callback request of the provider
+	Exchange(ctx context.Context, req *http.Request) (*User, error)
+}
+
+// Config configures authentication
+type Config struct {
+	// SessionKey encrypts and authenticates session cookies with AES-GCM, it is 16, 24 or 32 bytes long
+	SessionKey []byte
+	// CookieName is the name of the session cookie, "bhojpur_session" by default
+	CookieName string
+	// SessionTTL is the duration of sessions, 24 hours by default
+	SessionTTL time.Duration
+	// InsecureCookie sends session cookies over plain HTTP too, for development only
+	InsecureCookie bool
+	// TokenKey signs the JWT tokens of the API with HS256, tokens are not issued nor accepted if it is empty
+	TokenKey []byte
+	// TokenTTL is the duration of tokens, an hour by default
+	TokenTTL time.Duration
+	// StateTTL is the duration of the state of redirects to providers, 10 minutes by default
+	StateTTL time.Duration
+	// Issuer is the issuer of tokens
+	Issuer string
+	// SecondFactorRoles are the roles of users who have to verify a second factor, "*" for all users. Users who
+	// enrolled a second factor verify it anyway
+	SecondFactorRoles []string
+	// SecondFactorURL is the page users are redirected to after login to verify their second factor, with the redirect
+	// parameter of the login
+	SecondFactorURL string
+	// MaxSecondFactorAttempts is the number of invalid codes users can enter before their pending session ends and
+	// they are locked out of second factors, 5 by default
+	MaxSecondFactorAttempts int
+	// SecondFactorLockout is the duration users are locked out of second factors, 15 minutes by default
+	SecondFactorLockout time.Duration
+}
+
+// Auth authenticates requests with the sessions and tokens of users authenticated by its providers
+type Auth struct {
+	config    Config
+	providers map[string]Provider
+	factors   []SecondFactor
+	failures  failures
+}
+
+// New initialize authentication with providers, and second factors
+func New(config Config, providers ...Provider) *Auth {
+	if config.CookieName == "" {
+		config.CookieName = "bhojpur_session"
+	}
+	if config.SessionTTL <= 0 {
+		config.SessionTTL = 24 * time.Hour
+	}
+	if config.TokenTTL <= 0 {
+		config.TokenTTL = time.Hour
+	}
+	if config.StateTTL <= 0 {
+		config.StateTTL = 10 * time.Minute
+	}
+	if config.MaxSecondFactorAttempts <= 0 {
+		config.MaxSecondFactorAttempts = 5
+	}
+	if config.SecondFactorLockout <= 0 {
+		config.SecondFactorLockout = 15 * time.Minute
+	}
+
+	a := &Auth{config: config, providers: map[string]Provider{}}
+	for _, provider := range providers {
+		a.providers[provider.Name()] = provider
+		if factor, ok := provider.(SecondFactor); ok {
+			a.factors = append(a.factors, factor)
+		}
+	}
+	return a
+}
+
+// GetProvider returns the registered provider with the name
+func (a *Auth) GetProvider(name string) Provider {
+	return a.providers[name]
+}
+
+// Authenticate authenticates a user with the credentials of a CredentialsProvider
+func (a *Auth) Authenticate(ctx context.Context, providerName, username, password string) (*User, error) {
+	provider, ok := a.providers[providerName].(CredentialsProvider)
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	user, err := provider.Authenticate(ctx, username, password)
+	if err != nil {
+		log.Info("authentication failed", applog.String("provider", providerName), applog.Err(err))
+		return nil, err
+	}
+	user.Provider = providerName
+	return user, nil
+}
+
+type contextKey int
+
+const userKey contextKey = iota
+
+// authenticate returns the user of a request, from its bearer token or its session cookie
+func (a *Auth) authenticate(req *http.Request) (*User, error) {
+	var (
+		user *User
+		err  error
+	)
+	if token := bearerToken(req); token != "" {
+		user, err = a.ParseToken(token)
+	} else {
+		user, err = a.GetSession(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// like sessions started before the roles of the user required a second factor
+	if user.SecondFactor == "" && a.requiresSecondFactor(user) {
+		return nil, ErrSecondFactorRequired
+	}
+	return user, nil
+}
+
+// Middleware authenticates requests with their bearer token or session cookie, anonymous requests are passed
+// through, see GetUser
+func (a *Auth) Middleware() *appsvr.Middleware {
+	return &appsvr.Middleware{
+		Name: "auth",
+		Handler: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				user, err := a.authenticate(req)
+				if err != nil {
+					if err != ErrUnauthenticated {
+						log.Debug("invalid credentials of request", applog.Err(err))
+					}
+					next.ServeHTTP(w, req)
+					return
+				}
+				next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), userKey, user)))
+			})
+		},
+	}
+}
+
+// GetUser returns the user of a request authenticated by the middleware, nil if it is anonymous
+func GetUser(req *http.Request) *User {
+	user, _ := req.Context().Value(userKey).(*User)
+	return user
+}
+
+// CurrentUser returns the user of a request as the current user of contexts, it can be used as the CurrentUser of the
+// API, e.g. api.CurrentUser = auth.CurrentUser
+func CurrentUser(req *http.Request) appsvr.CurrentUser {
+	if user := GetUser(req); user != nil {
+		return user
+	}
+	return nil
+}
+
+// Populate sets the user of the request of the context as its current user, and the roles matching them, see
+// RegisterRoles
+func Populate(context *appsvr.Context) {
+	var user interface{}
+	if context.Request != nil {
+		if currentUser := GetUser(context.Request); currentUser != nil {
+			context.CurrentUser, user = currentUser, currentUser
+		}
+	}
+	context.Roles = roles.MatchedRoles(context.Request, user)
+}
+
+// RegisterRoles registers roles matching the users having them, so that the roles of users are used by permissions
+func RegisterRoles(names ...string) {
+	for _, name := range names {
+		name := name
+		roles.Register(name, func(req *http.Request, user interface{}) bool {
+			u, ok := user.(*User)
+			return ok && u.HasRole(name)
+		})
+	}
+}
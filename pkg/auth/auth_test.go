@@ -0,0 +1,142 @@
+package auth_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bhojpur/application/pkg/auth"
+	"github.com/bhojpur/application/pkg/auth/password"
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newAuth(t *testing.T) *auth.Auth {
+	db := utils.SQLiteTestDB(t)
+
+	provider, err := password.New(db)
+	require.NoError(t, err)
+	provider.Cost = bcrypt.MinCost
+	require.NoError(t, provider.Register(&password.Identity{Username: "jane", Name: "Jane", Roles: "editor, admin"}, "secret"))
+	assert.Equal(t, password.ErrUsernameTaken, provider.Register(&password.Identity{Username: "jane"}, "other"))
+
+	return auth.New(auth.Config{SessionKey: []byte("0123456789abcdef"), TokenKey: []byte("token key"), Issuer: "test"}, provider)
+}
+
+// serve serves a request with the middleware, and returns the context of the request populated by the handler
+func serve(a *auth.Auth, req *http.Request) *appsvr.Context {
+	var context *appsvr.Context
+	a.Middleware().Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		context = &appsvr.Context{Request: req}
+		auth.Populate(context)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	return context
+}
+
+func TestSession(t *testing.T) {
+	auth.RegisterRoles("editor")
+	a := newAuth(t)
+
+	login := func(pass, redirect string) *httptest.ResponseRecorder {
+		form := url.Values{"provider": {"password"}, "username": {"jane"}, "password": {pass}, "redirect": {redirect}}
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login("wrong", "").Code)
+
+	w := login("secret", "https://example.com/")
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/", w.Header().Get("Location"), "redirects to other sites are ignored")
+	assert.Equal(t, "/products", login("secret", "/products").Header().Get("Location"))
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.True(t, cookies[0].Secure)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	context := serve(a, req)
+	require.NotNil(t, context.CurrentUser)
+	assert.Equal(t, "Jane", context.CurrentUser.DisplayName())
+	assert.Equal(t, []string{"editor"}, context.Roles)
+	assert.Equal(t, "password", auth.GetUser(context.Request).Provider)
+
+	// a tampered session is anonymous
+	tampered := *cookies[0]
+	tampered.Value = strings.ToUpper(tampered.Value)
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&tampered)
+	context = serve(a, req)
+	assert.Nil(t, context.CurrentUser)
+	assert.Nil(t, auth.CurrentUser(context.Request))
+	assert.Empty(t, context.Roles)
+}
+
+func TestToken(t *testing.T) {
+	a := newAuth(t)
+
+	user, err := a.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil).Context(), "password", "jane", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor", "admin"}, user.Roles)
+
+	token, err := a.IssueToken(user)
+	require.NoError(t, err)
+	parsed, err := a.ParseToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, user, parsed)
+
+	other := auth.New(auth.Config{TokenKey: []byte("other key")})
+	_, err = other.ParseToken(token)
+	assert.Equal(t, auth.ErrUnauthenticated, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	context := serve(a, req)
+	require.NotNil(t, context.CurrentUser)
+	assert.Equal(t, user, context.CurrentUser)
+
+	// clients accepting JSON get a token when they login
+	form := url.Values{"provider": {"password"}, "username": {"jane"}, "password": {"secret"}}
+	req = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct{ Token string }
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	parsed, err = a.ParseToken(response.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", parsed.Name)
+}
@@ -0,0 +1,226 @@
+package auth
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	applog "github.com/bhojpur/application/pkg/log"
+)
+
+type oauthState struct {
+	State     string `json:"state"`
+	Redirect  string `json:"redirect"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type loginResponse struct {
+	User  *User  `json:"user"`
+	Token string `json:"token,omitempty"`
+}
+
+type secondFactorResponse struct {
+	// SecondFactors are the second factors the user enrolled, it has to enroll one if there are none
+	SecondFactors []string `json:"secondFactors"`
+}
+
+// ServeHTTP serves the login and logout of users, mount it with its prefix stripped:
+//
+//	POST /login                login with the provider, username and password of the form
+//	POST /logout               end the session
+//	GET  /<provider>/login     redirect to the provider
+//	GET  /<provider>/callback  login with the callback of the provider
+//	POST /2fa/<factor>/challenge  challenge the pending user with a second factor, see Challenger
+//	POST /2fa/<factor>/verify     verify the code of the form with a second factor, and complete the login
+//
+// Users are redirected to the relative URL of the redirect parameter after login, or get their user and a token as
+// JSON if the request accepts JSON. Users who have to verify a second factor are redirected to the SecondFactorURL of
+// the config, or get the second factors they enrolled as JSON, with status 401
+//
+//	mux.Handle("/auth/", http.StripPrefix("/auth", a))
+func (a *Auth) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "login" && req.Method == http.MethodPost:
+		user, err := a.Authenticate(req.Context(), req.FormValue("provider"), req.FormValue("username"), req.FormValue("password"))
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		a.login(w, req, user, req.FormValue("redirect"))
+	case len(parts) == 1 && parts[0] == "logout" && req.Method == http.MethodPost:
+		a.Logout(w, req)
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 2 && parts[1] == "login" && req.Method == http.MethodGet:
+		a.redirect(w, req, parts[0])
+	case len(parts) == 2 && parts[1] == "callback" && req.Method == http.MethodGet:
+		a.callback(w, req, parts[0])
+	case len(parts) == 3 && parts[0] == "2fa" && parts[2] == "challenge" && req.Method == http.MethodPost:
+		challenge, err := a.Challenge(req, parts[1])
+		if err != nil {
+			secondFactorError(w, req, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(challenge)
+	case len(parts) == 3 && parts[0] == "2fa" && parts[2] == "verify" && req.Method == http.MethodPost:
+		user, err := a.VerifySecondFactor(w, req, parts[1], req.FormValue("code"))
+		if err != nil {
+			secondFactorError(w, req, err)
+			return
+		}
+		a.loggedIn(w, req, user, req.FormValue("redirect"))
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (a *Auth) redirect(w http.ResponseWriter, req *http.Request, providerName string) {
+	provider, ok := a.providers[providerName].(RedirectProvider)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	state := oauthState{State: hex.EncodeToString(nonce), Redirect: req.FormValue("redirect"), ExpiresAt: time.Now().Add(a.config.StateTTL).Unix()}
+	value, err := a.seal(state)
+	if err != nil {
+		log.Error("failed to seal state", applog.Err(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	a.setCookie(w, a.stateCookieName(providerName), value, int(a.config.StateTTL/time.Second))
+	http.Redirect(w, req, provider.AuthCodeURL(state.State), http.StatusFound)
+}
+
+func (a *Auth) callback(w http.ResponseWriter, req *http.Request, providerName string) {
+	provider, ok := a.providers[providerName].(RedirectProvider)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	var state oauthState
+	cookie, err := req.Cookie(a.stateCookieName(providerName))
+	if err == nil {
+		err = a.open(cookie.Value, &state)
+	}
+	a.setCookie(w, a.stateCookieName(providerName), "", -1)
+	if err != nil || state.State == "" || state.State != req.FormValue("state") || time.Now().Unix() >= state.ExpiresAt {
+		http.Error(w, "invalid state", http.StatusBadRequest)
+		return
+	}
+
+	user, err := provider.Exchange(req.Context(), req)
+	if err != nil {
+		log.Info("authentication failed", applog.String("provider", providerName), applog.Err(err))
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	user.Provider = providerName
+	a.login(w, req, user, state.Redirect)
+}
+
+// login starts the session of an authenticated user
+func (a *Auth) login(w http.ResponseWriter, req *http.Request, user *User, redirect string) {
+	factors, err := a.startSession(w, req, user)
+	switch {
+	case err == ErrSecondFactorRequired:
+		a.secondFactorRequired(w, req, factors, redirect)
+	case err != nil:
+		log.Error("failed to start session", applog.Err(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	default:
+		a.loggedIn(w, req, user, redirect)
+	}
+}
+
+func (a *Auth) secondFactorRequired(w http.ResponseWriter, req *http.Request, factors []string, redirect string) {
+	if !acceptsJSON(req) && a.config.SecondFactorURL != "" {
+		http.Redirect(w, req, a.config.SecondFactorURL+"?"+url.Values{"redirect": {redirect}}.Encode(), http.StatusSeeOther)
+		return
+	}
+
+	if factors == nil {
+		factors = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(secondFactorResponse{SecondFactors: factors})
+}
+
+func secondFactorError(w http.ResponseWriter, req *http.Request, err error) {
+	switch err {
+	case ErrUnknownProvider:
+		http.NotFound(w, req)
+	case ErrUnauthenticated, ErrInvalidCode:
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	case ErrTooManyAttempts:
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+	default:
+		log.Error("failed to verify second factor", applog.Err(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+func acceptsJSON(req *http.Request) bool {
+	return strings.Contains(req.Header.Get("Accept"), "application/json")
+}
+
+func (a *Auth) loggedIn(w http.ResponseWriter, req *http.Request, user *User, redirect string) {
+	if acceptsJSON(req) {
+		response := loginResponse{User: user}
+		if len(a.config.TokenKey) > 0 {
+			token, err := a.IssueToken(user)
+			if err != nil {
+				log.Error("failed to issue token", applog.Err(err))
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			response.Token = token
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	// only relative redirects, so that login can't be used to redirect to other sites
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		redirect = "/"
+	}
+	http.Redirect(w, req, redirect, http.StatusSeeOther)
+}
+
+func (a *Auth) stateCookieName(providerName string) string {
+	return a.config.CookieName + "_" + providerName + "_state"
+}
@@ -0,0 +1,154 @@
+package ldap
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/bhojpur/application/pkg/auth"
+)
+
+// ErrInsecure is returned when the password would be sent in plain text, the provider has neither TLSConfig nor
+// StartTLS and isn't Insecure
+var ErrInsecure = errors.New("ldap: refusing to bind without TLS, set TLSConfig or StartTLS")
+
+// Provider authenticates users with a simple bind to an LDAP directory, as the DN of their username. Passwords are
+// only sent over TLS, either LDAPS or StartTLS, unless the provider is Insecure
+//
+//	provider := &ldap.Provider{Addr: "ldap.example.com:636", TLSConfig: &tls.Config{ServerName: "ldap.example.com"},
+//		BindDN: "uid=%s,ou=people,dc=example,dc=com"}
+type Provider struct {
+	// Addr is the host:port of the directory
+	Addr string
+	// TLSConfig connects with TLS (LDAPS) if it is set, or configures StartTLS
+	TLSConfig *tls.Config
+	// StartTLS upgrades the connection to TLS before binding, with TLSConfig if it is set
+	StartTLS bool
+	// Insecure allows binding without TLS, the password is sent in plain text
+	Insecure bool
+	// BindDN is the format of the DN of users, their escaped username is its only argument
+	BindDN string
+	// Timeout of the bind, 10 seconds by default
+	Timeout time.Duration
+	// Roles returns the roles of an authenticated user, like the roles of its groups, it has none if it isn't set
+	Roles func(ctx context.Context, username string) ([]string, error)
+}
+
+var _ auth.CredentialsProvider = &Provider{}
+
+// Name returns "ldap"
+func (provider *Provider) Name() string {
+	return "ldap"
+}
+
+// Authenticate binds as the DN of the username with the password, auth.ErrInvalidCredentials if the directory
+// rejects it
+func (provider *Provider) Authenticate(ctx context.Context, username, password string) (*auth.User, error) {
+	// a bind without password is an unauthenticated bind, which directories accept
+	if username == "" || password == "" {
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	dn := fmt.Sprintf(provider.BindDN, ldap.EscapeDN(username))
+	if err := provider.bind(ctx, dn, password); err != nil {
+		return nil, err
+	}
+
+	user := &auth.User{ID: dn, Name: username}
+	if provider.Roles != nil {
+		roles, err := provider.Roles(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		user.Roles = roles
+	}
+	return user, nil
+}
+
+func (provider *Provider) bind(ctx context.Context, dn, password string) error {
+	if provider.TLSConfig == nil && !provider.StartTLS && !provider.Insecure {
+		return ErrInsecure
+	}
+
+	timeout := provider.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+
+	var (
+		dialer = &net.Dialer{Timeout: timeout}
+		conn   *ldap.Conn
+		err    error
+	)
+	if provider.TLSConfig != nil && !provider.StartTLS {
+		conn, err = ldap.DialURL("ldaps://"+provider.Addr, ldap.DialWithTLSDialer(provider.TLSConfig, dialer))
+	} else {
+		conn, err = ldap.DialURL("ldap://"+provider.Addr, ldap.DialWithDialer(dialer))
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetTimeout(timeout)
+
+	// the connection is closed when the context is done, which fails the pending request
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if provider.StartTLS {
+		config := provider.TLSConfig
+		if config == nil {
+			host, _, _ := net.SplitHostPort(provider.Addr)
+			config = &tls.Config{ServerName: host}
+		}
+		if err := conn.StartTLS(config); err != nil {
+			return err
+		}
+	}
+
+	if err := conn.Bind(dn, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return auth.ErrInvalidCredentials
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
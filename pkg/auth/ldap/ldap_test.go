@@ -0,0 +1,108 @@
+package ldap_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bhojpur/application/pkg/auth"
+	"github.com/bhojpur/application/pkg/auth/ldap"
+)
+
+// serve answers the bind requests of a directory whose users all have the password, and returns its address
+func serve(t *testing.T, password string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					packet, err := ber.ReadPacket(conn)
+					if err != nil || len(packet.Children) < 2 || packet.Children[1].Tag != goldap.ApplicationBindRequest {
+						return
+					}
+
+					code := int64(goldap.LDAPResultSuccess)
+					if packet.Children[1].Children[2].Data.String() != password {
+						code = goldap.LDAPResultInvalidCredentials
+					}
+					result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationBindResponse, nil, "")
+					result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""))
+					result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+					result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+
+					response := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+					response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, packet.Children[0].Value, ""))
+					response.AppendChild(result)
+					if _, err := conn.Write(response.Bytes()); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestAuthenticate(t *testing.T) {
+	addr := serve(t, "secret")
+
+	t.Run("binds without TLS are refused", func(t *testing.T) {
+		provider := &ldap.Provider{Addr: addr, BindDN: "uid=%s,ou=people,dc=example,dc=com"}
+		_, err := provider.Authenticate(context.Background(), "jane", "secret")
+		assert.ErrorIs(t, err, ldap.ErrInsecure)
+	})
+
+	provider := &ldap.Provider{Addr: addr, Insecure: true, BindDN: "uid=%s,ou=people,dc=example,dc=com",
+		Roles: func(ctx context.Context, username string) ([]string, error) {
+			return []string{"staff"}, nil
+		}}
+
+	t.Run("valid credentials", func(t *testing.T) {
+		user, err := provider.Authenticate(context.Background(), "jane,admin", "secret")
+		require.NoError(t, err)
+		assert.Equal(t, `uid=jane\,admin,ou=people,dc=example,dc=com`, user.ID)
+		assert.Equal(t, "jane,admin", user.Name)
+		assert.Equal(t, []string{"staff"}, user.Roles)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		_, err := provider.Authenticate(context.Background(), "jane", "guess")
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+
+		_, err = provider.Authenticate(context.Background(), "jane", "")
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	})
+}
@@ -0,0 +1,171 @@
+package oauth2
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bhojpur/application/pkg/auth"
+	"github.com/coreos/go-oidc"
+	xoauth2 "golang.org/x/oauth2"
+)
+
+// Provider authenticates users with OAuth2, by the claims of their ID token if it is an OpenID Connect provider, see
+// NewOIDC, or the claims of its user info endpoint otherwise
+type Provider struct {
+	ProviderName string
+	Config       xoauth2.Config
+	// Verifier verifies the ID tokens of OpenID Connect providers
+	Verifier *oidc.IDTokenVerifier
+	// UserInfoURL is the URL of the claims of users of OAuth2 providers without ID tokens
+	UserInfoURL string
+	// RolesClaim is the claim of the roles of users, "roles" by default
+	RolesClaim string
+}
+
+var _ auth.RedirectProvider = &Provider{}
+
+// NewOIDC initialize a provider with the endpoints and the keys of the OpenID Connect provider discovered at issuer.
+// The redirect URL of config is the callback of the provider, at /<name>/callback of the handler of Auth
+func NewOIDC(ctx context.Context, name, issuer string, config xoauth2.Config) (*Provider, error) {
+	provider, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Endpoint = provider.Endpoint()
+	if !hasScope(config.Scopes, oidc.ScopeOpenID) {
+		config.Scopes = append([]string{oidc.ScopeOpenID}, config.Scopes...)
+	}
+	return &Provider{
+		ProviderName: name,
+		Config:       config,
+		Verifier:     provider.Verifier(&oidc.Config{ClientID: config.ClientID}),
+	}, nil
+}
+
+// Name returns the name of the provider
+func (provider *Provider) Name() string {
+	return provider.ProviderName
+}
+
+// AuthCodeURL returns the URL of the consent page of the provider
+func (provider *Provider) AuthCodeURL(state string) string {
+	return provider.Config.AuthCodeURL(state)
+}
+
+// Exchange exchanges the code of the callback for a token, and returns the user of its claims
+func (provider *Provider) Exchange(ctx context.Context, req *http.Request) (*auth.User, error) {
+	if reason := req.FormValue("error"); reason != "" {
+		return nil, fmt.Errorf("oauth2: %v", reason)
+	}
+	token, err := provider.Config.Exchange(ctx, req.FormValue("code"))
+	if err != nil {
+		return nil, err
+	}
+
+	claims := map[string]interface{}{}
+	if provider.Verifier != nil {
+		rawIDToken, ok := token.Extra("id_token").(string)
+		if !ok {
+			return nil, errors.New("oauth2: no id token")
+		}
+		idToken, err := provider.Verifier.Verify(ctx, rawIDToken)
+		if err != nil {
+			return nil, err
+		}
+		if err := idToken.Claims(&claims); err != nil {
+			return nil, err
+		}
+	} else if provider.UserInfoURL != "" {
+		if err := provider.userInfo(ctx, token, &claims); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, errors.New("oauth2: no verifier nor user info URL")
+	}
+	return provider.user(claims)
+}
+
+func (provider *Provider) userInfo(ctx context.Context, token *xoauth2.Token, claims *map[string]interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.UserInfoURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := provider.Config.Client(ctx, token).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oauth2: user info: %v", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(claims)
+}
+
+func (provider *Provider) user(claims map[string]interface{}) (*auth.User, error) {
+	user := &auth.User{}
+	// "sub" of OpenID Connect, "id" of OAuth2 providers like GitHub
+	for _, name := range []string{"sub", "id"} {
+		switch id := claims[name].(type) {
+		case string:
+			user.ID = id
+		case float64:
+			user.ID = fmt.Sprint(int64(id))
+		}
+		if user.ID != "" {
+			break
+		}
+	}
+	if user.ID == "" {
+		return nil, errors.New("oauth2: no subject claim")
+	}
+
+	user.Name, _ = claims["name"].(string)
+	user.Email, _ = claims["email"].(string)
+
+	rolesClaim := provider.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = "roles"
+	}
+	roles, _ := claims[rolesClaim].([]interface{})
+	for _, role := range roles {
+		if role, ok := role.(string); ok {
+			user.Roles = append(user.Roles, role)
+		}
+	}
+	return user, nil
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package oauth2_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	xoauth2 "golang.org/x/oauth2"
+
+	"github.com/bhojpur/application/pkg/auth"
+	"github.com/bhojpur/application/pkg/auth/oauth2"
+)
+
+// newProvider returns an OAuth2 provider whose token and user info endpoints are served by a test server
+func newProvider(t *testing.T) *oauth2.Provider {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch req.URL.Path {
+		case "/token":
+			json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token", "token_type": "bearer"})
+		case "/user":
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": 42, "name": "Jane", "roles": []string{"editor"}})
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return &oauth2.Provider{
+		ProviderName: "github",
+		Config: xoauth2.Config{
+			ClientID: "client",
+			Endpoint: xoauth2.Endpoint{AuthURL: server.URL + "/authorize", TokenURL: server.URL + "/token"},
+		},
+		UserInfoURL: server.URL + "/user",
+	}
+}
+
+// redirect starts the login with the provider, and returns the state of its consent URL and the state cookie
+func redirect(t *testing.T, a *auth.Auth) (string, *http.Cookie) {
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/github/login?redirect=/products", nil))
+	require.Equal(t, http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	return location.Query().Get("state"), cookies[0]
+}
+
+func callback(a *auth.Auth, state string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/github/callback?code=code&state="+url.QueryEscape(state), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, req)
+	return w
+}
+
+func TestCallback(t *testing.T) {
+	config := auth.Config{SessionKey: []byte("0123456789abcdef")}
+	a := auth.New(config, newProvider(t))
+
+	t.Run("valid state", func(t *testing.T) {
+		state, cookie := redirect(t, a)
+		require.NotEmpty(t, state)
+
+		w := callback(a, state, cookie)
+		require.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/products", w.Header().Get("Location"))
+	})
+
+	t.Run("mismatched state", func(t *testing.T) {
+		_, cookie := redirect(t, a)
+		assert.Equal(t, http.StatusBadRequest, callback(a, "other", cookie).Code)
+	})
+
+	t.Run("state of another login", func(t *testing.T) {
+		state, _ := redirect(t, a)
+		_, cookie := redirect(t, a)
+		assert.Equal(t, http.StatusBadRequest, callback(a, state, cookie).Code)
+	})
+
+	t.Run("no state cookie", func(t *testing.T) {
+		state, _ := redirect(t, a)
+		assert.Equal(t, http.StatusBadRequest, callback(a, state, nil).Code)
+	})
+
+	t.Run("expired state", func(t *testing.T) {
+		config.StateTTL = time.Nanosecond
+		a := auth.New(config, newProvider(t))
+		state, cookie := redirect(t, a)
+		assert.Equal(t, http.StatusBadRequest, callback(a, state, cookie).Code)
+	})
+}
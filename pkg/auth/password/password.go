@@ -0,0 +1,150 @@
+package password
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/application/pkg/auth"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUsernameTaken is returned when registering a username which is already registered
+var ErrUsernameTaken = errors.New("password: username taken")
+
+// Identity is a user authenticated by a password, its password is stored as a bcrypt hash
+type Identity struct {
+	ID           uint   `orm:"primary_key"`
+	Username     string `orm:"size:255;unique_index"`
+	PasswordHash string `orm:"size:255"`
+	Name         string `orm:"size:255"`
+	Email        string `orm:"size:255"`
+	// Roles are the roles of the user, separated by commas
+	Roles     string `orm:"size:1024"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName table name of identities
+func (Identity) TableName() string {
+	return "auth_password_identities"
+}
+
+// User returns the user of the identity
+func (identity *Identity) User() *auth.User {
+	user := &auth.User{ID: strconv.FormatUint(uint64(identity.ID), 10), Name: identity.Name, Email: identity.Email}
+	for _, role := range strings.Split(identity.Roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			user.Roles = append(user.Roles, role)
+		}
+	}
+	return user
+}
+
+// Provider authenticates users with the passwords of their identities in the database
+type Provider struct {
+	DB *orm.DB
+	// Cost is the bcrypt cost of password hashes, bcrypt.DefaultCost by default
+	Cost int
+}
+
+var _ auth.CredentialsProvider = &Provider{}
+
+// New initialize a password provider, migrating the table of identities
+func New(db *orm.DB) (*Provider, error) {
+	if err := db.AutoMigrate(&Identity{}).Error; err != nil {
+		return nil, err
+	}
+	return &Provider{DB: db, Cost: bcrypt.DefaultCost}, nil
+}
+
+// Name returns "password"
+func (provider *Provider) Name() string {
+	return "password"
+}
+
+// Register registers an identity with the password, ErrUsernameTaken if its username is already registered
+func (provider *Provider) Register(identity *Identity, password string) error {
+	hash, err := provider.hash(password)
+	if err != nil {
+		return err
+	}
+	identity.PasswordHash = hash
+
+	return provider.DB.Transaction(func(tx *orm.DB) error {
+		var count int
+		if err := tx.Model(&Identity{}).Where("username = ?", identity.Username).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrUsernameTaken
+		}
+		return tx.Create(identity).Error
+	})
+}
+
+// SetPassword changes the password of the identity with the username
+func (provider *Provider) SetPassword(username, password string) error {
+	hash, err := provider.hash(password)
+	if err != nil {
+		return err
+	}
+	db := provider.DB.Model(&Identity{}).Where("username = ?", username).UpdateColumn("password_hash", hash)
+	if db.Error == nil && db.RowsAffected == 0 {
+		return orm.ErrRecordNotFound
+	}
+	return db.Error
+}
+
+// Authenticate returns the user of the identity with the username, auth.ErrInvalidCredentials if it doesn't exist or
+// its password doesn't match
+func (provider *Provider) Authenticate(ctx context.Context, username, password string) (*auth.User, error) {
+	var identity Identity
+	if err := provider.DB.Where("username = ?", username).First(&identity).Error; err != nil {
+		if !errors.Is(err, orm.ErrRecordNotFound) {
+			return nil, err
+		}
+		// compare anyway, so that unknown usernames can't be told apart by the time of the response
+		bcrypt.CompareHashAndPassword(unknownHash, []byte(password))
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(identity.PasswordHash), []byte(password)) != nil {
+		return nil, auth.ErrInvalidCredentials
+	}
+	return identity.User(), nil
+}
+
+func (provider *Provider) hash(password string) (string, error) {
+	cost := provider.Cost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	return string(hash), err
+}
+
+var unknownHash, _ = bcrypt.GenerateFromPassword([]byte("unknown"), bcrypt.DefaultCost)
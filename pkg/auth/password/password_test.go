@@ -0,0 +1,70 @@
+package password_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/bhojpur/application/pkg/auth"
+	"github.com/bhojpur/application/pkg/auth/password"
+	"github.com/bhojpur/application/test/utils"
+)
+
+func TestAuthenticate(t *testing.T) {
+	provider, err := password.New(utils.SQLiteTestDB(t))
+	require.NoError(t, err)
+	provider.Cost = bcrypt.MinCost
+	require.NoError(t, provider.Register(&password.Identity{Username: "jane", Name: "Jane", Roles: "editor, admin"}, "secret"))
+
+	t.Run("valid password", func(t *testing.T) {
+		user, err := provider.Authenticate(context.Background(), "jane", "secret")
+		require.NoError(t, err)
+		assert.Equal(t, "Jane", user.Name)
+		assert.Equal(t, []string{"editor", "admin"}, user.Roles)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		user, err := provider.Authenticate(context.Background(), "jane", "guess")
+		assert.Nil(t, user)
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+
+		_, err = provider.Authenticate(context.Background(), "jane", "")
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	})
+
+	t.Run("unknown username", func(t *testing.T) {
+		_, err := provider.Authenticate(context.Background(), "john", "secret")
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	})
+
+	t.Run("changed password", func(t *testing.T) {
+		require.NoError(t, provider.SetPassword("jane", "changed"))
+		_, err := provider.Authenticate(context.Background(), "jane", "secret")
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+		_, err = provider.Authenticate(context.Background(), "jane", "changed")
+		assert.NoError(t, err)
+	})
+}
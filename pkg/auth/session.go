@@ -0,0 +1,178 @@
+package auth
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+type session struct {
+	User      *User `json:"user"`
+	ExpiresAt int64 `json:"exp"`
+	// Pending sessions wait for the verification of a second factor, one of Factors if the user enrolled any
+	Pending bool     `json:"pending,omitempty"`
+	Factors []string `json:"factors,omitempty"`
+}
+
+// Login authenticates a user with the credentials of a CredentialsProvider, and starts its session. The session is
+// pending and ErrSecondFactorRequired is returned if the user has to verify a second factor, see VerifySecondFactor
+func (a *Auth) Login(w http.ResponseWriter, req *http.Request, providerName, username, password string) (*User, error) {
+	user, err := a.Authenticate(req.Context(), providerName, username, password)
+	if err != nil {
+		return nil, err
+	}
+	_, err = a.startSession(w, req, user)
+	return user, err
+}
+
+// Logout ends the session of the request
+func (a *Auth) Logout(w http.ResponseWriter, req *http.Request) {
+	a.setCookie(w, a.config.CookieName, "", -1)
+}
+
+// SetSession starts a session of the user, stored in a cookie encrypted with the session key
+func (a *Auth) SetSession(w http.ResponseWriter, user *User) error {
+	value, err := a.seal(session{User: user, ExpiresAt: time.Now().Add(a.config.SessionTTL).Unix()})
+	if err != nil {
+		return err
+	}
+	a.setCookie(w, a.config.CookieName, value, int(a.config.SessionTTL/time.Second))
+	return nil
+}
+
+// GetSession returns the user of the session of the request, ErrUnauthenticated if it has none, it expired, or it is
+// pending
+func (a *Auth) GetSession(req *http.Request) (*User, error) {
+	s, err := a.getSession(req)
+	if err != nil {
+		return nil, err
+	}
+	if s.Pending {
+		return nil, ErrUnauthenticated
+	}
+	return s.User, nil
+}
+
+func (a *Auth) setPendingSession(w http.ResponseWriter, user *User, factors []string) error {
+	value, err := a.seal(session{User: user, ExpiresAt: time.Now().Add(pendingTTL).Unix(), Pending: true, Factors: factors})
+	if err != nil {
+		return err
+	}
+	a.setCookie(w, a.config.CookieName, value, int(pendingTTL/time.Second))
+	return nil
+}
+
+func (a *Auth) getSession(req *http.Request) (*session, error) {
+	cookie, err := req.Cookie(a.config.CookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	var s session
+	if err := a.open(cookie.Value, &s); err != nil {
+		return nil, err
+	}
+	if s.User == nil || time.Now().Unix() >= s.ExpiresAt {
+		return nil, ErrUnauthenticated
+	}
+	return &s, nil
+}
+
+func (a *Auth) setCookie(w http.ResponseWriter, name, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   !a.config.InsecureCookie,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) == 0 {
+		return nil, errors.New("auth: no key")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt encrypts data with AES-GCM and the key, like the session key, and returns it base64 encoded
+func Encrypt(key, data []byte) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, data, nil)), nil
+}
+
+// Decrypt decrypts data encrypted by Encrypt with the key, ErrUnauthenticated if it was tampered with
+func Decrypt(key []byte, encrypted string) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil || len(data) < aead.NonceSize() {
+		return nil, ErrUnauthenticated
+	}
+
+	data, err = aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+	return data, nil
+}
+
+// seal encrypts a value as JSON, authenticated with the session key
+func (a *Auth) seal(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return Encrypt(a.config.SessionKey, data)
+}
+
+// open decrypts a value sealed by seal, the value is rejected if it was tampered with
+func (a *Auth) open(sealed string, value interface{}) error {
+	data, err := Decrypt(a.config.SessionKey, sealed)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
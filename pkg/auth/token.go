@@ -0,0 +1,104 @@
+package auth
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	applog "github.com/bhojpur/application/pkg/log"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Claims are the claims of the tokens issued for users
+type Claims struct {
+	jwt.RegisteredClaims
+	Name     string   `json:"name,omitempty"`
+	Email    string   `json:"email,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+	Provider string   `json:"provider,omitempty"`
+	// SecondFactor is the second factor verified by the user
+	SecondFactor string `json:"2fa,omitempty"`
+}
+
+// IssueToken issues a JWT token for the user, signed with the token key, for the Authorization header of API requests
+func (a *Auth) IssueToken(user *User) (string, error) {
+	if len(a.config.TokenKey) == 0 {
+		return "", errors.New("auth: no token key")
+	}
+
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID,
+			Issuer:    a.config.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.config.TokenTTL)),
+		},
+		Name:         user.Name,
+		Email:        user.Email,
+		Roles:        user.Roles,
+		Provider:     user.Provider,
+		SecondFactor: user.SecondFactor,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.config.TokenKey)
+}
+
+// ParseToken returns the user of a token issued by IssueToken, ErrUnauthenticated if it is invalid or expired
+func (a *Auth) ParseToken(token string) (*User, error) {
+	if len(a.config.TokenKey) == 0 {
+		return nil, ErrUnauthenticated
+	}
+
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("auth: unexpected signing method")
+		}
+		return a.config.TokenKey, nil
+	})
+	if err != nil {
+		log.Debug("invalid token", applog.Err(err))
+		return nil, ErrUnauthenticated
+	}
+	if a.config.Issuer != "" && !claims.VerifyIssuer(a.config.Issuer, true) {
+		return nil, ErrUnauthenticated
+	}
+
+	return &User{
+		ID:           claims.Subject,
+		Name:         claims.Name,
+		Email:        claims.Email,
+		Roles:        claims.Roles,
+		Provider:     claims.Provider,
+		SecondFactor: claims.SecondFactor,
+	}, nil
+}
+
+func bearerToken(req *http.Request) string {
+	header := req.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
@@ -0,0 +1,250 @@
+package auth
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// pendingTTL is the duration users have to verify their second factor after they logged in
+const pendingTTL = 10 * time.Minute
+
+var (
+	// ErrSecondFactorRequired is returned when a user has to verify a second factor to complete its login
+	ErrSecondFactorRequired = errors.New("auth: second factor required")
+	// ErrInvalidCode is returned when the code of a second factor doesn't match
+	ErrInvalidCode = errors.New("auth: invalid code")
+	// ErrTooManyAttempts is returned when a user entered too many invalid codes, and is locked out of second factors
+	ErrTooManyAttempts = errors.New("auth: too many attempts")
+)
+
+// SecondFactor verifies the codes of a second factor of users, like TOTP or recovery codes. Second factors are
+// registered as providers, see New
+type SecondFactor interface {
+	Provider
+	// Enrolled returns true if the user enrolled the second factor
+	Enrolled(ctx context.Context, user *User) (bool, error)
+	// Verify returns true if the code of the user matches
+	Verify(ctx context.Context, user *User, code string) (bool, error)
+}
+
+// Challenger is a SecondFactor which challenges users before they verify it, like by sending them a code by SMS, or
+// with the options of a WebAuthn assertion. The challenge is sent to users as JSON
+type Challenger interface {
+	SecondFactor
+	Challenge(ctx context.Context, user *User) (interface{}, error)
+}
+
+// Enroller is a SecondFactor which users enroll by verifying it once, like their first TOTP code
+type Enroller interface {
+	SecondFactor
+	// Confirm confirms the enrollment of the user with the code, ErrInvalidCode if it doesn't match
+	Confirm(ctx context.Context, user *User, code string) error
+}
+
+func (a *Auth) requiresSecondFactor(user *User) bool {
+	for _, role := range a.config.SecondFactorRoles {
+		if role == "*" || user.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// SecondFactors returns the names of the second factors the user enrolled
+func (a *Auth) SecondFactors(ctx context.Context, user *User) ([]string, error) {
+	var names []string
+	for _, factor := range a.factors {
+		enrolled, err := factor.Enrolled(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if enrolled {
+			names = append(names, factor.Name())
+		}
+	}
+	return names, nil
+}
+
+// startSession starts the session of a user who logged in, it is pending if the user has to verify a second factor.
+// It returns the second factors of the user
+func (a *Auth) startSession(w http.ResponseWriter, req *http.Request, user *User) ([]string, error) {
+	factors, err := a.SecondFactors(req.Context(), user)
+	if err != nil {
+		return nil, err
+	}
+	if len(factors) == 0 && !a.requiresSecondFactor(user) {
+		return nil, a.SetSession(w, user)
+	}
+
+	if err := a.setPendingSession(w, user, factors); err != nil {
+		return nil, err
+	}
+	return factors, ErrSecondFactorRequired
+}
+
+// GetPendingUser returns the user of the pending session of the request, and the names of the second factors it
+// enrolled. Users who have to verify a second factor but enrolled none have to enroll one, like with TOTP, before
+// they verify it
+func (a *Auth) GetPendingUser(req *http.Request) (*User, []string, error) {
+	s, err := a.getSession(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !s.Pending {
+		return nil, nil, ErrUnauthenticated
+	}
+	return s.User, s.Factors, nil
+}
+
+func (a *Auth) pendingFactor(req *http.Request, name string) (*User, SecondFactor, bool, error) {
+	user, factors, err := a.GetPendingUser(req)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	factor, ok := a.providers[name].(SecondFactor)
+	if !ok {
+		return nil, nil, false, ErrUnknownProvider
+	}
+
+	for _, enrolled := range factors {
+		if enrolled == name {
+			return user, factor, true, nil
+		}
+	}
+	// users who enrolled second factors verify one of them, they don't enroll others until they are logged in
+	if len(factors) > 0 {
+		return nil, nil, false, ErrUnknownProvider
+	}
+	return user, factor, false, nil
+}
+
+// Challenge challenges the user of the pending session of the request with the second factor, see Challenger
+func (a *Auth) Challenge(req *http.Request, name string) (interface{}, error) {
+	user, factor, _, err := a.pendingFactor(req, name)
+	if err != nil {
+		return nil, err
+	}
+	challenger, ok := factor.(Challenger)
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return challenger.Challenge(req.Context(), user)
+}
+
+// VerifySecondFactor verifies the code of the second factor of the user of the pending session of the request, and
+// completes its session. Users who enrolled no second factor confirm their enrollment of an Enroller with the code.
+// The pending session ends when the user entered MaxSecondFactorAttempts invalid codes, and ErrTooManyAttempts is
+// returned until its SecondFactorLockout is over
+func (a *Auth) VerifySecondFactor(w http.ResponseWriter, req *http.Request, name, code string) (*User, error) {
+	user, factor, enrolled, err := a.pendingFactor(req, name)
+	if err != nil {
+		return nil, err
+	}
+	if a.failures.locked(user.UniqueID()) {
+		a.Logout(w, req)
+		return nil, ErrTooManyAttempts
+	}
+
+	if enrolled {
+		var ok bool
+		if ok, err = factor.Verify(req.Context(), user, code); err == nil && !ok {
+			err = ErrInvalidCode
+		}
+	} else {
+		enroller, ok := factor.(Enroller)
+		if !ok {
+			return nil, ErrUnknownProvider
+		}
+		err = enroller.Confirm(req.Context(), user, code)
+	}
+	if err == ErrInvalidCode && a.failures.add(user.UniqueID(), a.config.MaxSecondFactorAttempts, a.config.SecondFactorLockout) {
+		a.Logout(w, req)
+		return nil, ErrTooManyAttempts
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a.failures.reset(user.UniqueID())
+	user.SecondFactor = name
+	return user, a.SetSession(w, user)
+}
+
+// failures counts the invalid codes of second factors of users, by their unique ID
+type failures struct {
+	mutex sync.Mutex
+	users map[string]*failure
+}
+
+type failure struct {
+	count       int
+	lastAt      time.Time
+	lockedUntil time.Time
+}
+
+func (f *failures) locked(userID string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	entry, ok := f.users[userID]
+	return ok && time.Now().Before(entry.lockedUntil)
+}
+
+// add counts an invalid code of the user, and returns true if it is locked out. Invalid codes are counted until the
+// user verifies a code, or for the duration of the lockout after the last one
+func (f *failures) add(userID string, max int, lockout time.Duration) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	now := time.Now()
+	if f.users == nil {
+		f.users = map[string]*failure{}
+	}
+	for id, entry := range f.users {
+		if now.Sub(entry.lastAt) >= lockout && !now.Before(entry.lockedUntil) {
+			delete(f.users, id)
+		}
+	}
+
+	entry, ok := f.users[userID]
+	if !ok {
+		entry = &failure{}
+		f.users[userID] = entry
+	}
+	entry.count++
+	entry.lastAt = now
+	if entry.count >= max {
+		entry.count = 0
+		entry.lockedUntil = now.Add(lockout)
+		return true
+	}
+	return false
+}
+
+func (f *failures) reset(userID string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	delete(f.users, userID)
+}
@@ -0,0 +1,131 @@
+package twofactor
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/application/pkg/auth"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// RecoveryCode is a single use code of a user who lost its other second factor, only its hash is stored
+type RecoveryCode struct {
+	ID        uint   `orm:"primary_key"`
+	UserID    string `orm:"size:255;index"`
+	Hash      string `orm:"size:64;unique_index"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// TableName table name of recovery codes
+func (RecoveryCode) TableName() string {
+	return "auth_recovery_codes"
+}
+
+// RecoveryCodes is the second factor of recovery codes, users who have unused codes enrolled it
+type RecoveryCodes struct {
+	DB *orm.DB
+	// Count is the number of codes generated, 10 by default
+	Count int
+}
+
+var _ auth.SecondFactor = &RecoveryCodes{}
+
+// NewRecoveryCodes initialize the recovery codes second factor, migrating the table of codes
+func NewRecoveryCodes(db *orm.DB) (*RecoveryCodes, error) {
+	if err := db.AutoMigrate(&RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	return &RecoveryCodes{DB: db, Count: 10}, nil
+}
+
+// Name returns "recovery"
+func (r *RecoveryCodes) Name() string {
+	return "recovery"
+}
+
+// Generate replaces the codes of the user with new ones, and returns them to be shown once to the user, e.g.
+// "k4xm2-pq7ta"
+func (r *RecoveryCodes) Generate(ctx context.Context, user *auth.User) ([]string, error) {
+	count := r.Count
+	if count <= 0 {
+		count = 10
+	}
+
+	codes := make([]string, count)
+	err := r.DB.Transaction(func(tx *orm.DB) error {
+		if err := tx.Where("user_id = ?", user.UniqueID()).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		for i := range codes {
+			random := make([]byte, 7)
+			if _, err := rand.Read(random); err != nil {
+				return err
+			}
+			code := strings.ToLower(base32Encoding.EncodeToString(random))[:10]
+			codes[i] = code[:5] + "-" + code[5:]
+			if err := tx.Create(&RecoveryCode{UserID: user.UniqueID(), Hash: hashRecoveryCode(user, code)}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Remaining returns the number of unused codes of the user
+func (r *RecoveryCodes) Remaining(ctx context.Context, user *auth.User) (int, error) {
+	var count int
+	err := r.DB.Model(&RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.UniqueID()).Count(&count).Error
+	return count, err
+}
+
+// Enrolled returns true if the user has unused codes
+func (r *RecoveryCodes) Enrolled(ctx context.Context, user *auth.User) (bool, error) {
+	count, err := r.Remaining(ctx, user)
+	return count > 0, err
+}
+
+// Verify returns true if the code is an unused code of the user, and marks it used
+func (r *RecoveryCodes) Verify(ctx context.Context, user *auth.User, code string) (bool, error) {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != 10 {
+		return false, nil
+	}
+
+	db := r.DB.Model(&RecoveryCode{}).Where("user_id = ? AND hash = ? AND used_at IS NULL", user.UniqueID(), hashRecoveryCode(user, code)).
+		UpdateColumn("used_at", time.Now())
+	return db.RowsAffected == 1, db.Error
+}
+
+func hashRecoveryCode(user *auth.User, code string) string {
+	sum := sha256.Sum256([]byte(user.UniqueID() + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,216 @@
+package twofactor
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/application/pkg/auth"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// ErrAlreadyEnrolled is returned when enrolling a user who already enrolled the second factor
+var ErrAlreadyEnrolled = errors.New("twofactor: already enrolled")
+
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	// totpSkew is the number of periods codes are accepted before and after the current one, for clock drift
+	totpSkew = 1
+)
+
+var base32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPSecret is the secret of the time-based one-time passwords of a user, see RFC 6238
+type TOTPSecret struct {
+	ID     uint   `orm:"primary_key"`
+	UserID string `orm:"size:255;unique_index"`
+	// Secret is the key, encrypted with the key of TOTP
+	Secret    string `orm:"size:255"`
+	Confirmed bool
+	// Counter is the time step of the last code verified, codes can't be verified twice
+	Counter   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName table name of TOTP secrets
+func (TOTPSecret) TableName() string {
+	return "auth_totp_secrets"
+}
+
+// TOTP is the second factor of time-based one-time passwords of authenticator apps
+type TOTP struct {
+	DB *orm.DB
+	// Issuer is the name of the application in authenticator apps
+	Issuer string
+	// Key encrypts the secrets in the database with AES-GCM, like the SessionKey of the config of Auth
+	Key []byte
+}
+
+var _ auth.Enroller = &TOTP{}
+
+// NewTOTP initialize the TOTP second factor, migrating the table of secrets, which are encrypted with key
+func NewTOTP(db *orm.DB, issuer string, key []byte) (*TOTP, error) {
+	if err := db.AutoMigrate(&TOTPSecret{}).Error; err != nil {
+		return nil, err
+	}
+	return &TOTP{DB: db, Issuer: issuer, Key: key}, nil
+}
+
+// Name returns "totp"
+func (t *TOTP) Name() string {
+	return "totp"
+}
+
+// Enroll generates a secret for the user, and returns it with its otpauth URI, for the QR code scanned by
+// authenticator apps. The enrollment is confirmed with the first code of the app, see Confirm
+func (t *TOTP) Enroll(ctx context.Context, user *auth.User) (string, string, error) {
+	key := make([]byte, 20)
+	if _, err := rand.Read(key); err != nil {
+		return "", "", err
+	}
+	secret := base32Encoding.EncodeToString(key)
+	encrypted, err := auth.Encrypt(t.Key, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = t.DB.Transaction(func(tx *orm.DB) error {
+		var existing TOTPSecret
+		err := tx.Where("user_id = ?", user.UniqueID()).First(&existing).Error
+		switch {
+		case err == nil && existing.Confirmed:
+			return ErrAlreadyEnrolled
+		case err == nil:
+			return tx.Model(&existing).Updates(map[string]interface{}{"secret": encrypted, "counter": 0}).Error
+		case errors.Is(err, orm.ErrRecordNotFound):
+			return tx.Create(&TOTPSecret{UserID: user.UniqueID(), Secret: encrypted}).Error
+		default:
+			return err
+		}
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	label := user.Email
+	if label == "" {
+		label = user.DisplayName()
+	}
+	if t.Issuer != "" {
+		label = t.Issuer + ":" + label
+	}
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + label,
+		RawQuery: url.Values{"secret": {secret}, "issuer": {t.Issuer}, "period": {fmt.Sprint(totpPeriod)}, "digits": {fmt.Sprint(totpDigits)}}.Encode(),
+	}
+	return secret, uri.String(), nil
+}
+
+// Confirm confirms the enrollment of the user with the first code of its authenticator app
+func (t *TOTP) Confirm(ctx context.Context, user *auth.User, code string) error {
+	ok, err := t.verify(user, code, false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return auth.ErrInvalidCode
+	}
+	return nil
+}
+
+// Enrolled returns true if the user confirmed its enrollment
+func (t *TOTP) Enrolled(ctx context.Context, user *auth.User) (bool, error) {
+	var count int
+	err := t.DB.Model(&TOTPSecret{}).Where("user_id = ? AND confirmed = ?", user.UniqueID(), true).Count(&count).Error
+	return count > 0, err
+}
+
+// Verify returns true if the code matches one of the current period of the secret of the user, and wasn't verified
+// before
+func (t *TOTP) Verify(ctx context.Context, user *auth.User, code string) (bool, error) {
+	return t.verify(user, code, true)
+}
+
+// Disable removes the secret of the user
+func (t *TOTP) Disable(ctx context.Context, user *auth.User) error {
+	return t.DB.Where("user_id = ?", user.UniqueID()).Delete(&TOTPSecret{}).Error
+}
+
+func (t *TOTP) verify(user *auth.User, code string, confirmed bool) (bool, error) {
+	var secret TOTPSecret
+	if err := t.DB.Where("user_id = ? AND confirmed = ?", user.UniqueID(), confirmed).First(&secret).Error; err != nil {
+		if errors.Is(err, orm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	key, err := auth.Decrypt(t.Key, secret.Secret)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now().Unix() / totpPeriod
+	for counter := now - totpSkew; counter <= now+totpSkew; counter++ {
+		if counter <= secret.Counter || !hmac.Equal([]byte(totpCode(key, counter)), []byte(strings.TrimSpace(code))) {
+			continue
+		}
+		// the counter is only moved forward, so that concurrent verifications of the code can't both succeed
+		db := t.DB.Model(&TOTPSecret{}).Where("id = ? AND counter < ?", secret.ID, counter).UpdateColumns(map[string]interface{}{"counter": counter, "confirmed": true})
+		return db.RowsAffected == 1, db.Error
+	}
+	return false, nil
+}
+
+// TOTPCode returns the code of the secret at the time, as authenticator apps compute it
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := base32Encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+	return totpCode(key, t.Unix()/totpPeriod), nil
+}
+
+// totpCode computes the HOTP value of the counter, see RFC 4226
+func totpCode(key []byte, counter int64) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
@@ -0,0 +1,197 @@
+package twofactor_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bhojpur/application/pkg/auth"
+	"github.com/bhojpur/application/pkg/auth/twofactor"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type provider struct{}
+
+func (provider) Name() string { return "static" }
+
+func (provider) Authenticate(ctx context.Context, username, password string) (*auth.User, error) {
+	if password != "secret" {
+		return nil, auth.ErrInvalidCredentials
+	}
+	return &auth.User{ID: username, Roles: []string{"admin"}}, nil
+}
+
+func openDB(t *testing.T) *orm.DB {
+	db := utils.SQLiteTestDB(t)
+	// the tables are created here, as the ones migrated with sqlite have no auto-increment primary key
+	require.NoError(t, db.Exec(`CREATE TABLE auth_totp_secrets (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id TEXT UNIQUE, secret TEXT,
+		confirmed BOOLEAN, counter INTEGER, created_at DATETIME, updated_at DATETIME)`).Error)
+	require.NoError(t, db.Exec(`CREATE TABLE auth_recovery_codes (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id TEXT, hash TEXT UNIQUE,
+		used_at DATETIME, created_at DATETIME)`).Error)
+	return db
+}
+
+func post(a *auth.Auth, path string, form url.Values, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, req)
+	return w
+}
+
+func TestTOTP(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	totp := &twofactor.TOTP{DB: db, Issuer: "Shop", Key: []byte("0123456789abcdef")}
+	a := auth.New(auth.Config{SessionKey: []byte("0123456789abcdef"), SecondFactorRoles: []string{"admin"}}, provider{}, totp)
+
+	// admins have to enroll a second factor before they are logged in
+	login := post(a, "/login", url.Values{"provider": {"static"}, "username": {"jane"}, "password": {"secret"}})
+	require.Equal(t, http.StatusUnauthorized, login.Code)
+	assert.JSONEq(t, `{"secondFactors": []}`, login.Body.String())
+	pending := login.Result().Cookies()[0]
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(pending)
+	user, factors, err := a.GetPendingUser(req)
+	require.NoError(t, err)
+	assert.Empty(t, factors)
+	_, err = a.GetSession(req)
+	assert.Equal(t, auth.ErrUnauthenticated, err)
+
+	secret, uri, err := totp.Enroll(ctx, user)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Shop:jane?"))
+	assert.Contains(t, uri, "secret="+secret)
+
+	// secrets are encrypted in the database
+	var stored twofactor.TOTPSecret
+	require.NoError(t, db.Where("user_id = ?", user.UniqueID()).First(&stored).Error)
+	assert.NotContains(t, stored.Secret, secret)
+	other := &twofactor.TOTP{DB: db, Key: []byte("fedcba9876543210")}
+	err = other.Confirm(ctx, user, "000000")
+	assert.Equal(t, auth.ErrUnauthenticated, err)
+
+	assert.Equal(t, http.StatusUnauthorized, post(a, "/2fa/totp/verify", url.Values{"code": {"000000"}}, pending).Code)
+	code, err := twofactor.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	verified := post(a, "/2fa/totp/verify", url.Values{"code": {code}}, pending)
+	require.Equal(t, http.StatusOK, verified.Code)
+	assert.Contains(t, verified.Body.String(), `"secondFactor":"totp"`)
+
+	enrolled, err := totp.Enrolled(ctx, user)
+	require.NoError(t, err)
+	assert.True(t, enrolled)
+	_, _, err = totp.Enroll(ctx, user)
+	assert.Equal(t, twofactor.ErrAlreadyEnrolled, err)
+
+	// codes can't be verified twice
+	login = post(a, "/login", url.Values{"provider": {"static"}, "username": {"jane"}, "password": {"secret"}})
+	assert.JSONEq(t, `{"secondFactors": ["totp"]}`, login.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, post(a, "/2fa/totp/verify", url.Values{"code": {code}}, login.Result().Cookies()[0]).Code)
+
+	next, err := twofactor.TOTPCode(secret, time.Now().Add(30*time.Second))
+	require.NoError(t, err)
+	ok, err := totp.Verify(ctx, user, next)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestSecondFactorLockout(t *testing.T) {
+	ctx := context.Background()
+	totp := &twofactor.TOTP{DB: openDB(t), Key: []byte("0123456789abcdef")}
+	a := auth.New(auth.Config{SessionKey: []byte("0123456789abcdef"), SecondFactorRoles: []string{"admin"},
+		MaxSecondFactorAttempts: 3}, provider{}, totp)
+
+	login := func(username string) *http.Cookie {
+		return post(a, "/login", url.Values{"provider": {"static"}, "username": {username}, "password": {"secret"}}).Result().Cookies()[0]
+	}
+	pending := login("jane")
+	secret, _, err := totp.Enroll(ctx, &auth.User{ID: "jane", Provider: "static"})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusUnauthorized, post(a, "/2fa/totp/verify", url.Values{"code": {"000000"}}, pending).Code)
+	assert.Equal(t, http.StatusUnauthorized, post(a, "/2fa/totp/verify", url.Values{"code": {"000000"}}, pending).Code)
+	locked := post(a, "/2fa/totp/verify", url.Values{"code": {"000000"}}, pending)
+	assert.Equal(t, http.StatusTooManyRequests, locked.Code)
+	// the pending session is ended
+	cookies := locked.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Empty(t, cookies[0].Value)
+	assert.Less(t, cookies[0].MaxAge, 0)
+
+	// valid codes are rejected until the lockout is over, even with a new pending session
+	code, err := twofactor.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, post(a, "/2fa/totp/verify", url.Values{"code": {code}}, login("jane")).Code)
+
+	// other users aren't locked out
+	assert.Equal(t, http.StatusUnauthorized, post(a, "/2fa/totp/verify", url.Values{"code": {"000000"}}, login("john")).Code)
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	ctx := context.Background()
+	recovery := &twofactor.RecoveryCodes{DB: openDB(t), Count: 3}
+	user := &auth.User{ID: "jane", Provider: "static"}
+
+	enrolled, err := recovery.Enrolled(ctx, user)
+	require.NoError(t, err)
+	assert.False(t, enrolled)
+
+	codes, err := recovery.Generate(ctx, user)
+	require.NoError(t, err)
+	require.Len(t, codes, 3)
+	assert.Len(t, codes[0], 11)
+
+	ok, err := recovery.Verify(ctx, user, strings.ToUpper(codes[1]))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = recovery.Verify(ctx, user, codes[1])
+	require.NoError(t, err)
+	assert.False(t, ok, "codes are single use")
+	ok, err = recovery.Verify(ctx, &auth.User{ID: "john", Provider: "static"}, codes[0])
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	remaining, err := recovery.Remaining(ctx, user)
+	require.NoError(t, err)
+	assert.Equal(t, 2, remaining)
+
+	// new codes replace the previous ones
+	_, err = recovery.Generate(ctx, user)
+	require.NoError(t, err)
+	ok, err = recovery.Verify(ctx, user, codes[0])
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,216 @@
+package branding
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.branding")
+
+// Branding is the branding of a tenant, managed at runtime with NewResource. The record without a tenant
+// is the default branding, the blank fields of a tenant's branding fall back to it.
+type Branding struct {
+	ID uint `orm:"primary_key"`
+	// Tenant is the tenant the branding applies to, the default branding if empty.
+	Tenant         string `orm:"size:128;unique_index"`
+	ProductName    string `orm:"size:128"`
+	LogoURL        string `orm:"size:1024"`
+	FaviconURL     string `orm:"size:1024"`
+	PrimaryColor   string `orm:"size:16"`
+	SecondaryColor string `orm:"size:16"`
+	AccentColor    string `orm:"size:16"`
+	// EmailFooter is the HTML footer of the emails sent to the tenant's users.
+	EmailFooter  string `orm:"type:text"`
+	SupportEmail string `orm:"size:256"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// TableName table name of brandings
+func (Branding) TableName() string {
+	return "brandings"
+}
+
+// Theme is the resolved branding of a tenant, as exposed to the UI and API templates, email templates
+// and PDF rendering.
+type Theme struct {
+	Tenant         string `json:"tenant,omitempty"`
+	ProductName    string `json:"productName"`
+	LogoURL        string `json:"logoURL,omitempty"`
+	FaviconURL     string `json:"faviconURL,omitempty"`
+	PrimaryColor   string `json:"primaryColor,omitempty"`
+	SecondaryColor string `json:"secondaryColor,omitempty"`
+	AccentColor    string `json:"accentColor,omitempty"`
+	EmailFooter    string `json:"emailFooter,omitempty"`
+	SupportEmail   string `json:"supportEmail,omitempty"`
+}
+
+// Merge returns the theme with the non blank fields of branding applied over it.
+func (theme Theme) Merge(branding Branding) Theme {
+	merge := func(value *string, override string) {
+		if override != "" {
+			*value = override
+		}
+	}
+
+	merge(&theme.ProductName, branding.ProductName)
+	merge(&theme.LogoURL, branding.LogoURL)
+	merge(&theme.FaviconURL, branding.FaviconURL)
+	merge(&theme.PrimaryColor, branding.PrimaryColor)
+	merge(&theme.SecondaryColor, branding.SecondaryColor)
+	merge(&theme.AccentColor, branding.AccentColor)
+	merge(&theme.EmailFooter, branding.EmailFooter)
+	merge(&theme.SupportEmail, branding.SupportEmail)
+	if branding.Tenant != "" {
+		theme.Tenant = branding.Tenant
+	}
+	return theme
+}
+
+// FuncMap returns the template functions exposing the theme, for html/template and text/template,
+// like `{{ branding.ProductName }}` and `{{ brandingFooter }}` in email templates.
+func (theme Theme) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"branding": func() Theme {
+			return theme
+		},
+		// the footer is HTML authored by the administrators
+		"brandingFooter": func() template.HTML {
+			return template.HTML(theme.EmailFooter)
+		},
+	}
+}
+
+// Provider resolves the theme of tenants.
+type Provider interface {
+	Theme(tenant string) (Theme, error)
+}
+
+// Store resolves the themes from the brandings stored in db, caching them for the TTL.
+// The table of Branding has to be migrated.
+type Store struct {
+	// Defaults is the theme the default branding is applied over.
+	Defaults Theme
+	// TTL is how long themes are cached, 1 minute by default.
+	TTL time.Duration
+
+	db    *orm.DB
+	lock  sync.RWMutex
+	cache map[string]cachedTheme
+}
+
+type cachedTheme struct {
+	theme   Theme
+	expires time.Time
+}
+
+// New returns a store resolving the themes from the brandings in db, over defaults.
+func New(db *orm.DB, defaults Theme) *Store {
+	return &Store{Defaults: defaults, TTL: time.Minute, db: db, cache: map[string]cachedTheme{}}
+}
+
+// Theme returns the theme of tenant: the tenant's branding applied over the default branding.
+func (store *Store) Theme(tenant string) (Theme, error) {
+	store.lock.RLock()
+	cached, ok := store.cache[tenant]
+	store.lock.RUnlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.theme, nil
+	}
+
+	var brandings []Branding
+	tenants := []string{""}
+	if tenant != "" {
+		tenants = append(tenants, tenant)
+	}
+	if err := store.db.Where("tenant IN (?)", tenants).Find(&brandings).Error; err != nil {
+		return store.Defaults, err
+	}
+
+	theme := store.Defaults
+	// the default branding is applied first
+	for _, branding := range brandings {
+		if branding.Tenant == "" {
+			theme = theme.Merge(branding)
+		}
+	}
+	for _, branding := range brandings {
+		if branding.Tenant != "" {
+			theme = theme.Merge(branding)
+		}
+	}
+	theme.Tenant = tenant
+
+	ttl := store.TTL
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	store.lock.Lock()
+	store.cache[tenant] = cachedTheme{theme: theme, expires: time.Now().Add(ttl)}
+	store.lock.Unlock()
+	return theme, nil
+}
+
+// Invalidate drops the cached themes, so that the changes of brandings apply immediately.
+func (store *Store) Invalidate() {
+	store.lock.Lock()
+	store.cache = map[string]cachedTheme{}
+	store.lock.Unlock()
+}
+
+// TenantExtractor returns the tenant of a request, e.g. from its host or a header.
+type TenantExtractor func(req *http.Request) string
+
+type contextKey struct{}
+
+// Middleware resolves the theme of the tenant of each request, and adds it to the request context.
+// Requests are served with the theme of the default branding if it can't be resolved.
+func Middleware(provider Provider, extractor TenantExtractor) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			tenant := extractor(req)
+			theme, err := provider.Theme(tenant)
+			if err != nil {
+				log.Warnf("failed to resolve branding of tenant %q: %s", tenant, err)
+			}
+			next.ServeHTTP(w, req.WithContext(NewContext(req.Context(), theme)))
+		})
+	}
+}
+
+// NewContext returns a context carrying theme.
+func NewContext(ctx context.Context, theme Theme) context.Context {
+	return context.WithValue(ctx, contextKey{}, theme)
+}
+
+// FromContext returns the theme of the context, false if it carries none.
+func FromContext(ctx context.Context) (Theme, bool) {
+	theme, ok := ctx.Value(contextKey{}).(Theme)
+	return theme, ok
+}
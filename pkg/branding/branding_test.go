@@ -0,0 +1,91 @@
+package branding_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bhojpur/application/pkg/branding"
+)
+
+type providerFunc func(tenant string) (branding.Theme, error)
+
+func (f providerFunc) Theme(tenant string) (branding.Theme, error) {
+	return f(tenant)
+}
+
+func TestMerge(t *testing.T) {
+	defaults := branding.Theme{ProductName: "Bhojpur", PrimaryColor: "#000000", EmailFooter: "Sent by Bhojpur"}
+
+	theme := defaults.Merge(branding.Branding{Tenant: "acme", ProductName: "Acme", LogoURL: "https://acme.com/logo.png"})
+	assert.Equal(t, "acme", theme.Tenant)
+	assert.Equal(t, "Acme", theme.ProductName)
+	assert.Equal(t, "https://acme.com/logo.png", theme.LogoURL)
+	assert.Equal(t, "#000000", theme.PrimaryColor)
+	assert.Equal(t, "Sent by Bhojpur", theme.EmailFooter)
+}
+
+func TestFuncMap(t *testing.T) {
+	theme := branding.Theme{ProductName: "Acme", EmailFooter: "<b>Acme Inc.</b>"}
+
+	tmpl, err := template.New("email").Funcs(template.FuncMap(theme.FuncMap())).Parse(`Welcome to {{ branding.ProductName }}. {{ brandingFooter }}`)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, tmpl.Execute(&out, nil))
+	assert.Equal(t, "Welcome to Acme. <b>Acme Inc.</b>", out.String())
+}
+
+func TestMiddleware(t *testing.T) {
+	provider := providerFunc(func(tenant string) (branding.Theme, error) {
+		if tenant == "broken" {
+			return branding.Theme{ProductName: "Bhojpur"}, errors.New("database is down")
+		}
+		return branding.Theme{Tenant: tenant, ProductName: "Acme"}, nil
+	})
+	extractor := func(req *http.Request) string {
+		return req.Header.Get("X-Tenant")
+	}
+
+	var theme branding.Theme
+	handler := branding.Middleware(provider, extractor)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var ok bool
+		theme, ok = branding.FromContext(req.Context())
+		assert.True(t, ok)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Tenant", "acme")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, branding.Theme{Tenant: "acme", ProductName: "Acme"}, theme)
+
+	req.Header.Set("X-Tenant", "broken")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "Bhojpur", theme.ProductName)
+}
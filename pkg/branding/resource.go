@@ -0,0 +1,89 @@
+package branding
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/validations"
+)
+
+var colorRegexp = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// NewResource returns the Branding resource, to manage brandings at runtime, like from the admin.
+// Its records are validated: the default branding requires a product name, the colors have to be
+// hex colors and the support email a valid address. The cached themes of store are invalidated
+// when a branding is saved, store may be nil.
+func NewResource(store *Store) *resource.Resource {
+	res := resource.New(&Branding{})
+
+	res.AddValidator(&resource.Validator{
+		Name: "branding",
+		Handler: func(record interface{}, metaValues *resource.MetaValues, context *appsvr.Context) error {
+			var (
+				branding = record.(*Branding)
+				errs     validations.Errors
+			)
+
+			if branding.Tenant == "" && strings.TrimSpace(branding.ProductName) == "" {
+				errs.Add(validations.NewFieldError(branding, "ProductName", validations.CodeRequired, "Product name of the default branding can't be blank", nil))
+			}
+
+			for column, color := range map[string]string{
+				"PrimaryColor":   branding.PrimaryColor,
+				"SecondaryColor": branding.SecondaryColor,
+				"AccentColor":    branding.AccentColor,
+			} {
+				if color != "" && !colorRegexp.MatchString(color) {
+					errs.Add(validations.NewFieldError(branding, column, validations.CodeInvalid, column+" should be a hex color like #1a2b3c", nil))
+				}
+			}
+
+			if branding.SupportEmail != "" {
+				if _, err := mail.ParseAddress(branding.SupportEmail); err != nil {
+					errs.Add(validations.NewFieldError(branding, "SupportEmail", validations.CodeEmail, "Support email is invalid", nil))
+				}
+			}
+
+			return errs.ErrorOrNil()
+		},
+	})
+
+	if store != nil {
+		saveHandler := res.SaveHandler
+		res.SaveHandler = func(record interface{}, context *appsvr.Context) error {
+			err := saveHandler(record, context)
+			store.Invalidate()
+			return err
+		}
+		deleteHandler := res.DeleteHandler
+		res.DeleteHandler = func(record interface{}, context *appsvr.Context) error {
+			err := deleteHandler(record, context)
+			store.Invalidate()
+			return err
+		}
+	}
+	return res
+}
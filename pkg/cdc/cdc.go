@@ -0,0 +1,213 @@
+package cdc
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// Operations of changes
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// Config configures the capture of the changes of a resource.
+type Config struct {
+	// Columns are the captured columns, by field or column name.
+	Columns []string
+	// SchemaVersion is the version of the payload schema, bump it when changing the columns.
+	SchemaVersion int
+}
+
+// Change is the payload of a captured change, values are keyed by column name. Consumers have to be idempotent,
+// using the ID, since changes are delivered at least once.
+type Change struct {
+	ID             uint                   `json:"id"`
+	SchemaVersion  int                    `json:"schemaVersion"`
+	Resource       string                 `json:"resource"`
+	Table          string                 `json:"table"`
+	Operation      string                 `json:"operation"`
+	PrimaryKey     string                 `json:"primaryKey"`
+	Before         map[string]interface{} `json:"before,omitempty"`
+	After          map[string]interface{} `json:"after,omitempty"`
+	ChangedColumns []string               `json:"changedColumns,omitempty"`
+	Timestamp      time.Time              `json:"timestamp"`
+}
+
+// Outbox is a captured change, written in the transaction of the save or delete, until it is published.
+type Outbox struct {
+	ID          uint       `orm:"primary_key"`
+	Resource    string     `orm:"size:128"`
+	Payload     string     `orm:"type:text"`
+	PublishedAt *time.Time `orm:"index"`
+	Attempts    int
+	Error       string `orm:"type:text"`
+	CreatedAt   time.Time
+}
+
+// TableName table name of the outbox
+func (Outbox) TableName() string {
+	return "cdc_outbox"
+}
+
+// Capture captures the changes of the columns of a resource on every save and delete. The changes are written to
+// the outbox in the transaction of the operation, and published by a Relay. The table of Outbox has to be migrated.
+func Capture(res *resource.Resource, config Config) error {
+	if len(config.Columns) == 0 {
+		return fmt.Errorf("cdc: no columns configured for resource %s", res.Name)
+	}
+
+	scope := &orm.Scope{Value: res.NewStruct()}
+	for _, column := range config.Columns {
+		if _, ok := scope.FieldByName(column); !ok {
+			return fmt.Errorf("cdc: %s is not a column of resource %s", column, res.Name)
+		}
+	}
+
+	saveHandler, deleteHandler := res.SaveHandler, res.DeleteHandler
+
+	res.SaveHandler = func(result interface{}, context *appsvr.Context) error {
+		return inTransaction(context, func(txContext *appsvr.Context) error {
+			var (
+				db        = txContext.GetDB()
+				operation = OperationCreate
+				before    map[string]interface{}
+			)
+
+			if !db.NewScope(result).PrimaryKeyZero() {
+				previous := res.NewStruct()
+				err := db.Where(primaryKeyQuery(db, result)).First(previous).Error
+				if err == nil {
+					operation, before = OperationUpdate, columnValues(db, previous, config.Columns)
+				} else if !errors.Is(err, orm.ErrRecordNotFound) {
+					return err
+				}
+			}
+
+			if err := saveHandler(result, txContext); err != nil {
+				return err
+			}
+			return writeChange(txContext, res, config, operation, result, before, columnValues(db, result, config.Columns))
+		})
+	}
+
+	res.DeleteHandler = func(result interface{}, context *appsvr.Context) error {
+		return inTransaction(context, func(txContext *appsvr.Context) error {
+			// the delete handler loads the record before deleting it
+			if err := deleteHandler(result, txContext); err != nil {
+				return err
+			}
+			return writeChange(txContext, res, config, OperationDelete, result, columnValues(txContext.GetDB(), result, config.Columns), nil)
+		})
+	}
+	return nil
+}
+
+func inTransaction(context *appsvr.Context, fc func(*appsvr.Context) error) error {
+	return context.GetDB().Transaction(func(db *orm.DB) error {
+		txContext := context.Clone()
+		txContext.SetDB(db)
+		return fc(txContext)
+	})
+}
+
+func writeChange(context *appsvr.Context, res *resource.Resource, config Config, operation string, record interface{}, before, after map[string]interface{}) error {
+	db := context.GetDB()
+	scope := db.NewScope(record)
+
+	change := Change{
+		SchemaVersion:  config.SchemaVersion,
+		Resource:       res.Name,
+		Table:          scope.TableName(),
+		Operation:      operation,
+		PrimaryKey:     fmt.Sprint(scope.PrimaryKeyValue()),
+		Before:         before,
+		After:          after,
+		ChangedColumns: ChangedColumns(before, after),
+		Timestamp:      time.Now().UTC(),
+	}
+
+	outbox := &Outbox{Resource: res.Name}
+	if err := db.Create(outbox).Error; err != nil {
+		return err
+	}
+
+	change.ID = outbox.ID
+	payload, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	return db.Model(outbox).UpdateColumn("payload", string(payload)).Error
+}
+
+// ChangedColumns returns the columns whose values differ between before and after, sorted by name.
+func ChangedColumns(before, after map[string]interface{}) []string {
+	columns := map[string]bool{}
+	for column := range before {
+		columns[column] = true
+	}
+	for column := range after {
+		columns[column] = true
+	}
+
+	var changed []string
+	for column := range columns {
+		beforeValue, inBefore := before[column]
+		afterValue, inAfter := after[column]
+		if inBefore != inAfter || !reflect.DeepEqual(beforeValue, afterValue) {
+			changed = append(changed, column)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// columnValues returns the values of the columns of a record, keyed by column name
+func columnValues(db *orm.DB, record interface{}, columns []string) map[string]interface{} {
+	scope := db.NewScope(record)
+	values := map[string]interface{}{}
+	for _, column := range columns {
+		if field, ok := scope.FieldByName(column); ok {
+			values[field.DBName] = field.Field.Interface()
+		}
+	}
+	return values
+}
+
+// primaryKeyQuery returns the conditions of the primary keys of a record
+func primaryKeyQuery(db *orm.DB, record interface{}) map[string]interface{} {
+	conditions := map[string]interface{}{}
+	for _, field := range db.NewScope(record).PrimaryFields() {
+		conditions[field.DBName] = field.Field.Interface()
+	}
+	return conditions
+}
@@ -0,0 +1,52 @@
+package cdc_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	"github.com/bhojpur/application/pkg/cdc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedColumns(t *testing.T) {
+	t.Run("update", func(t *testing.T) {
+		before := map[string]interface{}{"name": "a", "price": 10, "code": "x"}
+		after := map[string]interface{}{"name": "b", "price": 10, "code": "y"}
+		assert.Equal(t, []string{"code", "name"}, cdc.ChangedColumns(before, after))
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		values := map[string]interface{}{"name": "a"}
+		assert.Empty(t, cdc.ChangedColumns(values, map[string]interface{}{"name": "a"}))
+	})
+
+	t.Run("create and delete", func(t *testing.T) {
+		values := map[string]interface{}{"name": "a", "price": 10}
+		assert.Equal(t, []string{"name", "price"}, cdc.ChangedColumns(nil, values))
+		assert.Equal(t, []string{"name", "price"}, cdc.ChangedColumns(values, nil))
+	})
+}
+
+func TestTopic(t *testing.T) {
+	assert.Equal(t, "cdc.products", cdc.Topic("cdc.{resource}", "products"))
+	assert.Equal(t, "changes", cdc.Topic("changes", "products"))
+}
@@ -0,0 +1,146 @@
+package cdc
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/bhojpur/service/pkg/pubsub"
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.cdc")
+
+// RelayConfig configures a Relay.
+type RelayConfig struct {
+	PubSub     pubsub.PubSub
+	PubsubName string
+	// Topic is the topic of the changes, it could contain the resource, like "cdc.{resource}".
+	Topic string
+	// BatchSize is the number of changes published on every poll, 100 by default.
+	BatchSize int
+	// PollInterval is the interval between polls of the outbox, 1 second by default.
+	PollInterval time.Duration
+}
+
+// Relay publishes the changes of the outbox to the pub/sub component, in the order they were captured. A change is
+// marked as published only once the component accepted it, a failed change is retried on the next poll, so changes
+// are delivered at least once.
+type Relay struct {
+	db     *orm.DB
+	config RelayConfig
+}
+
+// NewRelay creates a relay of the outbox of the database.
+func NewRelay(db *orm.DB, config RelayConfig) *Relay {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	return &Relay{db: db, config: config}
+}
+
+// Run publishes the changes of the outbox until the context is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Flush(ctx); err != nil {
+			log.Errorf("failed to publish changes: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush publishes a batch of unpublished changes and returns the number of published ones. It stops at the first
+// failure, so the following changes of the batch aren't published before it.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	var outboxes []Outbox
+	if err := r.db.Where("published_at IS NULL").Order("id").Limit(r.config.BatchSize).Find(&outboxes).Error; err != nil {
+		return 0, err
+	}
+
+	for idx := range outboxes {
+		if ctx.Err() != nil {
+			return idx, nil
+		}
+
+		outbox := &outboxes[idx]
+		if err := r.publish(outbox); err != nil {
+			r.db.Model(outbox).UpdateColumns(map[string]interface{}{"attempts": outbox.Attempts + 1, "error": err.Error()})
+			return idx, err
+		}
+
+		now := time.Now()
+		if err := r.db.Model(outbox).UpdateColumns(map[string]interface{}{"attempts": outbox.Attempts + 1, "error": "", "published_at": &now}).Error; err != nil {
+			// the change was published, it will be published again on the next poll
+			return idx, err
+		}
+	}
+	return len(outboxes), nil
+}
+
+// Drain publishes the unpublished changes until the outbox is empty or ctx is done. It is meant to run on shutdown,
+// so that the last captured changes are not delayed until the next instance starts relaying.
+func (r *Relay) Drain(ctx context.Context) error {
+	for {
+		published, err := r.Flush(ctx)
+		if err != nil {
+			return err
+		}
+		if published < r.config.BatchSize || ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// Purge deletes the changes published before the time.
+func (r *Relay) Purge(before time.Time) error {
+	return r.db.Where("published_at < ?", before).Delete(&Outbox{}).Error
+}
+
+func (r *Relay) publish(outbox *Outbox) error {
+	contentType := "application/json"
+	return r.config.PubSub.Publish(&pubsub.PublishRequest{
+		Data:        []byte(outbox.Payload),
+		PubsubName:  r.config.PubsubName,
+		Topic:       Topic(r.config.Topic, outbox.Resource),
+		ContentType: &contentType,
+		Metadata:    map[string]string{"cdcChangeID": strconv.FormatUint(uint64(outbox.ID), 10)},
+	})
+}
+
+// Topic expands the {resource} placeholder of a topic with the resource.
+func Topic(topic string, resource string) string {
+	return strings.ReplaceAll(topic, "{resource}", resource)
+}
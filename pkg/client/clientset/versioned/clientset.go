@@ -31,12 +31,14 @@ import (
 
 	componentsv1alpha1 "github.com/bhojpur/application/pkg/client/clientset/versioned/typed/components/v1alpha1"
 	configurationv1alpha1 "github.com/bhojpur/application/pkg/client/clientset/versioned/typed/configuration/v1alpha1"
+	subscriptionsv1alpha1 "github.com/bhojpur/application/pkg/client/clientset/versioned/typed/subscriptions/v1alpha1"
 )
 
 type Interface interface {
 	Discovery() discovery.DiscoveryInterface
 	ComponentsV1alpha1() componentsv1alpha1.ComponentsV1alpha1Interface
 	ConfigurationV1alpha1() configurationv1alpha1.ConfigurationV1alpha1Interface
+	SubscriptionsV1alpha1() subscriptionsv1alpha1.SubscriptionsV1alpha1Interface
 }
 
 // Clientset contains the clients for groups. Each group has exactly one
@@ -45,6 +47,7 @@ type Clientset struct {
 	*discovery.DiscoveryClient
 	componentsV1alpha1    *componentsv1alpha1.ComponentsV1alpha1Client
 	configurationV1alpha1 *configurationv1alpha1.ConfigurationV1alpha1Client
+	subscriptionsV1alpha1 *subscriptionsv1alpha1.SubscriptionsV1alpha1Client
 }
 
 // ComponentsV1alpha1 retrieves the ComponentsV1alpha1Client
@@ -57,6 +60,11 @@ func (c *Clientset) ConfigurationV1alpha1() configurationv1alpha1.ConfigurationV
 	return c.configurationV1alpha1
 }
 
+// SubscriptionsV1alpha1 retrieves the SubscriptionsV1alpha1Client
+func (c *Clientset) SubscriptionsV1alpha1() subscriptionsv1alpha1.SubscriptionsV1alpha1Interface {
+	return c.subscriptionsV1alpha1
+}
+
 // Discovery retrieves the DiscoveryClient
 func (c *Clientset) Discovery() discovery.DiscoveryInterface {
 	if c == nil {
@@ -86,6 +94,10 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 	if err != nil {
 		return nil, err
 	}
+	cs.subscriptionsV1alpha1, err = subscriptionsv1alpha1.NewForConfig(&configShallowCopy)
+	if err != nil {
+		return nil, err
+	}
 
 	cs.DiscoveryClient, err = discovery.NewDiscoveryClientForConfig(&configShallowCopy)
 	if err != nil {
@@ -100,6 +112,7 @@ func NewForConfigOrDie(c *rest.Config) *Clientset {
 	var cs Clientset
 	cs.componentsV1alpha1 = componentsv1alpha1.NewForConfigOrDie(c)
 	cs.configurationV1alpha1 = configurationv1alpha1.NewForConfigOrDie(c)
+	cs.subscriptionsV1alpha1 = subscriptionsv1alpha1.NewForConfigOrDie(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClientForConfigOrDie(c)
 	return &cs
@@ -110,6 +123,7 @@ func New(c rest.Interface) *Clientset {
 	var cs Clientset
 	cs.componentsV1alpha1 = componentsv1alpha1.New(c)
 	cs.configurationV1alpha1 = configurationv1alpha1.New(c)
+	cs.subscriptionsV1alpha1 = subscriptionsv1alpha1.New(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClient(c)
 	return &cs
@@ -31,6 +31,7 @@ import (
 
 	componentsv1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
 	configurationv1alpha1 "github.com/bhojpur/application/pkg/kubernetes/configuration/v1alpha1"
+	subscriptionsv1alpha1 "github.com/bhojpur/application/pkg/kubernetes/subscriptions/v1alpha1"
 )
 
 var (
@@ -40,6 +41,7 @@ var (
 	localSchemeBuilder = runtime.SchemeBuilder{
 		componentsv1alpha1.AddToScheme,
 		configurationv1alpha1.AddToScheme,
+		subscriptionsv1alpha1.AddToScheme,
 	}
 )
 
@@ -36,6 +36,7 @@ import (
 	components "github.com/bhojpur/application/pkg/client/informers/externalversions/components"
 	configuration "github.com/bhojpur/application/pkg/client/informers/externalversions/configuration"
 	internalinterfaces "github.com/bhojpur/application/pkg/client/informers/externalversions/internalinterfaces"
+	subscriptions "github.com/bhojpur/application/pkg/client/informers/externalversions/subscriptions"
 )
 
 // SharedInformerOption defines the functional option type for SharedInformerFactory.
@@ -180,6 +181,7 @@ type SharedInformerFactory interface {
 
 	Components() components.Interface
 	Configuration() configuration.Interface
+	Subscriptions() subscriptions.Interface
 }
 
 func (f *sharedInformerFactory) Components() components.Interface {
@@ -188,4 +190,8 @@ func (f *sharedInformerFactory) Components() components.Interface {
 
 func (f *sharedInformerFactory) Configuration() configuration.Interface {
 	return configuration.New(f, f.namespace, f.tweakListOptions)
+}
+
+func (f *sharedInformerFactory) Subscriptions() subscriptions.Interface {
+	return subscriptions.New(f, f.namespace, f.tweakListOptions)
 }
\ No newline at end of file
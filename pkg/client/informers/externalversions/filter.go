@@ -0,0 +1,109 @@
+package externalversions
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	time "time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fields "k8s.io/apimachinery/pkg/fields"
+	labels "k8s.io/apimachinery/pkg/labels"
+
+	versioned "github.com/bhojpur/application/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/bhojpur/application/pkg/client/informers/externalversions/internalinterfaces"
+)
+
+// InformerFilter limits the resources cached by the informers of a SharedInformerFactory,
+// so large clusters don't cache irrelevant objects.
+type InformerFilter struct {
+	// Namespaces to watch, all namespaces are watched when empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// LabelSelector restricts the cached objects by their labels.
+	LabelSelector string `json:"labelSelector,omitempty"`
+	// FieldSelector restricts the cached objects by their fields.
+	FieldSelector string `json:"fieldSelector,omitempty"`
+}
+
+// Validate checks the label and field selectors of the filter can be parsed.
+func (f InformerFilter) Validate() error {
+	if _, err := labels.Parse(f.LabelSelector); err != nil {
+		return fmt.Errorf("invalid label selector %q: %w", f.LabelSelector, err)
+	}
+	if _, err := fields.ParseSelector(f.FieldSelector); err != nil {
+		return fmt.Errorf("invalid field selector %q: %w", f.FieldSelector, err)
+	}
+	return nil
+}
+
+// TweakListOptions returns a TweakListOptionsFunc adding the selectors of the filter to the list options.
+func (f InformerFilter) TweakListOptions() internalinterfaces.TweakListOptionsFunc {
+	return func(options *v1.ListOptions) {
+		options.LabelSelector = joinSelectors(options.LabelSelector, f.LabelSelector)
+		options.FieldSelector = joinSelectors(options.FieldSelector, f.FieldSelector)
+	}
+}
+
+// WithFilter applies the selectors of the filter to all listers of the SharedInformerFactory.
+// The factory is limited to the namespace of the filter when it has exactly one.
+func WithFilter(filter InformerFilter) SharedInformerOption {
+	return func(factory *sharedInformerFactory) *sharedInformerFactory {
+		factory.tweakListOptions = filter.TweakListOptions()
+		if len(filter.Namespaces) == 1 {
+			factory.namespace = filter.Namespaces[0]
+		}
+		return factory
+	}
+}
+
+// NewSharedInformerFactoriesForFilter validates the filter and constructs a SharedInformerFactory for each
+// of its namespaces, keyed by namespace. A single factory for all namespaces is returned when the filter has none.
+func NewSharedInformerFactoriesForFilter(client versioned.Interface, defaultResync time.Duration, filter InformerFilter) (map[string]SharedInformerFactory, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	namespaces := filter.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{v1.NamespaceAll}
+	}
+
+	factories := make(map[string]SharedInformerFactory, len(namespaces))
+	for _, namespace := range namespaces {
+		factories[namespace] = NewSharedInformerFactoryWithOptions(client, defaultResync,
+			WithTweakListOptions(filter.TweakListOptions()), WithNamespace(namespace))
+	}
+	return factories, nil
+}
+
+func joinSelectors(selectors ...string) string {
+	var joined string
+	for _, selector := range selectors {
+		if selector == "" {
+			continue
+		}
+		if joined != "" {
+			joined += ","
+		}
+		joined += selector
+	}
+	return joined
+}
@@ -30,6 +30,7 @@ import (
 
 	v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
 	configurationv1alpha1 "github.com/bhojpur/application/pkg/kubernetes/configuration/v1alpha1"
+	subscriptionsv1alpha1 "github.com/bhojpur/application/pkg/kubernetes/subscriptions/v1alpha1"
 )
 
 // GenericInformer is type of SharedIndexInformer which will locate and delegate to other
@@ -66,6 +67,10 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	case configurationv1alpha1.SchemeGroupVersion.WithResource("configurations"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Configuration().V1alpha1().Configurations().Informer()}, nil
 
+		// Group=subscriptions.bhojpur.net, Version=v1alpha1
+	case subscriptionsv1alpha1.SchemeGroupVersion.WithResource("subscriptions"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Subscriptions().V1alpha1().Subscriptions().Informer()}, nil
+
 	}
 
 	return nil, fmt.Errorf("no informer found for %v", resource)
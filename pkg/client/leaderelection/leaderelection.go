@@ -0,0 +1,210 @@
+package leaderelection
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.client.leaderelection")
+
+// Defaults of the lease timings, the ones of the Kubernetes controller managers.
+const (
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRenewDeadline = 10 * time.Second
+	DefaultRetryPeriod   = 2 * time.Second
+)
+
+// Config configures the election of the leader among the replicas of a controller, like the controllers
+// consuming the ComponentInformer, so that a single replica is active.
+type Config struct {
+	// Name is the name of the Lease the replicas compete for.
+	Name string
+	// Namespace is the namespace of the Lease, the NAMESPACE environment variable by default.
+	Namespace string
+	// Identity identifies the replica, the host name with a random suffix by default.
+	Identity string
+	// LeaseDuration is how long the followers wait before taking over a lease which isn't renewed.
+	LeaseDuration time.Duration
+	// RenewDeadline is how long the leader retries to renew the lease before giving up leadership.
+	RenewDeadline time.Duration
+	// RetryPeriod is the interval of the attempts to acquire or renew the lease.
+	RetryPeriod time.Duration
+	// ReleaseOnCancel releases the lease when the context is cancelled, so that another replica takes over immediately.
+	ReleaseOnCancel bool
+
+	// OnStartedLeading is called when the replica becomes the leader, ctx is cancelled when it stops leading.
+	OnStartedLeading func(ctx context.Context)
+	// OnStoppedLeading is called when the replica stops leading, or the election ends.
+	OnStoppedLeading func()
+	// OnNewLeader is called when another replica becomes the leader, it is optional.
+	OnNewLeader func(identity string)
+}
+
+// setDefaults fills the unset fields of the configuration, and validates it.
+func (c *Config) setDefaults() error {
+	if c.Name == "" {
+		return errors.New("leader election requires a lease name")
+	}
+	if c.OnStartedLeading == nil {
+		return errors.New("leader election requires an OnStartedLeading callback")
+	}
+
+	if c.Namespace == "" {
+		c.Namespace = os.Getenv("NAMESPACE")
+	}
+	if c.Namespace == "" {
+		return errors.New("leader election requires a namespace, set the NAMESPACE environment variable")
+	}
+
+	if c.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("failed to get the host name of the leader election identity: %w", err)
+		}
+		c.Identity = hostname + "_" + uuid.New().String()
+	}
+
+	if c.LeaseDuration <= 0 {
+		c.LeaseDuration = DefaultLeaseDuration
+	}
+	if c.RenewDeadline <= 0 {
+		c.RenewDeadline = DefaultRenewDeadline
+	}
+	if c.RetryPeriod <= 0 {
+		c.RetryPeriod = DefaultRetryPeriod
+	}
+	if c.LeaseDuration <= c.RenewDeadline {
+		return fmt.Errorf("lease duration %s must be greater than the renew deadline %s", c.LeaseDuration, c.RenewDeadline)
+	}
+	if c.OnStoppedLeading == nil {
+		c.OnStoppedLeading = func() {}
+	}
+	return nil
+}
+
+// Elector runs a controller on the replica elected leader.
+type Elector struct {
+	config  Config
+	elector *leaderelection.LeaderElector
+}
+
+// New returns an elector competing for the Lease of the configuration with client.
+func New(client kubernetes.Interface, config Config) (*Elector, error) {
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: meta_v1.ObjectMeta{
+			Name:      config.Name,
+			Namespace: config.Namespace,
+		},
+		Client: client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: config.Identity,
+		},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   config.LeaseDuration,
+		RenewDeadline:   config.RenewDeadline,
+		RetryPeriod:     config.RetryPeriod,
+		ReleaseOnCancel: config.ReleaseOnCancel,
+		Name:            config.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				log.Infof("%s is the leader of %s/%s", config.Identity, config.Namespace, config.Name)
+				config.OnStartedLeading(ctx)
+			},
+			OnStoppedLeading: func() {
+				log.Infof("%s stopped leading %s/%s", config.Identity, config.Namespace, config.Name)
+				config.OnStoppedLeading()
+			},
+			OnNewLeader: func(identity string) {
+				if identity == config.Identity {
+					return
+				}
+				log.Infof("%s is the new leader of %s/%s", identity, config.Namespace, config.Name)
+				if config.OnNewLeader != nil {
+					config.OnNewLeader(identity)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Elector{config: config, elector: elector}, nil
+}
+
+// Identity returns the identity of the replica.
+func (e *Elector) Identity() string {
+	return e.config.Identity
+}
+
+// IsLeader returns true if the replica is currently the leader.
+func (e *Elector) IsLeader() bool {
+	return e.elector.IsLeader()
+}
+
+// Leader returns the identity of the current leader, empty if unknown.
+func (e *Elector) Leader() string {
+	return e.elector.GetLeader()
+}
+
+// Run competes for leadership until ctx is done. The replica runs OnStartedLeading while it leads,
+// and competes again when it loses the leadership.
+func (e *Elector) Run(ctx context.Context) {
+	for {
+		e.elector.Run(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			log.Warnf("%s lost the leadership of %s/%s, competing again", e.config.Identity, e.config.Namespace, e.config.Name)
+		}
+	}
+}
+
+// Run competes for the leadership of the Lease of the configuration until ctx is done.
+func Run(ctx context.Context, client kubernetes.Interface, config Config) error {
+	elector, err := New(client, config)
+	if err != nil {
+		return err
+	}
+	elector.Run(ctx)
+	return nil
+}
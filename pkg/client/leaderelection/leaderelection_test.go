@@ -0,0 +1,101 @@
+package leaderelection
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConfigDefaults(t *testing.T) {
+	t.Setenv("NAMESPACE", "bhojpur-system")
+
+	config := Config{Name: "operator", OnStartedLeading: func(ctx context.Context) {}}
+	require.NoError(t, config.setDefaults())
+	assert.Equal(t, "bhojpur-system", config.Namespace)
+	assert.NotEmpty(t, config.Identity)
+	assert.Equal(t, DefaultLeaseDuration, config.LeaseDuration)
+	assert.Equal(t, DefaultRenewDeadline, config.RenewDeadline)
+	assert.Equal(t, DefaultRetryPeriod, config.RetryPeriod)
+	assert.NotNil(t, config.OnStoppedLeading)
+
+	t.Run("name is required", func(t *testing.T) {
+		config := Config{OnStartedLeading: func(ctx context.Context) {}}
+		assert.Error(t, config.setDefaults())
+	})
+
+	t.Run("callback is required", func(t *testing.T) {
+		config := Config{Name: "operator"}
+		assert.Error(t, config.setDefaults())
+	})
+
+	t.Run("lease must outlast the renew deadline", func(t *testing.T) {
+		config := Config{Name: "operator", OnStartedLeading: func(ctx context.Context) {}, LeaseDuration: time.Second, RenewDeadline: 2 * time.Second}
+		assert.Error(t, config.setDefaults())
+	})
+}
+
+func TestElector(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	elector, err := New(fake.NewSimpleClientset(), Config{
+		Name:            "operator",
+		Namespace:       "default",
+		Identity:        "replica-1",
+		LeaseDuration:   2 * time.Second,
+		RenewDeadline:   time.Second,
+		RetryPeriod:     100 * time.Millisecond,
+		ReleaseOnCancel: true,
+		OnStartedLeading: func(ctx context.Context) {
+			close(started)
+			<-ctx.Done()
+		},
+		OnStoppedLeading: func() {
+			close(stopped)
+		},
+	})
+	require.NoError(t, err)
+
+	go elector.Run(ctx)
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("replica didn't become the leader")
+	}
+	assert.True(t, elector.IsLeader())
+	assert.Equal(t, "replica-1", elector.Leader())
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("replica didn't stop leading")
+	}
+}
@@ -0,0 +1,142 @@
+package commands
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.commands")
+
+var (
+	// ErrNoHandler is returned when dispatching a command without handler.
+	ErrNoHandler = errors.New("no handler registered for command")
+	// ErrInvalidCommand wraps the validation errors of commands.
+	ErrInvalidCommand = errors.New("invalid command")
+	// ErrPermissionDenied is returned when the roles of the context are not allowed to run a command.
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// Command is a business operation, like creating an order. Commands are plain structs dispatched
+// through a Bus, so that HTTP handlers, jobs and CLIs run the same operations.
+type Command interface {
+	// CommandName identifies the type of the command, like "orders.create".
+	CommandName() string
+}
+
+// Handler runs a command and returns its result.
+type Handler func(ctx context.Context, command Command) (interface{}, error)
+
+// Middleware wraps the handlers of a bus, like to validate commands or run them in a transaction.
+type Middleware func(next Handler) Handler
+
+// Bus dispatches commands to their handlers through its middlewares.
+type Bus struct {
+	handlers    map[string]Handler
+	middlewares []Middleware
+	lock        sync.RWMutex
+}
+
+// DefaultBus is the bus of the package level functions.
+var DefaultBus = NewBus()
+
+// NewBus returns a bus without handlers nor middlewares.
+func NewBus() *Bus {
+	return &Bus{handlers: map[string]Handler{}}
+}
+
+// Register registers the handler of the commands of the same type as command, it fails if the type
+// already has a handler.
+//
+//	bus.Register(CreateOrder{}, func(ctx context.Context, command commands.Command) (interface{}, error) {
+//	    create := command.(CreateOrder)
+//	    ...
+//	})
+func (b *Bus) Register(command Command, handler Handler) error {
+	name := command.CommandName()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if _, ok := b.handlers[name]; ok {
+		return fmt.Errorf("command %s already has a handler", name)
+	}
+	b.handlers[name] = handler
+	return nil
+}
+
+// Use appends middlewares, the first one is the outermost.
+func (b *Bus) Use(middlewares ...Middleware) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.middlewares = append(b.middlewares, middlewares...)
+}
+
+// Dispatch runs the command with its handler through the middlewares, and returns its result.
+func (b *Bus) Dispatch(ctx context.Context, command Command) (interface{}, error) {
+	b.lock.RLock()
+	handler, ok := b.handlers[command.CommandName()]
+	middlewares := b.middlewares
+	b.lock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrNoHandler, command.CommandName())
+	}
+
+	for idx := len(middlewares) - 1; idx >= 0; idx-- {
+		handler = middlewares[idx](handler)
+	}
+	return handler(ctx, command)
+}
+
+// Register registers the handler of a command type on the default bus.
+func Register(command Command, handler Handler) error {
+	return DefaultBus.Register(command, handler)
+}
+
+// Use appends middlewares to the default bus.
+func Use(middlewares ...Middleware) {
+	DefaultBus.Use(middlewares...)
+}
+
+// Dispatch dispatches a command on the default bus.
+func Dispatch(ctx context.Context, command Command) (interface{}, error) {
+	return DefaultBus.Dispatch(ctx, command)
+}
+
+type rolesKey struct{}
+
+// WithRoles returns a context carrying the roles the commands are authorized with.
+func WithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// RolesFromContext returns the roles of the context.
+func RolesFromContext(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey{}).([]string)
+	return roles
+}
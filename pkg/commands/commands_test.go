@@ -0,0 +1,110 @@
+package commands_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bhojpur/application/pkg/commands"
+	"github.com/bhojpur/application/pkg/roles"
+)
+
+type createOrder struct {
+	Product  string
+	Quantity int
+}
+
+func (createOrder) CommandName() string {
+	return "orders.create"
+}
+
+func (c createOrder) Validate() error {
+	if c.Quantity <= 0 {
+		return errors.New("quantity must be positive")
+	}
+	return nil
+}
+
+func (createOrder) Permission() (roles.Permissioner, roles.PermissionMode) {
+	return roles.Allow(roles.Create, "sales"), roles.Create
+}
+
+type ping struct{}
+
+func (ping) CommandName() string {
+	return "ping"
+}
+
+func TestBus(t *testing.T) {
+	bus := commands.NewBus()
+	var order []string
+	bus.Use(func(next commands.Handler) commands.Handler {
+		return func(ctx context.Context, command commands.Command) (interface{}, error) {
+			order = append(order, "outer")
+			return next(ctx, command)
+		}
+	}, func(next commands.Handler) commands.Handler {
+		return func(ctx context.Context, command commands.Command) (interface{}, error) {
+			order = append(order, "inner")
+			return next(ctx, command)
+		}
+	})
+
+	require.NoError(t, bus.Register(ping{}, func(ctx context.Context, command commands.Command) (interface{}, error) {
+		return "pong", nil
+	}))
+	assert.Error(t, bus.Register(ping{}, func(ctx context.Context, command commands.Command) (interface{}, error) {
+		return nil, nil
+	}))
+
+	result, err := bus.Dispatch(context.Background(), ping{})
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", result)
+	assert.Equal(t, []string{"outer", "inner"}, order)
+
+	_, err = bus.Dispatch(context.Background(), createOrder{})
+	assert.True(t, errors.Is(err, commands.ErrNoHandler))
+}
+
+func TestValidationAndAuthorization(t *testing.T) {
+	bus := commands.NewBus()
+	bus.Use(commands.Logging(), commands.Validation(), commands.Authorization())
+	require.NoError(t, bus.Register(createOrder{}, func(ctx context.Context, command commands.Command) (interface{}, error) {
+		return command.(createOrder).Quantity, nil
+	}))
+
+	sales := commands.WithRoles(context.Background(), "sales")
+
+	result, err := bus.Dispatch(sales, createOrder{Product: "book", Quantity: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result)
+
+	_, err = bus.Dispatch(sales, createOrder{Product: "book"})
+	assert.True(t, errors.Is(err, commands.ErrInvalidCommand))
+
+	_, err = bus.Dispatch(commands.WithRoles(context.Background(), "support"), createOrder{Product: "book", Quantity: 2})
+	assert.True(t, errors.Is(err, commands.ErrPermissionDenied))
+}
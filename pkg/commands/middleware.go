@@ -0,0 +1,130 @@
+package commands
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bhojpur/application/pkg/roles"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// Validator is implemented by the commands validated by the Validation middleware.
+type Validator interface {
+	Validate() error
+}
+
+// Authorizer is implemented by the commands authorized by the Authorization middleware, it returns
+// the permissioner and the mode the roles of the context are checked against.
+type Authorizer interface {
+	Permission() (roles.Permissioner, roles.PermissionMode)
+}
+
+// Validation rejects the commands implementing Validator which are invalid, with ErrInvalidCommand.
+func Validation() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, command Command) (interface{}, error) {
+			if validator, ok := command.(Validator); ok {
+				if err := validator.Validate(); err != nil {
+					return nil, fmt.Errorf("%w %s: %s", ErrInvalidCommand, command.CommandName(), err)
+				}
+			}
+			return next(ctx, command)
+		}
+	}
+}
+
+// Authorization rejects the commands implementing Authorizer which the roles of the context, see WithRoles,
+// are not allowed to run, with ErrPermissionDenied.
+func Authorization() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, command Command) (interface{}, error) {
+			if authorizer, ok := command.(Authorizer); ok {
+				permissioner, mode := authorizer.Permission()
+
+				var contextRoles []interface{}
+				for _, role := range RolesFromContext(ctx) {
+					contextRoles = append(contextRoles, role)
+				}
+				if permissioner == nil || !permissioner.HasPermission(mode, contextRoles...) {
+					return nil, fmt.Errorf("%w: %s requires %s", ErrPermissionDenied, command.CommandName(), mode)
+				}
+			}
+			return next(ctx, command)
+		}
+	}
+}
+
+type dbKey struct{}
+
+// WithDB returns a context carrying db, the handlers get it with DB.
+func WithDB(ctx context.Context, db *orm.DB) context.Context {
+	return context.WithValue(ctx, dbKey{}, db)
+}
+
+// DB returns the database of the context, the transaction of the Transaction middleware, or nil.
+func DB(ctx context.Context) *orm.DB {
+	db, _ := ctx.Value(dbKey{}).(*orm.DB)
+	return db
+}
+
+type transactionKey struct{}
+
+// Transaction runs the commands in a transaction of db, committed if the handler succeeds and rolled back
+// otherwise. Commands dispatched by a handler join its transaction.
+func Transaction(db *orm.DB) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, command Command) (interface{}, error) {
+			if ctx.Value(transactionKey{}) != nil {
+				return next(ctx, command)
+			}
+
+			var result interface{}
+			err := db.Transaction(func(tx *orm.DB) error {
+				var err error
+				result, err = next(context.WithValue(WithDB(ctx, tx), transactionKey{}, true), command)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+	}
+}
+
+// Logging logs the commands with their duration, and their errors.
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, command Command) (interface{}, error) {
+			start := time.Now()
+			result, err := next(ctx, command)
+			if err != nil {
+				log.Warnf("command %s failed after %s: %s", command.CommandName(), time.Since(start), err)
+			} else {
+				log.Debugf("command %s succeeded in %s", command.CommandName(), time.Since(start))
+			}
+			return result, err
+		}
+	}
+}
@@ -26,3 +26,11 @@ import components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/compon
 type ComponentHandler interface {
 	OnComponentUpdated(component components_v1alpha1.Component)
 }
+
+// ComponentHandlerFunc is an adapter to use ordinary functions as component handlers.
+type ComponentHandlerFunc func(component components_v1alpha1.Component)
+
+// OnComponentUpdated calls f(component).
+func (f ComponentHandlerFunc) OnComponentUpdated(component components_v1alpha1.Component) {
+	f(component)
+}
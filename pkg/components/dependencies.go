@@ -0,0 +1,137 @@
+package components
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+
+	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+// SortByDependencies orders components so every component comes after the components it depends on.
+// Components without dependencies keep their relative order. An error is returned for dependency cycles.
+func SortByDependencies(comps []components_v1alpha1.Component) ([]components_v1alpha1.Component, error) {
+	byName := make(map[string]components_v1alpha1.Component, len(comps))
+	for _, comp := range comps {
+		byName[comp.Name] = comp
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	var (
+		sorted = make([]components_v1alpha1.Component, 0, len(comps))
+		state  = map[string]int{}
+		path   []string
+		visit  func(comp components_v1alpha1.Component) error
+	)
+
+	visit = func(comp components_v1alpha1.Component) error {
+		switch state[comp.Name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle detected: %s", strings.Join(append(path, comp.Name), " -> "))
+		}
+
+		state[comp.Name] = visiting
+		path = append(path, comp.Name)
+		for _, dependency := range comp.Spec.DependsOn {
+			// dependencies which are not loaded yet are waited on by the runtime
+			if dep, ok := byName[dependency]; ok {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[comp.Name] = visited
+
+		sorted = append(sorted, comp)
+		return nil
+	}
+
+	for _, comp := range comps {
+		if err := visit(comp); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// DependencyChain returns the names of the component and of all its transitive dependencies found in comps,
+// in initialization order.
+func DependencyChain(comp components_v1alpha1.Component, comps []components_v1alpha1.Component) ([]string, error) {
+	byName := make(map[string]components_v1alpha1.Component, len(comps))
+	for _, c := range comps {
+		byName[c.Name] = c
+	}
+
+	var (
+		chain   []components_v1alpha1.Component
+		visited = map[string]bool{comp.Name: true}
+		collect func(c components_v1alpha1.Component)
+	)
+
+	collect = func(c components_v1alpha1.Component) {
+		for _, dependency := range c.Spec.DependsOn {
+			if dep, ok := byName[dependency]; ok && !visited[dependency] {
+				visited[dependency] = true
+				chain = append(chain, dep)
+				collect(dep)
+			}
+		}
+	}
+	collect(comp)
+
+	sorted, err := SortByDependencies(append(chain, comp))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(sorted))
+	for _, c := range sorted {
+		names = append(names, c.Name)
+	}
+	return names, nil
+}
+
+// MissingDependencies returns the dependencies of the component which are not in comps.
+func MissingDependencies(comp components_v1alpha1.Component, comps []components_v1alpha1.Component) []string {
+	var missing []string
+	for _, dependency := range comp.Spec.DependsOn {
+		found := false
+		for _, c := range comps {
+			if c.Name == dependency {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			missing = append(missing, dependency)
+		}
+	}
+	return missing
+}
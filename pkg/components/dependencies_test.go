@@ -0,0 +1,97 @@
+package components
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+func newDependentComponent(name string, dependsOn ...string) components_v1alpha1.Component {
+	return components_v1alpha1.Component{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: components_v1alpha1.ComponentSpec{
+			DependsOn: dependsOn,
+		},
+	}
+}
+
+func TestSortByDependencies(t *testing.T) {
+	t.Run("dependencies come first", func(t *testing.T) {
+		sorted, err := SortByDependencies([]components_v1alpha1.Component{
+			newDependentComponent("pubsub", "secretstore"),
+			newDependentComponent("statestore"),
+			newDependentComponent("secretstore", "vault"),
+			newDependentComponent("vault"),
+		})
+		assert.NoError(t, err)
+
+		names := []string{}
+		for _, c := range sorted {
+			names = append(names, c.Name)
+		}
+		assert.Equal(t, []string{"vault", "secretstore", "pubsub", "statestore"}, names)
+	})
+
+	t.Run("unknown dependencies are ignored", func(t *testing.T) {
+		sorted, err := SortByDependencies([]components_v1alpha1.Component{
+			newDependentComponent("pubsub", "secretstore"),
+		})
+		assert.NoError(t, err)
+		assert.Len(t, sorted, 1)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := SortByDependencies([]components_v1alpha1.Component{
+			newDependentComponent("a", "b"),
+			newDependentComponent("b", "c"),
+			newDependentComponent("c", "a"),
+		})
+		assert.EqualError(t, err, "dependency cycle detected: a -> b -> c -> a")
+	})
+}
+
+func TestDependencyChain(t *testing.T) {
+	comps := []components_v1alpha1.Component{
+		newDependentComponent("vault"),
+		newDependentComponent("secretstore", "vault"),
+		newDependentComponent("statestore"),
+	}
+
+	chain, err := DependencyChain(newDependentComponent("pubsub", "secretstore"), comps)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vault", "secretstore", "pubsub"}, chain)
+}
+
+func TestMissingDependencies(t *testing.T) {
+	comps := []components_v1alpha1.Component{
+		newDependentComponent("secretstore"),
+	}
+
+	assert.Equal(t, []string{"vault"}, MissingDependencies(newDependentComponent("pubsub", "secretstore", "vault"), comps))
+	assert.Empty(t, MissingDependencies(newDependentComponent("pubsub", "secretstore"), comps))
+}
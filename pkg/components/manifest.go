@@ -0,0 +1,116 @@
+package components
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/application/pkg/config"
+	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+// Categories are the prefixes of the component types understood by the runtime, eg. "state" for state.redis.
+var Categories = []string{"bindings", "pubsub", "secretstores", "state", "middleware", "configuration"}
+
+// ValidateManifest checks a component manifest against the default schema registry, before it is applied.
+// It reports every issue of the spec at once, with a suggestion to fix it when one is known.
+func ValidateManifest(component components_v1alpha1.Component) *config.ValidationReport {
+	return DefaultSchemaRegistry.ValidateManifest(component)
+}
+
+// ValidateManifest checks a component manifest: the name and type of the component, its init timeout, the form of
+// its metadata items and secret references, and its metadata against the schema of its type.
+func (r *SchemaRegistry) ValidateManifest(component components_v1alpha1.Component) *config.ValidationReport {
+	report := &config.ValidationReport{}
+	source := fmt.Sprintf("component %s", component.Name)
+	if component.Name == "" {
+		source = "component"
+		report.AddError(source, "metadata.name", "name is empty", "")
+	}
+
+	r.validateType(report, source, component.Spec.Type)
+
+	if timeout := component.Spec.InitTimeout; timeout != "" {
+		if _, err := time.ParseDuration(timeout); err != nil {
+			report.AddError(source, "spec.initTimeout", fmt.Sprintf("invalid duration %q", timeout), "use a Go duration such as 5s or 1m")
+		}
+	}
+
+	seen := map[string]int{}
+	for i, item := range component.Spec.Metadata {
+		path := fmt.Sprintf("spec.metadata[%d]", i)
+		if item.Name == "" {
+			report.AddError(source, path+".name", "metadata name is empty", "")
+			continue
+		}
+		if first, ok := seen[item.Name]; ok {
+			report.AddError(source, path+".name", fmt.Sprintf("metadata %q is declared more than once", item.Name),
+				fmt.Sprintf("remove it or merge it with spec.metadata[%d]", first))
+		} else {
+			seen[item.Name] = i
+		}
+
+		ref := item.SecretKeyRef
+		switch {
+		case ref.Name == "" && ref.Key != "":
+			report.AddError(source, path+".secretKeyRef.name", "secret name is missing", fmt.Sprintf("set the name of the secret holding the key %q", ref.Key))
+		case ref.Name != "" && ref.Key == "":
+			report.AddError(source, path+".secretKeyRef.key", "secret key is missing", fmt.Sprintf("set the key of the value in the secret %q", ref.Name))
+		case ref.Name != "" && len(item.Value.Raw) > 0:
+			report.AddWarning(source, path+".value", "value is ignored as the secret reference takes precedence", "remove either value or secretKeyRef")
+		}
+	}
+
+	var validationErr *ValidationError
+	if err := r.Validate(component); errors.As(err, &validationErr) {
+		for _, fieldErr := range validationErr.Errors {
+			report.AddError(source, fmt.Sprintf("spec.metadata[%s]", fieldErr.Field), fieldErr.Message, "")
+		}
+	} else if err != nil {
+		report.AddError(source, "spec.metadata", err.Error(), "")
+	}
+	return report
+}
+
+func (r *SchemaRegistry) validateType(report *config.ValidationReport, source, componentType string) {
+	if componentType == "" {
+		report.AddError(source, "spec.type", "type is empty", "use a component type such as state.redis")
+		return
+	}
+
+	for _, known := range r.Types() {
+		if known != componentType && strings.EqualFold(known, componentType) {
+			report.AddError(source, "spec.type", fmt.Sprintf("unknown component type %q", componentType), fmt.Sprintf("did you mean %s?", known))
+			return
+		}
+	}
+
+	for _, category := range Categories {
+		if strings.HasPrefix(componentType, category+".") && len(componentType) > len(category)+1 {
+			return
+		}
+	}
+	report.AddError(source, "spec.type", fmt.Sprintf("unknown component type %q", componentType),
+		fmt.Sprintf("component types start with one of %s, followed by the name of the component", strings.Join(Categories, ", ")))
+}
@@ -0,0 +1,98 @@
+package components
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bhojpur/application/pkg/config"
+	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+func issuePaths(report *config.ValidationReport, severity config.ValidationSeverity) []string {
+	paths := []string{}
+	for _, issue := range report.Issues {
+		if issue.Severity == severity {
+			paths = append(paths, issue.Path)
+		}
+	}
+	return paths
+}
+
+func TestValidateManifest(t *testing.T) {
+	registry := NewSchemaRegistry()
+	registry.Register(MetadataSchema{
+		Type:   "state.mystore",
+		Fields: []MetadataField{{Name: "host", Required: true}},
+	})
+
+	t.Run("valid manifest", func(t *testing.T) {
+		report := registry.ValidateManifest(newSchemaComponent("v1", metadataValue("host", "localhost")))
+		assert.Empty(t, report.Issues)
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		comp := newSchemaComponent("v1", metadataValue("host", "localhost"))
+		comp.Spec.Type = "mystore"
+		report := registry.ValidateManifest(comp)
+		require.Len(t, report.Issues, 1)
+		assert.Equal(t, "spec.type", report.Issues[0].Path)
+		assert.Contains(t, report.Issues[0].Suggestion, "state")
+
+		comp.Spec.Type = "State.MyStore"
+		report = registry.ValidateManifest(comp)
+		require.Len(t, report.Issues, 1)
+		assert.Equal(t, "did you mean state.mystore?", report.Issues[0].Suggestion)
+	})
+
+	t.Run("invalid spec", func(t *testing.T) {
+		secretRef := metadataValue("password", "ignored")
+		secretRef.SecretKeyRef = components_v1alpha1.SecretKeyRef{Name: "redis"}
+		keyOnly := components_v1alpha1.MetadataItem{Name: "token", SecretKeyRef: components_v1alpha1.SecretKeyRef{Key: "token"}}
+
+		comp := newSchemaComponent("v1", metadataValue("port", "6379"), metadataValue("port", "6380"), secretRef, keyOnly)
+		comp.Name = ""
+		comp.Spec.InitTimeout = "soon"
+		report := registry.ValidateManifest(comp)
+
+		assert.True(t, report.HasErrors())
+		assert.Equal(t, []string{
+			"metadata.name",
+			"spec.initTimeout",
+			"spec.metadata[1].name",
+			"spec.metadata[2].secretKeyRef.key",
+			"spec.metadata[3].secretKeyRef.name",
+			"spec.metadata[host]",
+		}, issuePaths(report, config.SeverityError))
+		assert.Empty(t, issuePaths(report, config.SeverityWarning))
+	})
+
+	t.Run("value ignored by secret reference", func(t *testing.T) {
+		secretRef := metadataValue("password", "ignored")
+		secretRef.SecretKeyRef = components_v1alpha1.SecretKeyRef{Name: "redis", Key: "password"}
+		report := registry.ValidateManifest(newSchemaComponent("v1", metadataValue("host", "localhost"), secretRef))
+		assert.False(t, report.HasErrors())
+		assert.Equal(t, []string{"spec.metadata[1].value"}, issuePaths(report, config.SeverityWarning))
+	})
+}
@@ -0,0 +1,68 @@
+package components
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"sort"
+
+	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+// AnyComponentType is the key of the quota applied to the component types without their own quota.
+const AnyComponentType = "*"
+
+// QuotaLimit returns the maximum number of components of componentType allowed by maxPerType, and false if the
+// type is unlimited.
+func QuotaLimit(maxPerType map[string]int, componentType string) (int, bool) {
+	limit, ok := maxPerType[componentType]
+	if !ok {
+		limit, ok = maxPerType[AnyComponentType]
+	}
+	return limit, ok && limit > 0
+}
+
+// ExceedingQuota returns the names of the components over the quota of their type. The oldest components of a
+// type are the ones within quota, so that creating a component never pushes an existing one over quota.
+func ExceedingQuota(comps []components_v1alpha1.Component, maxPerType map[string]int) map[string]bool {
+	byType := map[string][]components_v1alpha1.Component{}
+	for _, comp := range comps {
+		byType[comp.Spec.Type] = append(byType[comp.Spec.Type], comp)
+	}
+
+	exceeding := map[string]bool{}
+	for componentType, ofType := range byType {
+		limit, ok := QuotaLimit(maxPerType, componentType)
+		if !ok || len(ofType) <= limit {
+			continue
+		}
+
+		sort.SliceStable(ofType, func(i, j int) bool {
+			if !ofType[i].CreationTimestamp.Equal(&ofType[j].CreationTimestamp) {
+				return ofType[i].CreationTimestamp.Before(&ofType[j].CreationTimestamp)
+			}
+			return ofType[i].Name < ofType[j].Name
+		})
+		for _, comp := range ofType[limit:] {
+			exceeding[comp.Name] = true
+		}
+	}
+	return exceeding
+}
@@ -0,0 +1,75 @@
+package components
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+func newTypedComponent(name, componentType string, created time.Time) components_v1alpha1.Component {
+	return components_v1alpha1.Component{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: components_v1alpha1.ComponentSpec{
+			Type: componentType,
+		},
+	}
+}
+
+func TestQuotaLimit(t *testing.T) {
+	maxPerType := map[string]int{"state.redis": 2, AnyComponentType: 5, "pubsub.kafka": 0}
+
+	limit, ok := QuotaLimit(maxPerType, "state.redis")
+	assert.True(t, ok)
+	assert.Equal(t, 2, limit)
+
+	limit, ok = QuotaLimit(maxPerType, "bindings.cron")
+	assert.True(t, ok)
+	assert.Equal(t, 5, limit)
+
+	_, ok = QuotaLimit(maxPerType, "pubsub.kafka")
+	assert.False(t, ok)
+
+	_, ok = QuotaLimit(nil, "state.redis")
+	assert.False(t, ok)
+}
+
+func TestExceedingQuota(t *testing.T) {
+	now := time.Now()
+	comps := []components_v1alpha1.Component{
+		newTypedComponent("newest", "state.redis", now),
+		newTypedComponent("oldest", "state.redis", now.Add(-2*time.Hour)),
+		newTypedComponent("older", "state.redis", now.Add(-time.Hour)),
+		newTypedComponent("pubsub", "pubsub.kafka", now),
+	}
+
+	assert.Equal(t, map[string]bool{"newest": true}, ExceedingQuota(comps, map[string]int{"state.redis": 2}))
+	assert.Empty(t, ExceedingQuota(comps, map[string]int{"state.redis": 3}))
+	assert.Empty(t, ExceedingQuota(comps, nil))
+}
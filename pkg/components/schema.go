@@ -0,0 +1,235 @@
+package components
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+// MetadataFieldType is the expected type of the value of a metadata field.
+type MetadataFieldType string
+
+const (
+	// StringField accepts any value.
+	StringField MetadataFieldType = "string"
+	// NumberField accepts integer and floating point values.
+	NumberField MetadataFieldType = "number"
+	// BoolField accepts the values understood by strconv.ParseBool.
+	BoolField MetadataFieldType = "bool"
+	// DurationField accepts Go durations, such as "5s", or a number of milliseconds.
+	DurationField MetadataFieldType = "duration"
+)
+
+// MetadataField describes a metadata field expected by a component type.
+type MetadataField struct {
+	Name     string
+	Type     MetadataFieldType
+	Required bool
+	// Secret marks sensitive fields, whose values are never included in validation errors.
+	Secret bool
+}
+
+// MetadataSchema declares the metadata fields expected by a component type, eg. state.redis.
+// An empty version applies to the initial versions of the component type.
+type MetadataSchema struct {
+	Type    string
+	Version string
+	Fields  []MetadataField
+}
+
+// FieldError is a validation error of a single metadata field.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("metadata field %q: %s", e.Field, e.Message)
+}
+
+// ValidationError lists the metadata errors of a component.
+type ValidationError struct {
+	Component string
+	Type      string
+	Errors    []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("invalid component %s (%s): %s", e.Component, e.Type, strings.Join(messages, "; "))
+}
+
+// SchemaRegistry holds the metadata schemas of component types.
+type SchemaRegistry struct {
+	mutex   sync.RWMutex
+	schemas map[string]MetadataSchema
+}
+
+// NewSchemaRegistry returns an empty metadata schema registry.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		schemas: map[string]MetadataSchema{},
+	}
+}
+
+// DefaultSchemaRegistry is the schema registry used by the component loaders and validators.
+var DefaultSchemaRegistry = NewSchemaRegistry()
+
+// RegisterSchemas registers metadata schemas with the default schema registry.
+func RegisterSchemas(schemas ...MetadataSchema) {
+	DefaultSchemaRegistry.Register(schemas...)
+}
+
+// ValidateComponent validates the metadata of a component against the default schema registry.
+func ValidateComponent(component components_v1alpha1.Component) error {
+	return DefaultSchemaRegistry.Validate(component)
+}
+
+// Register registers metadata schemas, replacing previously registered schemas of the same type and version.
+func (r *SchemaRegistry) Register(schemas ...MetadataSchema) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, schema := range schemas {
+		r.schemas[schemaKey(schema.Type, schema.Version)] = schema
+	}
+}
+
+// Schema returns the metadata schema of a component type and version.
+func (r *SchemaRegistry) Schema(componentType, version string) (MetadataSchema, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if schema, ok := r.schemas[schemaKey(componentType, version)]; ok {
+		return schema, true
+	}
+	if IsInitialVersion(version) {
+		schema, ok := r.schemas[schemaKey(componentType, "")]
+		return schema, ok
+	}
+	return MetadataSchema{}, false
+}
+
+// Types returns the sorted component types with a registered schema.
+func (r *SchemaRegistry) Types() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	seen := map[string]bool{}
+	types := make([]string, 0, len(r.schemas))
+	for _, schema := range r.schemas {
+		componentType := strings.ToLower(schema.Type)
+		if !seen[componentType] {
+			seen[componentType] = true
+			types = append(types, componentType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Validate validates the metadata of a component against the schema of its type. Components
+// of types without a registered schema are always valid, and so are undeclared fields.
+func (r *SchemaRegistry) Validate(component components_v1alpha1.Component) error {
+	schema, ok := r.Schema(component.Spec.Type, component.Spec.Version)
+	if !ok {
+		return nil
+	}
+
+	items := map[string]components_v1alpha1.MetadataItem{}
+	for _, item := range component.Spec.Metadata {
+		items[item.Name] = item
+	}
+
+	errs := []FieldError{}
+	for _, field := range schema.Fields {
+		item, ok := items[field.Name]
+		if !ok {
+			if field.Required {
+				errs = append(errs, FieldError{Field: field.Name, Message: "required field is missing"})
+			}
+			continue
+		}
+
+		// The value of a secret reference is only known once it is resolved.
+		if item.SecretKeyRef.Name != "" {
+			continue
+		}
+
+		value := item.Value.String()
+		if value == "" {
+			if field.Required {
+				errs = append(errs, FieldError{Field: field.Name, Message: "required field is empty"})
+			}
+			continue
+		}
+
+		if err := validateFieldValue(field.Type, value); err != nil {
+			message := fmt.Sprintf("expected a %s value", field.Type)
+			if !field.Secret {
+				message = fmt.Sprintf("%s, got %q", message, value)
+			}
+			errs = append(errs, FieldError{Field: field.Name, Message: message})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{
+		Component: component.Name,
+		Type:      schemaKey(component.Spec.Type, component.Spec.Version),
+		Errors:    errs,
+	}
+}
+
+func validateFieldValue(fieldType MetadataFieldType, value string) error {
+	var err error
+	switch fieldType {
+	case NumberField:
+		_, err = strconv.ParseFloat(value, 64)
+	case BoolField:
+		_, err = strconv.ParseBool(value)
+	case DurationField:
+		if _, err = time.ParseDuration(value); err != nil {
+			_, err = strconv.Atoi(value)
+		}
+	}
+	return err
+}
+
+func schemaKey(componentType, version string) string {
+	key := strings.ToLower(componentType)
+	if version != "" {
+		key += "/" + strings.ToLower(version)
+	}
+	return key
+}
@@ -0,0 +1,121 @@
+package components
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiextensionsV1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+func newSchemaComponent(version string, metadata ...components_v1alpha1.MetadataItem) components_v1alpha1.Component {
+	return components_v1alpha1.Component{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "statestore",
+		},
+		Spec: components_v1alpha1.ComponentSpec{
+			Type:     "state.mystore",
+			Version:  version,
+			Metadata: metadata,
+		},
+	}
+}
+
+func metadataValue(name, value string) components_v1alpha1.MetadataItem {
+	return components_v1alpha1.MetadataItem{
+		Name: name,
+		Value: components_v1alpha1.DynamicValue{
+			JSON: apiextensionsV1.JSON{Raw: []byte(strconv.Quote(value))},
+		},
+	}
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	registry := NewSchemaRegistry()
+	registry.Register(MetadataSchema{
+		Type: "state.mystore",
+		Fields: []MetadataField{
+			{Name: "host", Type: StringField, Required: true},
+			{Name: "password", Type: StringField, Required: true, Secret: true},
+			{Name: "port", Type: NumberField},
+			{Name: "enableTLS", Type: BoolField},
+			{Name: "timeout", Type: DurationField},
+		},
+	})
+
+	t.Run("valid metadata", func(t *testing.T) {
+		comp := newSchemaComponent("v1",
+			metadataValue("host", "localhost"),
+			components_v1alpha1.MetadataItem{
+				Name:         "password",
+				SecretKeyRef: components_v1alpha1.SecretKeyRef{Name: "store-secret", Key: "password"},
+			},
+			metadataValue("port", "6379"),
+			metadataValue("enableTLS", "true"),
+			metadataValue("timeout", "5s"),
+			metadataValue("undeclared", "value"),
+		)
+		assert.NoError(t, registry.Validate(comp))
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		comp := newSchemaComponent("",
+			metadataValue("password", ""),
+			metadataValue("port", "default"),
+			metadataValue("enableTLS", "maybe"),
+			metadataValue("timeout", "1000"),
+		)
+		err := registry.Validate(comp)
+		assert.EqualError(t, err, `invalid component statestore (state.mystore): `+
+			`metadata field "host": required field is missing; `+
+			`metadata field "password": required field is empty; `+
+			`metadata field "port": expected a number value, got "default"; `+
+			`metadata field "enableTLS": expected a bool value, got "maybe"`)
+
+		validationErr, ok := err.(*ValidationError)
+		assert.True(t, ok)
+		assert.Len(t, validationErr.Errors, 4)
+	})
+
+	t.Run("secret values are not printed", func(t *testing.T) {
+		registry.Register(MetadataSchema{
+			Type:   "state.mystore",
+			Fields: []MetadataField{{Name: "port", Type: NumberField, Secret: true}},
+		})
+		err := registry.Validate(newSchemaComponent("", metadataValue("port", "s3cr3t")))
+		assert.EqualError(t, err, `invalid component statestore (state.mystore): metadata field "port": expected a number value`)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		assert.NoError(t, registry.Validate(newSchemaComponent("v2")))
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		comp := newSchemaComponent("")
+		comp.Spec.Type = "state.other"
+		assert.NoError(t, registry.Validate(comp))
+	})
+}
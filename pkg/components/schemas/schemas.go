@@ -0,0 +1,74 @@
+package schemas
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "github.com/bhojpur/application/pkg/components"
+
+// init registers the metadata schemas of the built-in component types with the default
+// schema registry, binaries import this package for its side effects.
+func init() {
+	components.RegisterSchemas(
+		components.MetadataSchema{
+			Type: "state.redis",
+			Fields: []components.MetadataField{
+				{Name: "redisHost", Type: components.StringField, Required: true},
+				{Name: "redisPassword", Type: components.StringField, Secret: true},
+				{Name: "enableTLS", Type: components.BoolField},
+				{Name: "maxRetries", Type: components.NumberField},
+				{Name: "maxRetryBackoff", Type: components.DurationField},
+				{Name: "actorStateStore", Type: components.BoolField},
+			},
+		},
+		components.MetadataSchema{
+			Type: "state.embedded",
+			Fields: []components.MetadataField{
+				{Name: "driver", Type: components.StringField},
+				{Name: "path", Type: components.StringField},
+				{Name: "tableName", Type: components.StringField},
+				{Name: "ttlInSeconds", Type: components.NumberField},
+			},
+		},
+		components.MetadataSchema{
+			Type: "pubsub.redis",
+			Fields: []components.MetadataField{
+				{Name: "redisHost", Type: components.StringField, Required: true},
+				{Name: "redisPassword", Type: components.StringField, Secret: true},
+				{Name: "enableTLS", Type: components.BoolField},
+				{Name: "processingTimeout", Type: components.DurationField},
+				{Name: "redeliverInterval", Type: components.DurationField},
+				{Name: "concurrency", Type: components.NumberField},
+			},
+		},
+		components.MetadataSchema{
+			Type: "secretstores.local.file",
+			Fields: []components.MetadataField{
+				{Name: "secretsFile", Type: components.StringField, Required: true},
+				{Name: "nestedSeparator", Type: components.StringField},
+			},
+		},
+		components.MetadataSchema{
+			Type: "bindings.cron",
+			Fields: []components.MetadataField{
+				{Name: "schedule", Type: components.StringField, Required: true},
+			},
+		},
+	)
+}
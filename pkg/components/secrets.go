@@ -0,0 +1,171 @@
+package components
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"strings"
+	"unicode"
+
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+const kubernetesSecretStore = "kubernetes"
+
+// SecretKeyResolver resolves the secret key references of component metadata.
+type SecretKeyResolver interface {
+	// ResolveSecretKeys returns the values of the references of a namespace. References to missing keys are left out.
+	ResolveSecretKeys(ctx context.Context, namespace string, refs []components_v1alpha1.SecretKeyRef) (map[components_v1alpha1.SecretKeyRef][]byte, error)
+}
+
+// SecretGetter returns the data of a Kubernetes Secret.
+type SecretGetter func(ctx context.Context, namespace, name string) (map[string][]byte, error)
+
+// KubernetesSecretResolver resolves references from the Secrets of the namespace. Every referenced Secret is fetched
+// once by name, so RBAC rules can grant the get verb on the referenced resourceNames only.
+type KubernetesSecretResolver struct {
+	Get SecretGetter
+}
+
+// ResolveSecretKeys implements SecretKeyResolver.
+func (r KubernetesSecretResolver) ResolveSecretKeys(ctx context.Context, namespace string, refs []components_v1alpha1.SecretKeyRef) (map[components_v1alpha1.SecretKeyRef][]byte, error) {
+	secrets := map[string]map[string][]byte{}
+	values := map[components_v1alpha1.SecretKeyRef][]byte{}
+
+	for _, ref := range refs {
+		data, ok := secrets[ref.Name]
+		if !ok {
+			var err error
+			if data, err = r.Get(ctx, namespace, ref.Name); err != nil {
+				return nil, err
+			}
+			secrets[ref.Name] = data
+		}
+
+		if value, ok := data[secretKey(ref)]; ok {
+			values[ref] = value
+		}
+	}
+	return values, nil
+}
+
+// EnvSecretResolver resolves references from environment variables, for components loaded outside of a cluster. The
+// variable of a reference is its key, or name without key, upper-cased with the other characters than letters and
+// digits replaced by underscores, like DB_PASSWORD for db-password, after the optional prefix.
+type EnvSecretResolver struct {
+	Prefix string
+}
+
+// ResolveSecretKeys implements SecretKeyResolver.
+func (r EnvSecretResolver) ResolveSecretKeys(ctx context.Context, namespace string, refs []components_v1alpha1.SecretKeyRef) (map[components_v1alpha1.SecretKeyRef][]byte, error) {
+	values := map[components_v1alpha1.SecretKeyRef][]byte{}
+	for _, ref := range refs {
+		if value, ok := os.LookupEnv(r.Prefix + EnvSecretName(secretKey(ref))); ok {
+			values[ref] = []byte(value)
+		}
+	}
+	return values, nil
+}
+
+// EnvSecretName returns the environment variable name of a secret key.
+func EnvSecretName(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return '_'
+		}
+		return unicode.ToUpper(r)
+	}, key)
+}
+
+// SecretValueEncoder encodes a resolved secret into a metadata value.
+type SecretValueEncoder func(value []byte) ([]byte, error)
+
+// PlainSecretValue encodes secrets as JSON strings.
+func PlainSecretValue(value []byte) ([]byte, error) {
+	return json.Marshal(string(value))
+}
+
+// Base64SecretValue encodes secrets as JSON strings of their base64 encoding, like the operator sends them to the
+// runtime.
+func Base64SecretValue(value []byte) ([]byte, error) {
+	return json.Marshal(base64.StdEncoding.EncodeToString(value))
+}
+
+// UsesKubernetesSecrets returns whether the secret key references of a component resolve from Kubernetes Secrets.
+func UsesKubernetesSecrets(component components_v1alpha1.Component) bool {
+	return component.Auth.SecretStore == "" || component.Auth.SecretStore == kubernetesSecretStore
+}
+
+// ResolveSecretKeyRefs sets the metadata values of the secret key references of the components using Kubernetes
+// Secrets. The references of all components are resolved in one batch.
+func ResolveSecretKeyRefs(ctx context.Context, components []*components_v1alpha1.Component, namespace string, resolver SecretKeyResolver, encode SecretValueEncoder) error {
+	var refs []components_v1alpha1.SecretKeyRef
+	for _, component := range components {
+		if !UsesKubernetesSecrets(*component) {
+			continue
+		}
+		for _, item := range component.Spec.Metadata {
+			if item.SecretKeyRef.Name != "" {
+				refs = append(refs, item.SecretKeyRef)
+			}
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	values, err := resolver.ResolveSecretKeys(ctx, namespace, refs)
+	if err != nil {
+		return err
+	}
+
+	for _, component := range components {
+		if !UsesKubernetesSecrets(*component) {
+			continue
+		}
+		for i, item := range component.Spec.Metadata {
+			value, ok := values[item.SecretKeyRef]
+			if item.SecretKeyRef.Name == "" || !ok {
+				continue
+			}
+
+			raw, err := encode(value)
+			if err != nil {
+				return err
+			}
+			component.Spec.Metadata[i].Value = components_v1alpha1.DynamicValue{JSON: v1.JSON{Raw: raw}}
+		}
+	}
+	return nil
+}
+
+// secretKey returns the key of a reference, its name if no key given
+func secretKey(ref components_v1alpha1.SecretKeyRef) string {
+	if ref.Key == "" {
+		return ref.Name
+	}
+	return ref.Key
+}
@@ -0,0 +1,111 @@
+package components
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+func newSecretComponent(secretStore string, refs ...components_v1alpha1.SecretKeyRef) *components_v1alpha1.Component {
+	component := &components_v1alpha1.Component{Auth: components_v1alpha1.Auth{SecretStore: secretStore}}
+	for _, ref := range refs {
+		component.Spec.Metadata = append(component.Spec.Metadata, components_v1alpha1.MetadataItem{Name: ref.Name, SecretKeyRef: ref})
+	}
+	return component
+}
+
+func TestResolveSecretKeyRefs(t *testing.T) {
+	secrets := map[string]map[string][]byte{
+		"db":    {"password": []byte("secret"), "user": []byte("admin")},
+		"token": {"token": []byte("abc")},
+	}
+
+	t.Run("secrets are fetched once", func(t *testing.T) {
+		calls := map[string]int{}
+		resolver := KubernetesSecretResolver{Get: func(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+			assert.Equal(t, "default", namespace)
+			calls[name]++
+			return secrets[name], nil
+		}}
+
+		state := newSecretComponent("", components_v1alpha1.SecretKeyRef{Name: "db", Key: "password"}, components_v1alpha1.SecretKeyRef{Name: "db", Key: "user"})
+		pubsub := newSecretComponent(kubernetesSecretStore, components_v1alpha1.SecretKeyRef{Name: "token"}, components_v1alpha1.SecretKeyRef{Name: "db", Key: "missing"})
+		vault := newSecretComponent("vault", components_v1alpha1.SecretKeyRef{Name: "vault"})
+
+		err := ResolveSecretKeyRefs(context.Background(), []*components_v1alpha1.Component{state, pubsub, vault}, "default", resolver, PlainSecretValue)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{"db": 1, "token": 1}, calls)
+
+		assert.Equal(t, `"secret"`, string(state.Spec.Metadata[0].Value.Raw))
+		assert.Equal(t, `"admin"`, string(state.Spec.Metadata[1].Value.Raw))
+		assert.Equal(t, `"abc"`, string(pubsub.Spec.Metadata[0].Value.Raw))
+		assert.Empty(t, pubsub.Spec.Metadata[1].Value.Raw)
+		assert.Empty(t, vault.Spec.Metadata[0].Value.Raw)
+	})
+
+	t.Run("base64 values", func(t *testing.T) {
+		resolver := KubernetesSecretResolver{Get: func(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+			return secrets[name], nil
+		}}
+
+		component := newSecretComponent("", components_v1alpha1.SecretKeyRef{Name: "token"})
+		err := ResolveSecretKeyRefs(context.Background(), []*components_v1alpha1.Component{component}, "default", resolver, Base64SecretValue)
+		assert.NoError(t, err)
+		assert.Equal(t, `"YWJj"`, string(component.Spec.Metadata[0].Value.Raw))
+	})
+
+	t.Run("errors are returned", func(t *testing.T) {
+		resolver := KubernetesSecretResolver{Get: func(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+			return nil, errors.New("forbidden")
+		}}
+
+		component := newSecretComponent("", components_v1alpha1.SecretKeyRef{Name: "token"})
+		err := ResolveSecretKeyRefs(context.Background(), []*components_v1alpha1.Component{component}, "default", resolver, PlainSecretValue)
+		assert.EqualError(t, err, "forbidden")
+	})
+
+	t.Run("environment variables", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD", "secret")
+		t.Setenv("APP_TOKEN", "abc")
+
+		component := newSecretComponent("", components_v1alpha1.SecretKeyRef{Name: "db", Key: "db-password"}, components_v1alpha1.SecretKeyRef{Name: "other"})
+		err := ResolveSecretKeyRefs(context.Background(), []*components_v1alpha1.Component{component}, "", EnvSecretResolver{}, PlainSecretValue)
+		assert.NoError(t, err)
+		assert.Equal(t, `"secret"`, string(component.Spec.Metadata[0].Value.Raw))
+		assert.Empty(t, component.Spec.Metadata[1].Value.Raw)
+
+		component = newSecretComponent("", components_v1alpha1.SecretKeyRef{Name: "token"})
+		err = ResolveSecretKeyRefs(context.Background(), []*components_v1alpha1.Component{component}, "", EnvSecretResolver{Prefix: "APP_"}, PlainSecretValue)
+		assert.NoError(t, err)
+		assert.Equal(t, `"abc"`, string(component.Spec.Metadata[0].Value.Raw))
+	})
+}
+
+func TestEnvSecretName(t *testing.T) {
+	assert.Equal(t, "DB_PASSWORD", EnvSecretName("db-password"))
+	assert.Equal(t, "REDIS_HOST_1", EnvSecretName("redis.host_1"))
+}
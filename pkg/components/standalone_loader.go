@@ -23,6 +23,8 @@ package components
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -31,6 +33,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	config "github.com/bhojpur/application/pkg/config/modes"
+	"github.com/bhojpur/application/pkg/fswatcher"
 	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
 )
 
@@ -72,6 +75,64 @@ func (s *StandaloneComponents) LoadComponents() ([]components_v1alpha1.Component
 	return list, nil
 }
 
+// WatchComponents watches the components directory and calls the handler with every component of
+// the directory whenever a manifest is created or written, until ctx is done. Removed components
+// are not unloaded, same as with the Kubernetes informer.
+func (s *StandaloneComponents) WatchComponents(ctx context.Context, handler ComponentHandler) error {
+	events := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- fswatcher.Watch(ctx, filepath.Clean(s.config.ComponentsPath), events)
+	}()
+
+	for {
+		select {
+		case <-events:
+			comps, err := s.LoadComponents()
+			if err != nil {
+				log.Warnf("Bhojpur Application runtime failed to reload components from %s: %s", s.config.ComponentsPath, err)
+				continue
+			}
+			for _, comp := range comps {
+				handler.OnComponentUpdated(comp)
+			}
+		case err := <-done:
+			return err
+		}
+	}
+}
+
+// ValidateComponents validates the Bhojpur Application components of a given directory,
+// returning the parsing and metadata errors of every file.
+func (s *StandaloneComponents) ValidateComponents() ([]components_v1alpha1.Component, []error) {
+	files, err := os.ReadDir(s.config.ComponentsPath)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	list := []components_v1alpha1.Component{}
+	errs := []error{}
+	for _, file := range files {
+		if file.IsDir() || !s.isYaml(file.Name()) {
+			continue
+		}
+
+		path := filepath.Join(s.config.ComponentsPath, file.Name())
+		b, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+
+		components, decodeErrs := s.decodeYaml(b)
+		list = append(list, components...)
+		for _, err := range decodeErrs {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return list, errs
+}
+
 func (s *StandaloneComponents) loadComponentsFromFile(filename string) []components_v1alpha1.Component {
 	var errors []error
 
@@ -142,6 +203,12 @@ func (s *StandaloneComponents) decodeYaml(b []byte) ([]components_v1alpha1.Compo
 			continue
 		}
 
+		if err := ValidateComponent(comp); err != nil {
+			errors = append(errors, err)
+
+			continue
+		}
+
 		list = append(list, comp)
 	}
 
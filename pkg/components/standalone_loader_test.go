@@ -21,14 +21,17 @@ package components
 // THE SOFTWARE.
 
 import (
+	"context"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	config "github.com/bhojpur/application/pkg/config/modes"
+	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
 )
 
 const configPrefix = "."
@@ -303,3 +306,77 @@ spec:
 	assert.Equal(t, "prop3", components[1].Spec.Metadata[0].Name)
 	assert.Equal(t, "value3", components[1].Spec.Metadata[0].Value.String())
 }
+
+func TestStandaloneDecodeValidatesMetadata(t *testing.T) {
+	RegisterSchemas(MetadataSchema{
+		Type:   "state.schemaloadertest",
+		Fields: []MetadataField{{Name: "host", Type: StringField, Required: true}},
+	})
+
+	request := &StandaloneComponents{
+		config: config.StandaloneConfig{
+			ComponentsPath: "test_component_path",
+		},
+	}
+	yaml := `
+apiVersion: bhojpur.net/v1alpha1
+kind: Component
+metadata:
+    name: statestore1
+spec:
+  type: state.schemaloadertest
+  metadata:
+    - name: host
+      value: localhost
+---
+apiVersion: bhojpur.net/v1alpha1
+kind: Component
+metadata:
+    name: statestore2
+spec:
+  type: state.schemaloadertest
+  metadata:
+    - name: port
+      value: 6379
+`
+	components, errs := request.decodeYaml([]byte(yaml))
+	assert.Len(t, components, 1)
+	assert.Equal(t, "statestore1", components[0].Name)
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], `invalid component statestore2 (state.schemaloadertest): metadata field "host": required field is missing`)
+}
+
+func TestWatchComponents(t *testing.T) {
+	dir := t.TempDir()
+	loader := NewStandaloneComponents(config.StandaloneConfig{ComponentsPath: dir})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan components_v1alpha1.Component, 1)
+	go loader.WatchComponents(ctx, ComponentHandlerFunc(func(component components_v1alpha1.Component) {
+		updates <- component
+	}))
+	// give the watcher time to start
+	time.Sleep(100 * time.Millisecond)
+
+	yaml := `
+apiVersion: bhojpur.net/v1alpha1
+kind: Component
+metadata:
+  name: statestore
+spec:
+  type: state.couchbase
+  metadata:
+  - name: prop1
+    value: value1
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "statestore.yaml"), []byte(yaml), fs.FileMode(0644)))
+
+	select {
+	case component := <-updates:
+		assert.Equal(t, "statestore", component.Name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("component update not received")
+	}
+}
@@ -0,0 +1,121 @@
+package embedded
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type boltBackend struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+type boltTransaction struct {
+	bucket *bolt.Bucket
+}
+
+func openBolt(path, bucketName string) (backend, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	b := &boltBackend{db: db, bucket: []byte(bucketName)}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(b.bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *boltBackend) view(fn func(tx transaction) error) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		return fn(&boltTransaction{bucket: tx.Bucket(b.bucket)})
+	})
+}
+
+func (b *boltBackend) update(fn func(tx transaction) error) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTransaction{bucket: tx.Bucket(b.bucket)})
+	})
+}
+
+func (b *boltBackend) purgeExpired(now time.Time) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.bucket)
+		var expired [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			var e entry
+			if err := json.Unmarshal(v, &e); err == nil && e.expired(now) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err = bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *boltBackend) close() error {
+	return b.db.Close()
+}
+
+func (t *boltTransaction) get(key string) (*entry, error) {
+	v := t.bucket.Get([]byte(key))
+	if v == nil {
+		return nil, nil
+	}
+
+	var e entry
+	if err := json.Unmarshal(v, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (t *boltTransaction) put(key string, e *entry) error {
+	v, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return t.bucket.Put([]byte(key), v)
+}
+
+func (t *boltTransaction) delete(key string) error {
+	return t.bucket.Delete([]byte(key))
+}
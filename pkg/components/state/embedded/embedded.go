@@ -0,0 +1,342 @@
+package embedded
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bhojpur/service/pkg/state"
+	"github.com/bhojpur/service/pkg/state/utils"
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+const (
+	// BoltDriver stores the state in a bbolt file, it is the default driver.
+	BoltDriver = "bolt"
+	// SQLiteDriver stores the state in a SQLite database.
+	SQLiteDriver = "sqlite"
+
+	driverKey       = "driver"
+	pathKey         = "path"
+	tableNameKey    = "tableName"
+	ttlInSecondsKey = "ttlInSeconds"
+
+	defaultPath      = "app_state.db"
+	defaultTableName = "state"
+)
+
+// entry is a value of the embedded store, with its etag and expiry.
+type entry struct {
+	Value   []byte    `json:"value"`
+	ETag    uint64    `json:"etag"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && now.After(e.Expires)
+}
+
+// transaction reads and writes the entries of a backend atomically.
+type transaction interface {
+	get(key string) (*entry, error)
+	put(key string, e *entry) error
+	delete(key string) error
+}
+
+// backend is the storage of the embedded store.
+type backend interface {
+	view(fn func(tx transaction) error) error
+	update(fn func(tx transaction) error) error
+	purgeExpired(now time.Time) error
+	close() error
+}
+
+type embeddedMetadata struct {
+	driver    string
+	path      string
+	tableName string
+	ttl       time.Duration
+}
+
+// Store is a state store embedded in the runtime, it keeps the state in a local
+// file so the application can run as a single binary, e.g. on edge devices.
+type Store struct {
+	state.DefaultBulkStore
+
+	backend backend
+	ttl     time.Duration
+	logger  logger.Logger
+}
+
+// NewEmbeddedStateStore returns a new embedded state store.
+func NewEmbeddedStateStore(logger logger.Logger) *Store {
+	s := &Store{logger: logger}
+	s.DefaultBulkStore = state.NewDefaultBulkStore(s)
+
+	return s
+}
+
+// Init opens the store file with the configured driver.
+func (s *Store) Init(metadata state.Metadata) error {
+	meta, err := parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	switch meta.driver {
+	case BoltDriver:
+		s.backend, err = openBolt(meta.path, meta.tableName)
+	case SQLiteDriver:
+		s.backend, err = openSQLite(meta.path, meta.tableName)
+	default:
+		return fmt.Errorf("embedded store error: unknown driver %q, expected %s or %s", meta.driver, BoltDriver, SQLiteDriver)
+	}
+	if err != nil {
+		return fmt.Errorf("embedded store error: can't open %s: %w", meta.path, err)
+	}
+
+	s.ttl = meta.ttl
+	if err = s.backend.purgeExpired(time.Now()); err != nil {
+		s.logger.Warnf("embedded store: failed to purge expired state: %s", err)
+	}
+	s.logger.Debugf("embedded store: opened %s with the %s driver", meta.path, meta.driver)
+
+	return nil
+}
+
+func parseMetadata(metadata state.Metadata) (embeddedMetadata, error) {
+	meta := embeddedMetadata{
+		driver:    BoltDriver,
+		path:      defaultPath,
+		tableName: defaultTableName,
+	}
+
+	if val, ok := metadata.Properties[driverKey]; ok && val != "" {
+		meta.driver = val
+	}
+	if val, ok := metadata.Properties[pathKey]; ok && val != "" {
+		meta.path = val
+	}
+	if val, ok := metadata.Properties[tableNameKey]; ok && val != "" {
+		meta.tableName = val
+	}
+
+	ttl, err := parseTTL(metadata.Properties)
+	if err != nil {
+		return meta, err
+	}
+	if ttl > 0 {
+		meta.ttl = ttl
+	}
+
+	return meta, nil
+}
+
+// parseTTL returns the ttlInSeconds of the metadata, zero if missing and a negative duration to disable expiry.
+func parseTTL(metadata map[string]string) (time.Duration, error) {
+	val, ok := metadata[ttlInSecondsKey]
+	if !ok || val == "" {
+		return 0, nil
+	}
+
+	seconds, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("embedded store error: can't parse ttlInSeconds field: %s", err)
+	}
+	if seconds <= 0 {
+		return -1, nil
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// Features returns the features available in this state store.
+func (s *Store) Features() []state.Feature {
+	return []state.Feature{state.FeatureETag, state.FeatureTransactional}
+}
+
+// Ping checks that the store file is readable.
+func (s *Store) Ping() error {
+	return s.backend.view(func(tx transaction) error {
+		_, err := tx.get("")
+		return err
+	})
+}
+
+// Get returns the state of a key.
+func (s *Store) Get(req *state.GetRequest) (*state.GetResponse, error) {
+	var e *entry
+	err := s.backend.view(func(tx transaction) (err error) {
+		e, err = tx.get(req.Key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if e == nil || e.expired(time.Now()) {
+		return &state.GetResponse{}, nil
+	}
+
+	etag := strconv.FormatUint(e.ETag, 10)
+	return &state.GetResponse{
+		Data: e.Value,
+		ETag: &etag,
+	}, nil
+}
+
+// Set saves the state of a key.
+func (s *Store) Set(req *state.SetRequest) error {
+	return s.backend.update(func(tx transaction) error {
+		return s.set(tx, req)
+	})
+}
+
+// Delete removes the state of a key.
+func (s *Store) Delete(req *state.DeleteRequest) error {
+	return s.backend.update(func(tx transaction) error {
+		return s.delete(tx, req)
+	})
+}
+
+// Multi applies the operations of a transaction atomically.
+func (s *Store) Multi(request *state.TransactionalStateRequest) error {
+	return s.backend.update(func(tx transaction) error {
+		for _, o := range request.Operations {
+			switch o.Operation {
+			case state.Upsert:
+				req, ok := o.Request.(state.SetRequest)
+				if !ok {
+					return fmt.Errorf("embedded store error: expected a set request, got %T", o.Request)
+				}
+				if err := s.set(tx, &req); err != nil {
+					return err
+				}
+			case state.Delete:
+				req, ok := o.Request.(state.DeleteRequest)
+				if !ok {
+					return fmt.Errorf("embedded store error: expected a delete request, got %T", o.Request)
+				}
+				if err := s.delete(tx, &req); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("embedded store error: unsupported operation %s", o.Operation)
+			}
+		}
+		return nil
+	})
+}
+
+// Close closes the store file.
+func (s *Store) Close() error {
+	if s.backend == nil {
+		return nil
+	}
+	return s.backend.close()
+}
+
+func (s *Store) set(tx transaction, req *state.SetRequest) error {
+	if err := state.CheckRequestOptions(req.Options); err != nil {
+		return err
+	}
+
+	ttl, err := parseTTL(req.Metadata)
+	if err != nil {
+		return err
+	}
+	if ttl == 0 {
+		ttl = s.ttl
+	}
+
+	value, err := utils.Marshal(req.Value, json.Marshal)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.current(tx, req.Key)
+	if err != nil {
+		return err
+	}
+	if err = checkETag(current, req.ETag, req.Options.Concurrency); err != nil {
+		return err
+	}
+
+	e := &entry{Value: value, ETag: 1}
+	if current != nil {
+		e.ETag = current.ETag + 1
+	}
+	if ttl > 0 {
+		e.Expires = time.Now().Add(ttl)
+	}
+
+	return tx.put(req.Key, e)
+}
+
+func (s *Store) delete(tx transaction, req *state.DeleteRequest) error {
+	if err := state.CheckRequestOptions(req.Options); err != nil {
+		return err
+	}
+
+	current, err := s.current(tx, req.Key)
+	if err != nil {
+		return err
+	}
+	if err = checkETag(current, req.ETag, req.Options.Concurrency); err != nil {
+		return err
+	}
+	if current == nil {
+		return nil
+	}
+
+	return tx.delete(req.Key)
+}
+
+// current returns the entry of a key, nil if missing or expired.
+func (s *Store) current(tx transaction, key string) (*entry, error) {
+	e, err := tx.get(key)
+	if err != nil || e == nil || e.expired(time.Now()) {
+		return nil, err
+	}
+	return e, nil
+}
+
+func checkETag(current *entry, etag *string, concurrency string) error {
+	if etag == nil || *etag == "" {
+		if concurrency == state.FirstWrite && current != nil {
+			return state.NewETagError(state.ETagMismatch, errors.New("first-write requires an etag to update an existing key"))
+		}
+		return nil
+	}
+
+	value, err := strconv.ParseUint(*etag, 10, 64)
+	if err != nil {
+		return state.NewETagError(state.ETagInvalid, err)
+	}
+	if current == nil || current.ETag != value {
+		return state.NewETagError(state.ETagMismatch, nil)
+	}
+	return nil
+}
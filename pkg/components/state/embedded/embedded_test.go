@@ -0,0 +1,112 @@
+package embedded
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bhojpur/service/pkg/state"
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+func TestEmbeddedStore(t *testing.T) {
+	for _, driver := range []string{BoltDriver, SQLiteDriver} {
+		t.Run(driver, func(t *testing.T) {
+			s := NewEmbeddedStateStore(logger.NewLogger("test"))
+			err := s.Init(state.Metadata{Properties: map[string]string{
+				driverKey: driver,
+				pathKey:   filepath.Join(t.TempDir(), "state.db"),
+			}})
+			require.NoError(t, err)
+			defer s.Close()
+
+			assert.NoError(t, s.Ping())
+
+			t.Run("set and get", func(t *testing.T) {
+				require.NoError(t, s.Set(&state.SetRequest{Key: "key", Value: map[string]string{"name": "value"}}))
+
+				res, err := s.Get(&state.GetRequest{Key: "key"})
+				require.NoError(t, err)
+				assert.Equal(t, `{"name":"value"}`, string(res.Data))
+				assert.Equal(t, "1", *res.ETag)
+			})
+
+			t.Run("etag mismatch", func(t *testing.T) {
+				etag := "42"
+				err := s.Set(&state.SetRequest{Key: "key", Value: "value", ETag: &etag})
+				assert.IsType(t, &state.ETagError{}, err)
+
+				err = s.Set(&state.SetRequest{Key: "key", Value: "value", Options: state.SetStateOption{Concurrency: state.FirstWrite}})
+				assert.IsType(t, &state.ETagError{}, err)
+			})
+
+			t.Run("transaction is atomic", func(t *testing.T) {
+				etag := "42"
+				err := s.Multi(&state.TransactionalStateRequest{
+					Operations: []state.TransactionalStateOperation{
+						{Operation: state.Upsert, Request: state.SetRequest{Key: "other", Value: []byte("value")}},
+						{Operation: state.Delete, Request: state.DeleteRequest{Key: "key", ETag: &etag}},
+					},
+				})
+				assert.Error(t, err)
+
+				res, err := s.Get(&state.GetRequest{Key: "other"})
+				require.NoError(t, err)
+				assert.Nil(t, res.Data)
+			})
+
+			t.Run("delete", func(t *testing.T) {
+				require.NoError(t, s.Delete(&state.DeleteRequest{Key: "key"}))
+
+				res, err := s.Get(&state.GetRequest{Key: "key"})
+				require.NoError(t, err)
+				assert.Nil(t, res.Data)
+			})
+
+			t.Run("ttl", func(t *testing.T) {
+				require.NoError(t, s.Set(&state.SetRequest{Key: "ttl", Value: []byte("value"), Metadata: map[string]string{ttlInSecondsKey: "60"}}))
+
+				var e *entry
+				require.NoError(t, s.backend.view(func(tx transaction) (err error) {
+					e, err = tx.get("ttl")
+					return err
+				}))
+				assert.False(t, e.expired(time.Now()))
+				assert.True(t, e.expired(time.Now().Add(time.Minute+time.Second)))
+			})
+		})
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	meta, err := parseMetadata(state.Metadata{Properties: map[string]string{}})
+	require.NoError(t, err)
+	assert.Equal(t, BoltDriver, meta.driver)
+	assert.Equal(t, defaultPath, meta.path)
+
+	_, err = parseMetadata(state.Metadata{Properties: map[string]string{ttlInSecondsKey: "soon"}})
+	assert.Error(t, err)
+}
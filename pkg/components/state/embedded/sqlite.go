@@ -0,0 +1,123 @@
+package embedded
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	// register the sqlite3 driver.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type sqliteBackend struct {
+	db    *sql.DB
+	table string
+}
+
+type sqliteTransaction struct {
+	tx    *sql.Tx
+	table string
+}
+
+func openSQLite(path, tableName string) (backend, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, serialize the transactions instead of failing with SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q (
+		key TEXT NOT NULL PRIMARY KEY,
+		value BLOB,
+		etag INTEGER NOT NULL,
+		expires INTEGER
+	)`, tableName))
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqliteBackend{db: db, table: tableName}, nil
+}
+
+func (b *sqliteBackend) view(fn func(tx transaction) error) error {
+	return b.update(fn)
+}
+
+func (b *sqliteBackend) update(fn func(tx transaction) error) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = fn(&sqliteTransaction{tx: tx, table: b.table}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (b *sqliteBackend) purgeExpired(now time.Time) error {
+	_, err := b.db.Exec(fmt.Sprintf(`DELETE FROM %q WHERE expires IS NOT NULL AND expires < ?`, b.table), now.UnixNano())
+	return err
+}
+
+func (b *sqliteBackend) close() error {
+	return b.db.Close()
+}
+
+func (t *sqliteTransaction) get(key string) (*entry, error) {
+	var (
+		e       entry
+		expires sql.NullInt64
+	)
+	err := t.tx.QueryRow(fmt.Sprintf(`SELECT value, etag, expires FROM %q WHERE key = ?`, t.table), key).Scan(&e.Value, &e.ETag, &expires)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if expires.Valid {
+		e.Expires = time.Unix(0, expires.Int64)
+	}
+	return &e, nil
+}
+
+func (t *sqliteTransaction) put(key string, e *entry) error {
+	var expires sql.NullInt64
+	if !e.Expires.IsZero() {
+		expires = sql.NullInt64{Int64: e.Expires.UnixNano(), Valid: true}
+	}
+
+	_, err := t.tx.Exec(fmt.Sprintf(`INSERT INTO %q (key, value, etag, expires) VALUES (?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, etag = excluded.etag, expires = excluded.expires`, t.table),
+		key, e.Value, e.ETag, expires)
+	return err
+}
+
+func (t *sqliteTransaction) delete(key string) error {
+	_, err := t.tx.Exec(fmt.Sprintf(`DELETE FROM %q WHERE key = ?`, t.table), key)
+	return err
+}
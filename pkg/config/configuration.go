@@ -32,7 +32,6 @@ import (
 	"github.com/pkg/errors"
 	yaml "gopkg.in/yaml.v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/sets"
 
 	operatorv1pb "github.com/bhojpur/api/pkg/core/v1/operator"
 )
@@ -99,6 +98,7 @@ type ConfigurationSpec struct {
 	NameResolutionSpec NameResolutionSpec `json:"nameResolution,omitempty" yaml:"nameResolution,omitempty"`
 	Features           []FeatureSpec      `json:"features,omitempty" yaml:"features,omitempty"`
 	APISpec            APISpec            `json:"api,omitempty" yaml:"api,omitempty"`
+	QuotaSpec          QuotaSpec          `json:"quotas,omitempty" yaml:"quotas,omitempty"`
 }
 
 type SecretsSpec struct {
@@ -159,6 +159,10 @@ type ZipkinSpec struct {
 // MetricSpec configuration for metrics.
 type MetricSpec struct {
 	Enabled bool `json:"enabled" yaml:"enabled"`
+	// HighCardinalityLabels enables labels with unbounded values, such as record IDs and raw paths.
+	HighCardinalityLabels bool `json:"highCardinalityLabels,omitempty" yaml:"highCardinalityLabels,omitempty"`
+	// Modules enables or disables the metric groups of modules, such as resource, roles and operator.
+	Modules map[string]bool `json:"modules,omitempty" yaml:"modules,omitempty"`
 }
 
 // AppPolicySpec defines the policy data structure for each app.
@@ -203,6 +207,15 @@ type SpiffeID struct {
 	AppID       string
 }
 
+// QuotaSpec defines the limits of the objects of a namespace, a zero limit is unlimited.
+// The runtime enforces MaxActorTypes, the other limits are enforced by the operator.
+type QuotaSpec struct {
+	MaxComponentsPerType map[string]int `json:"maxComponentsPerType,omitempty" yaml:"maxComponentsPerType,omitempty"`
+	MaxActorTypes        int            `json:"maxActorTypes,omitempty" yaml:"maxActorTypes,omitempty"`
+	MaxSubscriptions     int            `json:"maxSubscriptions,omitempty" yaml:"maxSubscriptions,omitempty"`
+	Enforcement          string         `json:"enforcement,omitempty" yaml:"enforcement,omitempty"`
+}
+
 // FeatureSpec defines which preview features are enabled.
 type FeatureSpec struct {
 	Name    Feature `json:"name" yaml:"name"`
@@ -247,7 +260,7 @@ func LoadStandaloneConfiguration(config string) (*Configuration, string, error)
 	if err != nil {
 		return nil, string(b), err
 	}
-	err = sortAndValidateSecretsConfiguration(conf)
+	err = validateAndSortConfiguration(conf)
 	if err != nil {
 		return nil, string(b), err
 	}
@@ -274,7 +287,7 @@ func LoadKubernetesConfiguration(config, namespace string, podName string, opera
 		return nil, err
 	}
 
-	err = sortAndValidateSecretsConfiguration(conf)
+	err = validateAndSortConfiguration(conf)
 	if err != nil {
 		return nil, err
 	}
@@ -284,28 +297,34 @@ func LoadKubernetesConfiguration(config, namespace string, podName string, opera
 
 // Validate the secrets configuration and sort to the allowed and denied lists if present.
 func sortAndValidateSecretsConfiguration(conf *Configuration) error {
-	scopes := conf.Spec.Secrets.Scopes
-	set := sets.NewString()
-	for _, scope := range scopes {
-		// validate scope
-		if set.Has(scope.StoreName) {
-			return errors.Errorf("%q storeName is repeated in secrets configuration", scope.StoreName)
-		}
-		if scope.DefaultAccess != "" &&
-			!strings.EqualFold(scope.DefaultAccess, AllowAccess) &&
-			!strings.EqualFold(scope.DefaultAccess, DenyAccess) {
-			return errors.Errorf("defaultAccess %q can be either allow or deny", scope.DefaultAccess)
-		}
-		set.Insert(scope.StoreName)
+	report := &ValidationReport{}
+	validateSecrets(report, "configuration", conf.Spec.Secrets)
+	if err := report.Err(); err != nil {
+		return err
+	}
 
-		// modify scope
-		sort.Strings(scope.AllowedSecrets)
-		sort.Strings(scope.DeniedSecrets)
+	sortSecretsConfiguration(conf)
+	return nil
+}
+
+// validateAndSortConfiguration validates the whole configuration, failing with a report of every
+// issue found, and sorts the allowed and denied lists of the secrets configuration.
+func validateAndSortConfiguration(conf *Configuration) error {
+	if err := conf.Validate().Err(); err != nil {
+		return err
 	}
 
+	sortSecretsConfiguration(conf)
 	return nil
 }
 
+func sortSecretsConfiguration(conf *Configuration) {
+	for _, scope := range conf.Spec.Secrets.Scopes {
+		sort.Strings(scope.AllowedSecrets)
+		sort.Strings(scope.DeniedSecrets)
+	}
+}
+
 // IsSecretAllowed Check if the secret is allowed to be accessed.
 func (c SecretsScope) IsSecretAllowed(key string) bool {
 	// By default, set allow access for the secret store.
@@ -37,4 +37,10 @@ const (
 	AppPort string = "APP_PORT"
 	// AppID is the ID of the application.
 	AppID string = "APP_ID"
+	// InformerNamespaces is the comma separated list of namespaces watched by the Bhojpur Application informers.
+	InformerNamespaces string = "INFORMER_NAMESPACES"
+	// InformerLabelSelector is the label selector applied to the Bhojpur Application informers.
+	InformerLabelSelector string = "INFORMER_LABEL_SELECTOR"
+	// InformerFieldSelector is the field selector applied to the Bhojpur Application informers.
+	InformerFieldSelector string = "INFORMER_FIELD_SELECTOR"
 )
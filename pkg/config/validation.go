@@ -0,0 +1,264 @@
+package config
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationSeverity is the severity of a configuration issue.
+type ValidationSeverity string
+
+const (
+	// SeverityError marks issues which prevent the runtime from starting.
+	SeverityError ValidationSeverity = "error"
+	// SeverityWarning marks issues which are reported, but tolerated.
+	SeverityWarning ValidationSeverity = "warning"
+)
+
+var knownFeatures = []Feature{ActorReentrancy, ActorTypeMetadata, PubSubRouting, StateEncryption}
+
+// ValidationIssue is a problem found while validating a configuration.
+type ValidationIssue struct {
+	Severity ValidationSeverity
+	// Source is the configuration the issue was found in, eg. "configuration appconfig" or "component statestore".
+	Source string
+	// Path is the path of the offending field in the source, eg. "spec.secrets.scopes[0].defaultAccess".
+	Path       string
+	Message    string
+	Suggestion string
+}
+
+func (i ValidationIssue) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s", i.Severity, i.Source)
+	if i.Path != "" {
+		fmt.Fprintf(&b, " %s", i.Path)
+	}
+	fmt.Fprintf(&b, ": %s", i.Message)
+	if i.Suggestion != "" {
+		fmt.Fprintf(&b, " (%s)", i.Suggestion)
+	}
+	return b.String()
+}
+
+// ValidationReport aggregates the issues of all loaded configuration, so they can be
+// reported at once instead of failing on the first one.
+type ValidationReport struct {
+	Issues []ValidationIssue
+}
+
+// AddError adds an issue which prevents the runtime from starting.
+func (r *ValidationReport) AddError(source, path, message, suggestion string) {
+	r.Issues = append(r.Issues, ValidationIssue{Severity: SeverityError, Source: source, Path: path, Message: message, Suggestion: suggestion})
+}
+
+// AddWarning adds an issue which is tolerated.
+func (r *ValidationReport) AddWarning(source, path, message, suggestion string) {
+	r.Issues = append(r.Issues, ValidationIssue{Severity: SeverityWarning, Source: source, Path: path, Message: message, Suggestion: suggestion})
+}
+
+// Merge adds the issues of another report.
+func (r *ValidationReport) Merge(other *ValidationReport) {
+	if other != nil {
+		r.Issues = append(r.Issues, other.Issues...)
+	}
+}
+
+// HasErrors returns true if the report contains issues of error severity.
+func (r *ValidationReport) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns the report as an error if it contains issues of error severity, and nil otherwise.
+func (r *ValidationReport) Err() error {
+	if r.HasErrors() {
+		return r
+	}
+	return nil
+}
+
+func (r *ValidationReport) Error() string {
+	return r.String()
+}
+
+// String renders the report, one issue per line.
+func (r *ValidationReport) String() string {
+	var errs, warnings int
+	lines := make([]string, 0, len(r.Issues))
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+		lines = append(lines, "  "+issue.String())
+	}
+	return fmt.Sprintf("configuration validation found %d error(s) and %d warning(s):\n%s", errs, warnings, strings.Join(lines, "\n"))
+}
+
+// Validate checks the whole configuration and reports every issue found.
+func (c *Configuration) Validate() *ValidationReport {
+	report := &ValidationReport{}
+	source := "configuration"
+	if c.Name != "" {
+		source = fmt.Sprintf("configuration %s", c.Name)
+	}
+
+	validateSecrets(report, source, c.Spec.Secrets)
+	validateAccessControl(report, source, c.Spec.AccessControlSpec)
+	validateTracing(report, source, c.Spec.TracingSpec)
+	validateMTLS(report, source, c.Spec.MTLSSpec)
+
+	for i, handler := range c.Spec.HTTPPipelineSpec.Handlers {
+		path := fmt.Sprintf("spec.httpPipeline.handlers[%d]", i)
+		if handler.Name == "" {
+			report.AddWarning(source, path+".name", "handler name is empty", "set it to the name of a middleware component")
+		}
+		if !strings.HasPrefix(handler.Type, "middleware.") {
+			report.AddWarning(source, path+".type", fmt.Sprintf("unexpected handler type %q", handler.Type), "middleware types start with \"middleware.\"")
+		}
+	}
+
+	for i, rule := range c.Spec.APISpec.Allowed {
+		path := fmt.Sprintf("spec.api.allowed[%d]", i)
+		if rule.Name == "" {
+			report.AddWarning(source, path+".name", "API name is empty", "")
+		}
+		if rule.Protocol != "" && rule.Protocol != HTTPProtocol && rule.Protocol != GRPCProtocol {
+			report.AddWarning(source, path+".protocol", fmt.Sprintf("unknown protocol %q", rule.Protocol), fmt.Sprintf("use %s or %s", HTTPProtocol, GRPCProtocol))
+		}
+	}
+
+	for i, feature := range c.Spec.Features {
+		if !isKnownFeature(feature.Name) {
+			report.AddWarning(source, fmt.Sprintf("spec.features[%d].name", i), fmt.Sprintf("unknown feature %q", feature.Name), suggestFeature(feature.Name))
+		}
+	}
+
+	return report
+}
+
+func validateSecrets(report *ValidationReport, source string, secrets SecretsSpec) {
+	stores := map[string]int{}
+	for i, scope := range secrets.Scopes {
+		path := fmt.Sprintf("spec.secrets.scopes[%d]", i)
+		if first, ok := stores[scope.StoreName]; ok {
+			report.AddError(source, path+".storeName", fmt.Sprintf("%q storeName is repeated in secrets configuration", scope.StoreName),
+				fmt.Sprintf("merge it with spec.secrets.scopes[%d]", first))
+		} else {
+			stores[scope.StoreName] = i
+		}
+		if !isAccessAction(scope.DefaultAccess, true) {
+			report.AddError(source, path+".defaultAccess", fmt.Sprintf("defaultAccess %q can be either allow or deny", scope.DefaultAccess), "")
+		}
+	}
+}
+
+func validateAccessControl(report *ValidationReport, source string, spec AccessControlSpec) {
+	if !isAccessAction(spec.DefaultAction, true) {
+		report.AddWarning(source, "spec.accessControl.defaultAction", fmt.Sprintf("defaultAction %q can be either allow or deny", spec.DefaultAction), "")
+	}
+
+	for i, policy := range spec.AppPolicies {
+		path := fmt.Sprintf("spec.accessControl.policies[%d]", i)
+		if policy.AppName == "" {
+			report.AddError(source, path+".appId", "app name is missing", "set it to the ID of the calling app")
+		}
+		if policy.TrustDomain == "" {
+			report.AddError(source, path+".trustDomain", "trust domain is missing", fmt.Sprintf("use %q unless the app has its own trust domain", DefaultTrustDomain))
+		}
+		if policy.Namespace == "" {
+			report.AddError(source, path+".namespace", "namespace is missing", fmt.Sprintf("use %q in standalone mode", DefaultNamespace))
+		}
+		if !isAccessAction(policy.DefaultAction, true) {
+			report.AddWarning(source, path+".defaultAction", fmt.Sprintf("defaultAction %q can be either allow or deny", policy.DefaultAction), "")
+		}
+		for j, operation := range policy.AppOperationActions {
+			operationPath := fmt.Sprintf("%s.operations[%d]", path, j)
+			if operation.Operation == "" {
+				report.AddWarning(source, operationPath+".name", "operation name is empty", "use a path such as /invoke/*")
+			}
+			if !isAccessAction(operation.Action, false) {
+				report.AddWarning(source, operationPath+".action", fmt.Sprintf("action %q can be either allow or deny", operation.Action), "")
+			}
+		}
+	}
+}
+
+func validateTracing(report *ValidationReport, source string, spec TracingSpec) {
+	if spec.SamplingRate == "" {
+		return
+	}
+
+	rate, err := strconv.ParseFloat(spec.SamplingRate, 64)
+	if err != nil || rate < 0 || rate > 1 {
+		report.AddWarning(source, "spec.tracing.samplingRate", fmt.Sprintf("invalid sampling rate %q, tracing is disabled", spec.SamplingRate), "use a number between 0 and 1")
+	}
+}
+
+func validateMTLS(report *ValidationReport, source string, spec MTLSSpec) {
+	if spec.WorkloadCertTTL != "" {
+		if _, err := time.ParseDuration(spec.WorkloadCertTTL); err != nil {
+			report.AddWarning(source, "spec.mtls.workloadCertTTL", fmt.Sprintf("invalid duration %q", spec.WorkloadCertTTL), "use a duration such as 24h")
+		}
+	}
+	if spec.AllowedClockSkew != "" {
+		if _, err := time.ParseDuration(spec.AllowedClockSkew); err != nil {
+			report.AddWarning(source, "spec.mtls.allowedClockSkew", fmt.Sprintf("invalid duration %q", spec.AllowedClockSkew), "use a duration such as 15m")
+		}
+	}
+}
+
+func isAccessAction(action string, allowEmpty bool) bool {
+	if action == "" {
+		return allowEmpty
+	}
+	return strings.EqualFold(action, AllowAccess) || strings.EqualFold(action, DenyAccess)
+}
+
+func isKnownFeature(name Feature) bool {
+	for _, feature := range knownFeatures {
+		if feature == name {
+			return true
+		}
+	}
+	return false
+}
+
+func suggestFeature(name Feature) string {
+	names := make([]string, 0, len(knownFeatures))
+	for _, feature := range knownFeatures {
+		if strings.EqualFold(string(feature), string(name)) {
+			return fmt.Sprintf("did you mean %s?", feature)
+		}
+		names = append(names, string(feature))
+	}
+	return fmt.Sprintf("known features are %s", strings.Join(names, ", "))
+}
@@ -0,0 +1,69 @@
+package config
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfiguration(t *testing.T) {
+	t.Run("default configuration is valid", func(t *testing.T) {
+		report := LoadDefaultConfiguration().Validate()
+		assert.Empty(t, report.Issues)
+		assert.NoError(t, report.Err())
+	})
+
+	t.Run("report every issue", func(t *testing.T) {
+		conf := LoadDefaultConfiguration()
+		conf.Name = "appconfig"
+		conf.Spec.Secrets.Scopes = []SecretsScope{
+			{StoreName: "store", DefaultAccess: "maybe"},
+			{StoreName: "store"},
+		}
+		conf.Spec.AccessControlSpec.AppPolicies = []AppPolicySpec{{AppName: "app1"}}
+		conf.Spec.TracingSpec.SamplingRate = "often"
+		conf.Spec.Features = []FeatureSpec{{Name: "actor.reentrancy", Enabled: true}}
+
+		report := conf.Validate()
+		assert.True(t, report.HasErrors())
+		assert.Len(t, report.Issues, 6)
+
+		err := report.Err()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "found 4 error(s) and 2 warning(s)")
+		assert.Contains(t, err.Error(), "error: configuration appconfig spec.secrets.scopes[0].defaultAccess")
+		assert.Contains(t, err.Error(), "spec.secrets.scopes[1].storeName: \"store\" storeName is repeated in secrets configuration (merge it with spec.secrets.scopes[0])")
+		assert.Contains(t, err.Error(), "spec.accessControl.policies[0].namespace")
+		assert.Contains(t, err.Error(), "warning: configuration appconfig spec.features[0].name: unknown feature \"actor.reentrancy\" (did you mean Actor.Reentrancy?)")
+	})
+
+	t.Run("warnings are tolerated", func(t *testing.T) {
+		conf := LoadDefaultConfiguration()
+		conf.Spec.MTLSSpec.WorkloadCertTTL = "one day"
+
+		report := conf.Validate()
+		assert.Len(t, report.Issues, 1)
+		assert.Equal(t, SeverityWarning, report.Issues[0].Severity)
+		assert.NoError(t, report.Err())
+	})
+}
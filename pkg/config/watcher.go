@@ -0,0 +1,77 @@
+package config
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	operatorv1pb "github.com/bhojpur/api/pkg/core/v1/operator"
+)
+
+// ConfigurationLoader loads a configuration, from a file or from the operator.
+type ConfigurationLoader func() (*Configuration, error)
+
+// StandaloneConfigurationLoader returns a loader of a configuration file.
+func StandaloneConfigurationLoader(config string) ConfigurationLoader {
+	return func() (*Configuration, error) {
+		conf, _, err := LoadStandaloneConfiguration(config)
+		return conf, err
+	}
+}
+
+// KubernetesConfigurationLoader returns a loader of a configuration from the Kubernetes operator.
+func KubernetesConfigurationLoader(config, namespace string, podName string, operatorClient operatorv1pb.OperatorClient) ConfigurationLoader {
+	return func() (*Configuration, error) {
+		return LoadKubernetesConfiguration(config, namespace, podName, operatorClient)
+	}
+}
+
+// WatchConfiguration reloads the configuration on every interval until the context is done, and calls onChange with
+// the configurations whose spec differs from the previous one. Configurations failing to load or validate are
+// reported to onError and skipped, the previous one stays in effect.
+func WatchConfiguration(ctx context.Context, current *Configuration, load ConfigurationLoader, interval time.Duration, onChange func(*Configuration), onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		conf, err := load()
+		if err != nil {
+			if onError != nil {
+				onError(err)
+			}
+			continue
+		}
+
+		if current != nil && reflect.DeepEqual(current.Spec, conf.Spec) {
+			continue
+		}
+		current = conf
+		onChange(conf)
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchConfiguration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(samplingRate string) {
+		content := "kind: Configuration\nspec:\n  tracing:\n    samplingRate: \"" + samplingRate + "\"\n"
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	write("1")
+	current, _, err := LoadStandaloneConfiguration(path)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan *Configuration, 10)
+	errs := make(chan error, 10)
+	go WatchConfiguration(ctx, current, StandaloneConfigurationLoader(path), 10*time.Millisecond,
+		func(conf *Configuration) { changes <- conf },
+		func(err error) { errs <- err })
+
+	t.Run("unchanged configuration is skipped", func(t *testing.T) {
+		select {
+		case <-changes:
+			t.Fatal("unexpected change")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("changed configuration is reported", func(t *testing.T) {
+		write("0.5")
+		select {
+		case conf := <-changes:
+			assert.Equal(t, "0.5", conf.Spec.TracingSpec.SamplingRate)
+		case <-time.After(time.Second):
+			t.Fatal("change not reported")
+		}
+	})
+
+	t.Run("invalid configuration is reported as error", func(t *testing.T) {
+		assert.NoError(t, os.Remove(path))
+		select {
+		case err := <-errs:
+			assert.Error(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("error not reported")
+		}
+	})
+}
@@ -20,6 +20,140 @@ package cors
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+)
+
 // DefaultAllowedOrigins is the default origins allowed for the Bhojpur Application
 // runtime's HTTP servers.
 const DefaultAllowedOrigins = "*"
+
+// Name is the name of the CORS middleware of the engine.
+const Name = "cors"
+
+// Options configures the CORS middleware.
+type Options struct {
+	// AllowedOrigins are the origins allowed to make cross-origin requests, like "https://example.com". "*" allows
+	// all origins, and a leading "*." allows the subdomains of a domain, like "*.example.com".
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed, GET, HEAD, POST, PUT, PATCH and DELETE by default.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed, the ones requested by preflight requests are allowed by default.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers exposed to clients.
+	ExposedHeaders []string
+	// AllowCredentials allows requests with cookies and authorization headers. Credentials are never allowed to all
+	// origins, the origin of the request is allowed instead of "*".
+	AllowCredentials bool
+	// MaxAge is the duration preflight responses are cached by clients.
+	MaxAge time.Duration
+}
+
+// Middleware returns the CORS middleware of the engine, it answers preflight requests of allowed origins, and
+// allows the responses of their requests to be read.
+func Middleware(options Options) *appsvr.Middleware {
+	if len(options.AllowedMethods) == 0 {
+		options.AllowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	var (
+		methods = strings.Join(options.AllowedMethods, ", ")
+		headers = strings.Join(options.AllowedHeaders, ", ")
+		exposed = strings.Join(options.ExposedHeaders, ", ")
+		maxAge  = strconv.Itoa(int(options.MaxAge.Seconds()))
+	)
+
+	return &appsvr.Middleware{
+		Name: Name,
+		Handler: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				origin := req.Header.Get("Origin")
+				header := w.Header()
+				header.Add("Vary", "Origin")
+				preflight := req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != ""
+				if preflight {
+					header.Add("Vary", "Access-Control-Request-Method")
+					header.Add("Vary", "Access-Control-Request-Headers")
+				}
+
+				if origin == "" || !options.allowsOrigin(origin) {
+					if preflight {
+						w.WriteHeader(http.StatusForbidden)
+						return
+					}
+					next.ServeHTTP(w, req)
+					return
+				}
+
+				if options.AllowCredentials || !options.allowsAllOrigins() {
+					header.Set("Access-Control-Allow-Origin", origin)
+				} else {
+					header.Set("Access-Control-Allow-Origin", "*")
+				}
+				if options.AllowCredentials {
+					header.Set("Access-Control-Allow-Credentials", "true")
+				}
+
+				if !preflight {
+					if exposed != "" {
+						header.Set("Access-Control-Expose-Headers", exposed)
+					}
+					next.ServeHTTP(w, req)
+					return
+				}
+
+				if !options.allowsMethod(req.Header.Get("Access-Control-Request-Method")) {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				header.Set("Access-Control-Allow-Methods", methods)
+				if headers != "" {
+					header.Set("Access-Control-Allow-Headers", headers)
+				} else if requested := req.Header.Get("Access-Control-Request-Headers"); requested != "" {
+					header.Set("Access-Control-Allow-Headers", requested)
+				}
+				if options.MaxAge > 0 {
+					header.Set("Access-Control-Max-Age", maxAge)
+				}
+				w.WriteHeader(http.StatusNoContent)
+			})
+		},
+	}
+}
+
+func (options Options) allowsAllOrigins() bool {
+	for _, allowed := range options.AllowedOrigins {
+		if allowed == DefaultAllowedOrigins {
+			return true
+		}
+	}
+	return false
+}
+
+func (options Options) allowsOrigin(origin string) bool {
+	for _, allowed := range options.AllowedOrigins {
+		switch {
+		case allowed == DefaultAllowedOrigins, strings.EqualFold(allowed, origin):
+			return true
+		case strings.HasPrefix(allowed, "*."):
+			// the scheme of the origin is kept, "*.example.com" allows https://shop.example.com
+			if i := strings.Index(origin, "://"); i >= 0 && strings.HasSuffix(strings.ToLower(origin[i+3:]), strings.ToLower(allowed[1:])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (options Options) allowsMethod(method string) bool {
+	for _, allowed := range options.AllowedMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package cors_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bhojpur/application/pkg/cors"
+)
+
+func TestMiddleware(t *testing.T) {
+	handler := cors.Middleware(cors.Options{
+		AllowedOrigins:   []string{"https://example.com", "*.example.org"},
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+		ExposedHeaders:   []string{"RateLimit-Remaining"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}).Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("ok")) }))
+
+	serve := func(method, origin, requestMethod string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/products", nil)
+		req.Header.Set("Origin", origin)
+		if requestMethod != "" {
+			req.Header.Set("Access-Control-Request-Method", requestMethod)
+			req.Header.Set("Access-Control-Request-Headers", "X-CSRF-Token")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodGet, "https://example.com", "")
+	assert.Equal(t, "ok", w.Body.String())
+	assert.Equal(t, "https://example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "RateLimit-Remaining", w.Header().Get("Access-Control-Expose-Headers"))
+
+	w = serve(http.MethodOptions, "https://shop.example.org", http.MethodPost)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://shop.example.org", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "X-CSRF-Token", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "3600", w.Header().Get("Access-Control-Max-Age"))
+
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodOptions, "https://example.com", http.MethodDelete).Code)
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodOptions, "https://evil.example", http.MethodGet).Code)
+
+	// requests of other origins are served, without the headers allowing their responses to be read
+	w = serve(http.MethodGet, "https://evil.example", "")
+	assert.Equal(t, "ok", w.Body.String())
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,230 @@
+package csrf
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/utils"
+)
+
+const (
+	// Name is the name of the CSRF middleware of the engine
+	Name = "csrf"
+	// CookieName is the name of the cookie of the secret of a client
+	CookieName = "_csrf"
+	// HeaderName is the header of the token of requests of scripts, like the ones of the JSON API
+	HeaderName = "X-CSRF-Token"
+	// FieldName is the form field of the token of form posts
+	FieldName = "_csrf_token"
+)
+
+const secretLength = 32
+
+var (
+	// ErrInvalidToken is returned when an unsafe request has no token, or its token doesn't match its secret
+	ErrInvalidToken = errors.New("csrf: invalid token")
+	// ErrInvalidOrigin is returned when an unsafe request comes from an origin which isn't trusted
+	ErrInvalidOrigin = errors.New("csrf: invalid origin")
+)
+
+type contextKey int
+
+const verifiedKey contextKey = iota
+
+// Token returns the token of the client of the context, for the FieldName field of forms, or the HeaderName header
+// of scripts. The secret of the client is set to its cookie if it has none. Tokens are masked, so that they differ
+// for each call
+func Token(context *appsvr.Context) string {
+	secret := getSecret(context.Request)
+	if secret == nil {
+		secret = make([]byte, secretLength)
+		if _, err := rand.Read(secret); err != nil {
+			return ""
+		}
+		value := base64.RawURLEncoding.EncodeToString(secret)
+		if context.Writer != nil {
+			utils.SetCookie(http.Cookie{Name: CookieName, Value: value, SameSite: http.SameSiteLaxMode}, context)
+		}
+		if context.Request != nil {
+			// make the secret visible to later calls of current request
+			context.Request.AddCookie(&http.Cookie{Name: CookieName, Value: value})
+		}
+	}
+
+	masked := make([]byte, 2*secretLength)
+	if _, err := rand.Read(masked[:secretLength]); err != nil {
+		return ""
+	}
+	for i := range secret {
+		masked[secretLength+i] = masked[i] ^ secret[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// TemplateField returns the hidden field of the token of the context, to be rendered in forms
+func TemplateField(context *appsvr.Context) template.HTML {
+	return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`, FieldName, template.HTMLEscapeString(Token(context))))
+}
+
+// Verify verifies the token of an unsafe request, from its HeaderName header or its FieldName form field. Requests
+// which can't be forged by other sites are not verified: safe requests, requests without cookies, and requests with
+// an Authorization header, like the bearer tokens of auth
+func Verify(req *http.Request) error {
+	if req == nil || !needsVerification(req) {
+		return nil
+	}
+
+	secret := getSecret(req)
+	if secret == nil {
+		return ErrInvalidToken
+	}
+
+	token := req.Header.Get(HeaderName)
+	if token == "" && isForm(req) {
+		token = req.FormValue(FieldName)
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	switch len(decoded) {
+	case 2 * secretLength:
+		for i := 0; i < secretLength; i++ {
+			decoded[i] ^= decoded[secretLength+i]
+		}
+		decoded = decoded[:secretLength]
+	case secretLength:
+		// the secret itself, like scripts of the same site which copy its cookie
+	default:
+		return ErrInvalidToken
+	}
+	if subtle.ConstantTimeCompare(decoded, secret) != 1 {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+func needsVerification(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	if req.Header.Get("Authorization") != "" || len(req.Cookies()) == 0 {
+		return false
+	}
+	verified, _ := req.Context().Value(verifiedKey).(bool)
+	return !verified
+}
+
+func isForm(req *http.Request) bool {
+	contentType := req.Header.Get("Content-Type")
+	return strings.HasPrefix(contentType, "application/x-www-form-urlencoded") || strings.HasPrefix(contentType, "multipart/form-data")
+}
+
+func getSecret(req *http.Request) []byte {
+	if req == nil {
+		return nil
+	}
+	cookie, err := req.Cookie(CookieName)
+	if err != nil {
+		return nil
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(secret) != secretLength {
+		return nil
+	}
+	return secret
+}
+
+// Config configures the CSRF middleware
+type Config struct {
+	// TrustedOrigins are the origins, other than the one of the request, allowed to make unsafe requests, like
+	// "https://admin.example.com"
+	TrustedOrigins []string
+	// Exempt exempts requests from verification, like the ones of webhooks
+	Exempt func(req *http.Request) bool
+}
+
+// Middleware returns the CSRF middleware of the engine, it rejects unsafe requests from other origins, or without
+// a valid token, see Verify
+func Middleware(config Config) *appsvr.Middleware {
+	return &appsvr.Middleware{
+		Name: Name,
+		Handler: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if config.Exempt != nil && config.Exempt(req) {
+					next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), verifiedKey, true)))
+					return
+				}
+				if !needsVerification(req) {
+					next.ServeHTTP(w, req)
+					return
+				}
+
+				err := verifyOrigin(req, config.TrustedOrigins)
+				if err == nil {
+					err = Verify(req)
+				}
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusForbidden)
+					return
+				}
+				// handlers decoding the request, like resource.Decode, don't verify it again
+				next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), verifiedKey, true)))
+			})
+		},
+	}
+}
+
+// verifyOrigin verifies the Origin header of a request, or its Referer, is the origin of the request or trusted
+func verifyOrigin(req *http.Request, trusted []string) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		referer, err := url.Parse(req.Header.Get("Referer"))
+		if err != nil || referer.Host == "" {
+			// browsers send the origin of unsafe requests, requests without it are verified by their token
+			return nil
+		}
+		origin = referer.Scheme + "://" + referer.Host
+	}
+
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, req.Host) {
+		return nil
+	}
+	for _, t := range trusted {
+		if strings.EqualFold(strings.TrimSuffix(t, "/"), origin) {
+			return nil
+		}
+	}
+	return ErrInvalidOrigin
+}
@@ -0,0 +1,88 @@
+package csrf_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bhojpur/application/pkg/csrf"
+	appsvr "github.com/bhojpur/application/pkg/engine"
+)
+
+func TestCSRF(t *testing.T) {
+	// render a form, which sets the secret of the client
+	w := httptest.NewRecorder()
+	context := &appsvr.Context{Request: httptest.NewRequest(http.MethodGet, "/products/new", nil), Writer: w}
+	token := csrf.Token(context)
+	assert.NotEqual(t, token, csrf.Token(context), "tokens are masked")
+	assert.Contains(t, string(csrf.TemplateField(context)), `name="_csrf_token"`)
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	secret := cookies[0]
+
+	var handled int
+	handler := csrf.Middleware(csrf.Config{
+		TrustedOrigins: []string{"https://admin.example.com"},
+		Exempt:         func(req *http.Request) bool { return req.URL.Path == "/webhooks" },
+	}).Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		handled++
+		assert.NoError(t, csrf.Verify(req), "verified requests aren't verified again")
+	}))
+
+	post := func(path string, form url.Values, header http.Header, cookies ...*http.Cookie) int {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com"+path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, post("/products", url.Values{"_csrf_token": {token}}, nil, secret))
+	assert.Equal(t, http.StatusOK, post("/products", nil, http.Header{"X-Csrf-Token": {token}}, secret))
+	assert.Equal(t, http.StatusOK, post("/products", nil, http.Header{"X-Csrf-Token": {secret.Value}}, secret))
+	assert.Equal(t, http.StatusForbidden, post("/products", nil, nil, secret))
+	assert.Equal(t, http.StatusForbidden, post("/products", url.Values{"_csrf_token": {"forged"}}, nil, secret))
+	assert.Equal(t, http.StatusForbidden, post("/products", url.Values{"_csrf_token": {token}}, nil, &http.Cookie{Name: "session", Value: "1"}))
+
+	// origins other than the one of the request have to be trusted
+	assert.Equal(t, http.StatusForbidden, post("/products", url.Values{"_csrf_token": {token}}, http.Header{"Origin": {"https://evil.example"}}, secret))
+	assert.Equal(t, http.StatusOK, post("/products", url.Values{"_csrf_token": {token}}, http.Header{"Origin": {"https://admin.example.com"}}, secret))
+
+	// requests which can't be forged by other sites
+	session := &http.Cookie{Name: "session", Value: "1"}
+	assert.Equal(t, http.StatusOK, post("/products", nil, nil))
+	assert.Equal(t, http.StatusOK, post("/products", nil, http.Header{"Authorization": {"Bearer token"}}, session))
+	assert.Equal(t, http.StatusOK, post("/webhooks", nil, nil, session))
+	assert.Equal(t, 7, handled)
+}
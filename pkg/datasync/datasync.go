@@ -0,0 +1,337 @@
+package datasync
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownResource is returned for resources which are not registered.
+	ErrUnknownResource = errors.New("datasync: unknown resource")
+	// ErrInvalidToken is returned for malformed sequence tokens, or tokens of another resource.
+	ErrInvalidToken = errors.New("datasync: invalid sequence token")
+	// ErrTokenExpired is returned when tombstones after the token were purged, the client has to resync from scratch.
+	ErrTokenExpired = errors.New("datasync: sequence token expired")
+)
+
+// Operation is the kind of a change.
+type Operation string
+
+const (
+	// OperationUpsert creates or updates a record.
+	OperationUpsert Operation = "upsert"
+	// OperationDelete deletes a record, its change is kept as a tombstone.
+	OperationDelete Operation = "delete"
+)
+
+// ConflictPolicy decides how a client mutation based on an outdated version of a record is resolved.
+type ConflictPolicy string
+
+const (
+	// ServerWins discards the mutation, the client gets the server's record back.
+	ServerWins ConflictPolicy = "server-wins"
+	// ClientWins applies the mutation over the server's record.
+	ClientWins ConflictPolicy = "client-wins"
+	// Merge applies the mutation returned by the merge callback of the resource.
+	Merge ConflictPolicy = "merge"
+)
+
+// Change is an entry of the change feed of a resource.
+type Change struct {
+	Sequence  uint64          `json:"sequence"`
+	Resource  string          `json:"resource"`
+	ID        string          `json:"id"`
+	Operation Operation       `json:"operation"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	// Version is the version of the record, it is incremented by every change of it.
+	Version   uint64    `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// IsTombstone returns true if the change deletes the record.
+func (change Change) IsTombstone() bool {
+	return change.Operation == OperationDelete
+}
+
+// Mutation is a change made by a client while offline.
+type Mutation struct {
+	ID        string          `json:"id"`
+	Operation Operation       `json:"operation"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	// BaseVersion is the version of the record the mutation was made on, zero for new records.
+	BaseVersion uint64    `json:"baseVersion"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// MergeFunc merges a conflicting client mutation with the server's record, and returns the mutation to apply.
+type MergeFunc func(server Change, client Mutation) (Mutation, error)
+
+// ResultStatus is the outcome of a pushed mutation.
+type ResultStatus string
+
+const (
+	// StatusApplied means the mutation was applied as is.
+	StatusApplied ResultStatus = "applied"
+	// StatusMerged means the mutation conflicted, and the merged mutation was applied.
+	StatusMerged ResultStatus = "merged"
+	// StatusConflict means the mutation conflicted and was discarded, Change is the server's record.
+	StatusConflict ResultStatus = "conflict"
+	// StatusRejected means the mutation is invalid, or couldn't be applied.
+	StatusRejected ResultStatus = "rejected"
+)
+
+// MutationResult is the result of a pushed mutation, Change is the current state of the record.
+type MutationResult struct {
+	ID     string       `json:"id"`
+	Status ResultStatus `json:"status"`
+	Change *Change      `json:"change,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// ResourceConfig configures the synchronization of a resource.
+type ResourceConfig struct {
+	Name string
+	// Policy resolves conflicting mutations, ServerWins by default.
+	Policy ConflictPolicy
+	// Merge is the merge callback, it is required by the Merge policy.
+	Merge MergeFunc
+	// Apply persists an accepted change, like saving the record with resource.CallSave. The change
+	// is rejected if it returns an error. Changes are only recorded in the feed if it is nil.
+	Apply func(change Change) error
+}
+
+// Feed is a page of the change feed of a resource.
+type Feed struct {
+	Changes []Change `json:"changes"`
+	// Token is the sequence token to get the next page with.
+	Token   string `json:"token"`
+	HasMore bool   `json:"hasMore"`
+}
+
+// Syncer exposes the change feeds of resources, and applies the mutations pushed by clients.
+type Syncer struct {
+	log       ChangeLog
+	resources map[string]ResourceConfig
+	lock      sync.Mutex
+}
+
+// New returns a Syncer recording the changes in log.
+func New(log ChangeLog) *Syncer {
+	return &Syncer{log: log, resources: map[string]ResourceConfig{}}
+}
+
+// Register registers a resource to be synchronized.
+func (s *Syncer) Register(config ResourceConfig) error {
+	if config.Name == "" {
+		return errors.New("datasync: resource name is required")
+	}
+
+	switch config.Policy {
+	case "":
+		config.Policy = ServerWins
+	case ServerWins, ClientWins:
+	case Merge:
+		if config.Merge == nil {
+			return fmt.Errorf("datasync: resource %s uses the merge policy without a merge callback", config.Name)
+		}
+	default:
+		return fmt.Errorf("datasync: resource %s has unknown conflict policy %q", config.Name, config.Policy)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.resources[config.Name] = config
+	return nil
+}
+
+// Record records a change made on the server, like from an admin form, to the feed of the resource.
+func (s *Syncer) Record(resource, id string, operation Operation, data json.RawMessage) (Change, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.resources[resource]; !ok {
+		return Change{}, ErrUnknownResource
+	}
+
+	latest, _, err := s.log.Latest(resource, id)
+	if err != nil {
+		return Change{}, err
+	}
+	return s.log.Append(newChange(resource, id, operation, data, latest.Version))
+}
+
+// Changes returns the changes of the resource after the token, at most limit of them if it is positive.
+// An empty token starts from the beginning of the feed. Only the latest change of every record is returned,
+// deleted records are returned as tombstones.
+func (s *Syncer) Changes(resource, token string, limit int) (*Feed, error) {
+	s.lock.Lock()
+	_, ok := s.resources[resource]
+	s.lock.Unlock()
+	if !ok {
+		return nil, ErrUnknownResource
+	}
+
+	var sequence uint64
+	if token != "" {
+		var err error
+		if sequence, err = ParseToken(resource, token); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, hasMore, err := s.log.Since(resource, sequence, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) > 0 {
+		sequence = changes[len(changes)-1].Sequence
+	}
+	return &Feed{Changes: changes, Token: NewToken(resource, sequence), HasMore: hasMore}, nil
+}
+
+// Push applies a batch of offline mutations of a client to the resource, in order.
+// Mutations based on an outdated version of a record are resolved with the conflict policy of the resource.
+func (s *Syncer) Push(resource string, mutations []Mutation) ([]MutationResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	config, ok := s.resources[resource]
+	if !ok {
+		return nil, ErrUnknownResource
+	}
+
+	results := make([]MutationResult, 0, len(mutations))
+	for _, mutation := range mutations {
+		result, err := s.push(config, mutation)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s *Syncer) push(config ResourceConfig, mutation Mutation) (MutationResult, error) {
+	result := MutationResult{ID: mutation.ID, Status: StatusApplied}
+	if err := validateMutation(mutation); err != nil {
+		result.Status, result.Error = StatusRejected, err.Error()
+		return result, nil
+	}
+
+	latest, exists, err := s.log.Latest(config.Name, mutation.ID)
+	if err != nil {
+		return result, err
+	}
+
+	if exists && latest.Version != mutation.BaseVersion {
+		switch config.Policy {
+		case ServerWins:
+			result.Status, result.Change = StatusConflict, &latest
+			return result, nil
+		case Merge:
+			merged, err := config.Merge(latest, mutation)
+			if err != nil {
+				result.Status, result.Change, result.Error = StatusRejected, &latest, err.Error()
+				return result, nil
+			}
+			merged.ID = mutation.ID
+			if err := validateMutation(merged); err != nil {
+				result.Status, result.Change, result.Error = StatusRejected, &latest, err.Error()
+				return result, nil
+			}
+			mutation, result.Status = merged, StatusMerged
+		}
+	}
+
+	change := newChange(config.Name, mutation.ID, mutation.Operation, mutation.Data, latest.Version)
+	if config.Apply != nil {
+		if err := config.Apply(change); err != nil {
+			result.Status, result.Error = StatusRejected, err.Error()
+			if exists {
+				result.Change = &latest
+			}
+			return result, nil
+		}
+	}
+
+	if change, err = s.log.Append(change); err != nil {
+		return result, err
+	}
+	result.Change = &change
+	return result, nil
+}
+
+func validateMutation(mutation Mutation) error {
+	if mutation.ID == "" {
+		return errors.New("mutation id is required")
+	}
+
+	switch mutation.Operation {
+	case OperationUpsert:
+		if len(mutation.Data) == 0 {
+			return errors.New("upsert mutation requires data")
+		}
+	case OperationDelete:
+	default:
+		return fmt.Errorf("unknown operation %q", mutation.Operation)
+	}
+	return nil
+}
+
+func newChange(resource, id string, operation Operation, data json.RawMessage, version uint64) Change {
+	if operation == OperationDelete {
+		data = nil
+	}
+	return Change{Resource: resource, ID: id, Operation: operation, Data: data, Version: version + 1, Timestamp: time.Now().UTC()}
+}
+
+// NewToken returns the opaque sequence token of a position in the feed of a resource.
+func NewToken(resource string, sequence uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(resource + ":" + strconv.FormatUint(sequence, 10)))
+}
+
+// ParseToken returns the sequence of a token of the resource.
+func ParseToken(resource, token string) (uint64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+
+	idx := strings.LastIndex(string(decoded), ":")
+	if idx < 0 || string(decoded[:idx]) != resource {
+		return 0, ErrInvalidToken
+	}
+
+	sequence, err := strconv.ParseUint(string(decoded[idx+1:]), 10, 64)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	return sequence, nil
+}
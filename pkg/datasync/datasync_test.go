@@ -0,0 +1,163 @@
+package datasync_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bhojpur/application/pkg/datasync"
+)
+
+func TestChangesAndTombstones(t *testing.T) {
+	log := datasync.NewMemoryLog()
+	s := datasync.New(log)
+	assert.NoError(t, s.Register(datasync.ResourceConfig{Name: "orders"}))
+
+	_, err := s.Record("orders", "1", datasync.OperationUpsert, json.RawMessage(`{"qty":1}`))
+	assert.NoError(t, err)
+	_, err = s.Record("orders", "2", datasync.OperationUpsert, json.RawMessage(`{"qty":2}`))
+	assert.NoError(t, err)
+
+	feed, err := s.Changes("orders", "", 1)
+	assert.NoError(t, err)
+	assert.True(t, feed.HasMore)
+	assert.Equal(t, "1", feed.Changes[0].ID)
+
+	feed, err = s.Changes("orders", feed.Token, 0)
+	assert.NoError(t, err)
+	assert.False(t, feed.HasMore)
+	assert.Len(t, feed.Changes, 1)
+	token := feed.Token
+
+	// only the latest change of a record is in the feed, deletes are tombstones
+	_, err = s.Record("orders", "1", datasync.OperationDelete, nil)
+	assert.NoError(t, err)
+	feed, err = s.Changes("orders", "", 0)
+	assert.NoError(t, err)
+	if assert.Len(t, feed.Changes, 2) {
+		assert.Equal(t, "1", feed.Changes[1].ID)
+		assert.True(t, feed.Changes[1].IsTombstone())
+		assert.Equal(t, uint64(2), feed.Changes[1].Version)
+	}
+
+	purged, err := log.PurgeTombstones(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = s.Changes("orders", token, 0)
+	assert.True(t, errors.Is(err, datasync.ErrTokenExpired))
+	_, err = s.Changes("orders", feed.Token, 0)
+	assert.NoError(t, err)
+
+	_, err = s.Changes("orders", datasync.NewToken("users", 1), 0)
+	assert.True(t, errors.Is(err, datasync.ErrInvalidToken))
+	_, err = s.Changes("users", "", 0)
+	assert.True(t, errors.Is(err, datasync.ErrUnknownResource))
+}
+
+func TestPushConflictPolicies(t *testing.T) {
+	setup := func(config datasync.ResourceConfig) *datasync.Syncer {
+		s := datasync.New(datasync.NewMemoryLog())
+		config.Name = "orders"
+		assert.NoError(t, s.Register(config))
+		s.Record("orders", "1", datasync.OperationUpsert, json.RawMessage(`{"qty":1}`))
+		s.Record("orders", "1", datasync.OperationUpsert, json.RawMessage(`{"qty":2}`))
+		return s
+	}
+	stale := datasync.Mutation{ID: "1", Operation: datasync.OperationUpsert, Data: json.RawMessage(`{"qty":3}`), BaseVersion: 1}
+
+	results, err := setup(datasync.ResourceConfig{}).Push("orders", []datasync.Mutation{stale})
+	assert.NoError(t, err)
+	assert.Equal(t, datasync.StatusConflict, results[0].Status)
+	assert.JSONEq(t, `{"qty":2}`, string(results[0].Change.Data))
+
+	results, err = setup(datasync.ResourceConfig{Policy: datasync.ClientWins}).Push("orders", []datasync.Mutation{stale})
+	assert.NoError(t, err)
+	assert.Equal(t, datasync.StatusApplied, results[0].Status)
+	assert.Equal(t, uint64(3), results[0].Change.Version)
+
+	results, err = setup(datasync.ResourceConfig{Policy: datasync.Merge, Merge: func(server datasync.Change, client datasync.Mutation) (datasync.Mutation, error) {
+		client.Data = json.RawMessage(`{"qty":5}`)
+		return client, nil
+	}}).Push("orders", []datasync.Mutation{stale})
+	assert.NoError(t, err)
+	assert.Equal(t, datasync.StatusMerged, results[0].Status)
+	assert.JSONEq(t, `{"qty":5}`, string(results[0].Change.Data))
+
+	var applied []datasync.Change
+	s := setup(datasync.ResourceConfig{Apply: func(change datasync.Change) error {
+		if change.ID == "invalid" {
+			return errors.New("invalid order")
+		}
+		applied = append(applied, change)
+		return nil
+	}})
+	results, err = s.Push("orders", []datasync.Mutation{
+		{ID: "1", Operation: datasync.OperationDelete, BaseVersion: 2},
+		{ID: "invalid", Operation: datasync.OperationUpsert, Data: json.RawMessage(`{}`)},
+		{ID: "2", Operation: "rename"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, datasync.StatusApplied, results[0].Status)
+	assert.True(t, results[0].Change.IsTombstone())
+	assert.Equal(t, datasync.StatusRejected, results[1].Status)
+	assert.Equal(t, "invalid order", results[1].Error)
+	assert.Equal(t, datasync.StatusRejected, results[2].Status)
+	assert.Len(t, applied, 1)
+
+	assert.Error(t, datasync.New(datasync.NewMemoryLog()).Register(datasync.ResourceConfig{Name: "orders", Policy: datasync.Merge}))
+}
+
+func TestHandler(t *testing.T) {
+	s := datasync.New(datasync.NewMemoryLog())
+	assert.NoError(t, s.Register(datasync.ResourceConfig{Name: "orders"}))
+	handler := s.Handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/sync/orders", strings.NewReader(`{"mutations":[{"id":"1","operation":"upsert","data":{"qty":1}}]}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var push datasync.PushResponse
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&push))
+	assert.Equal(t, datasync.StatusApplied, push.Results[0].Status)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sync/orders?limit=10", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var feed datasync.Feed
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&feed))
+	assert.Len(t, feed.Changes, 1)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sync/orders?token=invalid", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sync/users", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,102 @@
+package datasync
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PushRequest is the body of a push request.
+type PushRequest struct {
+	Mutations []Mutation `json:"mutations"`
+}
+
+// PushResponse is the body of a push response.
+type PushResponse struct {
+	Results []MutationResult `json:"results"`
+}
+
+// Handler returns the HTTP API of the syncer, the resource is the last segment of the request path:
+//
+//	GET  /{resource}?token={token}&limit={limit}  returns the change feed after the token
+//	POST /{resource}                               pushes a batch of mutations
+func (s *Syncer) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		resource := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+
+		switch req.Method {
+		case http.MethodGet:
+			var limit int
+			if value := req.URL.Query().Get("limit"); value != "" {
+				var err error
+				if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+					http.Error(w, "invalid limit", http.StatusBadRequest)
+					return
+				}
+			}
+
+			feed, err := s.Changes(resource, req.URL.Query().Get("token"), limit)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, feed)
+		case http.MethodPost:
+			var body PushRequest
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				http.Error(w, "invalid push request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			results, err := s.Push(resource, body.Mutations)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, PushResponse{Results: results})
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownResource):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrInvalidToken):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrTokenExpired):
+		http.Error(w, err.Error(), http.StatusGone)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,152 @@
+package datasync
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ChangeLog stores the change feeds of resources.
+type ChangeLog interface {
+	// Append assigns the next sequence of the feed of the resource to the change, and stores it.
+	// It supersedes the previous change of the same record.
+	Append(change Change) (Change, error)
+	// Since returns the latest changes of the records of the resource changed after the sequence, at
+	// most limit of them if it is positive, and whether there are more. It returns ErrTokenExpired if
+	// tombstones after the sequence were purged.
+	Since(resource string, sequence uint64, limit int) ([]Change, bool, error)
+	// Latest returns the latest change of a record, if any.
+	Latest(resource, id string) (Change, bool, error)
+	// PurgeTombstones removes the tombstones recorded before the time, and returns how many were removed.
+	PurgeTombstones(before time.Time) (int, error)
+}
+
+type feed struct {
+	sequence uint64
+	// purged is the latest sequence of the purged tombstones, older tokens can't be resumed
+	purged  uint64
+	changes []Change
+	latest  map[string]Change
+}
+
+// MemoryLog is a ChangeLog kept in memory.
+type MemoryLog struct {
+	feeds map[string]*feed
+	lock  sync.RWMutex
+}
+
+// NewMemoryLog returns an empty MemoryLog.
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{feeds: map[string]*feed{}}
+}
+
+// Append implements ChangeLog.
+func (l *MemoryLog) Append(change Change) (Change, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	f, ok := l.feeds[change.Resource]
+	if !ok {
+		f = &feed{latest: map[string]Change{}}
+		l.feeds[change.Resource] = f
+	}
+
+	if previous, ok := f.latest[change.ID]; ok {
+		f.remove(previous.Sequence)
+	}
+
+	f.sequence++
+	change.Sequence = f.sequence
+	f.changes = append(f.changes, change)
+	f.latest[change.ID] = change
+	return change, nil
+}
+
+// Since implements ChangeLog.
+func (l *MemoryLog) Since(resource string, sequence uint64, limit int) ([]Change, bool, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	f, ok := l.feeds[resource]
+	if !ok {
+		return []Change{}, false, nil
+	}
+
+	if sequence > 0 && sequence < f.purged {
+		return nil, false, ErrTokenExpired
+	}
+
+	changes := f.changes[f.search(sequence+1):]
+	hasMore := limit > 0 && len(changes) > limit
+	if hasMore {
+		changes = changes[:limit]
+	}
+	return append([]Change{}, changes...), hasMore, nil
+}
+
+// Latest implements ChangeLog.
+func (l *MemoryLog) Latest(resource, id string) (Change, bool, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if f, ok := l.feeds[resource]; ok {
+		change, ok := f.latest[id]
+		return change, ok, nil
+	}
+	return Change{}, false, nil
+}
+
+// PurgeTombstones implements ChangeLog.
+func (l *MemoryLog) PurgeTombstones(before time.Time) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var purged int
+	for _, f := range l.feeds {
+		changes := f.changes[:0]
+		for _, change := range f.changes {
+			if change.IsTombstone() && change.Timestamp.Before(before) {
+				delete(f.latest, change.ID)
+				f.purged = change.Sequence
+				purged++
+				continue
+			}
+			changes = append(changes, change)
+		}
+		f.changes = changes
+	}
+	return purged, nil
+}
+
+// search returns the index of the first change with a sequence greater or equal to the sequence
+func (f *feed) search(sequence uint64) int {
+	return sort.Search(len(f.changes), func(i int) bool {
+		return f.changes[i].Sequence >= sequence
+	})
+}
+
+func (f *feed) remove(sequence uint64) {
+	if idx := f.search(sequence); idx < len(f.changes) && f.changes[idx].Sequence == sequence {
+		f.changes = append(f.changes[:idx], f.changes[idx+1:]...)
+	}
+}
@@ -249,5 +249,10 @@ func (h *httpMetrics) convertPathToMetricLabel(path string) string {
 		return "/" + strings.Join(parsedPath[0:5], "/")
 	}
 
+	// Keep the raw path only if high cardinality labels are enabled, eg. /v1/invoke/app/method/orders/1
+	// is reported as /v1/invoke/app otherwise.
+	if !diag_utils.IsHighCardinalityLabelsEnabled() && len(parsedPath) > 3 {
+		return "/" + strings.Join(parsedPath[0:3], "/")
+	}
 	return path
 }
@@ -28,6 +28,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fasthttp"
 	"go.opencensus.io/stats/view"
+
+	diag_utils "github.com/bhojpur/application/pkg/diagnostics/utils"
 )
 
 func TestFastHTTPMiddleware(t *testing.T) {
@@ -129,6 +131,7 @@ func TestConvertPathToMethodName(t *testing.T) {
 		{"actors/DemoActor/1/method/method1", "actors/DemoActor/{id}/method/method1"},
 		{"actors/DemoActor/1/method/timer/timer1", "actors/DemoActor/{id}/method/timer/timer1"},
 		{"actors/DemoActor/1/method/remind/reminder1", "actors/DemoActor/{id}/method/remind/reminder1"},
+		{"/v1/invoke/app/method/orders/1", "/v1/invoke/app"},
 		{"", ""},
 	}
 
@@ -139,6 +142,13 @@ func TestConvertPathToMethodName(t *testing.T) {
 			assert.Equal(t, tt.out, lowCardinalityName)
 		})
 	}
+
+	t.Run("high cardinality labels", func(t *testing.T) {
+		diag_utils.SetHighCardinalityLabels(true)
+		defer diag_utils.SetHighCardinalityLabels(false)
+
+		assert.Equal(t, "/v1/invoke/app/method/orders/1", testHTTP.convertPathToMetricLabel("/v1/invoke/app/method/orders/1"))
+	})
 }
 
 func fakeFastHTTPRequestCtx(expectedBody string) *fasthttp.RequestCtx {
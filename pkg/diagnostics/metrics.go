@@ -25,6 +25,10 @@ import (
 
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
+
+	projections_monitoring "github.com/bhojpur/application/pkg/projections/monitoring"
+	resource_monitoring "github.com/bhojpur/application/pkg/resource/monitoring"
+	roles_monitoring "github.com/bhojpur/application/pkg/roles/monitoring"
 )
 
 // appIDKey is a tag key for App ID.
@@ -56,6 +60,18 @@ func InitMetrics(appID string) error {
 		return err
 	}
 
+	if err := resource_monitoring.InitMetrics(); err != nil {
+		return err
+	}
+
+	if err := roles_monitoring.InitMetrics(); err != nil {
+		return err
+	}
+
+	if err := projections_monitoring.InitMetrics(); err != nil {
+		return err
+	}
+
 	// Set reporting period of views
 	view.SetReportingPeriod(DefaultReportingPeriod)
 
@@ -47,6 +47,9 @@ type serviceMetrics struct {
 	componentLoaded        *stats.Int64Measure
 	componentInitCompleted *stats.Int64Measure
 	componentInitFailed    *stats.Int64Measure
+	componentReloaded      *stats.Int64Measure
+	componentReloadFailed  *stats.Int64Measure
+	componentLoadFailed    *stats.Int64Measure
 
 	// mTLS metrics
 	mtlsInitCompleted             *stats.Int64Measure
@@ -90,6 +93,18 @@ func newServiceMetrics() *serviceMetrics {
 			"runtime/component/init_fail_total",
 			"The number of component initialization failures.",
 			stats.UnitDimensionless),
+		componentReloaded: stats.Int64(
+			"runtime/component/reload_total",
+			"The number of components reloaded after a spec update.",
+			stats.UnitDimensionless),
+		componentReloadFailed: stats.Int64(
+			"runtime/component/reload_fail_total",
+			"The number of component reload failures.",
+			stats.UnitDimensionless),
+		componentLoadFailed: stats.Int64(
+			"runtime/component/load_failures_total",
+			"The number of components which failed to load, for any reason.",
+			stats.UnitDimensionless),
 
 		// mTLS
 		mtlsInitCompleted: stats.Int64(
@@ -171,6 +186,9 @@ func (s *serviceMetrics) Init(appID string) error {
 		diag_utils.NewMeasureView(s.componentLoaded, []tag.Key{appIDKey}, view.Count()),
 		diag_utils.NewMeasureView(s.componentInitCompleted, []tag.Key{appIDKey, componentKey}, view.Count()),
 		diag_utils.NewMeasureView(s.componentInitFailed, []tag.Key{appIDKey, componentKey, failReasonKey}, view.Count()),
+		diag_utils.NewMeasureView(s.componentReloaded, []tag.Key{appIDKey, componentKey}, view.Count()),
+		diag_utils.NewMeasureView(s.componentReloadFailed, []tag.Key{appIDKey, componentKey, failReasonKey}, view.Count()),
+		diag_utils.NewMeasureView(s.componentLoadFailed, []tag.Key{appIDKey, componentKey}, view.Count()),
 
 		diag_utils.NewMeasureView(s.mtlsInitCompleted, []tag.Key{appIDKey}, view.Count()),
 		diag_utils.NewMeasureView(s.mtlsInitFailed, []tag.Key{appIDKey, failReasonKey}, view.Count()),
@@ -219,6 +237,36 @@ func (s *serviceMetrics) ComponentInitFailed(component string, reason string) {
 	}
 }
 
+// ComponentReloaded records metric when component is reloaded after a spec update.
+func (s *serviceMetrics) ComponentReloaded(component string) {
+	if s.enabled {
+		stats.RecordWithTags(
+			s.ctx,
+			diag_utils.WithTags(appIDKey, s.appID, componentKey, component),
+			s.componentReloaded.M(1))
+	}
+}
+
+// ComponentReloadFailed records metric when component reload is failed.
+func (s *serviceMetrics) ComponentReloadFailed(component string, reason string) {
+	if s.enabled {
+		stats.RecordWithTags(
+			s.ctx,
+			diag_utils.WithTags(appIDKey, s.appID, componentKey, component, failReasonKey, reason),
+			s.componentReloadFailed.M(1))
+	}
+}
+
+// ComponentLoadFailed records metric when component failed to load.
+func (s *serviceMetrics) ComponentLoadFailed(component string) {
+	if s.enabled {
+		stats.RecordWithTags(
+			s.ctx,
+			diag_utils.WithTags(appIDKey, s.appID, componentKey, component),
+			s.componentLoadFailed.M(1))
+	}
+}
+
 // MTLSInitCompleted records metric when component is initialized.
 func (s *serviceMetrics) MTLSInitCompleted() {
 	if s.enabled {
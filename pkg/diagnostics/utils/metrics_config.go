@@ -0,0 +1,82 @@
+package utils
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import "sync"
+
+const (
+	// ResourceMetricsModule is the metric group of the resource module.
+	ResourceMetricsModule = "resource"
+	// RolesMetricsModule is the metric group of the roles module.
+	RolesMetricsModule = "roles"
+	// OperatorMetricsModule is the metric group of the operator.
+	OperatorMetricsModule = "operator"
+	// ProjectionsMetricsModule is the metric group of the read-model projections.
+	ProjectionsMetricsModule = "projections"
+)
+
+var (
+	metricsConfigLock     sync.RWMutex
+	metricsModules        = map[string]bool{}
+	highCardinalityLabels bool
+)
+
+// SetMetricsModuleEnabled enables or disables the metric group of a module.
+func SetMetricsModuleEnabled(module string, enabled bool) {
+	metricsConfigLock.Lock()
+	defer metricsConfigLock.Unlock()
+
+	metricsModules[module] = enabled
+}
+
+// IsMetricsModuleEnabled returns whether the metric group of a module is enabled, which is the default.
+func IsMetricsModuleEnabled(module string) bool {
+	metricsConfigLock.RLock()
+	defer metricsConfigLock.RUnlock()
+
+	enabled, ok := metricsModules[module]
+	return !ok || enabled
+}
+
+// SetHighCardinalityLabels enables or disables labels with unbounded values, such as record IDs and raw paths.
+// They are disabled by default to keep the size of the scrapes manageable.
+func SetHighCardinalityLabels(enabled bool) {
+	metricsConfigLock.Lock()
+	defer metricsConfigLock.Unlock()
+
+	highCardinalityLabels = enabled
+}
+
+// IsHighCardinalityLabelsEnabled returns whether labels with unbounded values are enabled.
+func IsHighCardinalityLabelsEnabled() bool {
+	metricsConfigLock.RLock()
+	defer metricsConfigLock.RUnlock()
+
+	return highCardinalityLabels
+}
+
+// HighCardinalityLabel returns value if high cardinality labels are enabled, and placeholder otherwise.
+func HighCardinalityLabel(value, placeholder string) string {
+	if IsHighCardinalityLabelsEnabled() {
+		return value
+	}
+	return placeholder
+}
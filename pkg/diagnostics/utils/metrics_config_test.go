@@ -0,0 +1,53 @@
+package utils
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsModules(t *testing.T) {
+	t.Run("enabled by default", func(t *testing.T) {
+		assert.True(t, IsMetricsModuleEnabled("unknown"))
+	})
+
+	t.Run("disable module", func(t *testing.T) {
+		SetMetricsModuleEnabled(RolesMetricsModule, false)
+		defer SetMetricsModuleEnabled(RolesMetricsModule, true)
+		assert.False(t, IsMetricsModuleEnabled(RolesMetricsModule))
+		assert.True(t, IsMetricsModuleEnabled(ResourceMetricsModule))
+	})
+}
+
+func TestHighCardinalityLabel(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		assert.False(t, IsHighCardinalityLabelsEnabled())
+		assert.Equal(t, "", HighCardinalityLabel("1234", ""))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		SetHighCardinalityLabels(true)
+		defer SetHighCardinalityLabels(false)
+		assert.Equal(t, "1234", HighCardinalityLabel("1234", ""))
+	})
+}
@@ -22,9 +22,16 @@ package engine
 
 import (
 	orm "github.com/bhojpur/orm/pkg/engine"
+
+	"github.com/bhojpur/application/pkg/config"
 )
 
 // Config is the basic Bhojpur Apps configuration struct
 type Config struct {
 	DB *orm.DB
+	// Tracing is the tracing spec of the Configuration, its sampling rate samples the requests of the engine and the
+	// operations of resources which are not part of a trace yet. Nothing is traced if it is nil
+	Tracing *config.TracingSpec
+	// Translator translates the labels and messages of contexts, see Context.T. Keys are not translated if it is nil
+	Translator Translator
 }
@@ -21,7 +21,9 @@ package engine
 // THE SOFTWARE.
 
 import (
+	stdcontext "context"
 	"net/http"
+	"sync/atomic"
 
 	orm "github.com/bhojpur/orm/pkg/engine"
 )
@@ -31,7 +33,9 @@ type CurrentUser interface {
 	DisplayName() string
 }
 
-// Context Apps context, which is used for many components, used to share information between them
+// Context Apps context, which is used for many components, used to share information between them. Besides its
+// fields, it carries request-scoped values, see Set and the typed accessors like SetLocale. A context is not safe for
+// concurrent use, clone it for other goroutines
 type Context struct {
 	Request     *http.Request
 	Writer      http.ResponseWriter
@@ -41,22 +45,90 @@ type Context struct {
 	DB          *orm.DB
 	Config      *Config
 	Errors
+	values map[interface{}]interface{}
 }
 
-// Clone clone current context
+// Clone clone current context, values set to the clone don't change current context
 func (context *Context) Clone() *Context {
 	var clone = *context
+	if context.values != nil {
+		clone.values = make(map[interface{}]interface{}, len(context.values))
+		for key, value := range context.values {
+			clone.values[key] = value
+		}
+	}
 	return &clone
 }
 
-// GetDB get database from current context
+// GetDB get database from current context, the database of its request if not set, see WithDB, otherwise the one of
+// its config
 func (context *Context) GetDB() *orm.DB {
 	if context.DB != nil {
 		return context.DB
 	}
+	if context.Request != nil {
+		if db := RequestDB(context.Request); db != nil {
+			return db
+		}
+	}
+	if context.Config == nil {
+		return nil
+	}
 	return context.Config.DB
 }
 
+// WithDB returns a shallow copy of the request carrying db, the database of the contexts of the request unless they
+// set their own, like the database of the tenant of the request
+func WithDB(req *http.Request, db *orm.DB) *http.Request {
+	return req.WithContext(stdcontext.WithValue(req.Context(), requestDBKey, db))
+}
+
+// RequestDB returns the database carried by the request, nil if it carries none, see WithDB
+func RequestDB(req *http.Request) *orm.DB {
+	db, _ := req.Context().Value(requestDBKey).(*orm.DB)
+	return db
+}
+
+// readDB is the database the contexts of a request read from, until one of them writes. primary is the database of
+// the request it replicates, nil for the database of the config
+type readDB struct {
+	db      *orm.DB
+	primary *orm.DB
+	written int32
+	onWrite func()
+}
+
+// WithReadDB returns a shallow copy of the request carrying db, the database its contexts read from, like a read
+// replica of the database the request carries, until one of them is marked written, see GetReadDB and SetWritten.
+// onWrite is called then, if not nil
+func WithReadDB(req *http.Request, db *orm.DB, onWrite func()) *http.Request {
+	return req.WithContext(stdcontext.WithValue(req.Context(), readDBKey, &readDB{db: db, primary: RequestDB(req), onWrite: onWrite}))
+}
+
+// GetReadDB get database to read from, the database of current context if set, otherwise the read database of its
+// request until it wrote, see WithReadDB, or GetDB. The read database isn't used once the request carries another
+// database than the one it replicates, like the database of its tenant
+func (context *Context) GetReadDB() *orm.DB {
+	if context.DB == nil && context.Request != nil {
+		if read, ok := context.Request.Context().Value(readDBKey).(*readDB); ok && atomic.LoadInt32(&read.written) == 0 &&
+			RequestDB(context.Request) == read.primary {
+			return read.db
+		}
+	}
+	return context.GetDB()
+}
+
+// SetWritten marks the request of current context as written, so that its contexts read from GetDB afterwards, to
+// read their own writes
+func (context *Context) SetWritten() {
+	if context.Request == nil {
+		return
+	}
+	if read, ok := context.Request.Context().Value(readDBKey).(*readDB); ok && atomic.CompareAndSwapInt32(&read.written, 0, 1) && read.onWrite != nil {
+		read.onWrite()
+	}
+}
+
 // SetDB set database into current context
 func (context *Context) SetDB(db *orm.DB) {
 	context.DB = db
@@ -0,0 +1,68 @@
+package engine_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct{}
+
+func (user) DisplayName() string { return "user" }
+
+type contextKey string
+
+func TestContextValues(t *testing.T) {
+	context := &appsvr.Context{}
+	assert.Equal(t, "", context.GetLocale())
+	assert.Equal(t, time.Local, context.GetTimeZone())
+	assert.Nil(t, context.GetCurrentUser())
+
+	tokyo := time.FixedZone("JST", 9*60*60)
+	context.SetCurrentUser(user{})
+	context.SetLocale("ja-JP")
+	context.SetTimeZone(tokyo)
+	context.SetTraceID("4bf92f3577b34da6a3ce929d0e0e4736")
+	context.Set(contextKey("tenant"), "acme")
+
+	assert.Equal(t, user{}, context.GetCurrentUser())
+	assert.Equal(t, "ja-JP", context.GetLocale())
+	assert.Equal(t, tokyo, context.GetTimeZone())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", context.GetTraceID())
+	tenant, ok := context.Get(contextKey("tenant"))
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenant)
+
+	clone := context.Clone()
+	clone.SetLocale("en-US")
+	clone.Delete(contextKey("tenant"))
+	assert.Equal(t, "ja-JP", context.GetLocale())
+	assert.Equal(t, "en-US", clone.GetLocale())
+	assert.Equal(t, tokyo, clone.GetTimeZone())
+	_, ok = context.Get(contextKey("tenant"))
+	assert.True(t, ok)
+	_, ok = clone.Get(contextKey("tenant"))
+	assert.False(t, ok)
+}
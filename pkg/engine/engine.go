@@ -0,0 +1,204 @@
+package engine
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"net/http"
+	"sync"
+
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/plugin/ochttp/propagation/tracecontext"
+	"go.opencensus.io/trace"
+
+	diag_utils "github.com/bhojpur/application/pkg/diagnostics/utils"
+)
+
+// Middleware is a cross-cutting concern of the routes of the engine, like logging, recovery, CORS, authentication or
+// metrics. Its name lets routes skip it
+type Middleware struct {
+	Name    string
+	Handler func(http.Handler) http.Handler
+}
+
+// Engine serves the routers mounted to it, like the API and GraphQL endpoints of resources, through the middlewares
+// registered with Use. Middlewares run in the order they are registered, the first one receives requests first, then
+// the middlewares of the route:
+//
+//	engine := appsvr.New(config)
+//	engine.Use(&appsvr.Middleware{Name: "recovery", Handler: recovery}, &appsvr.Middleware{Name: "cors", Handler: cors})
+//	engine.Mount("/api/", api)
+//	engine.Mount("/healthz", health, appsvr.Skip("cors"))
+type Engine struct {
+	Config      *Config
+	middlewares []*Middleware
+	routes      []*route
+	handler     http.Handler
+	mutex       sync.RWMutex
+}
+
+type route struct {
+	pattern     string
+	handler     http.Handler
+	skipped     map[string]bool
+	middlewares []*Middleware
+}
+
+// RouteOption overrides the middlewares of a route
+type RouteOption func(*route)
+
+// Skip skips the middlewares of the engine with names for the route
+func Skip(names ...string) RouteOption {
+	return func(r *route) {
+		for _, name := range names {
+			r.skipped[name] = true
+		}
+	}
+}
+
+// With adds middlewares to the route, they run after the ones of the engine
+func With(middlewares ...*Middleware) RouteOption {
+	return func(r *route) {
+		r.middlewares = append(r.middlewares, middlewares...)
+	}
+}
+
+// New initialize an engine
+func New(config *Config) *Engine {
+	return &Engine{Config: config}
+}
+
+// Use registers middlewares for all routes, mounted before or after. A middleware with the name of a registered one
+// replaces it at its position
+func (engine *Engine) Use(middlewares ...*Middleware) {
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+
+	for _, middleware := range middlewares {
+		replaced := false
+		for idx, m := range engine.middlewares {
+			if middleware.Name != "" && m.Name == middleware.Name {
+				engine.middlewares[idx], replaced = middleware, true
+				break
+			}
+		}
+		if !replaced {
+			engine.middlewares = append(engine.middlewares, middleware)
+		}
+	}
+	engine.handler = nil
+}
+
+// Mount mounts handler at pattern, patterns are the ones of http.ServeMux. Mounting a pattern again replaces its
+// handler
+func (engine *Engine) Mount(pattern string, handler http.Handler, options ...RouteOption) {
+	r := &route{pattern: pattern, handler: handler, skipped: map[string]bool{}}
+	for _, option := range options {
+		option(r)
+	}
+
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+
+	replaced := false
+	for idx, existing := range engine.routes {
+		if existing.pattern == pattern {
+			engine.routes[idx], replaced = r, true
+			break
+		}
+	}
+	if !replaced {
+		engine.routes = append(engine.routes, r)
+	}
+	engine.handler = nil
+}
+
+// Middlewares returns the middlewares of the engine in the order they run
+func (engine *Engine) Middlewares() []*Middleware {
+	engine.mutex.RLock()
+	defer engine.mutex.RUnlock()
+	return append([]*Middleware{}, engine.middlewares...)
+}
+
+// ServeHTTP serves requests with the handler of the route matching them, requests matching no route are not found,
+// after the middlewares of the engine. Requests are traced if the tracing spec of the config enables it, the span of a
+// request continues the trace of its `traceparent` header, and is the trace context of the Apps contexts of the request
+func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	engine.getHandler().ServeHTTP(w, req)
+}
+
+// getHandler returns the mux of the routes wrapped by their middlewares, built again after Use or Mount
+func (engine *Engine) getHandler() http.Handler {
+	engine.mutex.RLock()
+	handler := engine.handler
+	engine.mutex.RUnlock()
+	if handler != nil {
+		return handler
+	}
+
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+	if engine.handler == nil {
+		mux := http.NewServeMux()
+		root := false
+		for _, r := range engine.routes {
+			var middlewares []*Middleware
+			for _, middleware := range engine.middlewares {
+				if !r.skipped[middleware.Name] {
+					middlewares = append(middlewares, middleware)
+				}
+			}
+			mux.Handle(r.pattern, chain(r.handler, append(middlewares, r.middlewares...)))
+			root = root || r.pattern == "/"
+		}
+		if !root {
+			mux.Handle("/", chain(http.NotFoundHandler(), engine.middlewares))
+		}
+		engine.handler = engine.traced(mux)
+	}
+	return engine.handler
+}
+
+// traced wraps handler by a span of each request, unless the tracing spec of the config disables tracing
+func (engine *Engine) traced(handler http.Handler) http.Handler {
+	if engine.Config == nil || engine.Config.Tracing == nil || !diag_utils.IsTracingEnabled(engine.Config.Tracing.SamplingRate) {
+		return handler
+	}
+
+	return &ochttp.Handler{
+		Handler:     handler,
+		Propagation: &tracecontext.HTTPFormat{},
+		StartOptions: trace.StartOptions{
+			Sampler:  trace.ProbabilitySampler(diag_utils.GetTraceSamplingRate(engine.Config.Tracing.SamplingRate)),
+			SpanKind: trace.SpanKindServer,
+		},
+		FormatSpanName: func(req *http.Request) string {
+			return req.Method + " " + req.URL.Path
+		},
+	}
+}
+
+// chain wraps handler by middlewares, so that the first one runs first
+func chain(handler http.Handler, middlewares []*Middleware) http.Handler {
+	for idx := len(middlewares) - 1; idx >= 0; idx-- {
+		handler = middlewares[idx].Handler(handler)
+	}
+	return handler
+}
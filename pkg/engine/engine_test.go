@@ -0,0 +1,115 @@
+package engine_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bhojpur/application/pkg/config"
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+)
+
+// traceMiddleware returns a middleware which appends its name to the X-Trace header of responses
+func traceMiddleware(name string) *appsvr.Middleware {
+	return &appsvr.Middleware{Name: name, Handler: func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Add("X-Trace", name)
+			next.ServeHTTP(w, req)
+		})
+	}}
+}
+
+func serve(engine *appsvr.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestEngine(t *testing.T) {
+	engine := appsvr.New(&appsvr.Config{})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("X-Trace", "handler")
+	})
+
+	engine.Use(traceMiddleware("recovery"), traceMiddleware("cors"))
+	engine.Mount("/api/", ok, appsvr.With(traceMiddleware("auth")))
+	engine.Mount("/healthz", ok, appsvr.Skip("cors"))
+
+	assert.Equal(t, []string{"recovery", "cors", "auth", "handler"}, serve(engine, "/api/products").Header()["X-Trace"])
+	assert.Equal(t, []string{"recovery", "handler"}, serve(engine, "/healthz").Header()["X-Trace"])
+
+	w := serve(engine, "/missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, []string{"recovery", "cors"}, w.Header()["X-Trace"])
+
+	// middlewares apply to routes mounted before, and replace the ones with the same name
+	engine.Use(traceMiddleware("metrics"), &appsvr.Middleware{Name: "recovery", Handler: traceMiddleware("recover").Handler})
+	assert.Equal(t, []string{"recover", "cors", "metrics", "auth", "handler"}, serve(engine, "/api/products").Header()["X-Trace"])
+
+	var names []string
+	for _, middleware := range engine.Middlewares() {
+		names = append(names, middleware.Name)
+	}
+	assert.Equal(t, "recovery,cors,metrics", strings.Join(names, ","))
+
+	engine.Mount("/healthz", ok)
+	assert.Equal(t, []string{"recover", "cors", "metrics", "handler"}, serve(engine, "/healthz").Header()["X-Trace"])
+}
+
+type exporter struct {
+	spans []*trace.SpanData
+}
+
+func (e *exporter) ExportSpan(span *trace.SpanData) {
+	e.spans = append(e.spans, span)
+}
+
+func TestEngineTracing(t *testing.T) {
+	e := &exporter{}
+	trace.RegisterExporter(e)
+	t.Cleanup(func() { trace.UnregisterExporter(e) })
+
+	var traceID string
+	engine := appsvr.New(&appsvr.Config{Tracing: &config.TracingSpec{SamplingRate: "1"}})
+	engine.Mount("/api/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		traceID = (&appsvr.Context{Request: req}).GetTraceID()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	require.Len(t, e.spans, 1)
+	assert.Equal(t, "GET /api/products", e.spans[0].Name)
+	assert.Equal(t, "00f067aa0ba902b7", e.spans[0].ParentSpanID.String())
+
+	engine.Config.Tracing.SamplingRate = "0"
+	engine.Mount("/api/", http.NotFoundHandler())
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Len(t, e.spans, 1)
+}
@@ -0,0 +1,64 @@
+package engine
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+)
+
+// Translator translates the keys of messages and labels to locales, like the store of pkg/i18n
+type Translator interface {
+	// Translate returns the translation of key in locale, false if it has none
+	Translate(locale, key string) (string, bool)
+}
+
+// T translates key to the locale of the context with the translator of its config, args format the translation like
+// fmt.Sprintf. It returns key, formatted with args, if it has no translation
+//
+//	context.T("products.out_of_stock", product.Name)
+func (context *Context) T(key string, args ...interface{}) string {
+	return context.TDefault(key, key, args...)
+}
+
+// TDefault translates key like T, it returns value, formatted with args, if key has no translation
+func (context *Context) TDefault(key, value string, args ...interface{}) string {
+	if context.Config != nil && context.Config.Translator != nil {
+		if translation, ok := context.Config.Translator.Translate(context.locale(), key); ok {
+			value = translation
+		}
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(value, args...)
+	}
+	return value
+}
+
+// locale returns the locale of the context, or the one negotiated for its request, set to its Locale header
+func (context *Context) locale() string {
+	if locale := context.GetLocale(); locale != "" {
+		return locale
+	}
+	if context.Request != nil {
+		return context.Request.Header.Get("Locale")
+	}
+	return ""
+}
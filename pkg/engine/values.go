@@ -0,0 +1,145 @@
+package engine
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	stdcontext "context"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+// contextKey is the type of the keys of the values of the context set with typed accessors, so they never collide
+// with the keys of other packages
+type contextKey int
+
+const (
+	localeKey contextKey = iota
+	timeZoneKey
+	traceIDKey
+	traceContextKey
+	requestDBKey
+	readDBKey
+	fieldsKey
+)
+
+// Set sets a request-scoped value of the context, use a key of an unexported type of your package, like
+// `type contextKey string`, to avoid collisions. Values set to a clone are not seen by the context it is cloned from
+//
+//	type contextKey string
+//	context.Set(contextKey("tenant"), tenant)
+func (context *Context) Set(key, value interface{}) {
+	if context.values == nil {
+		context.values = map[interface{}]interface{}{}
+	}
+	context.values[key] = value
+}
+
+// Get returns a value set with Set, and whether it is set
+func (context *Context) Get(key interface{}) (interface{}, bool) {
+	value, ok := context.values[key]
+	return value, ok
+}
+
+// Delete removes a value set with Set
+func (context *Context) Delete(key interface{}) {
+	delete(context.values, key)
+}
+
+// GetCurrentUser get current user from current context
+func (context *Context) GetCurrentUser() CurrentUser {
+	return context.CurrentUser
+}
+
+// SetCurrentUser set current user into current context
+func (context *Context) SetCurrentUser(user CurrentUser) {
+	context.CurrentUser = user
+}
+
+// GetLocale get locale from current context, it is empty if not set
+func (context *Context) GetLocale() string {
+	locale, _ := context.values[localeKey].(string)
+	return locale
+}
+
+// SetLocale set locale into current context
+func (context *Context) SetLocale(locale string) {
+	context.Set(localeKey, locale)
+}
+
+// GetTimeZone get time zone from current context, time.Local if not set
+func (context *Context) GetTimeZone() *time.Location {
+	if location, ok := context.values[timeZoneKey].(*time.Location); ok && location != nil {
+		return location
+	}
+	return time.Local
+}
+
+// SetTimeZone set time zone into current context
+func (context *Context) SetTimeZone(location *time.Location) {
+	context.Set(timeZoneKey, location)
+}
+
+// GetTraceID get trace ID of the request from current context, the one of the span of its trace context if not set
+func (context *Context) GetTraceID() string {
+	if traceID, _ := context.values[traceIDKey].(string); traceID != "" {
+		return traceID
+	}
+	if span := trace.FromContext(context.GetTraceContext()); span != nil {
+		return span.SpanContext().TraceID.String()
+	}
+	return ""
+}
+
+// SetTraceID set trace ID of the request into current context
+func (context *Context) SetTraceID(traceID string) {
+	context.Set(traceIDKey, traceID)
+}
+
+// GetTraceContext get the context of the current span from current context, to start its child spans. It is the
+// context of the request if not set
+func (context *Context) GetTraceContext() stdcontext.Context {
+	if ctx, ok := context.values[traceContextKey].(stdcontext.Context); ok && ctx != nil {
+		return ctx
+	}
+	if context.Request != nil {
+		return context.Request.Context()
+	}
+	return stdcontext.Background()
+}
+
+// SetTraceContext set the context of the current span into current context
+func (context *Context) SetTraceContext(ctx stdcontext.Context) {
+	context.Set(traceContextKey, ctx)
+}
+
+// GetFields get the field projection from current context, the names of the fields of the records to find, all of
+// them if empty
+func (context *Context) GetFields() []string {
+	fields, _ := context.values[fieldsKey].([]string)
+	return fields
+}
+
+// SetFields set the field projection into current context, so that only the columns of the fields, and of the
+// primary fields, of the records are selected, like the sparse fieldset `?fields=Name,Code` of a request
+func (context *Context) SetFields(fields ...string) {
+	context.Set(fieldsKey, fields)
+}
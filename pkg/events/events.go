@@ -0,0 +1,182 @@
+package events
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.events")
+
+// Action is the mutation of a resource event.
+type Action string
+
+// Actions of resource events
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	// ActionPublish and ActionUnpublish are published by pkg/publish, when drafts go live or are taken down
+	ActionPublish   Action = "publish"
+	ActionUnpublish Action = "unpublish"
+)
+
+// Event is published for every successful mutation of a resource.
+type Event struct {
+	Resource   string      `json:"resource"`
+	Action     Action      `json:"action"`
+	PrimaryKey string      `json:"primaryKey"`
+	Actor      string      `json:"actor,omitempty"`
+	Record     interface{} `json:"record,omitempty"`
+	Timestamp  time.Time   `json:"timestamp"`
+}
+
+// Handler handles the events of a subscription, in process.
+type Handler func(ctx context.Context, event Event) error
+
+// Sink forwards events to an external system, like NATS or Kafka.
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// SinkFunc is a function implementing Sink.
+type SinkFunc func(ctx context.Context, event Event) error
+
+// Publish implements Sink.
+func (fc SinkFunc) Publish(ctx context.Context, event Event) error {
+	return fc(ctx, event)
+}
+
+type subscription struct {
+	id        uint64
+	handler   Handler
+	resources map[string]bool
+}
+
+// Bus is a publish/subscribe bus of resource events. Events are dispatched synchronously to the
+// subscriptions and then to the sinks, the errors of the handlers and sinks are logged.
+type Bus struct {
+	subscriptions []*subscription
+	sinks         []Sink
+	lastID        uint64
+	lock          sync.RWMutex
+}
+
+// DefaultBus is the bus the resources publish their events to by default.
+var DefaultBus = NewBus()
+
+// NewBus returns an empty bus.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe subscribes the handler to the events of the resources, or of every resource if none is given.
+// It returns a function to unsubscribe.
+func (b *Bus) Subscribe(handler Handler, resources ...string) func() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.lastID++
+	s := &subscription{id: b.lastID, handler: handler}
+	if len(resources) > 0 {
+		s.resources = map[string]bool{}
+		for _, resource := range resources {
+			s.resources[resource] = true
+		}
+	}
+	b.subscriptions = append(b.subscriptions, s)
+
+	return func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+
+		for idx, subscription := range b.subscriptions {
+			if subscription.id == s.id {
+				b.subscriptions = append(b.subscriptions[:idx:idx], b.subscriptions[idx+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// AddSink adds a sink the events are forwarded to.
+func (b *Bus) AddSink(sink Sink) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.sinks = append(b.sinks, sink)
+}
+
+// Publish publishes an event, and returns the number of handlers and sinks which failed to process it.
+func (b *Bus) Publish(ctx context.Context, event Event) int {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	b.lock.RLock()
+	subscriptions, sinks := b.subscriptions, b.sinks
+	b.lock.RUnlock()
+
+	var failed int
+	for _, s := range subscriptions {
+		if s.resources != nil && !s.resources[event.Resource] {
+			continue
+		}
+
+		if err := dispatch(ctx, s.handler, event); err != nil {
+			log.Warnf("event handler failed to process %s of %s %s: %s", event.Action, event.Resource, event.PrimaryKey, err)
+			failed++
+		}
+	}
+
+	for _, sink := range sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			log.Warnf("event sink failed to publish %s of %s %s: %s", event.Action, event.Resource, event.PrimaryKey, err)
+			failed++
+		}
+	}
+	return failed
+}
+
+// dispatch calls the handler, recovering from its panics
+func dispatch(ctx context.Context, handler Handler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, event)
+}
+
+// Subscribe subscribes the handler to the events of the resources on the default bus.
+func Subscribe(handler Handler, resources ...string) func() {
+	return DefaultBus.Subscribe(handler, resources...)
+}
+
+// AddSink adds a sink to the default bus.
+func AddSink(sink Sink) {
+	DefaultBus.AddSink(sink)
+}
@@ -0,0 +1,95 @@
+package events_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/bhojpur/service/pkg/pubsub"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bhojpur/application/pkg/events"
+)
+
+func TestBus(t *testing.T) {
+	bus := events.NewBus()
+
+	var all, products []events.Event
+	unsubscribe := bus.Subscribe(func(ctx context.Context, event events.Event) error {
+		all = append(all, event)
+		return nil
+	})
+	bus.Subscribe(func(ctx context.Context, event events.Event) error {
+		products = append(products, event)
+		return errors.New("failed")
+	}, "Product")
+	bus.Subscribe(func(ctx context.Context, event events.Event) error {
+		panic("boom")
+	}, "Order")
+
+	var sunk []events.Event
+	bus.AddSink(events.SinkFunc(func(ctx context.Context, event events.Event) error {
+		sunk = append(sunk, event)
+		return nil
+	}))
+
+	assert.Equal(t, 1, bus.Publish(context.Background(), events.Event{Resource: "Product", Action: events.ActionCreate, PrimaryKey: "1"}))
+	assert.Equal(t, 1, bus.Publish(context.Background(), events.Event{Resource: "Order", Action: events.ActionDelete, PrimaryKey: "2"}))
+
+	assert.Len(t, all, 2)
+	assert.False(t, all[0].Timestamp.IsZero())
+	assert.Len(t, products, 1)
+	assert.Len(t, sunk, 2)
+
+	unsubscribe()
+	bus.Publish(context.Background(), events.Event{Resource: "Product", Action: events.ActionUpdate, PrimaryKey: "1"})
+	assert.Len(t, all, 2)
+	assert.Len(t, products, 2)
+}
+
+type fakePubSub struct {
+	pubsub.PubSub
+	requests []*pubsub.PublishRequest
+}
+
+func (f *fakePubSub) Publish(req *pubsub.PublishRequest) error {
+	f.requests = append(f.requests, req)
+	return nil
+}
+
+func TestPubSubSink(t *testing.T) {
+	fake := &fakePubSub{}
+	sink := &events.PubSubSink{PubSub: fake, PubsubName: "nats", Topic: "resources.{resource}.{action}"}
+
+	err := sink.Publish(context.Background(), events.Event{Resource: "Product", Action: events.ActionUpdate, PrimaryKey: "1", Actor: "admin"})
+	assert.NoError(t, err)
+	if assert.Len(t, fake.requests, 1) {
+		assert.Equal(t, "nats", fake.requests[0].PubsubName)
+		assert.Equal(t, "resources.Product.update", fake.requests[0].Topic)
+
+		var event events.Event
+		assert.NoError(t, json.Unmarshal(fake.requests[0].Data, &event))
+		assert.Equal(t, "admin", event.Actor)
+	}
+}
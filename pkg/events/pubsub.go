@@ -0,0 +1,59 @@
+package events
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/bhojpur/service/pkg/pubsub"
+)
+
+// PubSubSink publishes events to a topic of a pub/sub component, like NATS or Kafka.
+type PubSubSink struct {
+	PubSub     pubsub.PubSub
+	PubsubName string
+	// Topic is the topic of the events, it could contain the resource and action, like
+	// "resources.{resource}.{action}".
+	Topic string
+}
+
+// Publish implements Sink.
+func (s *PubSubSink) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	contentType := "application/json"
+	return s.PubSub.Publish(&pubsub.PublishRequest{
+		Data:        data,
+		PubsubName:  s.PubsubName,
+		Topic:       Topic(s.Topic, event),
+		ContentType: &contentType,
+	})
+}
+
+// Topic expands the {resource} and {action} placeholders of a topic with the ones of the event.
+func Topic(topic string, event Event) string {
+	return strings.NewReplacer("{resource}", event.Resource, "{action}", string(event.Action)).Replace(topic)
+}
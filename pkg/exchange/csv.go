@@ -0,0 +1,67 @@
+package exchange
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/bhojpur/application/pkg/utils"
+)
+
+// NewCSVReader returns a reader of the rows of a CSV file, rows may have less cells than the header
+func NewCSVReader(r io.Reader) Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	return reader
+}
+
+// NewCSVWriter returns a writer of rows to a CSV file, Close flushes them but doesn't close w
+func NewCSVWriter(w io.Writer) Writer {
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func (w *csvWriter) Write(values []interface{}) error {
+	w.record = w.record[:0]
+	for _, value := range values {
+		if value == nil {
+			w.record = append(w.record, "")
+		} else {
+			w.record = append(w.record, utils.ToString(value))
+		}
+	}
+	return w.writer.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
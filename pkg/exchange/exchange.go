@@ -0,0 +1,360 @@
+package exchange
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/pkg/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// TimeFormat is the format of the times of exported files
+var TimeFormat = "2006-01-02 15:04:05"
+
+// Reader reads the rows of a file, the first row is the header. Read returns io.EOF after the last row
+type Reader interface {
+	Read() ([]string, error)
+}
+
+// Writer writes the rows of a file, Close flushes them
+type Writer interface {
+	Write(values []interface{}) error
+	Close() error
+}
+
+// Template maps the columns of the files exchanged with a resource to its metas. Imports set the records with the
+// setters of the metas, so the validators and processors of the resource apply like for forms, and exports get the
+// values with their valuers
+type Template struct {
+	*resource.Resource
+	// BatchSize is the number of records exported or imported at once, 500 by default
+	BatchSize int
+	columns   []*Column
+}
+
+// Column is a column of a file, set from and to a meta of the resource
+type Column struct {
+	Header string
+	Meta   *resource.Meta
+}
+
+// New initialize a template of a resource, with a column for each field of its model except ignored and
+// association fields, the header of a column being the name of its field
+func New(res *resource.Resource) *Template {
+	template := &Template{Resource: res, BatchSize: 500}
+	for _, field := range (&orm.Scope{Value: res.Value}).GetStructFields() {
+		if field.IsIgnored || field.Relationship != nil {
+			continue
+		}
+		template.Column(field.Name, &resource.Meta{Name: field.Name})
+	}
+	return template
+}
+
+// Column adds a column of a meta, or replaces the one with the same header, columns are in the order they are added
+//
+//	products.Column("Category", &resource.Meta{Name: "CategoryCode", FieldName: "Category.Code"})
+func (template *Template) Column(header string, meta *resource.Meta) *Column {
+	meta.BaseResource = template
+	if err := meta.PreInitialize(); err != nil {
+		panic(err)
+	}
+	if err := meta.Initialize(); err != nil {
+		panic(err)
+	}
+
+	column := &Column{Header: header, Meta: meta}
+	for idx, c := range template.columns {
+		if c.Header == header {
+			template.columns[idx] = column
+			return column
+		}
+	}
+	template.columns = append(template.columns, column)
+	return column
+}
+
+// RemoveColumn removes the column with the header
+func (template *Template) RemoveColumn(header string) {
+	for idx, c := range template.columns {
+		if c.Header == header {
+			template.columns = append(template.columns[:idx], template.columns[idx+1:]...)
+			return
+		}
+	}
+}
+
+// GetColumns returns the columns of the template
+func (template *Template) GetColumns() []*Column {
+	return template.columns
+}
+
+// GetMetas returns the metas of the columns, all of them if names is empty, to match interface `Resourcer`
+func (template *Template) GetMetas(names []string) []resource.Metaor {
+	var metas []resource.Metaor
+	for _, column := range template.columns {
+		if len(names) == 0 {
+			metas = append(metas, meta{column.Meta})
+			continue
+		}
+		for _, name := range names {
+			if column.Meta.Name == name {
+				metas = append(metas, meta{column.Meta})
+			}
+		}
+	}
+	return metas
+}
+
+// meta is a meta of a template to match interface `Metaor`, cells are not nested
+type meta struct {
+	*resource.Meta
+}
+
+func (meta) GetMetas() []resource.Metaor {
+	return nil
+}
+
+func (meta) GetResource() resource.Resourcer {
+	return nil
+}
+
+// Export writes the header and the records found with the context, in batches so that large tables are streamed,
+// and closes w. It returns the number of exported records
+//
+//	count, err := products.Export(context, exchange.NewCSVWriter(w))
+func (template *Template) Export(context *appsvr.Context, w Writer) (int, error) {
+	header := make([]interface{}, len(template.columns))
+	for idx, column := range template.columns {
+		header[idx] = column.Header
+	}
+	if err := w.Write(header); err != nil {
+		return 0, err
+	}
+
+	var exported int
+	for offset := 0; ; offset += template.batchSize() {
+		batchContext := context.Clone()
+		batchContext.SetDB(context.GetDB().Limit(template.batchSize()).Offset(offset))
+
+		records := template.NewSlice()
+		if err := template.CallFindMany(records, batchContext); err != nil {
+			return exported, err
+		}
+
+		values := reflect.Indirect(reflect.ValueOf(records))
+		for i := 0; i < values.Len(); i++ {
+			record := values.Index(i).Interface()
+			row := make([]interface{}, len(template.columns))
+			for idx, column := range template.columns {
+				if column.Meta.HasPermission(roles.Read, context) && column.Meta.IsShown(record, context) {
+					row[idx] = cellValue(column.Meta.GetValuer()(record, context), context)
+				}
+			}
+			if err := w.Write(row); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+
+		if values.Len() < template.batchSize() {
+			break
+		}
+	}
+	return exported, w.Close()
+}
+
+// Import imports the rows read from r after its header with the resource, in batches of the mode of config, see
+// resource.ImportMode. The import stops after the first batch with a failing row, the rows of the previous batches
+// are kept. The line of a row is its number among the rows of the file, the header being the first one. The returned error is for
+// failures of the import itself, like an unknown column or an unreadable file, failures of rows are in the report
+//
+//	report, err := products.Import(context, exchange.NewCSVReader(file), resource.ImportConfig{Mode: resource.ImportDeferred})
+func (template *Template) Import(context *appsvr.Context, r Reader, config resource.ImportConfig) (*resource.ImportReport, error) {
+	report := &resource.ImportReport{}
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return report, nil
+	} else if err != nil {
+		return report, err
+	}
+
+	columns, err := template.headerColumns(header)
+	if err != nil {
+		return report, err
+	}
+
+	var batch []resource.ImportRow
+	flush := func() error {
+		batchReport, err := template.Resource.Import(context, batch, config)
+		if batchReport != nil {
+			report.Imported += batchReport.Imported
+			report.Errors = append(report.Errors, batchReport.Errors...)
+		}
+		batch = batch[:0]
+		return err
+	}
+
+	for line := 2; ; line++ {
+		cells, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return report, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if row, ok := template.importRow(line, columns, cells); ok {
+			report.Total++
+			if batch = append(batch, row); len(batch) >= template.batchSize() {
+				if err := flush(); err != nil || report.HasError() {
+					return report, err
+				}
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		return report, flush()
+	}
+	return report, nil
+}
+
+func (template *Template) batchSize() int {
+	if template.BatchSize <= 0 {
+		return 500
+	}
+	return template.BatchSize
+}
+
+// headerColumns returns the columns of the cells of the header, matched by header, case insensitively
+func (template *Template) headerColumns(header []string) ([]*Column, error) {
+	var (
+		columns = make([]*Column, len(header))
+		unknown []string
+		seen    = map[*Column]bool{}
+	)
+	for idx, name := range header {
+		name = strings.TrimSpace(name)
+		if idx == 0 {
+			// byte order mark of files saved by spreadsheets
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		if name == "" {
+			continue
+		}
+
+		for _, column := range template.columns {
+			if strings.EqualFold(column.Header, name) {
+				columns[idx] = column
+				break
+			}
+		}
+
+		switch {
+		case columns[idx] == nil:
+			unknown = append(unknown, name)
+		case seen[columns[idx]]:
+			return nil, fmt.Errorf("duplicated column %s", name)
+		default:
+			seen[columns[idx]] = true
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown columns %s of resource %s", strings.Join(unknown, ", "), template.Name)
+	}
+	return columns, nil
+}
+
+// importRow returns the meta values of the cells of a row, false if the row is blank. Blank primary keys are
+// skipped, so that the row creates a record
+func (template *Template) importRow(line int, columns []*Column, cells []string) (resource.ImportRow, bool) {
+	var (
+		metaValues = &resource.MetaValues{}
+		blank      = true
+	)
+	for idx, cell := range cells {
+		if idx >= len(columns) || columns[idx] == nil {
+			continue
+		}
+		if cell = strings.TrimSpace(cell); cell != "" {
+			blank = false
+		} else if template.isPrimaryKey(columns[idx].Meta) {
+			continue
+		}
+		metaValues.Values = append(metaValues.Values, &resource.MetaValue{Name: columns[idx].Meta.Name, Value: cell, Meta: meta{columns[idx].Meta}})
+	}
+	return resource.ImportRow{Line: line, MetaValues: metaValues}, !blank
+}
+
+func (template *Template) isPrimaryKey(meta *resource.Meta) bool {
+	for _, field := range template.PrimaryFields {
+		if field.Name == meta.GetFieldName() {
+			return true
+		}
+	}
+	return false
+}
+
+// cellValue returns the value of a cell of a meta value, times are formatted with TimeFormat
+func cellValue(value interface{}, context *appsvr.Context) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+		return utils.FormatTime(v, TimeFormat, context)
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return cellValue(*v, context)
+	case driver.Valuer:
+		if reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+			return nil
+		}
+		value, err := v.Value()
+		if err != nil {
+			return nil
+		}
+		return cellValue(value, context)
+	}
+
+	if reflected := reflect.ValueOf(value); reflected.Kind() == reflect.Ptr {
+		if reflected.IsNil() {
+			return nil
+		}
+		return cellValue(reflected.Elem().Interface(), context)
+	}
+	return value
+}
@@ -0,0 +1,158 @@
+package exchange_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/exchange"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/validations"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Product struct {
+	ID     uint
+	Name   string
+	Price  float64
+	Active bool
+}
+
+func newTemplate(t *testing.T) (*exchange.Template, *appsvr.Context) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, price REAL, active BOOLEAN)`)
+
+	res := resource.New(&Product{})
+	res.AddProcessor(&resource.Processor{
+		Name: "name",
+		Handler: func(record interface{}, metaValues *resource.MetaValues, context *appsvr.Context) error {
+			if record.(*Product).Name == "" {
+				return validations.NewError(record, "Name", "Name can't be blank")
+			}
+			return nil
+		},
+	})
+	return exchange.New(res), &appsvr.Context{Config: &appsvr.Config{DB: db}}
+}
+
+func readAll(t *testing.T, r exchange.Reader) (rows [][]string) {
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return rows
+		}
+		require.NoError(t, err)
+		rows = append(rows, append([]string{}, row...))
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	template, context := newTemplate(t)
+	template.BatchSize = 2
+
+	csv := "name,Price,active\nBlue,10.5,true\nGreen,3,false\n\nRed,1,true\n"
+	report, err := template.Import(context, exchange.NewCSVReader(strings.NewReader(csv)), resource.ImportConfig{Mode: resource.ImportDeferred})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 3, report.Imported)
+	assert.False(t, report.HasError())
+
+	var products []Product
+	require.NoError(t, context.GetDB().Order("id").Find(&products).Error)
+	assert.Equal(t, []Product{{1, "Blue", 10.5, true}, {2, "Green", 3, false}, {3, "Red", 1, true}}, products)
+
+	// failing rows are reported by line, and rows with primary keys update their records
+	csv = "ID,Name,Price\n1,Black,10.5\n,,2\n"
+	report, err = template.Import(context, exchange.NewCSVReader(strings.NewReader(csv)), resource.ImportConfig{Mode: resource.ImportDeferred})
+	require.NoError(t, err)
+	require.True(t, report.HasError())
+	assert.Equal(t, []int{3}, report.FailedLines())
+	assert.Equal(t, "Name", report.Errors[0].Column)
+
+	csv = "ID,Name,Price\n1,Black,10.5\n"
+	report, err = template.Import(context, exchange.NewCSVReader(strings.NewReader(csv)), resource.ImportConfig{Mode: resource.ImportDeferred})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	var product Product
+	require.NoError(t, context.GetDB().First(&product, 1).Error)
+	assert.Equal(t, "Black", product.Name)
+
+	_, err = template.Import(context, exchange.NewCSVReader(strings.NewReader("Name,Color\n")), resource.ImportConfig{})
+	assert.EqualError(t, err, "unknown columns Color of resource Product")
+}
+
+func TestExport(t *testing.T) {
+	template, context := newTemplate(t)
+	template.BatchSize = 2
+	template.RemoveColumn("Active")
+	for _, name := range []string{"Blue", "Green", "Red"} {
+		require.NoError(t, context.GetDB().Create(&Product{Name: name, Price: 2.5}).Error)
+	}
+
+	var buf bytes.Buffer
+	count, err := template.Export(context, exchange.NewCSVWriter(&buf))
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, "ID,Name,Price\n3,Red,2.5\n2,Green,2.5\n1,Blue,2.5\n", buf.String())
+
+	buf.Reset()
+	_, err = template.Export(context, exchange.NewXLSXWriter(&buf, "Products"))
+	require.NoError(t, err)
+	reader, err := exchange.NewXLSXReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"ID", "Name", "Price"}, {"3", "Red", "2.5"}, {"2", "Green", "2.5"}, {"1", "Blue", "2.5"},
+	}, readAll(t, reader))
+
+	// exported files import back
+	report, err := template.Import(context, exchange.NewCSVReader(strings.NewReader("ID,Name,Price\n3,Purple,2.5\n")), resource.ImportConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+}
+
+func TestXLSXReader(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"xl/sharedStrings.xml":     `<sst><si><t>Name</t></si><si><r><t>Bo</t></r><r><t>b</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="B1" t="s"><v>0</v></c></row><row r="3"><c r="A3"><f>1+1</f><v>2</v></c><c r="B3" t="s"><v>1</v></c><c r="C3" t="b"><v>1</v></c></row></sheetData></worksheet>`,
+	} {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = io.WriteString(w, content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+
+	reader, err := exchange.NewXLSXReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	// missing rows are read as empty rows, so that lines match the rows of the sheet
+	assert.Equal(t, [][]string{{"", "Name"}, {}, {"2", "Bob", "true"}}, readAll(t, reader))
+
+	_, err = exchange.NewXLSXReader(strings.NewReader("not a zip"), 9)
+	assert.Error(t, err)
+}
@@ -0,0 +1,459 @@
+package exchange
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Namespaces and content types of the parts of XLSX files, see ECMA-376
+const (
+	xlsxMainNamespace         = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsxRelationshipNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	xlsxPackageRelationships  = "http://schemas.openxmlformats.org/package/2006/relationships"
+)
+
+var errXLSXClosed = errors.New("xlsx: write after close")
+
+// NewXLSXReader returns a reader of the rows of the first sheet of a XLSX file of size bytes. Rows are decoded as they
+// are read, only the shared strings of the file are loaded at once. Dates are read as the serial numbers of the cells,
+// unless they are stored as text
+func NewXLSXReader(r io.ReaderAt, size int64) (Reader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX file: %w", err)
+	}
+
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[strings.TrimPrefix(file.Name, "/")] = file
+	}
+
+	reader := &xlsxReader{}
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if reader.sharedStrings, err = readSharedStrings(file); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, ok := files[firstSheetPath(files)]
+	if !ok {
+		return nil, errors.New("invalid XLSX file: no worksheet")
+	}
+	if reader.sheet, err = sheet.Open(); err != nil {
+		return nil, err
+	}
+	reader.decoder = xml.NewDecoder(reader.sheet)
+	return reader, nil
+}
+
+// firstSheetPath returns the path of the first sheet of the workbook
+func firstSheetPath(files map[string]*zip.File) string {
+	var (
+		workbook struct {
+			Sheets []struct {
+				ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+			} `xml:"sheets>sheet"`
+		}
+		relationships struct {
+			Relationships []struct {
+				ID     string `xml:"Id,attr"`
+				Target string `xml:"Target,attr"`
+			} `xml:"Relationship"`
+		}
+	)
+
+	if decodeXMLFile(files["xl/workbook.xml"], &workbook) == nil && len(workbook.Sheets) > 0 &&
+		decodeXMLFile(files["xl/_rels/workbook.xml.rels"], &relationships) == nil {
+		for _, relationship := range relationships.Relationships {
+			if relationship.ID != workbook.Sheets[0].ID {
+				continue
+			}
+			if strings.HasPrefix(relationship.Target, "/") {
+				return strings.TrimPrefix(relationship.Target, "/")
+			}
+			return path.Join("xl", relationship.Target)
+		}
+	}
+	return "xl/worksheets/sheet1.xml"
+}
+
+func decodeXMLFile(file *zip.File, value interface{}) error {
+	if file == nil {
+		return errors.New("missing file")
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return xml.NewDecoder(reader).Decode(value)
+}
+
+// readSharedStrings returns the shared strings of a XLSX file, the text of rich strings is concatenated
+func readSharedStrings(file *zip.File) ([]string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var (
+		strs    []string
+		current strings.Builder
+		inText  bool
+		decoder = xml.NewDecoder(reader)
+	)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return strs, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid XLSX shared strings: %w", err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "si":
+				current.Reset()
+			case "t":
+				inText = true
+			case "rPh":
+				// phonetic hints are not part of the text
+				if err := decoder.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			switch token.Name.Local {
+			case "si":
+				strs = append(strs, current.String())
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				current.Write(token)
+			}
+		}
+	}
+}
+
+type xlsxReader struct {
+	sheet         io.ReadCloser
+	decoder       *xml.Decoder
+	sharedStrings []string
+	// row is the number of the last row read, missing rows of the sheet are read as empty rows
+	row     int
+	pending []string
+	done    bool
+}
+
+func (r *xlsxReader) Read() ([]string, error) {
+	if r.pending != nil {
+		r.row++
+		if next, _ := strconv.Atoi(r.pending[0]); r.row < next {
+			return []string{}, nil
+		}
+		cells := r.pending[1:]
+		r.pending = nil
+		return cells, nil
+	}
+	if r.done {
+		return nil, io.EOF
+	}
+
+	for {
+		token, err := r.decoder.Token()
+		if err == io.EOF {
+			r.done = true
+			r.sheet.Close()
+			return nil, io.EOF
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid XLSX sheet: %w", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "row" {
+			number := r.row + 1
+			if value := xmlAttr(start, "r"); value != "" {
+				if number, err = strconv.Atoi(value); err != nil {
+					return nil, fmt.Errorf("invalid XLSX row %q", value)
+				}
+			}
+
+			cells, err := r.readRow()
+			if err != nil {
+				return nil, err
+			}
+			// the number of the row is kept with its cells until the missing rows before it are read
+			r.pending = append([]string{strconv.Itoa(number)}, cells...)
+			return r.Read()
+		}
+	}
+}
+
+// readRow reads the cells of a row, placed by their reference
+func (r *xlsxReader) readRow() ([]string, error) {
+	var cells []string
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid XLSX sheet: %w", err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			if token.Name.Local != "c" {
+				continue
+			}
+
+			idx := len(cells)
+			if ref := xmlAttr(token, "r"); ref != "" {
+				if idx, err = columnIndex(ref); err != nil {
+					return nil, err
+				}
+			}
+			value, err := r.readCell(xmlAttr(token, "t"))
+			if err != nil {
+				return nil, err
+			}
+
+			for len(cells) <= idx {
+				cells = append(cells, "")
+			}
+			cells[idx] = value
+		case xml.EndElement:
+			if token.Name.Local == "row" {
+				return cells, nil
+			}
+		}
+	}
+}
+
+// readCell reads the value of a cell of the type
+func (r *xlsxReader) readCell(typ string) (string, error) {
+	var (
+		value  strings.Builder
+		inText bool
+	)
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("invalid XLSX sheet: %w", err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "v", "t":
+				inText = true
+			case "f", "rPh":
+				if err := r.decoder.Skip(); err != nil {
+					return "", err
+				}
+			}
+		case xml.EndElement:
+			switch token.Name.Local {
+			case "v", "t":
+				inText = false
+			case "c":
+				return r.cellValue(typ, value.String())
+			}
+		case xml.CharData:
+			if inText {
+				value.Write(token)
+			}
+		}
+	}
+}
+
+func (r *xlsxReader) cellValue(typ, value string) (string, error) {
+	switch typ {
+	case "s":
+		idx, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || idx < 0 || idx >= len(r.sharedStrings) {
+			return "", fmt.Errorf("invalid XLSX shared string %q", value)
+		}
+		return r.sharedStrings[idx], nil
+	case "b":
+		if strings.TrimSpace(value) == "1" {
+			return "true", nil
+		}
+		return "false", nil
+	}
+	return value, nil
+}
+
+func xmlAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// columnIndex returns the index of the column of a cell reference, like 27 for AB3
+func columnIndex(ref string) (int, error) {
+	idx := 0
+	for i, c := range ref {
+		if c >= 'A' && c <= 'Z' {
+			idx = idx*26 + int(c-'A') + 1
+		} else if c >= 'a' && c <= 'z' {
+			idx = idx*26 + int(c-'a') + 1
+		} else if i == 0 {
+			return 0, fmt.Errorf("invalid XLSX cell reference %q", ref)
+		} else {
+			break
+		}
+	}
+	return idx - 1, nil
+}
+
+// columnName returns the name of the column of an index, like AB for 27
+func columnName(idx int) string {
+	var name []byte
+	for idx++; idx > 0; idx = (idx - 1) / 26 {
+		name = append([]byte{byte('A' + (idx-1)%26)}, name...)
+	}
+	return string(name)
+}
+
+// NewXLSXWriter returns a writer of rows to the sheet of a XLSX file, rows are written to w as they are written,
+// Close writes the workbook but doesn't close w. Strings are written inline, numbers and booleans as typed cells
+func NewXLSXWriter(w io.Writer, sheet string) Writer {
+	if sheet == "" {
+		sheet = "Sheet1"
+	}
+	return &xlsxWriter{archive: zip.NewWriter(w), sheetName: sheet}
+}
+
+type xlsxWriter struct {
+	archive   *zip.Writer
+	sheetName string
+	sheet     *bufio.Writer
+	row       int
+	closed    bool
+}
+
+func (w *xlsxWriter) Write(values []interface{}) error {
+	if w.closed {
+		return errXLSXClosed
+	}
+	if err := w.openSheet(); err != nil {
+		return err
+	}
+
+	w.row++
+	fmt.Fprintf(w.sheet, `<row r="%d">`, w.row)
+	for idx, value := range values {
+		ref := columnName(idx) + strconv.Itoa(w.row)
+		switch value := value.(type) {
+		case nil:
+			continue
+		case bool:
+			if value {
+				fmt.Fprintf(w.sheet, `<c r="%s" t="b"><v>1</v></c>`, ref)
+			} else {
+				fmt.Fprintf(w.sheet, `<c r="%s" t="b"><v>0</v></c>`, ref)
+			}
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			fmt.Fprintf(w.sheet, `<c r="%s"><v>%v</v></c>`, ref, value)
+		default:
+			fmt.Fprintf(w.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(w.sheet, []byte(fmt.Sprint(value))); err != nil {
+				return err
+			}
+			w.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := w.sheet.WriteString(`</row>`)
+	return err
+}
+
+// openSheet starts the part of the sheet, the other parts are written once the rows are
+func (w *xlsxWriter) openSheet() error {
+	if w.sheet != nil {
+		return nil
+	}
+
+	part, err := w.archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	w.sheet = bufio.NewWriter(part)
+	_, err = w.sheet.WriteString(xml.Header + `<worksheet xmlns="` + xlsxMainNamespace + `"><sheetData>`)
+	return err
+}
+
+func (w *xlsxWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	if err := w.openSheet(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	w.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+
+	var sheetName strings.Builder
+	xml.EscapeText(&sheetName, []byte(w.sheetName))
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="` + xlsxPackageRelationships + `">` +
+			`<Relationship Id="rId1" Type="` + xlsxRelationshipNamespace + `/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="` + xlsxMainNamespace + `" xmlns:r="` + xlsxRelationshipNamespace + `">` +
+			`<sheets><sheet name="` + sheetName.String() + `" sheetId="1" r:id="rId1"/></sheets>` +
+			`</workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="` + xlsxPackageRelationships + `">` +
+			`<Relationship Id="rId1" Type="` + xlsxRelationshipNamespace + `/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+	}
+	for _, part := range parts {
+		writer, err := w.archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(writer, xml.Header+part.content); err != nil {
+			return err
+		}
+	}
+	return w.archive.Close()
+}
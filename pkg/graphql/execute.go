@@ -0,0 +1,575 @@
+package graphql
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/pkg/validations"
+)
+
+// Request is a GraphQL request
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is a GraphQL response, Data is absent if the request failed before its execution
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Location is a location of a request document
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is an error of a response, Path is the path of the field whose resolver failed. Extensions have the
+// `code` of permission and validation errors, and the `field` of validation errors
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (err *Error) Error() string {
+	return err.Message
+}
+
+// orderedMap is an object of a response, its fields are in the order of the selection set
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if m.values == nil {
+		m.values = map[string]interface{}{}
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// MarshalJSON implements json.Marshaler
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for idx, key := range m.keys {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// execution is the execution of an operation of a request
+type execution struct {
+	schema    *schema
+	document  *document
+	operation *operation
+	variables map[string]interface{}
+	context   *appsvr.Context
+	errors    []*Error
+}
+
+// prepare parses the request, selects its operation and coerces its variables, the response has the errors
+// of the request if it is invalid
+func (s *schema) prepare(request *Request, context *appsvr.Context) (*execution, *Response) {
+	doc, err := parse(request.Query)
+	if err != nil {
+		return nil, &Response{Errors: []*Error{toError(err)}}
+	}
+
+	e := &execution{schema: s, document: doc, context: context}
+	for _, op := range doc.operations {
+		if request.OperationName == "" || op.name == request.OperationName {
+			if e.operation != nil {
+				if request.OperationName == "" {
+					return nil, &Response{Errors: []*Error{{Message: "Must provide operation name if query contains multiple operations."}}}
+				}
+				return nil, &Response{Errors: []*Error{{Message: fmt.Sprintf("There can be only one operation named %q.", op.name)}}}
+			}
+			e.operation = op
+		}
+	}
+	if e.operation == nil {
+		return nil, &Response{Errors: []*Error{{Message: fmt.Sprintf("Unknown operation named %q.", request.OperationName)}}}
+	}
+
+	if e.variables, err = e.coerceVariables(request.Variables); err != nil {
+		return nil, &Response{Errors: []*Error{toError(err)}}
+	}
+	return e, nil
+}
+
+func (e *execution) coerceVariables(values map[string]interface{}) (map[string]interface{}, error) {
+	variables := map[string]interface{}{}
+	for _, definition := range e.operation.variables {
+		t, ok := e.schema.typeOf(definition.typ)
+		if !ok || (t.namedType().kind != kindScalar && t.namedType().kind != kindEnum && t.namedType().kind != kindInputObject) {
+			return nil, &Error{
+				Message:   fmt.Sprintf("Variable \"$%s\" cannot be non-input type %q.", definition.name, definition.typ),
+				Locations: []Location{definition.location},
+			}
+		}
+
+		value, ok := values[definition.name]
+		if !ok && definition.hasDefault {
+			value, ok = e.valueOf(definition.defaultValue), true
+		}
+		if !ok {
+			if t.kind == kindNonNull {
+				return nil, &Error{
+					Message:   fmt.Sprintf("Variable \"$%s\" of required type %q was not provided.", definition.name, definition.typ),
+					Locations: []Location{definition.location},
+				}
+			}
+			continue
+		}
+
+		coerced, err := coerceInput(t, value)
+		if err != nil {
+			return nil, &Error{
+				Message:   fmt.Sprintf("Variable \"$%s\" got invalid value %s; %v", definition.name, printValue(value), err),
+				Locations: []Location{definition.location},
+			}
+		}
+		variables[definition.name] = coerced
+	}
+	return variables, nil
+}
+
+// valueOf returns the value of a literal, with the values of its variables
+func (e *execution) valueOf(v value) interface{} {
+	switch value := v.(type) {
+	case variable:
+		return e.variables[string(value)]
+	case []value:
+		list := make([]interface{}, len(value))
+		for idx, item := range value {
+			list[idx] = e.valueOf(item)
+		}
+		return list
+	case objectValue:
+		object := make(map[string]interface{}, len(value))
+		for _, f := range value {
+			if name, ok := f.value.(variable); ok {
+				if _, provided := e.variables[string(name)]; !provided {
+					continue
+				}
+			}
+			object[f.name] = e.valueOf(f.value)
+		}
+		return object
+	}
+	return v
+}
+
+// coerceInput coerces a value to an input type
+func coerceInput(t *graphType, v interface{}) (interface{}, error) {
+	if t.kind == kindNonNull {
+		if v == nil {
+			return nil, fmt.Errorf("Expected non-nullable type %q not to be null.", t)
+		}
+		return coerceInput(t.ofType, v)
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t.kind {
+	case kindList:
+		items, ok := v.([]interface{})
+		if !ok {
+			item, err := coerceInput(t.ofType, v)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{item}, nil
+		}
+
+		list := make([]interface{}, len(items))
+		for idx, item := range items {
+			coerced, err := coerceInput(t.ofType, item)
+			if err != nil {
+				return nil, fmt.Errorf("At index %d: %v", idx, err)
+			}
+			list[idx] = coerced
+		}
+		return list, nil
+	case kindInputObject:
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Expected type %q to be an object.", t)
+		}
+
+		object := map[string]interface{}{}
+		for name := range fields {
+			if findInput(t.inputFields, name) == nil {
+				return nil, fmt.Errorf("Field %q is not defined by type %q.", name, t)
+			}
+		}
+		for _, input := range t.inputFields {
+			value, ok := fields[input.name]
+			if !ok {
+				if input.defaultValue != nil {
+					object[input.name] = input.defaultValue
+				} else if input.typ.kind == kindNonNull {
+					return nil, fmt.Errorf("Field \"%s.%s\" of required type %q was not provided.", t, input.name, input.typ)
+				}
+				continue
+			}
+
+			coerced, err := coerceInput(input.typ, value)
+			if err != nil {
+				return nil, fmt.Errorf("At field %q: %v", input.name, err)
+			}
+			object[input.name] = coerced
+		}
+		return object, nil
+	default:
+		return t.parse(v)
+	}
+}
+
+func findInput(inputs []*inputValue, name string) *inputValue {
+	for _, input := range inputs {
+		if input.name == name {
+			return input
+		}
+	}
+	return nil
+}
+
+// execute executes the operation, mutations are executed in the order of their fields
+func (e *execution) execute() *Response {
+	var root *graphType
+	switch e.operation.kind {
+	case "query":
+		root = e.schema.query
+	case "mutation":
+		root = e.schema.mutation
+	}
+	if root == nil {
+		return &Response{Errors: []*Error{{Message: fmt.Sprintf("Schema is not configured for %ss.", e.operation.kind), Locations: []Location{e.operation.location}}}}
+	}
+
+	data, _ := e.executeFields(root, nil, e.collectFields(root, e.operation.selections, map[string]bool{}, nil), nil)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+// fieldGroup are the fields of a selection set with the same response key
+type fieldGroup struct {
+	key    string
+	fields []*field
+}
+
+// collectFields returns the fields of a selection set, with those of its fragments
+func (e *execution) collectFields(t *graphType, selections []selection, visited map[string]bool, groups []*fieldGroup) []*fieldGroup {
+	for _, s := range selections {
+		switch s := s.(type) {
+		case *field:
+			if !e.included(s.directives) {
+				continue
+			}
+
+			var group *fieldGroup
+			for _, g := range groups {
+				if g.key == s.responseKey() {
+					group = g
+				}
+			}
+			if group == nil {
+				group = &fieldGroup{key: s.responseKey()}
+				groups = append(groups, group)
+			}
+			group.fields = append(group.fields, s)
+		case *fragmentSpread:
+			if visited[s.name] || !e.included(s.directives) {
+				continue
+			}
+			visited[s.name] = true
+
+			f, ok := e.document.fragments[s.name]
+			if !ok {
+				e.errors = append(e.errors, &Error{Message: fmt.Sprintf("Unknown fragment %q.", s.name), Locations: []Location{s.location}})
+				continue
+			}
+			if f.typeCondition == t.name {
+				groups = e.collectFields(t, f.selections, visited, groups)
+			}
+		case *inlineFragment:
+			if e.included(s.directives) && (s.typeCondition == "" || s.typeCondition == t.name) {
+				groups = e.collectFields(t, s.selections, visited, groups)
+			}
+		}
+	}
+	return groups
+}
+
+// included returns false if the directives skip a selection
+func (e *execution) included(directives []*directive) bool {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			continue
+		}
+
+		for _, arg := range d.arguments {
+			if arg.name == "if" {
+				if value, _ := e.valueOf(arg.value).(bool); value == (d.name == "skip") {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// executeFields returns the object of the fields, it returns false if a non-null field is null
+func (e *execution) executeFields(t *graphType, source interface{}, groups []*fieldGroup, path []interface{}) (*orderedMap, bool) {
+	object := &orderedMap{}
+	for _, group := range groups {
+		value, ok := e.executeField(t, source, group, appendPath(path, group.key))
+		if !ok {
+			return nil, false
+		}
+		object.set(group.key, value)
+	}
+	return object, true
+}
+
+func (e *execution) executeField(t *graphType, source interface{}, group *fieldGroup, path []interface{}) (interface{}, bool) {
+	f := group.fields[0]
+	if f.name == "__typename" {
+		return t.name, true
+	}
+
+	definition := t.field(f.name)
+	if definition == nil && t == e.schema.query {
+		for _, meta := range e.schema.metaFields() {
+			if meta.name == f.name {
+				definition = meta
+			}
+		}
+	}
+	if definition == nil {
+		e.errors = append(e.errors, &Error{Message: fmt.Sprintf("Cannot query field %q on type %q.", f.name, t), Locations: []Location{f.location}})
+		return nil, true
+	}
+
+	args, err := e.argumentValues(definition, f)
+	if err == nil {
+		var value interface{}
+		if value, err = definition.resolve(&resolveParams{source: source, args: args, context: e.context}); err == nil {
+			return e.completeValue(definition.typ, group.fields, value, path)
+		}
+	}
+
+	e.addError(err, f, path)
+	return nil, definition.typ.kind != kindNonNull
+}
+
+func (e *execution) argumentValues(definition *fieldDefinition, f *field) (map[string]interface{}, error) {
+	for _, arg := range f.arguments {
+		if findInput(definition.args, arg.name) == nil {
+			return nil, &Error{Message: fmt.Sprintf("Unknown argument %q on field %q.", arg.name, definition.name), Locations: []Location{arg.location}}
+		}
+	}
+
+	args := map[string]interface{}{}
+	for _, input := range definition.args {
+		var literal *argument
+		for _, arg := range f.arguments {
+			if arg.name == input.name {
+				literal = arg
+			}
+		}
+		if literal != nil {
+			if name, ok := literal.value.(variable); ok {
+				if _, provided := e.variables[string(name)]; !provided {
+					literal = nil
+				}
+			}
+		}
+
+		if literal == nil {
+			if input.defaultValue != nil {
+				args[input.name] = input.defaultValue
+			} else if input.typ.kind == kindNonNull {
+				return nil, &Error{Message: fmt.Sprintf("Argument %q of required type %q was not provided.", input.name, input.typ), Locations: []Location{f.location}}
+			}
+			continue
+		}
+
+		value, err := coerceInput(input.typ, e.valueOf(literal.value))
+		if err != nil {
+			return nil, &Error{Message: fmt.Sprintf("Argument %q has invalid value %s; %v", input.name, printValue(e.valueOf(literal.value)), err), Locations: []Location{literal.location}}
+		}
+		args[input.name] = value
+	}
+	return args, nil
+}
+
+// completeValue returns the value of a field in the response, it returns false if a non-null value is null,
+// which nulls the closest nullable parent
+func (e *execution) completeValue(t *graphType, fields []*field, value interface{}, path []interface{}) (interface{}, bool) {
+	if t.kind != kindNonNull {
+		if completed, ok := e.completeNullable(t, fields, value, path); ok {
+			return completed, true
+		}
+		return nil, true
+	}
+
+	completed, ok := e.completeNullable(t.ofType, fields, value, path)
+	if ok && completed == nil {
+		e.addError(fmt.Errorf("Cannot return null for non-nullable field %s.", fields[0].name), fields[0], path)
+	}
+	return completed, ok && completed != nil
+}
+
+func (e *execution) completeNullable(t *graphType, fields []*field, value interface{}, path []interface{}) (interface{}, bool) {
+	if isNil(value) {
+		return nil, true
+	}
+
+	switch t.kind {
+	case kindList:
+		items := reflect.Indirect(reflect.ValueOf(value))
+		if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+			e.addError(fmt.Errorf("Expected an iterable for field %s.", fields[0].name), fields[0], path)
+			return nil, false
+		}
+
+		list := make([]interface{}, items.Len())
+		for idx := range list {
+			item, ok := e.completeValue(t.ofType, fields, items.Index(idx).Interface(), appendPath(path, idx))
+			if !ok {
+				return nil, false
+			}
+			list[idx] = item
+		}
+		return list, true
+	case kindObject:
+		var selections []selection
+		for _, f := range fields {
+			selections = append(selections, f.selections...)
+		}
+		if len(selections) == 0 {
+			e.addError(fmt.Errorf("Field %q of type %q must have a selection of subfields.", fields[0].name, t), fields[0], path)
+			return nil, false
+		}
+		return e.executeFields(t, value, e.collectFields(t, selections, map[string]bool{}, nil), path)
+	default:
+		if valuer, ok := value.(driver.Valuer); ok {
+			v, err := valuer.Value()
+			if err != nil || v == nil {
+				return nil, err == nil
+			}
+			value = v
+		}
+
+		serialized, err := t.serialize(reflect.Indirect(reflect.ValueOf(value)).Interface())
+		if err != nil {
+			e.addError(err, fields[0], path)
+			return nil, false
+		}
+		return serialized, true
+	}
+}
+
+// addError adds the error of a field, with one error for each error of a multiple error
+func (e *execution) addError(err error, f *field, path []interface{}) {
+	errs := []error{err}
+	if multiple, ok := err.(interface{ GetErrors() []error }); ok && len(multiple.GetErrors()) > 0 {
+		errs = multiple.GetErrors()
+	}
+
+	for _, err := range errs {
+		gqlErr := toError(err)
+		if len(gqlErr.Locations) == 0 {
+			gqlErr.Locations = []Location{f.location}
+		}
+		if gqlErr.Path == nil {
+			gqlErr.Path = path
+		}
+		e.errors = append(e.errors, gqlErr)
+	}
+}
+
+// toError returns the error of a response, with the code of permission and validation errors
+func toError(err error) *Error {
+	var (
+		gqlErr        *Error
+		validationErr *validations.Error
+	)
+	switch {
+	case errors.As(err, &gqlErr):
+		copied := *gqlErr
+		return &copied
+	case errors.As(err, &validationErr):
+		return &Error{Message: validationErr.Message, Extensions: map[string]interface{}{"code": "BAD_USER_INPUT", "field": validationErr.Column}}
+	case errors.Is(err, roles.ErrPermissionDenied):
+		return &Error{Message: err.Error(), Extensions: map[string]interface{}{"code": "FORBIDDEN"}}
+	}
+	return &Error{Message: err.Error()}
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// appendPath returns a copy of the path with the key or index, paths of the errors are not shared
+func appendPath(path []interface{}, key interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(path)+1), path...), key)
+}
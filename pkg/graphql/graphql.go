@@ -0,0 +1,505 @@
+package graphql
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	stdcontext "context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/roles"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/bhojpur/orm/pkg/inflection"
+)
+
+// DefaultPath is the path the endpoint is mounted at by default
+const DefaultPath = "/graphql"
+
+// GraphQL is a GraphQL endpoint generated from registered resources, each resource is an object type with a
+// field for each of its metas, and has queries and mutations:
+//
+//	productVariation(id: ID!): ProductVariation
+//	productVariations(keyword: String, scopes: [String!], filters: [FilterInput!], page: Int = 1, perPage: Int): [ProductVariation!]!
+//	saveProductVariation(id: ID, input: ProductVariationInput!): ProductVariation
+//	deleteProductVariation(id: ID!): Boolean!
+//
+// Fields the roles of a request can't read resolve to null with a `FORBIDDEN` error, and inputs of fields they
+// can't write fail the mutation
+type GraphQL struct {
+	Config *appsvr.Config
+	// Path is the path the endpoint is mounted at, DefaultPath by default
+	Path string
+	// Playground serves GraphiQL to browsers requesting the endpoint, true by default
+	Playground bool
+	// CurrentUser returns the user of a request, roles are matched against the request and user
+	CurrentUser func(req *http.Request) appsvr.CurrentUser
+	// PerPage is the default number of records of a page, 20 by default
+	PerPage int
+	// MaxPerPage caps the number of records of a page, 100 by default
+	MaxPerPage int
+	resources  []*Resource
+	schema     *schema
+	mutex      sync.RWMutex
+}
+
+// New initialize a GraphQL endpoint of the resources registered with AddResource
+func New(config *appsvr.Config) *GraphQL {
+	return &GraphQL{Config: config, Path: DefaultPath, Playground: true, PerPage: 20, MaxPerPage: 100}
+}
+
+// Resource is a resource of the GraphQL endpoint. Its metas are the fields of its object type, they are decoded
+// from mutation inputs by their setters and resolved by their valuers, and their permissions decide which roles
+// can read and write them
+type Resource struct {
+	*resource.Resource
+	// TypeName is the name of the object type of the resource, like `ProductVariation`
+	TypeName string
+	metas    []*resource.Meta
+	graphql  *GraphQL
+}
+
+// AddResource registers a resource, its type name is the camel case of its name. Metas are added for the fields
+// of its model except ignored, `json:"-"` and association fields
+func (g *GraphQL) AddResource(res *resource.Resource) *Resource {
+	gqlRes := &Resource{Resource: res, TypeName: typeName(res.Name)}
+
+	for _, field := range (&orm.Scope{Value: res.Value}).GetStructFields() {
+		if field.IsIgnored || field.Relationship != nil || field.Struct.Tag.Get("json") == "-" {
+			continue
+		}
+		gqlRes.Meta(&resource.Meta{Name: field.Name})
+	}
+	gqlRes.graphql = g
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	for idx, r := range g.resources {
+		if r.TypeName == gqlRes.TypeName {
+			g.resources[idx], g.schema = gqlRes, nil
+			return gqlRes
+		}
+	}
+	g.resources, g.schema = append(g.resources, gqlRes), nil
+	return gqlRes
+}
+
+// GetResource returns a registered resource by its type name
+func (g *GraphQL) GetResource(typeName string) *Resource {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	for _, res := range g.resources {
+		if res.TypeName == typeName {
+			return res
+		}
+	}
+	return nil
+}
+
+// getSchema returns the schema of the registered resources, it is generated again once they change
+func (g *GraphQL) getSchema() *schema {
+	g.mutex.RLock()
+	s := g.schema
+	g.mutex.RUnlock()
+	if s != nil {
+		return s
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.schema == nil {
+		g.schema = g.newSchema()
+	}
+	return g.schema
+}
+
+// Meta adds a meta to the resource, or replaces the one with the same name. Set its Permission to restrict the
+// roles which can read and write the field, or its Computed func to add a read-only field
+//
+//	products.Meta(&resource.Meta{Name: "Cost", Permission: roles.Allow(roles.CRUD, "admin")})
+func (res *Resource) Meta(meta *resource.Meta) *resource.Meta {
+	meta.BaseResource = res
+	if err := meta.PreInitialize(); err != nil {
+		panic(err)
+	}
+	if err := meta.Initialize(); err != nil {
+		panic(err)
+	}
+
+	if res.graphql != nil {
+		res.graphql.mutex.Lock()
+		defer res.graphql.mutex.Unlock()
+		res.graphql.schema = nil
+	}
+
+	for idx, m := range res.metas {
+		if m.Name == meta.Name {
+			res.metas[idx] = meta
+			return meta
+		}
+	}
+	res.metas = append(res.metas, meta)
+	return meta
+}
+
+// GetMeta returns a meta of the resource by name
+func (res *Resource) GetMeta(name string) *resource.Meta {
+	for _, meta := range res.metas {
+		if meta.Name == name {
+			return meta
+		}
+	}
+	return nil
+}
+
+// GetMetas returns the metas of the resource, all of them if names is empty, to match interface `Resourcer`
+func (res *Resource) GetMetas(names []string) []resource.Metaor {
+	var metas []resource.Metaor
+	for _, m := range res.metas {
+		if len(names) == 0 {
+			metas = append(metas, meta{m})
+			continue
+		}
+		for _, name := range names {
+			if m.Name == name {
+				metas = append(metas, meta{m})
+			}
+		}
+	}
+	return metas
+}
+
+// meta is a meta of the GraphQL endpoint to match interface `Metaor`, fields of records are not nested
+type meta struct {
+	*resource.Meta
+}
+
+func (meta) GetMetas() []resource.Metaor {
+	return nil
+}
+
+func (meta) GetResource() resource.Resourcer {
+	return nil
+}
+
+var (
+	filterBoundType = newEnum("FilterBound", "The bound of a date range filter.", "FROM", "TO")
+
+	filterInputType = &graphType{
+		kind:        kindInputObject,
+		name:        "FilterInput",
+		description: "A filter of a resource, like `{name: \"state\", values: [\"paid\", \"shipped\"]}`.",
+		inputFields: []*inputValue{
+			{name: "name", typ: nonNull(stringType)},
+			{name: "bound", description: "The bound of a date range filter.", typ: filterBoundType},
+			{name: "values", typ: nonNull(listOf(nonNull(stringType)))},
+		},
+	}
+)
+
+func (g *GraphQL) newSchema() *schema {
+	query := &graphType{kind: kindObject, name: "Query"}
+	mutation := &graphType{kind: kindObject, name: "Mutation"}
+
+	for _, res := range g.resources {
+		var (
+			object   = res.objectType()
+			input    = res.inputType()
+			singular = fieldName(res.TypeName)
+			plural   = inflection.Plural(singular)
+		)
+		if plural == singular {
+			plural += "List"
+		}
+
+		query.fields = append(query.fields,
+			&fieldDefinition{
+				name:        singular,
+				description: fmt.Sprintf("Returns a %v by its ID.", res.Name),
+				args:        []*inputValue{{name: "id", typ: nonNull(idType)}},
+				typ:         object,
+				resolve:     res.findOne,
+			},
+			&fieldDefinition{
+				name:        plural,
+				description: fmt.Sprintf("Returns a page of %v, matching the keyword, scopes and filters.", inflection.Plural(res.Name)),
+				args: []*inputValue{
+					{name: "keyword", typ: stringType},
+					{name: "scopes", typ: listOf(nonNull(stringType))},
+					{name: "filters", typ: listOf(nonNull(filterInputType))},
+					{name: "page", typ: intType, defaultValue: 1},
+					{name: "perPage", typ: intType, defaultValue: g.PerPage},
+				},
+				typ:     nonNull(listOf(nonNull(object))),
+				resolve: res.findMany,
+			},
+		)
+
+		mutation.fields = append(mutation.fields,
+			&fieldDefinition{
+				name:        "save" + res.TypeName,
+				description: fmt.Sprintf("Creates a %v, or updates the one with the ID.", res.Name),
+				args:        []*inputValue{{name: "id", typ: idType}, {name: "input", typ: nonNull(input)}},
+				typ:         object,
+				resolve:     res.save,
+			},
+			&fieldDefinition{
+				name:        "delete" + res.TypeName,
+				description: fmt.Sprintf("Deletes a %v by its ID.", res.Name),
+				args:        []*inputValue{{name: "id", typ: nonNull(idType)}},
+				typ:         nonNull(booleanType),
+				resolve:     res.delete,
+			},
+		)
+	}
+
+	if len(mutation.fields) == 0 {
+		mutation = nil
+	}
+	return newSchema(query, mutation)
+}
+
+func (res *Resource) objectType() *graphType {
+	object := &graphType{kind: kindObject, name: res.TypeName}
+	for _, meta := range res.metas {
+		meta, typ := meta, res.typeOf(meta)
+		if typ == idType {
+			typ = nonNull(idType)
+		}
+
+		object.fields = append(object.fields, &fieldDefinition{
+			name: fieldName(meta.Name),
+			typ:  typ,
+			resolve: func(p *resolveParams) (interface{}, error) {
+				if !meta.HasPermission(roles.Read, p.context) {
+					return nil, roles.ErrPermissionDenied
+				}
+				if !meta.IsShown(p.source, p.context) {
+					return nil, nil
+				}
+				return meta.GetValuer()(p.source, p.context), nil
+			},
+		})
+	}
+	return object
+}
+
+// inputType returns the input type of the mutations, with the metas which can be set except computed metas
+// and primary fields
+func (res *Resource) inputType() *graphType {
+	input := &graphType{kind: kindInputObject, name: res.TypeName + "Input"}
+	for _, meta := range res.metas {
+		if meta.Setter == nil || meta.Computed != nil || (meta.FieldStruct != nil && meta.FieldStruct.IsPrimaryKey) {
+			continue
+		}
+		input.inputFields = append(input.inputFields, &inputValue{name: fieldName(meta.Name), typ: res.typeOf(meta)})
+	}
+	return input
+}
+
+// typeOf returns the type of a meta by the type of its field, computed metas and fields of other types are JSON
+func (res *Resource) typeOf(meta *resource.Meta) *graphType {
+	if meta.FieldStruct == nil {
+		return jsonType
+	}
+	if meta.FieldStruct.IsPrimaryKey {
+		return idType
+	}
+
+	typ := meta.FieldStruct.Struct.Type
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == reflect.TypeOf(time.Time{}) {
+		return stringType
+	}
+
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return intType
+	case reflect.Float32, reflect.Float64:
+		return floatType
+	case reflect.Bool:
+		return booleanType
+	case reflect.String:
+		return stringType
+	}
+	return jsonType
+}
+
+func (res *Resource) findOne(p *resolveParams) (interface{}, error) {
+	context := p.context.Clone()
+	context.ResourceID = p.args["id"].(string)
+
+	result := res.NewStruct()
+	if err := res.CallFindOne(result, nil, context); err != nil {
+		if orm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (res *Resource) findMany(p *resolveParams) (interface{}, error) {
+	query := url.Values{}
+	if keyword, ok := p.args["keyword"].(string); ok && keyword != "" {
+		query.Set("keyword", keyword)
+	}
+	if scopes, ok := p.args["scopes"].([]interface{}); ok {
+		for _, scope := range scopes {
+			query.Add("scopes", scope.(string))
+		}
+	}
+	if filters, ok := p.args["filters"].([]interface{}); ok {
+		for _, f := range filters {
+			filter := f.(map[string]interface{})
+			key := "filters[" + filter["name"].(string) + "]"
+			if bound, ok := filter["bound"].(string); ok {
+				key += "[" + strings.ToLower(bound) + "]"
+			}
+			for _, value := range filter["values"].([]interface{}) {
+				query.Add(key, value.(string))
+			}
+		}
+	}
+
+	page, _ := p.args["page"].(int)
+	perPage, _ := p.args["perPage"].(int)
+	if page < 1 || perPage < 1 {
+		return nil, fmt.Errorf("invalid page %v of %v records", page, perPage)
+	}
+	if perPage > res.graphql.MaxPerPage {
+		perPage = res.graphql.MaxPerPage
+	}
+
+	// the scopes, filters and keyword are applied from the query of the request, like those of the other APIs, and
+	// the page is read from the read database of the request, like the replica or the database of its tenant
+	context := p.context.Clone()
+	if p.context.Request != nil {
+		context.Request = p.context.Request.Clone(p.context.Request.Context())
+	} else {
+		context.Request = (&http.Request{Method: http.MethodPost, URL: &url.URL{Path: res.graphql.Path}, Header: http.Header{}}).
+			WithContext(stdcontext.Background())
+	}
+	context.Request.URL.RawQuery = query.Encode()
+	context.SetDB(p.context.GetReadDB().Offset((page - 1) * perPage).Limit(perPage))
+
+	results := res.NewSlice()
+	if err := res.CallFindMany(results, context); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (res *Resource) save(p *resolveParams) (interface{}, error) {
+	var (
+		context = p.context.Clone()
+		result  = res.NewStruct()
+		mode    = roles.Create
+	)
+	if id, ok := p.args["id"].(string); ok {
+		context.ResourceID, mode = id, roles.Update
+		if err := res.CallFindOne(result, nil, context); err != nil {
+			return nil, err
+		}
+	}
+
+	values := map[string]interface{}{}
+	for name, value := range p.args["input"].(map[string]interface{}) {
+		for _, meta := range res.metas {
+			if fieldName(meta.Name) != name {
+				continue
+			}
+			if !meta.HasPermission(mode, context) {
+				return nil, fmt.Errorf("%w to write %v", roles.ErrPermissionDenied, name)
+			}
+			values[meta.Name] = value
+		}
+	}
+
+	body, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	metaValues, err := resource.ConvertJSONToMetaValues(bytes.NewReader(body), res.GetMetas(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	err = resource.RunInTransaction(context, func(tx *resource.Txn) error {
+		if err := tx.Decode(res, result, metaValues); err != nil {
+			return err
+		}
+		return tx.Save(res, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (res *Resource) delete(p *resolveParams) (interface{}, error) {
+	context := p.context.Clone()
+	context.ResourceID = p.args["id"].(string)
+	if err := res.CallDelete(res.NewStruct(), context); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// typeName returns the type name of a resource name, `Product Variation` is `ProductVariation`
+func typeName(name string) string {
+	var builder strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		runes := []rune(word)
+		builder.WriteRune(unicode.ToUpper(runes[0]))
+		builder.WriteString(string(runes[1:]))
+	}
+	return builder.String()
+}
+
+// fieldName returns the field name of a meta or type name, `CreatedAt` is `createdAt`, `ID` is `id` and
+// `URLPath` is `urlPath`
+func fieldName(name string) string {
+	runes := []rune(typeName(name))
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if upper > 1 && upper < len(runes) && unicode.IsLower(runes[upper]) {
+		upper--
+	}
+	for idx := 0; idx < upper; idx++ {
+		runes[idx] = unicode.ToLower(runes[idx])
+	}
+	return string(runes)
+}
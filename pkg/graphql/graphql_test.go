@@ -0,0 +1,382 @@
+package graphql_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bhojpur/application/pkg/config"
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/graphql"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+)
+
+type ProductVariation struct {
+	ID        uint
+	Name      string
+	Cost      float64
+	Available bool
+	Secret    string `json:"-"`
+	Tags      []Tag
+}
+
+type Tag struct {
+	ID                 uint
+	ProductVariationID uint
+	Name               string
+}
+
+type admin struct{}
+
+func (*admin) DisplayName() string { return "admin" }
+
+func newGraphQL(t *testing.T) *graphql.GraphQL {
+	roles.Reset()
+	roles.Register("admin", func(req *http.Request, user interface{}) bool {
+		_, ok := user.(*admin)
+		return ok
+	})
+	t.Cleanup(roles.Reset)
+
+	db := utils.SQLiteTestDB(t, `CREATE TABLE product_variations (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, cost REAL, available BOOLEAN, secret TEXT)`)
+	for _, name := range []string{"Blue", "Green", "Red"} {
+		require.NoError(t, db.Create(&ProductVariation{Name: name, Cost: 4.5, Available: name != "Green"}).Error)
+	}
+
+	g := graphql.New(&appsvr.Config{DB: db})
+	g.CurrentUser = func(req *http.Request) appsvr.CurrentUser {
+		if req.Header.Get("X-Admin") != "" {
+			return &admin{}
+		}
+		return nil
+	}
+
+	products := g.AddResource(resource.New(&ProductVariation{}))
+	products.Meta(&resource.Meta{Name: "Cost", Permission: roles.Allow(roles.CRUD, "admin")})
+	products.Meta(&resource.Meta{Name: "Label", Computed: func(record interface{}, context *appsvr.Context) interface{} {
+		return map[string]interface{}{"text": record.(*ProductVariation).Name}
+	}})
+	return g
+}
+
+func post(g *graphql.GraphQL, request graphql.Request, header http.Header) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(request)
+	req := httptest.NewRequest(http.MethodPost, graphql.DefaultPath, strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	return w
+}
+
+func TestQuery(t *testing.T) {
+	g := newGraphQL(t)
+
+	w := post(g, graphql.Request{Query: `{
+		productVariation(id: 1) { __typename id name available label ...costs }
+		missing: productVariation(id: "9") { id }
+	}
+	fragment costs on ProductVariation { cost }`}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"data": {
+			"productVariation": {"__typename": "ProductVariation", "id": "1", "name": "Blue", "available": true, "label": {"text": "Blue"}, "cost": null},
+			"missing": null
+		},
+		"errors": [{"message": "permission denied", "locations": [{"line": 5, "column": 39}], "path": ["productVariation", "cost"], "extensions": {"code": "FORBIDDEN"}}]
+	}`, w.Body.String())
+
+	w = post(g, graphql.Request{
+		Query:         `query Other { __typename } query Product($id: ID!, $withCost: Boolean = false) { productVariation(id: $id) { name cost @include(if: $withCost) } }`,
+		OperationName: "Product",
+		Variables:     map[string]interface{}{"id": 1, "withCost": true},
+	}, http.Header{"X-Admin": {"true"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data": {"productVariation": {"name": "Blue", "cost": 4.5}}}`, w.Body.String())
+}
+
+func TestMutation(t *testing.T) {
+	g := newGraphQL(t)
+
+	w := post(g, graphql.Request{Query: `mutation { saveProductVariation(input: {name: "Black", cost: 3}) { id } }`}, nil)
+	assert.JSONEq(t, `{
+		"data": {"saveProductVariation": null},
+		"errors": [{"message": "permission denied to write cost", "locations": [{"line": 1, "column": 12}], "path": ["saveProductVariation"], "extensions": {"code": "FORBIDDEN"}}]
+	}`, w.Body.String())
+
+	w = post(g, graphql.Request{
+		Query:     `mutation($input: ProductVariationInput!) { saveProductVariation(input: $input) { id name cost available } }`,
+		Variables: map[string]interface{}{"input": map[string]interface{}{"name": "Black", "cost": 3, "available": true}},
+	}, http.Header{"X-Admin": {"true"}})
+	assert.JSONEq(t, `{"data": {"saveProductVariation": {"id": "4", "name": "Black", "cost": 3, "available": true}}}`, w.Body.String())
+
+	w = post(g, graphql.Request{Query: `mutation {
+		saveProductVariation(id: 2, input: {available: true}) { name available }
+		deleteProductVariation(id: 1)
+	}`}, nil)
+	assert.JSONEq(t, `{"data": {"saveProductVariation": {"name": "Green", "available": true}, "deleteProductVariation": true}}`, w.Body.String())
+
+	w = post(g, graphql.Request{Query: `{
+		first: productVariations(perPage: 2) { name }
+		second: productVariations(page: 2, perPage: 2) { name }
+	}`}, nil)
+	var response struct {
+		Data struct{ First, Second []struct{ Name string } }
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data.First, 2)
+	require.Len(t, response.Data.Second, 1)
+	assert.ElementsMatch(t, []string{"Green", "Red", "Black"},
+		[]string{response.Data.First[0].Name, response.Data.First[1].Name, response.Data.Second[0].Name})
+}
+
+func TestResolvers(t *testing.T) {
+	g := newGraphQL(t)
+	products := g.GetResource("ProductVariation")
+	adminContext := func() *appsvr.Context {
+		return &appsvr.Context{Config: g.Config, CurrentUser: &admin{}, Roles: []string{"admin"}}
+	}
+
+	t.Run("contexts out of a request", func(t *testing.T) {
+		result, err := products.Resolve("findMany", map[string]interface{}{"page": 2, "perPage": 2}, &appsvr.Context{Config: g.Config})
+		require.NoError(t, err)
+		assert.Len(t, *result.(*[]*ProductVariation), 1)
+
+		result, err = products.Resolve("findOne", map[string]interface{}{"id": "9"}, &appsvr.Context{Config: g.Config})
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("invalid page", func(t *testing.T) {
+		_, err := products.Resolve("findMany", map[string]interface{}{"page": 0, "perPage": 2}, &appsvr.Context{Config: g.Config})
+		assert.EqualError(t, err, "invalid page 0 of 2 records")
+	})
+
+	t.Run("pages are read from the read database of the request", func(t *testing.T) {
+		replica := utils.SQLiteTestDB(t, `CREATE TABLE product_variations (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, cost REAL, available BOOLEAN, secret TEXT)`)
+		require.NoError(t, replica.Create(&ProductVariation{Name: "Replica"}).Error)
+
+		req := appsvr.WithReadDB(httptest.NewRequest(http.MethodPost, graphql.DefaultPath, nil), replica, nil)
+		result, err := products.Resolve("findMany", map[string]interface{}{"page": 1, "perPage": 10}, &appsvr.Context{Config: g.Config, Request: req})
+		require.NoError(t, err)
+		records := *result.(*[]*ProductVariation)
+		require.Len(t, records, 1)
+		assert.Equal(t, "Replica", records[0].Name)
+
+		// the database of the tenant of the request isn't replicated
+		tenant := appsvr.WithDB(req, g.Config.DB)
+		result, err = products.Resolve("findMany", map[string]interface{}{"page": 1, "perPage": 10}, &appsvr.Context{Config: g.Config, Request: tenant})
+		require.NoError(t, err)
+		assert.Len(t, *result.(*[]*ProductVariation), 3)
+	})
+
+	t.Run("save creates and updates records", func(t *testing.T) {
+		result, err := products.Resolve("save", map[string]interface{}{"input": map[string]interface{}{"name": "Black", "cost": 3}}, adminContext())
+		require.NoError(t, err)
+		created := result.(*ProductVariation)
+		assert.NotZero(t, created.ID)
+		assert.Equal(t, 3.0, created.Cost)
+
+		id := fmt.Sprint(created.ID)
+		result, err = products.Resolve("save", map[string]interface{}{"id": id, "input": map[string]interface{}{"available": true}}, adminContext())
+		require.NoError(t, err)
+		assert.Equal(t, "Black", result.(*ProductVariation).Name)
+
+		var saved ProductVariation
+		require.NoError(t, g.Config.DB.First(&saved, created.ID).Error)
+		assert.Equal(t, ProductVariation{ID: created.ID, Name: "Black", Cost: 3, Available: true}, saved)
+	})
+
+	t.Run("save fails without writing", func(t *testing.T) {
+		_, err := products.Resolve("save", map[string]interface{}{"id": "9", "input": map[string]interface{}{"name": "Missing"}}, adminContext())
+		assert.True(t, orm.IsRecordNotFoundError(err))
+
+		_, err = products.Resolve("save", map[string]interface{}{"id": "1", "input": map[string]interface{}{"name": "Cheap", "cost": 1}}, &appsvr.Context{Config: g.Config})
+		assert.ErrorIs(t, err, roles.ErrPermissionDenied)
+
+		var product ProductVariation
+		require.NoError(t, g.Config.DB.First(&product, 1).Error)
+		assert.Equal(t, "Blue", product.Name)
+		assert.Equal(t, 4.5, product.Cost)
+	})
+}
+
+func TestIntrospection(t *testing.T) {
+	g := newGraphQL(t)
+
+	w := post(g, graphql.Request{Query: `{
+		__schema { queryType { name } mutationType { fields { name args { name defaultValue type { kind name ofType { name } } } } } }
+		input: __type(name: "ProductVariationInput") { kind inputFields { name type { name } } }
+	}`}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data struct {
+			Schema struct {
+				QueryType    struct{ Name string }
+				MutationType struct {
+					Fields []struct {
+						Name string
+						Args []struct {
+							Name string
+							Type struct {
+								Kind   string
+								Name   *string
+								OfType *struct{ Name string }
+							}
+						}
+					}
+				}
+			} `json:"__schema"`
+			Input struct {
+				Kind        string
+				InputFields []struct {
+					Name string
+					Type struct{ Name string }
+				}
+			}
+		}
+		Errors []graphql.Error
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Empty(t, response.Errors)
+
+	assert.Equal(t, "Query", response.Data.Schema.QueryType.Name)
+	var mutations []string
+	for _, field := range response.Data.Schema.MutationType.Fields {
+		mutations = append(mutations, field.Name)
+	}
+	assert.Equal(t, []string{"saveProductVariation", "deleteProductVariation"}, mutations)
+	assert.Equal(t, "NON_NULL", response.Data.Schema.MutationType.Fields[0].Args[1].Type.Kind)
+	assert.Equal(t, "ProductVariationInput", response.Data.Schema.MutationType.Fields[0].Args[1].Type.OfType.Name)
+
+	assert.Equal(t, "INPUT_OBJECT", response.Data.Input.Kind)
+	inputs := map[string]string{}
+	for _, field := range response.Data.Input.InputFields {
+		inputs[field.Name] = field.Type.Name
+	}
+	assert.Equal(t, map[string]string{"name": "String", "cost": "Float", "available": "Boolean"}, inputs)
+}
+
+func TestErrors(t *testing.T) {
+	g := newGraphQL(t)
+
+	w := post(g, graphql.Request{Query: `{ productVariation(id: 1) { name `}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"errors": [{"message": "Syntax Error: Expected Name, found <EOF>", "locations": [{"line": 1, "column": 34}]}]}`, w.Body.String())
+
+	w = post(g, graphql.Request{Query: `query($id: ID!) { productVariation(id: $id) { name } }`}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `Variable \"$id\" of required type \"ID!\" was not provided.`)
+
+	w = post(g, graphql.Request{Query: `{ productVariation(id: 1) { name secret } }`}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"data": {"productVariation": {"name": "Blue", "secret": null}},
+		"errors": [{"message": "Cannot query field \"secret\" on type \"ProductVariation\".", "locations": [{"line": 1, "column": 34}]}]
+	}`, w.Body.String())
+
+	req := httptest.NewRequest(http.MethodGet, graphql.DefaultPath+"?query="+url.QueryEscape(`mutation { deleteProductVariation(id: 1) }`), nil)
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "POST", w.Header().Get("Allow"))
+
+	req = httptest.NewRequest(http.MethodPut, graphql.DefaultPath, nil)
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestPlayground(t *testing.T) {
+	g := newGraphQL(t)
+
+	req := httptest.NewRequest(http.MethodGet, graphql.DefaultPath, nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `GraphiQL.createFetcher({ url: "/graphql" })`)
+
+	g.Playground = false
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, graphql.DefaultPath+"?query="+url.QueryEscape(`{ productVariation(id: 1) { name } }`), nil)
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	assert.JSONEq(t, `{"data": {"productVariation": {"name": "Blue"}}}`, w.Body.String())
+}
+
+type exporter struct {
+	spans []*trace.SpanData
+}
+
+func (e *exporter) ExportSpan(span *trace.SpanData) {
+	e.spans = append(e.spans, span)
+}
+
+func TestTracing(t *testing.T) {
+	e := &exporter{}
+	trace.RegisterExporter(e)
+	t.Cleanup(func() { trace.UnregisterExporter(e) })
+
+	g := newGraphQL(t)
+	g.Config.Tracing = &config.TracingSpec{SamplingRate: "1"}
+	engine := appsvr.New(g.Config)
+	engine.Mount(graphql.DefaultPath, g)
+
+	body, _ := json.Marshal(graphql.Request{Query: `{ productVariation(id: 9) { name } }`})
+	req := httptest.NewRequest(http.MethodPost, graphql.DefaultPath, strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, e.spans, 2)
+	operation, request := e.spans[0], e.spans[1]
+	assert.Equal(t, "resource/Product Variation/find_one", operation.Name)
+	assert.Equal(t, request.SpanID, operation.ParentSpanID)
+	assert.Equal(t, request.TraceID, operation.TraceID)
+	assert.Equal(t, "9", operation.Attributes["app.resource.record_id"])
+	assert.Equal(t, int32(trace.StatusCodeNotFound), operation.Code)
+	assert.Equal(t, "POST /graphql", request.Name)
+
+	// operations out of a request start their own trace
+	context := &appsvr.Context{Config: g.Config, ResourceID: "1"}
+	require.NoError(t, g.GetResource("ProductVariation").CallFindOne(&ProductVariation{}, nil, context))
+	require.Len(t, e.spans, 3)
+	assert.Equal(t, trace.SpanID{}, e.spans[2].ParentSpanID)
+}
@@ -0,0 +1,150 @@
+package graphql
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.graphql")
+
+// ServeHTTP serves GraphQL requests, POST requests have a JSON body, `{"query": "...", "variables": {...}}`, or
+// an `application/graphql` query, and GET requests have the query, operationName and variables parameters. Only
+// queries are executed for GET requests, and browsers get the playground if it is enabled
+func (g *GraphQL) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != g.Path {
+		writeJSON(w, http.StatusNotFound, &Response{Errors: []*Error{{Message: "not found"}}})
+		return
+	}
+
+	var request Request
+	switch req.Method {
+	case http.MethodGet:
+		query := req.URL.Query()
+		if g.Playground && query.Get("query") == "" && strings.Contains(req.Header.Get("Accept"), "text/html") {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if err := playground.Execute(w, g.Path); err != nil {
+				log.Errorf("failed to render GraphQL playground: %v", err)
+			}
+			return
+		}
+
+		request.Query, request.OperationName = query.Get("query"), query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				writeJSON(w, http.StatusBadRequest, &Response{Errors: []*Error{{Message: fmt.Sprintf("Variables are invalid JSON: %v", err)}}})
+				return
+			}
+		}
+	case http.MethodPost:
+		mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		switch mediaType {
+		case "application/graphql":
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, &Response{Errors: []*Error{{Message: err.Error()}}})
+				return
+			}
+			request.Query = string(body)
+		case "application/json", "":
+			if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+				writeJSON(w, http.StatusBadRequest, &Response{Errors: []*Error{{Message: fmt.Sprintf("Body is invalid JSON: %v", err)}}})
+				return
+			}
+		default:
+			writeJSON(w, http.StatusUnsupportedMediaType, &Response{Errors: []*Error{{Message: fmt.Sprintf("Unsupported content type %q.", mediaType)}}})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeJSON(w, http.StatusMethodNotAllowed, &Response{Errors: []*Error{{Message: http.StatusText(http.StatusMethodNotAllowed)}}})
+		return
+	}
+
+	if strings.TrimSpace(request.Query) == "" {
+		writeJSON(w, http.StatusBadRequest, &Response{Errors: []*Error{{Message: "Must provide query string."}}})
+		return
+	}
+
+	e, response := g.getSchema().prepare(&request, g.newContext(w, req))
+	if response != nil {
+		writeJSON(w, http.StatusBadRequest, response)
+		return
+	}
+	if req.Method == http.MethodGet && e.operation.kind != "query" {
+		w.Header().Set("Allow", "POST")
+		writeJSON(w, http.StatusMethodNotAllowed, &Response{Errors: []*Error{{Message: fmt.Sprintf("Can only perform a %v operation from a POST request.", e.operation.kind)}}})
+		return
+	}
+	writeJSON(w, http.StatusOK, e.execute())
+}
+
+// newContext returns the context of a request, with its user and the roles matching them
+func (g *GraphQL) newContext(w http.ResponseWriter, req *http.Request) *appsvr.Context {
+	context := &appsvr.Context{Request: req, Writer: w, Config: g.Config}
+
+	var user interface{}
+	if g.CurrentUser != nil {
+		if currentUser := g.CurrentUser(req); currentUser != nil {
+			context.CurrentUser, user = currentUser, currentUser
+		}
+	}
+	context.Roles = roles.MatchedRoles(req, user)
+	return context
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// playground is GraphiQL, its data is the path of the endpoint
+var playground = template.Must(template.New("playground").Parse(`<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8" />
+    <title>GraphQL Playground</title>
+    <link rel="stylesheet" href="https://unpkg.com/graphiql@2/graphiql.min.css" />
+  </head>
+  <body style="margin: 0">
+    <div id="graphiql" style="height: 100vh"></div>
+    <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+    <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+    <script crossorigin src="https://unpkg.com/graphiql@2/graphiql.min.js"></script>
+    <script>
+      ReactDOM.createRoot(document.getElementById("graphiql")).render(
+        React.createElement(GraphiQL, { fetcher: GraphiQL.createFetcher({ url: {{.}} }) })
+      );
+    </script>
+  </body>
+</html>
+`))
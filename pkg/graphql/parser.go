@@ -0,0 +1,887 @@
+package graphql
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is a parsed GraphQL request document
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string
+	name       string
+	variables  []*variableDefinition
+	directives []*directive
+	selections []selection
+	location   Location
+}
+
+type variableDefinition struct {
+	name         string
+	typ          *typeRef
+	defaultValue value
+	hasDefault   bool
+	location     Location
+}
+
+// typeRef is the type of a variable definition, like `[ID!]!`
+type typeRef struct {
+	name    string
+	elem    *typeRef
+	nonNull bool
+}
+
+func (ref *typeRef) String() string {
+	var name = ref.name
+	if ref.elem != nil {
+		name = "[" + ref.elem.String() + "]"
+	}
+	if ref.nonNull {
+		name += "!"
+	}
+	return name
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	directives    []*directive
+	selections    []selection
+	location      Location
+}
+
+// selection is a *field, *fragmentSpread or *inlineFragment
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []*argument
+	directives []*directive
+	selections []selection
+	location   Location
+}
+
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	location   Location
+}
+
+type inlineFragment struct {
+	typeCondition string
+	directives    []*directive
+	selections    []selection
+	location      Location
+}
+
+type argument struct {
+	name     string
+	value    value
+	location Location
+}
+
+type directive struct {
+	name      string
+	arguments []*argument
+	location  Location
+}
+
+// value is a literal of a document: nil, bool, int64, float64, string, enumValue, variable, []value or objectValue
+type value interface{}
+
+type variable string
+
+type enumValue string
+
+type objectValue []*objectField
+
+type objectField struct {
+	name  string
+	value value
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind     tokenKind
+	value    string
+	location Location
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "<EOF>"
+	case tokenString:
+		return strconv.Quote(t.value)
+	default:
+		return t.value
+	}
+}
+
+type lexer struct {
+	source    string
+	pos       int
+	line      int
+	lineStart int
+	lookahead token
+	err       error
+	peeked    bool
+}
+
+func (l *lexer) location() Location {
+	return Location{Line: l.line, Column: utf8.RuneCountInString(l.source[l.lineStart:l.pos]) + 1}
+}
+
+func (l *lexer) errorf(loc Location, format string, args ...interface{}) error {
+	return &Error{Message: "Syntax Error: " + fmt.Sprintf(format, args...), Locations: []Location{loc}}
+}
+
+func (l *lexer) newline() {
+	l.line++
+	l.lineStart = l.pos
+}
+
+// skipIgnored skips white space, line terminators, commas, comments and byte order marks
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.source) {
+		switch c := l.source[l.pos]; c {
+		case ' ', '\t', ',':
+			l.pos++
+		case '\n':
+			l.pos++
+			l.newline()
+		case '\r':
+			l.pos++
+			if l.pos < len(l.source) && l.source[l.pos] == '\n' {
+				l.pos++
+			}
+			l.newline()
+		case '#':
+			for l.pos < len(l.source) && l.source[l.pos] != '\n' && l.source[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			if !strings.HasPrefix(l.source[l.pos:], "\ufeff") {
+				return
+			}
+			l.pos += len("\ufeff")
+		}
+	}
+}
+
+func (l *lexer) peek() (token, error) {
+	if !l.peeked {
+		l.lookahead, l.err = l.lex()
+		l.peeked = true
+	}
+	return l.lookahead, l.err
+}
+
+func (l *lexer) next() (token, error) {
+	t, err := l.peek()
+	l.peeked = false
+	return t, err
+}
+
+func (l *lexer) lex() (token, error) {
+	l.skipIgnored()
+	loc := l.location()
+	if l.pos >= len(l.source) {
+		return token{kind: tokenEOF, location: loc}, nil
+	}
+
+	switch c := l.source[l.pos]; {
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		l.pos++
+		return token{kind: tokenPunctuator, value: string(c), location: loc}, nil
+	case c == '.':
+		if !strings.HasPrefix(l.source[l.pos:], "...") {
+			return token{}, l.errorf(loc, "Unexpected \".\"")
+		}
+		l.pos += 3
+		return token{kind: tokenPunctuator, value: "...", location: loc}, nil
+	case c == '_' || isLetter(c):
+		start := l.pos
+		for l.pos < len(l.source) && (l.source[l.pos] == '_' || isLetter(l.source[l.pos]) || isDigit(l.source[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.source[start:l.pos], location: loc}, nil
+	case c == '-' || isDigit(c):
+		return l.lexNumber(loc)
+	case c == '"':
+		if strings.HasPrefix(l.source[l.pos:], `"""`) {
+			return l.lexBlockString(loc)
+		}
+		return l.lexString(loc)
+	}
+	return token{}, l.errorf(loc, "Unexpected character %s", l.describeChar())
+}
+
+func (l *lexer) lexNumber(loc Location) (token, error) {
+	start, kind := l.pos, tokenInt
+	if l.source[l.pos] == '-' {
+		l.pos++
+	}
+
+	digits := func() error {
+		if l.pos >= len(l.source) || !isDigit(l.source[l.pos]) {
+			return l.errorf(l.location(), "Invalid number, expected digit but got %s", l.describeChar())
+		}
+		for l.pos < len(l.source) && isDigit(l.source[l.pos]) {
+			l.pos++
+		}
+		return nil
+	}
+
+	if l.pos < len(l.source) && l.source[l.pos] == '0' {
+		l.pos++
+		if l.pos < len(l.source) && isDigit(l.source[l.pos]) {
+			return token{}, l.errorf(l.location(), "Invalid number, unexpected digit after 0")
+		}
+	} else if err := digits(); err != nil {
+		return token{}, err
+	}
+
+	if l.pos < len(l.source) && l.source[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		if err := digits(); err != nil {
+			return token{}, err
+		}
+	}
+
+	if l.pos < len(l.source) && (l.source[l.pos] == 'e' || l.source[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.source) && (l.source[l.pos] == '+' || l.source[l.pos] == '-') {
+			l.pos++
+		}
+		if err := digits(); err != nil {
+			return token{}, err
+		}
+	}
+
+	if l.pos < len(l.source) && (l.source[l.pos] == '_' || l.source[l.pos] == '.' || isLetter(l.source[l.pos])) {
+		return token{}, l.errorf(l.location(), "Invalid number, expected digit but got %s", l.describeChar())
+	}
+	return token{kind: kind, value: l.source[start:l.pos], location: loc}, nil
+}
+
+func (l *lexer) lexString(loc Location) (token, error) {
+	var builder strings.Builder
+	l.pos++
+	for l.pos < len(l.source) {
+		c := l.source[l.pos]
+		switch c {
+		case '"':
+			l.pos++
+			return token{kind: tokenString, value: builder.String(), location: loc}, nil
+		case '\n', '\r':
+			return token{}, l.errorf(l.location(), "Unterminated string")
+		case '\\':
+			if l.pos+1 >= len(l.source) {
+				return token{}, l.errorf(l.location(), "Unterminated string")
+			}
+
+			switch e := l.source[l.pos+1]; e {
+			case '"', '\\', '/':
+				builder.WriteByte(e)
+			case 'b':
+				builder.WriteByte('\b')
+			case 'f':
+				builder.WriteByte('\f')
+			case 'n':
+				builder.WriteByte('\n')
+			case 'r':
+				builder.WriteByte('\r')
+			case 't':
+				builder.WriteByte('\t')
+			case 'u':
+				if l.pos+6 > len(l.source) {
+					return token{}, l.errorf(l.location(), "Invalid Unicode escape sequence")
+				}
+				code, err := strconv.ParseUint(l.source[l.pos+2:l.pos+6], 16, 32)
+				if err != nil {
+					return token{}, l.errorf(l.location(), "Invalid Unicode escape sequence %q", l.source[l.pos:l.pos+6])
+				}
+				builder.WriteRune(rune(code))
+				l.pos += 4
+			default:
+				return token{}, l.errorf(l.location(), "Invalid character escape sequence \\%c", e)
+			}
+			l.pos += 2
+		default:
+			builder.WriteByte(c)
+			l.pos++
+		}
+	}
+	return token{}, l.errorf(l.location(), "Unterminated string")
+}
+
+func (l *lexer) lexBlockString(loc Location) (token, error) {
+	var builder strings.Builder
+	l.pos += 3
+	for l.pos < len(l.source) {
+		switch {
+		case strings.HasPrefix(l.source[l.pos:], `"""`):
+			l.pos += 3
+			return token{kind: tokenString, value: blockStringValue(builder.String()), location: loc}, nil
+		case strings.HasPrefix(l.source[l.pos:], `\"""`):
+			builder.WriteString(`"""`)
+			l.pos += 4
+		default:
+			c := l.source[l.pos]
+			builder.WriteByte(c)
+			l.pos++
+			if c == '\n' || (c == '\r' && !strings.HasPrefix(l.source[l.pos:], "\n")) {
+				l.newline()
+			}
+		}
+	}
+	return token{}, l.errorf(l.location(), "Unterminated string")
+}
+
+// blockStringValue removes the common indentation, and the leading and trailing blank lines of a block string
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(raw), "\n")
+
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for idx := 1; idx < len(lines); idx++ {
+			if len(lines[idx]) >= indent {
+				lines[idx] = lines[idx][indent:]
+			} else {
+				lines[idx] = strings.TrimLeft(lines[idx], " \t")
+			}
+		}
+	}
+
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (l *lexer) describeChar() string {
+	if l.pos >= len(l.source) {
+		return "<EOF>"
+	}
+	r, _ := utf8.DecodeRuneInString(l.source[l.pos:])
+	return strconv.QuoteRune(r)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parse parses a request document, a syntax error is an *Error with its location
+func parse(source string) (*document, error) {
+	p := &parser{lexer: &lexer{source: source, line: 1}}
+	return p.parseDocument()
+}
+
+type parser struct {
+	*lexer
+}
+
+// expect consumes the next token, which should be the punctuator or keyword, or any name if value is blank
+func (p *parser) expect(kind tokenKind, value string) (token, error) {
+	t, err := p.next()
+	if err != nil {
+		return t, err
+	}
+
+	if t.kind != kind || (value != "" && t.value != value) {
+		expected := value
+		if expected == "" {
+			expected = "Name"
+		}
+		return t, p.errorf(t.location, "Expected %s, found %s", expected, t)
+	}
+	return t, nil
+}
+
+// skip consumes the next token if it is the punctuator or keyword
+func (p *parser) skip(kind tokenKind, value string) (bool, error) {
+	t, err := p.peek()
+	if err != nil || t.kind != kind || t.value != value {
+		return false, err
+	}
+	p.next()
+	return true, nil
+}
+
+func (p *parser) unexpected(t token) error {
+	return p.errorf(t.location, "Unexpected %s", t)
+}
+
+func (p *parser) parseDocument() (*document, error) {
+	doc := &document{fragments: map[string]*fragment{}}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case t.kind == tokenEOF:
+			if len(doc.operations) == 0 {
+				return nil, p.errorf(t.location, "Unexpected <EOF>")
+			}
+			return doc, nil
+		case t.kind == tokenPunctuator && t.value == "{":
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: selections, location: t.location})
+		case t.kind == tokenName && (t.value == "query" || t.value == "mutation" || t.value == "subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case t.kind == tokenName && t.value == "fragment":
+			f, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[f.name]; ok {
+				return nil, &Error{Message: fmt.Sprintf("There can be only one fragment named %q.", f.name), Locations: []Location{f.location}}
+			}
+			doc.fragments[f.name] = f
+		default:
+			return nil, p.unexpected(t)
+		}
+	}
+}
+
+func (p *parser) parseOperation() (*operation, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	op := &operation{kind: t.value, location: t.location}
+
+	if name, err := p.peek(); err != nil {
+		return nil, err
+	} else if name.kind == tokenName {
+		p.next()
+		op.name = name.value
+	}
+
+	if ok, err := p.skip(tokenPunctuator, "("); err != nil {
+		return nil, err
+	} else if ok {
+		for {
+			if ok, err := p.skip(tokenPunctuator, ")"); err != nil {
+				return nil, err
+			} else if ok {
+				break
+			}
+
+			definition, err := p.parseVariableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = append(op.variables, definition)
+		}
+	}
+
+	if op.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if op.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinition() (*variableDefinition, error) {
+	t, err := p.expect(tokenPunctuator, "$")
+	if err != nil {
+		return nil, err
+	}
+	name, err := p.expect(tokenName, "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokenPunctuator, ":"); err != nil {
+		return nil, err
+	}
+
+	definition := &variableDefinition{name: name.value, location: t.location}
+	if definition.typ, err = p.parseTypeRef(); err != nil {
+		return nil, err
+	}
+
+	if ok, err := p.skip(tokenPunctuator, "="); err != nil {
+		return nil, err
+	} else if ok {
+		if definition.defaultValue, err = p.parseValue(true); err != nil {
+			return nil, err
+		}
+		definition.hasDefault = true
+	}
+
+	// directives of variable definitions are allowed, but none is supported
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+func (p *parser) parseTypeRef() (*typeRef, error) {
+	ref := &typeRef{}
+	if ok, err := p.skip(tokenPunctuator, "["); err != nil {
+		return nil, err
+	} else if ok {
+		if ref.elem, err = p.parseTypeRef(); err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenPunctuator, "]"); err != nil {
+			return nil, err
+		}
+	} else {
+		name, err := p.expect(tokenName, "")
+		if err != nil {
+			return nil, err
+		}
+		ref.name = name.value
+	}
+
+	nonNull, err := p.skip(tokenPunctuator, "!")
+	ref.nonNull = nonNull
+	return ref, err
+}
+
+func (p *parser) parseFragment() (*fragment, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := p.expect(tokenName, "")
+	if err != nil {
+		return nil, err
+	}
+	if name.value == "on" {
+		return nil, p.unexpected(name)
+	}
+	if _, err := p.expect(tokenName, "on"); err != nil {
+		return nil, err
+	}
+	typeCondition, err := p.expect(tokenName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	f := &fragment{name: name.value, typeCondition: typeCondition.value, location: t.location}
+	if f.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if f.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (p *parser) parseSelectionSet() ([]selection, error) {
+	if _, err := p.expect(tokenPunctuator, "{"); err != nil {
+		return nil, err
+	}
+
+	var selections []selection
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.kind == tokenPunctuator && t.value == "}" {
+			if len(selections) == 0 {
+				return nil, p.errorf(t.location, "Expected Name, found }")
+			}
+			p.next()
+			return selections, nil
+		}
+
+		s, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+}
+
+func (p *parser) parseSelection() (selection, error) {
+	if t, err := p.peek(); err != nil {
+		return nil, err
+	} else if t.kind == tokenPunctuator && t.value == "..." {
+		return p.parseFragmentSelection()
+	}
+
+	name, err := p.expect(tokenName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	f := &field{name: name.value, location: name.location}
+	if ok, err := p.skip(tokenPunctuator, ":"); err != nil {
+		return nil, err
+	} else if ok {
+		if name, err = p.expect(tokenName, ""); err != nil {
+			return nil, err
+		}
+		f.alias, f.name = f.name, name.value
+	}
+
+	if f.arguments, err = p.parseArguments(); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+
+	if t, err := p.peek(); err != nil {
+		return nil, err
+	} else if t.kind == tokenPunctuator && t.value == "{" {
+		if f.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// parseFragmentSelection parses a fragment spread, `...Name`, or an inline fragment, `... on Type { }`
+func (p *parser) parseFragmentSelection() (selection, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if name.kind == tokenName && name.value != "on" {
+		p.next()
+		spread := &fragmentSpread{name: name.value, location: t.location}
+		spread.directives, err = p.parseDirectives()
+		return spread, err
+	}
+
+	inline := &inlineFragment{location: t.location}
+	if ok, err := p.skip(tokenName, "on"); err != nil {
+		return nil, err
+	} else if ok {
+		typeCondition, err := p.expect(tokenName, "")
+		if err != nil {
+			return nil, err
+		}
+		inline.typeCondition = typeCondition.value
+	}
+
+	if inline.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if inline.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return inline, nil
+}
+
+func (p *parser) parseArguments() ([]*argument, error) {
+	if ok, err := p.skip(tokenPunctuator, "("); err != nil || !ok {
+		return nil, err
+	}
+
+	var arguments []*argument
+	for {
+		if ok, err := p.skip(tokenPunctuator, ")"); err != nil {
+			return nil, err
+		} else if ok {
+			return arguments, nil
+		}
+
+		name, err := p.expect(tokenName, "")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenPunctuator, ":"); err != nil {
+			return nil, err
+		}
+
+		v, err := p.parseValue(false)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, &argument{name: name.value, value: v, location: name.location})
+	}
+}
+
+func (p *parser) parseDirectives() ([]*directive, error) {
+	var directives []*directive
+	for {
+		t, err := p.peek()
+		if err != nil || t.kind != tokenPunctuator || t.value != "@" {
+			return directives, err
+		}
+		p.next()
+
+		name, err := p.expect(tokenName, "")
+		if err != nil {
+			return nil, err
+		}
+
+		d := &directive{name: name.value, location: t.location}
+		if d.arguments, err = p.parseArguments(); err != nil {
+			return nil, err
+		}
+		directives = append(directives, d)
+	}
+}
+
+// parseValue parses a value, variables are not allowed in constant values like default values
+func (p *parser) parseValue(constant bool) (value, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.kind {
+	case tokenInt:
+		i, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			return nil, p.errorf(t.location, "Invalid number %s", t.value)
+		}
+		return i, nil
+	case tokenFloat:
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, p.errorf(t.location, "Invalid number %s", t.value)
+		}
+		return f, nil
+	case tokenString:
+		return t.value, nil
+	case tokenName:
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return enumValue(t.value), nil
+	case tokenPunctuator:
+		switch t.value {
+		case "$":
+			if constant {
+				return nil, p.unexpected(t)
+			}
+			name, err := p.expect(tokenName, "")
+			if err != nil {
+				return nil, err
+			}
+			return variable(name.value), nil
+		case "[":
+			list := []value{}
+			for {
+				if ok, err := p.skip(tokenPunctuator, "]"); err != nil {
+					return nil, err
+				} else if ok {
+					return list, nil
+				}
+
+				item, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			}
+		case "{":
+			object := objectValue{}
+			for {
+				if ok, err := p.skip(tokenPunctuator, "}"); err != nil {
+					return nil, err
+				} else if ok {
+					return object, nil
+				}
+
+				name, err := p.expect(tokenName, "")
+				if err != nil {
+					return nil, err
+				}
+				if _, err := p.expect(tokenPunctuator, ":"); err != nil {
+					return nil, err
+				}
+				item, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				object = append(object, &objectField{name: name.value, value: item})
+			}
+		}
+	}
+	return nil, p.unexpected(t)
+}
@@ -0,0 +1,588 @@
+package graphql
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+)
+
+type typeKind string
+
+const (
+	kindScalar      typeKind = "SCALAR"
+	kindObject      typeKind = "OBJECT"
+	kindInputObject typeKind = "INPUT_OBJECT"
+	kindEnum        typeKind = "ENUM"
+	kindList        typeKind = "LIST"
+	kindNonNull     typeKind = "NON_NULL"
+)
+
+// graphType is a type of a schema, named types are scalars, enums, objects and input objects, lists and
+// non-null types wrap their type
+type graphType struct {
+	kind        typeKind
+	name        string
+	description string
+	fields      []*fieldDefinition
+	inputFields []*inputValue
+	enumValues  []string
+	ofType      *graphType
+	// serialize converts a resolved value of a scalar or enum to its value of a response
+	serialize func(interface{}) (interface{}, error)
+	// parse coerces a value of a scalar or enum from a literal or a variable
+	parse func(interface{}) (interface{}, error)
+}
+
+// fieldDefinition is a field of an object type, resolve returns its value from the object
+type fieldDefinition struct {
+	name        string
+	description string
+	args        []*inputValue
+	typ         *graphType
+	resolve     func(*resolveParams) (interface{}, error)
+}
+
+// inputValue is an argument of a field or a field of an input object type
+type inputValue struct {
+	name         string
+	description  string
+	typ          *graphType
+	defaultValue interface{}
+}
+
+type resolveParams struct {
+	source  interface{}
+	args    map[string]interface{}
+	context *appsvr.Context
+}
+
+func nonNull(t *graphType) *graphType {
+	return &graphType{kind: kindNonNull, ofType: t}
+}
+
+func listOf(t *graphType) *graphType {
+	return &graphType{kind: kindList, ofType: t}
+}
+
+// String returns the type as written in documents, like `[Product!]!`
+func (t *graphType) String() string {
+	switch t.kind {
+	case kindNonNull:
+		return t.ofType.String() + "!"
+	case kindList:
+		return "[" + t.ofType.String() + "]"
+	}
+	return t.name
+}
+
+// namedType returns the named type of lists and non-null types
+func (t *graphType) namedType() *graphType {
+	for t.ofType != nil {
+		t = t.ofType
+	}
+	return t
+}
+
+func (t *graphType) field(name string) *fieldDefinition {
+	for _, f := range t.fields {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func newEnum(name, description string, values ...string) *graphType {
+	t := &graphType{kind: kindEnum, name: name, description: description, enumValues: values}
+	isValue := func(v string) bool {
+		for _, value := range values {
+			if value == v {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.serialize = func(v interface{}) (interface{}, error) {
+		if s := fmt.Sprint(v); isValue(s) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("Enum %q cannot represent value: %v", name, v)
+	}
+	t.parse = func(v interface{}) (interface{}, error) {
+		switch value := v.(type) {
+		case enumValue:
+			if isValue(string(value)) {
+				return string(value), nil
+			}
+		case string:
+			if isValue(value) {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("Value %v does not exist in %q enum.", printValue(v), name)
+	}
+	return t
+}
+
+// Built-in scalars, and JSON for values without a GraphQL type like computed metas
+var (
+	intType = &graphType{kind: kindScalar, name: "Int", description: "The `Int` scalar type represents non-fractional signed whole numeric values between -(2^31) and 2^31 - 1.",
+		serialize: func(v interface{}) (interface{}, error) {
+			if i, ok := toInt(v); ok {
+				return i, nil
+			}
+			return nil, fmt.Errorf("Int cannot represent non-integer or non 32-bit signed integer value: %v", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			if _, isEnum := v.(enumValue); !isEnum {
+				if i, ok := toInt(v); ok {
+					return i, nil
+				}
+			}
+			return nil, fmt.Errorf("Int cannot represent non-integer or non 32-bit signed integer value: %v", printValue(v))
+		},
+	}
+
+	floatType = &graphType{kind: kindScalar, name: "Float", description: "The `Float` scalar type represents signed double-precision fractional values as specified by IEEE 754.",
+		serialize: func(v interface{}) (interface{}, error) {
+			if f, ok := toFloat(v); ok {
+				return f, nil
+			}
+			return nil, fmt.Errorf("Float cannot represent non numeric value: %v", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			if _, isEnum := v.(enumValue); !isEnum {
+				if f, ok := toFloat(v); ok {
+					return f, nil
+				}
+			}
+			return nil, fmt.Errorf("Float cannot represent non numeric value: %v", printValue(v))
+		},
+	}
+
+	stringType = &graphType{kind: kindScalar, name: "String", description: "The `String` scalar type represents textual data, represented as UTF-8 character sequences.",
+		serialize: func(v interface{}) (interface{}, error) {
+			switch value := v.(type) {
+			case string:
+				return value, nil
+			case []byte:
+				return string(value), nil
+			case time.Time:
+				return value.Format(time.RFC3339Nano), nil
+			case fmt.Stringer:
+				return value.String(), nil
+			}
+
+			switch reflect.ValueOf(v).Kind() {
+			case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+				return fmt.Sprint(v), nil
+			}
+			return nil, fmt.Errorf("String cannot represent value: %v", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("String cannot represent a non string value: %v", printValue(v))
+		},
+	}
+
+	booleanType = &graphType{kind: kindScalar, name: "Boolean", description: "The `Boolean` scalar type represents `true` or `false`.",
+		serialize: func(v interface{}) (interface{}, error) {
+			if value := reflect.ValueOf(v); value.Kind() == reflect.Bool {
+				return value.Bool(), nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent a non boolean value: %v", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent a non boolean value: %v", printValue(v))
+		},
+	}
+
+	idType = &graphType{kind: kindScalar, name: "ID", description: "The `ID` scalar type represents a unique identifier, it is serialized as a String.",
+		serialize: func(v interface{}) (interface{}, error) {
+			if i, ok := toInt64(v); ok {
+				return strconv.FormatInt(i, 10), nil
+			}
+			if value := reflect.ValueOf(v); value.Kind() == reflect.String {
+				return value.String(), nil
+			}
+			if s, ok := v.(fmt.Stringer); ok {
+				return s.String(), nil
+			}
+			return nil, fmt.Errorf("ID cannot represent value: %v", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			if _, isEnum := v.(enumValue); !isEnum {
+				if i, ok := toInt64(v); ok {
+					return strconv.FormatInt(i, 10), nil
+				}
+			}
+			return nil, fmt.Errorf("ID cannot represent value: %v", printValue(v))
+		},
+	}
+
+	jsonType = &graphType{kind: kindScalar, name: "JSON", description: "The `JSON` scalar type represents any JSON value.",
+		serialize: func(v interface{}) (interface{}, error) {
+			return v, nil
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			if e, ok := v.(enumValue); ok {
+				return string(e), nil
+			}
+			return v, nil
+		},
+	}
+)
+
+func toInt64(v interface{}) (int64, bool) {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value.Uint() <= math.MaxInt64 {
+			return int64(value.Uint()), true
+		}
+	case reflect.Float32, reflect.Float64:
+		if f := value.Float(); f == math.Trunc(f) && math.Abs(f) <= 1<<53 {
+			return int64(f), true
+		}
+	}
+	return 0, false
+}
+
+func toInt(v interface{}) (int, bool) {
+	if i, ok := toInt64(v); ok && i >= math.MinInt32 && i <= math.MaxInt32 {
+		return int(i), true
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+	if i, ok := toInt64(v); ok {
+		return float64(i), true
+	}
+	return 0, false
+}
+
+// printValue prints a value as a GraphQL literal, like default values of the introspection
+func printValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(value)
+	case enumValue:
+		return string(value)
+	case variable:
+		return "$" + string(value)
+	case []interface{}:
+		items := make([]string, len(value))
+		for idx, item := range value {
+			items[idx] = printValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fields := make([]string, len(keys))
+		for idx, key := range keys {
+			fields[idx] = key + ": " + printValue(value[key])
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	}
+	return fmt.Sprint(v)
+}
+
+// directiveDefinition is a directive of a schema, only the executable @skip and @include are supported
+type directiveDefinition struct {
+	name        string
+	description string
+	locations   []string
+	args        []*inputValue
+}
+
+var directives = []*directiveDefinition{
+	{
+		name:        "include",
+		description: "Directs the executor to include this field or fragment only when the `if` argument is true.",
+		locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		args:        []*inputValue{{name: "if", description: "Included when true.", typ: nonNull(booleanType)}},
+	},
+	{
+		name:        "skip",
+		description: "Directs the executor to skip this field or fragment when the `if` argument is true.",
+		locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		args:        []*inputValue{{name: "if", description: "Skipped when true.", typ: nonNull(booleanType)}},
+	},
+}
+
+// schema is an executable schema, types are its named types by name
+type schema struct {
+	query    *graphType
+	mutation *graphType
+	types    map[string]*graphType
+}
+
+// newSchema returns the schema of the root types, it panics if two types have the same name
+func newSchema(query, mutation *graphType) *schema {
+	s := &schema{query: query, mutation: mutation, types: map[string]*graphType{}}
+
+	var add func(t *graphType)
+	add = func(t *graphType) {
+		t = t.namedType()
+		if existing, ok := s.types[t.name]; ok {
+			if existing != t {
+				panic(fmt.Sprintf("graphql: two types are named %v", t.name))
+			}
+			return
+		}
+
+		s.types[t.name] = t
+		for _, f := range t.fields {
+			add(f.typ)
+			for _, arg := range f.args {
+				add(arg.typ)
+			}
+		}
+		for _, f := range t.inputFields {
+			add(f.typ)
+		}
+	}
+
+	for _, t := range []*graphType{query, mutation, schemaType, stringType, booleanType} {
+		if t != nil {
+			add(t)
+		}
+	}
+	return s
+}
+
+// typeOf returns the type of a variable definition
+func (s *schema) typeOf(ref *typeRef) (*graphType, bool) {
+	var (
+		t  *graphType
+		ok = true
+	)
+	if ref.elem != nil {
+		var elem *graphType
+		if elem, ok = s.typeOf(ref.elem); ok {
+			t = listOf(elem)
+		}
+	} else {
+		t, ok = s.types[ref.name]
+	}
+
+	if ok && ref.nonNull {
+		t = nonNull(t)
+	}
+	return t, ok
+}
+
+// sortedTypes returns the named types of the schema sorted by name
+func (s *schema) sortedTypes() []*graphType {
+	types := make([]*graphType, 0, len(s.types))
+	for _, t := range s.types {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].name < types[j].name })
+	return types
+}
+
+// Introspection types, the __schema and __type fields of the query type resolve to them
+var (
+	typeKindType = newEnum("__TypeKind", "An enum describing what kind of type a given `__Type` is.",
+		"SCALAR", "OBJECT", "INTERFACE", "UNION", "ENUM", "INPUT_OBJECT", "LIST", "NON_NULL")
+
+	directiveLocationType = newEnum("__DirectiveLocation", "A Directive can be adjacent to many parts of the GraphQL language, a __DirectiveLocation describes one such possible adjacencies.",
+		"QUERY", "MUTATION", "SUBSCRIPTION", "FIELD", "FRAGMENT_DEFINITION", "FRAGMENT_SPREAD", "INLINE_FRAGMENT", "VARIABLE_DEFINITION",
+		"SCHEMA", "SCALAR", "OBJECT", "FIELD_DEFINITION", "ARGUMENT_DEFINITION", "INTERFACE", "UNION", "ENUM", "ENUM_VALUE",
+		"INPUT_OBJECT", "INPUT_FIELD_DEFINITION")
+
+	schemaType       = &graphType{kind: kindObject, name: "__Schema", description: "A GraphQL Schema defines the capabilities of a GraphQL server."}
+	typeType         = &graphType{kind: kindObject, name: "__Type", description: "The fundamental unit of any GraphQL Schema is the type."}
+	fieldType        = &graphType{kind: kindObject, name: "__Field", description: "Object and Interface types are described by a list of Fields, each of which has a name, potentially a list of arguments, and a return type."}
+	inputValueType   = &graphType{kind: kindObject, name: "__InputValue", description: "Arguments provided to Fields or Directives and the input fields of an InputObject are represented as Input Values which describe their type and optionally a default value."}
+	enumValueType    = &graphType{kind: kindObject, name: "__EnumValue", description: "One possible value for a given Enum."}
+	directiveType    = &graphType{kind: kindObject, name: "__Directive", description: "A Directive provides a way to describe alternate runtime execution and type validation behavior in a GraphQL document."}
+	includeArguments = []*inputValue{{name: "includeDeprecated", typ: booleanType, defaultValue: false}}
+)
+
+func init() {
+	nullable := func(value interface{}) interface{} {
+		if s, ok := value.(string); ok && s == "" {
+			return nil
+		}
+		return value
+	}
+	notDeprecated := []*fieldDefinition{
+		{name: "isDeprecated", typ: nonNull(booleanType), resolve: func(*resolveParams) (interface{}, error) { return false, nil }},
+		{name: "deprecationReason", typ: stringType, resolve: func(*resolveParams) (interface{}, error) { return nil, nil }},
+	}
+
+	schemaType.fields = []*fieldDefinition{
+		{name: "description", typ: stringType, resolve: func(*resolveParams) (interface{}, error) { return nil, nil }},
+		{name: "types", description: "A list of all types supported by this server.", typ: nonNull(listOf(nonNull(typeType))),
+			resolve: func(p *resolveParams) (interface{}, error) { return p.source.(*schema).sortedTypes(), nil }},
+		{name: "queryType", description: "The type that query operations will be rooted at.", typ: nonNull(typeType),
+			resolve: func(p *resolveParams) (interface{}, error) { return p.source.(*schema).query, nil }},
+		{name: "mutationType", description: "If this server supports mutation, the type that mutation operations will be rooted at.", typ: typeType,
+			resolve: func(p *resolveParams) (interface{}, error) {
+				if mutation := p.source.(*schema).mutation; mutation != nil {
+					return mutation, nil
+				}
+				return nil, nil
+			}},
+		{name: "subscriptionType", description: "If this server support subscription, the type that subscription operations will be rooted at.", typ: typeType,
+			resolve: func(*resolveParams) (interface{}, error) { return nil, nil }},
+		{name: "directives", description: "A list of all directives supported by this server.", typ: nonNull(listOf(nonNull(directiveType))),
+			resolve: func(*resolveParams) (interface{}, error) { return directives, nil }},
+	}
+
+	typeType.fields = []*fieldDefinition{
+		{name: "kind", typ: nonNull(typeKindType), resolve: func(p *resolveParams) (interface{}, error) { return string(p.source.(*graphType).kind), nil }},
+		{name: "name", typ: stringType, resolve: func(p *resolveParams) (interface{}, error) { return nullable(p.source.(*graphType).name), nil }},
+		{name: "description", typ: stringType, resolve: func(p *resolveParams) (interface{}, error) { return nullable(p.source.(*graphType).description), nil }},
+		{name: "specifiedByURL", typ: stringType, resolve: func(*resolveParams) (interface{}, error) { return nil, nil }},
+		{name: "fields", args: includeArguments, typ: listOf(nonNull(fieldType)), resolve: func(p *resolveParams) (interface{}, error) {
+			if t := p.source.(*graphType); t.kind == kindObject {
+				return t.fields, nil
+			}
+			return nil, nil
+		}},
+		{name: "interfaces", typ: listOf(nonNull(typeType)), resolve: func(p *resolveParams) (interface{}, error) {
+			if t := p.source.(*graphType); t.kind == kindObject {
+				return []*graphType{}, nil
+			}
+			return nil, nil
+		}},
+		{name: "possibleTypes", typ: listOf(nonNull(typeType)), resolve: func(*resolveParams) (interface{}, error) { return nil, nil }},
+		{name: "enumValues", args: includeArguments, typ: listOf(nonNull(enumValueType)), resolve: func(p *resolveParams) (interface{}, error) {
+			if t := p.source.(*graphType); t.kind == kindEnum {
+				return t.enumValues, nil
+			}
+			return nil, nil
+		}},
+		{name: "inputFields", args: includeArguments, typ: listOf(nonNull(inputValueType)), resolve: func(p *resolveParams) (interface{}, error) {
+			if t := p.source.(*graphType); t.kind == kindInputObject {
+				return t.inputFields, nil
+			}
+			return nil, nil
+		}},
+		{name: "ofType", typ: typeType, resolve: func(p *resolveParams) (interface{}, error) {
+			if t := p.source.(*graphType); t.ofType != nil {
+				return t.ofType, nil
+			}
+			return nil, nil
+		}},
+		{name: "isOneOf", typ: booleanType, resolve: func(p *resolveParams) (interface{}, error) {
+			if t := p.source.(*graphType); t.kind == kindInputObject {
+				return false, nil
+			}
+			return nil, nil
+		}},
+	}
+
+	fieldType.fields = append([]*fieldDefinition{
+		{name: "name", typ: nonNull(stringType), resolve: func(p *resolveParams) (interface{}, error) { return p.source.(*fieldDefinition).name, nil }},
+		{name: "description", typ: stringType, resolve: func(p *resolveParams) (interface{}, error) {
+			return nullable(p.source.(*fieldDefinition).description), nil
+		}},
+		{name: "args", args: includeArguments, typ: nonNull(listOf(nonNull(inputValueType))), resolve: func(p *resolveParams) (interface{}, error) {
+			return append([]*inputValue{}, p.source.(*fieldDefinition).args...), nil
+		}},
+		{name: "type", typ: nonNull(typeType), resolve: func(p *resolveParams) (interface{}, error) { return p.source.(*fieldDefinition).typ, nil }},
+	}, notDeprecated...)
+
+	inputValueType.fields = append([]*fieldDefinition{
+		{name: "name", typ: nonNull(stringType), resolve: func(p *resolveParams) (interface{}, error) { return p.source.(*inputValue).name, nil }},
+		{name: "description", typ: stringType, resolve: func(p *resolveParams) (interface{}, error) { return nullable(p.source.(*inputValue).description), nil }},
+		{name: "type", typ: nonNull(typeType), resolve: func(p *resolveParams) (interface{}, error) { return p.source.(*inputValue).typ, nil }},
+		{name: "defaultValue", description: "A GraphQL-formatted string representing the default value for this input value.", typ: stringType,
+			resolve: func(p *resolveParams) (interface{}, error) {
+				if v := p.source.(*inputValue).defaultValue; v != nil {
+					return printValue(v), nil
+				}
+				return nil, nil
+			}},
+	}, notDeprecated...)
+
+	enumValueType.fields = append([]*fieldDefinition{
+		{name: "name", typ: nonNull(stringType), resolve: func(p *resolveParams) (interface{}, error) { return p.source, nil }},
+		{name: "description", typ: stringType, resolve: func(*resolveParams) (interface{}, error) { return nil, nil }},
+	}, notDeprecated...)
+
+	directiveType.fields = []*fieldDefinition{
+		{name: "name", typ: nonNull(stringType), resolve: func(p *resolveParams) (interface{}, error) { return p.source.(*directiveDefinition).name, nil }},
+		{name: "description", typ: stringType, resolve: func(p *resolveParams) (interface{}, error) {
+			return nullable(p.source.(*directiveDefinition).description), nil
+		}},
+		{name: "isRepeatable", typ: nonNull(booleanType), resolve: func(*resolveParams) (interface{}, error) { return false, nil }},
+		{name: "locations", typ: nonNull(listOf(nonNull(directiveLocationType))), resolve: func(p *resolveParams) (interface{}, error) {
+			return p.source.(*directiveDefinition).locations, nil
+		}},
+		{name: "args", args: includeArguments, typ: nonNull(listOf(nonNull(inputValueType))), resolve: func(p *resolveParams) (interface{}, error) {
+			return p.source.(*directiveDefinition).args, nil
+		}},
+	}
+}
+
+// metaFields returns the __schema and __type fields of the query type of the schema
+func (s *schema) metaFields() []*fieldDefinition {
+	return []*fieldDefinition{
+		{name: "__schema", description: "Access the current type schema of this server.", typ: nonNull(schemaType),
+			resolve: func(*resolveParams) (interface{}, error) { return s, nil }},
+		{name: "__type", description: "Request the type information of a single type.", typ: typeType,
+			args: []*inputValue{{name: "name", typ: nonNull(stringType)}},
+			resolve: func(p *resolveParams) (interface{}, error) {
+				if t, ok := s.types[p.args["name"].(string)]; ok {
+					return t, nil
+				}
+				return nil, nil
+			}},
+	}
+}
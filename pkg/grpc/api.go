@@ -92,6 +92,7 @@ type API interface {
 	SetAppChannel(appChannel channel.AppChannel)
 	SetDirectMessaging(directMessaging messaging.DirectMessaging)
 	SetActorRuntime(actor actors.Actors)
+	SetAccessControlList(accessControlList *config.AccessControlList)
 	RegisterActorTimer(ctx context.Context, in *runtimev1pb.RegisterActorTimerRequest) (*emptypb.Empty, error)
 	UnregisterActorTimer(ctx context.Context, in *runtimev1pb.UnregisterActorTimerRequest) (*emptypb.Empty, error)
 	RegisterActorReminder(ctx context.Context, in *runtimev1pb.RegisterActorReminderRequest) (*emptypb.Empty, error)
@@ -124,6 +125,7 @@ type api struct {
 	sendToOutputBindingFn      func(name string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error)
 	tracingSpec                config.TracingSpec
 	accessControlList          *config.AccessControlList
+	accessControlListLock      sync.RWMutex
 	appProtocol                string
 	extendedMetadata           sync.Map
 	components                 []components_v1alpha.Component
@@ -186,7 +188,11 @@ func (a *api) CallLocal(ctx context.Context, in *internalv1pb.InternalInvokeRequ
 		return nil, status.Errorf(codes.InvalidArgument, messages.ErrInternalInvokeRequest, err.Error())
 	}
 
-	if a.accessControlList != nil {
+	a.accessControlListLock.RLock()
+	accessControlList := a.accessControlList
+	a.accessControlListLock.RUnlock()
+
+	if accessControlList != nil {
 		// An access control policy has been specified for the app. Apply the policies.
 		operation := req.Message().Method
 		var httpVerb commonv1pb.HTTPExtension_Verb
@@ -197,7 +203,7 @@ func (a *api) CallLocal(ctx context.Context, in *internalv1pb.InternalInvokeRequ
 				httpVerb = httpExt.GetVerb()
 			}
 		}
-		callAllowed, errMsg := acl.ApplyAccessControlPolicies(ctx, operation, httpVerb, a.appProtocol, a.accessControlList)
+		callAllowed, errMsg := acl.ApplyAccessControlPolicies(ctx, operation, httpVerb, a.appProtocol, accessControlList)
 
 		if !callAllowed {
 			return nil, status.Errorf(codes.PermissionDenied, errMsg)
@@ -1188,6 +1194,13 @@ func (a *api) SetActorRuntime(actor actors.Actors) {
 	a.actor = actor
 }
 
+// SetAccessControlList replaces the access control policies applied to the calls to the app.
+func (a *api) SetAccessControlList(accessControlList *config.AccessControlList) {
+	a.accessControlListLock.Lock()
+	a.accessControlList = accessControlList
+	a.accessControlListLock.Unlock()
+}
+
 func (a *api) GetMetadata(ctx context.Context, in *emptypb.Empty) (*runtimev1pb.GetMetadataResponse, error) {
 	temp := make(map[string]string)
 
@@ -348,6 +348,43 @@ func mustMarshalAny(msg proto.Message) *anypb.Any {
 	return any
 }
 
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (f *fakeServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func TestAPIAuthenticationMiddlewareStream(t *testing.T) {
+	token := "1234"
+	interceptor := setAPIAuthenticationMiddlewareStream(token, "app-api-token")
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("app-api-token", token))
+		err := interceptor(nil, &fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, handler)
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("app-api-token", "4567"))
+		err := interceptor(nil, &fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, handler)
+		s, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.Unauthenticated, s.Code())
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{})
+		err := interceptor(nil, &fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, handler)
+		assert.Error(t, err)
+	})
+}
+
 func TestAPIToken(t *testing.T) {
 	mockDirectMessaging := new(appt.MockDirectMessaging)
 
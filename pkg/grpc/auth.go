@@ -28,28 +28,42 @@ import (
 	"google.golang.org/grpc/metadata"
 
 	v1 "github.com/bhojpur/application/pkg/messaging/v1"
+	auth "github.com/bhojpur/application/pkg/runtime/security"
 )
 
 func setAPIAuthenticationMiddlewareUnary(apiToken, authHeader string) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			err := v1.ErrorFromHTTPResponseCode(http.StatusUnauthorized, "missing metadata in request")
+		if err := checkAPIToken(ctx, apiToken, authHeader); err != nil {
 			return nil, err
 		}
+		return handler(ctx, req)
+	}
+}
 
-		token := md.Get(authHeader)
-		if len(token) == 0 {
-			err := v1.ErrorFromHTTPResponseCode(http.StatusUnauthorized, "missing api token in request metadata")
-			return nil, err
+func setAPIAuthenticationMiddlewareStream(apiToken, authHeader string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkAPIToken(stream.Context(), apiToken, authHeader); err != nil {
+			return err
 		}
+		return handler(srv, stream)
+	}
+}
 
-		if token[0] != apiToken {
-			err := v1.ErrorFromHTTPResponseCode(http.StatusUnauthorized, "authentication error: api token mismatch")
-			return nil, err
-		}
+func checkAPIToken(ctx context.Context, apiToken, authHeader string) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return v1.ErrorFromHTTPResponseCode(http.StatusUnauthorized, "missing metadata in request")
+	}
 
-		md.Set(authHeader, "")
-		return handler(ctx, req)
+	token := md.Get(authHeader)
+	if len(token) == 0 {
+		return v1.ErrorFromHTTPResponseCode(http.StatusUnauthorized, "missing api token in request metadata")
 	}
+
+	if !auth.TokenMatches(apiToken, token[0]) {
+		return v1.ErrorFromHTTPResponseCode(http.StatusUnauthorized, "authentication error: api token mismatch")
+	}
+
+	md.Set(authHeader, "")
+	return nil
 }
@@ -209,6 +209,7 @@ func (s *server) getMiddlewareOptions() []grpc_go.ServerOption {
 	if s.authToken != "" {
 		s.logger.Info("enabled token authentication on gRPC server")
 		intr = append(intr, setAPIAuthenticationMiddlewareUnary(s.authToken, auth.APITokenHeader))
+		intrStream = append(intrStream, setAPIAuthenticationMiddlewareStream(s.authToken, auth.APITokenHeader))
 	}
 
 	if diag_utils.IsTracingEnabled(s.tracingSpec.SamplingRate) {
@@ -253,7 +253,7 @@ func useAPIAuthentication(next fasthttp.RequestHandler) fasthttp.RequestHandler
 
 	return func(ctx *fasthttp.RequestCtx) {
 		v := ctx.Request.Header.Peek(auth.APITokenHeader)
-		if auth.ExcludedRoute(string(ctx.Request.URI().FullURI())) || string(v) == token {
+		if auth.ExcludedRoute(string(ctx.Path())) || auth.TokenMatches(token, string(v)) {
 			ctx.Request.Header.Del(auth.APITokenHeader)
 			next(ctx)
 		} else {
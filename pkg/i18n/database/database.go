@@ -0,0 +1,76 @@
+package database
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"github.com/bhojpur/application/pkg/i18n"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// Translation is a translation stored in the database
+type Translation struct {
+	ID     uint   `orm:"primary_key"`
+	Locale string `orm:"size:32;unique_index:idx_translation_locale_key"`
+	Key    string `orm:"size:255;unique_index:idx_translation_locale_key"`
+	Value  string `orm:"type:text"`
+}
+
+// TableName table name of translations
+func (Translation) TableName() string {
+	return "translations"
+}
+
+// Backend loads and saves translations in a database table, so they can be edited at runtime. The table of
+// Translation has to be migrated
+type Backend struct {
+	db *orm.DB
+}
+
+// New returns a backend storing translations in db
+func New(db *orm.DB) *Backend {
+	return &Backend{db: db}
+}
+
+// LoadTranslations loads the translations of the table
+func (backend *Backend) LoadTranslations() ([]*i18n.Translation, error) {
+	var stored []Translation
+	if err := backend.db.Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	translations := make([]*i18n.Translation, len(stored))
+	for idx, translation := range stored {
+		translations[idx] = &i18n.Translation{Locale: translation.Locale, Key: translation.Key, Value: translation.Value}
+	}
+	return translations, nil
+}
+
+// SaveTranslation creates or updates the translation of its locale and key
+func (backend *Backend) SaveTranslation(translation *i18n.Translation) error {
+	return backend.db.Where(Translation{Locale: translation.Locale, Key: translation.Key}).
+		Assign(Translation{Value: translation.Value}).
+		FirstOrCreate(&Translation{}).Error
+}
+
+// DeleteTranslation deletes the translation of its locale and key
+func (backend *Backend) DeleteTranslation(translation *i18n.Translation) error {
+	return backend.db.Where(Translation{Locale: translation.Locale, Key: translation.Key}).Delete(&Translation{}).Error
+}
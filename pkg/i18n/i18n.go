@@ -0,0 +1,275 @@
+package i18n
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/validations"
+)
+
+// ErrReadOnly is returned by backends which can't save translations, like YAML files
+var ErrReadOnly = errors.New("i18n: read only backend")
+
+// Translation is the translation of a key to a locale
+type Translation struct {
+	Locale string
+	Key    string
+	Value  string
+}
+
+// Backend loads and saves translations, like YAML files or a database table
+type Backend interface {
+	LoadTranslations() ([]*Translation, error)
+	SaveTranslation(*Translation) error
+	DeleteTranslation(*Translation) error
+}
+
+// I18n is a store of the translations of its backends, it is the Translator of the config of Apps contexts:
+//
+//	store, err := i18n.New(yaml.New("config/locales"), database.New(db))
+//	config.Translator = store
+//	engine.Use(store.Middleware())
+type I18n struct {
+	// DefaultLocale is the locale of the translations used for the keys without translation in the requested locale,
+	// "en" by default
+	DefaultLocale string
+	backends      []Backend
+	translations  map[string]map[string]string
+	mutex         sync.RWMutex
+}
+
+// New initialize a store loading the translations of backends. For a key translated by several backends, the
+// translation of the first one wins, and translations are saved to the first backend
+func New(backends ...Backend) (*I18n, error) {
+	i18n := &I18n{DefaultLocale: "en", backends: backends}
+	if err := i18n.Reload(); err != nil {
+		return nil, err
+	}
+	return i18n, nil
+}
+
+// Reload loads the translations of the backends again
+func (i18n *I18n) Reload() error {
+	translations := map[string]map[string]string{}
+	for idx := len(i18n.backends) - 1; idx >= 0; idx-- {
+		loaded, err := i18n.backends[idx].LoadTranslations()
+		if err != nil {
+			return fmt.Errorf("failed to load translations: %w", err)
+		}
+		for _, translation := range loaded {
+			locale := normalizeLocale(translation.Locale)
+			if translations[locale] == nil {
+				translations[locale] = map[string]string{}
+			}
+			translations[locale][translation.Key] = translation.Value
+		}
+	}
+
+	i18n.mutex.Lock()
+	i18n.translations = translations
+	i18n.mutex.Unlock()
+	return nil
+}
+
+// AddTranslation saves a translation to the first backend
+func (i18n *I18n) AddTranslation(translation *Translation) error {
+	if len(i18n.backends) == 0 {
+		return ErrReadOnly
+	}
+	if err := i18n.backends[0].SaveTranslation(translation); err != nil {
+		return err
+	}
+
+	locale := normalizeLocale(translation.Locale)
+	i18n.mutex.Lock()
+	defer i18n.mutex.Unlock()
+	if i18n.translations[locale] == nil {
+		i18n.translations[locale] = map[string]string{}
+	}
+	i18n.translations[locale][translation.Key] = translation.Value
+	return nil
+}
+
+// DeleteTranslation deletes a translation from the first backend, the ones of the other backends are used again
+func (i18n *I18n) DeleteTranslation(translation *Translation) error {
+	if len(i18n.backends) == 0 {
+		return ErrReadOnly
+	}
+	if err := i18n.backends[0].DeleteTranslation(translation); err != nil {
+		return err
+	}
+	return i18n.Reload()
+}
+
+// Locales returns the locales with translations, sorted
+func (i18n *I18n) Locales() []string {
+	i18n.mutex.RLock()
+	defer i18n.mutex.RUnlock()
+
+	var locales []string
+	for locale := range i18n.translations {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Translate returns the translation of key in locale, eg. "de-CH" falls back to the translations of "de", then of
+// the default locale, to implement appsvr.Translator
+func (i18n *I18n) Translate(locale, key string) (string, bool) {
+	i18n.mutex.RLock()
+	defer i18n.mutex.RUnlock()
+
+	for _, locale := range fallbackLocales(locale, i18n.DefaultLocale) {
+		if value, ok := i18n.translations[locale][key]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// T returns the translation of key in locale formatted with args like fmt.Sprintf, or key if it has none
+func (i18n *I18n) T(locale, key string, args ...interface{}) string {
+	value, ok := i18n.Translate(locale, key)
+	if !ok {
+		value = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(value, args...)
+	}
+	return value
+}
+
+// Negotiate returns the locale of a request, from its locale query parameter or cookie, or the best locale with
+// translations of its Accept-Language header, the default locale otherwise
+func (i18n *I18n) Negotiate(req *http.Request) string {
+	if locale := req.URL.Query().Get("locale"); locale != "" {
+		return locale
+	}
+	if cookie, err := req.Cookie("locale"); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+
+	available := map[string]bool{}
+	for _, locale := range i18n.Locales() {
+		available[locale] = true
+	}
+	for _, locale := range acceptedLocales(req.Header.Get("Accept-Language")) {
+		for _, candidate := range fallbackLocales(locale, "") {
+			if available[candidate] {
+				return locale
+			}
+		}
+	}
+	return i18n.DefaultLocale
+}
+
+// Middleware returns a middleware setting the negotiated locale of requests to their Locale header, which is the
+// locale of their Apps contexts, see utils.GetLocale
+func (i18n *I18n) Middleware() *appsvr.Middleware {
+	return &appsvr.Middleware{
+		Name: "i18n",
+		Handler: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				req.Header.Set("Locale", i18n.Negotiate(req))
+				w.Header().Add("Vary", "Accept-Language")
+				next.ServeHTTP(w, req)
+			})
+		},
+	}
+}
+
+// TranslateError returns the messages of err translated to the locale of the context, validation errors are
+// translated by their message, see validations.Error.Translate
+func TranslateError(context *appsvr.Context, err error) []string {
+	errs := []error{err}
+	if multiple, ok := err.(interface{ GetErrors() []error }); ok {
+		errs = multiple.GetErrors()
+	}
+
+	var messages []string
+	for _, e := range errs {
+		var validationErr *validations.Error
+		if errors.As(e, &validationErr) {
+			messages = append(messages, validationErr.Translate(context))
+		} else {
+			messages = append(messages, context.T(e.Error()))
+		}
+	}
+	return messages
+}
+
+// acceptedLocales returns the locales of an Accept-Language header, by decreasing quality
+func acceptedLocales(header string) []string {
+	type accepted struct {
+		locale  string
+		quality float64
+	}
+
+	var locales []accepted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		locale, quality := strings.TrimSpace(fields[0]), 1.0
+		if locale == "" || locale == "*" {
+			continue
+		}
+		for _, param := range fields[1:] {
+			if param = strings.TrimSpace(param); strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			locales = append(locales, accepted{locale: locale, quality: quality})
+		}
+	}
+
+	sort.SliceStable(locales, func(i, j int) bool { return locales[i].quality > locales[j].quality })
+	result := make([]string, len(locales))
+	for idx, locale := range locales {
+		result[idx] = locale.locale
+	}
+	return result
+}
+
+// fallbackLocales returns the locales looked up for locale, like "de-ch", "de", then the default locale
+func fallbackLocales(locale, defaultLocale string) []string {
+	var locales []string
+	for _, l := range []string{locale, strings.SplitN(normalizeLocale(locale), "-", 2)[0], defaultLocale} {
+		if l = normalizeLocale(l); l != "" && (len(locales) == 0 || locales[len(locales)-1] != l) {
+			locales = append(locales, l)
+		}
+	}
+	return locales
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(locale), "_", "-", -1))
+}
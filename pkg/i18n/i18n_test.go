@@ -0,0 +1,149 @@
+package i18n_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/i18n"
+	"github.com/bhojpur/application/pkg/i18n/database"
+	"github.com/bhojpur/application/pkg/i18n/yaml"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/validations"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ProductVariation struct {
+	ID   uint
+	Name string
+	SKU  string
+}
+
+const translations = `
+en:
+  greeting: Hello %s
+  resources:
+    product_variation:
+      name: Variation
+de:
+  greeting: Hallo %s
+  resources:
+    product_variation:
+      name: Variante
+      attributes:
+        Name: Bezeichnung
+  "Name can't be blank": Name darf nicht leer sein
+  permission denied: Zugriff verweigert
+`
+
+func newStore(t *testing.T) (*i18n.I18n, *orm.DB) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "locales.yml"), []byte(translations), 0o600))
+
+	db := utils.SQLiteTestDB(t, `CREATE TABLE translations (id INTEGER PRIMARY KEY AUTOINCREMENT, locale VARCHAR(32), "key" VARCHAR(255), value TEXT,
+		UNIQUE (locale, "key"))`)
+
+	store, err := i18n.New(database.New(db), yaml.New(dir))
+	require.NoError(t, err)
+	return store, db
+}
+
+func TestTranslate(t *testing.T) {
+	store, db := newStore(t)
+
+	assert.Equal(t, []string{"de", "en"}, store.Locales())
+	assert.Equal(t, "Hallo Ada", store.T("de-CH", "greeting", "Ada"))
+	assert.Equal(t, "Hello Ada", store.T("fr", "greeting", "Ada"))
+	assert.Equal(t, "missing", store.T("de", "missing"))
+
+	// translations of the database win over the ones of files, and are saved to it
+	require.NoError(t, store.AddTranslation(&i18n.Translation{Locale: "de", Key: "greeting", Value: "Servus %s"}))
+	assert.Equal(t, "Servus Ada", store.T("de", "greeting", "Ada"))
+	require.NoError(t, store.AddTranslation(&i18n.Translation{Locale: "de", Key: "greeting", Value: "Grüß Gott %s"}))
+	count := 0
+	db.Model(&database.Translation{}).Count(&count)
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, store.Reload())
+	assert.Equal(t, "Grüß Gott Ada", store.T("de", "greeting", "Ada"))
+
+	require.NoError(t, store.DeleteTranslation(&i18n.Translation{Locale: "de", Key: "greeting"}))
+	assert.Equal(t, "Hallo Ada", store.T("de", "greeting", "Ada"))
+}
+
+func TestNegotiate(t *testing.T) {
+	store, _ := newStore(t)
+
+	cases := []struct {
+		url            string
+		acceptLanguage string
+		want           string
+	}{
+		{"/", "", "en"},
+		{"/", "fr-FR, de-CH;q=0.8, en;q=0.5", "de-CH"},
+		{"/", "fr, en;q=0.1, de;q=0.9", "de"},
+		{"/", "de;q=0, fr", "en"},
+		{"/?locale=fr", "de", "fr"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.url, nil)
+		req.Header.Set("Accept-Language", c.acceptLanguage)
+		assert.Equal(t, c.want, store.Negotiate(req), c.acceptLanguage)
+	}
+}
+
+func TestContext(t *testing.T) {
+	store, _ := newStore(t)
+	config := &appsvr.Config{Translator: store}
+
+	var context *appsvr.Context
+	handler := store.Middleware().Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		context = &appsvr.Context{Request: req, Writer: w, Config: config}
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "de-DE")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "Hallo Ada", context.T("greeting", "Ada"))
+	assert.Equal(t, "fallback", context.TDefault("missing", "fallback"))
+
+	res := resource.New(&ProductVariation{})
+	assert.Equal(t, "Variante", res.GetTranslatedName(context))
+	assert.Equal(t, "Bezeichnung", res.GetTranslatedLabel("Name", context))
+	assert.Equal(t, "SKU", res.GetTranslatedLabel("SKU", context))
+
+	var errs appsvr.Errors
+	errs.AddError(validations.NewError(&ProductVariation{}, "Name", "Name can't be blank"), errors.New("permission denied"))
+	assert.Equal(t, []string{"Name darf nicht leer sein", "Zugriff verweigert"}, i18n.TranslateError(context, errs))
+
+	context.SetLocale("en")
+	assert.Equal(t, "Variation", res.GetTranslatedName(context))
+	assert.Equal(t, "Name can't be blank", validations.NewError(nil, "Name", "Name can't be blank").(*validations.Error).Translate(context))
+}
@@ -0,0 +1,132 @@
+package yaml
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	goyaml "gopkg.in/yaml.v2"
+
+	"github.com/bhojpur/application/pkg/i18n"
+)
+
+// Backend loads translations from YAML files, the keys of their top level are locales, and their nested keys are
+// joined with dots:
+//
+//	de:
+//	  resources:
+//	    product:
+//	      name: Produkt
+//	  "Name can't be blank": Name darf nicht leer sein
+type Backend struct {
+	paths []string
+}
+
+// New returns a backend loading the YAML files of paths, the .yml and .yaml files of directories, in their order
+func New(paths ...string) *Backend {
+	return &Backend{paths: paths}
+}
+
+// LoadTranslations loads the translations of the files, a key of a later file wins
+func (backend *Backend) LoadTranslations() ([]*i18n.Translation, error) {
+	var translations []*i18n.Translation
+	for _, path := range backend.paths {
+		files, err := yamlFiles(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			content, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+
+			var locales map[string]interface{}
+			if err := goyaml.Unmarshal(content, &locales); err != nil {
+				return nil, fmt.Errorf("invalid translations file %s: %w", file, err)
+			}
+			for locale, values := range locales {
+				translations = flatten(translations, locale, "", values)
+			}
+		}
+	}
+	return translations, nil
+}
+
+// SaveTranslation returns i18n.ErrReadOnly, files are not written
+func (backend *Backend) SaveTranslation(*i18n.Translation) error {
+	return i18n.ErrReadOnly
+}
+
+// DeleteTranslation returns i18n.ErrReadOnly, files are not written
+func (backend *Backend) DeleteTranslation(*i18n.Translation) error {
+	return i18n.ErrReadOnly
+}
+
+// yamlFiles returns path if it is a file, or the YAML files of the directory, sorted
+func yamlFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ext := strings.ToLower(filepath.Ext(file)); !info.IsDir() && (ext == ".yml" || ext == ".yaml") {
+			files = append(files, file)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// flatten appends the translations of the nested values of a locale, their keys joined with dots
+func flatten(translations []*i18n.Translation, locale, prefix string, value interface{}) []*i18n.Translation {
+	nested, ok := value.(map[interface{}]interface{})
+	if !ok {
+		if value == nil {
+			return translations
+		}
+		return append(translations, &i18n.Translation{Locale: locale, Key: prefix, Value: fmt.Sprint(value)})
+	}
+
+	for key, value := range nested {
+		if prefix != "" {
+			translations = flatten(translations, locale, prefix+"."+fmt.Sprint(key), value)
+		} else {
+			translations = flatten(translations, locale, fmt.Sprint(key), value)
+		}
+	}
+	return translations
+}
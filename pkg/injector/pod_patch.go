@@ -179,7 +179,7 @@ func (i *injector) getPodPatchOperations(ar *v1.AdmissionReview,
 		path = containersPath
 		value = []corev1.Container{*sidecarContainer}
 	} else {
-		envPatchOps = addAppEnvVarsToContainers(pod.Spec.Containers)
+		envPatchOps = addAppEnvVarsToContainers(pod.Spec.Containers, pod.Annotations)
 		path = "/spec/containers/-"
 		value = sidecarContainer
 	}
@@ -199,7 +199,9 @@ func (i *injector) getPodPatchOperations(ar *v1.AdmissionReview,
 
 // This function add Bhojpur Application runtime environment variables to all the containers
 // in any Bhojpur Application enabled pod. The containers can be injected or user defined.
-func addAppEnvVarsToContainers(containers []corev1.Container) []PatchOperation {
+// The API tokens are shared with the application, so that it can authenticate its calls to
+// the sidecar and validate the calls it receives from the sidecar.
+func addAppEnvVarsToContainers(containers []corev1.Container, annotations map[string]string) []PatchOperation {
 	portEnv := []corev1.EnvVar{
 		{
 			Name:  userContainerAppHTTPPortName,
@@ -210,6 +212,7 @@ func addAppEnvVarsToContainers(containers []corev1.Container) []PatchOperation {
 			Value: strconv.Itoa(sidecarAPIGRPCPort),
 		},
 	}
+	portEnv = append(portEnv, getTokenEnvVars(annotations)...)
 	envPatchOps := make([]PatchOperation, 0, len(containers))
 	for i, container := range containers {
 		path := fmt.Sprintf("%s/%d/env", containersPath, i)
@@ -352,6 +355,33 @@ func appSSLEnabled(annotations map[string]string) bool {
 	return getBoolAnnotationOrDefault(annotations, appAppSSLKey, defaultAppSSL)
 }
 
+// getTokenEnvVars returns the environment variables holding the API tokens of the secrets
+// referenced by the annotations.
+func getTokenEnvVars(annotations map[string]string) []corev1.EnvVar {
+	env := []corev1.EnvVar{}
+	if secret := getAPITokenSecret(annotations); secret != "" {
+		env = append(env, tokenEnvVar(auth.APITokenEnvVar, secret))
+	}
+	if appSecret := GetAppTokenSecret(annotations); appSecret != "" {
+		env = append(env, tokenEnvVar(auth.AppAPITokenEnvVar, appSecret))
+	}
+	return env
+}
+
+func tokenEnvVar(name, secret string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				Key: "token",
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: secret,
+				},
+			},
+		},
+	}
+}
+
 func getAPITokenSecret(annotations map[string]string) string {
 	return getStringAnnotationOrDefault(annotations, appAPITokenSecret, "")
 }
@@ -714,35 +744,7 @@ func getSidecarContainer(annotations map[string]string, id, appSidecarImage, ima
 		c.Args = append(c.Args, "--http-stream-request-body")
 	}
 
-	secret := getAPITokenSecret(annotations)
-	if secret != "" {
-		c.Env = append(c.Env, corev1.EnvVar{
-			Name: auth.APITokenEnvVar,
-			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					Key: "token",
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: secret,
-					},
-				},
-			},
-		})
-	}
-
-	appSecret := GetAppTokenSecret(annotations)
-	if appSecret != "" {
-		c.Env = append(c.Env, corev1.EnvVar{
-			Name: auth.AppAPITokenEnvVar,
-			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					Key: "token",
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: appSecret,
-					},
-				},
-			},
-		})
-	}
+	c.Env = append(c.Env, getTokenEnvVars(annotations)...)
 
 	resources, err := getResourceRequirements(annotations)
 	if err != nil {
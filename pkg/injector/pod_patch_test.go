@@ -27,6 +27,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	auth "github.com/bhojpur/application/pkg/runtime/security"
+
 	corev1 "k8s.io/api/core/v1"
 
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -467,10 +469,45 @@ func TestAddAppEnvVarsToContainers(t *testing.T) {
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(tc.testName, func(t *testing.T) {
-			patchEnv := addAppEnvVarsToContainers([]corev1.Container{tc.mockContainer})
+			patchEnv := addAppEnvVarsToContainers([]corev1.Container{tc.mockContainer}, nil)
 			fmt.Println(tc.testName)
 			assert.Equal(t, tc.expOpsLen, len(patchEnv))
 			assert.Equal(t, tc.expOps, patchEnv)
 		})
 	}
 }
+
+func TestAddAppTokenEnvVarsToContainers(t *testing.T) {
+	annotations := map[string]string{
+		appAPITokenSecret: "api-secret",
+		appAppTokenSecret: "app-secret",
+	}
+	container := corev1.Container{
+		Name: "Mock Container",
+		Env: []corev1.EnvVar{
+			{
+				Name:  userContainerAppHTTPPortName,
+				Value: "3510",
+			},
+			{
+				Name:  userContainerAppGRPCPortName,
+				Value: "550000",
+			},
+		},
+	}
+
+	patchEnv := addAppEnvVarsToContainers([]corev1.Container{container}, annotations)
+	assert.Equal(t, []PatchOperation{
+		{
+			Op:    "add",
+			Path:  "/spec/containers/0/env/-",
+			Value: tokenEnvVar(auth.APITokenEnvVar, "api-secret"),
+		},
+		{
+			Op:    "add",
+			Path:  "/spec/containers/0/env/-",
+			Value: tokenEnvVar(auth.AppAPITokenEnvVar, "app-secret"),
+		},
+	}, patchEnv)
+	assert.Equal(t, "app-secret", patchEnv[1].Value.(corev1.EnvVar).ValueFrom.SecretKeyRef.Name)
+}
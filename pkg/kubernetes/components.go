@@ -26,8 +26,12 @@ import (
 	"strings"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 
+	listers "github.com/bhojpur/application/pkg/client/listers/components/v1alpha1"
 	v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
 	"github.com/bhojpur/application/pkg/utils"
 )
@@ -38,6 +42,7 @@ type ComponentsOutput struct {
 	Type    string `csv:"Type"`
 	Version string `csv:"VERSION"`
 	Scopes  string `csv:"SCOPES"`
+	Status  string `csv:"STATUS"`
 	Created string `csv:"CREATED"`
 	Age     string `csv:"AGE"`
 }
@@ -65,6 +70,25 @@ func PrintComponents(name, outputFormat string) error {
 	}, name, outputFormat)
 }
 
+// PrintComponentsFromLister writes the Bhojpur Application components held by the lister
+// cache, avoiding a round trip to the API server.
+func PrintComponentsFromLister(writer io.Writer, lister listers.ComponentLister, name, outputFormat string) error {
+	return writeComponents(writer, func() (*v1alpha1.ComponentList, error) {
+		items, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+
+		list := &v1alpha1.ComponentList{
+			Items: make([]v1alpha1.Component, 0, len(items)),
+		}
+		for _, item := range items {
+			list.Items = append(list.Items, *item.DeepCopy())
+		}
+		return list, nil
+	}, name, outputFormat)
+}
+
 func writeComponents(writer io.Writer, getConfigFunc func() (*v1alpha1.ComponentList, error), name, outputFormat string) error {
 	confs, err := getConfigFunc()
 	if err != nil {
@@ -88,8 +112,18 @@ func writeComponents(writer io.Writer, getConfigFunc func() (*v1alpha1.Component
 		}
 	}
 
-	if outputFormat == "" || outputFormat == "list" {
+	switch {
+	case isListOutput(outputFormat):
 		return printComponentList(writer, filtered)
+	case outputFormat == wideOutputFormat:
+		printComponentWideList(writer, filtered)
+		return nil
+	case isCustomColumnsOutput(outputFormat):
+		objects := make([]runtime.Object, 0, len(filtered))
+		for i := range filtered {
+			objects = append(objects, &filtered[i])
+		}
+		return writeCustomColumns(writer, outputFormat, objects)
 	}
 
 	return utils.PrintDetail(writer, outputFormat, filteredSpecs)
@@ -105,8 +139,50 @@ func printComponentList(writer io.Writer, list []v1alpha1.Component) error {
 			Age:     utils.GetAge(c.CreationTimestamp.Time),
 			Version: c.Spec.Version,
 			Scopes:  strings.Join(c.Scopes, ","),
+			Status:  componentStatus(c),
 		})
 	}
 
 	return utils.MarshalAndWriteTable(writer, co)
 }
+
+func printComponentWideList(writer io.Writer, list []v1alpha1.Component) {
+	header := []string{"NAMESPACE", "NAME", "TYPE", "VERSION", "SCOPES", "DEPENDS-ON", "STATUS", "MESSAGE", "CREATED", "AGE"}
+	rows := make([][]string, 0, len(list))
+	for _, c := range list {
+		message := noneValue
+		if condition := meta.FindStatusCondition(c.Status.Conditions, v1alpha1.ConditionDependenciesReady); condition != nil && condition.Message != "" {
+			message = condition.Message
+		}
+
+		rows = append(rows, []string{
+			c.GetNamespace(),
+			c.GetName(),
+			c.Spec.Type,
+			c.Spec.Version,
+			joinOrNone(c.Scopes),
+			joinOrNone(c.Spec.DependsOn),
+			componentStatus(c),
+			message,
+			c.CreationTimestamp.Format("2006-01-02 15:04.05"),
+			utils.GetAge(c.CreationTimestamp.Time),
+		})
+	}
+
+	utils.WriteRows(writer, header, rows)
+}
+
+// componentStatus summarizes the status conditions of a component for table output.
+func componentStatus(c v1alpha1.Component) string {
+	condition := meta.FindStatusCondition(c.Status.Conditions, v1alpha1.ConditionDependenciesReady)
+	switch {
+	case condition == nil:
+		return "Unknown"
+	case condition.Status == meta_v1.ConditionTrue:
+		return "Ready"
+	case condition.Reason != "":
+		return condition.Reason
+	default:
+		return "NotReady"
+	}
+}
@@ -31,6 +31,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// +kubebuilder:subresource:status
+
 // Component describes an Bhojpur Application runtime component type.
 type Component struct {
 	metav1.TypeMeta `json:",inline"`
@@ -42,6 +44,8 @@ type Component struct {
 	Auth `json:"auth,omitempty"`
 	// +optional
 	Scopes []string `json:"scopes,omitempty"`
+	// +optional
+	Status ComponentStatus `json:"status,omitempty"`
 }
 
 // ComponentSpec is the spec for a component.
@@ -53,8 +57,31 @@ type ComponentSpec struct {
 	Metadata     []MetadataItem `json:"metadata"`
 	// +optional
 	InitTimeout string `json:"initTimeout"`
+	// DependsOn lists the names of the components which must be ready before this component is initialized.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
 }
 
+// ComponentStatus is the observed state of a component.
+type ComponentStatus struct {
+	// ObservedGeneration is the generation of the spec the status was reported for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// ConditionReady is the condition type reporting whether a component has a valid config and ready dependencies.
+	ConditionReady = "Ready"
+	// ConditionConfigValid is the condition type reporting whether the spec and metadata of a component are valid.
+	ConditionConfigValid = "ConfigValid"
+	// ConditionDependenciesReady is the condition type reporting whether all dependencies of a component exist and are ready.
+	ConditionDependenciesReady = "DependenciesReady"
+	// ConditionWithinQuota is the condition type reporting whether a component is within the quota of its type in its namespace.
+	ConditionWithinQuota = "WithinQuota"
+)
+
 // MetadataItem is a name/value pair for a metadata.
 type MetadataItem struct {
 	Name string `json:"name"`
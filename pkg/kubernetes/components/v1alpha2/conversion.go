@@ -0,0 +1,165 @@
+package v1alpha2
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+// ConvertTo converts this Component to the Hub version (v1alpha1).
+func (c *Component) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1alpha1.Component)
+	if !ok {
+		return errors.New("expected to to convert to *v1alpha1.Component")
+	}
+
+	dst.ObjectMeta = c.ObjectMeta
+	dst.Scopes = c.Scopes
+	dst.Auth.SecretStore = c.Auth.SecretStore
+	dst.Status.ObservedGeneration = c.Status.ObservedGeneration
+	dst.Status.Conditions = c.Status.Conditions
+
+	dst.Spec.Type = c.Spec.Type
+	dst.Spec.Version = c.Spec.Version
+	dst.Spec.IgnoreErrors = c.Spec.IgnoreErrors
+	dst.Spec.InitTimeout = c.Spec.InitTimeout
+	dst.Spec.DependsOn = c.Spec.DependsOn
+
+	// Metadata is emitted sorted by name so repeated conversions are stable.
+	names := make([]string, 0, len(c.Spec.Metadata))
+	for name := range c.Spec.Metadata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dst.Spec.Metadata = make([]v1alpha1.MetadataItem, 0, len(names))
+	for _, name := range names {
+		value := c.Spec.Metadata[name]
+		raw, err := value.raw()
+		if err != nil {
+			return fmt.Errorf("metadata %q: %w", name, err)
+		}
+		item := v1alpha1.MetadataItem{
+			Name:  name,
+			Value: v1alpha1.DynamicValue{JSON: v1.JSON{Raw: raw}},
+		}
+		if value.SecretKeyRef != nil {
+			item.SecretKeyRef = v1alpha1.SecretKeyRef{
+				Name: value.SecretKeyRef.Name,
+				Key:  value.SecretKeyRef.Key,
+			}
+		}
+		dst.Spec.Metadata = append(dst.Spec.Metadata, item)
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha1) to this version.
+func (c *Component) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1alpha1.Component)
+	if !ok {
+		return errors.New("expected to to convert from *v1alpha1.Component")
+	}
+
+	c.ObjectMeta = src.ObjectMeta
+	c.Scopes = src.Scopes
+	c.Auth.SecretStore = src.Auth.SecretStore
+	c.Status.ObservedGeneration = src.Status.ObservedGeneration
+	c.Status.Conditions = src.Status.Conditions
+
+	c.Spec.Type = src.Spec.Type
+	c.Spec.Version = src.Spec.Version
+	c.Spec.IgnoreErrors = src.Spec.IgnoreErrors
+	c.Spec.InitTimeout = src.Spec.InitTimeout
+	c.Spec.DependsOn = src.Spec.DependsOn
+
+	c.Spec.Metadata = nil
+	if len(src.Spec.Metadata) > 0 {
+		c.Spec.Metadata = make(map[string]MetadataValue, len(src.Spec.Metadata))
+	}
+	for _, item := range src.Spec.Metadata {
+		value := metadataValueFromRaw(item.Value.Raw)
+		if item.SecretKeyRef.Name != "" {
+			value.SecretKeyRef = &SecretKeyRef{
+				Name: item.SecretKeyRef.Name,
+				Key:  item.SecretKeyRef.Key,
+			}
+		}
+		c.Spec.Metadata[item.Name] = value
+	}
+
+	return nil
+}
+
+// raw returns the JSON serialized form of the inline value, or nil if no inline value is set.
+func (m MetadataValue) raw() ([]byte, error) {
+	switch {
+	case m.String != nil:
+		return json.Marshal(*m.String)
+	case m.Int != nil:
+		return []byte(strconv.FormatInt(*m.Int, 10)), nil
+	case m.Bool != nil:
+		return []byte(strconv.FormatBool(*m.Bool)), nil
+	case m.JSON != nil:
+		return append([]byte(nil), m.JSON.Raw...), nil
+	}
+	return nil, nil
+}
+
+// metadataValueFromRaw maps a v1alpha1 dynamic value to the matching typed field.
+// Values which are not valid JSON are kept as strings, the same way DynamicValue.String treats them.
+func metadataValueFromRaw(raw []byte) MetadataValue {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return MetadataValue{}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil || decoder.More() {
+		s := string(trimmed)
+		return MetadataValue{String: &s}
+	}
+
+	switch v := decoded.(type) {
+	case string:
+		return MetadataValue{String: &v}
+	case bool:
+		return MetadataValue{Bool: &v}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return MetadataValue{Int: &i}
+		}
+	}
+	return MetadataValue{JSON: &v1.JSON{Raw: append([]byte(nil), trimmed...)}}
+}
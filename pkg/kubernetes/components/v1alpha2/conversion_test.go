@@ -0,0 +1,97 @@
+package v1alpha2_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+	"github.com/bhojpur/application/pkg/kubernetes/components/v1alpha2"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestConversion(t *testing.T) {
+	// Test converting to and from v1alpha1
+	port := int64(6379)
+	enableTLS := true
+	componentV2 := v1alpha2.Component{
+		ObjectMeta: metav1.ObjectMeta{Name: "statestore", Namespace: "default"},
+		Scopes:     []string{"app1", "app2"},
+		Auth:       v1alpha2.Auth{SecretStore: "kubernetes"},
+		Spec: v1alpha2.ComponentSpec{
+			Type:      "state.redis",
+			Version:   "v1",
+			DependsOn: []string{"secrets"},
+			Metadata: map[string]v1alpha2.MetadataValue{
+				"redisHost":  {String: strPtr("localhost")},
+				"redisPort":  {Int: &port},
+				"enableTLS":  {Bool: &enableTLS},
+				"hosts":      {JSON: &v1.JSON{Raw: []byte(`["a","b"]`)}},
+				"redisToken": {SecretKeyRef: &v1alpha2.SecretKeyRef{Name: "redis", Key: "token"}},
+			},
+		},
+	}
+
+	var componentV1 v1alpha1.Component
+	err := componentV2.ConvertTo(&componentV1)
+	require.NoError(t, err)
+
+	require.Len(t, componentV1.Spec.Metadata, 5)
+	assert.Equal(t, "enableTLS", componentV1.Spec.Metadata[0].Name)
+	assert.Equal(t, "true", string(componentV1.Spec.Metadata[0].Value.Raw))
+	assert.Equal(t, "localhost", componentV1.Spec.Metadata[2].Value.String())
+	assert.Equal(t, "6379", componentV1.Spec.Metadata[3].Value.String())
+	assert.Equal(t, "redis", componentV1.Spec.Metadata[4].SecretKeyRef.Name)
+
+	var actual v1alpha2.Component
+	err = actual.ConvertFrom(&componentV1)
+	require.NoError(t, err)
+
+	assert.Equal(t, &componentV2, &actual)
+}
+
+func TestConversionFromDynamicValues(t *testing.T) {
+	componentV1 := v1alpha1.Component{
+		Spec: v1alpha1.ComponentSpec{
+			Type: "pubsub.kafka",
+			Metadata: []v1alpha1.MetadataItem{
+				{Name: "quoted", Value: v1alpha1.DynamicValue{JSON: v1.JSON{Raw: []byte(`"42"`)}}},
+				{Name: "float", Value: v1alpha1.DynamicValue{JSON: v1.JSON{Raw: []byte(`1.5`)}}},
+				{Name: "plain", Value: v1alpha1.DynamicValue{JSON: v1.JSON{Raw: []byte(`not json`)}}},
+				{Name: "empty"},
+			},
+		},
+	}
+
+	var actual v1alpha2.Component
+	require.NoError(t, actual.ConvertFrom(&componentV1))
+
+	assert.Equal(t, "42", *actual.Spec.Metadata["quoted"].String)
+	assert.Equal(t, "1.5", string(actual.Spec.Metadata["float"].JSON.Raw))
+	assert.Equal(t, "not json", *actual.Spec.Metadata["plain"].String)
+	assert.Equal(t, v1alpha2.MetadataValue{}, actual.Spec.Metadata["empty"])
+}
@@ -0,0 +1,108 @@
+package v1alpha2
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:noStatus
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// Component describes an Bhojpur Application runtime component type.
+type Component struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// +optional
+	Spec ComponentSpec `json:"spec,omitempty"`
+	// +optional
+	Auth `json:"auth,omitempty"`
+	// +optional
+	Scopes []string `json:"scopes,omitempty"`
+	// +optional
+	Status ComponentStatus `json:"status,omitempty"`
+}
+
+// ComponentSpec is the spec for a component.
+type ComponentSpec struct {
+	Type    string `json:"type"`
+	Version string `json:"version"`
+	// +optional
+	IgnoreErrors bool `json:"ignoreErrors"`
+	// Metadata holds the configuration of the component keyed by name.
+	// +optional
+	Metadata map[string]MetadataValue `json:"metadata,omitempty"`
+	// +optional
+	InitTimeout string `json:"initTimeout"`
+	// DependsOn lists the names of the components which must be ready before this component is initialized.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// ComponentStatus is the observed state of a component.
+type ComponentStatus struct {
+	// ObservedGeneration is the generation of the spec the status was reported for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// MetadataValue is a typed metadata value. Exactly one of the fields is expected to be set.
+type MetadataValue struct {
+	// +optional
+	String *string `json:"string,omitempty"`
+	// +optional
+	Int *int64 `json:"int,omitempty"`
+	// +optional
+	Bool *bool `json:"bool,omitempty"`
+	// JSON holds values which are neither a string, an integer nor a boolean, such as lists and objects.
+	// +optional
+	JSON *v1.JSON `json:"json,omitempty"`
+	// +optional
+	SecretKeyRef *SecretKeyRef `json:"secretKeyRef,omitempty"`
+}
+
+// SecretKeyRef is a reference to a secret holding the value for the metadata item.
+// Name is the secret name, and key is the field in the secret.
+type SecretKeyRef struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// Auth represents authentication details for the component.
+type Auth struct {
+	SecretStore string `json:"secretStore"`
+}
+
+// +kubebuilder:object:root=true
+
+// ComponentList is a list of Bhojpur Application runtime components.
+type ComponentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Component `json:"items"`
+}
@@ -47,7 +47,7 @@ func TestComponents(t *testing.T) {
 			name:           "List one config",
 			configName:     "",
 			outputFormat:   "",
-			expectedOutput: "  NAME       TYPE         VERSION  SCOPES  CREATED              AGE  \n  appConfig  state.redis  v1               " + formattedNow + "  0s   \n",
+			expectedOutput: "  NAME       TYPE         VERSION  SCOPES  STATUS   CREATED              AGE  \n  appConfig  state.redis  v1               Unknown  " + formattedNow + "  0s   \n",
 			errString:      "",
 			errorExpected:  false,
 			k8sConfig: []v1alpha1.Component{
@@ -76,7 +76,7 @@ func TestComponents(t *testing.T) {
 			name:           "Filters out appsystem",
 			configName:     "",
 			outputFormat:   "",
-			expectedOutput: "  NAME       TYPE         VERSION  SCOPES  CREATED              AGE  \n  appConfig  state.redis  v1               " + formattedNow + "  0s   \n",
+			expectedOutput: "  NAME       TYPE         VERSION  SCOPES  STATUS   CREATED              AGE  \n  appConfig  state.redis  v1               Unknown  " + formattedNow + "  0s   \n",
 			errString:      "",
 			errorExpected:  false,
 			k8sConfig: []v1alpha1.Component{
@@ -103,7 +103,7 @@ func TestComponents(t *testing.T) {
 			name:           "Name does match",
 			configName:     "appConfig",
 			outputFormat:   "list",
-			expectedOutput: "  NAME       TYPE         VERSION  SCOPES  CREATED              AGE  \n  appConfig  state.redis  v1               " + formattedNow + "  0s   \n",
+			expectedOutput: "  NAME       TYPE         VERSION  SCOPES  STATUS   CREATED              AGE  \n  appConfig  state.redis  v1               Unknown  " + formattedNow + "  0s   \n",
 			errString:      "",
 			errorExpected:  false,
 			k8sConfig: []v1alpha1.Component{
@@ -123,7 +123,7 @@ func TestComponents(t *testing.T) {
 			name:           "Name does not match",
 			configName:     "appConfig",
 			outputFormat:   "list",
-			expectedOutput: "  NAME  TYPE  VERSION  SCOPES  CREATED  AGE  \n",
+			expectedOutput: "  NAME  TYPE  VERSION  SCOPES  STATUS  CREATED  AGE  \n",
 			errString:      "",
 			errorExpected:  false,
 			k8sConfig: []v1alpha1.Component{
@@ -239,6 +239,58 @@ func TestComponents(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:           "Wide one config",
+			configName:     "",
+			outputFormat:   "wide",
+			expectedOutput: "  NAMESPACE  NAME       TYPE         VERSION  SCOPES  DEPENDS-ON  STATUS  MESSAGE  CREATED              AGE  \n  default    appConfig  state.redis  v1       <none>  statestore  Ready   <none>   " + formattedNow + "  0s   \n",
+			errString:      "",
+			errorExpected:  false,
+			k8sConfig: []v1alpha1.Component{
+				{
+					ObjectMeta: meta_v1.ObjectMeta{
+						Name:              "appConfig",
+						Namespace:         "default",
+						CreationTimestamp: now,
+					},
+					Spec: v1alpha1.ComponentSpec{
+						Type:      "state.redis",
+						Version:   "v1",
+						DependsOn: []string{"statestore"},
+					},
+					Status: v1alpha1.ComponentStatus{
+						Conditions: []meta_v1.Condition{
+							{
+								Type:   v1alpha1.ConditionDependenciesReady,
+								Status: meta_v1.ConditionTrue,
+								Reason: "DependenciesReady",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:           "Custom columns",
+			configName:     "",
+			outputFormat:   "custom-columns=NAME:.metadata.name,TYPE:.spec.type,DEPENDS:.spec.dependsOn[*]",
+			expectedOutput: "  NAME       TYPE         DEPENDS  \n  appConfig  state.redis  a,b      \n",
+			errString:      "",
+			errorExpected:  false,
+			k8sConfig: []v1alpha1.Component{
+				{
+					ObjectMeta: meta_v1.ObjectMeta{
+						Name:              "appConfig",
+						CreationTimestamp: now,
+					},
+					Spec: v1alpha1.ComponentSpec{
+						Type:      "state.redis",
+						Version:   "v1",
+						DependsOn: []string{"a", "b"},
+					},
+				},
+			},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
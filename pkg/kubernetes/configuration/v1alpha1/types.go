@@ -60,6 +60,8 @@ type ConfigurationSpec struct {
 	Features []FeatureSpec `json:"features,omitempty"`
 	// +optional
 	APISpec APISpec `json:"api,omitempty"`
+	// +optional
+	QuotaSpec QuotaSpec `json:"quotas,omitempty"`
 }
 
 // APISpec describes the configuration for Bhojpur Application APIs.
@@ -143,6 +145,12 @@ type ZipkinSpec struct {
 // MetricSpec defines metrics configuration.
 type MetricSpec struct {
 	Enabled bool `json:"enabled"`
+	// HighCardinalityLabels enables labels with unbounded values, such as record IDs and raw paths.
+	// +optional
+	HighCardinalityLabels bool `json:"highCardinalityLabels,omitempty"`
+	// Modules enables or disables the metric groups of modules, such as resource, roles and operator.
+	// +optional
+	Modules map[string]bool `json:"modules,omitempty"`
 }
 
 // AppPolicySpec defines the policy data structure for each app.
@@ -176,6 +184,30 @@ type AccessControlSpec struct {
 	AppPolicies []AppPolicySpec `json:"policies" yaml:"policies"`
 }
 
+// QuotaSpec defines the limits of the objects of a namespace, a zero or missing limit is unlimited.
+type QuotaSpec struct {
+	// MaxComponentsPerType caps the number of components of a type in a namespace, keyed by component type
+	// like "state.redis". The "*" key applies to the types without their own limit.
+	// +optional
+	MaxComponentsPerType map[string]int `json:"maxComponentsPerType,omitempty" yaml:"maxComponentsPerType,omitempty"`
+	// MaxActorTypes caps the number of actor types hosted by an app.
+	// +optional
+	MaxActorTypes int `json:"maxActorTypes,omitempty" yaml:"maxActorTypes,omitempty"`
+	// MaxSubscriptions caps the number of subscriptions in a namespace.
+	// +optional
+	MaxSubscriptions int `json:"maxSubscriptions,omitempty" yaml:"maxSubscriptions,omitempty"`
+	// Enforcement is either "deny" to reject the objects over quota, or "mark" to accept them and report
+	// the quota in their status conditions. Defaults to "deny".
+	// +optional
+	Enforcement string `json:"enforcement,omitempty" yaml:"enforcement,omitempty"`
+}
+
+// Quota enforcement modes
+const (
+	QuotaEnforcementDeny = "deny"
+	QuotaEnforcementMark = "mark"
+)
+
 // FeatureSpec defines the features that are enabled/disabled.
 type FeatureSpec struct {
 	Name    string `json:"name" yaml:"name"`
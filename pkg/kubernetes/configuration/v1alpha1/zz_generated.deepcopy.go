@@ -191,7 +191,7 @@ func (in *ConfigurationSpec) DeepCopyInto(out *ConfigurationSpec) {
 	*out = *in
 	in.HTTPPipelineSpec.DeepCopyInto(&out.HTTPPipelineSpec)
 	out.TracingSpec = in.TracingSpec
-	out.MetricSpec = in.MetricSpec
+	in.MetricSpec.DeepCopyInto(&out.MetricSpec)
 	out.MTLSSpec = in.MTLSSpec
 	in.Secrets.DeepCopyInto(&out.Secrets)
 	in.AccessControlSpec.DeepCopyInto(&out.AccessControlSpec)
@@ -202,6 +202,7 @@ func (in *ConfigurationSpec) DeepCopyInto(out *ConfigurationSpec) {
 		copy(*out, *in)
 	}
 	in.APISpec.DeepCopyInto(&out.APISpec)
+	in.QuotaSpec.DeepCopyInto(&out.QuotaSpec)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigurationSpec.
@@ -279,6 +280,13 @@ func (in *MTLSSpec) DeepCopy() *MTLSSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetricSpec) DeepCopyInto(out *MetricSpec) {
 	*out = *in
+	if in.Modules != nil {
+		in, out := &in.Modules, &out.Modules
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricSpec.
@@ -329,6 +337,28 @@ func (in *PipelineSpec) DeepCopy() *PipelineSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaSpec) DeepCopyInto(out *QuotaSpec) {
+	*out = *in
+	if in.MaxComponentsPerType != nil {
+		in, out := &in.MaxComponentsPerType, &out.MaxComponentsPerType
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaSpec.
+func (in *QuotaSpec) DeepCopy() *QuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretsScope) DeepCopyInto(out *SecretsScope) {
 	*out = *in
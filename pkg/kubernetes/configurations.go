@@ -28,7 +28,10 @@ import (
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 
+	listers "github.com/bhojpur/application/pkg/client/listers/configuration/v1alpha1"
 	v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/configuration/v1alpha1"
 	"github.com/bhojpur/application/pkg/utils"
 )
@@ -69,6 +72,25 @@ func PrintConfigurations(name, outputFormat string) error {
 	}, name, outputFormat)
 }
 
+// PrintConfigurationsFromLister writes the Bhojpur Application configurations held by the
+// lister cache, avoiding a round trip to the API server.
+func PrintConfigurationsFromLister(writer io.Writer, lister listers.ConfigurationLister, name, outputFormat string) error {
+	return writeConfigurations(writer, func() (*v1alpha1.ConfigurationList, error) {
+		items, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+
+		list := &v1alpha1.ConfigurationList{
+			Items: make([]v1alpha1.Configuration, 0, len(items)),
+		}
+		for _, item := range items {
+			list.Items = append(list.Items, *item.DeepCopy())
+		}
+		return list, nil
+	}, name, outputFormat)
+}
+
 func writeConfigurations(writer io.Writer, getConfigFunc func() (*v1alpha1.ConfigurationList, error), name, outputFormat string) error {
 	confs, err := getConfigFunc()
 	if err != nil {
@@ -92,8 +114,18 @@ func writeConfigurations(writer io.Writer, getConfigFunc func() (*v1alpha1.Confi
 		}
 	}
 
-	if outputFormat == "" || outputFormat == "list" {
+	switch {
+	case isListOutput(outputFormat):
 		return printConfigurationList(writer, filtered)
+	case outputFormat == wideOutputFormat:
+		printConfigurationWideList(writer, filtered)
+		return nil
+	case isCustomColumnsOutput(outputFormat):
+		objects := make([]runtime.Object, 0, len(filtered))
+		for i := range filtered {
+			objects = append(objects, &filtered[i])
+		}
+		return writeCustomColumns(writer, outputFormat, objects)
 	}
 
 	return utils.PrintDetail(writer, outputFormat, filteredSpecs)
@@ -114,6 +146,24 @@ func printConfigurationList(writer io.Writer, list []v1alpha1.Configuration) err
 	return utils.MarshalAndWriteTable(writer, co)
 }
 
+func printConfigurationWideList(writer io.Writer, list []v1alpha1.Configuration) {
+	header := []string{"NAMESPACE", "NAME", "TRACING-ENABLED", "METRICS-ENABLED", "MTLS-ENABLED", "CREATED", "AGE"}
+	rows := make([][]string, 0, len(list))
+	for _, c := range list {
+		rows = append(rows, []string{
+			c.GetNamespace(),
+			c.GetName(),
+			strconv.FormatBool(tracingEnabled(c.Spec.TracingSpec)),
+			strconv.FormatBool(c.Spec.MetricSpec.Enabled),
+			strconv.FormatBool(c.Spec.MTLSSpec.Enabled),
+			c.CreationTimestamp.Format("2006-01-02 15:04.05"),
+			utils.GetAge(c.CreationTimestamp.Time),
+		})
+	}
+
+	utils.WriteRows(writer, header, rows)
+}
+
 func tracingEnabled(spec v1alpha1.TracingSpec) bool {
 	sr, err := strconv.ParseFloat(spec.SamplingRate, 32)
 	if err != nil {
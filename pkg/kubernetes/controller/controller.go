@@ -0,0 +1,243 @@
+package controller
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.kubernetes.controller")
+
+// Request identifies the object to reconcile.
+type Request struct {
+	Namespace string
+	Name      string
+}
+
+// String returns the key of the request, "namespace/name".
+func (r Request) String() string {
+	if r.Namespace == "" {
+		return r.Name
+	}
+	return r.Namespace + "/" + r.Name
+}
+
+// Result tells the controller whether to reconcile the request again.
+type Result struct {
+	// Requeue reconciles the request again, with the rate limiting backoff.
+	Requeue bool
+	// RequeueAfter reconciles the request again after the duration, like to poll an external state.
+	RequeueAfter time.Duration
+}
+
+// Reconciler converges the actual state of an object to its desired state. It is called with the
+// requests of the objects which changed, and must tolerate objects which were deleted since.
+type Reconciler interface {
+	Reconcile(ctx context.Context, request Request) (Result, error)
+}
+
+// ReconcilerFunc is a function implementing Reconciler.
+type ReconcilerFunc func(ctx context.Context, request Request) (Result, error)
+
+// Reconcile implements Reconciler.
+func (fc ReconcilerFunc) Reconcile(ctx context.Context, request Request) (Result, error) {
+	return fc(ctx, request)
+}
+
+// Options configures a controller.
+type Options struct {
+	// Workers is the number of requests reconciled concurrently, 1 by default.
+	Workers int
+	// MaxRetries is the number of retries of a failing request before it is dropped, 0 retries forever.
+	MaxRetries int
+	// BaseDelay is the delay before the first retry of a failing request, doubled for every retry,
+	// 5 milliseconds by default.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries, 1000 seconds by default.
+	MaxDelay time.Duration
+	// RateLimiter overrides the rate limiter of the retries built from BaseDelay and MaxDelay.
+	RateLimiter workqueue.RateLimiter
+}
+
+// Controller feeds the events of informers to a rate limited workqueue, and reconciles its requests
+// with a Reconciler, retrying the failures with backoff.
+type Controller struct {
+	name       string
+	reconciler Reconciler
+	options    Options
+	queue      workqueue.RateLimitingInterface
+	synced     []cache.InformerSynced
+	lock       sync.Mutex
+	started    bool
+}
+
+// New returns a controller reconciling requests with reconciler.
+func New(name string, reconciler Reconciler, options Options) *Controller {
+	if options.Workers <= 0 {
+		options.Workers = 1
+	}
+	if options.BaseDelay <= 0 {
+		options.BaseDelay = 5 * time.Millisecond
+	}
+	if options.MaxDelay <= 0 {
+		options.MaxDelay = 1000 * time.Second
+	}
+
+	rateLimiter := options.RateLimiter
+	if rateLimiter == nil {
+		rateLimiter = workqueue.NewItemExponentialFailureRateLimiter(options.BaseDelay, options.MaxDelay)
+	}
+
+	return &Controller{
+		name:       name,
+		reconciler: reconciler,
+		options:    options,
+		queue:      workqueue.NewNamedRateLimitingQueue(rateLimiter, name),
+	}
+}
+
+// Watch enqueues a request for the objects added, updated or deleted in the informer. It has to be called
+// before Run, the controller waits for the caches of the informers to be synced before reconciling.
+func (c *Controller) Watch(informer cache.SharedIndexInformer) {
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.Enqueue,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.Enqueue(newObj)
+		},
+		DeleteFunc: c.Enqueue,
+	})
+
+	c.lock.Lock()
+	c.synced = append(c.synced, informer.HasSynced)
+	c.lock.Unlock()
+}
+
+// Enqueue enqueues a request for obj, an object or a tombstone of a deleted object.
+func (c *Controller) Enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		log.Warnf("controller %s failed to get the key of %v: %s", c.name, obj, err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// EnqueueRequest enqueues a request, like for the objects depending on a changed object.
+func (c *Controller) EnqueueRequest(request Request) {
+	c.queue.Add(request.String())
+}
+
+// Run waits for the caches of the informers to be synced, then reconciles the requests with the workers
+// until ctx is done.
+func (c *Controller) Run(ctx context.Context) error {
+	c.lock.Lock()
+	if c.started {
+		c.lock.Unlock()
+		return fmt.Errorf("controller %s is already started", c.name)
+	}
+	c.started = true
+	synced := c.synced
+	c.lock.Unlock()
+
+	defer c.queue.ShutDown()
+
+	log.Infof("starting controller %s", c.name)
+	if !cache.WaitForNamedCacheSync(c.name, ctx.Done(), synced...) {
+		return errors.New("failed to sync the caches of controller " + c.name)
+	}
+
+	var workers sync.WaitGroup
+	for i := 0; i < c.options.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			wait.UntilWithContext(ctx, c.runWorker, time.Second)
+		}()
+	}
+
+	<-ctx.Done()
+	log.Infof("stopping controller %s", c.name)
+	c.queue.ShutDown()
+	workers.Wait()
+	return nil
+}
+
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextItem(ctx) {
+	}
+}
+
+// processNextItem reconciles the next request of the queue, and returns false once the queue is shut down.
+func (c *Controller) processNextItem(ctx context.Context) bool {
+	item, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(item)
+
+	key := item.(string)
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		log.Warnf("controller %s dropped invalid key %s: %s", c.name, key, err)
+		c.queue.Forget(item)
+		return true
+	}
+
+	result, err := c.reconcile(ctx, Request{Namespace: namespace, Name: name})
+	switch {
+	case err != nil:
+		if c.options.MaxRetries > 0 && c.queue.NumRequeues(item) >= c.options.MaxRetries {
+			log.Errorf("controller %s dropped %s after %d retries: %s", c.name, key, c.options.MaxRetries, err)
+			c.queue.Forget(item)
+			return true
+		}
+		log.Warnf("controller %s failed to reconcile %s, retrying: %s", c.name, key, err)
+		c.queue.AddRateLimited(item)
+	case result.RequeueAfter > 0:
+		c.queue.Forget(item)
+		c.queue.AddAfter(item, result.RequeueAfter)
+	case result.Requeue:
+		c.queue.AddRateLimited(item)
+	default:
+		c.queue.Forget(item)
+	}
+	return true
+}
+
+// reconcile calls the reconciler, recovering from its panics
+func (c *Controller) reconcile(ctx context.Context, request Request) (result Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return c.reconciler.Reconcile(ctx, request)
+}
@@ -0,0 +1,99 @@
+package controller_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bhojpur/application/pkg/kubernetes/controller"
+	"github.com/bhojpur/application/pkg/testing/fixtures"
+)
+
+type recorder struct {
+	lock     sync.Mutex
+	requests map[string]int
+}
+
+func (r *recorder) record(request controller.Request) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.requests == nil {
+		r.requests = map[string]int{}
+	}
+	r.requests[request.String()]++
+	return r.requests[request.String()]
+}
+
+func (r *recorder) count(key string) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.requests[key]
+}
+
+func TestController(t *testing.T) {
+	client := fixtures.Clientset(
+		fixtures.Component("statestore").Build(),
+		fixtures.Component("flaky").Build(),
+		fixtures.Component("broken").Build(),
+	)
+	factory := fixtures.Informers(t, client)
+
+	var calls recorder
+	c := controller.New("components", controller.ReconcilerFunc(func(ctx context.Context, request controller.Request) (controller.Result, error) {
+		attempt := calls.record(request)
+		switch request.Name {
+		case "flaky":
+			if attempt < 3 {
+				return controller.Result{}, errors.New("not ready")
+			}
+		case "broken":
+			panic("broken reconciler")
+		}
+		return controller.Result{}, nil
+	}), controller.Options{Workers: 2, MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+	c.Watch(factory.Components().V1alpha1().Components().Informer())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- c.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return calls.count("default/statestore") == 1 && calls.count("default/flaky") == 3 && calls.count("default/broken") == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the failing request is dropped after the retries
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 3, calls.count("default/broken"))
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Error(t, c.Run(context.Background()))
+}
@@ -0,0 +1,165 @@
+package kubernetes
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+
+	scheme "github.com/bhojpur/application/pkg/client/clientset/versioned"
+	informers "github.com/bhojpur/application/pkg/client/informers/externalversions"
+	v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+// ClusterLabel is the label set on federated components to record the cluster they were read from.
+const ClusterLabel = "bhojpur.net/cluster"
+
+// ClusterClient is a Bhojpur Application client bound to a single kubeconfig context.
+type ClusterClient struct {
+	Name   string
+	Client scheme.Interface
+}
+
+// AppClientsForContexts returns a Bhojpur Application client for each of the given kubeconfig contexts.
+func AppClientsForContexts(contexts ...string) ([]ClusterClient, error) {
+	doOnce.Do(func() {
+		flag.Parse()
+	})
+
+	clients := make([]ClusterClient, 0, len(contexts))
+	for _, context := range contexts {
+		config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: *kubeconfig},
+			&clientcmd.ConfigOverrides{CurrentContext: context},
+		).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("error loading kubeconfig context %s: %w", context, err)
+		}
+
+		client, err := scheme.NewForConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("error creating client for kubeconfig context %s: %w", context, err)
+		}
+		clients = append(clients, ClusterClient{Name: context, Client: client})
+	}
+	return clients, nil
+}
+
+// FederatedComponents watches Components across multiple clusters and merges them into a single registry.
+// Clusters are consulted in the order they were configured when a component exists in more than one of them.
+type FederatedComponents struct {
+	clusters  []ClusterClient
+	informers map[string]cache.SharedIndexInformer
+}
+
+// NewFederatedComponents returns a new federated registry for the given clusters, restricted to namespace.
+func NewFederatedComponents(clusters []ClusterClient, namespace string, resync time.Duration) *FederatedComponents {
+	f := &FederatedComponents{
+		clusters:  clusters,
+		informers: make(map[string]cache.SharedIndexInformer, len(clusters)),
+	}
+
+	for _, c := range clusters {
+		factory := informers.NewSharedInformerFactoryWithOptions(c.Client, resync, informers.WithNamespace(namespace))
+		f.informers[c.Name] = factory.Components().V1alpha1().Components().Informer()
+	}
+	return f
+}
+
+// Start runs the informers of all clusters and blocks until their caches are synced or stopCh is closed.
+func (f *FederatedComponents) Start(stopCh <-chan struct{}) error {
+	synced := make([]cache.InformerSynced, 0, len(f.informers))
+	for _, informer := range f.informers {
+		go informer.Run(stopCh)
+		synced = append(synced, informer.HasSynced)
+	}
+
+	if !cache.WaitForCacheSync(stopCh, synced...) {
+		return fmt.Errorf("timed out waiting for federated component caches to sync")
+	}
+	return nil
+}
+
+// Clusters returns the names of the federated clusters.
+func (f *FederatedComponents) Clusters() []string {
+	names := make([]string, 0, len(f.clusters))
+	for _, c := range f.clusters {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// List returns the components of all clusters, each labeled with ClusterLabel.
+func (f *FederatedComponents) List() []v1alpha1.Component {
+	components := []v1alpha1.Component{}
+	for _, c := range f.clusters {
+		items := f.informers[c.Name].GetStore().List()
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].(*v1alpha1.Component).GetName() < items[j].(*v1alpha1.Component).GetName()
+		})
+
+		for _, item := range items {
+			components = append(components, withClusterLabel(item.(*v1alpha1.Component), c.Name))
+		}
+	}
+	return components
+}
+
+// ClusterFor returns the name of the cluster an invocation of the given component should be routed to.
+func (f *FederatedComponents) ClusterFor(namespace, name string) (string, error) {
+	key := name
+	if namespace != "" {
+		key = namespace + "/" + name
+	}
+
+	for _, c := range f.clusters {
+		if _, exists, err := f.informers[c.Name].GetStore().GetByKey(key); err != nil {
+			return "", err
+		} else if exists {
+			return c.Name, nil
+		}
+	}
+	return "", fmt.Errorf("component %s not found in any of the federated clusters", key)
+}
+
+// Client returns the client of the named cluster.
+func (f *FederatedComponents) Client(cluster string) (scheme.Interface, bool) {
+	for _, c := range f.clusters {
+		if c.Name == cluster {
+			return c.Client, true
+		}
+	}
+	return nil, false
+}
+
+func withClusterLabel(component *v1alpha1.Component, cluster string) v1alpha1.Component {
+	c := component.DeepCopy()
+	if c.Labels == nil {
+		c.Labels = map[string]string{}
+	}
+	c.Labels[ClusterLabel] = cluster
+	return *c
+}
@@ -0,0 +1,78 @@
+package kubernetes
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+	"github.com/bhojpur/application/pkg/testing/fixtures"
+)
+
+func TestFederatedComponents(t *testing.T) {
+	newComponent := func(name string) *v1alpha1.Component {
+		return fixtures.Component(name).Type("state.redis", "v1").Build()
+	}
+
+	federated := NewFederatedComponents([]ClusterClient{
+		{Name: "east", Client: fixtures.Clientset(newComponent("statestore"), newComponent("pubsub"))},
+		{Name: "west", Client: fixtures.Clientset(newComponent("pubsub"), newComponent("binding"))},
+	}, "default", time.Minute)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	assert.NoError(t, federated.Start(stopCh))
+
+	t.Run("list merges all clusters with labels", func(t *testing.T) {
+		components := federated.List()
+		assert.Len(t, components, 4)
+		for _, c := range components {
+			assert.NotEmpty(t, c.Labels[ClusterLabel])
+		}
+	})
+
+	t.Run("routes to the cluster hosting the component", func(t *testing.T) {
+		cluster, err := federated.ClusterFor("default", "binding")
+		assert.NoError(t, err)
+		assert.Equal(t, "west", cluster)
+	})
+
+	t.Run("first configured cluster wins", func(t *testing.T) {
+		cluster, err := federated.ClusterFor("default", "pubsub")
+		assert.NoError(t, err)
+		assert.Equal(t, "east", cluster)
+	})
+
+	t.Run("unknown component", func(t *testing.T) {
+		_, err := federated.ClusterFor("default", "unknown")
+		assert.Error(t, err)
+	})
+
+	t.Run("client lookup", func(t *testing.T) {
+		_, ok := federated.Client("east")
+		assert.True(t, ok)
+		_, ok = federated.Client("north")
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,55 @@
+package kubernetes
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	informers "github.com/bhojpur/application/pkg/client/informers/externalversions"
+	"github.com/bhojpur/application/pkg/config/env"
+)
+
+// InformerFilterFromEnv returns the informer filter configured through environment variables.
+func InformerFilterFromEnv() informers.InformerFilter {
+	filter := informers.InformerFilter{
+		LabelSelector: os.Getenv(env.InformerLabelSelector),
+		FieldSelector: os.Getenv(env.InformerFieldSelector),
+	}
+
+	for _, namespace := range strings.Split(os.Getenv(env.InformerNamespaces), ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			filter.Namespaces = append(filter.Namespaces, namespace)
+		}
+	}
+	return filter
+}
+
+// AppInformerFactories returns Bhojpur Application shared informer factories, keyed by namespace,
+// restricted by the informer filter configured through environment variables.
+func AppInformerFactories(defaultResync time.Duration) (map[string]informers.SharedInformerFactory, error) {
+	client, err := AppClient()
+	if err != nil {
+		return nil, err
+	}
+	return informers.NewSharedInformerFactoriesForFilter(client, defaultResync, InformerFilterFromEnv())
+}
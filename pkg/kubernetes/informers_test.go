@@ -0,0 +1,75 @@
+package kubernetes
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bhojpur/application/pkg/client/clientset/versioned/fake"
+	informers "github.com/bhojpur/application/pkg/client/informers/externalversions"
+	"github.com/bhojpur/application/pkg/config/env"
+)
+
+func TestInformerFilterFromEnv(t *testing.T) {
+	t.Setenv(env.InformerNamespaces, "default, production,")
+	t.Setenv(env.InformerLabelSelector, "team=payments")
+	t.Setenv(env.InformerFieldSelector, "")
+
+	filter := InformerFilterFromEnv()
+	assert.Equal(t, []string{"default", "production"}, filter.Namespaces)
+	assert.Equal(t, "team=payments", filter.LabelSelector)
+	assert.NoError(t, filter.Validate())
+
+	options := meta_v1.ListOptions{LabelSelector: "tier=backend"}
+	filter.TweakListOptions()(&options)
+	assert.Equal(t, "tier=backend,team=payments", options.LabelSelector)
+	assert.Equal(t, "", options.FieldSelector)
+}
+
+func TestNewSharedInformerFactoriesForFilter(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	t.Run("one factory per namespace", func(t *testing.T) {
+		factories, err := informers.NewSharedInformerFactoriesForFilter(client, time.Minute, informers.InformerFilter{
+			Namespaces: []string{"default", "production"},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, factories, 2)
+		assert.Contains(t, factories, "production")
+	})
+
+	t.Run("all namespaces", func(t *testing.T) {
+		factories, err := informers.NewSharedInformerFactoriesForFilter(client, time.Minute, informers.InformerFilter{})
+		assert.NoError(t, err)
+		assert.Contains(t, factories, meta_v1.NamespaceAll)
+	})
+
+	t.Run("invalid selector", func(t *testing.T) {
+		_, err := informers.NewSharedInformerFactoriesForFilter(client, time.Minute, informers.InformerFilter{
+			LabelSelector: "team in (",
+		})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,134 @@
+package kubernetes
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/bhojpur/application/pkg/utils"
+)
+
+const (
+	listOutputFormat          = "list"
+	wideOutputFormat          = "wide"
+	customColumnsOutputPrefix = "custom-columns="
+
+	// noneValue is printed for table cells without a value.
+	noneValue = "<none>"
+)
+
+// customColumn is a table column whose cells are evaluated by a JSONPath expression.
+type customColumn struct {
+	header string
+	path   *jsonpath.JSONPath
+}
+
+func isListOutput(outputFormat string) bool {
+	return outputFormat == "" || outputFormat == listOutputFormat
+}
+
+func isCustomColumnsOutput(outputFormat string) bool {
+	return strings.HasPrefix(outputFormat, customColumnsOutputPrefix)
+}
+
+// parseCustomColumns parses a kubectl style "custom-columns=HEADER:.json.path,..." output format.
+func parseCustomColumns(outputFormat string) ([]customColumn, error) {
+	spec := strings.TrimPrefix(outputFormat, customColumnsOutputPrefix)
+	if spec == "" {
+		return nil, fmt.Errorf("custom-columns format specified but no custom columns given")
+	}
+
+	columns := []customColumn{}
+	for _, field := range strings.Split(spec, ",") {
+		parts := strings.SplitN(field, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("unexpected custom-columns spec: %s, expected <header>:<json-path-expr>", field)
+		}
+
+		expr := parts[1]
+		if !strings.HasPrefix(expr, "{") {
+			expr = "{" + expr + "}"
+		}
+		path := jsonpath.New(parts[0]).AllowMissingKeys(true)
+		if err := path.Parse(expr); err != nil {
+			return nil, fmt.Errorf("invalid json path expression %s: %w", parts[1], err)
+		}
+		columns = append(columns, customColumn{header: parts[0], path: path})
+	}
+	return columns, nil
+}
+
+// writeCustomColumns writes a table of the objects with the columns given by the custom-columns output format.
+func writeCustomColumns(writer io.Writer, outputFormat string, objects []runtime.Object) error {
+	columns, err := parseCustomColumns(outputFormat)
+	if err != nil {
+		return err
+	}
+
+	header := make([]string, 0, len(columns))
+	for _, column := range columns {
+		header = append(header, column.header)
+	}
+
+	rows := make([][]string, 0, len(objects))
+	for _, obj := range objects {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+
+		row := make([]string, 0, len(columns))
+		for _, column := range columns {
+			results, err := column.path.FindResults(content)
+			if err != nil {
+				return err
+			}
+			row = append(row, formatResults(results))
+		}
+		rows = append(rows, row)
+	}
+
+	utils.WriteRows(writer, header, rows)
+	return nil
+}
+
+func formatResults(results [][]reflect.Value) string {
+	values := []string{}
+	for _, result := range results {
+		for _, value := range result {
+			values = append(values, fmt.Sprint(value.Interface()))
+		}
+	}
+	return joinOrNone(values)
+}
+
+func joinOrNone(values []string) string {
+	if len(values) == 0 {
+		return noneValue
+	}
+	return strings.Join(values, ",")
+}
@@ -0,0 +1,44 @@
+package kubernetes
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCustomColumns(t *testing.T) {
+	columns, err := parseCustomColumns("custom-columns=NAME:.metadata.name,READY:{.status.conditions[0].status}")
+	assert.NoError(t, err)
+	assert.Len(t, columns, 2)
+	assert.Equal(t, "NAME", columns[0].header)
+	assert.Equal(t, "READY", columns[1].header)
+
+	_, err = parseCustomColumns("custom-columns=")
+	assert.Error(t, err)
+
+	_, err = parseCustomColumns("custom-columns=NAME")
+	assert.EqualError(t, err, "unexpected custom-columns spec: NAME, expected <header>:<json-path-expr>")
+
+	_, err = parseCustomColumns("custom-columns=NAME:.metadata[")
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package kubernetes
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+
+	scheme "github.com/bhojpur/application/pkg/client/clientset/versioned"
+	informers "github.com/bhojpur/application/pkg/client/informers/externalversions"
+	v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+// TenantLabel is the label assigning a component to a tenant, the tenant of unlabeled components is their namespace.
+const TenantLabel = "bhojpur.net/tenant"
+
+// NamespacedComponents watches the Components of a set of namespaces, or of all namespaces matching the
+// selectors of the filter, and partitions them per namespace and tenant.
+type NamespacedComponents struct {
+	informers map[string]cache.SharedIndexInformer
+}
+
+// NewNamespacedComponents returns a registry watching the namespaces of the filter with client, or all namespaces
+// when the filter has none.
+func NewNamespacedComponents(client scheme.Interface, resync time.Duration, filter informers.InformerFilter) (*NamespacedComponents, error) {
+	factories, err := informers.NewSharedInformerFactoriesForFilter(client, resync, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &NamespacedComponents{informers: make(map[string]cache.SharedIndexInformer, len(factories))}
+	for namespace, factory := range factories {
+		n.informers[namespace] = factory.Components().V1alpha1().Components().Informer()
+	}
+	return n, nil
+}
+
+// Start runs the informers of all namespaces and blocks until their caches are synced or stopCh is closed.
+func (n *NamespacedComponents) Start(stopCh <-chan struct{}) error {
+	synced := make([]cache.InformerSynced, 0, len(n.informers))
+	for _, informer := range n.informers {
+		go informer.Run(stopCh)
+		synced = append(synced, informer.HasSynced)
+	}
+
+	if !cache.WaitForCacheSync(stopCh, synced...) {
+		return fmt.Errorf("timed out waiting for namespaced component caches to sync")
+	}
+	return nil
+}
+
+// Informers returns the informers of the watched namespaces, keyed by namespace, the key is empty
+// when all namespaces are watched.
+func (n *NamespacedComponents) Informers() map[string]cache.SharedIndexInformer {
+	return n.informers
+}
+
+// Namespaces returns the namespaces which have components, sorted.
+func (n *NamespacedComponents) Namespaces() []string {
+	seen := map[string]bool{}
+	for _, informer := range n.informers {
+		for _, namespace := range informer.GetIndexer().ListIndexFuncValues(cache.NamespaceIndex) {
+			seen[namespace] = true
+		}
+	}
+
+	namespaces := make([]string, 0, len(seen))
+	for namespace := range seen {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// GetComponentsForNamespace returns the components of the namespace, sorted by name.
+func (n *NamespacedComponents) GetComponentsForNamespace(namespace string) ([]v1alpha1.Component, error) {
+	components := []v1alpha1.Component{}
+	for _, informer := range n.informers {
+		items, err := informer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			components = append(components, *item.(*v1alpha1.Component).DeepCopy())
+		}
+	}
+
+	sortComponents(components)
+	return components, nil
+}
+
+// GetComponentsForTenant returns the components of the tenant, the components labeled with the tenant
+// and the unlabeled components of the namespace named after it, sorted by namespace and name.
+func (n *NamespacedComponents) GetComponentsForTenant(tenant string) []v1alpha1.Component {
+	components := []v1alpha1.Component{}
+	for _, informer := range n.informers {
+		for _, item := range informer.GetStore().List() {
+			component := item.(*v1alpha1.Component)
+			if TenantOf(component) == tenant {
+				components = append(components, *component.DeepCopy())
+			}
+		}
+	}
+
+	sortComponents(components)
+	return components
+}
+
+// TenantOf returns the tenant of a component, its TenantLabel, or its namespace when unlabeled.
+func TenantOf(component *v1alpha1.Component) string {
+	if tenant := component.Labels[TenantLabel]; tenant != "" {
+		return tenant
+	}
+	return component.Namespace
+}
+
+func sortComponents(components []v1alpha1.Component) {
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Namespace != components[j].Namespace {
+			return components[i].Namespace < components[j].Namespace
+		}
+		return components[i].Name < components[j].Name
+	})
+}
@@ -0,0 +1,82 @@
+package kubernetes
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	informers "github.com/bhojpur/application/pkg/client/informers/externalversions"
+	v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+	"github.com/bhojpur/application/pkg/testing/fixtures"
+)
+
+func TestNamespacedComponents(t *testing.T) {
+	client := fixtures.Clientset(
+		fixtures.Component("statestore").Namespace("acme").Build(),
+		fixtures.Component("pubsub").Namespace("acme").Build(),
+		fixtures.Component("statestore").Namespace("globex").Build(),
+		fixtures.Component("shared").Namespace("platform").Label(TenantLabel, "acme").Build(),
+	)
+
+	names := func(t *testing.T, components []v1alpha1.Component) []string {
+		var result []string
+		for _, c := range components {
+			result = append(result, c.Namespace+"/"+c.Name)
+		}
+		return result
+	}
+
+	t.Run("all namespaces", func(t *testing.T) {
+		registry, err := NewNamespacedComponents(client, time.Minute, informers.InformerFilter{})
+		require.NoError(t, err)
+
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		require.NoError(t, registry.Start(stopCh))
+
+		assert.Equal(t, []string{"acme", "globex", "platform"}, registry.Namespaces())
+
+		components, err := registry.GetComponentsForNamespace("acme")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"acme/pubsub", "acme/statestore"}, names(t, components))
+
+		assert.Equal(t, []string{"acme/pubsub", "acme/statestore", "platform/shared"}, names(t, registry.GetComponentsForTenant("acme")))
+	})
+
+	t.Run("set of namespaces", func(t *testing.T) {
+		registry, err := NewNamespacedComponents(client, time.Minute, informers.InformerFilter{Namespaces: []string{"globex", "platform"}})
+		require.NoError(t, err)
+		assert.Len(t, registry.Informers(), 2)
+
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		require.NoError(t, registry.Start(stopCh))
+
+		components, err := registry.GetComponentsForNamespace("acme")
+		require.NoError(t, err)
+		assert.Empty(t, components)
+		assert.Equal(t, []string{"platform/shared"}, names(t, registry.GetComponentsForTenant("acme")))
+	})
+}
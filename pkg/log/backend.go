@@ -0,0 +1,163 @@
+package log
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	stdlog "log"
+	"strconv"
+	"strings"
+
+	"github.com/bhojpur/service/pkg/utils/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// formatFields returns the fields as `key=value` pairs, values with spaces are quoted
+func formatFields(fields []Field) string {
+	var builder strings.Builder
+	for idx, field := range fields {
+		if idx > 0 {
+			builder.WriteByte(' ')
+		}
+		value := fmt.Sprint(field.Value)
+		if strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		builder.WriteString(field.Key)
+		builder.WriteByte('=')
+		builder.WriteString(value)
+	}
+	return builder.String()
+}
+
+// formatMessage returns the message followed by the fields
+func formatMessage(entry *Entry) string {
+	if len(entry.Fields) == 0 {
+		return entry.Message
+	}
+	return entry.Message + " " + formatFields(entry.Fields)
+}
+
+type stdBackend struct {
+	logger *stdlog.Logger
+}
+
+// NewStdBackend returns a backend writing entries to a logger of the standard library, like
+// `level=info scope=app.resource msg="record saved" resource=Product`
+func NewStdBackend(logger *stdlog.Logger) Backend {
+	return &stdBackend{logger: logger}
+}
+
+func (b *stdBackend) Write(entry *Entry) {
+	fields := append([]Field{{Key: "level", Value: entry.Level}, {Key: "scope", Value: entry.Scope}, {Key: "msg", Value: entry.Message}}, entry.Fields...)
+	b.logger.Print(formatFields(fields))
+}
+
+type logrusBackend struct {
+	logger logrus.FieldLogger
+}
+
+// NewLogrusBackend returns a backend writing entries to a logrus logger, fields are logrus fields
+func NewLogrusBackend(logger logrus.FieldLogger) Backend {
+	return &logrusBackend{logger: logger}
+}
+
+func (b *logrusBackend) Write(entry *Entry) {
+	fields := make(logrus.Fields, len(entry.Fields)+1)
+	fields["scope"] = entry.Scope
+	for _, field := range entry.Fields {
+		if err, ok := field.Value.(error); ok {
+			fields[field.Key] = err.Error()
+			continue
+		}
+		fields[field.Key] = field.Value
+	}
+
+	e := b.logger.WithFields(fields)
+	switch entry.Level {
+	case DebugLevel:
+		e.Debug(entry.Message)
+	case InfoLevel:
+		e.Info(entry.Message)
+	case WarnLevel:
+		e.Warn(entry.Message)
+	default:
+		e.Error(entry.Message)
+	}
+}
+
+// SugaredLogger is the logger of zap with loosely typed fields, `*zap.SugaredLogger`
+type SugaredLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+type zapBackend struct {
+	logger SugaredLogger
+}
+
+// NewZapBackend returns a backend writing entries to a zap logger, like `NewZapBackend(zapLogger.Sugar())`
+func NewZapBackend(logger SugaredLogger) Backend {
+	return &zapBackend{logger: logger}
+}
+
+func (b *zapBackend) Write(entry *Entry) {
+	keysAndValues := make([]interface{}, 0, 2*len(entry.Fields)+2)
+	keysAndValues = append(keysAndValues, "scope", entry.Scope)
+	for _, field := range entry.Fields {
+		keysAndValues = append(keysAndValues, field.Key, field.Value)
+	}
+
+	switch entry.Level {
+	case DebugLevel:
+		b.logger.Debugw(entry.Message, keysAndValues...)
+	case InfoLevel:
+		b.logger.Infow(entry.Message, keysAndValues...)
+	case WarnLevel:
+		b.logger.Warnw(entry.Message, keysAndValues...)
+	default:
+		b.logger.Errorw(entry.Message, keysAndValues...)
+	}
+}
+
+type serviceBackend struct{}
+
+// NewServiceBackend returns a backend writing entries to the loggers of Bhojpur service of their scopes, so they are
+// formatted like the other logs of the runtime, fields follow the message
+func NewServiceBackend() Backend {
+	return serviceBackend{}
+}
+
+func (serviceBackend) Write(entry *Entry) {
+	l := logger.NewLogger(entry.Scope)
+	switch entry.Level {
+	case DebugLevel:
+		l.Debug(formatMessage(entry))
+	case InfoLevel:
+		l.Info(formatMessage(entry))
+	case WarnLevel:
+		l.Warn(formatMessage(entry))
+	default:
+		l.Error(formatMessage(entry))
+	}
+}
@@ -0,0 +1,250 @@
+package log
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+)
+
+// Level is the severity of a log entry
+type Level int
+
+const (
+	// DebugLevel is for verbose entries, like each permission check
+	DebugLevel Level = iota
+	// InfoLevel is the default level
+	InfoLevel
+	// WarnLevel is for possible issues
+	WarnLevel
+	// ErrorLevel is for errors
+	ErrorLevel
+)
+
+// String returns the name of the level, like `info`
+func (level Level) String() string {
+	switch level {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int(level))
+}
+
+// ParseLevel returns the level of a name, like `warn`
+func ParseLevel(name string) (Level, error) {
+	for _, level := range []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel} {
+		if strings.EqualFold(name, level.String()) {
+			return level, nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("unknown log level %q", name)
+}
+
+// Keys of the common fields of entries
+const (
+	RequestIDKey = "request_id"
+	ResourceKey  = "resource"
+	ActorKey     = "actor"
+	DurationKey  = "duration"
+	ErrorKey     = "error"
+)
+
+// Field is a key and value of a log entry
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string field
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool returns a bool field
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int returns an int field
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Any returns a field of any value
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// RequestID returns the field of the ID of a request
+func RequestID(id string) Field {
+	return Field{Key: RequestIDKey, Value: id}
+}
+
+// Resource returns the field of the name of a resource
+func Resource(name string) Field {
+	return Field{Key: ResourceKey, Value: name}
+}
+
+// Actor returns the field of the user doing an action
+func Actor(name string) Field {
+	return Field{Key: ActorKey, Value: name}
+}
+
+// Duration returns the field of the duration of an operation
+func Duration(duration time.Duration) Field {
+	return Field{Key: DurationKey, Value: duration}
+}
+
+// Err returns the field of an error
+func Err(err error) Field {
+	return Field{Key: ErrorKey, Value: err}
+}
+
+// Entry is a log entry, written by a backend
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Scope   string
+	Message string
+	Fields  []Field
+}
+
+// Backend writes log entries, see NewStdBackend, NewLogrusBackend and NewZapBackend for adapters of logging libraries
+type Backend interface {
+	Write(entry *Entry)
+}
+
+var (
+	backend Backend = NewServiceBackend()
+	level           = InfoLevel
+	mutex   sync.RWMutex
+)
+
+// SetBackend sets the backend of all loggers, the logger of Bhojpur service by default
+func SetBackend(b Backend) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	backend = b
+}
+
+// SetLevel sets the minimum level of the entries written by all loggers, InfoLevel by default
+func SetLevel(l Level) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	level = l
+}
+
+// Logger is a structured logger of a scope, like `app.resource`
+//
+//	var log = applog.New("app.resource")
+//	log.WithContext(context).Info("record saved", applog.Resource(res.Name), applog.Duration(time.Since(start)))
+type Logger struct {
+	scope  string
+	fields []Field
+}
+
+// New returns the logger of a scope
+func New(scope string) *Logger {
+	return &Logger{scope: scope}
+}
+
+// With returns a logger adding fields to its entries
+func (logger *Logger) With(fields ...Field) *Logger {
+	return &Logger{scope: logger.scope, fields: append(append([]Field{}, logger.fields...), fields...)}
+}
+
+// WithContext returns a logger adding the request ID and actor of an Apps context to its entries, see FromContext
+func (logger *Logger) WithContext(context *appsvr.Context) *Logger {
+	return logger.With(FromContext(context)...)
+}
+
+// Enabled returns whether entries of level are written, to skip building expensive fields
+func (logger *Logger) Enabled(l Level) bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return l >= level
+}
+
+// Debug writes an entry of DebugLevel
+func (logger *Logger) Debug(message string, fields ...Field) {
+	logger.write(DebugLevel, message, fields)
+}
+
+// Info writes an entry of InfoLevel
+func (logger *Logger) Info(message string, fields ...Field) {
+	logger.write(InfoLevel, message, fields)
+}
+
+// Warn writes an entry of WarnLevel
+func (logger *Logger) Warn(message string, fields ...Field) {
+	logger.write(WarnLevel, message, fields)
+}
+
+// Error writes an entry of ErrorLevel
+func (logger *Logger) Error(message string, fields ...Field) {
+	logger.write(ErrorLevel, message, fields)
+}
+
+func (logger *Logger) write(l Level, message string, fields []Field) {
+	mutex.RLock()
+	b, minLevel := backend, level
+	mutex.RUnlock()
+	if l < minLevel || b == nil {
+		return
+	}
+
+	entry := &Entry{Time: time.Now(), Level: l, Scope: logger.scope, Message: message, Fields: fields}
+	if len(logger.fields) > 0 {
+		entry.Fields = append(append([]Field{}, logger.fields...), fields...)
+	}
+	b.Write(entry)
+}
+
+// FromContext returns the fields of an Apps context, the request ID is its trace ID, or the X-Request-Id header of
+// its request, and the actor is the display name of its current user
+func FromContext(context *appsvr.Context) []Field {
+	if context == nil {
+		return nil
+	}
+
+	var fields []Field
+	requestID := context.GetTraceID()
+	if requestID == "" && context.Request != nil {
+		requestID = context.Request.Header.Get("X-Request-Id")
+	}
+	if requestID != "" {
+		fields = append(fields, RequestID(requestID))
+	}
+	if user := context.GetCurrentUser(); user != nil {
+		fields = append(fields, Actor(user.DisplayName()))
+	}
+	return fields
+}
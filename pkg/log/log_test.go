@@ -0,0 +1,115 @@
+package log_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	stdlog "log"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct{}
+
+func (user) DisplayName() string { return "jane" }
+
+func useBackend(t *testing.T, backend applog.Backend, level applog.Level) {
+	applog.SetBackend(backend)
+	applog.SetLevel(level)
+	t.Cleanup(func() {
+		applog.SetBackend(applog.NewServiceBackend())
+		applog.SetLevel(applog.InfoLevel)
+	})
+}
+
+func TestStdBackend(t *testing.T) {
+	var buf bytes.Buffer
+	useBackend(t, applog.NewStdBackend(stdlog.New(&buf, "", 0)), applog.InfoLevel)
+
+	context := &appsvr.Context{Request: httptest.NewRequest("GET", "/", nil), CurrentUser: user{}}
+	context.Request.Header.Set("X-Request-Id", "42")
+	logger := applog.New("app.test").WithContext(context)
+
+	logger.Debug("not written")
+	assert.False(t, logger.Enabled(applog.DebugLevel))
+	logger.Info("record saved", applog.Resource("Product"), applog.Duration(1500*time.Millisecond), applog.Err(errors.New("not found")))
+	assert.Equal(t, "level=info scope=app.test msg=\"record saved\" request_id=42 actor=jane resource=Product duration=1.5s error=\"not found\"\n", buf.String())
+
+	context.SetTraceID("abc")
+	assert.Equal(t, []applog.Field{applog.RequestID("abc"), applog.Actor("jane")}, applog.FromContext(context))
+	assert.Nil(t, applog.FromContext(nil))
+}
+
+func TestLogrusBackend(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	useBackend(t, applog.NewLogrusBackend(logger), applog.DebugLevel)
+
+	applog.New("app.test").With(applog.Resource("Product")).Debug("debug")
+	applog.New("app.test").Error("failed", applog.Err(errors.New("boom")))
+
+	require.Len(t, hook.Entries, 2)
+	assert.Equal(t, logrus.DebugLevel, hook.Entries[0].Level)
+	assert.Equal(t, logrus.Fields{"scope": "app.test", "resource": "Product"}, hook.Entries[0].Data)
+	assert.Equal(t, logrus.ErrorLevel, hook.LastEntry().Level)
+	assert.Equal(t, "boom", hook.LastEntry().Data["error"])
+}
+
+type sugared struct {
+	entries []string
+}
+
+func (s *sugared) write(level, msg string, keysAndValues []interface{}) {
+	s.entries = append(s.entries, fmt.Sprint(level, " ", msg, " ", keysAndValues))
+}
+
+func (s *sugared) Debugw(msg string, kv ...interface{}) { s.write("debug", msg, kv) }
+func (s *sugared) Infow(msg string, kv ...interface{})  { s.write("info", msg, kv) }
+func (s *sugared) Warnw(msg string, kv ...interface{})  { s.write("warn", msg, kv) }
+func (s *sugared) Errorw(msg string, kv ...interface{}) { s.write("error", msg, kv) }
+
+func TestZapBackend(t *testing.T) {
+	s := &sugared{}
+	useBackend(t, applog.NewZapBackend(s), applog.WarnLevel)
+
+	applog.New("app.test").Info("skipped")
+	applog.New("app.test").Warn("slow", applog.Int("count", 2))
+	assert.Equal(t, []string{"warn slow [scope app.test count 2]"}, s.entries)
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := applog.ParseLevel("WARN")
+	assert.NoError(t, err)
+	assert.Equal(t, applog.WarnLevel, level)
+
+	_, err = applog.ParseLevel("verbose")
+	assert.Error(t, err)
+}
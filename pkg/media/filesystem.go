@@ -0,0 +1,159 @@
+package media
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/application/pkg/utils"
+)
+
+// ErrInvalidSignature is returned when a signed URL is tampered with or expired
+var ErrInvalidSignature = errors.New("media: invalid signature")
+
+// FileSystem is a Storage in a local directory, its files are served by it as an http.Handler at BaseURL
+//
+//	storage := &media.FileSystem{Root: "/var/lib/app/media", BaseURL: "/media", SigningKey: key}
+//	mux.Handle("/media/", http.StripPrefix("/media", storage))
+type FileSystem struct {
+	Root    string
+	BaseURL string
+	// SigningKey signs URLs with an expiry, files are served without a signature if it is empty
+	SigningKey []byte
+}
+
+var _ Storage = &FileSystem{}
+
+func (fs *FileSystem) fullPath(name string) (string, error) {
+	return utils.SafeJoinWithOptions(utils.SafeJoinOptions{ResolveSymlinks: true}, fs.Root, filepath.FromSlash(strings.TrimPrefix(name, "/")))
+}
+
+// Put writes the file to path, creating its directories
+func (fs *FileSystem) Put(ctx context.Context, name string, r io.Reader, contentType string) error {
+	fullPath, err := fs.fullPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(fullPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(fullPath)
+		return err
+	}
+	return f.Close()
+}
+
+// Get opens the file of path
+func (fs *FileSystem) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	fullPath, err := fs.fullPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(fullPath)
+}
+
+// Delete deletes the file of path, it is not an error if it doesn't exist
+func (fs *FileSystem) Delete(ctx context.Context, name string) error {
+	fullPath, err := fs.fullPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// URL returns the URL of the file at BaseURL, signed with SigningKey to expire after ttl if it is positive
+func (fs *FileSystem) URL(ctx context.Context, name string, ttl time.Duration) (string, error) {
+	u := strings.TrimSuffix(fs.BaseURL, "/") + path.Join("/", name)
+	if ttl <= 0 || len(fs.SigningKey) == 0 {
+		return u, nil
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	return u + "?" + url.Values{"expires": {expires}, "signature": {fs.sign(path.Join("/", name), expires)}}.Encode(), nil
+}
+
+func (fs *FileSystem) sign(name, expires string) string {
+	mac := hmac.New(sha256.New, fs.SigningKey)
+	mac.Write([]byte(name + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of the URL of a file, which expires at the unix time expires
+func (fs *FileSystem) Verify(name, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt || !hmac.Equal([]byte(signature), []byte(fs.sign(path.Join("/", name), expires))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ServeHTTP serves the file of the path of the request, URLs have to be signed if SigningKey is set
+func (fs *FileSystem) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if len(fs.SigningKey) > 0 {
+		query := req.URL.Query()
+		if err := fs.Verify(req.URL.Path, query.Get("expires"), query.Get("signature")); err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+	}
+
+	fullPath, err := fs.fullPath(req.URL.Path)
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+	f, err := os.Open(fullPath)
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, req)
+		return
+	}
+	http.ServeContent(w, req, info.Name(), info.ModTime(), f)
+}
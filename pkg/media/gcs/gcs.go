@@ -0,0 +1,97 @@
+package gcs
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/bhojpur/application/pkg/media"
+)
+
+// Storage is a media.Storage in a Google Cloud Storage bucket
+//
+//	client, err := storage.NewClient(ctx)
+//	mediaStorage := gcs.New(client, "media")
+type Storage struct {
+	client *storage.Client
+	Bucket string
+	// Prefix is prepended to the paths of files
+	Prefix string
+	// GoogleAccessID and PrivateKey of a service account sign URLs, see storage.SignedURLOptions
+	GoogleAccessID string
+	PrivateKey     []byte
+}
+
+var _ media.Storage = &Storage{}
+
+// New initialize a storage in the bucket of a client
+func New(client *storage.Client, bucket string) *Storage {
+	return &Storage{client: client, Bucket: bucket}
+}
+
+func (s *Storage) object(name string) *storage.ObjectHandle {
+	return s.client.Bucket(s.Bucket).Object(path.Join(s.Prefix, name))
+}
+
+// Put uploads the file to path
+func (s *Storage) Put(ctx context.Context, name string, r io.Reader, contentType string) error {
+	w := s.object(name).NewWriter(ctx)
+	w.ContentType = contentType
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// Get downloads the file of path
+func (s *Storage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return s.object(name).NewReader(ctx)
+}
+
+// Delete deletes the file of path, it is not an error if it doesn't exist
+func (s *Storage) Delete(ctx context.Context, name string) error {
+	if err := s.object(name).Delete(ctx); err != nil && err != storage.ErrObjectNotExist {
+		return err
+	}
+	return nil
+}
+
+// URL returns the URL of the file, signed with the service account to expire after ttl if it is positive
+func (s *Storage) URL(ctx context.Context, name string, ttl time.Duration) (string, error) {
+	key := path.Join(s.Prefix, name)
+	if ttl > 0 {
+		return storage.SignedURL(s.Bucket, key, &storage.SignedURLOptions{
+			GoogleAccessID: s.GoogleAccessID,
+			PrivateKey:     s.PrivateKey,
+			Method:         http.MethodGet,
+			Expires:        time.Now().Add(ttl),
+		})
+	}
+	return (&url.URL{Scheme: "https", Host: "storage.googleapis.com", Path: "/" + s.Bucket + "/" + key}).String(), nil
+}
@@ -0,0 +1,212 @@
+package media
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"image"
+	"image/color"
+	_ "image/gif" // decodes GIF images
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"mime/multipart"
+	"path"
+)
+
+// Size is a named variant of image fields, see Variants. Images are resized to fit in Width and Height, keeping their
+// ratio, or cropped to fill them around their focal point with Crop. A zero dimension is unbounded, images are never
+// enlarged
+type Size struct {
+	Width  int
+	Height int
+	Crop   bool
+}
+
+// FocalPoint is the point of an image kept by crops, relative to its size from 0 to 1
+type FocalPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Image is the type of image upload fields, a File with a focal point and variants. Besides uploads, it scans the
+// JSON of a focal point from meta values, e.g. {"focalPoint":{"x":0.2,"y":0.4}}, which changes the one of the image
+// and regenerates its variants
+type Image struct {
+	File
+	FocalPoint *FocalPoint `json:"focalPoint,omitempty"`
+	// Variants are the generated variants of the image by size name
+	Variants map[string]File `json:"variants,omitempty"`
+}
+
+// Scan decodes uploaded images, focal points and the metadata stored in the database
+func (img *Image) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []*multipart.FileHeader, *multipart.FileHeader, nil:
+		focalPoint := img.FocalPoint
+		*img = Image{}
+		if err := img.File.Scan(v); err != nil {
+			return err
+		}
+		if img.HasUpload() {
+			img.FocalPoint = focalPoint
+		}
+		return nil
+	case []byte:
+		return img.Scan(string(v))
+	case []string:
+		if len(v) > 0 {
+			return img.Scan(v[0])
+		}
+		return nil
+	case string:
+		if v == "" {
+			return nil
+		}
+		var decoded Image
+		if v != "null" {
+			if err := json.Unmarshal([]byte(v), &decoded); err != nil {
+				return err
+			}
+		}
+		if decoded.Path == "" && decoded.FocalPoint != nil {
+			img.FocalPoint, img.Variants = decoded.FocalPoint, nil
+			return nil
+		}
+		*img = decoded
+		return nil
+	}
+	return img.File.Scan(value)
+}
+
+// Value stores the metadata of the image and its variants as JSON, NULL if there is no image
+func (img Image) Value() (driver.Value, error) {
+	if img.Path == "" {
+		return nil, nil
+	}
+	data, err := json.Marshal(img)
+	return string(data), err
+}
+
+// VariantPath returns the path of the variant of an image of path
+func VariantPath(original, size string) string {
+	return path.Join(path.Dir(original), size, path.Base(original))
+}
+
+// Resize decodes a JPEG, PNG or GIF image, and encodes its variant of size around the focal point, centered if nil.
+// JPEG images are encoded as JPEG, others as PNG
+func Resize(r io.Reader, size Size, focalPoint *FocalPoint) ([]byte, string, error) {
+	src, format, err := image.Decode(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if focalPoint == nil {
+		focalPoint = &FocalPoint{X: 0.5, Y: 0.5}
+	}
+
+	var (
+		buf     bytes.Buffer
+		resized = resize(src, size, *focalPoint)
+	)
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), "image/jpeg", err
+	}
+	err = png.Encode(&buf, resized)
+	return buf.Bytes(), "image/png", err
+}
+
+func resize(src image.Image, size Size, focalPoint FocalPoint) image.Image {
+	var (
+		bounds = src.Bounds()
+		sw, sh = float64(bounds.Dx()), float64(bounds.Dy())
+		width  = float64(size.Width)
+		height = float64(size.Height)
+		region = image.Rectangle{Min: bounds.Min, Max: bounds.Max}
+	)
+	if width <= 0 && height <= 0 {
+		return src
+	}
+
+	if size.Crop && width > 0 && height > 0 {
+		// the region of the source with the ratio of the size, around the focal point
+		scale := math.Min(math.Max(width/sw, height/sh), 1)
+		cw, ch := math.Min(sw, width/scale), math.Min(sh, height/scale)
+		x0 := clamp(focalPoint.X*sw-cw/2, 0, sw-cw)
+		y0 := clamp(focalPoint.Y*sh-ch/2, 0, sh-ch)
+		region = image.Rect(bounds.Min.X+int(x0), bounds.Min.Y+int(y0), bounds.Min.X+int(x0+cw), bounds.Min.Y+int(y0+ch))
+		width, height = math.Round(cw*scale), math.Round(ch*scale)
+	} else {
+		scale := 1.0
+		if width > 0 {
+			scale = math.Min(scale, width/sw)
+		}
+		if height > 0 {
+			scale = math.Min(scale, height/sh)
+		}
+		width, height = math.Max(math.Round(sw*scale), 1), math.Max(math.Round(sh*scale), 1)
+	}
+
+	return scaleBox(src, region, int(width), int(height))
+}
+
+func clamp(value, min, max float64) float64 {
+	return math.Max(min, math.Min(value, max))
+}
+
+// scaleBox scales the region of src to width and height, averaging the source pixels of each destination pixel
+func scaleBox(src image.Image, region image.Rectangle, width, height int) image.Image {
+	var (
+		dst = image.NewNRGBA(image.Rect(0, 0, width, height))
+		sx  = float64(region.Dx()) / float64(width)
+		sy  = float64(region.Dy()) / float64(height)
+	)
+	for y := 0; y < height; y++ {
+		y0 := region.Min.Y + int(float64(y)*sy)
+		y1 := maxInt(region.Min.Y+int(float64(y+1)*sy), y0+1)
+		for x := 0; x < width; x++ {
+			x0 := region.Min.X + int(float64(x)*sx)
+			x1 := maxInt(region.Min.X+int(float64(x+1)*sx), x0+1)
+
+			var r, g, b, a, n uint64
+			for py := y0; py < y1 && py < region.Max.Y; py++ {
+				for px := x0; px < x1 && px < region.Max.X; px++ {
+					c := color.NRGBA64Model.Convert(src.At(px, py)).(color.NRGBA64)
+					r, g, b, a, n = r+uint64(c.R), g+uint64(c.G), b+uint64(c.B), a+uint64(c.A), n+1
+				}
+			}
+			if n > 0 {
+				dst.Set(x, y, color.NRGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+			}
+		}
+	}
+	return dst
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
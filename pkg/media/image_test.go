@@ -0,0 +1,132 @@
+package media_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/media"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/worker"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Shoe struct {
+	ID    uint
+	Photo media.Image `orm:"type:text"`
+}
+
+func decodeImage(t *testing.T, data []byte) image.Image {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	return img
+}
+
+func TestVariants(t *testing.T) {
+	db := utils.SQLiteTestDB(t,
+		`CREATE TABLE shoes (id INTEGER PRIMARY KEY AUTOINCREMENT, photo TEXT)`,
+		`CREATE TABLE worker_runs (id INTEGER PRIMARY KEY AUTOINCREMENT, job TEXT, args TEXT, status TEXT,
+		attempts INTEGER, error TEXT, run_at DATETIME, started_at DATETIME, finished_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+	)
+
+	// red on the left half, blue on the right one
+	src := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 20; y++ {
+			if x < 20 {
+				src.Set(x, y, color.NRGBA{R: 255, A: 255})
+			} else {
+				src.Set(x, y, color.NRGBA{B: 255, A: 255})
+			}
+		}
+	}
+	var photo bytes.Buffer
+	require.NoError(t, png.Encode(&photo, src))
+
+	storage := &media.FileSystem{Root: t.TempDir()}
+	w := worker.New(db, worker.Config{})
+	variants := media.NewVariants(db, storage, w)
+	res := resource.New(&Shoe{})
+	media.Enable(res, storage)
+	variants.Declare(res, "Photo", map[string]media.Size{"thumb": {Width: 10, Height: 10, Crop: true}, "small": {Width: 20}})
+	ctx, context := context.Background(), &appsvr.Context{Config: &appsvr.Config{DB: db}}
+
+	shoe := &Shoe{}
+	require.NoError(t, shoe.Photo.Scan(upload(t, "shoe.png", photo.String())))
+	require.NoError(t, res.CallSave(shoe, context))
+	require.NoError(t, w.RunDue(ctx))
+
+	var saved Shoe
+	require.NoError(t, db.First(&saved, shoe.ID).Error)
+	require.Len(t, saved.Photo.Variants, 2)
+	assert.Equal(t, media.VariantPath(saved.Photo.Path, "thumb"), saved.Photo.Variants["thumb"].Path)
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		variants.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+		return recorder
+	}
+	recorder := serve("/small/" + saved.Photo.Path)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "public, max-age=86400", recorder.Header().Get("Cache-Control"))
+	assert.Equal(t, image.Rect(0, 0, 20, 10), decodeImage(t, recorder.Body.Bytes()).Bounds())
+	thumb := decodeImage(t, serve("/thumb/"+saved.Photo.Path).Body.Bytes())
+	assert.Equal(t, image.Rect(0, 0, 10, 10), thumb.Bounds())
+	assert.Equal(t, color.NRGBA{B: 255, A: 255}, color.NRGBAModel.Convert(thumb.At(9, 0)))
+	assert.Equal(t, http.StatusNotFound, serve("/large/"+saved.Photo.Path).Code)
+
+	// changing the focal point generates the variants again
+	require.NoError(t, saved.Photo.Scan(`{"focalPoint":{"x":0,"y":0.5}}`))
+	assert.Nil(t, saved.Photo.Variants)
+	require.NoError(t, res.CallSave(&saved, context))
+	require.NoError(t, w.RunDue(ctx))
+	thumb = decodeImage(t, serve("/thumb/"+saved.Photo.Path).Body.Bytes())
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, color.NRGBAModel.Convert(thumb.At(9, 0)))
+
+	require.NoError(t, db.First(&saved, shoe.ID).Error)
+	assert.Equal(t, &media.FocalPoint{X: 0, Y: 0.5}, saved.Photo.FocalPoint)
+	assert.Len(t, saved.Photo.Variants, 2)
+}
+
+func TestResize(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 30, 60))))
+
+	data, contentType, err := media.Resize(bytes.NewReader(buf.Bytes()), media.Size{Width: 100, Height: 100}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	// images are never enlarged
+	assert.Equal(t, image.Rect(0, 0, 30, 60), decodeImage(t, data).Bounds())
+
+	data, _, err = media.Resize(bytes.NewReader(buf.Bytes()), media.Size{Height: 20}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 10, 20), decodeImage(t, data).Bounds())
+}
@@ -0,0 +1,193 @@
+package media
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	applog "github.com/bhojpur/application/pkg/log"
+)
+
+var log = applog.New("app.media")
+
+// Storage stores the files of media fields
+type Storage interface {
+	Put(ctx context.Context, path string, r io.Reader, contentType string) error
+	Get(ctx context.Context, path string) (io.ReadCloser, error)
+	Delete(ctx context.Context, path string) error
+	// URL returns the URL of a file, signed to expire after ttl if it is positive
+	URL(ctx context.Context, path string, ttl time.Duration) (string, error)
+}
+
+// File is the type of file upload fields, it is stored as the JSON of its metadata. Metas of resources decode
+// uploaded files into it, as it is a sql.Scanner, they are stored when the record is saved, see Enable
+//
+//	type Product struct {
+//		ID     uint
+//		Manual media.File `orm:"type:text"`
+//	}
+type File struct {
+	Path        string `json:"path"`
+	FileName    string `json:"fileName"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType"`
+	// Checksum is the hex encoded SHA-256 of the file
+	Checksum string `json:"checksum"`
+
+	upload *multipart.FileHeader
+}
+
+// Scan decodes uploaded files, and the metadata stored in the database, "null" clears the file
+func (file *File) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []*multipart.FileHeader:
+		if len(v) > 0 {
+			file.setUpload(v[0])
+		}
+	case *multipart.FileHeader:
+		file.setUpload(v)
+	case []byte:
+		return file.Scan(string(v))
+	case []string:
+		if len(v) > 0 {
+			return file.Scan(v[0])
+		}
+	case string:
+		if v == "" {
+			return nil
+		}
+		*file = File{}
+		if v == "null" {
+			return nil
+		}
+		return json.Unmarshal([]byte(v), file)
+	case nil:
+		*file = File{}
+	default:
+		return fmt.Errorf("media: can't scan %T into a file", value)
+	}
+	return nil
+}
+
+func (file *File) setUpload(header *multipart.FileHeader) {
+	*file = File{FileName: header.Filename, upload: header}
+}
+
+// Value stores the metadata of the file as JSON, NULL if there is no file
+func (file File) Value() (driver.Value, error) {
+	if file.Path == "" {
+		return nil, nil
+	}
+	data, err := json.Marshal(file)
+	return string(data), err
+}
+
+// HasUpload returns true if a file was uploaded and not stored yet
+func (file File) HasUpload() bool {
+	return file.upload != nil
+}
+
+// URL returns the URL of the file in storage, signed to expire after ttl if it is positive
+func (file File) URL(ctx context.Context, storage Storage, ttl time.Duration) (string, error) {
+	if file.Path == "" {
+		return "", nil
+	}
+	return storage.URL(ctx, file.Path, ttl)
+}
+
+// Store stores the uploaded file to storage in dir, and records its metadata
+func (file *File) Store(ctx context.Context, storage Storage, dir string) error {
+	if file.upload == nil {
+		return nil
+	}
+
+	f, err := file.upload.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return file.store(ctx, storage, dir, f, file.upload.Header.Get("Content-Type"))
+}
+
+func (file *File) store(ctx context.Context, storage Storage, dir string, r io.Reader, contentType string) error {
+	var sniff [512]byte
+	n, err := io.ReadFull(r, sniff[:])
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(sniff[:n])
+	}
+
+	var (
+		hash    = sha256.New()
+		counter = &countingReader{r: io.TeeReader(io.MultiReader(bytes.NewReader(sniff[:n]), r), hash)}
+		random  = make([]byte, 8)
+	)
+	if _, err := rand.Read(random); err != nil {
+		return err
+	}
+
+	// the random directory keeps the names of uploads, without overwriting each other
+	filePath := path.Join(dir, hex.EncodeToString(random), sanitizeFileName(file.FileName))
+	if err := storage.Put(ctx, filePath, counter, contentType); err != nil {
+		return err
+	}
+
+	*file = File{Path: filePath, FileName: file.FileName, Size: counter.n, ContentType: contentType, Checksum: hex.EncodeToString(hash.Sum(nil))}
+	return nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^\w.\-]+`)
+
+func sanitizeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Trim(unsafeFileNameChars.ReplaceAllString(name, "_"), "._")
+	if name == "" {
+		return "file"
+	}
+	return name
+}
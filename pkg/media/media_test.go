@@ -0,0 +1,120 @@
+package media_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/media"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Product struct {
+	ID     uint
+	Manual media.File `orm:"type:text"`
+}
+
+func upload(t *testing.T, name, content string) []*multipart.FileHeader {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("Manual", name)
+	require.NoError(t, err)
+	part.Write([]byte(content))
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	require.NoError(t, req.ParseMultipartForm(1<<20))
+	return req.MultipartForm.File["Manual"]
+}
+
+func TestMedia(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, manual TEXT)`)
+
+	root := t.TempDir()
+	storage := &media.FileSystem{Root: root, BaseURL: "/media", SigningKey: []byte("secret")}
+	res := resource.New(&Product{})
+	media.Enable(res, storage)
+	ctx, context := context.Background(), &appsvr.Context{Config: &appsvr.Config{DB: db}}
+
+	product := &Product{}
+	require.NoError(t, product.Manual.Scan(upload(t, "../User Guide.txt", "hello")))
+	require.NoError(t, res.CallSave(product, context))
+	first := product.Manual
+	assert.True(t, strings.HasPrefix(first.Path, "products/Manual/"))
+	assert.True(t, strings.HasSuffix(first.Path, "/User_Guide.txt"))
+	assert.Equal(t, int64(5), first.Size)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", first.Checksum)
+
+	var saved Product
+	require.NoError(t, db.First(&saved, product.ID).Error)
+	assert.Equal(t, first, saved.Manual)
+
+	// signed URLs are served until they expire
+	u, err := saved.Manual.URL(ctx, storage, time.Minute)
+	require.NoError(t, err)
+	parsed, err := url.Parse(u)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	http.StripPrefix("/media", storage).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, u, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "hello", recorder.Body.String())
+
+	query := parsed.Query()
+	query.Set("signature", strings.Repeat("0", 64))
+	parsed.RawQuery = query.Encode()
+	recorder = httptest.NewRecorder()
+	http.StripPrefix("/media", storage).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, parsed.String(), nil))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// replaced files and files of deleted records are deleted
+	require.NoError(t, product.Manual.Scan(upload(t, "guide.txt", "world")))
+	require.NoError(t, res.CallSave(product, context))
+	_, err = os.Stat(filepath.Join(root, filepath.FromSlash(first.Path)))
+	assert.True(t, os.IsNotExist(err))
+
+	r, err := storage.Get(ctx, product.Manual.Path)
+	require.NoError(t, err)
+	content, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "world", string(content))
+
+	deleteContext := context.Clone()
+	deleteContext.ResourceID = "1"
+	require.NoError(t, res.CallDelete(&Product{}, deleteContext))
+	_, err = os.Stat(filepath.Join(root, filepath.FromSlash(product.Manual.Path)))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,163 @@
+package media
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	stdcontext "context"
+	"errors"
+	"path"
+	"reflect"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
+	"github.com/bhojpur/application/pkg/resource"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// Enable stores the files uploaded to the File fields of the records of the resource in storage when they are saved,
+// in a directory of their table and field. Files replaced by saving a record or of deleted records are deleted from
+// storage once the change succeeded, so that they aren't orphaned
+func Enable(res *resource.Resource, storage Storage) {
+	saveHandler, deleteHandler := res.SaveHandler, res.DeleteHandler
+
+	res.SaveHandler = func(result interface{}, context *appsvr.Context) error {
+		ctx := requestContext(context)
+		scope := context.GetDB().NewScope(result)
+
+		var previous []File
+		if !scope.PrimaryKeyZero() {
+			old := res.NewStruct()
+			if err := context.GetDB().First(old, scope.PrimaryKeyValue()).Error; err == nil {
+				previous = files(old)
+			} else if !errors.Is(err, orm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		var stored []File
+		for name, file := range fileFields(result) {
+			if !file.HasUpload() {
+				continue
+			}
+			if err := file.Store(ctx, storage, path.Join(scope.TableName(), name)); err != nil {
+				deleteFiles(ctx, storage, stored)
+				return err
+			}
+			stored = append(stored, *file)
+		}
+
+		if err := saveHandler(result, context); err != nil {
+			deleteFiles(ctx, storage, stored)
+			return err
+		}
+		deleteFiles(ctx, storage, orphans(previous, files(result)))
+		return nil
+	}
+
+	res.DeleteHandler = func(result interface{}, context *appsvr.Context) error {
+		if err := deleteHandler(result, context); err != nil {
+			return err
+		}
+		deleteFiles(requestContext(context), storage, files(result))
+		return nil
+	}
+}
+
+// fileFields returns the files of the File and Image fields of a record by field name
+func fileFields(record interface{}) map[string]*File {
+	fields := map[string]*File{}
+	value := reflect.Indirect(reflect.ValueOf(record))
+	if value.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		if value.Type().Field(i).PkgPath != "" {
+			continue
+		}
+
+		switch field := value.Field(i).Addr().Interface().(type) {
+		case *File:
+			fields[value.Type().Field(i).Name] = field
+		case *Image:
+			fields[value.Type().Field(i).Name] = &field.File
+		}
+	}
+	return fields
+}
+
+// files returns the stored files of a record, including the variants of images
+func files(record interface{}) []File {
+	var files []File
+	value := reflect.Indirect(reflect.ValueOf(record))
+	for _, file := range fileFields(record) {
+		if file.Path != "" {
+			files = append(files, *file)
+		}
+	}
+
+	for i := 0; value.Kind() == reflect.Struct && i < value.NumField(); i++ {
+		if value.Type().Field(i).PkgPath != "" {
+			continue
+		}
+
+		if img, ok := value.Field(i).Addr().Interface().(*Image); ok {
+			for _, variant := range img.Variants {
+				files = append(files, variant)
+			}
+		}
+	}
+	return files
+}
+
+// orphans returns the previous files not kept by the current ones, variants of kept images are overwritten when they
+// are generated again
+func orphans(previous, current []File) []File {
+	var orphans []File
+	for _, file := range previous {
+		var kept bool
+		for _, f := range current {
+			if f.Path == file.Path || VariantPath(f.Path, path.Base(path.Dir(file.Path))) == file.Path {
+				kept = true
+				break
+			}
+		}
+		if !kept {
+			orphans = append(orphans, file)
+		}
+	}
+	return orphans
+}
+
+func deleteFiles(ctx stdcontext.Context, storage Storage, files []File) {
+	for _, file := range files {
+		if err := storage.Delete(ctx, file.Path); err != nil {
+			log.Warn("failed to delete file", applog.String("path", file.Path), applog.Err(err))
+		}
+	}
+}
+
+func requestContext(context *appsvr.Context) stdcontext.Context {
+	if context.Request != nil {
+		return context.Request.Context()
+	}
+	return stdcontext.Background()
+}
@@ -0,0 +1,99 @@
+package s3
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"io"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+
+	"github.com/bhojpur/application/pkg/media"
+)
+
+// Storage is a media.Storage in an S3 bucket
+//
+//	storage := s3.New(awss3.New(session.Must(session.NewSession())), "media")
+type Storage struct {
+	client   *awss3.S3
+	uploader *s3manager.Uploader
+	Bucket   string
+	// Prefix is prepended to the paths of files
+	Prefix string
+	// ACL of uploaded files, e.g. "public-read", the default ACL of the bucket if empty
+	ACL string
+}
+
+var _ media.Storage = &Storage{}
+
+// New initialize a storage in the bucket of an S3 client
+func New(client *awss3.S3, bucket string) *Storage {
+	return &Storage{client: client, uploader: s3manager.NewUploaderWithClient(client), Bucket: bucket}
+}
+
+func (storage *Storage) key(name string) *string {
+	return aws.String(path.Join(storage.Prefix, name))
+}
+
+// Put uploads the file to path
+func (storage *Storage) Put(ctx context.Context, name string, r io.Reader, contentType string) error {
+	input := &s3manager.UploadInput{Bucket: aws.String(storage.Bucket), Key: storage.key(name), Body: r}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	if storage.ACL != "" {
+		input.ACL = aws.String(storage.ACL)
+	}
+	_, err := storage.uploader.UploadWithContext(ctx, input)
+	return err
+}
+
+// Get downloads the file of path
+func (storage *Storage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	output, err := storage.client.GetObjectWithContext(ctx, &awss3.GetObjectInput{Bucket: aws.String(storage.Bucket), Key: storage.key(name)})
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+// Delete deletes the file of path
+func (storage *Storage) Delete(ctx context.Context, name string) error {
+	_, err := storage.client.DeleteObjectWithContext(ctx, &awss3.DeleteObjectInput{Bucket: aws.String(storage.Bucket), Key: storage.key(name)})
+	return err
+}
+
+// URL returns the URL of the file, presigned to expire after ttl if it is positive
+func (storage *Storage) URL(ctx context.Context, name string, ttl time.Duration) (string, error) {
+	req, _ := storage.client.GetObjectRequest(&awss3.GetObjectInput{Bucket: aws.String(storage.Bucket), Key: storage.key(name)})
+	req.SetContext(ctx)
+	if ttl > 0 {
+		return req.Presign(ttl)
+	}
+	if err := req.Build(); err != nil {
+		return "", err
+	}
+	return req.HTTPRequest.URL.String(), nil
+}
@@ -0,0 +1,241 @@
+package media
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/worker"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// VariantsJob is the name of the job generating the variants of images
+const VariantsJob = "media:variants"
+
+type variantsArgs struct {
+	Table      string
+	PrimaryKey string
+	Field      string
+	Path       string
+}
+
+type variantsField struct {
+	res   *resource.Resource
+	sizes map[string]Size
+}
+
+// Variants declares the sizes of the Image fields of resources, generates their variants with a worker when they are
+// saved, and serves them
+type Variants struct {
+	db      *orm.DB
+	storage Storage
+	worker  *worker.Worker
+	fields  map[string]*variantsField
+	mu      sync.RWMutex
+	// MaxAge is the max-age of the Cache-Control of served variants, a day by default
+	MaxAge time.Duration
+}
+
+// NewVariants initialize the variants of images stored in storage, it registers the VariantsJob job to the worker
+func NewVariants(db *orm.DB, storage Storage, w *worker.Worker) *Variants {
+	v := &Variants{db: db, storage: storage, worker: w, fields: map[string]*variantsField{}, MaxAge: 24 * time.Hour}
+	w.Register(&worker.Job{
+		Name: VariantsJob,
+		Args: &variantsArgs{},
+		Handler: func(ctx context.Context, args interface{}) error {
+			return v.generate(ctx, args.(*variantsArgs))
+		},
+	})
+	return v
+}
+
+// Declare declares the sizes of an Image field of the resource by name, e.g. {"thumb": {Width: 120, Height: 120,
+// Crop: true}}. Saving a record with the resource enqueues the generation of the variants of its image, if it or its
+// focal point changed
+func (v *Variants) Declare(res *resource.Resource, field string, sizes map[string]Size) {
+	table := v.db.NewScope(res.Value).TableName()
+	v.mu.Lock()
+	v.fields[table+"/"+field] = &variantsField{res: res, sizes: sizes}
+	v.mu.Unlock()
+
+	saveHandler := res.SaveHandler
+	res.SaveHandler = func(result interface{}, context *appsvr.Context) error {
+		if err := saveHandler(result, context); err != nil {
+			return err
+		}
+
+		img := imageField(result, field)
+		if img == nil || img.Path == "" || hasVariants(img, sizes) {
+			return nil
+		}
+		_, err := v.worker.EnqueueWithContext(context, VariantsJob, &variantsArgs{Table: table, PrimaryKey: res.GetPrimaryKey(result, context), Field: field, Path: img.Path})
+		return err
+	}
+}
+
+func (v *Variants) field(table, field string) *variantsField {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.fields[table+"/"+field]
+}
+
+func imageField(record interface{}, name string) *Image {
+	value := reflect.Indirect(reflect.ValueOf(record))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	if field := value.FieldByName(name); field.IsValid() && field.CanAddr() {
+		img, _ := field.Addr().Interface().(*Image)
+		return img
+	}
+	return nil
+}
+
+func hasVariants(img *Image, sizes map[string]Size) bool {
+	for name := range sizes {
+		if _, ok := img.Variants[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// generate generates the variants of the image of a record, unless it changed since the run was enqueued
+func (v *Variants) generate(ctx context.Context, args *variantsArgs) error {
+	field := v.field(args.Table, args.Field)
+	if field == nil {
+		return fmt.Errorf("media: no variants declared for %v of %v", args.Field, args.Table)
+	}
+
+	var (
+		context                        = &appsvr.Context{Config: &appsvr.Config{DB: v.db}}
+		record                         = field.res.NewStruct()
+		primaryQuerySQL, primaryParams = field.res.ToPrimaryQueryParams(args.PrimaryKey, context)
+	)
+	if err := v.db.First(record, append([]interface{}{primaryQuerySQL}, primaryParams...)...).Error; err != nil {
+		if errors.Is(err, orm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	img := imageField(record, args.Field)
+	if img == nil || img.Path != args.Path {
+		return nil
+	}
+	previous, err := img.Value()
+	if err != nil {
+		return err
+	}
+
+	variants := map[string]File{}
+	for name, size := range field.sizes {
+		variant, _, err := v.generateVariant(ctx, img.Path, name, size, img.FocalPoint)
+		if err != nil {
+			return err
+		}
+		variants[name] = variant
+	}
+	img.Variants = variants
+
+	// the image isn't updated if it changed meanwhile, like its focal point, the run of the change generates it
+	scope := v.db.NewScope(record)
+	column, _ := scope.FieldByName(args.Field)
+	return v.db.Model(record).Where(fmt.Sprintf("%v = ?", scope.Quote(column.DBName)), previous).UpdateColumn(column.DBName, *img).Error
+}
+
+func (v *Variants) generateVariant(ctx context.Context, original, name string, size Size, focalPoint *FocalPoint) (File, []byte, error) {
+	r, err := v.storage.Get(ctx, original)
+	if err != nil {
+		return File{}, nil, err
+	}
+	defer r.Close()
+
+	data, contentType, err := Resize(r, size, focalPoint)
+	if err != nil {
+		return File{}, nil, err
+	}
+
+	variant := File{Path: VariantPath(original, name), FileName: path.Base(original), Size: int64(len(data)), ContentType: contentType}
+	checksum := sha256.Sum256(data)
+	variant.Checksum = hex.EncodeToString(checksum[:])
+	return variant, data, v.storage.Put(ctx, variant.Path, bytes.NewReader(data), contentType)
+}
+
+// ServeHTTP serves the variants of images at /<size>/<path of the image>, e.g. /thumb/products/Photo/1f2e/shoe.jpg.
+// Variants which aren't generated yet are generated from the image, centered, and stored, so that they are served
+// from storage next time
+func (v *Variants) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)
+	if len(parts) != 2 || path.Clean(parts[1]) != parts[1] {
+		http.NotFound(w, req)
+		return
+	}
+
+	name, original := parts[0], parts[1]
+	segments := strings.Split(original, "/")
+	if len(segments) < 3 {
+		http.NotFound(w, req)
+		return
+	}
+	field := v.field(segments[0], segments[1])
+	if field == nil {
+		http.NotFound(w, req)
+		return
+	}
+	size, ok := field.sizes[name]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	var data []byte
+	r, err := v.storage.Get(req.Context(), VariantPath(original, name))
+	if err == nil {
+		data, err = io.ReadAll(r)
+		r.Close()
+	}
+	if err != nil {
+		if _, data, err = v.generateVariant(req.Context(), original, name, size, nil); err != nil {
+			log.Warn("failed to generate variant", applog.String("path", original), applog.String("size", name), applog.Err(err))
+			http.NotFound(w, req)
+			return
+		}
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(v.MaxAge.Seconds())))
+	http.ServeContent(w, req, path.Base(original), time.Time{}, bytes.NewReader(data))
+}
@@ -22,6 +22,7 @@ package messaging
 
 import (
 	"context"
+	"sync"
 
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
@@ -43,6 +44,7 @@ type Proxy interface {
 	Handler() grpc.StreamHandler
 	SetRemoteAppFn(func(string) (remoteApp, error))
 	SetTelemetryFn(func(context.Context) context.Context)
+	SetAccessControlList(acl *config.AccessControlList)
 }
 
 type proxy struct {
@@ -53,6 +55,7 @@ type proxy struct {
 	telemetryFn       func(context.Context) context.Context
 	localAppAddress   string
 	acl               *config.AccessControlList
+	aclLock           sync.RWMutex
 	sslEnabled        bool
 }
 
@@ -95,8 +98,12 @@ func (p *proxy) intercept(ctx context.Context, fullName string) (context.Context
 
 	if target.id == p.appID {
 		// proxy locally to the app
-		if p.acl != nil {
-			ok, authError := acl.ApplyAccessControlPolicies(ctx, fullName, common.HTTPExtension_NONE, config.GRPCProtocol, p.acl)
+		p.aclLock.RLock()
+		accessControlList := p.acl
+		p.aclLock.RUnlock()
+
+		if accessControlList != nil {
+			ok, authError := acl.ApplyAccessControlPolicies(ctx, fullName, common.HTTPExtension_NONE, config.GRPCProtocol, accessControlList)
 			if !ok {
 				return ctx, nil, status.Errorf(codes.PermissionDenied, authError)
 			}
@@ -123,3 +130,10 @@ func (p *proxy) SetRemoteAppFn(remoteAppFn func(appID string) (remoteApp, error)
 func (p *proxy) SetTelemetryFn(spanFn func(context.Context) context.Context) {
 	p.telemetryFn = spanFn
 }
+
+// SetAccessControlList replaces the access control policies applied to the calls proxied to the app.
+func (p *proxy) SetAccessControlList(acl *config.AccessControlList) {
+	p.aclLock.Lock()
+	p.acl = acl
+	p.aclLock.Unlock()
+}
@@ -56,6 +56,14 @@ func TestNewProxy(t *testing.T) {
 	assert.True(t, proxy.sslEnabled)
 }
 
+func TestSetAccessControlList(t *testing.T) {
+	p := NewProxy(connectionFn, "a", "a:123", 50005, nil, false)
+	accessControlList := &config.AccessControlList{TrustDomain: "public"}
+	p.SetAccessControlList(accessControlList)
+
+	assert.Equal(t, accessControlList, p.(*proxy).acl)
+}
+
 func TestSetRemoteAppFn(t *testing.T) {
 	p := NewProxy(connectionFn, "a", "a:123", 50005, nil, false)
 	p.SetRemoteAppFn(func(s string) (remoteApp, error) {
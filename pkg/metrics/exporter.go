@@ -82,6 +82,10 @@ func (m *promMetricsExporter) Init() error {
 		return nil
 	}
 
+	if err := m.exporter.Options().apply(); err != nil {
+		return err
+	}
+
 	var err error
 	if m.ocExporter, err = ocprom.NewExporter(ocprom.Options{
 		Namespace: m.namespace,
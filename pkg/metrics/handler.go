@@ -0,0 +1,46 @@
+package metrics
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"net/http"
+
+	ocprom "contrib.go.opencensus.io/exporter/prometheus"
+	"github.com/pkg/errors"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultHandlerPath is the path the handler of NewHandler is mounted at by convention.
+const DefaultHandlerPath = "/metrics"
+
+// NewHandler returns a HTTP handler serving the metrics of the registered views in the Prometheus text format, to
+// mount at DefaultHandlerPath of a server of the application instead of starting the metrics server of an Exporter.
+// The metrics are named with the namespace, like `app_resource_find_total`.
+func NewHandler(namespace string) (http.Handler, error) {
+	exporter, err := ocprom.NewExporter(ocprom.Options{
+		Namespace: namespace,
+		Registry:  prom.NewRegistry(),
+	})
+	if err != nil {
+		return nil, errors.Errorf("failed to create Prometheus exporter: %v", err)
+	}
+	return exporter, nil
+}
@@ -0,0 +1,58 @@
+package metrics
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resource_monitoring "github.com/bhojpur/application/pkg/resource/monitoring"
+	roles_monitoring "github.com/bhojpur/application/pkg/roles/monitoring"
+)
+
+func TestHandler(t *testing.T) {
+	require.NoError(t, resource_monitoring.InitMetrics())
+	require.NoError(t, roles_monitoring.InitMetrics())
+
+	resource_monitoring.RecordOperation("Product", resource_monitoring.Save, "1", nil, 30*time.Millisecond)
+	resource_monitoring.RecordOperation("Product", resource_monitoring.FindMany, "", errors.New("timeout"), time.Millisecond)
+	roles_monitoring.RecordPermissionDenied("Product", "delete", []string{"editor"})
+	roles_monitoring.RecordPermissionDenied("Product", "delete", nil)
+
+	handler, err := NewHandler("test")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, DefaultHandlerPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, `test_resource_save_duration_seconds_bucket{resource="Product",success="true",le="0.05"} 1`)
+	assert.Contains(t, body, `test_resource_find_total{operation="find_many",resource="Product",success="false"} 1`)
+	assert.Contains(t, body, `test_roles_permission_denied_total{mode="delete",resource="Product",role="editor"} 1`)
+	assert.Contains(t, body, `test_roles_permission_denied_total{mode="delete",resource="Product",role="none"} 1`)
+}
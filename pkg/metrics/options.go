@@ -21,12 +21,17 @@ package metrics
 // THE SOFTWARE.
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
+
+	diag_utils "github.com/bhojpur/application/pkg/diagnostics/utils"
 )
 
 const (
-	defaultMetricsPort    = "9090"
-	defaultMetricsEnabled = true
+	defaultMetricsPort                  = "9090"
+	defaultMetricsEnabled               = true
+	defaultMetricsHighCardinalityLabels = false
 )
 
 // Options defines the sets of options for Bhojpur Application logging.
@@ -35,12 +40,19 @@ type Options struct {
 	MetricsEnabled bool
 
 	Port string
+
+	// HighCardinalityLabels enables labels with unbounded values, such as record IDs and raw paths.
+	HighCardinalityLabels bool
+
+	// Modules toggles the metric groups of modules, eg. "resource=true,roles=false".
+	Modules string
 }
 
 func defaultMetricOptions() *Options {
 	return &Options{
-		Port:           defaultMetricsPort,
-		MetricsEnabled: defaultMetricsEnabled,
+		Port:                  defaultMetricsPort,
+		MetricsEnabled:        defaultMetricsEnabled,
+		HighCardinalityLabels: defaultMetricsHighCardinalityLabels,
 	}
 }
 
@@ -69,6 +81,52 @@ func (o *Options) AttachCmdFlags(
 		"enable-metrics",
 		defaultMetricsEnabled,
 		"Enable prometheus metric")
+	boolVar(
+		&o.HighCardinalityLabels,
+		"metrics-high-cardinality-labels",
+		defaultMetricsHighCardinalityLabels,
+		"Enable metric labels with unbounded values, such as record IDs and raw paths")
+	stringVar(
+		&o.Modules,
+		"metrics-modules",
+		"",
+		"Comma separated list of metric groups to enable or disable, eg. resource=true,roles=false")
+}
+
+// MetricsModules parses the metric group toggles of the modules.
+func (o *Options) MetricsModules() (map[string]bool, error) {
+	modules := map[string]bool{}
+	for _, toggle := range strings.Split(o.Modules, ",") {
+		toggle = strings.TrimSpace(toggle)
+		if toggle == "" {
+			continue
+		}
+
+		parts := strings.SplitN(toggle, "=", 2)
+		enabled := true
+		if len(parts) == 2 {
+			var err error
+			if enabled, err = strconv.ParseBool(parts[1]); err != nil {
+				return nil, fmt.Errorf("invalid metrics module toggle %q: %w", toggle, err)
+			}
+		}
+		modules[strings.TrimSpace(parts[0])] = enabled
+	}
+	return modules, nil
+}
+
+// apply applies the cardinality and module options to the metrics recorders.
+func (o *Options) apply() error {
+	modules, err := o.MetricsModules()
+	if err != nil {
+		return err
+	}
+
+	diag_utils.SetHighCardinalityLabels(o.HighCardinalityLabels)
+	for module, enabled := range modules {
+		diag_utils.SetMetricsModuleEnabled(module, enabled)
+	}
+	return nil
 }
 
 // AttachCmdFlag attaches single metrics option to command flags.
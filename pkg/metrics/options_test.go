@@ -93,4 +93,23 @@ func TestOptions(t *testing.T) {
 		// assert
 		assert.True(t, metricsPortAsserted)
 	})
+
+	t.Run("parse metrics modules", func(t *testing.T) {
+		o := Options{
+			Modules: "resource=false, roles ,operator=true",
+		}
+
+		modules, err := o.MetricsModules()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]bool{"resource": false, "roles": true, "operator": true}, modules)
+	})
+
+	t.Run("invalid metrics modules", func(t *testing.T) {
+		o := Options{
+			Modules: "resource=maybe",
+		}
+
+		_, err := o.MetricsModules()
+		assert.Error(t, err)
+	})
 }
@@ -0,0 +1,348 @@
+package accesslog
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the verbosity of the access log of a route.
+type Level int
+
+const (
+	// LevelNone disables the access log.
+	LevelNone Level = iota
+	// LevelBasic logs the method, path, status, size and duration of requests.
+	LevelBasic
+	// LevelHeaders also logs the request and response headers.
+	LevelHeaders
+	// LevelBody also logs the request and response bodies of sampled requests.
+	LevelBody
+)
+
+// Redacted replaces the values of redacted fields.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedFields are the fields flagged as PII or secret when the configuration doesn't declare any.
+var DefaultRedactedFields = []string{
+	"authorization", "cookie", "set-cookie", "password", "secret", "token", "access_token", "refresh_token",
+	"api_key", "apikey", "credit_card", "card_number", "cvv", "ssn", "email", "phone",
+}
+
+// Route declares the verbosity of the requests matching a pattern, like "GET /products/*".
+// Patterns without a method match every method, and a trailing "*" matches every path with the prefix.
+type Route struct {
+	Pattern string
+	Level   Level
+}
+
+// Config configures the access log.
+type Config struct {
+	// Level is the verbosity of the requests not matching any route.
+	Level  Level
+	Routes []Route
+	// SampleRate is the fraction of requests, between 0 and 1, whose bodies are logged at LevelBody.
+	SampleRate float64
+	// MaxBodySize is the number of bytes of a body logged, longer bodies are truncated. Defaults to 4KB.
+	MaxBodySize int
+	// RedactedFields are the names of the headers, query parameters and body fields flagged as PII or
+	// secret, matched case insensitively. Defaults to DefaultRedactedFields.
+	RedactedFields []string
+	// Output receives the entries as JSON lines. Defaults to stdout.
+	Output io.Writer
+}
+
+// Entry is the structured access log entry of a request.
+type Entry struct {
+	Time            time.Time         `json:"time"`
+	Method          string            `json:"method"`
+	Path            string            `json:"path"`
+	Query           string            `json:"query,omitempty"`
+	RemoteAddr      string            `json:"remoteAddr"`
+	Status          int               `json:"status"`
+	Size            int               `json:"size"`
+	DurationMs      float64           `json:"durationMs"`
+	RequestHeaders  map[string]string `json:"requestHeaders,omitempty"`
+	ResponseHeaders map[string]string `json:"responseHeaders,omitempty"`
+	RequestBody     string            `json:"requestBody,omitempty"`
+	ResponseBody    string            `json:"responseBody,omitempty"`
+	Truncated       bool              `json:"truncated,omitempty"`
+}
+
+const defaultMaxBodySize = 4096
+
+type route struct {
+	method string
+	path   string
+	prefix bool
+	level  Level
+}
+
+type logger struct {
+	config   Config
+	routes   []route
+	redacted map[string]bool
+	lock     sync.Mutex
+	encoder  *json.Encoder
+	sample   func() float64
+}
+
+// New validates the configuration and returns a middleware writing an access log entry per request.
+func New(config Config) (func(http.Handler) http.Handler, error) {
+	l, err := newLogger(config)
+	if err != nil {
+		return nil, err
+	}
+	return l.middleware, nil
+}
+
+func newLogger(config Config) (*logger, error) {
+	if config.SampleRate < 0 || config.SampleRate > 1 {
+		return nil, fmt.Errorf("invalid access log sample rate %v: must be between 0 and 1", config.SampleRate)
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultMaxBodySize
+	}
+	if config.RedactedFields == nil {
+		config.RedactedFields = DefaultRedactedFields
+	}
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+
+	compiled := make([]route, 0, len(config.Routes))
+	for _, declaration := range config.Routes {
+		r := route{method: "*", level: declaration.Level}
+		if parts := strings.SplitN(strings.TrimSpace(declaration.Pattern), " ", 2); len(parts) == 2 {
+			r.method, r.path = strings.ToUpper(parts[0]), strings.TrimSpace(parts[1])
+		} else {
+			r.path = parts[0]
+		}
+		if !strings.HasPrefix(r.path, "/") {
+			return nil, fmt.Errorf("invalid access log route %q: path must start with /", declaration.Pattern)
+		}
+		if strings.HasSuffix(r.path, "*") {
+			r.prefix = true
+			r.path = strings.TrimSuffix(r.path, "*")
+		}
+		compiled = append(compiled, r)
+	}
+
+	// the most specific route wins, same as with the route annotations
+	sort.SliceStable(compiled, func(i, j int) bool {
+		if compiled[i].prefix != compiled[j].prefix {
+			return !compiled[i].prefix
+		}
+		if len(compiled[i].path) != len(compiled[j].path) {
+			return len(compiled[i].path) > len(compiled[j].path)
+		}
+		return compiled[i].method != "*" && compiled[j].method == "*"
+	})
+
+	redacted := map[string]bool{}
+	for _, field := range config.RedactedFields {
+		redacted[strings.ToLower(field)] = true
+	}
+
+	return &logger{
+		config:   config,
+		routes:   compiled,
+		redacted: redacted,
+		encoder:  json.NewEncoder(config.Output),
+		sample:   rand.Float64,
+	}, nil
+}
+
+func (l *logger) level(req *http.Request) Level {
+	for _, r := range l.routes {
+		if r.method != "*" && r.method != req.Method {
+			continue
+		}
+		if (r.prefix && strings.HasPrefix(req.URL.Path, r.path)) || (!r.prefix && req.URL.Path == r.path) {
+			return r.level
+		}
+	}
+	return l.config.Level
+}
+
+func (l *logger) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		level := l.level(req)
+		if level == LevelNone {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		logBody := level >= LevelBody && l.config.SampleRate > 0 && l.sample() < l.config.SampleRate
+
+		entry := Entry{
+			Time:       time.Now(),
+			Method:     req.Method,
+			Path:       req.URL.Path,
+			Query:      l.redactQuery(req.URL.RawQuery),
+			RemoteAddr: req.RemoteAddr,
+		}
+		if level >= LevelHeaders {
+			entry.RequestHeaders = l.redactHeaders(req.Header)
+		}
+
+		var requestBody []byte
+		if logBody && req.Body != nil {
+			requestBody, _ = io.ReadAll(io.LimitReader(req.Body, int64(l.config.MaxBodySize)+1))
+			req.Body = readCloser{io.MultiReader(bytes.NewReader(requestBody), req.Body), req.Body}
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK, limit: l.config.MaxBodySize + 1, capture: logBody}
+		next.ServeHTTP(recorder, req)
+
+		entry.Status = recorder.status
+		entry.Size = recorder.size
+		entry.DurationMs = float64(time.Since(entry.Time)) / float64(time.Millisecond)
+		if level >= LevelHeaders {
+			entry.ResponseHeaders = l.redactHeaders(w.Header())
+		}
+		if logBody {
+			var truncated bool
+			entry.RequestBody, truncated = l.body(requestBody, req.Header.Get("Content-Type"))
+			entry.Truncated = truncated
+			entry.ResponseBody, truncated = l.body(recorder.body.Bytes(), w.Header().Get("Content-Type"))
+			entry.Truncated = entry.Truncated || truncated
+		}
+
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		l.encoder.Encode(entry)
+	})
+}
+
+// body returns the body to log with its redacted fields, truncated to the size limit.
+func (l *logger) body(body []byte, contentType string) (string, bool) {
+	truncated := len(body) > l.config.MaxBodySize
+	if truncated {
+		body = body[:l.config.MaxBodySize]
+	}
+
+	switch {
+	case strings.Contains(contentType, "json"):
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err == nil {
+			if b, err := json.Marshal(l.redactValue(value)); err == nil {
+				return string(b), truncated
+			}
+		} else if truncated {
+			// a truncated JSON body can't be redacted reliably
+			return Redacted, truncated
+		}
+	case strings.Contains(contentType, "application/x-www-form-urlencoded"):
+		return l.redactQuery(string(body)), truncated
+	}
+	return string(body), truncated
+}
+
+func (l *logger) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if l.redacted[strings.ToLower(key)] {
+				v[key] = Redacted
+			} else {
+				v[key] = l.redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = l.redactValue(item)
+		}
+	}
+	return value
+}
+
+func (l *logger) redactQuery(query string) string {
+	if query == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return query
+	}
+	for key := range values {
+		if l.redacted[strings.ToLower(key)] {
+			values[key] = []string{Redacted}
+		}
+	}
+	return values.Encode()
+}
+
+func (l *logger) redactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if l.redacted[strings.ToLower(name)] {
+			headers[name] = Redacted
+		} else {
+			headers[name] = strings.Join(values, ", ")
+		}
+	}
+	return headers
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// responseRecorder writes a response through, recording its status, size and the beginning of its body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status  int
+	size    int
+	limit   int
+	capture bool
+	body    bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.capture && r.body.Len() < r.limit {
+		if remaining := r.limit - r.body.Len(); len(b) > remaining {
+			r.body.Write(b[:remaining])
+		} else {
+			r.body.Write(b)
+		}
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.size += n
+	return n, err
+}
@@ -0,0 +1,133 @@
+package accesslog_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bhojpur/application/pkg/middleware/accesslog"
+)
+
+func serve(t *testing.T, config accesslog.Config, req *http.Request) []accesslog.Entry {
+	var output bytes.Buffer
+	config.Output = &output
+	middleware, err := accesslog.New(config)
+	require.NoError(t, err)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+	middleware(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	var entries []accesslog.Entry
+	decoder := json.NewDecoder(&output)
+	for decoder.More() {
+		var entry accesslog.Entry
+		require.NoError(t, decoder.Decode(&entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func newRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/users?name=jane&token=xyz", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer xyz")
+	return req
+}
+
+func TestAccessLog(t *testing.T) {
+	t.Run("basic level logs the request line", func(t *testing.T) {
+		entries := serve(t, accesslog.Config{Level: accesslog.LevelBasic}, newRequest(`{"name":"jane"}`))
+		require.Len(t, entries, 1)
+		assert.Equal(t, http.MethodPost, entries[0].Method)
+		assert.Equal(t, "/users", entries[0].Path)
+		assert.Equal(t, http.StatusCreated, entries[0].Status)
+		assert.Equal(t, len(`{"name":"jane"}`), entries[0].Size)
+		assert.Empty(t, entries[0].RequestHeaders)
+		assert.Empty(t, entries[0].RequestBody)
+
+		query, err := url.ParseQuery(entries[0].Query)
+		require.NoError(t, err)
+		assert.Equal(t, "jane", query.Get("name"))
+		assert.Equal(t, accesslog.Redacted, query.Get("token"))
+	})
+
+	t.Run("headers are redacted", func(t *testing.T) {
+		entries := serve(t, accesslog.Config{Level: accesslog.LevelHeaders}, newRequest(`{}`))
+		require.Len(t, entries, 1)
+		assert.Equal(t, accesslog.Redacted, entries[0].RequestHeaders["Authorization"])
+		assert.Equal(t, "application/json", entries[0].RequestHeaders["Content-Type"])
+		assert.Equal(t, accesslog.Redacted, entries[0].ResponseHeaders["Set-Cookie"])
+	})
+
+	t.Run("sampled bodies are redacted", func(t *testing.T) {
+		body := `{"name":"jane","password":"secret","cards":[{"cvv":"123"}]}`
+		entries := serve(t, accesslog.Config{Level: accesslog.LevelBody, SampleRate: 1}, newRequest(body))
+		require.Len(t, entries, 1)
+		assert.JSONEq(t, `{"name":"jane","password":"[REDACTED]","cards":[{"cvv":"[REDACTED]"}]}`, entries[0].RequestBody)
+		assert.JSONEq(t, entries[0].RequestBody, entries[0].ResponseBody)
+	})
+
+	t.Run("bodies are not logged when not sampled", func(t *testing.T) {
+		entries := serve(t, accesslog.Config{Level: accesslog.LevelBody}, newRequest(`{"name":"jane"}`))
+		require.Len(t, entries, 1)
+		assert.Empty(t, entries[0].RequestBody)
+	})
+
+	t.Run("long bodies are truncated", func(t *testing.T) {
+		req := newRequest("plain text body")
+		req.Header.Set("Content-Type", "text/plain")
+		entries := serve(t, accesslog.Config{Level: accesslog.LevelBody, SampleRate: 1, MaxBodySize: 5}, req)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "plain", entries[0].RequestBody)
+		assert.True(t, entries[0].Truncated)
+	})
+
+	t.Run("routes override the level", func(t *testing.T) {
+		config := accesslog.Config{
+			Level:  accesslog.LevelBasic,
+			Routes: []accesslog.Route{{Pattern: "POST /users*", Level: accesslog.LevelNone}},
+		}
+		assert.Empty(t, serve(t, config, newRequest(`{}`)))
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		_, err := accesslog.New(accesslog.Config{SampleRate: 2})
+		assert.Error(t, err)
+		_, err = accesslog.New(accesslog.Config{Routes: []accesslog.Route{{Pattern: "users"}}})
+		assert.Error(t, err)
+	})
+}
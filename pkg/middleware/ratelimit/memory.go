@@ -0,0 +1,75 @@
+package ratelimit
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+// MemoryStore is a Store in memory, for a single instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+var _ Store = &MemoryStore{}
+
+// NewMemoryStore initialize a store in memory.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, swept: time.Now()}
+}
+
+// Take takes a token of the bucket of key.
+func (store *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// full buckets are dropped, they are filled again when they are needed
+	if now.Sub(store.swept) > time.Minute {
+		for k, b := range store.buckets {
+			if !now.Before(b.full) {
+				delete(store.buckets, k)
+			}
+		}
+		store.swept = now
+	}
+
+	b, ok := store.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Capacity()), updated: now}
+		store.buckets[key] = b
+	}
+
+	result, tokens := take(b.tokens, now.Sub(b.updated), limit)
+	b.tokens, b.updated, b.full = tokens, now, now.Add(result.Reset)
+	return result, nil
+}
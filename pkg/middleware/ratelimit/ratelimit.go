@@ -0,0 +1,212 @@
+package ratelimit
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bhojpur/application/pkg/auth"
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
+)
+
+var log = applog.New("app.ratelimit")
+
+// Name is the name of the rate limiting middleware, routes skip it to override the default limit.
+const Name = "ratelimit"
+
+// Limit is a token bucket, refilled with Requests tokens per Period, holding up to Burst tokens. Each request takes a
+// token, and is rejected if the bucket is empty.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	// Burst is the capacity of the bucket, Requests by default.
+	Burst int
+}
+
+// Capacity returns the capacity of the bucket, its burst.
+func (limit Limit) Capacity() int {
+	if limit.Burst > 0 {
+		return limit.Burst
+	}
+	return limit.Requests
+}
+
+// Rate returns the tokens added to the bucket per second.
+func (limit Limit) Rate() float64 {
+	return float64(limit.Requests) / limit.Period.Seconds()
+}
+
+// Result is the state of a bucket after a request took a token.
+type Result struct {
+	Allowed bool
+	// Remaining is the number of tokens left in the bucket.
+	Remaining int
+	// Reset is the duration until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the duration until the bucket has a token again, if the request wasn't allowed.
+	RetryAfter time.Duration
+}
+
+// Store stores the buckets of clients, see MemoryStore, and redisstore for buckets shared by instances.
+type Store interface {
+	// Take takes a token of the bucket of key, filled at first.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Config configures rate limiting.
+type Config struct {
+	Store Store
+	// Limit is the default limit of requests, of routes without overrides.
+	Limit Limit
+	// Key identifies the client of a request, KeyByUserOrIP by default.
+	Key func(req *http.Request) string
+}
+
+// Limiter limits the rate of the requests of each client, by the bucket of its key.
+//
+//	limiter := ratelimit.New(ratelimit.Config{Store: ratelimit.NewMemoryStore(), Limit: ratelimit.Limit{Requests: 100, Period: time.Minute}})
+//	engine.Use(a.Middleware(), limiter.Middleware())
+//	engine.Mount("/api/search", search, limiter.Override("search", ratelimit.Limit{Requests: 10, Period: time.Minute})...)
+type Limiter struct {
+	config Config
+}
+
+// New initialize a limiter.
+func New(config Config) *Limiter {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.Key == nil {
+		config.Key = KeyByUserOrIP("X-API-Key")
+	}
+	return &Limiter{config: config}
+}
+
+// Middleware returns the middleware limiting requests to the default limit, register it after the middleware of
+// auth, so that authenticated users have their own bucket.
+func (limiter *Limiter) Middleware() *appsvr.Middleware {
+	return limiter.middleware(Name, "default", limiter.config.Limit)
+}
+
+// Override returns the options of a route limiting its requests to limit instead of the default limit, in their own
+// buckets of the class.
+func (limiter *Limiter) Override(class string, limit Limit) []appsvr.RouteOption {
+	return []appsvr.RouteOption{appsvr.Skip(Name), appsvr.With(limiter.middleware(Name+":"+class, class, limit))}
+}
+
+func (limiter *Limiter) middleware(name, class string, limit Limit) *appsvr.Middleware {
+	return &appsvr.Middleware{
+		Name: name,
+		Handler: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if limit.Requests <= 0 || limit.Period <= 0 {
+					next.ServeHTTP(w, req)
+					return
+				}
+
+				result, err := limiter.config.Store.Take(req.Context(), class+":"+limiter.config.Key(req), limit)
+				if err != nil {
+					// requests aren't rejected because the store is unavailable
+					log.Warn("failed to take token", applog.String("class", class), applog.Err(err))
+					next.ServeHTTP(w, req)
+					return
+				}
+
+				writeHeaders(w, limit, result)
+				if !result.Allowed {
+					retryAfter := seconds(result.RetryAfter)
+					w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+					http.Error(w, fmt.Sprintf("%v, retry after %d seconds", http.StatusText(http.StatusTooManyRequests), retryAfter), http.StatusTooManyRequests)
+					return
+				}
+				next.ServeHTTP(w, req)
+			})
+		},
+	}
+}
+
+// writeHeaders writes the RateLimit-* headers of the IETF draft of rate limit headers.
+func writeHeaders(w http.ResponseWriter, limit Limit, result Result) {
+	header := w.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(limit.Capacity()))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+	header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", limit.Requests, seconds(limit.Period), limit.Capacity()))
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// KeyByIP identifies clients by their remote IP.
+func KeyByIP(req *http.Request) string {
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return "ip:" + host
+	}
+	return "ip:" + req.RemoteAddr
+}
+
+// KeyByUserOrIP identifies clients by their user authenticated by auth, otherwise by their API key in the header,
+// otherwise by their remote IP.
+func KeyByUserOrIP(apiKeyHeader string) func(req *http.Request) string {
+	return func(req *http.Request) string {
+		if user := auth.GetUser(req); user != nil {
+			return "user:" + user.UniqueID()
+		}
+		if apiKeyHeader != "" {
+			if apiKey := req.Header.Get(apiKeyHeader); apiKey != "" {
+				// keys are hashed, so that stores don't hold them
+				sum := sha256.Sum256([]byte(apiKey))
+				return "key:" + hex.EncodeToString(sum[:16])
+			}
+		}
+		return KeyByIP(req)
+	}
+}
+
+// take takes a token of a bucket, refilled since it was updated, and returns the result and the tokens left.
+func take(tokens float64, elapsed time.Duration, limit Limit) (Result, float64) {
+	var (
+		rate  = limit.Rate()
+		burst = float64(limit.Capacity())
+	)
+	tokens = math.Min(burst, tokens+math.Max(0, elapsed.Seconds())*rate)
+
+	var result Result
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	result.Remaining = int(tokens)
+	result.Reset = time.Duration((burst - tokens) / rate * float64(time.Second))
+	return result, tokens
+}
@@ -0,0 +1,103 @@
+package ratelimit_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/middleware/ratelimit"
+)
+
+func TestLimiter(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Config{Limit: ratelimit.Limit{Requests: 2, Period: time.Minute}})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("ok")) })
+
+	engine := appsvr.New(&appsvr.Config{})
+	engine.Use(limiter.Middleware())
+	engine.Mount("/products", ok)
+	engine.Mount("/search", ok, limiter.Override("search", ratelimit.Limit{Requests: 1, Period: time.Minute})...)
+
+	serve := func(path, remoteAddr, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/products", "192.0.2.1:1234", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=60;burst=2", w.Header().Get("RateLimit-Policy"))
+
+	assert.Equal(t, http.StatusOK, serve("/products", "192.0.2.1:1234", "").Code)
+	w = serve("/products", "192.0.2.1:5678", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "retry after 30 seconds")
+
+	// other clients, and routes with overrides, have their own buckets
+	assert.Equal(t, http.StatusOK, serve("/products", "192.0.2.2:1234", "").Code)
+	assert.Equal(t, http.StatusOK, serve("/products", "192.0.2.1:1234", "key").Code)
+	w = serve("/search", "192.0.2.1:1234", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("/search", "192.0.2.1:1234", "").Code)
+}
+
+func TestMemoryStore(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = ratelimit.NewMemoryStore()
+		limit = ratelimit.Limit{Requests: 1, Period: 50 * time.Millisecond, Burst: 2}
+	)
+
+	for i := 0; i < 2; i++ {
+		result, err := store.Take(ctx, "a", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+	result, err := store.Take(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.True(t, result.RetryAfter > 0 && result.RetryAfter <= 50*time.Millisecond)
+
+	// the bucket is refilled over time
+	time.Sleep(result.RetryAfter + 5*time.Millisecond)
+	result, err = store.Take(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
@@ -0,0 +1,102 @@
+package redisstore
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/bhojpur/application/pkg/middleware/ratelimit"
+)
+
+// takeScript takes a token of the bucket of KEYS[1], a hash of its tokens and the time it was updated, by the clock
+// of Redis so that instances share it. ARGV are the rate per second and the burst of the limit
+var takeScript = redis.NewScript(`
+redis.replicate_commands()
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(state[1]) or burst
+local updated = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "updated", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// Store is a ratelimit.Store in Redis, so that the buckets of clients are shared by instances
+//
+//	limiter := ratelimit.New(ratelimit.Config{Store: redisstore.New(redis.NewClient(&redis.Options{Addr: "localhost:6379"})), Limit: limit})
+type Store struct {
+	client redis.UniversalClient
+	// Prefix is the prefix of the keys of buckets, "ratelimit:" by default
+	Prefix string
+	// Timeout limits the duration of each command, 1 second by default
+	Timeout time.Duration
+}
+
+var _ ratelimit.Store = &Store{}
+
+// New initialize a store of a Redis client
+func New(client redis.UniversalClient) *Store {
+	return &Store{client: client, Prefix: "ratelimit:", Timeout: time.Second}
+}
+
+// Take takes a token of the bucket of key
+func (store *Store) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.Timeout)
+	defer cancel()
+
+	rate, burst := limit.Rate(), limit.Capacity()
+	values, err := takeScript.Run(ctx, store.client, []string{store.Prefix + key}, rate, burst).Slice()
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	allowed, _ := values[0].(int64)
+	tokens, err := strconv.ParseFloat(values[1].(string), 64)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	result := ratelimit.Result{
+		Allowed:   allowed == 1,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(burst) - tokens) / rate * float64(time.Second)),
+	}
+	if !result.Allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return result, nil
+}
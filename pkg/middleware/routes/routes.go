@@ -0,0 +1,317 @@
+package routes
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/bhojpur/application/pkg/roles"
+	roles_middleware "github.com/bhojpur/application/pkg/roles/middleware"
+)
+
+// Annotations declares the cross-cutting behavior of a route, the middlewares are assembled from them.
+type Annotations struct {
+	// CacheTTL caches the successful responses of GET requests for the duration, zero disables caching.
+	CacheTTL time.Duration
+	// Permission is the permission mode required to access the route, an empty mode disables the check.
+	Permission roles.PermissionMode
+	// Resource is the name of the permissioner the permission is checked against.
+	Resource string
+	// RateLimit is the name of the rate limit class of the route, an empty class disables rate limiting.
+	RateLimit string
+}
+
+// Route declares the annotations of the requests matching a pattern, like "GET /products/*".
+// Patterns without a method match every method, and a trailing "*" matches every path with the prefix.
+type Route struct {
+	Pattern string
+	Annotations
+}
+
+// RateLimit is a rate limit class, allowing Requests per Period and client, with bursts up to Burst.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// Config configures the middlewares assembled from the route declarations.
+type Config struct {
+	Routes []Route
+	// Permissions are the permissioners of the resources, by name.
+	Permissions map[string]roles.Permissioner
+	// RoleExtractor returns the roles of a request, it is required by routes with a permission.
+	RoleExtractor roles_middleware.RoleExtractor
+	// RateLimits are the rate limit classes, by name.
+	RateLimits map[string]RateLimit
+	// ClientKey identifies the client of a request for rate limiting, the remote IP is used by default.
+	ClientKey func(req *http.Request) string
+}
+
+type route struct {
+	method string
+	path   string
+	prefix bool
+	Annotations
+}
+
+// New validates the route declarations, and returns a middleware which applies, in order, the rate
+// limit, the permission check and the cache of the route matching each request.
+func New(config Config) (func(http.Handler) http.Handler, error) {
+	compiled, err := compileRoutes(config)
+	if err != nil {
+		return nil, err
+	}
+
+	clientKey := config.ClientKey
+	if clientKey == nil {
+		clientKey = remoteIP
+	}
+
+	limiters := newLimiters(config.RateLimits)
+	cache := newResponseCache()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			r, ok := matchRoute(compiled, req)
+			if !ok {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			if r.RateLimit != "" && !limiters.allow(r.RateLimit, clientKey(req)) {
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			if r.Permission != "" {
+				var matchedRoles []interface{}
+				requestRoles := config.RoleExtractor(req)
+				for _, role := range requestRoles {
+					matchedRoles = append(matchedRoles, role)
+				}
+
+				if !config.Permissions[r.Resource].HasPermission(r.Permission, matchedRoles...) {
+					roles_middleware.WriteDenied(w, req, r.Permission, requestRoles)
+					return
+				}
+			}
+
+			if r.CacheTTL > 0 && req.Method == http.MethodGet {
+				cache.serve(w, req, next, r.CacheTTL)
+				return
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}, nil
+}
+
+// compileRoutes parses the route declarations, and reports the problems of all of them at once.
+func compileRoutes(config Config) ([]route, error) {
+	var problems []string
+	seen := map[string]bool{}
+	compiled := make([]route, 0, len(config.Routes))
+	for _, declaration := range config.Routes {
+		r := route{method: "*", Annotations: declaration.Annotations}
+		if parts := strings.SplitN(strings.TrimSpace(declaration.Pattern), " ", 2); len(parts) == 2 {
+			r.method, r.path = strings.ToUpper(parts[0]), strings.TrimSpace(parts[1])
+		} else {
+			r.path = parts[0]
+		}
+
+		if !strings.HasPrefix(r.path, "/") {
+			problems = append(problems, fmt.Sprintf("route %q: path must start with /", declaration.Pattern))
+		}
+		if strings.HasSuffix(r.path, "*") {
+			r.prefix = true
+			r.path = strings.TrimSuffix(r.path, "*")
+		}
+
+		key := fmt.Sprintf("%s %s %v", r.method, r.path, r.prefix)
+		if seen[key] {
+			problems = append(problems, fmt.Sprintf("route %q: declared more than once", declaration.Pattern))
+		}
+		seen[key] = true
+
+		if r.Permission != "" {
+			if _, ok := config.Permissions[r.Resource]; !ok {
+				problems = append(problems, fmt.Sprintf("route %q: unknown resource %q", declaration.Pattern, r.Resource))
+			}
+			if config.RoleExtractor == nil {
+				problems = append(problems, fmt.Sprintf("route %q: a role extractor is required to check permissions", declaration.Pattern))
+			}
+		}
+		if r.RateLimit != "" {
+			if limit, ok := config.RateLimits[r.RateLimit]; !ok {
+				problems = append(problems, fmt.Sprintf("route %q: unknown rate limit class %q", declaration.Pattern, r.RateLimit))
+			} else if limit.Requests <= 0 || limit.Period <= 0 {
+				problems = append(problems, fmt.Sprintf("route %q: rate limit class %q must allow a positive number of requests per period", declaration.Pattern, r.RateLimit))
+			}
+		}
+		if r.CacheTTL < 0 {
+			problems = append(problems, fmt.Sprintf("route %q: cache TTL must not be negative", declaration.Pattern))
+		}
+
+		compiled = append(compiled, r)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid routes: %s", strings.Join(problems, "; "))
+	}
+
+	// the most specific route wins: exact paths first, then longer prefixes, then routes with an explicit method
+	sort.SliceStable(compiled, func(i, j int) bool {
+		if compiled[i].prefix != compiled[j].prefix {
+			return !compiled[i].prefix
+		}
+		if len(compiled[i].path) != len(compiled[j].path) {
+			return len(compiled[i].path) > len(compiled[j].path)
+		}
+		return compiled[i].method != "*" && compiled[j].method == "*"
+	})
+	return compiled, nil
+}
+
+func matchRoute(routes []route, req *http.Request) (route, bool) {
+	for _, r := range routes {
+		if r.method != "*" && r.method != req.Method {
+			continue
+		}
+
+		if (r.prefix && strings.HasPrefix(req.URL.Path, r.path)) || (!r.prefix && req.URL.Path == r.path) {
+			return r, true
+		}
+	}
+	return route{}, false
+}
+
+func remoteIP(req *http.Request) string {
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
+type limiters struct {
+	lock     sync.Mutex
+	classes  map[string]RateLimit
+	limiters map[string]*rate.Limiter
+}
+
+func newLimiters(classes map[string]RateLimit) *limiters {
+	return &limiters{classes: classes, limiters: map[string]*rate.Limiter{}}
+}
+
+func (l *limiters) allow(class, client string) bool {
+	key := class + "/" + client
+
+	l.lock.Lock()
+	limiter, ok := l.limiters[key]
+	if !ok {
+		limit := l.classes[class]
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = limit.Requests
+		}
+		limiter = rate.NewLimiter(rate.Limit(float64(limit.Requests)/limit.Period.Seconds()), burst)
+		l.limiters[key] = limiter
+	}
+	l.lock.Unlock()
+
+	return limiter.Allow()
+}
+
+type cachedResponse struct {
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+type responseCache struct {
+	lock      sync.RWMutex
+	responses map[string]cachedResponse
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{responses: map[string]cachedResponse{}}
+}
+
+// serve writes the cached response of the request until it expires, otherwise it calls next and caches its response if successful.
+func (c *responseCache) serve(w http.ResponseWriter, req *http.Request, next http.Handler, ttl time.Duration) {
+	key := req.URL.RequestURI()
+
+	c.lock.RLock()
+	cached, ok := c.responses[key]
+	c.lock.RUnlock()
+
+	if ok && time.Now().Before(cached.expires) {
+		for name, values := range cached.header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(cached.body)
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(recorder, req)
+	if recorder.status != http.StatusOK {
+		return
+	}
+
+	now := time.Now()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for k, response := range c.responses {
+		if now.After(response.expires) {
+			delete(c.responses, k)
+		}
+	}
+	c.responses[key] = cachedResponse{header: w.Header().Clone(), body: recorder.body.Bytes(), expires: now.Add(ttl)}
+}
+
+// responseRecorder writes a response through, recording its status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
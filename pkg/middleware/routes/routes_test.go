@@ -0,0 +1,110 @@
+package routes_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bhojpur/application/pkg/middleware/routes"
+	"github.com/bhojpur/application/pkg/roles"
+)
+
+func TestRoutes(t *testing.T) {
+	var calls int
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.Write([]byte("ok"))
+	})
+	extractor := func(req *http.Request) []string {
+		if role := req.Header.Get("X-Role"); role != "" {
+			return strings.Split(role, ",")
+		}
+		return nil
+	}
+
+	middleware, err := routes.New(routes.Config{
+		Routes: []routes.Route{
+			{Pattern: "GET /products/*", Annotations: routes.Annotations{CacheTTL: time.Minute}},
+			{Pattern: "DELETE /products/*", Annotations: routes.Annotations{Permission: roles.Delete, Resource: "product"}},
+			{Pattern: "/search", Annotations: routes.Annotations{RateLimit: "search"}},
+		},
+		Permissions:   map[string]roles.Permissioner{"product": roles.Allow(roles.Delete, "admin")},
+		RoleExtractor: extractor,
+		RateLimits:    map[string]routes.RateLimit{"search": {Requests: 1, Period: time.Hour}},
+	})
+	assert.NoError(t, err)
+	handler := middleware(next)
+
+	serve := func(method, path, role string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Role", role)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("cache", func(t *testing.T) {
+		calls = 0
+		assert.Equal(t, "ok", serve(http.MethodGet, "/products/1", "").Body.String())
+		assert.Equal(t, "ok", serve(http.MethodGet, "/products/1", "").Body.String())
+		assert.Equal(t, 1, calls)
+
+		serve(http.MethodGet, "/products/2", "")
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("permission", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodDelete, "/products/1", "visitor").Code)
+		assert.Equal(t, http.StatusOK, serve(http.MethodDelete, "/products/1", "admin").Code)
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/search", "").Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(http.MethodGet, "/search", "").Code)
+	})
+
+	t.Run("undeclared routes", func(t *testing.T) {
+		calls = 0
+		serve(http.MethodGet, "/orders", "")
+		serve(http.MethodGet, "/orders", "")
+		assert.Equal(t, 2, calls)
+	})
+}
+
+func TestInvalidRoutes(t *testing.T) {
+	_, err := routes.New(routes.Config{
+		Routes: []routes.Route{
+			{Pattern: "GET products", Annotations: routes.Annotations{RateLimit: "unknown"}},
+			{Pattern: "DELETE /products/*", Annotations: routes.Annotations{Permission: roles.Delete, Resource: "product"}},
+		},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `route "GET products": path must start with /`)
+	assert.Contains(t, err.Error(), `unknown rate limit class "unknown"`)
+	assert.Contains(t, err.Error(), `unknown resource "product"`)
+	assert.Contains(t, err.Error(), "a role extractor is required")
+}
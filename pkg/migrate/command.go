@@ -0,0 +1,143 @@
+package migrate
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Command returns the command applying and rolling back the migrations of the migrator, to add to the root command
+// of applications, as migrations and models are registered in their code. Its diff subcommand fails on drift
+// between models and the database, e.g. to check it in CI
+//
+//	rootCmd.AddCommand(migrate.Command(migrator))
+func Command(m *Migrator) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "migrate",
+		Short:        "Apply and roll back database migrations",
+		SilenceUsage: true,
+	}
+
+	var dryRun bool
+	up := &cobra.Command{
+		Use:   "up",
+		Short: "Apply pending migrations, then create missing tables and columns of models",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			if dryRun {
+				pending, err := m.Pending()
+				if err != nil {
+					return err
+				}
+				for _, migration := range pending {
+					fmt.Fprintf(out, "pending %v\n", migration.Version)
+				}
+				changes, err := m.Diff()
+				printChanges(out, changes)
+				return err
+			}
+
+			applied, err := m.Up()
+			for _, migration := range applied {
+				fmt.Fprintf(out, "applied %v\n", migration.Version)
+			}
+			if err != nil {
+				return err
+			}
+			changes, err := m.AutoMigrate()
+			printChanges(out, changes)
+			return err
+		},
+	}
+	up.Flags().BoolVar(&dryRun, "dry-run", false, "Print pending migrations and changes of models without applying them")
+
+	var steps int
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Roll back the last applied migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rolledBack, err := m.Down(steps)
+			for _, migration := range rolledBack {
+				fmt.Fprintf(cmd.OutOrStdout(), "rolled back %v\n", migration.Version)
+			}
+			return err
+		},
+	}
+	down.Flags().IntVar(&steps, "steps", 1, "Number of migrations to roll back")
+
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "Print applied and pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statuses, err := m.Status()
+			if err != nil {
+				return err
+			}
+			for _, status := range statuses {
+				switch {
+				case status.Unknown:
+					fmt.Fprintf(cmd.OutOrStdout(), "unknown %v applied at %v\n", status.Version, status.AppliedAt.Format(time.RFC3339))
+				case status.AppliedAt.IsZero():
+					fmt.Fprintf(cmd.OutOrStdout(), "pending %v\n", status.Version)
+				default:
+					fmt.Fprintf(cmd.OutOrStdout(), "applied %v at %v\n", status.Version, status.AppliedAt.Format(time.RFC3339))
+				}
+			}
+			return nil
+		},
+	}
+
+	diff := &cobra.Command{
+		Use:   "diff",
+		Short: "Print changes between models and the database, failing if there are any",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			changes, err := m.Diff()
+			if err != nil {
+				return err
+			}
+			printChanges(cmd.OutOrStdout(), changes)
+			if len(changes) > 0 {
+				return fmt.Errorf("%v changes between models and the database", len(changes))
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(up, down, status, diff)
+	return cmd
+}
+
+func printChanges(out io.Writer, changes []Change) {
+	for _, change := range changes {
+		fmt.Fprintln(out, change)
+		for _, statement := range change.SQL {
+			fmt.Fprintf(out, "  %v\n", statement)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package migrate
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	applog "github.com/bhojpur/application/pkg/log"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// ChangeKind is the kind of a change of the schema
+type ChangeKind string
+
+const (
+	// CreateTable creates the missing table of a model, with its indexes
+	CreateTable ChangeKind = "create table"
+	// AddColumn adds the missing column of a field of a model
+	AddColumn ChangeKind = "add column"
+	// UnknownColumn is a column of the table of a model matching none of its fields, it isn't dropped
+	UnknownColumn ChangeKind = "unknown column"
+)
+
+// Change is a difference between a model and its table
+type Change struct {
+	Kind   ChangeKind
+	Table  string
+	Column string
+	// SQL are the statements applying the change, none if it isn't applied by AutoMigrate
+	SQL []string
+}
+
+func (change Change) String() string {
+	if change.Column == "" {
+		return fmt.Sprintf("%v %v", change.Kind, change.Table)
+	}
+	return fmt.Sprintf("%v %v.%v", change.Kind, change.Table, change.Column)
+}
+
+// Diff returns the changes AutoMigrate would apply to the tables of the registered models, and their columns matching
+// no field, so that drift between models and the database is detected without changing it. Tables and columns are
+// compared, but neither the types of columns, the indexes of existing tables nor the join tables of many to many
+// associations
+func (m *Migrator) Diff() ([]Change, error) {
+	var changes []Change
+	for _, model := range m.models {
+		modelChanges, err := m.plan(model)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, modelChanges...)
+	}
+	return changes, nil
+}
+
+// AutoMigrate creates the missing tables and columns of the registered models, see Diff, it returns the applied
+// changes
+func (m *Migrator) AutoMigrate() ([]Change, error) {
+	changes, err := m.Diff()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Change
+	for _, change := range changes {
+		if len(change.SQL) == 0 {
+			continue
+		}
+		if err := m.apply(change); err != nil {
+			return applied, err
+		}
+		applied = append(applied, change)
+	}
+	return applied, nil
+}
+
+func (m *Migrator) apply(change Change) error {
+	for _, statement := range change.SQL {
+		if err := m.DB.Exec(statement).Error; err != nil {
+			return fmt.Errorf("migrate: failed to %v: %w", change, err)
+		}
+	}
+	log.Info("migrated", applog.String("change", change.String()))
+	return nil
+}
+
+// plan returns the changes of the table of a model
+func (m *Migrator) plan(model interface{}) ([]Change, error) {
+	scope := m.DB.NewScope(model)
+	table, quotedTable := scope.TableName(), scope.QuotedTableName()
+	columns, err := m.columns(quotedTable)
+	if err != nil {
+		return nil, err
+	}
+	if columns == nil {
+		return []Change{{Kind: CreateTable, Table: table, SQL: createTable(scope)}}, nil
+	}
+
+	var (
+		changes []Change
+		exists  = map[string]bool{}
+		known   = map[string]bool{}
+	)
+	for _, column := range columns {
+		exists[strings.ToLower(column)] = true
+	}
+	for _, field := range scope.GetModelStruct().StructFields {
+		if !field.IsNormal {
+			continue
+		}
+		known[strings.ToLower(field.DBName)] = true
+		if !exists[strings.ToLower(field.DBName)] {
+			changes = append(changes, Change{Kind: AddColumn, Table: table, Column: field.DBName, SQL: []string{
+				fmt.Sprintf("ALTER TABLE %v ADD %v %v", quotedTable, scope.Quote(field.DBName), scope.Dialect().DataTypeOf(field)),
+			}})
+		}
+	}
+	for _, column := range columns {
+		if !known[strings.ToLower(column)] {
+			changes = append(changes, Change{Kind: UnknownColumn, Table: table, Column: column})
+		}
+	}
+	return changes, nil
+}
+
+// columns returns the columns of a table, nil if it doesn't exist. Tables are probed, as dialects can't tell if they
+// exist in every database
+func (m *Migrator) columns(quotedTable string) ([]string, error) {
+	rows, err := m.DB.Raw(fmt.Sprintf("SELECT * FROM %v WHERE 1 = 0", quotedTable)).Rows()
+	if err != nil {
+		// the probe fails for missing tables, but for unavailable databases too
+		if err := m.DB.DB().Ping(); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	defer rows.Close()
+	return rows.Columns()
+}
+
+// createTable returns the statements creating the table of the model of scope and its indexes, like orm's AutoMigrate
+func createTable(scope *orm.Scope) []string {
+	var (
+		columns, primaryKeys   []string
+		primaryKeyInType       bool
+		indexes, uniqueIndexes = map[string][]string{}, map[string][]string{}
+	)
+	for _, field := range scope.GetModelStruct().StructFields {
+		if !field.IsNormal {
+			continue
+		}
+		sqlType := scope.Dialect().DataTypeOf(field)
+		if strings.Contains(strings.ToLower(sqlType), "primary key") {
+			primaryKeyInType = true
+		}
+		columns = append(columns, scope.Quote(field.DBName)+" "+sqlType)
+		if field.IsPrimaryKey {
+			primaryKeys = append(primaryKeys, scope.Quote(field.DBName))
+		}
+
+		for kind, tag := range map[string]string{"idx": "INDEX", "uix": "UNIQUE_INDEX"} {
+			names, ok := field.TagSettingsGet(tag)
+			if !ok {
+				continue
+			}
+			for _, name := range strings.Split(names, ",") {
+				if name == tag || name == "" {
+					name = scope.Dialect().BuildKeyName(kind, scope.TableName(), field.DBName)
+				}
+				name, column := scope.Dialect().NormalizeIndexAndColumn(name, field.DBName)
+				if kind == "idx" {
+					indexes[name] = append(indexes[name], scope.Quote(column))
+				} else {
+					uniqueIndexes[name] = append(uniqueIndexes[name], scope.Quote(column))
+				}
+			}
+		}
+	}
+
+	var constraint, options string
+	if len(primaryKeys) > 0 && !primaryKeyInType {
+		constraint = fmt.Sprintf(", PRIMARY KEY (%v)", strings.Join(primaryKeys, ","))
+	}
+	if tableOptions, ok := scope.Get("orm:table_options"); ok {
+		options = " " + tableOptions.(string)
+	}
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %v (%v%v)%v", scope.QuotedTableName(), strings.Join(columns, ","), constraint, options),
+	}
+	statements = append(statements, createIndexes("CREATE INDEX", indexes, scope)...)
+	return append(statements, createIndexes("CREATE UNIQUE INDEX", uniqueIndexes, scope)...)
+}
+
+func createIndexes(create string, indexes map[string][]string, scope *orm.Scope) []string {
+	var names []string
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var statements []string
+	for _, name := range names {
+		statements = append(statements, fmt.Sprintf("%v %v ON %v(%v)", create, name, scope.QuotedTableName(), strings.Join(indexes[name], ", ")))
+	}
+	return statements
+}
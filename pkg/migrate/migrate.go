@@ -0,0 +1,255 @@
+package migrate
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	applog "github.com/bhojpur/application/pkg/log"
+	"github.com/bhojpur/application/pkg/resource"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+var log = applog.New("app.migrate")
+
+// ErrIrreversible is returned when rolling back a migration without Down
+var ErrIrreversible = errors.New("migrate: irreversible migration")
+
+// Migration is a versioned change of the schema, see LoadSQL for migrations of SQL files
+type Migration struct {
+	// Version identifies the migration, migrations are applied in the order of their versions, so they are usually
+	// prefixed by a timestamp, e.g. "20220301120000_add_products_sku"
+	Version string
+	Up      func(tx *orm.DB) error
+	// Down rolls back the migration, it is irreversible if nil
+	Down func(tx *orm.DB) error
+}
+
+// SchemaMigration records an applied migration, in the table schema_migrations
+type SchemaMigration struct {
+	Version   string `orm:"primary_key;size:255"`
+	AppliedAt time.Time
+}
+
+// Status is the status of a migration
+type Status struct {
+	Version string
+	// AppliedAt is the time the migration was applied, zero if it is pending
+	AppliedAt time.Time
+	// Unknown is true for applied migrations which aren't registered, e.g. of a newer release
+	Unknown bool
+}
+
+// Migrator applies versioned migrations, and migrates the models of resources, see Migrate. Migrations each run in
+// a transaction, but DDL statements aren't transactional in every database, e.g. MySQL. Don't run several migrators
+// of a database at once
+type Migrator struct {
+	DB         *orm.DB
+	migrations []*Migration
+	models     []interface{}
+}
+
+// New initialize a migrator of db
+func New(db *orm.DB) *Migrator {
+	return &Migrator{DB: db}
+}
+
+// Add registers migrations, it panics if a version is registered twice
+func (m *Migrator) Add(migrations ...*Migration) {
+	for _, migration := range migrations {
+		if m.migration(migration.Version) != nil {
+			panic(fmt.Sprintf("migrate: migration %v registered twice", migration.Version))
+		}
+		m.migrations = append(m.migrations, migration)
+	}
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+}
+
+// AddResources registers the models of resources, they are auto migrated after the versioned migrations
+func (m *Migrator) AddResources(resources ...resource.Resourcer) {
+	for _, res := range resources {
+		m.AddModels(res.GetResource().Value)
+	}
+}
+
+// AddModels registers models, they are auto migrated after the versioned migrations
+func (m *Migrator) AddModels(values ...interface{}) {
+	m.models = append(m.models, values...)
+}
+
+// Migrations returns the registered migrations, in the order of their versions
+func (m *Migrator) Migrations() []*Migration {
+	return append([]*Migration(nil), m.migrations...)
+}
+
+func (m *Migrator) migration(version string) *Migration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+	return nil
+}
+
+// applied returns the applied migrations, in the order of their versions, the table of SchemaMigration is created if
+// create is true, otherwise none were applied if it doesn't exist
+func (m *Migrator) applied(create bool) ([]SchemaMigration, error) {
+	changes, err := m.plan(&SchemaMigration{})
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		if change.Kind != CreateTable {
+			continue
+		}
+		if !create {
+			return nil, nil
+		}
+		if err := m.apply(change); err != nil {
+			return nil, err
+		}
+	}
+
+	var applied []SchemaMigration
+	if err := m.DB.Order("version").Find(&applied).Error; err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// Status returns the status of the registered and applied migrations, in the order of their versions
+func (m *Migrator) Status() ([]Status, error) {
+	return m.status(false)
+}
+
+func (m *Migrator) status(create bool) ([]Status, error) {
+	applied, err := m.applied(create)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	appliedAt := map[string]time.Time{}
+	for _, record := range applied {
+		appliedAt[record.Version] = record.AppliedAt
+		if m.migration(record.Version) == nil {
+			statuses = append(statuses, Status{Version: record.Version, AppliedAt: record.AppliedAt, Unknown: true})
+		}
+	}
+	for _, migration := range m.migrations {
+		statuses = append(statuses, Status{Version: migration.Version, AppliedAt: appliedAt[migration.Version]})
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Pending returns the migrations not applied yet, in the order of their versions
+func (m *Migrator) Pending() ([]*Migration, error) {
+	return m.pending(false)
+}
+
+func (m *Migrator) pending(create bool) ([]*Migration, error) {
+	statuses, err := m.status(create)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []*Migration
+	for _, status := range statuses {
+		if status.AppliedAt.IsZero() {
+			pending = append(pending, m.migration(status.Version))
+		}
+	}
+	return pending, nil
+}
+
+// Up applies the pending migrations, it returns the applied ones, until one failed
+func (m *Migrator) Up() ([]*Migration, error) {
+	pending, err := m.pending(true)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []*Migration
+	for _, migration := range pending {
+		err := m.DB.Transaction(func(tx *orm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: migration.Version, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migrate: failed to apply %v: %w", migration.Version, err)
+		}
+		log.Info("applied migration", applog.String("version", migration.Version))
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// Down rolls back the last steps applied migrations, it returns the rolled back ones, until one failed
+func (m *Migrator) Down(steps int) ([]*Migration, error) {
+	applied, err := m.applied(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var rolledBack []*Migration
+	for i := len(applied) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		version := applied[i].Version
+		migration := m.migration(version)
+		if migration == nil {
+			return rolledBack, fmt.Errorf("migrate: failed to roll back %v: unknown migration", version)
+		}
+		if migration.Down == nil {
+			return rolledBack, fmt.Errorf("migrate: failed to roll back %v: %w", version, ErrIrreversible)
+		}
+
+		err := m.DB.Transaction(func(tx *orm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Where("version = ?", version).Delete(&SchemaMigration{}).Error
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("migrate: failed to roll back %v: %w", version, err)
+		}
+		log.Info("rolled back migration", applog.String("version", version))
+		rolledBack = append(rolledBack, migration)
+	}
+	return rolledBack, nil
+}
+
+// Migrate applies the pending migrations, then auto migrates the registered models
+func (m *Migrator) Migrate() error {
+	if _, err := m.Up(); err != nil {
+		return err
+	}
+	_, err := m.AutoMigrate()
+	return err
+}
@@ -0,0 +1,154 @@
+package migrate_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bhojpur/application/pkg/migrate"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Product struct {
+	ID   uint
+	Name string
+	SKU  string `orm:"size:32"`
+}
+
+type Category struct {
+	ID   uint
+	Name string `orm:"unique_index"`
+}
+
+func TestMigrator(t *testing.T) {
+	db := utils.SQLiteTestDB(t)
+
+	m := migrate.New(db)
+	m.Add(&migrate.Migration{
+		Version: "001_create_products",
+		Up: func(tx *orm.DB) error {
+			return tx.Exec(`CREATE TABLE products (id INTEGER PRIMARY KEY, name TEXT, legacy TEXT)`).Error
+		},
+		Down: func(tx *orm.DB) error {
+			return tx.Exec(`DROP TABLE products`).Error
+		},
+	})
+	require.NoError(t, m.AddSQL(fstest.MapFS{
+		"002_seed_products.up.sql":   {Data: []byte(`INSERT INTO products (id, name) VALUES (1, 'Blue'); INSERT INTO products (id, name) VALUES (2, 'Red');`)},
+		"002_seed_products.down.sql": {Data: []byte(`DELETE FROM products;`)},
+	}))
+	m.AddModels(&Product{}, &Category{})
+
+	pending, err := m.Pending()
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+	changes, err := m.Diff()
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "create table products", changes[0].String())
+
+	applied, err := m.Up()
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+	count := func() (count int) {
+		require.NoError(t, db.Table("products").Count(&count).Error)
+		return count
+	}
+	assert.Equal(t, 2, count())
+
+	// the last migration is rolled back, and applied again
+	rolledBack, err := m.Down(1)
+	require.NoError(t, err)
+	require.Len(t, rolledBack, 1)
+	assert.Equal(t, "002_seed_products", rolledBack[0].Version)
+	assert.Equal(t, 0, count())
+
+	m.Add(migrate.SQL("003_index_products", `CREATE INDEX idx_products_name ON products(name)`, ""))
+	applied, err = m.Up()
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+	assert.Equal(t, 2, count())
+	_, err = m.Down(1)
+	assert.True(t, errors.Is(err, migrate.ErrIrreversible))
+
+	changes, err = m.Diff()
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	assert.Equal(t, migrate.Change{Kind: migrate.AddColumn, Table: "products", Column: "sku", SQL: []string{`ALTER TABLE "products" ADD "sku" VARCHAR(32)`}}, changes[0])
+	assert.Equal(t, migrate.Change{Kind: migrate.UnknownColumn, Table: "products", Column: "legacy"}, changes[1])
+	assert.Equal(t, migrate.CreateTable, changes[2].Kind)
+	assert.Equal(t, `CREATE UNIQUE INDEX uix_categories_name ON "categories"("name")`, changes[2].SQL[1])
+
+	// the diff isn't applied by a dry run
+	cmd := migrate.Command(m)
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"up", "--dry-run"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "add column products.sku\n  ALTER TABLE \"products\" ADD \"sku\" VARCHAR(32)\n")
+	assert.Contains(t, out.String(), "create table categories\n")
+	changes, err = m.Diff()
+	require.NoError(t, err)
+	assert.Len(t, changes, 3)
+
+	changes, err = m.AutoMigrate()
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+	require.NoError(t, db.Exec(`UPDATE products SET sku = 'B-1' WHERE id = 1`).Error)
+	// AutoMigrate is what is tested here, the ids are explicit as its sqlite tables have no auto-increment primary key
+	require.NoError(t, db.Exec(`INSERT INTO categories (id, name) VALUES (1, 'Paints')`).Error)
+	assert.Error(t, db.Exec(`INSERT INTO categories (id, name) VALUES (2, 'Paints')`).Error)
+
+	// unknown columns are kept, so drift is still reported
+	out.Reset()
+	cmd.SetArgs([]string{"diff"})
+	assert.EqualError(t, cmd.Execute(), "1 changes between models and the database")
+	assert.Equal(t, "unknown column products.legacy\n", out.String())
+
+	out.Reset()
+	cmd.SetArgs([]string{"status"})
+	require.NoError(t, cmd.Execute())
+	assert.Regexp(t, `^applied 001_create_products at .+\napplied 002_seed_products at .+\napplied 003_index_products at .+\n$`, out.String())
+}
+
+func TestLoadSQL(t *testing.T) {
+	migrations, err := migrate.LoadSQL(fstest.MapFS{
+		"001_a.up.sql":   {Data: []byte(`SELECT 1`)},
+		"002_b.up.sql":   {Data: []byte(`SELECT 2`)},
+		"002_b.down.sql": {Data: []byte(`SELECT 3`)},
+		"README.md":      {Data: []byte(`migrations`)},
+	})
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Nil(t, migrations[0].Down)
+	assert.NotNil(t, migrations[1].Down)
+
+	_, err = migrate.LoadSQL(fstest.MapFS{"001_a.down.sql": {Data: []byte(`SELECT 1`)}})
+	assert.EqualError(t, err, "migrate: 001_a.down.sql has no up migration")
+	_, err = migrate.LoadSQL(fstest.MapFS{"001_a.sql": {Data: []byte(`SELECT 1`)}})
+	assert.EqualError(t, err, "migrate: 001_a.sql is neither an up nor a down migration")
+}
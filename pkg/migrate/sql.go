@@ -0,0 +1,109 @@
+package migrate
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+const (
+	upSuffix   = ".up.sql"
+	downSuffix = ".down.sql"
+)
+
+// LoadSQL loads the migrations of the SQL files of the root of fsys, pairs of <version>.up.sql and <version>.down.sql,
+// the down file is optional. Files hold several statements, so MySQL connections need multiStatements
+//
+//	//go:embed migrations
+//	var migrations embed.FS
+//
+//	dir, _ := fs.Sub(migrations, "migrations")
+//	sqlMigrations, err := migrate.LoadSQL(dir)
+func LoadSQL(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		migrations []*Migration
+		downs      = map[string]string{}
+	)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		script, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case strings.HasSuffix(name, upSuffix):
+			migrations = append(migrations, SQL(strings.TrimSuffix(name, upSuffix), string(script), ""))
+		case strings.HasSuffix(name, downSuffix):
+			downs[strings.TrimSuffix(name, downSuffix)] = string(script)
+		default:
+			return nil, fmt.Errorf("migrate: %v is neither an up nor a down migration", name)
+		}
+	}
+
+	for _, migration := range migrations {
+		if down, ok := downs[migration.Version]; ok {
+			migration.Down = execSQL(down)
+			delete(downs, migration.Version)
+		}
+	}
+	for version := range downs {
+		return nil, fmt.Errorf("migrate: %v%v has no up migration", version, downSuffix)
+	}
+	return migrations, nil
+}
+
+// SQL returns a migration executing SQL scripts, it is irreversible if down is empty
+func SQL(version, up, down string) *Migration {
+	migration := &Migration{Version: version, Up: execSQL(up)}
+	if down != "" {
+		migration.Down = execSQL(down)
+	}
+	return migration
+}
+
+func execSQL(script string) func(tx *orm.DB) error {
+	return func(tx *orm.DB) error {
+		return tx.Exec(script).Error
+	}
+}
+
+// AddSQL registers the migrations of the SQL files of the root of fsys, see LoadSQL
+func (m *Migrator) AddSQL(fsys fs.FS) error {
+	migrations, err := LoadSQL(fsys)
+	if err != nil {
+		return err
+	}
+	m.Add(migrations...)
+	return nil
+}
@@ -29,8 +29,6 @@ import (
 
 	"google.golang.org/protobuf/types/known/emptypb"
 
-	b64 "encoding/base64"
-
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
@@ -42,9 +40,9 @@ import (
 	"github.com/bhojpur/service/pkg/utils/logger"
 
 	corev1 "k8s.io/api/core/v1"
-	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 
 	operatorv1pb "github.com/bhojpur/api/pkg/core/v1/operator"
+	app_components "github.com/bhojpur/application/pkg/components"
 	app_credentials "github.com/bhojpur/application/pkg/credentials"
 	componentsapi "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
 	configurationapi "github.com/bhojpur/application/pkg/kubernetes/configuration/v1alpha1"
@@ -135,16 +133,26 @@ func (a *apiServer) ListComponents(ctx context.Context, in *operatorv1pb.ListCom
 	}); err != nil {
 		return nil, errors.Wrap(err, "error getting components")
 	}
+	// components are returned in dependency order so the runtime initializes them in order
+	sorted, err := app_components.SortByDependencies(components.Items)
+	if err != nil {
+		log.Warnf("error ordering components from pod %s/%s: %s", in.Namespace, in.PodName, err)
+	} else {
+		components.Items = sorted
+	}
 	resp := &operatorv1pb.ListComponentResponse{
 		Components: [][]byte{},
 	}
+	items := make([]*componentsapi.Component, len(components.Items))
 	for i := range components.Items {
-		c := components.Items[i] // Make a copy since we will refer to this as a reference in this loop.
-		err := processComponentSecrets(&c, in.Namespace, a.Client)
-		if err != nil {
-			log.Warnf("error processing component %s secrets from pod %s/%s: %s", c.Name, in.Namespace, in.PodName, err)
-			return &operatorv1pb.ListComponentResponse{}, err
-		}
+		items[i] = &components.Items[i]
+	}
+	if err := resolveComponentSecrets(items, in.Namespace, a.Client); err != nil {
+		log.Warnf("error processing component secrets from pod %s/%s: %s", in.Namespace, in.PodName, err)
+		return &operatorv1pb.ListComponentResponse{}, err
+	}
+	for i := range components.Items {
+		c := components.Items[i]
 
 		b, err := json.Marshal(&c)
 		if err != nil {
@@ -157,41 +165,23 @@ func (a *apiServer) ListComponents(ctx context.Context, in *operatorv1pb.ListCom
 }
 
 func processComponentSecrets(component *componentsapi.Component, namespace string, kubeClient client.Client) error {
-	for i, m := range component.Spec.Metadata {
-		if m.SecretKeyRef.Name != "" && (component.Auth.SecretStore == kubernetesSecretStore || component.Auth.SecretStore == "") {
-			var secret corev1.Secret
+	return resolveComponentSecrets([]*componentsapi.Component{component}, namespace, kubeClient)
+}
 
-			err := kubeClient.Get(context.TODO(), types.NamespacedName{
-				Name:      m.SecretKeyRef.Name,
+// resolveComponentSecrets resolves the secret key references of the components in one batch, the values are sent
+// base64 encoded to the runtime.
+func resolveComponentSecrets(components []*componentsapi.Component, namespace string, kubeClient client.Client) error {
+	resolver := app_components.KubernetesSecretResolver{
+		Get: func(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+			var secret corev1.Secret
+			err := kubeClient.Get(ctx, types.NamespacedName{
+				Name:      name,
 				Namespace: namespace,
 			}, &secret)
-			if err != nil {
-				return err
-			}
-
-			key := m.SecretKeyRef.Key
-			if key == "" {
-				key = m.SecretKeyRef.Name
-			}
-
-			val, ok := secret.Data[key]
-			enc := b64.StdEncoding.EncodeToString(val)
-			jsonEnc, err := json.Marshal(enc)
-			if err != nil {
-				return err
-			}
-
-			if ok {
-				component.Spec.Metadata[i].Value = componentsapi.DynamicValue{
-					JSON: v1.JSON{
-						Raw: jsonEnc,
-					},
-				}
-			}
-		}
+			return secret.Data, err
+		},
 	}
-
-	return nil
+	return app_components.ResolveSecretKeyRefs(context.TODO(), components, namespace, resolver, app_components.Base64SecretValue)
 }
 
 // ListSubscriptions returns a list of Bhojpur Application pub/sub subscriptions.
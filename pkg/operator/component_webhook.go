@@ -0,0 +1,163 @@
+package operator
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/bhojpur/application/pkg/components"
+	"github.com/bhojpur/application/pkg/config"
+	componentsapi "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+// componentValidationPath is the path of the validating admission webhook of components.
+const componentValidationPath = "/validate-bhojpur-net-v1alpha1-component"
+
+// componentValidator rejects invalid component manifests, such as unknown types, metadata not matching the schema
+// of their type or malformed secret references, and the components over the quota of their type in their namespace.
+// References to secrets which do not exist yet are only warned about, so that manifests can be applied in any order.
+type componentValidator struct {
+	client client.Client
+	// secrets reads the referenced secrets from the API server, so that secrets are not cached by the webhooks.
+	secrets    client.Reader
+	configName string
+}
+
+func (v *componentValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation == admissionv1.Delete {
+		return admission.Allowed("")
+	}
+
+	var component componentsapi.Component
+	if err := json.Unmarshal(req.Object.Raw, &component); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	report := components.ValidateManifest(component)
+	v.checkSecretRefs(ctx, req.Namespace, component, report)
+	if report.HasErrors() {
+		return admission.Denied(report.String())
+	}
+
+	var warnings []string
+	for _, issue := range report.Issues {
+		warnings = append(warnings, issue.String())
+	}
+	return v.checkQuota(ctx, req, component).WithWarnings(warnings...)
+}
+
+// checkSecretRefs reports the references to missing keys of the secrets of the Kubernetes secret store.
+func (v *componentValidator) checkSecretRefs(ctx context.Context, namespace string, component componentsapi.Component, report *config.ValidationReport) {
+	if v.secrets == nil || (component.Auth.SecretStore != "" && component.Auth.SecretStore != "kubernetes") {
+		return
+	}
+
+	source := fmt.Sprintf("component %s", component.Name)
+	secrets := map[string]*corev1.Secret{}
+	for i, item := range component.Spec.Metadata {
+		ref := item.SecretKeyRef
+		if ref.Name == "" || ref.Key == "" {
+			continue
+		}
+
+		secret, ok := secrets[ref.Name]
+		if !ok {
+			secret = &corev1.Secret{}
+			if err := v.secrets.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+				if !apierrors.IsNotFound(err) {
+					log.Warnf("unable to get secret %s/%s referenced by component %s: %s", namespace, ref.Name, component.Name, err)
+					continue
+				}
+				secret = nil
+			}
+			secrets[ref.Name] = secret
+		}
+
+		path := fmt.Sprintf("spec.metadata[%d].secretKeyRef", i)
+		if secret == nil {
+			report.AddWarning(source, path+".name", fmt.Sprintf("secret %q does not exist in namespace %s", ref.Name, namespace),
+				"create it before the component is loaded")
+			continue
+		}
+		if _, ok := secret.Data[ref.Key]; !ok {
+			keys := make([]string, 0, len(secret.Data))
+			for key := range secret.Data {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			report.AddError(source, path+".key", fmt.Sprintf("secret %q has no key %q", ref.Name, ref.Key),
+				fmt.Sprintf("keys of the secret are %s", strings.Join(keys, ", ")))
+		}
+	}
+}
+
+func (v *componentValidator) checkQuota(ctx context.Context, req admission.Request, component componentsapi.Component) admission.Response {
+	if v.client == nil {
+		return admission.Allowed("")
+	}
+
+	// updates are only checked when they move the component to another type
+	if req.Operation == admissionv1.Update {
+		var old componentsapi.Component
+		if err := json.Unmarshal(req.OldObject.Raw, &old); err == nil && old.Spec.Type == component.Spec.Type {
+			return admission.Allowed("")
+		}
+	}
+
+	quotas, err := loadQuotas(v.configName, v.client)
+	if err != nil {
+		log.Warnf("unable to load quotas, admitting component %s/%s: %s", req.Namespace, req.Name, err)
+		return admission.Allowed("")
+	}
+
+	limit, ok := components.QuotaLimit(quotas.MaxComponentsPerType, component.Spec.Type)
+	if !ok {
+		return admission.Allowed("")
+	}
+
+	var list componentsapi.ComponentList
+	if err := v.client.List(ctx, &list, client.InNamespace(req.Namespace)); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	var count int
+	for _, existing := range list.Items {
+		if existing.Name != req.Name && existing.Spec.Type == component.Spec.Type {
+			count++
+		}
+	}
+	if count < limit {
+		return admission.Allowed("")
+	}
+	return quotaResponse(quotas, componentQuotaMessage(req.Namespace, component.Spec.Type, limit))
+}
@@ -35,6 +35,7 @@ import (
 type Config struct {
 	MTLSEnabled bool
 	Credentials credentials.TLSCredentials
+	Quotas      v1alpha1.QuotaSpec
 }
 
 // LoadConfiguration loads the Kubernetes configuration and returns an Operator Config.
@@ -49,5 +50,6 @@ func LoadConfiguration(name string, client client.Client) (*Config, error) {
 	}
 	return &Config{
 		MTLSEnabled: conf.Spec.MTLSSpec.Enabled,
+		Quotas:      conf.Spec.QuotaSpec,
 	}, nil
 }
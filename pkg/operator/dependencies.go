@@ -0,0 +1,76 @@
+package operator
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bhojpur/application/pkg/components"
+	componentsapi "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+// dependenciesCondition reports whether the dependencies of a component all exist and report the Ready condition.
+func dependenciesCondition(c componentsapi.Component, comps []componentsapi.Component) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               componentsapi.ConditionDependenciesReady,
+		ObservedGeneration: c.Generation,
+	}
+
+	chain, err := components.DependencyChain(c, comps)
+	switch missing := components.MissingDependencies(c, comps); {
+	case err != nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "DependencyCycle"
+		condition.Message = err.Error()
+	case len(missing) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "DependenciesMissing"
+		condition.Message = fmt.Sprintf("waiting for dependencies: %s", strings.Join(missing, ", "))
+	default:
+		if notReady := notReadyDependencies(c, comps); len(notReady) > 0 {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "DependenciesNotReady"
+			condition.Message = fmt.Sprintf("waiting for dependencies to be ready: %s", strings.Join(notReady, ", "))
+			break
+		}
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "DependenciesReady"
+		condition.Message = fmt.Sprintf("initialization order: %s", strings.Join(chain, " -> "))
+	}
+	return condition
+}
+
+// notReadyDependencies returns the dependencies of the component found in comps without a true Ready condition.
+func notReadyDependencies(c componentsapi.Component, comps []componentsapi.Component) []string {
+	var notReady []string
+	for _, dependency := range c.Spec.DependsOn {
+		for _, dep := range comps {
+			if dep.Name == dependency && !meta.IsStatusConditionTrue(dep.Status.Conditions, componentsapi.ConditionReady) {
+				notReady = append(notReady, dependency)
+			}
+		}
+	}
+	return notReady
+}
@@ -0,0 +1,110 @@
+package operator
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	componentsapi "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+func newComponent(name string, ready bool, dependsOn ...string) componentsapi.Component {
+	c := componentsapi.Component{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Generation: 2,
+		},
+		Spec: componentsapi.ComponentSpec{
+			Type:      "state.redis",
+			DependsOn: dependsOn,
+		},
+	}
+	if ready {
+		c.Status.Conditions = []metav1.Condition{{Type: componentsapi.ConditionReady, Status: metav1.ConditionTrue}}
+	}
+	return c
+}
+
+func TestDependenciesCondition(t *testing.T) {
+	testCases := []struct {
+		name    string
+		comps   []componentsapi.Component
+		status  metav1.ConditionStatus
+		reason  string
+		message string
+	}{
+		{
+			name: "ready dependencies",
+			comps: []componentsapi.Component{
+				newComponent("pubsub", false, "secretstore"),
+				newComponent("secretstore", true),
+			},
+			status:  metav1.ConditionTrue,
+			reason:  "DependenciesReady",
+			message: "initialization order: secretstore -> pubsub",
+		},
+		{
+			name: "dependencies not ready",
+			comps: []componentsapi.Component{
+				newComponent("pubsub", false, "secretstore", "statestore"),
+				newComponent("secretstore", false),
+				newComponent("statestore", true),
+			},
+			status:  metav1.ConditionFalse,
+			reason:  "DependenciesNotReady",
+			message: "waiting for dependencies to be ready: secretstore",
+		},
+		{
+			name: "missing dependencies",
+			comps: []componentsapi.Component{
+				newComponent("pubsub", false, "secretstore"),
+			},
+			status:  metav1.ConditionFalse,
+			reason:  "DependenciesMissing",
+			message: "waiting for dependencies: secretstore",
+		},
+		{
+			name: "dependency cycle",
+			comps: []componentsapi.Component{
+				newComponent("pubsub", true, "secretstore"),
+				newComponent("secretstore", true, "pubsub"),
+			},
+			status: metav1.ConditionFalse,
+			reason: "DependencyCycle",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			condition := dependenciesCondition(tc.comps[0], tc.comps)
+			assert.Equal(t, componentsapi.ConditionDependenciesReady, condition.Type)
+			assert.Equal(t, int64(2), condition.ObservedGeneration)
+			assert.Equal(t, tc.status, condition.Status)
+			assert.Equal(t, tc.reason, condition.Reason)
+			if tc.message != "" {
+				assert.Equal(t, tc.message, condition.Message)
+			}
+		})
+	}
+}
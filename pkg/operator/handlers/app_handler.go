@@ -103,6 +103,10 @@ func (h *AppHandler) Init() error {
 		return err
 	}
 
+	if err := h.initComponentController(); err != nil {
+		return err
+	}
+
 	if err := ctrl.NewControllerManagedBy(h.mgr).
 		For(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
@@ -27,6 +27,7 @@ import (
 
 	"k8s.io/client-go/kubernetes/scheme"
 
+	componentsapi "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
 	app_testing "github.com/bhojpur/application/pkg/testing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -208,6 +209,7 @@ func TestInit(t *testing.T) {
 	mgr := app_testing.NewMockManager()
 
 	_ = scheme.AddToScheme(mgr.GetScheme())
+	_ = componentsapi.AddToScheme(mgr.GetScheme())
 
 	handler := NewAppHandler(mgr)
 
@@ -218,7 +220,7 @@ func TestInit(t *testing.T) {
 
 		assert.Nil(t, err)
 
-		assert.Equal(t, 2, len(mgr.GetRunnables()))
+		assert.Equal(t, 3, len(mgr.GetRunnables()))
 
 		srv := &corev1.Service{}
 		val := mgr.GetIndexerFunc(&corev1.Service{})(srv)
@@ -243,8 +245,9 @@ func TestInit(t *testing.T) {
 	})
 
 	t.Run("test wrapper", func(t *testing.T) {
-		deploymentCtl := mgr.GetRunnables()[0]
-		statefulsetCtl := mgr.GetRunnables()[1]
+		// the first runnable is the component controller
+		deploymentCtl := mgr.GetRunnables()[1]
+		statefulsetCtl := mgr.GetRunnables()[2]
 
 		// the runnable is sigs.k8s.io/controller-runtime/pkg/internal/controller.Controller
 		reconciler := reflect.Indirect(reflect.ValueOf(deploymentCtl)).FieldByName("Do").Interface().(*Reconciler)
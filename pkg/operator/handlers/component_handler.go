@@ -0,0 +1,149 @@
+package handlers
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	componentsapi "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+const (
+	// ComponentLabelKey is the label set on every object derived from a Component, with the Component name as value.
+	ComponentLabelKey = "bhojpur.net/component"
+	// ComponentFinalizer is the finalizer removing the objects derived from a Component before it is deleted.
+	ComponentFinalizer = "bhojpur.net/component-cleanup"
+)
+
+// ComponentReconciler adds the cleanup finalizer to Components and removes their derived objects on deletion.
+type ComponentReconciler struct {
+	client.Client
+}
+
+// SetComponentOwner marks obj as derived from the component: the component becomes its controller owner,
+// so Kubernetes garbage collects it, and the component label lets the finalizer find it.
+func (h *AppHandler) SetComponentOwner(component *componentsapi.Component, obj client.Object) error {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[ComponentLabelKey] = component.Name
+	obj.SetLabels(labels)
+
+	return ctrl.SetControllerReference(component, obj, h.Scheme)
+}
+
+func (h *AppHandler) initComponentController() error {
+	return ctrl.NewControllerManagedBy(h.mgr).
+		For(&componentsapi.Component{}).
+		Owns(&corev1.Secret{}).
+		Owns(&corev1.Service{}).
+		Owns(&appsv1.Deployment{}).
+		Complete(&ComponentReconciler{
+			Client: h.Client,
+		})
+}
+
+// Reconcile ensures the cleanup finalizer of a Component, and runs the cleanup when the Component is being deleted.
+func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var component componentsapi.Component
+	if err := r.Get(ctx, req.NamespacedName, &component); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Debugf("component has been deleted, %s", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		log.Errorf("unable to get component, %s, err: %s", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	if component.GetDeletionTimestamp() == nil {
+		if !controllerutil.ContainsFinalizer(&component, ComponentFinalizer) {
+			controllerutil.AddFinalizer(&component, ComponentFinalizer)
+			if err := r.Update(ctx, &component); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if controllerutil.ContainsFinalizer(&component, ComponentFinalizer) {
+		if err := r.deleteDerivedObjects(ctx, &component); err != nil {
+			log.Errorf("unable to delete objects derived from component, %s, err: %s", req.NamespacedName, err)
+			return ctrl.Result{Requeue: true}, err
+		}
+
+		controllerutil.RemoveFinalizer(&component, ComponentFinalizer)
+		if err := r.Update(ctx, &component); err != nil {
+			return ctrl.Result{}, err
+		}
+		log.Debugf("cleaned up objects derived from component, %s", req.NamespacedName)
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *ComponentReconciler) deleteDerivedObjects(ctx context.Context, component *componentsapi.Component) error {
+	opts := []client.ListOption{
+		client.InNamespace(component.Namespace),
+		client.MatchingLabels{ComponentLabelKey: component.Name},
+	}
+
+	var (
+		deployments appsv1.DeploymentList
+		services    corev1.ServiceList
+		secrets     corev1.SecretList
+		derived     []client.Object
+	)
+
+	if err := r.List(ctx, &deployments, opts...); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		derived = append(derived, &deployments.Items[i])
+	}
+
+	if err := r.List(ctx, &services, opts...); err != nil {
+		return err
+	}
+	for i := range services.Items {
+		derived = append(derived, &services.Items[i])
+	}
+
+	if err := r.List(ctx, &secrets, opts...); err != nil {
+		return err
+	}
+	for i := range secrets.Items {
+		derived = append(derived, &secrets.Items[i])
+	}
+
+	for _, obj := range derived {
+		if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package handlers
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/bhojpur/application/pkg/client/clientset/versioned/scheme"
+	componentsapi "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+func getComponentTestScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	assert.NoError(t, scheme.AddToScheme(s))
+	assert.NoError(t, corev1.AddToScheme(s))
+	assert.NoError(t, appsv1.AddToScheme(s))
+	return s
+}
+
+func TestSetComponentOwner(t *testing.T) {
+	component := &componentsapi.Component{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "statestore",
+			Namespace: "default",
+			UID:       "component-uid",
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "statestore-secret",
+			Namespace: "default",
+		},
+	}
+
+	h := &AppHandler{Scheme: getComponentTestScheme(t)}
+	assert.NoError(t, h.SetComponentOwner(component, secret))
+
+	assert.Equal(t, "statestore", secret.Labels[ComponentLabelKey])
+	owner := meta_v1.GetControllerOf(secret)
+	assert.NotNil(t, owner)
+	assert.Equal(t, "statestore", owner.Name)
+	assert.Equal(t, types.UID("component-uid"), owner.UID)
+}
+
+func TestComponentReconcile(t *testing.T) {
+	key := types.NamespacedName{Namespace: "default", Name: "statestore"}
+	component := &componentsapi.Component{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+	}
+	derived := &corev1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "statestore-secret",
+			Namespace: "default",
+			Labels:    map[string]string{ComponentLabelKey: "statestore"},
+		},
+	}
+	unrelated := &corev1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "other-secret",
+			Namespace: "default",
+		},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(getComponentTestScheme(t)).
+		WithObjects(component, derived, unrelated).
+		Build()
+	r := &ComponentReconciler{Client: c}
+	ctx := context.Background()
+
+	t.Run("adds finalizer", func(t *testing.T) {
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		assert.NoError(t, err)
+
+		var got componentsapi.Component
+		assert.NoError(t, c.Get(ctx, key, &got))
+		assert.True(t, controllerutil.ContainsFinalizer(&got, ComponentFinalizer))
+	})
+
+	t.Run("deletes derived objects", func(t *testing.T) {
+		var got componentsapi.Component
+		assert.NoError(t, c.Get(ctx, key, &got))
+		assert.NoError(t, c.Delete(ctx, &got))
+
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		assert.NoError(t, err)
+
+		err = c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "statestore-secret"}, &corev1.Secret{})
+		assert.True(t, apierrors.IsNotFound(err))
+		assert.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "other-secret"}, &corev1.Secret{}))
+
+		if err = c.Get(ctx, key, &got); err == nil {
+			assert.False(t, controllerutil.ContainsFinalizer(&got, ComponentFinalizer))
+		}
+	})
+}
@@ -54,21 +54,34 @@ var (
 
 // RecordServiceCreatedCount records the number of Bhojpur Application service created.
 func RecordServiceCreatedCount(appID string) {
+	if !diag_utils.IsMetricsModuleEnabled(diag_utils.OperatorMetricsModule) {
+		return
+	}
 	stats.RecordWithTags(context.Background(), diag_utils.WithTags(appIDKey, appID), serviceCreatedTotal.M(1))
 }
 
 // RecordServiceDeletedCount records the number of Bhojpur Application service deleted.
 func RecordServiceDeletedCount(appID string) {
+	if !diag_utils.IsMetricsModuleEnabled(diag_utils.OperatorMetricsModule) {
+		return
+	}
 	stats.RecordWithTags(context.Background(), diag_utils.WithTags(appIDKey, appID), serviceDeletedTotal.M(1))
 }
 
 // RecordServiceUpdatedCount records the number of Bhojpur Application service updated.
 func RecordServiceUpdatedCount(appID string) {
+	if !diag_utils.IsMetricsModuleEnabled(diag_utils.OperatorMetricsModule) {
+		return
+	}
 	stats.RecordWithTags(context.Background(), diag_utils.WithTags(appIDKey, appID), serviceUpdatedTotal.M(1))
 }
 
-// InitMetrics initialize the operator service metrics.
+// InitMetrics initialize the operator service metrics, unless the operator metric group is disabled.
 func InitMetrics() error {
+	if !diag_utils.IsMetricsModuleEnabled(diag_utils.OperatorMetricsModule) {
+		return nil
+	}
+
 	err := view.Register(
 		diag_utils.NewMeasureView(serviceCreatedTotal, []tag.Key{appIDKey}, view.Count()),
 		diag_utils.NewMeasureView(serviceDeletedTotal, []tag.Key{appIDKey}, view.Count()),
@@ -34,6 +34,7 @@ import (
 	"github.com/bhojpur/application/pkg/fswatcher"
 	"github.com/bhojpur/application/pkg/health"
 	componentsapi "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+	componentsapi_v1alpha2 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha2"
 	configurationapi "github.com/bhojpur/application/pkg/kubernetes/configuration/v1alpha1"
 	subscriptionsapi_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/subscriptions/v1alpha1"
 	subscriptionsapi_v2alpha1 "github.com/bhojpur/application/pkg/kubernetes/subscriptions/v2alpha1"
@@ -72,6 +73,7 @@ func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 
 	_ = componentsapi.AddToScheme(scheme)
+	_ = componentsapi_v1alpha2.AddToScheme(scheme)
 	_ = configurationapi.AddToScheme(scheme)
 	_ = subscriptionsapi_v1alpha1.AddToScheme(scheme)
 	_ = subscriptionsapi_v2alpha1.AddToScheme(scheme)
@@ -131,6 +133,12 @@ func (o *operator) syncComponent(obj interface{}) {
 	c, ok := obj.(*componentsapi.Component)
 	if ok {
 		log.Debugf("observed component to be synced, %s/%s", c.Namespace, c.Name)
+		if err := o.updateStatusConditions(context.TODO(), c.Namespace); err != nil {
+			log.Warnf("error updating status conditions of components in namespace %s: %s", c.Namespace, err)
+		}
+		if err := o.updateQuotaConditions(context.TODO(), c.Namespace); err != nil {
+			log.Warnf("error updating quota conditions of components in namespace %s: %s", c.Namespace, err)
+		}
 		o.apiServer.OnComponentUpdated(c)
 	}
 }
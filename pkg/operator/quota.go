@@ -0,0 +1,114 @@
+package operator
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/bhojpur/application/pkg/components"
+	componentsapi "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+	"github.com/bhojpur/application/pkg/kubernetes/configuration/v1alpha1"
+)
+
+// loadQuotas returns the quotas of the Bhojpur Application system configuration.
+func loadQuotas(configName string, kubeClient client.Client) (v1alpha1.QuotaSpec, error) {
+	conf, err := LoadConfiguration(configName, kubeClient)
+	if err != nil {
+		return v1alpha1.QuotaSpec{}, err
+	}
+	return conf.Quotas, nil
+}
+
+// quotaResponse denies an object over quota, or admits it with a warning if the quotas are only marked.
+func quotaResponse(quotas v1alpha1.QuotaSpec, message string) admission.Response {
+	if quotas.Enforcement == v1alpha1.QuotaEnforcementMark {
+		return admission.Allowed("").WithWarnings(message)
+	}
+	return admission.Denied(message)
+}
+
+func componentQuotaMessage(namespace, componentType string, limit int) string {
+	return fmt.Sprintf("quota exceeded: namespace %s allows at most %d components of type %s", namespace, limit, componentType)
+}
+
+// updateQuotaConditions reflects the quotas of the component types of a namespace in the status conditions of its components.
+func (o *operator) updateQuotaConditions(ctx context.Context, namespace string) error {
+	if o.config == nil {
+		return nil
+	}
+	maxPerType := o.config.Quotas.MaxComponentsPerType
+
+	var list componentsapi.ComponentList
+	if err := o.client.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	exceeding := components.ExceedingQuota(list.Items, maxPerType)
+
+	for i := range list.Items {
+		c := list.Items[i]
+		existing := meta.FindStatusCondition(c.Status.Conditions, componentsapi.ConditionWithinQuota)
+
+		limit, limited := components.QuotaLimit(maxPerType, c.Spec.Type)
+		if !limited {
+			// the quota was lifted, the condition is removed instead of being kept stale
+			if existing == nil {
+				continue
+			}
+			meta.RemoveStatusCondition(&c.Status.Conditions, componentsapi.ConditionWithinQuota)
+		} else {
+			condition := quotaCondition(c, exceeding[c.Name], limit)
+			if existing != nil && existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+				continue
+			}
+			meta.SetStatusCondition(&c.Status.Conditions, condition)
+		}
+
+		c.Status.ObservedGeneration = c.Generation
+		if err := o.client.Status().Update(ctx, &c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func quotaCondition(c componentsapi.Component, exceeding bool, limit int) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               componentsapi.ConditionWithinQuota,
+		ObservedGeneration: c.Generation,
+	}
+
+	if exceeding {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "QuotaExceeded"
+		condition.Message = componentQuotaMessage(c.Namespace, c.Spec.Type, limit)
+	} else {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "WithinQuota"
+		condition.Message = fmt.Sprintf("namespace %s allows at most %d components of type %s", c.Namespace, limit, c.Spec.Type)
+	}
+	return condition
+}
@@ -0,0 +1,138 @@
+package operator
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/bhojpur/application/pkg/components"
+	componentsapi "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+// updateStatusConditions reflects the validity of the config, the readiness of the dependencies and the resulting
+// readiness of the components of a namespace in their status conditions.
+func (o *operator) updateStatusConditions(ctx context.Context, namespace string) error {
+	var list componentsapi.ComponentList
+	if err := o.client.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+
+	for _, c := range componentConditions(list.Items) {
+		c := c
+		if err := o.client.Status().Update(ctx, &c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// componentConditions computes the status conditions of the components and returns the ones whose conditions changed.
+// The components are visited in dependency order, so the Ready condition of a dependency is known before its dependents.
+func componentConditions(comps []componentsapi.Component) []componentsapi.Component {
+	sorted, err := components.SortByDependencies(comps)
+	if err != nil {
+		// the cycle is reported in the DependenciesReady condition of the components part of it
+		sorted = comps
+	}
+
+	updated := make([]componentsapi.Component, len(sorted))
+	for i := range sorted {
+		updated[i] = *sorted[i].DeepCopy()
+	}
+
+	var changed []componentsapi.Component
+	for i := range updated {
+		c := &updated[i]
+
+		conditions := []metav1.Condition{configCondition(*c)}
+		if len(c.Spec.DependsOn) > 0 {
+			conditions = append(conditions, dependenciesCondition(*c, updated))
+		}
+		conditions = append(conditions, readyCondition(*c, conditions))
+
+		modified := false
+		if len(c.Spec.DependsOn) == 0 && meta.FindStatusCondition(c.Status.Conditions, componentsapi.ConditionDependenciesReady) != nil {
+			// the dependencies were removed, the condition is removed instead of being kept stale
+			meta.RemoveStatusCondition(&c.Status.Conditions, componentsapi.ConditionDependenciesReady)
+			modified = true
+		}
+		for _, condition := range conditions {
+			if existing := meta.FindStatusCondition(c.Status.Conditions, condition.Type); existing != nil &&
+				existing.Status == condition.Status && existing.Reason == condition.Reason &&
+				existing.Message == condition.Message && existing.ObservedGeneration == condition.ObservedGeneration {
+				continue
+			}
+			meta.SetStatusCondition(&c.Status.Conditions, condition)
+			modified = true
+		}
+
+		if modified {
+			c.Status.ObservedGeneration = c.Generation
+			changed = append(changed, *c)
+		}
+	}
+	return changed
+}
+
+// configCondition reports whether the manifest of a component is valid.
+func configCondition(c componentsapi.Component) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               componentsapi.ConditionConfigValid,
+		ObservedGeneration: c.Generation,
+	}
+
+	if report := components.ValidateManifest(c); report.HasErrors() {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidConfig"
+		condition.Message = report.String()
+	} else {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ConfigValid"
+		condition.Message = "the spec and metadata of the component are valid"
+	}
+	return condition
+}
+
+// readyCondition reports a component as ready when all its other conditions are true,
+// or reports the first condition which is not.
+func readyCondition(c componentsapi.Component, conditions []metav1.Condition) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               componentsapi.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: c.Generation,
+		Reason:             "Ready",
+		Message:            "the component is ready to be loaded",
+	}
+
+	for _, other := range conditions {
+		if other.Status != metav1.ConditionTrue {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = other.Reason
+			condition.Message = other.Message
+			break
+		}
+	}
+	return condition
+}
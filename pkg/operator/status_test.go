@@ -0,0 +1,221 @@
+package operator
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	componentsapi "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+func TestComponentConditions(t *testing.T) {
+	invalid := newComponent("pubsub", false)
+	invalid.Spec.Type = "redis"
+
+	testCases := []struct {
+		name  string
+		comps []componentsapi.Component
+		// expected holds the status and reason of the conditions of each component, by component and condition type
+		expected map[string]map[string]string
+	}{
+		{
+			name: "valid components",
+			comps: []componentsapi.Component{
+				newComponent("statestore", false),
+			},
+			expected: map[string]map[string]string{
+				"statestore": {
+					componentsapi.ConditionConfigValid: "True/ConfigValid",
+					componentsapi.ConditionReady:       "True/Ready",
+				},
+			},
+		},
+		{
+			name: "invalid config",
+			comps: []componentsapi.Component{
+				invalid,
+			},
+			expected: map[string]map[string]string{
+				"pubsub": {
+					componentsapi.ConditionConfigValid: "False/InvalidConfig",
+					componentsapi.ConditionReady:       "False/InvalidConfig",
+				},
+			},
+		},
+		{
+			name: "dependencies are evaluated first",
+			comps: []componentsapi.Component{
+				newComponent("pubsub", false, "secretstore"),
+				newComponent("secretstore", false, "vault"),
+				newComponent("vault", false),
+			},
+			expected: map[string]map[string]string{
+				"vault": {
+					componentsapi.ConditionConfigValid: "True/ConfigValid",
+					componentsapi.ConditionReady:       "True/Ready",
+				},
+				"secretstore": {
+					componentsapi.ConditionConfigValid:       "True/ConfigValid",
+					componentsapi.ConditionDependenciesReady: "True/DependenciesReady",
+					componentsapi.ConditionReady:             "True/Ready",
+				},
+				"pubsub": {
+					componentsapi.ConditionConfigValid:       "True/ConfigValid",
+					componentsapi.ConditionDependenciesReady: "True/DependenciesReady",
+					componentsapi.ConditionReady:             "True/Ready",
+				},
+			},
+		},
+		{
+			name: "dependents of invalid components are not ready",
+			comps: []componentsapi.Component{
+				newComponent("statestore", false, "pubsub"),
+				invalid,
+			},
+			expected: map[string]map[string]string{
+				"pubsub": {
+					componentsapi.ConditionConfigValid: "False/InvalidConfig",
+					componentsapi.ConditionReady:       "False/InvalidConfig",
+				},
+				"statestore": {
+					componentsapi.ConditionConfigValid:       "True/ConfigValid",
+					componentsapi.ConditionDependenciesReady: "False/DependenciesNotReady",
+					componentsapi.ConditionReady:             "False/DependenciesNotReady",
+				},
+			},
+		},
+		{
+			name: "dependency cycle",
+			comps: []componentsapi.Component{
+				newComponent("pubsub", false, "secretstore"),
+				newComponent("secretstore", false, "pubsub"),
+			},
+			expected: map[string]map[string]string{
+				"pubsub": {
+					componentsapi.ConditionConfigValid:       "True/ConfigValid",
+					componentsapi.ConditionDependenciesReady: "False/DependencyCycle",
+					componentsapi.ConditionReady:             "False/DependencyCycle",
+				},
+				"secretstore": {
+					componentsapi.ConditionConfigValid:       "True/ConfigValid",
+					componentsapi.ConditionDependenciesReady: "False/DependencyCycle",
+					componentsapi.ConditionReady:             "False/DependencyCycle",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			changed := componentConditions(tc.comps)
+			assert.Len(t, changed, len(tc.expected))
+
+			for _, c := range changed {
+				assert.Equal(t, int64(2), c.Status.ObservedGeneration)
+
+				conditions := map[string]string{}
+				for _, condition := range c.Status.Conditions {
+					conditions[condition.Type] = string(condition.Status) + "/" + condition.Reason
+					assert.Equal(t, int64(2), condition.ObservedGeneration)
+				}
+				assert.Equal(t, tc.expected[c.Name], conditions, c.Name)
+			}
+		})
+	}
+
+	t.Run("unchanged components are not updated", func(t *testing.T) {
+		changed := componentConditions([]componentsapi.Component{newComponent("statestore", false)})
+		assert.Len(t, changed, 1)
+		assert.Empty(t, componentConditions(changed))
+	})
+
+	t.Run("stale dependency conditions are removed", func(t *testing.T) {
+		changed := componentConditions([]componentsapi.Component{
+			newComponent("pubsub", false, "secretstore"),
+			newComponent("secretstore", false),
+		})
+		assert.Len(t, changed, 2)
+
+		pubsub := changed[1]
+		assert.Equal(t, "pubsub", pubsub.Name)
+		pubsub.Spec.DependsOn = nil
+		changed = componentConditions([]componentsapi.Component{pubsub})
+		assert.Len(t, changed, 1)
+		assert.Nil(t, meta.FindStatusCondition(changed[0].Status.Conditions, componentsapi.ConditionDependenciesReady))
+	})
+
+	t.Run("inputs are not modified", func(t *testing.T) {
+		comps := []componentsapi.Component{newComponent("statestore", false)}
+		componentConditions(comps)
+		assert.Empty(t, comps[0].Status.Conditions)
+	})
+}
+
+func TestReadyCondition(t *testing.T) {
+	c := newComponent("pubsub", false)
+	testCases := []struct {
+		name       string
+		conditions []metav1.Condition
+		status     metav1.ConditionStatus
+		reason     string
+	}{
+		{
+			name: "all true",
+			conditions: []metav1.Condition{
+				{Type: componentsapi.ConditionConfigValid, Status: metav1.ConditionTrue, Reason: "ConfigValid"},
+				{Type: componentsapi.ConditionDependenciesReady, Status: metav1.ConditionTrue, Reason: "DependenciesReady"},
+			},
+			status: metav1.ConditionTrue,
+			reason: "Ready",
+		},
+		{
+			name: "first false condition wins",
+			conditions: []metav1.Condition{
+				{Type: componentsapi.ConditionConfigValid, Status: metav1.ConditionFalse, Reason: "InvalidConfig"},
+				{Type: componentsapi.ConditionDependenciesReady, Status: metav1.ConditionFalse, Reason: "DependenciesMissing"},
+			},
+			status: metav1.ConditionFalse,
+			reason: "InvalidConfig",
+		},
+		{
+			name: "unknown conditions are not ready",
+			conditions: []metav1.Condition{
+				{Type: componentsapi.ConditionConfigValid, Status: metav1.ConditionTrue, Reason: "ConfigValid"},
+				{Type: componentsapi.ConditionDependenciesReady, Status: metav1.ConditionUnknown, Reason: "Pending"},
+			},
+			status: metav1.ConditionFalse,
+			reason: "Pending",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			condition := readyCondition(c, tc.conditions)
+			assert.Equal(t, componentsapi.ConditionReady, condition.Type)
+			assert.Equal(t, tc.status, condition.Status)
+			assert.Equal(t, tc.reason, condition.Reason)
+		})
+	}
+}
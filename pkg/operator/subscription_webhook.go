@@ -0,0 +1,73 @@
+package operator
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	subscriptionsapi_v2alpha1 "github.com/bhojpur/application/pkg/kubernetes/subscriptions/v2alpha1"
+)
+
+// subscriptionValidationPath is the path of the validating admission webhook of subscriptions.
+const subscriptionValidationPath = "/validate-bhojpur-net-subscription"
+
+// subscriptionValidator rejects the subscriptions over the quota of their namespace.
+type subscriptionValidator struct {
+	client     client.Client
+	configName string
+}
+
+func (v *subscriptionValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Create || v.client == nil {
+		return admission.Allowed("")
+	}
+
+	quotas, err := loadQuotas(v.configName, v.client)
+	if err != nil {
+		log.Warnf("unable to load quotas, admitting subscription %s/%s: %s", req.Namespace, req.Name, err)
+		return admission.Allowed("")
+	}
+	if quotas.MaxSubscriptions <= 0 {
+		return admission.Allowed("")
+	}
+
+	var list subscriptionsapi_v2alpha1.SubscriptionList
+	if err := v.client.List(ctx, &list, client.InNamespace(req.Namespace)); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	var count int
+	for _, existing := range list.Items {
+		if existing.Name != req.Name {
+			count++
+		}
+	}
+	if count < quotas.MaxSubscriptions {
+		return admission.Allowed("")
+	}
+	return quotaResponse(quotas, fmt.Sprintf("quota exceeded: namespace %s allows at most %d subscriptions", req.Namespace, quotas.MaxSubscriptions))
+}
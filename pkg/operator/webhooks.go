@@ -35,14 +35,17 @@ import (
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	componentsapi_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+	componentsapi_v1alpha2 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha2"
 	subscriptionsapi_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/subscriptions/v1alpha1"
 	subscriptionsapi_v2alpha1 "github.com/bhojpur/application/pkg/kubernetes/subscriptions/v2alpha1"
 )
 
 const webhookCAName = "app-webhook-ca"
 
-func RunWebhooks(enableLeaderElection bool) {
+func RunWebhooks(config string, enableLeaderElection bool) {
 	conf, err := ctrl.GetConfig()
 	if err != nil {
 		log.Fatalf("unable to get controller runtime configuration, err: %s", err)
@@ -74,6 +77,18 @@ func RunWebhooks(enableLeaderElection bool) {
 			Complete(); err != nil {
 			log.Fatalf("unable to create webhook Subscriptions v2alpha1: %v", err)
 		}
+		if err = ctrl.NewWebhookManagedBy(mgr).
+			For(&componentsapi_v1alpha1.Component{}).
+			Complete(); err != nil {
+			log.Fatalf("unable to create webhook Components v1alpha1: %v", err)
+		}
+		if err = ctrl.NewWebhookManagedBy(mgr).
+			For(&componentsapi_v1alpha2.Component{}).
+			Complete(); err != nil {
+			log.Fatalf("unable to create webhook Components v1alpha2: %v", err)
+		}
+		mgr.GetWebhookServer().Register(componentValidationPath, &webhook.Admission{Handler: &componentValidator{client: mgr.GetClient(), secrets: mgr.GetAPIReader(), configName: config}})
+		mgr.GetWebhookServer().Register(subscriptionValidationPath, &webhook.Admission{Handler: &subscriptionValidator{client: mgr.GetClient(), configName: config}})
 	}
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -85,7 +100,7 @@ func RunWebhooks(enableLeaderElection bool) {
 
 	ctx := ctrl.SetupSignalHandler()
 
-	go patchCRDs(ctx, conf, "subscriptions.bhojpur.net")
+	go patchCRDs(ctx, conf, "subscriptions.bhojpur.net", "components.bhojpur.net")
 
 	log.Info("starting webhooks")
 	if err := mgr.Start(ctx); err != nil {
@@ -0,0 +1,329 @@
+package profiling
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ErrUnknownSampleType is returned when the profiles have no samples of the requested type.
+var ErrUnknownSampleType = errors.New("unknown sample type")
+
+// Flamegraph is the merge of the samples of profiles, as stacks ready to be drawn as a flame graph.
+type Flamegraph struct {
+	Kind       Kind      `json:"kind"`
+	SampleType string    `json:"sampleType"`
+	Unit       string    `json:"unit"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	Profiles   int       `json:"profiles"`
+	Stacks     []Stack   `json:"stacks"`
+}
+
+// Stack is a call stack, from the root function to the leaf one, and the sum of its sample values.
+type Stack struct {
+	Frames []string `json:"frames"`
+	Value  int64    `json:"value"`
+}
+
+// WriteFolded writes the stacks in the folded format of flame graph tools, a `root;caller;leaf value` line per stack.
+func (f *Flamegraph) WriteFolded(w io.Writer) error {
+	for _, stack := range f.Stacks {
+		if _, err := fmt.Fprintf(w, "%s %d\n", strings.Join(stack.Frames, ";"), stack.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flamegraph merges the profiles of kind taken in the window. sampleType selects the values of the samples, like
+// `alloc_space` for heap profiles, the last type of the profiles by default: CPU time and in use heap space.
+// The values of the samples of all profiles are summed.
+func (p *Profiler) Flamegraph(ctx context.Context, kind Kind, from, to time.Time, sampleType string) (*Flamegraph, error) {
+	snapshots, err := p.Snapshots(ctx, kind, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	flamegraph := &Flamegraph{Kind: kind, SampleType: sampleType, From: from, To: to, Stacks: []Stack{}}
+	values := map[string]int64{}
+	for _, snapshot := range snapshots {
+		data, err := p.storage.Get(ctx, snapshot.Key)
+		if errors.Is(err, ErrNotFound) {
+			// pruned since listed
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		prof, err := parseProfile(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing profile %s: %w", snapshot.Key, err)
+		}
+
+		index, unit, ok := prof.sampleIndex(flamegraph.SampleType)
+		if !ok {
+			return nil, fmt.Errorf("%w %q of profile %s", ErrUnknownSampleType, flamegraph.SampleType, snapshot.Key)
+		}
+		flamegraph.SampleType, flamegraph.Unit = prof.sampleTypes[index].name, unit
+
+		prof.fold(index, values)
+		flamegraph.Profiles++
+	}
+
+	for frames, value := range values {
+		flamegraph.Stacks = append(flamegraph.Stacks, Stack{Frames: strings.Split(frames, ";"), Value: value})
+	}
+	sort.Slice(flamegraph.Stacks, func(i, j int) bool {
+		return strings.Join(flamegraph.Stacks[i].Frames, ";") < strings.Join(flamegraph.Stacks[j].Frames, ";")
+	})
+	return flamegraph, nil
+}
+
+// profile is the part of a pprof profile, see https://github.com/google/pprof/blob/main/proto/profile.proto,
+// needed to fold its samples.
+type profile struct {
+	sampleTypes []sampleType
+	samples     []sample
+	// locations are the function IDs of the lines of the locations, by location ID. Inlined functions come first.
+	locations map[uint64][]uint64
+	// functions are the name indexes in strings of the functions, by function ID.
+	functions map[uint64]int64
+	strings   []string
+}
+
+type sampleType struct {
+	name, unit string
+	typ, un    int64
+}
+
+type sample struct {
+	locations []uint64
+	values    []int64
+}
+
+// sampleIndex returns the index of the values of the sample type, of the last one if name is empty.
+func (prof *profile) sampleIndex(name string) (int, string, bool) {
+	if len(prof.sampleTypes) == 0 {
+		return 0, "", false
+	}
+	if name == "" {
+		last := len(prof.sampleTypes) - 1
+		return last, prof.sampleTypes[last].unit, true
+	}
+	for idx, typ := range prof.sampleTypes {
+		if typ.name == name {
+			return idx, typ.unit, true
+		}
+	}
+	return 0, "", false
+}
+
+// fold adds the values at index of the samples to the values of their folded stacks.
+func (prof *profile) fold(index int, values map[string]int64) {
+	for _, s := range prof.samples {
+		if index >= len(s.values) || s.values[index] == 0 {
+			continue
+		}
+
+		// samples list locations from the leaf, and locations list inlined functions first
+		var frames []string
+		for i := len(s.locations) - 1; i >= 0; i-- {
+			functions := prof.locations[s.locations[i]]
+			for j := len(functions) - 1; j >= 0; j-- {
+				frames = append(frames, prof.str(prof.functions[functions[j]]))
+			}
+		}
+		if len(frames) == 0 {
+			frames = []string{"unknown"}
+		}
+		values[strings.Join(frames, ";")] += s.values[index]
+	}
+}
+
+func (prof *profile) str(index int64) string {
+	if index <= 0 || index >= int64(len(prof.strings)) {
+		return "unknown"
+	}
+	// folded stacks are separated by semicolons and spaces
+	return strings.NewReplacer(";", ":", " ", "_").Replace(prof.strings[index])
+}
+
+// parseProfile parses a pprof profile, gzipped as written by runtime/pprof or not.
+func parseProfile(data []byte) (*profile, error) {
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = ioutil.ReadAll(reader); err != nil {
+			return nil, err
+		}
+	}
+
+	prof := &profile{locations: map[uint64][]uint64{}, functions: map[uint64]int64{}}
+	err := decodeFields(data, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+		switch num {
+		case 1: // sample_type
+			var st sampleType
+			err := decodeFields(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+				switch num {
+				case 1:
+					st.typ = int64(v)
+				case 2:
+					st.un = int64(v)
+				}
+				return nil
+			})
+			prof.sampleTypes = append(prof.sampleTypes, st)
+			return err
+		case 2: // sample
+			var s sample
+			err := decodeFields(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+				if num != 1 && num != 2 {
+					return nil
+				}
+
+				values, err := varints(typ, v, b)
+				if num == 1 {
+					s.locations = append(s.locations, values...)
+				} else {
+					for _, value := range values {
+						s.values = append(s.values, int64(value))
+					}
+				}
+				return err
+			})
+			prof.samples = append(prof.samples, s)
+			return err
+		case 4: // location
+			var (
+				id        uint64
+				functions []uint64
+			)
+			err := decodeFields(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+				switch num {
+				case 1:
+					id = v
+				case 4: // line
+					return decodeFields(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+						if num == 1 {
+							functions = append(functions, v)
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+			prof.locations[id] = functions
+			return err
+		case 5: // function
+			var id uint64
+			var name int64
+			err := decodeFields(b, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+				switch num {
+				case 1:
+					id = v
+				case 2:
+					name = int64(v)
+				}
+				return nil
+			})
+			prof.functions[id] = name
+			return err
+		case 6: // string_table
+			prof.strings = append(prof.strings, string(b))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range prof.sampleTypes {
+		prof.sampleTypes[idx].name = prof.str(prof.sampleTypes[idx].typ)
+		prof.sampleTypes[idx].unit = prof.str(prof.sampleTypes[idx].un)
+	}
+	return prof, nil
+}
+
+// decodeFields calls fn with the fields of a protobuf message, v is the value of varint fields, b the one of
+// length delimited fields.
+func decodeFields(data []byte, fn func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		var (
+			v uint64
+			b []byte
+		)
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			b, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if err := fn(num, typ, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// varints returns the values of a repeated varint field, packed or not.
+func varints(typ protowire.Type, v uint64, b []byte) ([]uint64, error) {
+	if typ == protowire.VarintType {
+		return []uint64{v}, nil
+	}
+
+	var values []uint64
+	for len(b) > 0 {
+		value, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		values = append(values, value)
+		b = b[n:]
+	}
+	return values, nil
+}
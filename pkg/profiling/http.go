@@ -0,0 +1,90 @@
+package profiling
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Handler returns the HTTP API serving the flame graph of a window, it should be mounted behind an
+// authorization of the operators:
+//
+//	GET /?kind=cpu&from=2022-03-01T10:00:00Z&to=2022-03-01T11:00:00Z&sample=cpu&format=folded
+//
+// kind is cpu or heap, cpu by default. from and to are RFC 3339 times, the last hour by default. sample is the
+// sample type, like alloc_space for heap profiles. format is folded for the folded stacks of flame graph tools,
+// or json, the default.
+func (p *Profiler) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := req.URL.Query()
+		kind := Kind(query.Get("kind"))
+		switch kind {
+		case "":
+			kind = CPU
+		case CPU, Heap:
+		default:
+			http.Error(w, "invalid kind", http.StatusBadRequest)
+			return
+		}
+
+		to := time.Now()
+		from := to.Add(-time.Hour)
+		for name, value := range map[string]*time.Time{"from": &from, "to": &to} {
+			if param := query.Get(name); param != "" {
+				parsed, err := time.Parse(time.RFC3339, param)
+				if err != nil {
+					http.Error(w, "invalid "+name, http.StatusBadRequest)
+					return
+				}
+				*value = parsed
+			}
+		}
+
+		flamegraph, err := p.Flamegraph(req.Context(), kind, from, to, query.Get("sample"))
+		if err != nil {
+			if errors.Is(err, ErrUnknownSampleType) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		switch query.Get("format") {
+		case "folded":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			flamegraph.WriteFolded(w)
+		case "", "json":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(flamegraph)
+		default:
+			http.Error(w, "invalid format", http.StatusBadRequest)
+		}
+	})
+}
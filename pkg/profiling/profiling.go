@@ -0,0 +1,239 @@
+package profiling
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.profiling")
+
+// Kind is the kind of a profile.
+type Kind string
+
+const (
+	// CPU profiles sample the stacks running on CPU for Config.CPUDuration.
+	CPU Kind = "cpu"
+	// Heap profiles snapshot the allocations of the heap.
+	Heap Kind = "heap"
+)
+
+// Kinds are the kinds of profiles collected.
+var Kinds = []Kind{CPU, Heap}
+
+// keyTimeFormat formats the time of a profile in its storage key, keys sort in time order.
+const keyTimeFormat = "20060102T150405.000000000Z"
+
+// ErrNotFound is returned by storages for missing keys.
+var ErrNotFound = errors.New("profile not found")
+
+// Storage stores the profiles, by key. Keys are slash separated paths like `cpu/20220301T101500.000000000Z.pb.gz`.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns the data of the key, ErrNotFound if it doesn't exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List returns the keys starting with prefix, sorted.
+	List(ctx context.Context, prefix string) ([]string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Config configures the profiler.
+type Config struct {
+	// Interval is the average time between two samples, 5 minutes by default.
+	Interval time.Duration
+	// CPUDuration is how long the CPU is profiled per sample, 10 seconds by default, capped to half the interval.
+	CPUDuration time.Duration
+	// Retention is how long profiles are kept, 24 hours by default.
+	Retention time.Duration
+}
+
+// Snapshot is a stored profile.
+type Snapshot struct {
+	Kind Kind      `json:"kind"`
+	Time time.Time `json:"time"`
+	Key  string    `json:"key"`
+}
+
+// Profiler samples CPU and heap profiles of the process and stores them.
+type Profiler struct {
+	storage Storage
+	config  Config
+	rand    *rand.Rand
+	mu      sync.Mutex
+}
+
+// New returns a profiler storing profiles in storage.
+func New(storage Storage, config Config) *Profiler {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Minute
+	}
+	if config.CPUDuration <= 0 {
+		config.CPUDuration = 10 * time.Second
+	}
+	if config.CPUDuration > config.Interval/2 {
+		config.CPUDuration = config.Interval / 2
+	}
+	if config.Retention <= 0 {
+		config.Retention = 24 * time.Hour
+	}
+	return &Profiler{storage: storage, config: config, rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// Run samples profiles until ctx is done. Samples are blue noise: one sample is taken at a random time of
+// every interval, and two samples are at least half an interval apart, so that samples cover the time
+// evenly without aliasing with periodic jobs, like a fixed period would.
+func (p *Profiler) Run(ctx context.Context) {
+	var (
+		start = time.Now()
+		last  time.Time
+	)
+
+	for {
+		at := p.nextSample(start, last)
+		timer := time.NewTimer(time.Until(at))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := p.Collect(ctx); err != nil {
+			log.Warnf("error collecting profiles: %s", err)
+		}
+		if err := p.Prune(ctx, time.Now().Add(-p.config.Retention)); err != nil {
+			log.Warnf("error pruning profiles: %s", err)
+		}
+		start, last = start.Add(p.config.Interval), at
+	}
+}
+
+// nextSample returns the time of the sample of the interval starting at start, last is the time of the previous sample.
+func (p *Profiler) nextSample(start, last time.Time) time.Time {
+	p.mu.Lock()
+	offset := time.Duration(p.rand.Int63n(int64(p.config.Interval - p.config.CPUDuration + 1)))
+	p.mu.Unlock()
+
+	at := start.Add(offset)
+	if !last.IsZero() && at.Sub(last) < p.config.Interval/2 {
+		at = last.Add(p.config.Interval / 2)
+	}
+	return at
+}
+
+// Collect profiles the CPU for Config.CPUDuration and snapshots the heap, and stores the profiles. The CPU profile
+// is skipped if CPU profiling is already enabled, like by the pprof endpoint.
+func (p *Profiler) Collect(ctx context.Context) error {
+	var errs []string
+
+	var cpu bytes.Buffer
+	at := time.Now()
+	if err := pprof.StartCPUProfile(&cpu); err != nil {
+		log.Warnf("skipping CPU profile: %s", err)
+	} else {
+		timer := time.NewTimer(p.config.CPUDuration)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+		pprof.StopCPUProfile()
+
+		if err := p.storage.Put(ctx, Key(CPU, at), cpu.Bytes()); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	var heap bytes.Buffer
+	at = time.Now()
+	if err := pprof.Lookup("heap").WriteTo(&heap, 0); err != nil {
+		errs = append(errs, err.Error())
+	} else if err := p.storage.Put(ctx, Key(Heap, at), heap.Bytes()); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Snapshots returns the profiles of kind taken in the window, from and to included, in time order.
+func (p *Profiler) Snapshots(ctx context.Context, kind Kind, from, to time.Time) ([]Snapshot, error) {
+	keys, err := p.storage.List(ctx, string(kind)+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []Snapshot{}
+	for _, key := range keys {
+		snapshot, ok := parseKey(key)
+		if !ok || snapshot.Kind != kind || snapshot.Time.Before(from) || snapshot.Time.After(to) {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+// Prune deletes the profiles taken before the time.
+func (p *Profiler) Prune(ctx context.Context, before time.Time) error {
+	for _, kind := range Kinds {
+		snapshots, err := p.Snapshots(ctx, kind, time.Time{}, before.Add(-time.Nanosecond))
+		if err != nil {
+			return err
+		}
+		for _, snapshot := range snapshots {
+			if err := p.storage.Delete(ctx, snapshot.Key); err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Key returns the storage key of a profile of kind taken at the time.
+func Key(kind Kind, at time.Time) string {
+	return fmt.Sprintf("%s/%s.pb.gz", kind, at.UTC().Format(keyTimeFormat))
+}
+
+func parseKey(key string) (Snapshot, bool) {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 || !strings.HasSuffix(parts[1], ".pb.gz") {
+		return Snapshot{}, false
+	}
+
+	at, err := time.Parse(keyTimeFormat, strings.TrimSuffix(parts[1], ".pb.gz"))
+	if err != nil {
+		return Snapshot{}, false
+	}
+	return Snapshot{Kind: Kind(parts[0]), Time: at, Key: key}, true
+}
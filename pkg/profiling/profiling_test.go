@@ -0,0 +1,173 @@
+package profiling_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/bhojpur/application/pkg/profiling"
+)
+
+func message(fields ...func([]byte) []byte) []byte {
+	var b []byte
+	for _, field := range fields {
+		b = field(b)
+	}
+	return b
+}
+
+func varint(num protowire.Number, v uint64) func([]byte) []byte {
+	return func(b []byte) []byte {
+		return protowire.AppendVarint(protowire.AppendTag(b, num, protowire.VarintType), v)
+	}
+}
+
+func bytesField(num protowire.Number, v []byte) func([]byte) []byte {
+	return func(b []byte) []byte {
+		return protowire.AppendBytes(protowire.AppendTag(b, num, protowire.BytesType), v)
+	}
+}
+
+func packed(num protowire.Number, values ...uint64) func([]byte) []byte {
+	var b []byte
+	for _, v := range values {
+		b = protowire.AppendVarint(b, v)
+	}
+	return bytesField(num, b)
+}
+
+// testProfile returns a profile of main calling foo, in which bar is inlined, and of main alone.
+func testProfile() []byte {
+	var fields []func([]byte) []byte
+	for _, s := range []string{"", "samples", "count", "cpu", "nanoseconds", "main", "foo", "bar"} {
+		fields = append(fields, bytesField(6, []byte(s)))
+	}
+	fields = append(fields,
+		bytesField(1, message(varint(1, 1), varint(2, 2))),
+		bytesField(1, message(varint(1, 3), varint(2, 4))),
+		bytesField(5, message(varint(1, 1), varint(2, 5))),
+		bytesField(5, message(varint(1, 2), varint(2, 6))),
+		bytesField(5, message(varint(1, 3), varint(2, 7))),
+		// location 1 is bar inlined in foo, location 2 is main
+		bytesField(4, message(varint(1, 1), bytesField(4, message(varint(1, 3))), bytesField(4, message(varint(1, 2))))),
+		bytesField(4, message(varint(1, 2), bytesField(4, message(varint(1, 1))))),
+		bytesField(2, message(packed(1, 1, 2), packed(2, 1, 50))),
+		bytesField(2, message(varint(1, 2), varint(2, 1), varint(2, 30))),
+	)
+	return message(fields...)
+}
+
+func TestFlamegraph(t *testing.T) {
+	storage, err := profiling.NewFileStorage(t.TempDir())
+	require.NoError(t, err)
+	profiler := profiling.New(storage, profiling.Config{})
+
+	ctx := context.Background()
+	at := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, storage.Put(ctx, profiling.Key(profiling.CPU, at), testProfile()))
+	require.NoError(t, storage.Put(ctx, profiling.Key(profiling.CPU, at.Add(time.Minute)), testProfile()))
+	require.NoError(t, storage.Put(ctx, profiling.Key(profiling.CPU, at.Add(time.Hour)), testProfile()))
+
+	flamegraph, err := profiler.Flamegraph(ctx, profiling.CPU, at, at.Add(time.Minute), "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, flamegraph.Profiles)
+	assert.Equal(t, "cpu", flamegraph.SampleType)
+	assert.Equal(t, "nanoseconds", flamegraph.Unit)
+	assert.Equal(t, []profiling.Stack{
+		{Frames: []string{"main"}, Value: 60},
+		{Frames: []string{"main", "foo", "bar"}, Value: 100},
+	}, flamegraph.Stacks)
+
+	var folded bytes.Buffer
+	require.NoError(t, flamegraph.WriteFolded(&folded))
+	assert.Equal(t, "main 60\nmain;foo;bar 100\n", folded.String())
+
+	flamegraph, err = profiler.Flamegraph(ctx, profiling.CPU, at, at, "samples")
+	require.NoError(t, err)
+	assert.Equal(t, []profiling.Stack{
+		{Frames: []string{"main"}, Value: 1},
+		{Frames: []string{"main", "foo", "bar"}, Value: 1},
+	}, flamegraph.Stacks)
+
+	_, err = profiler.Flamegraph(ctx, profiling.CPU, at, at, "alloc_space")
+	assert.ErrorIs(t, err, profiling.ErrUnknownSampleType)
+}
+
+func TestCollectAndPrune(t *testing.T) {
+	storage, err := profiling.NewFileStorage(t.TempDir())
+	require.NoError(t, err)
+	profiler := profiling.New(storage, profiling.Config{Interval: time.Second, CPUDuration: 10 * time.Millisecond})
+
+	ctx := context.Background()
+	start := time.Now().Add(-time.Second)
+	require.NoError(t, profiler.Collect(ctx))
+
+	for _, kind := range profiling.Kinds {
+		snapshots, err := profiler.Snapshots(ctx, kind, start, time.Now())
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1, kind)
+
+		flamegraph, err := profiler.Flamegraph(ctx, kind, start, time.Now(), "")
+		require.NoError(t, err)
+		assert.Equal(t, 1, flamegraph.Profiles)
+	}
+
+	require.NoError(t, profiler.Prune(ctx, time.Now().Add(time.Second)))
+	keys, err := storage.List(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestFileStorageRejectsEscapingKeys(t *testing.T) {
+	storage, err := profiling.NewFileStorage(t.TempDir())
+	require.NoError(t, err)
+
+	assert.Error(t, storage.Put(context.Background(), "../cpu/profile.pb.gz", []byte("x")))
+	_, err = storage.Get(context.Background(), "cpu/missing.pb.gz")
+	assert.ErrorIs(t, err, profiling.ErrNotFound)
+}
+
+func TestHandler(t *testing.T) {
+	storage, err := profiling.NewFileStorage(t.TempDir())
+	require.NoError(t, err)
+	profiler := profiling.New(storage, profiling.Config{})
+
+	at := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, storage.Put(context.Background(), profiling.Key(profiling.CPU, at), testProfile()))
+
+	w := httptest.NewRecorder()
+	profiler.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?from=2022-03-01T09:00:00Z&to=2022-03-01T11:00:00Z&format=folded", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "main 30\nmain;foo;bar 50\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	profiler.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?kind=goroutine", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
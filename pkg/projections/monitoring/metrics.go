@@ -0,0 +1,89 @@
+package monitoring
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	diag_utils "github.com/bhojpur/application/pkg/diagnostics/utils"
+)
+
+var (
+	eventsProcessedTotal = stats.Int64(
+		"projections/events_processed_total",
+		"The total number of events processed by projections.",
+		stats.UnitDimensionless)
+	lagEvents = stats.Int64(
+		"projections/lag_events",
+		"The number of events a projection is behind.",
+		stats.UnitDimensionless)
+	lagSeconds = stats.Float64(
+		"projections/lag_seconds",
+		"The age of the latest event processed by a projection, when it is behind.",
+		stats.UnitSeconds)
+
+	projectionKey = tag.MustNewKey("projection")
+	successKey    = tag.MustNewKey("success")
+)
+
+// RecordEventProcessed records an event processed by a projection.
+func RecordEventProcessed(projection string, err error) {
+	if !diag_utils.IsMetricsModuleEnabled(diag_utils.ProjectionsMetricsModule) {
+		return
+	}
+
+	stats.RecordWithTags(
+		context.Background(),
+		diag_utils.WithTags(projectionKey, projection, successKey, strconv.FormatBool(err == nil)),
+		eventsProcessedTotal.M(1))
+}
+
+// RecordLag records how far a projection is behind the events, in events and in time.
+func RecordLag(projection string, events int64, age time.Duration) {
+	if !diag_utils.IsMetricsModuleEnabled(diag_utils.ProjectionsMetricsModule) {
+		return
+	}
+
+	stats.RecordWithTags(
+		context.Background(),
+		diag_utils.WithTags(projectionKey, projection),
+		lagEvents.M(events),
+		lagSeconds.M(age.Seconds()))
+}
+
+// InitMetrics initialize the projections metrics, unless the projections metric group is disabled.
+func InitMetrics() error {
+	if !diag_utils.IsMetricsModuleEnabled(diag_utils.ProjectionsMetricsModule) {
+		return nil
+	}
+
+	return view.Register(
+		diag_utils.NewMeasureView(eventsProcessedTotal, []tag.Key{projectionKey, successKey}, view.Count()),
+		diag_utils.NewMeasureView(lagEvents, []tag.Key{projectionKey}, view.LastValue()),
+		diag_utils.NewMeasureView(lagSeconds, []tag.Key{projectionKey}, view.LastValue()),
+	)
+}
@@ -0,0 +1,284 @@
+package projections
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/projections/monitoring"
+	"github.com/bhojpur/application/pkg/resource"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.projections")
+
+// DefaultBatchSize is the default number of events loaded per batch.
+const DefaultBatchSize = 100
+
+// Projection maintains a denormalized read model from the events of event-sourced resources.
+type Projection struct {
+	Name string
+	// Aggregates are the names of the resources whose events are consumed, all events are consumed if empty.
+	Aggregates []string
+	// Handle applies an event to the read model, in the transaction which advances the checkpoint.
+	Handle func(db *orm.DB, event *resource.Event) error
+	// Reset clears the read model before it is replayed.
+	Reset func(db *orm.DB) error
+}
+
+// Checkpoint is the position of a projection, the ID of the latest event it processed.
+type Checkpoint struct {
+	Name      string `orm:"primary_key;size:128"`
+	Position  uint
+	UpdatedAt time.Time
+}
+
+// TableName table name of checkpoints
+func (Checkpoint) TableName() string {
+	return "projection_checkpoints"
+}
+
+// Manager runs projections. The tables of resource.Event and Checkpoint have to be migrated.
+type Manager struct {
+	// BatchSize is the number of events loaded per batch, DefaultBatchSize if zero.
+	BatchSize int
+
+	db          *orm.DB
+	projections map[string]*Projection
+	lock        sync.RWMutex
+}
+
+// New returns a manager running projections on db.
+func New(db *orm.DB) *Manager {
+	return &Manager{db: db, projections: map[string]*Projection{}}
+}
+
+// Register registers a projection.
+func (m *Manager) Register(projection *Projection) error {
+	if projection.Name == "" {
+		return errors.New("projection name is required")
+	}
+
+	if projection.Handle == nil {
+		return fmt.Errorf("projection %s has no handler", projection.Name)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.projections[projection.Name]; ok {
+		return fmt.Errorf("projection %s is already registered", projection.Name)
+	}
+	m.projections[projection.Name] = projection
+	return nil
+}
+
+// Run processes the new events of all projections every interval, until ctx is done.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, name := range m.names() {
+			if _, err := m.CatchUp(name); err != nil {
+				log.Errorf("failed to process events of projection %s: %s", name, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CatchUp processes the events of a projection until it is up to date, and returns how many it processed.
+func (m *Manager) CatchUp(name string) (int, error) {
+	var total int
+	for {
+		processed, err := m.Process(name)
+		total += processed
+		if err != nil || processed < m.batchSize() {
+			return total, err
+		}
+	}
+}
+
+// Process processes the next batch of events of a projection, and returns how many it processed. Every event
+// is handled in the transaction which advances the checkpoint, processing stops at the first failing event.
+func (m *Manager) Process(name string) (int, error) {
+	projection, err := m.get(name)
+	if err != nil {
+		return 0, err
+	}
+	defer m.recordLag(projection)
+
+	checkpoint, err := loadCheckpoint(m.db, name)
+	if err != nil {
+		return 0, err
+	}
+
+	var events []resource.Event
+	if err := projection.events(m.db, checkpoint.Position).Order("id").Limit(m.batchSize()).Find(&events).Error; err != nil {
+		return 0, err
+	}
+
+	for idx := range events {
+		event := &events[idx]
+		err := m.db.Transaction(func(tx *orm.DB) error {
+			if err := projection.Handle(tx, event); err != nil {
+				return err
+			}
+			return saveCheckpoint(tx, name, event.ID)
+		})
+		monitoring.RecordEventProcessed(name, err)
+
+		if err != nil {
+			return idx, fmt.Errorf("event %d of %s %s: %w", event.ID, event.Aggregate, event.AggregateID, err)
+		}
+	}
+	return len(events), nil
+}
+
+// Replay resets the read model of a projection and its checkpoint, and processes all events again.
+func (m *Manager) Replay(name string) (int, error) {
+	projection, err := m.get(name)
+	if err != nil {
+		return 0, err
+	}
+
+	err = m.db.Transaction(func(tx *orm.DB) error {
+		if projection.Reset != nil {
+			if err := projection.Reset(tx); err != nil {
+				return err
+			}
+		}
+		return saveCheckpoint(tx, name, 0)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return m.CatchUp(name)
+}
+
+// Lag returns how many events a projection is behind, and the age of the oldest of them.
+func (m *Manager) Lag(name string) (int64, time.Duration, error) {
+	projection, err := m.get(name)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	checkpoint, err := loadCheckpoint(m.db, name)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var count int64
+	if err := projection.events(m.db, checkpoint.Position).Model(&resource.Event{}).Count(&count).Error; err != nil {
+		return 0, 0, err
+	}
+
+	if count == 0 {
+		return 0, 0, nil
+	}
+
+	var oldest resource.Event
+	if err := projection.events(m.db, checkpoint.Position).Order("id").First(&oldest).Error; err != nil {
+		return count, 0, err
+	}
+	return count, time.Since(oldest.CreatedAt), nil
+}
+
+// UseReadModel serves FindMany of the resource from the read model table, which has to have the columns of the
+// resource. Searches, permissions and other handlers wrapped by the current FindManyHandler still apply.
+func UseReadModel(res *resource.Resource, table string) {
+	findManyHandler := res.FindManyHandler
+	res.FindManyHandler = func(result interface{}, context *appsvr.Context) error {
+		readContext := context.Clone()
+		readContext.SetDB(context.GetDB().Table(table))
+		return findManyHandler(result, readContext)
+	}
+}
+
+func (m *Manager) recordLag(projection *Projection) {
+	events, age, err := m.Lag(projection.Name)
+	if err != nil {
+		log.Warnf("failed to compute lag of projection %s: %s", projection.Name, err)
+		return
+	}
+	monitoring.RecordLag(projection.Name, events, age)
+}
+
+func (m *Manager) get(name string) (*Projection, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if projection, ok := m.projections[name]; ok {
+		return projection, nil
+	}
+	return nil, fmt.Errorf("projection %s is not registered", name)
+}
+
+func (m *Manager) names() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	names := make([]string, 0, len(m.projections))
+	for name := range m.projections {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (m *Manager) batchSize() int {
+	if m.BatchSize > 0 {
+		return m.BatchSize
+	}
+	return DefaultBatchSize
+}
+
+// events returns the query of the events of the projection after the position
+func (projection *Projection) events(db *orm.DB, position uint) *orm.DB {
+	db = db.Where("id > ?", position)
+	if len(projection.Aggregates) > 0 {
+		db = db.Where("aggregate IN (?)", projection.Aggregates)
+	}
+	return db
+}
+
+func loadCheckpoint(db *orm.DB, name string) (Checkpoint, error) {
+	checkpoint := Checkpoint{Name: name}
+	if err := db.Where("name = ?", name).First(&checkpoint).Error; err != nil && !errors.Is(err, orm.ErrRecordNotFound) {
+		return checkpoint, err
+	}
+	return checkpoint, nil
+}
+
+func saveCheckpoint(db *orm.DB, name string, position uint) error {
+	return db.Save(&Checkpoint{Name: name, Position: position}).Error
+}
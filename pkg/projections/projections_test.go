@@ -0,0 +1,124 @@
+package projections_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/projections"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Order struct {
+	ID   uint
+	Code string
+}
+
+func TestManager(t *testing.T) {
+	db := utils.SQLiteTestDB(t,
+		`CREATE TABLE resource_events (id INTEGER PRIMARY KEY AUTOINCREMENT, aggregate TEXT, aggregate_id TEXT, version INTEGER, type TEXT, data TEXT, created_at DATETIME)`,
+		`CREATE TABLE projection_checkpoints (name TEXT PRIMARY KEY, position INTEGER, updated_at DATETIME)`,
+		`CREATE TABLE order_summaries (id INTEGER PRIMARY KEY, code TEXT)`,
+	)
+	addEvent := func(aggregate, aggregateID string) {
+		require.NoError(t, db.Create(&resource.Event{Aggregate: aggregate, AggregateID: aggregateID, Version: 1, Type: resource.EventSaved}).Error)
+	}
+
+	var failing string
+	manager := projections.New(db)
+	manager.BatchSize = 2
+	projection := &projections.Projection{
+		Name:       "order_summaries",
+		Aggregates: []string{"Order"},
+		Handle: func(db *orm.DB, event *resource.Event) error {
+			if err := db.Exec("INSERT INTO order_summaries (id, code) VALUES (?, ?)", event.AggregateID, "O-"+event.AggregateID).Error; err != nil {
+				return err
+			}
+			if event.AggregateID == failing {
+				return errors.New("unavailable")
+			}
+			return nil
+		},
+		Reset: func(db *orm.DB) error {
+			return db.Exec("DELETE FROM order_summaries").Error
+		},
+	}
+	require.NoError(t, manager.Register(projection))
+	assert.EqualError(t, manager.Register(projection), "projection order_summaries is already registered")
+	assert.EqualError(t, manager.Register(&projections.Projection{Name: "other"}), "projection other has no handler")
+	assert.EqualError(t, manager.Register(&projections.Projection{}), "projection name is required")
+	_, err := manager.Process("unknown")
+	assert.EqualError(t, err, "projection unknown is not registered")
+
+	for _, id := range []string{"1", "2", "3"} {
+		addEvent("Order", id)
+	}
+	addEvent("Invoice", "1")
+	events, _, err := manager.Lag("order_summaries")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), events, "events of other aggregates are not consumed")
+
+	processed, err := manager.CatchUp("order_summaries")
+	require.NoError(t, err)
+	assert.Equal(t, 3, processed)
+	events, age, err := manager.Lag("order_summaries")
+	require.NoError(t, err)
+	assert.Zero(t, events)
+	assert.Zero(t, age)
+
+	// the changes of a failing event are rolled back with its checkpoint
+	failing = "5"
+	for _, id := range []string{"4", "5", "6"} {
+		addEvent("Order", id)
+	}
+	processed, err = manager.CatchUp("order_summaries")
+	assert.EqualError(t, err, "event 6 of Order 5: unavailable")
+	assert.Equal(t, 1, processed)
+	events, _, err = manager.Lag("order_summaries")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), events)
+	summaries := func() []uint {
+		var ids []uint
+		require.NoError(t, db.Table("order_summaries").Order("id").Pluck("id", &ids).Error)
+		return ids
+	}
+	assert.Equal(t, []uint{1, 2, 3, 4}, summaries())
+
+	failing = ""
+	processed, err = manager.Replay("order_summaries")
+	require.NoError(t, err)
+	assert.Equal(t, 6, processed)
+	assert.Equal(t, []uint{1, 2, 3, 4, 5, 6}, summaries())
+
+	// the resource is found from its read model
+	res := resource.New(&Order{})
+	projections.UseReadModel(res, "order_summaries")
+	var orders []Order
+	require.NoError(t, res.CallFindMany(&orders, &appsvr.Context{Config: &appsvr.Config{DB: db}}))
+	require.Len(t, orders, 6)
+	assert.Equal(t, Order{ID: 6, Code: "O-6"}, orders[0])
+}
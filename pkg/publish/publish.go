@@ -0,0 +1,282 @@
+package publish
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/bhojpur/application/pkg/events"
+	applog "github.com/bhojpur/application/pkg/log"
+	"github.com/bhojpur/application/pkg/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+var log = applog.New("app.publish")
+
+// Status is embedded into the models of publishable records, their drafts are kept in a separate table, see
+// DraftTableName, and copied to the live table when they are published
+//
+//	type Article struct {
+//		orm.Model
+//		publish.Status
+//		Title string
+//	}
+type Status struct {
+	// PublishStatus is true when the draft has changes that aren't live yet
+	PublishStatus bool `orm:"index"`
+	// PublishAt and UnpublishAt schedule the draft to be published, and the live record to be unpublished, see
+	// PublishDue
+	PublishAt   *time.Time
+	UnpublishAt *time.Time
+	PublishedAt *time.Time
+}
+
+// GetPublishStatus returns true if the draft has unpublished changes
+func (s Status) GetPublishStatus() bool {
+	return s.PublishStatus
+}
+
+// SetPublishStatus set the publish status of the draft
+func (s *Status) SetPublishStatus(status bool) {
+	s.PublishStatus = status
+}
+
+// DraftTableName returns the table of the drafts of a table
+func DraftTableName(table string) string {
+	return table + "_draft"
+}
+
+// Config configures a publisher
+type Config struct {
+	// Bus is where publish and unpublish events are published, events.DefaultBus by default
+	Bus *events.Bus
+	// PollInterval is how often RunScheduler publishes the scheduled records, a minute by default
+	PollInterval time.Duration
+	// SkipMigration doesn't migrate the tables of registered models, when they are created by migrations
+	SkipMigration bool
+}
+
+// Publisher publishes the drafts of registered models to their live tables
+type Publisher struct {
+	db     *orm.DB
+	config Config
+	models []interface{}
+	mu     sync.RWMutex
+}
+
+// New returns a publisher of the drafts in db
+func New(db *orm.DB, config Config) *Publisher {
+	if config.Bus == nil {
+		config.Bus = events.DefaultBus
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Minute
+	}
+	return &Publisher{db: db, config: config}
+}
+
+// Register registers publishable models, which should embed Status, and migrates their live and draft tables unless
+// SkipMigration is set
+func (p *Publisher) Register(values ...interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, value := range values {
+		if _, ok := value.(interface{ SetPublishStatus(bool) }); !ok {
+			return fmt.Errorf("%T is not publishable, it should be a pointer to a model embedding publish.Status", value)
+		}
+		if !p.config.SkipMigration {
+			if err := p.db.AutoMigrate(value).Error; err != nil {
+				return err
+			}
+			if err := p.db.Table(p.draftTable(value)).AutoMigrate(value).Error; err != nil {
+				return err
+			}
+		}
+		p.models = append(p.models, value)
+	}
+	return nil
+}
+
+// DraftDB returns db querying the drafts of value, e.g. publisher.DraftDB(db, &Article{}).Find(&articles)
+func (p *Publisher) DraftDB(db *orm.DB, value interface{}) *orm.DB {
+	return db.Table(p.draftTable(value))
+}
+
+func (p *Publisher) draftTable(value interface{}) string {
+	return DraftTableName(p.db.NewScope(value).TableName())
+}
+
+// Publish copies the drafts of records to the live table in a transaction, so that they all go live at once, then
+// publishes an events.ActionPublish event for each of them. Records are drafts of registered models, like found with
+// DraftDB
+func (p *Publisher) Publish(ctx context.Context, records ...interface{}) error {
+	now := time.Now()
+	err := p.db.Transaction(func(tx *orm.DB) error {
+		tx = tx.Set("orm:save_associations", false)
+		for _, record := range records {
+			scope := tx.NewScope(record)
+			if scope.PrimaryKeyZero() {
+				return fmt.Errorf("published %T has no primary key", record)
+			}
+			if err := tx.Table(p.draftTable(record)).First(record).Error; err != nil {
+				return err
+			}
+
+			values := map[string]interface{}{"publish_status": false, "publish_at": nil, "published_at": now}
+			if err := tx.Table(p.draftTable(record)).Model(record).UpdateColumns(values).Error; err != nil {
+				return err
+			}
+			if err := tx.Table(scope.TableName()).Save(record).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p.publishEvents(ctx, events.ActionPublish, records)
+	return nil
+}
+
+// Unpublish removes records from the live table in a transaction, their drafts are kept to be published again, then
+// publishes an events.ActionUnpublish event for each of them
+func (p *Publisher) Unpublish(ctx context.Context, records ...interface{}) error {
+	err := p.db.Transaction(func(tx *orm.DB) error {
+		for _, record := range records {
+			scope := tx.NewScope(record)
+			if scope.PrimaryKeyZero() {
+				return fmt.Errorf("unpublished %T has no primary key", record)
+			}
+
+			values := map[string]interface{}{"publish_status": true, "unpublish_at": nil, "published_at": nil}
+			if err := tx.Table(p.draftTable(record)).Model(record).UpdateColumns(values).Error; err != nil {
+				return err
+			}
+			if err := tx.Table(scope.TableName()).Unscoped().Delete(record).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p.publishEvents(ctx, events.ActionUnpublish, records)
+	return nil
+}
+
+// Discard reverts the drafts of records to their live version, drafts of records never published are kept
+func (p *Publisher) Discard(records ...interface{}) error {
+	return p.db.Transaction(func(tx *orm.DB) error {
+		tx = tx.Set("orm:save_associations", false)
+		for _, record := range records {
+			scope := tx.NewScope(record)
+			if scope.PrimaryKeyZero() {
+				return fmt.Errorf("discarded %T has no primary key", record)
+			}
+			live := tx.Table(scope.TableName()).First(record)
+			if live.RecordNotFound() {
+				continue
+			} else if live.Error != nil {
+				return live.Error
+			}
+			if err := tx.Table(p.draftTable(record)).Save(record).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PublishDue publishes the drafts of registered models whose PublishAt is due, and unpublishes the live records whose
+// UnpublishAt is due
+func (p *Publisher) PublishDue(ctx context.Context) error {
+	p.mu.RLock()
+	models := p.models
+	p.mu.RUnlock()
+
+	now := time.Now()
+	for _, model := range models {
+		drafts := reflect.New(reflect.SliceOf(reflect.TypeOf(model)))
+		if err := p.db.Table(p.draftTable(model)).Where("publish_at <= ?", now).Find(drafts.Interface()).Error; err != nil {
+			return err
+		}
+		if err := p.Publish(ctx, toRecords(drafts)...); err != nil {
+			return err
+		}
+
+		live := reflect.New(reflect.SliceOf(reflect.TypeOf(model)))
+		if err := p.db.Where("unpublish_at <= ?", now).Find(live.Interface()).Error; err != nil {
+			return err
+		}
+		if err := p.Unpublish(ctx, toRecords(live)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunScheduler publishes and unpublishes the scheduled records every PollInterval until ctx is done
+func (p *Publisher) RunScheduler(ctx context.Context) error {
+	ticker := time.NewTicker(p.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.PublishDue(ctx); err != nil {
+			log.Error("failed to publish scheduled records", applog.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Publisher) publishEvents(ctx context.Context, action events.Action, records []interface{}) {
+	now := time.Now().UTC()
+	for _, record := range records {
+		p.config.Bus.Publish(ctx, events.Event{
+			Resource:   utils.HumanizeString(utils.ModelType(record).Name()),
+			Action:     action,
+			PrimaryKey: fmt.Sprint(p.db.NewScope(record).PrimaryKeyValue()),
+			Record:     record,
+			Timestamp:  now,
+		})
+	}
+}
+
+func toRecords(slice reflect.Value) []interface{} {
+	var records []interface{}
+	for i := 0; i < slice.Elem().Len(); i++ {
+		records = append(records, slice.Elem().Index(i).Interface())
+	}
+	return records
+}
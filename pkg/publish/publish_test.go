@@ -0,0 +1,107 @@
+package publish_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/events"
+	"github.com/bhojpur/application/pkg/publish"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Article struct {
+	ID uint `orm:"primary_key"`
+	publish.Status
+	Title string
+}
+
+func TestPublish(t *testing.T) {
+	db := utils.SQLiteTestDB(t)
+	for _, table := range []string{"articles", publish.DraftTableName("articles")} {
+		require.NoError(t, db.Exec(`CREATE TABLE `+table+` (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT, publish_status BOOLEAN,
+			publish_at DATETIME, unpublish_at DATETIME, published_at DATETIME)`).Error)
+	}
+
+	bus := events.NewBus()
+	var published []events.Action
+	bus.Subscribe(func(ctx context.Context, event events.Event) error {
+		published = append(published, event.Action)
+		return nil
+	})
+
+	p := publish.New(db, publish.Config{Bus: bus, SkipMigration: true})
+	require.NoError(t, p.Register(&Article{}))
+	assert.Error(t, p.Register(Article{}))
+
+	res := resource.New(&Article{})
+	p.EnableDraft(res)
+	ctx, context := context.Background(), &appsvr.Context{Config: &appsvr.Config{DB: db}}
+
+	// saved records are drafts until they are published
+	article := &Article{Title: "Draft"}
+	require.NoError(t, res.CallSave(article, context))
+	assert.True(t, article.PublishStatus)
+	var count int
+	require.NoError(t, db.Model(&Article{}).Count(&count).Error)
+	assert.Equal(t, 0, count)
+
+	require.NoError(t, p.Publish(ctx, &Article{ID: article.ID}))
+	var live Article
+	require.NoError(t, db.First(&live, article.ID).Error)
+	assert.Equal(t, "Draft", live.Title)
+	assert.False(t, live.PublishStatus)
+	assert.NotNil(t, live.PublishedAt)
+
+	article.Title = "Changed"
+	require.NoError(t, res.CallSave(article, context))
+	require.NoError(t, db.First(&live, article.ID).Error)
+	assert.Equal(t, "Draft", live.Title)
+
+	require.NoError(t, p.Discard(&Article{ID: article.ID}))
+	var draft Article
+	require.NoError(t, p.DraftDB(db, &Article{}).First(&draft, article.ID).Error)
+	assert.Equal(t, "Draft", draft.Title)
+	assert.False(t, draft.PublishStatus)
+
+	// scheduled changes are published when they are due
+	past := time.Now().Add(-time.Minute)
+	draft.Title = "Scheduled"
+	draft.PublishAt = &past
+	require.NoError(t, p.DraftDB(db, &Article{}).Save(&draft).Error)
+	require.NoError(t, p.PublishDue(ctx))
+	require.NoError(t, db.First(&live, article.ID).Error)
+	assert.Equal(t, "Scheduled", live.Title)
+	assert.Nil(t, live.PublishAt)
+
+	require.NoError(t, db.Model(&live).UpdateColumn("unpublish_at", past).Error)
+	require.NoError(t, p.PublishDue(ctx))
+	require.NoError(t, db.Model(&Article{}).Count(&count).Error)
+	assert.Equal(t, 0, count)
+
+	assert.Equal(t, []events.Action{events.ActionPublish, events.ActionPublish, events.ActionUnpublish}, published)
+}
@@ -0,0 +1,58 @@
+package publish
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+)
+
+// EnableDraft makes the resource find, save and delete the drafts of its records, so changes made with it, like from
+// the admin, don't go live until they are published. Saved drafts are marked with unpublished changes, deleting a
+// draft doesn't unpublish its live record
+func (p *Publisher) EnableDraft(res *resource.Resource) {
+	findOneHandler, findManyHandler, saveHandler, deleteHandler := res.FindOneHandler, res.FindManyHandler, res.SaveHandler, res.DeleteHandler
+
+	draftContext := func(context *appsvr.Context) *appsvr.Context {
+		draftContext := context.Clone()
+		draftContext.SetDB(p.DraftDB(context.GetDB(), res.Value))
+		return draftContext
+	}
+
+	res.FindOneHandler = func(result interface{}, metaValues *resource.MetaValues, context *appsvr.Context) error {
+		return findOneHandler(result, metaValues, draftContext(context))
+	}
+
+	res.FindManyHandler = func(result interface{}, context *appsvr.Context) error {
+		return findManyHandler(result, draftContext(context))
+	}
+
+	res.SaveHandler = func(result interface{}, context *appsvr.Context) error {
+		if status, ok := result.(interface{ SetPublishStatus(bool) }); ok {
+			status.SetPublishStatus(true)
+		}
+		return saveHandler(result, draftContext(context))
+	}
+
+	res.DeleteHandler = func(result interface{}, context *appsvr.Context) error {
+		return deleteHandler(result, draftContext(context))
+	}
+}
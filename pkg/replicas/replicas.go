@@ -0,0 +1,222 @@
+package replicas
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+var log = applog.New("app.replicas")
+
+// Name is the name of the middleware of read replicas
+const Name = "replicas"
+
+// Config configures read replicas
+type Config struct {
+	Replicas []*orm.DB
+	// StickyFor is the duration clients read from the primary database after they wrote, so that they read their own
+	// writes, it is longer than the replication lag, 5 seconds by default
+	StickyFor time.Duration
+	// CookieName is the name of the cookie of clients reading from the primary database, "bhojpur_read_primary" by
+	// default
+	CookieName string
+	// InsecureCookie sends the cookie over plain HTTP too, for development only
+	InsecureCookie bool
+	// CheckInterval is the interval of the health checks of replicas, see Run, 10 seconds by default
+	CheckInterval time.Duration
+	// CheckTimeout is the timeout of the health check of a replica, 2 seconds by default
+	CheckTimeout time.Duration
+	// Lag returns the replication lag of a replica, e.g. from pg_last_xact_replay_timestamp() on PostgreSQL, it isn't
+	// checked if nil
+	Lag func(ctx context.Context, db *orm.DB) (time.Duration, error)
+	// MaxLag is the replication lag from which replicas are unhealthy, 30 seconds by default
+	MaxLag time.Duration
+}
+
+// Router routes the reads of resources to healthy read replicas, in turn, and their writes to the primary database,
+// the database of the contexts of requests. Requests read from the primary database once they wrote, and so do their
+// clients for StickyFor, see Middleware
+//
+//	router := replicas.New(replicas.Config{Replicas: []*orm.DB{replica1, replica2}})
+//	go router.Run(ctx)
+//	engine.Use(router.Middleware())
+type Router struct {
+	config  Config
+	mutex   sync.RWMutex
+	healthy []*orm.DB
+	next    uint32
+}
+
+// New initialize a router, replicas are healthy until they are checked
+func New(config Config) *Router {
+	if config.StickyFor <= 0 {
+		config.StickyFor = 5 * time.Second
+	}
+	if config.CookieName == "" {
+		config.CookieName = "bhojpur_read_primary"
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = 10 * time.Second
+	}
+	if config.CheckTimeout <= 0 {
+		config.CheckTimeout = 2 * time.Second
+	}
+	if config.MaxLag <= 0 {
+		config.MaxLag = 30 * time.Second
+	}
+	return &Router{config: config, healthy: config.Replicas}
+}
+
+// Replica returns the next healthy replica, nil if none is healthy
+func (router *Router) Replica() *orm.DB {
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+	if len(router.healthy) == 0 {
+		return nil
+	}
+	return router.healthy[int(atomic.AddUint32(&router.next, 1)-1)%len(router.healthy)]
+}
+
+// Run checks the health of replicas every interval of the config, until ctx is done
+func (router *Router) Run(ctx context.Context) {
+	ticker := time.NewTicker(router.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		router.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check checks the health of replicas, unavailable ones and the ones lagging MaxLag or more are not read from until
+// they are healthy again
+func (router *Router) Check(ctx context.Context) {
+	var healthy []*orm.DB
+	for idx, replica := range router.config.Replicas {
+		err := router.check(ctx, replica)
+		if err == nil {
+			healthy = append(healthy, replica)
+		}
+
+		wasHealthy := router.isHealthy(replica)
+		if err != nil && wasHealthy {
+			log.Warn("replica unhealthy", applog.Int("replica", idx), applog.Err(err))
+		} else if err == nil && !wasHealthy {
+			log.Info("replica healthy", applog.Int("replica", idx))
+		}
+	}
+
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	router.healthy = healthy
+}
+
+func (router *Router) check(ctx context.Context, replica *orm.DB) error {
+	ctx, cancel := context.WithTimeout(ctx, router.config.CheckTimeout)
+	defer cancel()
+
+	if err := replica.DB().PingContext(ctx); err != nil {
+		return err
+	}
+	if router.config.Lag == nil {
+		return nil
+	}
+	lag, err := router.config.Lag(ctx, replica)
+	if err != nil {
+		return err
+	}
+	if lag >= router.config.MaxLag {
+		return fmt.Errorf("replication lag of %v", lag)
+	}
+	return nil
+}
+
+func (router *Router) isHealthy(replica *orm.DB) bool {
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+	for _, db := range router.healthy {
+		if db == replica {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware returns the middleware routing the reads of requests to replicas, see appsvr.WithReadDB. Once a
+// request writes, its client gets a cookie so that it reads from the primary database for StickyFor, unless the
+// response was written already. Replicas are the ones of the default database, requests carrying another database,
+// like the one of their tenant, see tenancy.Router.Middleware, read from it
+func (router *Router) Middleware() *appsvr.Middleware {
+	return &appsvr.Middleware{
+		Name: Name,
+		Handler: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if appsvr.RequestDB(req) != nil || router.sticky(req) {
+					next.ServeHTTP(w, req)
+					return
+				}
+				replica := router.Replica()
+				if replica == nil {
+					next.ServeHTTP(w, req)
+					return
+				}
+
+				next.ServeHTTP(w, appsvr.WithReadDB(req, replica, func() {
+					expires := time.Now().Add(router.config.StickyFor)
+					http.SetCookie(w, &http.Cookie{
+						Name:     router.config.CookieName,
+						Value:    strconv.FormatInt(expires.Unix(), 10),
+						Path:     "/",
+						Expires:  expires,
+						HttpOnly: true,
+						Secure:   !router.config.InsecureCookie,
+						SameSite: http.SameSiteLaxMode,
+					})
+				}))
+			})
+		},
+	}
+}
+
+// sticky returns true if the client of the request wrote recently, so it reads from the primary database
+func (router *Router) sticky(req *http.Request) bool {
+	cookie, err := req.Cookie(router.config.CookieName)
+	if err != nil {
+		return false
+	}
+	expires, err := strconv.ParseInt(cookie.Value, 10, 64)
+	return err == nil && time.Now().Unix() < expires
+}
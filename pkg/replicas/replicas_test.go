@@ -0,0 +1,171 @@
+package replicas_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/replicas"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/tenancy"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Product struct {
+	ID   uint
+	Name string
+}
+
+func openDB(t *testing.T, name string) *orm.DB {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`)
+	require.NoError(t, db.Exec(`INSERT INTO products (name) VALUES (?)`, name).Error)
+	return db
+}
+
+func TestRouter(t *testing.T) {
+	primary, replica := openDB(t, "Primary"), openDB(t, "Replica")
+	router := replicas.New(replicas.Config{Replicas: []*orm.DB{replica}, InsecureCookie: true})
+
+	res := resource.New(&Product{})
+	handler := router.Middleware().Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		context := &appsvr.Context{Request: req, Writer: w, Config: &appsvr.Config{DB: primary}, ResourceID: "1"}
+		find := func() string {
+			var product Product
+			require.NoError(t, res.CallFindOne(&product, nil, context))
+			return product.Name
+		}
+
+		names := find()
+		if name := req.URL.Query().Get("name"); name != "" {
+			require.NoError(t, res.CallSave(&Product{ID: 1, Name: name}, context))
+			// the request reads its own write
+			names += "," + find()
+		}
+		w.Write([]byte(names))
+	}))
+
+	get := func(url string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/")
+	assert.Equal(t, "Replica", w.Body.String())
+	assert.Empty(t, w.Result().Cookies())
+
+	w = get("/?name=Blue")
+	assert.Equal(t, "Replica,Blue", w.Body.String())
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	// the client reads from the primary database after it wrote
+	assert.Equal(t, "Blue", get("/", cookies...).Body.String())
+	cookies[0].Value = "1"
+	assert.Equal(t, "Replica", get("/", cookies...).Body.String())
+}
+
+func TestTenancy(t *testing.T) {
+	primary, replica, tenantDB := openDB(t, "Primary"), openDB(t, "Replica"), openDB(t, "Acme")
+	router := replicas.New(replicas.Config{Replicas: []*orm.DB{replica}})
+	tenants := tenancy.New(tenancy.Config{DB: primary})
+	tenants.Register(&tenancy.Tenant{ID: "acme", DB: tenantDB})
+
+	res := resource.New(&Product{})
+	find := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		context := &appsvr.Context{Request: req, Writer: w, Config: &appsvr.Config{DB: primary}, ResourceID: "1"}
+		var product Product
+		require.NoError(t, res.CallFindOne(&product, nil, context))
+		w.Write([]byte(product.Name))
+	})
+
+	// tenants read from their own database, whatever the order of the middlewares
+	for _, handler := range []http.Handler{
+		router.Middleware().Handler(tenants.Middleware().Handler(find)),
+		tenants.Middleware().Handler(router.Middleware().Handler(find)),
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, "Replica", w.Body.String())
+
+		req.Header.Set("X-Tenant-ID", "acme")
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, "Acme", w.Body.String())
+	}
+}
+
+func TestCheck(t *testing.T) {
+	replica1, replica2 := openDB(t, "1"), openDB(t, "2")
+	router := replicas.New(replicas.Config{
+		Replicas: []*orm.DB{replica1, replica2},
+		Lag: func(ctx context.Context, db *orm.DB) (time.Duration, error) {
+			if db == replica2 {
+				return 0, errors.New("not a replica")
+			}
+			return 0, nil
+		},
+	})
+
+	// replicas are read from in turn
+	assert.Equal(t, replica1, router.Replica())
+	assert.Equal(t, replica2, router.Replica())
+	assert.Equal(t, replica1, router.Replica())
+
+	router.Check(context.Background())
+	assert.Equal(t, replica1, router.Replica())
+	assert.Equal(t, replica1, router.Replica())
+
+	require.NoError(t, replica1.Close())
+	router.Check(context.Background())
+	assert.Nil(t, router.Replica())
+}
+
+func TestLag(t *testing.T) {
+	replica := openDB(t, "1")
+	lag := time.Minute
+	router := replicas.New(replicas.Config{
+		Replicas: []*orm.DB{replica},
+		Lag: func(ctx context.Context, db *orm.DB) (time.Duration, error) {
+			return lag, nil
+		},
+	})
+
+	router.Check(context.Background())
+	assert.Nil(t, router.Replica())
+	lag = time.Second
+	router.Check(context.Background())
+	assert.Equal(t, replica, router.Replica())
+}
@@ -0,0 +1,295 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/pkg/tenancy"
+)
+
+// CacheStore stores the records cached by resources, see EnableCache. A ttl of zero never expires
+type CacheStore interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(keys ...string) error
+}
+
+type cacheConfig struct {
+	store CacheStore
+	ttl   time.Duration
+}
+
+// EnableCache serves CallFindOne and CallFindMany from store, records are cached for ttl. Records found by primary
+// key are cached by it, lists and counts by the hash of their query, including the keyword, scopes, filters and
+// pagination. Saving or deleting a record with CallSave or CallDelete invalidates its entry and all lists, after the
+// transaction is committed, call InvalidateCache for changes made otherwise. Read permissions are checked before
+// cached records are served
+//
+//	products.EnableCache(resource.NewMemoryCacheStore(), 5*time.Minute)
+func (res *Resource) EnableCache(store CacheStore, ttl time.Duration) {
+	res.cache = &cacheConfig{store: store, ttl: ttl}
+}
+
+// DisableCache stops serving records from the cache
+func (res *Resource) DisableCache() {
+	res.cache = nil
+}
+
+// InvalidateCache invalidates the cached records of primary keys, in the format of ToPrimaryQueryParams, and all
+// cached lists of the resource
+func (res *Resource) InvalidateCache(primaryKeys ...string) error {
+	return res.InvalidateTenantCache("", primaryKeys...)
+}
+
+// InvalidateTenantCache invalidates the cached records of primary keys and all cached lists of a tenant, records of
+// tenants are cached apart, see tenancy
+func (res *Resource) InvalidateTenantCache(tenant string, primaryKeys ...string) error {
+	if res.cache == nil {
+		return nil
+	}
+
+	keys := []string{res.cacheKey(tenant, "generation")}
+	for _, primaryKey := range primaryKeys {
+		if res.l10n == nil {
+			keys = append(keys, res.cacheKey(tenant, "one", primaryKey))
+			continue
+		}
+		for _, locale := range res.l10n.locales {
+			keys = append(keys, res.cacheKey(tenant, "one", primaryKey, locale))
+		}
+	}
+	return res.cache.store.Delete(keys...)
+}
+
+// cacheKey returns the key of the cached records of a tenant, keys of the default database have no tenant
+func (res *Resource) cacheKey(tenant string, parts ...string) string {
+	key := "resource:" + res.Name
+	if tenant != "" {
+		key += "@" + tenant
+	}
+	for _, part := range parts {
+		key += ":" + part
+	}
+	return key
+}
+
+// cacheTenant returns the tenant of the database of the context, see tenancy
+func cacheTenant(context *appsvr.Context) string {
+	return tenancy.FromDB(context.GetDB())
+}
+
+// findOneCacheKey returns the key of the record of the context, and its locale for localized resources, records found by
+// meta values or with a field projection are not cached
+func (res *Resource) findOneCacheKey(metaValues *MetaValues, context *appsvr.Context) (string, bool) {
+	if res.cache == nil || metaValues != nil || context.ResourceID == "" || len(res.queryScopes) > 0 || len(context.GetFields()) > 0 {
+		return "", false
+	}
+	if res.l10n != nil {
+		return res.cacheKey(cacheTenant(context), "one", context.ResourceID, res.GetL10nLocale(context)), true
+	}
+	return res.cacheKey(cacheTenant(context), "one", context.ResourceID), true
+}
+
+// findManyCacheKey returns the key of the records of the query of the context, it changes with the generation of the
+// resource, which is changed to invalidate all lists
+func (res *Resource) findManyCacheKey(context *appsvr.Context) (string, bool) {
+	if res.cache == nil {
+		return "", false
+	}
+
+	tenant := cacheTenant(context)
+	generationKey := res.cacheKey(tenant, "generation")
+	generation, ok, err := res.cache.store.Get(generationKey)
+	if err != nil {
+		log.Warn("failed to get cache generation", applog.Resource(res.Name), applog.Err(err))
+		return "", false
+	}
+	if !ok {
+		// the generation expires with the lists of the resource, so it isn't kept after it isn't used
+		generation = []byte(strconv.FormatInt(time.Now().UnixNano(), 36))
+		if err := res.cache.store.Set(generationKey, generation, res.cache.ttl); err != nil {
+			log.Warn("failed to set cache generation", applog.Resource(res.Name), applog.Err(err))
+			return "", false
+		}
+	}
+
+	db := context.GetDB()
+	_, counting := db.Get("bhojpur:getting_total_count")
+	var keyword string
+	if context.Request != nil {
+		keyword = context.Request.URL.Query().Get("keyword")
+	}
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%v\x00%v\x00%v\x00%v\x00%v", *db.Model(res.Value).QueryExpr(), keyword, counting, res.GetL10nLocale(context), context.GetFields())))
+	return res.cacheKey(tenant, "many", string(generation), hex.EncodeToString(hash[:])), true
+}
+
+// getCache decodes the cached value of key to result, it returns false if it isn't cached or the roles of the context
+// can't read the resource, so its handler decides
+func (res *Resource) getCache(key string, result interface{}, context *appsvr.Context) bool {
+	if !res.HasPermission(roles.Read, context) {
+		return false
+	}
+
+	value, ok, err := res.cache.store.Get(key)
+	if err != nil {
+		log.WithContext(context).Warn("failed to get cached records", applog.Resource(res.Name), applog.Err(err))
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(value)).Decode(result); err != nil {
+		log.WithContext(context).Warn("failed to decode cached records", applog.Resource(res.Name), applog.Err(err))
+		return false
+	}
+	return true
+}
+
+func (res *Resource) setCache(key string, result interface{}, context *appsvr.Context) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(result); err != nil {
+		log.WithContext(context).Debug("records can't be cached", applog.Resource(res.Name), applog.Err(err))
+		return
+	}
+	if err := res.cache.store.Set(key, buf.Bytes(), res.cache.ttl); err != nil {
+		log.WithContext(context).Warn("failed to cache records", applog.Resource(res.Name), applog.Err(err))
+	}
+}
+
+// invalidateCache invalidates the entry of a saved or deleted record and all lists, after the transaction of the
+// context is committed
+func (res *Resource) invalidateCache(primaryKey string, context *appsvr.Context) {
+	if res.cache == nil {
+		return
+	}
+
+	tenant := cacheTenant(context)
+	invalidate := func() {
+		if err := res.InvalidateTenantCache(tenant, primaryKey); err != nil {
+			log.WithContext(context).Warn("failed to invalidate cached records", applog.Resource(res.Name), applog.Err(err))
+		}
+	}
+	if pending, ok := context.GetDB().Get(pendingEventsKey); ok {
+		*pending.(*[]pendingEvent) = append(*pending.(*[]pendingEvent), pendingEvent{afterCommit: invalidate})
+		return
+	}
+	invalidate()
+}
+
+// MemoryCacheStore is a CacheStore in memory, it keeps the most recently used entries up to MaxEntries, expired
+// entries are removed when they are read or evicted
+type MemoryCacheStore struct {
+	// MaxEntries maximum number of entries, the least recently used are evicted first, default to 10000
+	MaxEntries int
+
+	entries map[string]*list.Element
+	lru     *list.List
+	mutex   sync.Mutex
+}
+
+type memoryCacheEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCacheStore initialize a cache store in memory
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{MaxEntries: 10000, entries: map[string]*list.Element{}, lru: list.New()}
+}
+
+// Get returns the value of key, false if it isn't set or expired
+func (store *MemoryCacheStore) Get(key string) ([]byte, bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	element, ok := store.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*memoryCacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		store.remove(element)
+		return nil, false, nil
+	}
+	store.lru.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+// Set sets the value of key for ttl
+func (store *MemoryCacheStore) Set(key string, value []byte, ttl time.Duration) error {
+	entry := &memoryCacheEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if element, ok := store.entries[key]; ok {
+		element.Value = entry
+		store.lru.MoveToFront(element)
+		return nil
+	}
+	store.entries[key] = store.lru.PushFront(entry)
+
+	for store.MaxEntries > 0 && store.lru.Len() > store.MaxEntries {
+		store.remove(store.lru.Back())
+	}
+	return nil
+}
+
+// Delete deletes keys
+func (store *MemoryCacheStore) Delete(keys ...string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	for _, key := range keys {
+		if element, ok := store.entries[key]; ok {
+			store.remove(element)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries, including the expired ones which weren't removed yet
+func (store *MemoryCacheStore) Len() int {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.lru.Len()
+}
+
+func (store *MemoryCacheStore) remove(element *list.Element) {
+	store.lru.Remove(element)
+	delete(store.entries, element.Value.(*memoryCacheEntry).key)
+}
@@ -0,0 +1,156 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`)
+	require.NoError(t, db.Create(&Product{Name: "Blue"}).Error)
+
+	res := resource.New(&Product{})
+	res.EnableCache(resource.NewMemoryCacheStore(), time.Minute)
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}, ResourceID: "1"}
+
+	find := func() (string, []Product, int) {
+		var (
+			product  Product
+			products []Product
+			count    int
+		)
+		require.NoError(t, res.CallFindOne(&product, nil, context))
+		require.NoError(t, res.CallFindMany(&products, context))
+		countContext := context.Clone()
+		countContext.SetDB(db.Model(res.Value).Set("bhojpur:getting_total_count", true))
+		require.NoError(t, res.CallFindMany(&count, countContext))
+		return product.Name, products, count
+	}
+
+	name, products, count := find()
+	assert.Equal(t, "Blue", name)
+	assert.Equal(t, []Product{{ID: 1, Name: "Blue"}}, products)
+	assert.Equal(t, 1, count)
+
+	// changes made without the resource are served from the cache until it is invalidated
+	require.NoError(t, db.Exec(`UPDATE products SET name = 'Green'`).Error)
+	require.NoError(t, db.Create(&Product{Name: "Red"}).Error)
+	name, products, count = find()
+	assert.Equal(t, "Blue", name)
+	assert.Len(t, products, 1)
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, res.InvalidateCache("1"))
+	name, products, count = find()
+	assert.Equal(t, "Green", name)
+	assert.Len(t, products, 2)
+	assert.Equal(t, 2, count)
+
+	// saving in a transaction invalidates the cache once it is committed
+	err := resource.RunInTransaction(context, func(tx *resource.Txn) error {
+		return tx.Save(res, &Product{ID: 1, Name: "Black"})
+	})
+	require.NoError(t, err)
+	name, products, _ = find()
+	assert.Equal(t, "Black", name)
+	assert.ElementsMatch(t, []Product{{ID: 1, Name: "Black"}, {ID: 2, Name: "Red"}}, products)
+
+	deleteContext := context.Clone()
+	deleteContext.ResourceID = "2"
+	require.NoError(t, res.CallDelete(&Product{}, deleteContext))
+	_, products, count = find()
+	assert.Len(t, products, 1)
+	assert.Equal(t, 1, count)
+
+	missing := context.Clone()
+	missing.ResourceID = "2"
+	assert.True(t, orm.IsRecordNotFoundError(res.CallFindOne(&Product{}, nil, missing)))
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	store := resource.NewMemoryCacheStore()
+	require.NoError(t, store.Set("a", []byte("1"), time.Millisecond))
+	require.NoError(t, store.Set("b", []byte("2"), 0))
+
+	value, ok, err := store.Get("a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	time.Sleep(2 * time.Millisecond)
+	_, ok, _ = store.Get("a")
+	assert.False(t, ok)
+
+	require.NoError(t, store.Delete("b"))
+	_, ok, _ = store.Get("b")
+	assert.False(t, ok)
+
+	// the least recently used entries are evicted
+	store.MaxEntries = 2
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, store.Set(key, []byte(key), 0))
+		if key == "b" {
+			_, ok, _ = store.Get("a")
+			assert.True(t, ok)
+		}
+	}
+	assert.Equal(t, 2, store.Len())
+	for key, cached := range map[string]bool{"a": true, "b": false, "c": true} {
+		_, ok, _ = store.Get(key)
+		assert.Equal(t, cached, ok, key)
+	}
+}
+
+func TestCacheStoreBounded(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`)
+
+	store := resource.NewMemoryCacheStore()
+	store.MaxEntries = 10
+	res := resource.New(&Product{})
+	res.EnableCache(store, time.Minute)
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+
+	// every save changes the generation of the lists, lists of former generations are never read again
+	for i := 1; i <= 50; i++ {
+		require.NoError(t, res.CallSave(&Product{Name: fmt.Sprint(i)}, context))
+		for _, limit := range []int{1, 5, 100} {
+			var products []Product
+			findContext := context.Clone()
+			findContext.SetDB(db.Order("id").Limit(limit))
+			require.NoError(t, res.CallFindMany(&products, findContext))
+			if limit > i {
+				limit = i
+			}
+			assert.Len(t, products, limit)
+		}
+		assert.LessOrEqual(t, store.Len(), 10)
+	}
+}
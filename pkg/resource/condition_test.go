@@ -0,0 +1,88 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/validations"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Shipment struct {
+	ID             uint
+	Status         string
+	TrackingNumber string
+}
+
+func TestMetaConditions(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE shipments (id INTEGER PRIMARY KEY AUTOINCREMENT, status TEXT, tracking_number TEXT)`)
+
+	res := resource.New(&Shipment{})
+	status := &resource.Meta{Name: "Status", BaseResource: res}
+	tracking := &resource.Meta{Name: "TrackingNumber", BaseResource: res}
+	for _, meta := range []*resource.Meta{status, tracking} {
+		require.NoError(t, meta.PreInitialize())
+		require.NoError(t, meta.Initialize())
+	}
+	tracking.ShowIf(func(record interface{}, context *appsvr.Context) bool {
+		return record.(*Shipment).Status != "pending"
+	})
+	tracking.RequiredIf(func(record interface{}, context *appsvr.Context) bool {
+		return record.(*Shipment).Status == "shipped"
+	})
+
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+	validate := func(shipment *Shipment, statusValue, trackingValue string) validations.Errors {
+		// the tracking number is decoded after the status, which its conditions depend on
+		metaValues := &resource.MetaValues{Values: []*resource.MetaValue{
+			{Name: "TrackingNumber", Value: trackingValue, Meta: metaor{tracking}},
+			{Name: "Status", Value: statusValue, Meta: metaor{status}},
+		}}
+		errs, err := res.CallValidate(shipment, metaValues, context)
+		require.NoError(t, err)
+		return errs
+	}
+
+	var shipment Shipment
+	errs := validate(&shipment, "shipped", "")
+	require.Len(t, errs, 1)
+	assert.Equal(t, "TrackingNumber", errs[0].Column)
+	assert.Equal(t, validations.CodeRequired, errs[0].Code)
+
+	shipment = Shipment{}
+	assert.Empty(t, validate(&shipment, "shipped", "1Z999"))
+	assert.Equal(t, "1Z999", shipment.TrackingNumber)
+
+	// values of hidden metas are not decoded
+	shipment = Shipment{}
+	assert.Empty(t, validate(&shipment, "pending", "1Z999"))
+	assert.Empty(t, shipment.TrackingNumber)
+
+	assert.False(t, tracking.IsShown(&Shipment{Status: "pending"}, context))
+	assert.True(t, tracking.IsShown(&Shipment{Status: "returned"}, context))
+	assert.False(t, tracking.IsRequired(&Shipment{Status: "returned"}, context))
+	assert.True(t, status.IsShown(&Shipment{Status: "pending"}, context))
+}
@@ -21,57 +21,331 @@ package resource
 // THE SOFTWARE.
 
 import (
+	stdcontext "context"
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
+	"time"
 
 	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/events"
+	applog "github.com/bhojpur/application/pkg/log"
+	"github.com/bhojpur/application/pkg/resource/monitoring"
 	"github.com/bhojpur/application/pkg/roles"
 	"github.com/bhojpur/application/pkg/utils"
 	orm "github.com/bhojpur/orm/pkg/engine"
 )
 
+var log = applog.New("app.resource")
+
 // CallFindOne call find one method
 func (res *Resource) CallFindOne(result interface{}, metaValues *MetaValues, context *appsvr.Context) error {
-	return res.FindOneHandler(result, metaValues, context)
+	start, end := time.Now(), res.startSpan(monitoring.FindOne, context)
+	context = res.applyQueryScopes(res.logQueries(monitoring.FindOne, readContext(context)))
+	var err error
+	if key, ok := res.findOneCacheKey(metaValues, context); !ok {
+		err = res.FindOneHandler(result, metaValues, context)
+	} else if !res.getCache(key, result, context) {
+		// records are cached by their primary key, so that saving them invalidates their entry
+		if err = res.FindOneHandler(result, metaValues, context); err == nil && res.primaryKeyOf(result, context) == context.ResourceID {
+			res.setCache(key, result, context)
+		}
+	}
+	end(err)
+	res.recordOperation(monitoring.FindOne, err, context, start)
+	return err
 }
 
 // CallFindMany call find many method
 func (res *Resource) CallFindMany(result interface{}, context *appsvr.Context) error {
-	return res.FindManyHandler(result, context)
+	start, end := time.Now(), res.startSpan(monitoring.FindMany, context)
+	scopedContext, err := res.ApplyScopesAndFilters(res.applyQueryScopes(res.logQueries(monitoring.FindMany, readContext(context))))
+	if err == nil {
+		if key, ok := res.findManyCacheKey(scopedContext); !ok {
+			err = res.FindManyHandler(result, scopedContext)
+		} else if !res.getCache(key, result, scopedContext) {
+			if err = res.FindManyHandler(result, scopedContext); err == nil {
+				res.setCache(key, result, scopedContext)
+			}
+		}
+	}
+	end(err)
+	res.recordOperation(monitoring.FindMany, err, context, start)
+	return err
+}
+
+// CallFindManyStream calls handler with the records FindMany would find, one at a time as they are read from the
+// database, so that exports and ETL jobs over large tables use bounded memory. It stops at the first error of the
+// handler and returns it, or when the request of the context is done. Records are found with the scopes, filters, query
+// scopes and field projection of FindMany, but neither by its handler nor from the cache. A connection of the database
+// is held until the stream ends
+func (res *Resource) CallFindManyStream(context *appsvr.Context, handler func(record interface{}) error) error {
+	start, end := time.Now(), res.startSpan(monitoring.FindManyStream, context)
+	scopedContext, err := res.ApplyScopesAndFilters(res.applyQueryScopes(res.logQueries(monitoring.FindManyStream, readContext(context))))
+	if err == nil {
+		err = res.findManyStream(scopedContext, handler)
+	}
+	end(err)
+	res.recordOperation(monitoring.FindManyStream, err, context, start)
+	return err
+}
+
+func (res *Resource) findManyStream(context *appsvr.Context, handler func(record interface{}) error) error {
+	if !res.HasPermission(roles.Read, context) {
+		return roles.ErrPermissionDenied
+	}
+	db, err := res.searchDB(context)
+	if err == nil {
+		db, err = res.selectFields(db, context)
+	}
+	if err != nil {
+		return err
+	}
+
+	scope := db.NewScope(res.Value)
+	for _, field := range res.PrimaryFields {
+		db = db.Order(scope.QuotedTableName() + "." + scope.Quote(field.DBName) + " DESC")
+	}
+	rows, err := db.Model(res.Value).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	ctx := stdcontext.Background()
+	if context.Request != nil {
+		ctx = context.Request.Context()
+	}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		record := res.NewStruct()
+		if err := db.ScanRows(rows, record); err != nil {
+			return err
+		}
+		if err := handler(record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
 // CallSave call save method
 func (res *Resource) CallSave(result interface{}, context *appsvr.Context) error {
-	return res.SaveHandler(result, context)
+	start := time.Now()
+	action := events.ActionUpdate
+	if context.GetDB().NewScope(result).PrimaryKeyZero() {
+		action = events.ActionCreate
+	}
+
+	end := res.startSpan(monitoring.Save, context)
+	savingContext := res.generatePrimaryKeys(res.logQueries(monitoring.Save, context))
+	var err error
+	if changes := takePendingAssociations(savingContext, result); len(changes) > 0 {
+		err = res.saveWithAssociations(result, changes, savingContext)
+	} else {
+		err = res.SaveHandler(result, savingContext)
+	}
+	end(err)
+	res.recordOperation(monitoring.Save, err, context, start)
+	if err == nil {
+		context.SetWritten()
+		res.invalidateCache(res.primaryKeyOf(result, context), context)
+		res.publishEvent(action, result, context)
+	}
+	return err
 }
 
 // CallDelete call delete method
 func (res *Resource) CallDelete(result interface{}, context *appsvr.Context) error {
-	return res.DeleteHandler(result, context)
+	start, end := time.Now(), res.startSpan(monitoring.Delete, context)
+	err := res.DeleteHandler(result, res.applyQueryScopes(res.logQueries(monitoring.Delete, context)))
+	end(err)
+	res.recordOperation(monitoring.Delete, err, context, start)
+	if err == nil {
+		context.SetWritten()
+		primaryKey := res.primaryKeyOf(result, context)
+		if primaryKey == "" {
+			primaryKey = context.ResourceID
+		}
+		res.invalidateCache(primaryKey, context)
+		res.publishEvent(events.ActionDelete, result, context)
+	}
+	return err
+}
+
+func (res *Resource) recordOperation(operation string, err error, context *appsvr.Context, start time.Time) {
+	var recordID string
+	if context != nil {
+		recordID = context.ResourceID
+	}
+	duration := time.Since(start)
+	monitoring.RecordOperation(res.Name, operation, recordID, err, duration)
+
+	logger := log.WithContext(context).With(applog.Resource(res.Name), applog.String("operation", operation), applog.Duration(duration))
+	if recordID != "" {
+		logger = logger.With(applog.String("record_id", recordID))
+	}
+	if err != nil {
+		logger.Warn("resource operation failed", applog.Err(err))
+	} else if logger.Enabled(applog.DebugLevel) {
+		logger.Debug("resource operation")
+	}
 }
 
-// ToPrimaryQueryParams generate query params based on primary key, multiple primary value are linked with a comma
+// readContext returns a clone of the context reading from the read database of its request, like a read replica, see
+// appsvr.WithReadDB
+func readContext(context *appsvr.Context) *appsvr.Context {
+	if context == nil || context.DB != nil {
+		return context
+	}
+	db := context.GetReadDB()
+	if db == nil || db == context.GetDB() {
+		return context
+	}
+	readContext := context.Clone()
+	readContext.SetDB(db)
+	return readContext
+}
+
+// SetEventBus set the bus the events of the resource are published to, events.DefaultBus by default
+func (res *Resource) SetEventBus(bus *events.Bus) {
+	res.eventBus = bus
+}
+
+// pendingEventsKey is the DB setting of the events of a transaction, which are published after it is committed
+const pendingEventsKey = "resource:pending_events"
+
+type pendingEvent struct {
+	bus   *events.Bus
+	event events.Event
+	// afterCommit runs instead of publishing the event, like invalidating cached records
+	afterCommit func()
+}
+
+func (res *Resource) publishEvent(action events.Action, result interface{}, context *appsvr.Context) {
+	event := events.Event{Resource: res.Name, Action: action, PrimaryKey: res.primaryKeyOf(result, context), Record: result, Timestamp: time.Now().UTC()}
+	if event.PrimaryKey == "" {
+		event.PrimaryKey = context.ResourceID
+	}
+
+	if context.CurrentUser != nil {
+		event.Actor = context.CurrentUser.DisplayName()
+	}
+
+	bus := res.eventBus
+	if bus == nil {
+		bus = events.DefaultBus
+	}
+
+	if pending, ok := context.GetDB().Get(pendingEventsKey); ok {
+		*pending.(*[]pendingEvent) = append(*pending.(*[]pendingEvent), pendingEvent{bus: bus, event: event})
+		return
+	}
+	publishEvents(context, pendingEvent{bus: bus, event: event})
+}
+
+func publishEvents(context *appsvr.Context, pending ...pendingEvent) {
+	var ctx = stdcontext.Background()
+	if context.Request != nil {
+		ctx = context.Request.Context()
+	}
+
+	for _, p := range pending {
+		if p.afterCommit != nil {
+			p.afterCommit()
+			continue
+		}
+		p.bus.Publish(ctx, p.event)
+	}
+}
+
+// PrimaryKeySeparator separates the values of composite primary keys, like `code++locale`, see JoinPrimaryKey
+const PrimaryKeySeparator = "++"
+
+// primaryKeyEscaper escapes the values of composite primary keys, so that they may contain the separator
+var primaryKeyEscaper = strings.NewReplacer("%", "%25", "+", "%2B")
+
+// JoinPrimaryKey returns the composite primary key of values joined by PrimaryKeySeparator, "%" and "+" of values are
+// escaped as "%25" and "%2B"
+//
+//	JoinPrimaryKey("C++", "en-US") // "C%2B%2B++en-US"
+func JoinPrimaryKey(values ...string) string {
+	escaped := make([]string, len(values))
+	for idx, value := range values {
+		escaped[idx] = primaryKeyEscaper.Replace(value)
+	}
+	return strings.Join(escaped, PrimaryKeySeparator)
+}
+
+// SplitPrimaryKey returns the values of a composite primary key, see JoinPrimaryKey
+func SplitPrimaryKey(primaryKey string) ([]string, error) {
+	values := strings.Split(primaryKey, PrimaryKeySeparator)
+	for idx, value := range values {
+		unescaped, err := url.PathUnescape(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid primary key %q: %w", primaryKey, err)
+		}
+		values[idx] = unescaped
+	}
+	return values, nil
+}
+
+// GetPrimaryKey returns the primary key of the record, in the format of ToPrimaryQueryParams
+func (res *Resource) GetPrimaryKey(record interface{}, context *appsvr.Context) string {
+	return res.primaryKeyOf(record, context)
+}
+
+// primaryKeyOf returns the primary key of the record, in the format of ToPrimaryQueryParams
+func (res *Resource) primaryKeyOf(record interface{}, context *appsvr.Context) string {
+	scope := context.GetDB().NewScope(record)
+	if len(res.PrimaryFields) == 0 {
+		return fmt.Sprint(scope.PrimaryKeyValue())
+	}
+
+	var values []string
+	for _, primaryField := range res.PrimaryFields {
+		if field, ok := scope.FieldByName(primaryField.Name); ok {
+			values = append(values, fmt.Sprint(field.Field.Interface()))
+		}
+	}
+	if len(values) == 1 {
+		return values[0]
+	}
+	return JoinPrimaryKey(values...)
+}
+
+// ToPrimaryQueryParams generate query params based on primary key, the values of composite primary keys are joined by
+// PrimaryKeySeparator, see JoinPrimaryKey. Composite primary keys of another number of values, and keys which aren't
+// valid for the primary key strategy of the resource, find nothing
 func (res *Resource) ToPrimaryQueryParams(primaryValue string, context *appsvr.Context) (string, []interface{}) {
 	if primaryValue != "" {
 		scope := context.GetDB().NewScope(res.Value)
 
 		// multiple primary fields
 		if len(res.PrimaryFields) > 1 {
-			if primaryValueStrs := strings.Split(primaryValue, ","); len(primaryValueStrs) == len(res.PrimaryFields) {
-				sqls := []string{}
-				primaryValues := []interface{}{}
-				for idx, field := range res.PrimaryFields {
-					sqls = append(sqls, fmt.Sprintf("%v.%v = ?", scope.QuotedTableName(), scope.Quote(field.DBName)))
-					primaryValues = append(primaryValues, primaryValueStrs[idx])
-				}
+			primaryValueStrs, err := SplitPrimaryKey(primaryValue)
+			if err != nil || len(primaryValueStrs) != len(res.PrimaryFields) {
+				return "", []interface{}{}
+			}
 
-				return strings.Join(sqls, " AND "), primaryValues
+			sqls := []string{}
+			primaryValues := []interface{}{}
+			for idx, field := range res.PrimaryFields {
+				sqls = append(sqls, fmt.Sprintf("%v.%v = ?", scope.QuotedTableName(), scope.Quote(field.DBName)))
+				primaryValues = append(primaryValues, primaryValueStrs[idx])
 			}
+			return strings.Join(sqls, " AND "), primaryValues
 		}
 
-		// fallback to first configured primary field
+		// single configured primary field
 		if len(res.PrimaryFields) > 0 {
+			var ok bool
+			if primaryValue, ok = res.primaryKeyStrategy.normalize(primaryValue); !ok {
+				return "", []interface{}{}
+			}
 			return fmt.Sprintf("%v.%v = ?", scope.QuotedTableName(), scope.Quote(res.PrimaryFields[0].DBName)), []interface{}{primaryValue}
 		}
 
@@ -84,7 +358,8 @@ func (res *Resource) ToPrimaryQueryParams(primaryValue string, context *appsvr.C
 	return "", []interface{}{}
 }
 
-// ToPrimaryQueryParamsFromMetaValue generate query params based on MetaValues
+// ToPrimaryQueryParamsFromMetaValue generate query params based on MetaValues, they find nothing unless all primary
+// fields have a meta value, so that records of composite primary keys are not found by a part of their key
 func (res *Resource) ToPrimaryQueryParamsFromMetaValue(metaValues *MetaValues, context *appsvr.Context) (string, []interface{}) {
 	var (
 		sqls          []string
@@ -94,10 +369,12 @@ func (res *Resource) ToPrimaryQueryParamsFromMetaValue(metaValues *MetaValues, c
 
 	if metaValues != nil {
 		for _, field := range res.PrimaryFields {
-			if metaField := metaValues.Get(field.Name); metaField != nil {
-				sqls = append(sqls, fmt.Sprintf("%v.%v = ?", scope.QuotedTableName(), scope.Quote(field.DBName)))
-				primaryValues = append(primaryValues, utils.ToString(metaField.Value))
+			metaField := metaValues.Get(field.Name)
+			if metaField == nil {
+				return "", nil
 			}
+			sqls = append(sqls, fmt.Sprintf("%v.%v = ?", scope.QuotedTableName(), scope.Quote(field.DBName)))
+			primaryValues = append(primaryValues, utils.ToString(metaField.Value))
 		}
 	}
 
@@ -126,9 +403,17 @@ func (res *Resource) findOneHandler(result interface{}, metaValues *MetaValues,
 					}
 				}
 			}
-			return context.GetDB().First(result, append([]interface{}{primaryQuerySQL}, primaryParams...)...).Error
+			db, err := res.selectFields(context.GetDB(), context)
+			if err != nil {
+				return err
+			}
+			return db.First(result, append([]interface{}{primaryQuerySQL}, primaryParams...)...).Error
 		}
 
+		// keys which aren't valid for the primary fields of the resource find nothing
+		if metaValues == nil && context.ResourceID != "" {
+			return orm.ErrRecordNotFound
+		}
 		return errors.New("failed to find")
 	}
 	return roles.ErrPermissionDenied
@@ -136,20 +421,37 @@ func (res *Resource) findOneHandler(result interface{}, metaValues *MetaValues,
 
 func (res *Resource) findManyHandler(result interface{}, context *appsvr.Context) error {
 	if res.HasPermission(roles.Read, context) {
-		db := context.GetDB()
+		db, err := res.searchDB(context)
+		if err != nil {
+			return err
+		}
+
 		if _, ok := db.Get("bhojpur:getting_total_count"); ok {
-			return context.GetDB().Count(result).Error
+			return db.Count(result).Error
 		}
-		return context.GetDB().Set("orm:order_by_primary_key", "DESC").Find(result).Error
+		if db, err = res.selectFields(db, context); err != nil {
+			return err
+		}
+		return db.Set("orm:order_by_primary_key", "DESC").Find(result).Error
 	}
 
 	return roles.ErrPermissionDenied
 }
 
+// searchDB returns the database of the context, searching the keyword of its request if any
+func (res *Resource) searchDB(context *appsvr.Context) (*orm.DB, error) {
+	if context.Request != nil {
+		if keyword := context.Request.URL.Query().Get("keyword"); keyword != "" {
+			return res.CallSearch(keyword, context)
+		}
+	}
+	return context.GetDB(), nil
+}
+
 func (res *Resource) saveHandler(result interface{}, context *appsvr.Context) error {
 	if (context.GetDB().NewScope(result).PrimaryKeyZero() &&
-		res.HasPermission(roles.Create, context)) || // has create permission
-		res.HasPermission(roles.Update, context) { // has update permission
+		res.HasRecordPermission(roles.Create, result, context)) || // has create permission
+		res.HasRecordPermission(roles.Update, result, context) { // has update permission
 		return context.GetDB().Save(result).Error
 	}
 	return roles.ErrPermissionDenied
@@ -158,8 +460,10 @@ func (res *Resource) saveHandler(result interface{}, context *appsvr.Context) er
 func (res *Resource) deleteHandler(result interface{}, context *appsvr.Context) error {
 	if res.HasPermission(roles.Delete, context) {
 		if primaryQuerySQL, primaryParams := res.ToPrimaryQueryParams(context.ResourceID, context); primaryQuerySQL != "" {
-			if !context.GetDB().First(result, append([]interface{}{primaryQuerySQL}, primaryParams...)...).RecordNotFound() {
-				return context.GetDB().Delete(result).Error
+			where := append([]interface{}{primaryQuerySQL}, primaryParams...)
+			if !context.GetDB().First(result, where...).RecordNotFound() {
+				// records are deleted by the primary fields of the resource, which may not be the ones of the model
+				return context.GetDB().Delete(result, where...).Error
 			}
 		}
 		return orm.ErrRecordNotFound
@@ -0,0 +1,238 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// ErrInvalidCursor is returned for cursors which are malformed, of another resource or order, or not signed by the key
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrInvalidOrder is returned when records are ordered by a field which isn't a column of the resource
+var ErrInvalidOrder = errors.New("invalid order")
+
+// CallFindManyAfter finds the page of at most limit records after the cursor of the previous page, the first page if
+// the cursor is empty, and returns the cursor of the next page, empty for the last one. Records are ordered by the
+// fields of orderBy, descending if their name is prefixed with "-", then by their primary key descending, like
+// FindMany, so that records with the same values are ordered too. Pages are found by keyset pagination: the ones after
+// the last record of the previous page are found by comparing the values of these fields, so pages are stable under
+// concurrent inserts and deep pages are found as fast as the first one. The fields of orderBy can't be null.
+// Cursors are opaque to clients, they are signed with key so that they can't be forged, and only valid for the
+// orderBy of the first page
+func (res *Resource) CallFindManyAfter(result interface{}, cursor string, limit int, key []byte, context *appsvr.Context, orderBy ...string) (string, error) {
+	if limit < 1 {
+		return "", fmt.Errorf("invalid limit %v", limit)
+	}
+
+	db := context.GetReadDB()
+	scope := db.NewScope(res.Value)
+	fields, err := res.keysetFields(scope, orderBy)
+	if err != nil {
+		return "", err
+	}
+	for _, field := range fields {
+		if field.Desc {
+			db = db.Order(field.Column + " DESC")
+		} else {
+			db = db.Order(field.Column)
+		}
+	}
+	if cursor != "" {
+		values, err := res.decodeCursor(cursor, key, fields)
+		if err != nil {
+			return "", err
+		}
+		query, params := keysetCondition(fields, values)
+		db = db.Where(query, params...)
+	}
+
+	// a record more than the limit is found to know if there is a next page
+	pageContext := context.Clone()
+	pageContext.SetDB(db.Limit(limit + 1))
+	if projected := context.GetFields(); len(projected) > 0 {
+		// the fields of the order are selected too, for the cursor of the next page
+		projected = append([]string{}, projected...)
+		for _, field := range fields {
+			projected = append(projected, field.Name)
+		}
+		pageContext.SetFields(projected...)
+	}
+	if err := res.CallFindMany(result, pageContext); err != nil {
+		return "", err
+	}
+
+	records := reflect.Indirect(reflect.ValueOf(result))
+	if records.Len() <= limit {
+		return "", nil
+	}
+	records.Set(records.Slice(0, limit))
+	return res.encodeCursor(records.Index(limit-1).Interface(), key, fields, context)
+}
+
+// keysetField is a field records are ordered by, compared to the values of cursors
+type keysetField struct {
+	Name   string
+	Column string
+	Type   reflect.Type
+	Desc   bool
+}
+
+// keysetFields returns the fields of orderBy, followed by the primary fields
+func (res *Resource) keysetFields(scope *orm.Scope, orderBy []string) ([]keysetField, error) {
+	var fields []keysetField
+	for _, name := range orderBy {
+		field, ok := scope.FieldByName(strings.TrimPrefix(name, "-"))
+		if !ok || !field.IsNormal || field.IsIgnored {
+			return nil, fmt.Errorf("%w: %v is not a column of resource %v", ErrInvalidOrder, name, res.Name)
+		}
+		fields = append(fields, keysetField{
+			Name:   field.Name,
+			Column: scope.QuotedTableName() + "." + scope.Quote(field.DBName),
+			Type:   field.Struct.Type,
+			Desc:   strings.HasPrefix(name, "-"),
+		})
+	}
+	for _, field := range res.PrimaryFields {
+		fields = append(fields, keysetField{
+			Name:   field.Name,
+			Column: scope.QuotedTableName() + "." + scope.Quote(field.DBName),
+			Type:   field.Struct.Type,
+			Desc:   true,
+		})
+	}
+	return fields, nil
+}
+
+// keysetCondition returns the condition of the records after values in the order of fields, like
+// `(a < ?) OR (a = ? AND b < ?)` for the tuple (a, b) descending
+func keysetCondition(fields []keysetField, values []interface{}) (string, []interface{}) {
+	var (
+		clauses []string
+		params  []interface{}
+	)
+	for idx, field := range fields {
+		var conditions []string
+		for prev := 0; prev < idx; prev++ {
+			conditions = append(conditions, fields[prev].Column+" = ?")
+			params = append(params, values[prev])
+		}
+		if field.Desc {
+			conditions = append(conditions, field.Column+" < ?")
+		} else {
+			conditions = append(conditions, field.Column+" > ?")
+		}
+		params = append(params, values[idx])
+		clauses = append(clauses, "("+strings.Join(conditions, " AND ")+")")
+	}
+	return strings.Join(clauses, " OR "), params
+}
+
+// cursorPayload is the payload of cursors, the order of the pages and the values of its fields of the last record of
+// a page
+type cursorPayload struct {
+	Resource string            `json:"r"`
+	Order    []string          `json:"o,omitempty"`
+	Keys     []json.RawMessage `json:"k"`
+}
+
+func orderOf(fields []keysetField) []string {
+	var order []string
+	for _, field := range fields {
+		if field.Desc {
+			order = append(order, "-"+field.Name)
+		} else {
+			order = append(order, field.Name)
+		}
+	}
+	return order
+}
+
+// encodeCursor returns the cursor of the page after record, its payload and HMAC-SHA256 encoded in base64
+func (res *Resource) encodeCursor(record interface{}, key []byte, fields []keysetField, context *appsvr.Context) (string, error) {
+	scope := context.GetDB().NewScope(record)
+	payload := cursorPayload{Resource: res.Name, Order: orderOf(fields)}
+	for _, keysetField := range fields {
+		field, ok := scope.FieldByName(keysetField.Name)
+		if !ok {
+			return "", fmt.Errorf("%v is not a valid field for resource %v", keysetField.Name, res.Name)
+		}
+		value, err := json.Marshal(field.Field.Interface())
+		if err != nil {
+			return "", err
+		}
+		payload.Keys = append(payload.Keys, value)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(cursorMAC(encoded, key)), nil
+}
+
+// decodeCursor returns the values of the fields of a cursor, of their types, ErrInvalidCursor if it was encoded for
+// other fields
+func (res *Resource) decodeCursor(cursor string, key []byte, fields []keysetField) ([]interface{}, error) {
+	parts := strings.SplitN(cursor, ".", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	encoded := parts[0]
+	mac, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(mac, cursorMAC(encoded, key)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || json.Unmarshal(data, &payload) != nil || payload.Resource != res.Name || len(payload.Keys) != len(fields) ||
+		strings.Join(payload.Order, ",") != strings.Join(orderOf(fields), ",") {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(payload.Keys))
+	for idx, field := range fields {
+		value := reflect.New(field.Type)
+		if err := json.Unmarshal(payload.Keys[idx], value.Interface()); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[idx] = value.Elem().Interface()
+	}
+	return values, nil
+}
+
+func cursorMAC(encoded string, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
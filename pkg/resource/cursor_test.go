@@ -0,0 +1,164 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"strings"
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Stock struct {
+	Warehouse string `orm:"primary_key"`
+	SKU       string `orm:"primary_key"`
+	Quantity  int
+}
+
+func TestCallFindManyAfter(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`)
+	for _, name := range []string{"A", "B", "C", "D", "E"} {
+		require.NoError(t, db.Create(&Product{Name: name}).Error)
+	}
+
+	var (
+		key     = []byte("secret")
+		res     = resource.New(&Product{})
+		context = &appsvr.Context{Config: &appsvr.Config{DB: db}}
+	)
+	page := func(cursor string) ([]string, string) {
+		var products []Product
+		next, err := res.CallFindManyAfter(&products, cursor, 2, key, context)
+		require.NoError(t, err)
+		var names []string
+		for _, product := range products {
+			names = append(names, product.Name)
+		}
+		return names, next
+	}
+
+	names, next := page("")
+	assert.Equal(t, []string{"E", "D"}, names)
+	require.NotEmpty(t, next)
+
+	// records inserted meanwhile don't shift the next pages
+	require.NoError(t, db.Create(&Product{Name: "F"}).Error)
+	names, next = page(next)
+	assert.Equal(t, []string{"C", "B"}, names)
+	names, next = page(next)
+	assert.Equal(t, []string{"A"}, names)
+	assert.Empty(t, next)
+
+	_, cursor := page("")
+	// e30 is the payload {}, with the signature of another payload
+	for _, invalid := range []string{"x", cursor + "x", "e30." + strings.SplitN(cursor, ".", 2)[1]} {
+		_, err := res.CallFindManyAfter(&[]Product{}, invalid, 2, key, context)
+		assert.ErrorIs(t, err, resource.ErrInvalidCursor, invalid)
+	}
+	_, err := res.CallFindManyAfter(&[]Product{}, cursor, 2, []byte("other"), context)
+	assert.ErrorIs(t, err, resource.ErrInvalidCursor)
+	_, err = resource.New(&Stock{}).CallFindManyAfter(&[]Stock{}, cursor, 2, key, context)
+	assert.ErrorIs(t, err, resource.ErrInvalidCursor, "cursors are only valid for their resource")
+}
+
+func TestCallFindManyAfterCompositeKey(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE stocks (warehouse TEXT, sku TEXT, quantity INTEGER, PRIMARY KEY (warehouse, sku))`)
+	var all []Stock
+	for _, warehouse := range []string{"north", "south"} {
+		for _, sku := range []string{"a", "b", "c"} {
+			stock := Stock{Warehouse: warehouse, SKU: sku}
+			require.NoError(t, db.Create(&stock).Error)
+			all = append([]Stock{stock}, all...)
+		}
+	}
+
+	var (
+		res     = resource.New(&Stock{})
+		context = &appsvr.Context{Config: &appsvr.Config{DB: db}}
+		found   []Stock
+		cursor  string
+	)
+	require.NoError(t, res.SetPrimaryFields("Warehouse", "SKU"))
+	for {
+		var stocks []Stock
+		next, err := res.CallFindManyAfter(&stocks, cursor, 4, []byte("secret"), context)
+		require.NoError(t, err)
+		found = append(found, stocks...)
+		if cursor = next; cursor == "" {
+			break
+		}
+	}
+	assert.Equal(t, all, found)
+}
+
+func TestCallFindManyAfterOrder(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`)
+	for _, name := range []string{"A", "B", "A", "C", "B", "A"} {
+		require.NoError(t, db.Create(&Product{Name: name}).Error)
+	}
+
+	var (
+		key     = []byte("secret")
+		res     = resource.New(&Product{})
+		context = &appsvr.Context{Config: &appsvr.Config{DB: db}}
+	)
+	pages := func(context *appsvr.Context, orderBy ...string) []Product {
+		var (
+			found  []Product
+			cursor string
+		)
+		for {
+			var products []Product
+			next, err := res.CallFindManyAfter(&products, cursor, 2, key, context, orderBy...)
+			require.NoError(t, err)
+			found = append(found, products...)
+			if cursor = next; cursor == "" {
+				return found
+			}
+		}
+	}
+
+	// records with the same name are ordered by their primary key
+	assert.Equal(t, []Product{{6, "A"}, {3, "A"}, {1, "A"}, {5, "B"}, {2, "B"}, {4, "C"}}, pages(context, "Name"))
+	assert.Equal(t, []Product{{4, "C"}, {5, "B"}, {2, "B"}, {6, "A"}, {3, "A"}, {1, "A"}}, pages(context, "-name"))
+
+	// the fields of the order are selected with the projected ones
+	projected := context.Clone()
+	projected.SetFields("ID")
+	var ids []uint
+	for _, product := range pages(projected, "Name") {
+		ids = append(ids, product.ID)
+	}
+	assert.Equal(t, []uint{6, 3, 1, 5, 2, 4}, ids)
+
+	cursor, err := res.CallFindManyAfter(&[]Product{}, "", 2, key, context, "Name")
+	require.NoError(t, err)
+	_, err = res.CallFindManyAfter(&[]Product{}, cursor, 2, key, context, "-Name")
+	assert.ErrorIs(t, err, resource.ErrInvalidCursor, "cursors are only valid for their order")
+	_, err = res.CallFindManyAfter(&[]Product{}, cursor, 2, key, context)
+	assert.ErrorIs(t, err, resource.ErrInvalidCursor)
+	_, err = res.CallFindManyAfter(&[]Product{}, "", 2, key, context, "Price")
+	assert.ErrorIs(t, err, resource.ErrInvalidOrder)
+}
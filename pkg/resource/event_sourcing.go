@@ -0,0 +1,255 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// Event types recorded by event-sourced resources
+const (
+	EventSaved   = "saved"
+	EventDeleted = "deleted"
+)
+
+// Event is a domain event of an event-sourced resource, the aggregate is the resource and the aggregate id the
+// primary key of the record
+type Event struct {
+	ID          uint   `orm:"primary_key"`
+	Aggregate   string `orm:"size:128;unique_index:idx_resource_event_version"`
+	AggregateID string `orm:"size:128;unique_index:idx_resource_event_version"`
+	Version     uint64 `orm:"unique_index:idx_resource_event_version"`
+	Type        string `orm:"size:64"`
+	Data        string `orm:"type:text"`
+	CreatedAt   time.Time
+}
+
+// TableName table name of events
+func (Event) TableName() string {
+	return "resource_events"
+}
+
+// Snapshot is the state of an aggregate at a version, replays start from the latest snapshot
+type Snapshot struct {
+	ID          uint   `orm:"primary_key"`
+	Aggregate   string `orm:"size:128;index:idx_resource_snapshot_aggregate"`
+	AggregateID string `orm:"size:128;index:idx_resource_snapshot_aggregate"`
+	Version     uint64
+	Data        string `orm:"type:text"`
+	CreatedAt   time.Time
+}
+
+// TableName table name of snapshots
+func (Snapshot) TableName() string {
+	return "resource_snapshots"
+}
+
+// EventSourcingConfig event sourcing config of a resource
+type EventSourcingConfig struct {
+	// SnapshotEvery takes a snapshot of an aggregate every SnapshotEvery events, zero disables snapshots
+	SnapshotEvery uint64
+	// EventType returns the type of the event of a save, EventSaved by default
+	EventType func(record interface{}, context *appsvr.Context) string
+	// Apply applies an event to the state of an aggregate during replays. By default, the data of the events
+	// other than EventDeleted is the whole record and replaces the state
+	Apply func(state interface{}, event *Event) error
+}
+
+// EnableEventSourcing makes the resource event-sourced: saves and deletes append domain events, and the table of
+// the resource becomes a projection of them, which could be rebuilt by replaying them with RebuildProjections.
+// Events and snapshots are stored in the tables of Event and Snapshot, which have to be migrated.
+func (res *Resource) EnableEventSourcing(config EventSourcingConfig) {
+	if config.EventType == nil {
+		config.EventType = func(interface{}, *appsvr.Context) string { return EventSaved }
+	}
+
+	if config.Apply == nil {
+		config.Apply = applyEvent
+	}
+
+	res.eventSourcing = &config
+	saveHandler, deleteHandler := res.SaveHandler, res.DeleteHandler
+
+	res.SaveHandler = func(result interface{}, context *appsvr.Context) error {
+		return res.inEventTransaction(context, func(txContext *appsvr.Context) error {
+			if err := saveHandler(result, txContext); err != nil {
+				return err
+			}
+			return res.appendEvent(txContext, result, config.EventType(result, txContext), result)
+		})
+	}
+
+	res.DeleteHandler = func(result interface{}, context *appsvr.Context) error {
+		return res.inEventTransaction(context, func(txContext *appsvr.Context) error {
+			if err := deleteHandler(result, txContext); err != nil {
+				return err
+			}
+			return res.appendEvent(txContext, result, EventDeleted, nil)
+		})
+	}
+}
+
+// IsEventSourced returns true if event sourcing is enabled for the resource
+func (res *Resource) IsEventSourced() bool {
+	return res.eventSourcing != nil
+}
+
+// Events returns the events of an aggregate after the version, in order
+func (res *Resource) Events(aggregateID string, afterVersion uint64, context *appsvr.Context) ([]Event, error) {
+	var events []Event
+	err := context.GetDB().Where("aggregate = ? AND aggregate_id = ? AND version > ?", res.Name, aggregateID, afterVersion).Order("version").Find(&events).Error
+	return events, err
+}
+
+// Replay rebuilds the state of an aggregate from its latest snapshot and the events after it, and returns its
+// version. It returns orm.ErrRecordNotFound if the aggregate has no events or was deleted
+func (res *Resource) Replay(aggregateID string, state interface{}, context *appsvr.Context) (uint64, error) {
+	if res.eventSourcing == nil {
+		return 0, fmt.Errorf("event sourcing is not enabled for resource %v", res.Name)
+	}
+
+	var (
+		version  uint64
+		deleted  bool
+		snapshot Snapshot
+		db       = context.GetDB()
+	)
+
+	if err := db.Where("aggregate = ? AND aggregate_id = ?", res.Name, aggregateID).Order("version DESC").First(&snapshot).Error; err == nil {
+		if err := json.Unmarshal([]byte(snapshot.Data), state); err != nil {
+			return 0, err
+		}
+		version = snapshot.Version
+	} else if !errors.Is(err, orm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	events, err := res.Events(aggregateID, version, context)
+	if err != nil {
+		return 0, err
+	}
+
+	for idx := range events {
+		if err := res.eventSourcing.Apply(state, &events[idx]); err != nil {
+			return version, err
+		}
+		version = events[idx].Version
+		deleted = events[idx].Type == EventDeleted
+	}
+
+	if version == 0 || deleted {
+		return version, orm.ErrRecordNotFound
+	}
+	return version, nil
+}
+
+// RebuildProjections rebuilds the table of the resource by replaying the events of all aggregates, it is the
+// projection rebuild job, run it after changing the Apply logic, or to repair the projection
+func (res *Resource) RebuildProjections(context *appsvr.Context) error {
+	var aggregateIDs []string
+	if err := context.GetDB().Model(&Event{}).Where("aggregate = ?", res.Name).Pluck("DISTINCT aggregate_id", &aggregateIDs).Error; err != nil {
+		return err
+	}
+
+	for _, aggregateID := range aggregateIDs {
+		if err := res.RebuildProjection(aggregateID, context); err != nil {
+			return fmt.Errorf("failed to rebuild projection of %v %v: %w", res.Name, aggregateID, err)
+		}
+	}
+	return nil
+}
+
+// RebuildProjection rebuilds the record of an aggregate by replaying its events
+func (res *Resource) RebuildProjection(aggregateID string, context *appsvr.Context) error {
+	return context.GetDB().Transaction(func(db *orm.DB) error {
+		txContext := context.Clone()
+		txContext.SetDB(db)
+
+		state := res.NewStruct()
+		if _, err := res.Replay(aggregateID, state, txContext); err != nil {
+			if !errors.Is(err, orm.ErrRecordNotFound) {
+				return err
+			}
+
+			if primaryQuerySQL, primaryParams := res.ToPrimaryQueryParams(aggregateID, txContext); primaryQuerySQL != "" {
+				return db.Delete(res.NewStruct(), append([]interface{}{primaryQuerySQL}, primaryParams...)...).Error
+			}
+			return nil
+		}
+		return db.Save(state).Error
+	})
+}
+
+func (res *Resource) inEventTransaction(context *appsvr.Context, fc func(*appsvr.Context) error) error {
+	return context.GetDB().Transaction(func(db *orm.DB) error {
+		txContext := context.Clone()
+		txContext.SetDB(db)
+		return fc(txContext)
+	})
+}
+
+func (res *Resource) appendEvent(context *appsvr.Context, record interface{}, eventType string, data interface{}) error {
+	var (
+		db          = context.GetDB()
+		aggregateID = res.primaryKeyOf(record, context)
+		last        Event
+	)
+
+	if err := db.Where("aggregate = ? AND aggregate_id = ?", res.Name, aggregateID).Order("version DESC").First(&last).Error; err != nil && !errors.Is(err, orm.ErrRecordNotFound) {
+		return err
+	}
+
+	event := Event{Aggregate: res.Name, AggregateID: aggregateID, Version: last.Version + 1, Type: eventType}
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		event.Data = string(encoded)
+	}
+
+	if err := db.Create(&event).Error; err != nil {
+		return err
+	}
+
+	if every := res.eventSourcing.SnapshotEvery; every > 0 && event.Version%every == 0 && data != nil {
+		return db.Create(&Snapshot{Aggregate: res.Name, AggregateID: aggregateID, Version: event.Version, Data: event.Data}).Error
+	}
+	return nil
+}
+
+// applyEvent is the default Apply, replaces the state with the record of the event
+func applyEvent(state interface{}, event *Event) error {
+	if event.Type == EventDeleted || event.Data == "" {
+		return nil
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(state))
+	value.Set(reflect.Zero(value.Type()))
+	return json.Unmarshal([]byte(event.Data), state)
+}
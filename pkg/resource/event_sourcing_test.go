@@ -0,0 +1,96 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Account struct {
+	ID      uint
+	Owner   string
+	Balance int
+}
+
+func TestEventSourcing(t *testing.T) {
+	db := utils.SQLiteTestDB(t,
+		`CREATE TABLE accounts (id INTEGER PRIMARY KEY AUTOINCREMENT, owner TEXT, balance INTEGER)`,
+		`CREATE TABLE resource_events (id INTEGER PRIMARY KEY AUTOINCREMENT, aggregate TEXT, aggregate_id TEXT, version INTEGER, type TEXT, data TEXT, created_at DATETIME,
+			UNIQUE (aggregate, aggregate_id, version))`,
+		`CREATE TABLE resource_snapshots (id INTEGER PRIMARY KEY AUTOINCREMENT, aggregate TEXT, aggregate_id TEXT, version INTEGER, data TEXT, created_at DATETIME)`,
+	)
+
+	res := resource.New(&Account{})
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+	_, err := res.Replay("1", &Account{}, context)
+	assert.EqualError(t, err, "event sourcing is not enabled for resource Account")
+
+	res.EnableEventSourcing(resource.EventSourcingConfig{SnapshotEvery: 2})
+	assert.True(t, res.IsEventSourced())
+
+	account := Account{Owner: "ann", Balance: 10}
+	for _, balance := range []int{10, 20, 30} {
+		account.Balance = balance
+		require.NoError(t, res.CallSave(&account, context))
+	}
+
+	events, err := res.Events("1", 0, context)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	for idx, event := range events {
+		assert.Equal(t, uint64(idx+1), event.Version)
+		assert.Equal(t, resource.EventSaved, event.Type)
+	}
+	var snapshots []uint64
+	require.NoError(t, db.Model(&resource.Snapshot{}).Pluck("version", &snapshots).Error)
+	assert.Equal(t, []uint64{2}, snapshots)
+
+	var state Account
+	version, err := res.Replay("1", &state, context)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), version)
+	assert.Equal(t, account, state)
+
+	// the table is a projection of the events
+	require.NoError(t, db.Model(&Account{}).Where("id = ?", 1).Update("balance", 0).Error)
+	require.NoError(t, res.RebuildProjections(context))
+	var stored Account
+	require.NoError(t, db.First(&stored, 1).Error)
+	assert.Equal(t, account, stored)
+
+	deleteContext := &appsvr.Context{Config: &appsvr.Config{DB: db}, ResourceID: "1"}
+	require.NoError(t, res.CallDelete(&Account{}, deleteContext))
+	version, err = res.Replay("1", &Account{}, context)
+	assert.True(t, orm.IsRecordNotFoundError(err))
+	assert.Equal(t, uint64(4), version)
+
+	// records of deleted aggregates are removed from the projection
+	require.NoError(t, db.Create(&Account{ID: 1, Owner: "ann"}).Error)
+	require.NoError(t, res.RebuildProjection("1", context))
+	assert.True(t, db.First(&Account{}, 1).RecordNotFound())
+}
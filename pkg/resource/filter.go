@@ -0,0 +1,321 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// Scope is a named query of a resource, like "Pending", applied to FindMany with `?scopes=Pending`
+type Scope struct {
+	Name    string
+	Label   string
+	Handler func(*orm.DB, *appsvr.Context) *orm.DB
+}
+
+// FilterType is the type of a filter, which decides how its arguments are parsed
+type FilterType string
+
+// Filter types
+const (
+	// FilterSelect matches the attribute with one of the values, `?filters[state]=paid&filters[state]=shipped`
+	FilterSelect FilterType = "select"
+	// FilterDateRange matches the attribute within the dates, `?filters[created_at][from]=2021-01-01&filters[created_at][to]=2021-01-31`
+	FilterDateRange FilterType = "date-range"
+	// FilterBool matches the attribute with a bool, `?filters[archived]=true`
+	FilterBool FilterType = "bool"
+)
+
+// FilterOption is an option of a select filter
+type FilterOption struct {
+	Label string
+	Value string
+}
+
+// FilterArgument is the parsed argument of a filter
+type FilterArgument struct {
+	Values []string
+	From   *time.Time
+	To     *time.Time
+	Bool   bool
+}
+
+// Filter is a named filter of a resource, applied to FindMany with `?filters[name]=value`
+type Filter struct {
+	Name  string
+	Label string
+	Type  FilterType
+	// Attr is the filtered attribute, the name by default
+	Attr string
+	// Options are the allowed values of a select filter, any value is allowed if empty
+	Options []FilterOption
+	// Handler applies the filter, by default the attribute is matched according to the type
+	Handler func(*orm.DB, *FilterArgument, *appsvr.Context) *orm.DB
+}
+
+// Scope register scope for resource, a scope with the same name is replaced
+func (res *Resource) Scope(scope *Scope) {
+	if scope.Name == "" || scope.Handler == nil {
+		utils.ExitWithMsg("Scope of resource %v should have name and handler", res.Name)
+		return
+	}
+
+	if scope.Label == "" {
+		scope.Label = utils.HumanizeString(scope.Name)
+	}
+
+	for idx, s := range res.scopes {
+		if s.Name == scope.Name {
+			res.scopes[idx] = scope
+			return
+		}
+	}
+	res.scopes = append(res.scopes, scope)
+}
+
+// GetScopes get registered scopes
+func (res *Resource) GetScopes() []*Scope {
+	return res.scopes
+}
+
+// Filter register filter for resource, a filter with the same name is replaced
+func (res *Resource) Filter(filter *Filter) {
+	if filter.Name == "" {
+		utils.ExitWithMsg("Filter of resource %v should have name", res.Name)
+		return
+	}
+
+	switch filter.Type {
+	case "":
+		filter.Type = FilterSelect
+	case FilterSelect, FilterDateRange, FilterBool:
+	default:
+		utils.ExitWithMsg("Filter %v of resource %v has unknown type %v", filter.Name, res.Name, filter.Type)
+		return
+	}
+
+	if filter.Attr == "" {
+		filter.Attr = filter.Name
+	}
+
+	if filter.Label == "" {
+		filter.Label = res.GetLabel(filter.Attr)
+	}
+
+	if filter.Handler == nil {
+		field, _, err := getSearchField(&orm.Scope{Value: res.Value}, []string{filter.Attr})
+		if err != nil {
+			utils.ExitWithMsg("Filter %v of resource %v has no handler, and %v is not a valid attribute", filter.Name, res.Name, filter.Attr)
+			return
+		}
+		filter.Handler = filterHandler(res, filter, field)
+	}
+
+	for idx, f := range res.filters {
+		if f.Name == filter.Name {
+			res.filters[idx] = filter
+			return
+		}
+	}
+	res.filters = append(res.filters, filter)
+}
+
+// GetFilters get registered filters
+func (res *Resource) GetFilters() []*Filter {
+	return res.filters
+}
+
+var filterParamRegexp = regexp.MustCompile(`^filters\[([^\]]+)\](?:\[(from|to)\])?$`)
+
+// ApplyScopesAndFilters returns the context with the scopes and filters of its request, `?scopes=Pending&filters[state]=paid`,
+// applied to its DB. CallFindMany applies them before calling FindManyHandler
+func (res *Resource) ApplyScopesAndFilters(context *appsvr.Context) (*appsvr.Context, error) {
+	if context == nil || context.Request == nil || (len(res.scopes) == 0 && len(res.filters) == 0) {
+		return context, nil
+	}
+
+	var (
+		query     = context.Request.URL.Query()
+		db        = context.GetDB()
+		arguments = map[string]*FilterArgument{}
+		applied   bool
+	)
+
+	for _, names := range query["scopes"] {
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+
+			scope := res.getScope(name)
+			if scope == nil {
+				return context, fmt.Errorf("unknown scope %v of resource %v", name, res.Name)
+			}
+			db, applied = scope.Handler(db, context), true
+		}
+	}
+
+	for key, values := range query {
+		matches := filterParamRegexp.FindStringSubmatch(key)
+		if matches == nil {
+			continue
+		}
+
+		filter := res.getFilter(matches[1])
+		if filter == nil {
+			return context, fmt.Errorf("unknown filter %v of resource %v", matches[1], res.Name)
+		}
+
+		argument, ok := arguments[filter.Name]
+		if !ok {
+			argument = &FilterArgument{}
+			arguments[filter.Name] = argument
+		}
+
+		if err := parseFilterArgument(filter, argument, matches[2], values, context); err != nil {
+			return context, err
+		}
+	}
+
+	for _, filter := range res.filters {
+		if argument, ok := arguments[filter.Name]; ok && !argument.isBlank(filter) {
+			db, applied = filter.Handler(db, argument, context), true
+		}
+	}
+
+	if !applied {
+		return context, nil
+	}
+
+	scopedContext := context.Clone()
+	scopedContext.SetDB(db)
+	return scopedContext, nil
+}
+
+func (res *Resource) getScope(name string) *Scope {
+	for _, scope := range res.scopes {
+		if scope.Name == name {
+			return scope
+		}
+	}
+	return nil
+}
+
+func (res *Resource) getFilter(name string) *Filter {
+	for _, filter := range res.filters {
+		if filter.Name == name {
+			return filter
+		}
+	}
+	return nil
+}
+
+func parseFilterArgument(filter *Filter, argument *FilterArgument, bound string, values []string, context *appsvr.Context) error {
+	switch filter.Type {
+	case FilterSelect:
+		for _, value := range values {
+			if value == "" {
+				continue
+			}
+
+			if len(filter.Options) > 0 && !filter.hasOption(value) {
+				return fmt.Errorf("%v is not a valid value of filter %v", value, filter.Name)
+			}
+			argument.Values = append(argument.Values, value)
+		}
+	case FilterDateRange:
+		if bound == "" || len(values) == 0 || values[0] == "" {
+			return nil
+		}
+
+		date, err := utils.ParseTime(values[0], context)
+		if err != nil {
+			return fmt.Errorf("%v is not a valid date of filter %v", values[0], filter.Name)
+		}
+
+		if bound == "from" {
+			argument.From = &date
+		} else {
+			argument.To = &date
+		}
+	case FilterBool:
+		if len(values) == 0 || values[0] == "" {
+			return nil
+		}
+
+		value, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return fmt.Errorf("%v is not a valid value of filter %v", values[0], filter.Name)
+		}
+		argument.Values, argument.Bool = values[:1], value
+	}
+	return nil
+}
+
+func (filter *Filter) hasOption(value string) bool {
+	for _, option := range filter.Options {
+		if option.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (argument *FilterArgument) isBlank(filter *Filter) bool {
+	if filter.Type == FilterDateRange {
+		return argument.From == nil && argument.To == nil
+	}
+	return len(argument.Values) == 0
+}
+
+func filterHandler(res *Resource, filter *Filter, field *orm.StructField) func(*orm.DB, *FilterArgument, *appsvr.Context) *orm.DB {
+	return func(db *orm.DB, argument *FilterArgument, context *appsvr.Context) *orm.DB {
+		scope := db.NewScope(res.Value)
+		column := fmt.Sprintf("%v.%v", scope.QuotedTableName(), scope.Quote(field.DBName))
+
+		switch filter.Type {
+		case FilterDateRange:
+			if argument.From != nil {
+				db = db.Where(fmt.Sprintf("%v >= ?", column), *argument.From)
+			}
+			if argument.To != nil {
+				to := *argument.To
+				// a date without time includes the whole day
+				if to.Hour() == 0 && to.Minute() == 0 && to.Second() == 0 && to.Nanosecond() == 0 {
+					to = to.AddDate(0, 0, 1)
+				}
+				db = db.Where(fmt.Sprintf("%v < ?", column), to)
+			}
+			return db
+		case FilterBool:
+			return db.Where(fmt.Sprintf("%v = ?", column), argument.Bool)
+		}
+		return db.Where(fmt.Sprintf("%v IN (?)", column), argument.Values)
+	}
+}
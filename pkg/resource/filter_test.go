@@ -0,0 +1,111 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Parcel struct {
+	ID        uint
+	State     string
+	Fragile   bool
+	ShippedAt time.Time
+}
+
+func TestScopesAndFilters(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE parcels (id INTEGER PRIMARY KEY AUTOINCREMENT, state TEXT, fragile BOOLEAN, shipped_at DATETIME)`)
+	for _, parcel := range []Parcel{
+		{State: "paid", ShippedAt: time.Date(2021, 1, 1, 12, 0, 0, 0, time.Local)},
+		{State: "shipped", Fragile: true, ShippedAt: time.Date(2021, 1, 2, 12, 0, 0, 0, time.Local)},
+		{State: "shipped", ShippedAt: time.Date(2021, 1, 3, 12, 0, 0, 0, time.Local)},
+		{State: "returned", Fragile: true, ShippedAt: time.Date(2021, 1, 4, 12, 0, 0, 0, time.Local)},
+	} {
+		require.NoError(t, db.Create(&parcel).Error)
+	}
+
+	res := resource.New(&Parcel{})
+	res.Scope(&resource.Scope{Name: "Fragile", Handler: func(db *orm.DB, context *appsvr.Context) *orm.DB {
+		return db.Where("fragile = ?", true)
+	}})
+	res.Scope(&resource.Scope{Name: "Shipped", Handler: func(db *orm.DB, context *appsvr.Context) *orm.DB {
+		return db.Where("state = ?", "shipped")
+	}})
+	res.Filter(&resource.Filter{Name: "state", Options: []resource.FilterOption{{Value: "paid"}, {Value: "shipped"}, {Value: "returned"}}})
+	res.Filter(&resource.Filter{Name: "shipped", Attr: "ShippedAt", Type: resource.FilterDateRange})
+	res.Filter(&resource.Filter{Name: "fragile", Type: resource.FilterBool})
+	require.Len(t, res.GetScopes(), 2)
+	assert.Equal(t, "Fragile", res.GetScopes()[0].Label)
+	require.Len(t, res.GetFilters(), 3)
+	assert.Equal(t, "fragile", res.GetFilters()[2].Attr)
+
+	find := func(query string) ([]uint, error) {
+		var parcels []Parcel
+		context := &appsvr.Context{Config: &appsvr.Config{DB: db}, Request: httptest.NewRequest("GET", "/parcels?"+query, nil)}
+		if err := res.CallFindMany(&parcels, context); err != nil {
+			return nil, err
+		}
+		var ids []uint
+		for _, parcel := range parcels {
+			ids = append(ids, parcel.ID)
+		}
+		return ids, nil
+	}
+
+	for query, ids := range map[string][]uint{
+		"":                              {4, 3, 2, 1},
+		"scopes=Fragile":                {4, 2},
+		"scopes=Fragile,Shipped":        {2},
+		"scopes=Fragile&scopes=Shipped": {2},
+		"filters[state]=paid&filters[state]=returned": {4, 1},
+		"filters[state]=":                   {4, 3, 2, 1},
+		"filters[fragile]=false":            {3, 1},
+		"filters[fragile]=":                 {4, 3, 2, 1},
+		"filters[shipped][from]=2021-01-02": {4, 3, 2},
+		// dates without time include the whole day
+		"filters[shipped][from]=2021-01-02&filters[shipped][to]=2021-01-03": {3, 2},
+		"scopes=Shipped&filters[fragile]=false":                             {3},
+	} {
+		found, err := find(query)
+		require.NoError(t, err, query)
+		assert.Equal(t, ids, found, query)
+	}
+
+	for query, message := range map[string]string{
+		"scopes=Lost":                     "unknown scope Lost of resource Parcel",
+		"filters[owner]=ann":              "unknown filter owner of resource Parcel",
+		"filters[state]=lost":             "lost is not a valid value of filter state",
+		"filters[fragile]=maybe":          "maybe is not a valid value of filter fragile",
+		"filters[shipped][from]=tomorrow": "tomorrow is not a valid date of filter shipped",
+	} {
+		_, err := find(query)
+		assert.EqualError(t, err, message, query)
+	}
+}
@@ -0,0 +1,233 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/utils"
+)
+
+// FormTokenParam name of the form field and meta value carrying the one-time form token
+const FormTokenParam = "_form_token"
+
+// FormSessionCookie name of the cookie used to identify the session when no SessionID func is configured
+const FormSessionCookie = "_form_session"
+
+// ErrDuplicateSubmission returned when a form token is unknown, expired or already consumed
+var ErrDuplicateSubmission = errors.New("resource: duplicate form submission")
+
+// ErrMissingFormToken returned when a required form token is not submitted
+var ErrMissingFormToken = errors.New("resource: missing form token")
+
+// FormTokenStore server side storage of issued form tokens, keyed by session
+type FormTokenStore interface {
+	// Put remember an issued token for the session until expiresAt
+	Put(sessionID, token string, expiresAt time.Time) error
+	// Consume remove the token from the session, return false if it was not found or expired
+	Consume(sessionID, token string) (bool, error)
+}
+
+// FormTokens issue one-time form tokens on render and consume them on save, so refreshing or
+// resubmitting a form won't create duplicate records
+type FormTokens struct {
+	Store FormTokenStore
+	// SessionID return the session of current request, integrate your session store here, default to a cookie based session
+	SessionID func(*appsvr.Context) string
+	// TTL how long an issued token is valid, default to 1 hour
+	TTL time.Duration
+	// Required reject submissions without a token, otherwise they are passed through, e.g. for API clients
+	Required bool
+}
+
+// NewFormTokens create form tokens with store, default to an in-memory store
+func NewFormTokens(store FormTokenStore) *FormTokens {
+	if store == nil {
+		store = NewMemoryFormTokenStore()
+	}
+	return &FormTokens{Store: store, TTL: time.Hour}
+}
+
+// Generate issue a new token for current session, embed it in the rendered form as a hidden `_form_token` field
+func (tokens *FormTokens) Generate(context *appsvr.Context) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	ttl := tokens.TTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+
+	if err := tokens.Store.Put(tokens.sessionID(context, true), token, time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Consume consume token for current session, return ErrDuplicateSubmission if it has been used already
+func (tokens *FormTokens) Consume(context *appsvr.Context, token string) error {
+	if token == "" {
+		if tokens.Required {
+			return ErrMissingFormToken
+		}
+		return nil
+	}
+
+	sessionID := tokens.sessionID(context, false)
+	if sessionID == "" {
+		return ErrDuplicateSubmission
+	}
+
+	ok, err := tokens.Store.Consume(sessionID, token)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrDuplicateSubmission
+	}
+	return nil
+}
+
+func (tokens *FormTokens) sessionID(context *appsvr.Context, create bool) string {
+	if tokens.SessionID != nil {
+		return tokens.SessionID(context)
+	}
+
+	if context.Request != nil {
+		if cookie, err := context.Request.Cookie(FormSessionCookie); err == nil && cookie.Value != "" {
+			return cookie.Value
+		}
+	}
+
+	if !create || context.Writer == nil {
+		return ""
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	sessionID := hex.EncodeToString(buf)
+	utils.SetCookie(http.Cookie{Name: FormSessionCookie, Value: sessionID}, context)
+	if context.Request != nil {
+		// make the session visible to later calls of current request
+		context.Request.AddCookie(&http.Cookie{Name: FormSessionCookie, Value: sessionID})
+	}
+	return sessionID
+}
+
+// ProtectDuplicateSubmission add a validator consuming the submitted form token, saving a form twice with the same token will fail
+func (res *Resource) ProtectDuplicateSubmission(tokens *FormTokens) {
+	res.AddValidator(&Validator{
+		Name: "form_token",
+		Handler: func(record interface{}, metaValues *MetaValues, context *appsvr.Context) error {
+			var token string
+			if metaValues != nil {
+				if metaValue := metaValues.Get(FormTokenParam); metaValue != nil {
+					token = utils.ToString(metaValue.Value)
+				}
+			}
+			if token == "" && context.Request != nil {
+				token = context.Request.FormValue(FormTokenParam)
+			}
+			return tokens.Consume(context, token)
+		},
+	})
+}
+
+// MemoryFormTokenStore in-memory form token store, expired tokens are pruned when the session issues new ones
+type MemoryFormTokenStore struct {
+	// MaxPerSession maximum outstanding tokens per session, oldest are dropped first, default to 100
+	MaxPerSession int
+
+	mutex  sync.Mutex
+	tokens map[string]map[string]time.Time
+}
+
+// NewMemoryFormTokenStore create an in-memory form token store
+func NewMemoryFormTokenStore() *MemoryFormTokenStore {
+	return &MemoryFormTokenStore{MaxPerSession: 100, tokens: map[string]map[string]time.Time{}}
+}
+
+// Put remember an issued token
+func (store *MemoryFormTokenStore) Put(sessionID, token string, expiresAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	session, ok := store.tokens[sessionID]
+	if !ok {
+		session = map[string]time.Time{}
+		store.tokens[sessionID] = session
+	}
+
+	now := time.Now()
+	for t, expires := range session {
+		if now.After(expires) {
+			delete(session, t)
+		}
+	}
+
+	if store.MaxPerSession > 0 {
+		for len(session) >= store.MaxPerSession {
+			var oldest string
+			for t, expires := range session {
+				if oldest == "" || expires.Before(session[oldest]) {
+					oldest = t
+				}
+			}
+			delete(session, oldest)
+		}
+	}
+
+	session[token] = expiresAt
+	return nil
+}
+
+// Consume remove the token, return false if it was not issued, expired or already consumed
+func (store *MemoryFormTokenStore) Consume(sessionID, token string) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	session, ok := store.tokens[sessionID]
+	if !ok {
+		return false, nil
+	}
+
+	expires, ok := session[token]
+	if !ok {
+		return false, nil
+	}
+
+	delete(session, token)
+	if len(session) == 0 {
+		delete(store.tokens, sessionID)
+	}
+	return time.Now().Before(expires), nil
+}
@@ -0,0 +1,108 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormTokens(t *testing.T) {
+	tokens := resource.NewFormTokens(nil)
+
+	w := httptest.NewRecorder()
+	token, err := tokens.Generate(&appsvr.Context{Request: httptest.NewRequest(http.MethodGet, "/products/new", nil), Writer: w})
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, resource.FormSessionCookie, cookies[0].Name)
+
+	submit := func(cookie *http.Cookie) *appsvr.Context {
+		req := httptest.NewRequest(http.MethodPost, "/products", nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		return &appsvr.Context{Request: req}
+	}
+
+	assert.ErrorIs(t, tokens.Consume(submit(nil), token), resource.ErrDuplicateSubmission)
+	assert.ErrorIs(t, tokens.Consume(submit(&http.Cookie{Name: resource.FormSessionCookie, Value: "other"}), token), resource.ErrDuplicateSubmission)
+	require.NoError(t, tokens.Consume(submit(cookies[0]), token))
+	assert.ErrorIs(t, tokens.Consume(submit(cookies[0]), token), resource.ErrDuplicateSubmission, "tokens are consumed once")
+
+	// submissions without token are passed through unless tokens are required
+	require.NoError(t, tokens.Consume(submit(cookies[0]), ""))
+	tokens.Required = true
+	assert.ErrorIs(t, tokens.Consume(submit(cookies[0]), ""), resource.ErrMissingFormToken)
+
+	// sessions are identified by SessionID if set
+	tokens.SessionID = func(*appsvr.Context) string { return "session" }
+	token, err = tokens.Generate(&appsvr.Context{})
+	require.NoError(t, err)
+	require.NoError(t, tokens.Consume(&appsvr.Context{}, token))
+}
+
+func TestMemoryFormTokenStore(t *testing.T) {
+	store := resource.NewMemoryFormTokenStore()
+	store.MaxPerSession = 2
+
+	expiresAt := time.Now().Add(time.Hour)
+	for _, token := range []string{"a", "b", "c"} {
+		require.NoError(t, store.Put("session", token, expiresAt))
+		expiresAt = expiresAt.Add(time.Minute)
+	}
+	require.NoError(t, store.Put("session", "expired", time.Now().Add(-time.Minute)))
+
+	for token, valid := range map[string]bool{"a": false, "b": false, "c": true, "expired": false} {
+		ok, err := store.Consume("session", token)
+		require.NoError(t, err)
+		assert.Equal(t, valid, ok, token)
+	}
+	ok, err := store.Consume("other", "c")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestProtectDuplicateSubmission(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`)
+
+	tokens := resource.NewFormTokens(nil)
+	tokens.SessionID = func(*appsvr.Context) string { return "session" }
+	res := resource.New(&Product{})
+	res.ProtectDuplicateSubmission(tokens)
+
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+	token, err := tokens.Generate(context)
+	require.NoError(t, err)
+	metaValues := &resource.MetaValues{Values: []*resource.MetaValue{{Name: resource.FormTokenParam, Value: token}}}
+	require.NoError(t, resource.DecodeToResource(res, &Product{}, metaValues, context).Start())
+	err = resource.DecodeToResource(res, &Product{}, metaValues, context).Start()
+	assert.EqualError(t, err, resource.ErrDuplicateSubmission.Error())
+}
@@ -0,0 +1,330 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/pkg/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// MaxGraphDepth max hops of a record neighborhood
+var MaxGraphDepth = 3
+
+// GraphNeighborLimit max related records loaded by association of a record
+var GraphNeighborLimit = 20
+
+// ErrUnknownResource returned when the resource of a graph isn't registered
+var ErrUnknownResource = errors.New("unknown resource")
+
+// ResourceGraph association graph between resources
+type ResourceGraph struct {
+	Nodes []ResourceNode `json:"nodes"`
+	Edges []ResourceEdge `json:"edges"`
+}
+
+// ResourceNode resource of a resource graph
+type ResourceNode struct {
+	Name  string `json:"name"`
+	Model string `json:"model"`
+	Table string `json:"table"`
+}
+
+// ResourceEdge association of a resource graph, cardinality is one of one-to-one, many-to-one, one-to-many and many-to-many
+type ResourceEdge struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Association string `json:"association"`
+	Kind        string `json:"kind"`
+	Cardinality string `json:"cardinality"`
+}
+
+// RecordGraph neighborhood of a record, the related records up to some hops
+type RecordGraph struct {
+	Root  string       `json:"root"`
+	Nodes []RecordNode `json:"nodes"`
+	Edges []RecordEdge `json:"edges"`
+}
+
+// RecordNode record of a record graph, its ID is "Resource:PrimaryKey"
+type RecordNode struct {
+	ID         string `json:"id"`
+	Resource   string `json:"resource"`
+	PrimaryKey string `json:"primaryKey"`
+	Label      string `json:"label"`
+	Depth      int    `json:"depth"`
+}
+
+// RecordEdge association between records of a record graph
+type RecordEdge struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Association string `json:"association"`
+}
+
+// Graph returns the association graph between the resources readable by the current user, associations to
+// unregistered models are left out
+func (introspector *Introspector) Graph(context *appsvr.Context) *ResourceGraph {
+	introspector.mutex.RLock()
+	defer introspector.mutex.RUnlock()
+
+	graph := &ResourceGraph{Nodes: []ResourceNode{}, Edges: []ResourceEdge{}}
+	for _, res := range introspector.resources {
+		if !res.HasPermission(roles.Read, context) {
+			continue
+		}
+
+		graph.Nodes = append(graph.Nodes, ResourceNode{
+			Name:  res.Name,
+			Model: utils.ModelType(res.Value).String(),
+			Table: context.GetDB().NewScope(res.Value).TableName(),
+		})
+
+		for _, field := range context.GetDB().NewScope(res.Value).GetStructFields() {
+			if field.IsIgnored || field.Relationship == nil {
+				continue
+			}
+
+			if target := introspector.resourceOf(field.Struct.Type); target != nil && target.HasPermission(roles.Read, context) {
+				graph.Edges = append(graph.Edges, ResourceEdge{
+					From:        res.Name,
+					To:          target.Name,
+					Association: field.Name,
+					Kind:        field.Relationship.Kind,
+					Cardinality: cardinality(field.Relationship.Kind),
+				})
+			}
+		}
+	}
+
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].Name < graph.Nodes[j].Name })
+	return graph
+}
+
+// Neighborhood returns the records related to a record up to depth hops, only records readable by the current user
+// are visited
+func (introspector *Introspector) Neighborhood(context *appsvr.Context, name string, primaryKey string, depth int) (*RecordGraph, error) {
+	introspector.mutex.RLock()
+	defer introspector.mutex.RUnlock()
+
+	res := introspector.resourceByName(name)
+	if res == nil {
+		return nil, ErrUnknownResource
+	}
+	if depth < 0 || depth > MaxGraphDepth {
+		depth = MaxGraphDepth
+	}
+
+	findContext := context.Clone()
+	findContext.ResourceID = primaryKey
+	root := res.NewStruct()
+	if err := res.CallFindOne(root, nil, findContext); err != nil {
+		return nil, err
+	}
+	if !res.HasRecordPermission(roles.Read, root, context) {
+		return nil, roles.ErrPermissionDenied
+	}
+
+	type visit struct {
+		res    *Resource
+		record interface{}
+		id     string
+	}
+
+	var (
+		db      = context.GetDB()
+		rootID  = recordID(res, root, context)
+		graph   = &RecordGraph{Root: rootID, Nodes: []RecordNode{}, Edges: []RecordEdge{}}
+		visited = map[string]bool{rootID: true}
+		edges   = map[RecordEdge]bool{}
+		current = []visit{{res: res, record: root, id: rootID}}
+	)
+	graph.Nodes = append(graph.Nodes, recordNode(res, root, rootID, 0, context))
+
+	for hop := 1; hop <= depth && len(current) > 0; hop++ {
+		var next []visit
+		for _, from := range current {
+			for _, field := range db.NewScope(from.record).GetStructFields() {
+				if field.IsIgnored || field.Relationship == nil {
+					continue
+				}
+
+				target := introspector.resourceOf(field.Struct.Type)
+				if target == nil || !target.HasPermission(roles.Read, context) {
+					continue
+				}
+
+				// associations to pointers are loaded to a record, the orm can't load them to a nil pointer
+				relatedType := field.Struct.Type
+				if relatedType.Kind() == reflect.Ptr {
+					relatedType = relatedType.Elem()
+				}
+				related := reflect.New(relatedType)
+				if err := db.Limit(GraphNeighborLimit).Model(from.record).Related(related.Interface(), field.Name).Error; err != nil {
+					continue
+				}
+
+				for _, record := range records(related) {
+					if !target.HasRecordPermission(roles.Read, record, context) {
+						continue
+					}
+
+					id := recordID(target, record, context)
+					if edge := (RecordEdge{From: from.id, To: id, Association: field.Name}); !edges[edge] {
+						edges[edge] = true
+						graph.Edges = append(graph.Edges, edge)
+					}
+
+					if !visited[id] {
+						visited[id] = true
+						graph.Nodes = append(graph.Nodes, recordNode(target, record, id, hop, context))
+						next = append(next, visit{res: target, record: record, id: id})
+					}
+				}
+			}
+		}
+		current = next
+	}
+	return graph, nil
+}
+
+// GraphHandler serves the resource graph as JSON, or the neighborhood of a record with
+// `?resource=Name&id=PrimaryKey&depth=N`
+func (introspector *Introspector) GraphHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var (
+			context = &appsvr.Context{Request: req, Writer: w, Config: introspector.Config}
+			query   = req.URL.Query()
+			result  interface{}
+		)
+
+		if name := query.Get("resource"); name != "" {
+			depth := 1
+			if value := query.Get("depth"); value != "" {
+				var err error
+				if depth, err = strconv.Atoi(value); err != nil {
+					http.Error(w, fmt.Sprintf("invalid depth %q", value), http.StatusBadRequest)
+					return
+				}
+			}
+
+			graph, err := introspector.Neighborhood(context, name, query.Get("id"), depth)
+			switch {
+			case err == ErrUnknownResource || orm.IsRecordNotFoundError(err):
+				http.NotFound(w, req)
+				return
+			case err == roles.ErrPermissionDenied:
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			result = graph
+		} else {
+			result = introspector.Graph(context)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	})
+}
+
+// resourceOf returns the registered resource of a model type, of its elements for slices
+func (introspector *Introspector) resourceOf(typ reflect.Type) *Resource {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+
+	for _, res := range introspector.resources {
+		if utils.ModelType(res.Value) == typ {
+			return res
+		}
+	}
+	return nil
+}
+
+func (introspector *Introspector) resourceByName(name string) *Resource {
+	for _, res := range introspector.resources {
+		if res.Name == name {
+			return res
+		}
+	}
+	return nil
+}
+
+// cardinality returns the cardinality of a relationship kind
+func cardinality(kind string) string {
+	switch kind {
+	case "belongs_to":
+		return "many-to-one"
+	case "has_one":
+		return "one-to-one"
+	case "has_many":
+		return "one-to-many"
+	case "many_to_many":
+		return "many-to-many"
+	}
+	return kind
+}
+
+// records returns the records of a pointer to a struct, a pointer or a slice
+func records(value reflect.Value) []interface{} {
+	value = reflect.Indirect(value)
+	if value.Kind() == reflect.Slice {
+		var result []interface{}
+		for i := 0; i < value.Len(); i++ {
+			result = append(result, records(value.Index(i).Addr())...)
+		}
+		return result
+	}
+
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	return []interface{}{value.Addr().Interface()}
+}
+
+func recordID(res *Resource, record interface{}, context *appsvr.Context) string {
+	return res.Name + ":" + fmt.Sprint(context.GetDB().NewScope(record).PrimaryKeyValue())
+}
+
+func recordNode(res *Resource, record interface{}, id string, depth int, context *appsvr.Context) RecordNode {
+	return RecordNode{
+		ID:         id,
+		Resource:   res.Name,
+		PrimaryKey: fmt.Sprint(context.GetDB().NewScope(record).PrimaryKeyValue()),
+		Label:      utils.Stringify(record),
+		Depth:      depth,
+	}
+}
@@ -0,0 +1,157 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Author struct {
+	ID    uint
+	Name  string
+	Books []Book
+}
+
+type Book struct {
+	ID       uint
+	Title    string
+	AuthorID uint
+	Author   *Author
+	Reviews  []Review
+}
+
+type Review struct {
+	ID     uint
+	BookID uint
+	Body   string
+}
+
+func newIntrospector(t *testing.T) (*resource.Introspector, *resource.Resource) {
+	db := utils.SQLiteTestDB(t,
+		`CREATE TABLE authors (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`,
+		`CREATE TABLE books (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT, author_id INTEGER)`,
+		`CREATE TABLE reviews (id INTEGER PRIMARY KEY AUTOINCREMENT, book_id INTEGER, body TEXT)`,
+	)
+	require.NoError(t, db.Create(&Author{Name: "ann", Books: []Book{
+		{Title: "Go", Reviews: []Review{{Body: "good"}, {Body: "long"}}},
+		{Title: "SQL"},
+	}}).Error)
+	require.NoError(t, db.Create(&Author{Name: "bob"}).Error)
+
+	reviews := resource.New(&Review{})
+	return resource.NewIntrospector(&appsvr.Config{DB: db}, resource.New(&Author{}), resource.New(&Book{}), reviews), reviews
+}
+
+func TestIntrospectorGraph(t *testing.T) {
+	introspector, reviews := newIntrospector(t)
+	context := &appsvr.Context{Config: introspector.Config}
+
+	graph := introspector.Graph(context)
+	assert.Equal(t, []resource.ResourceNode{
+		{Name: "Author", Model: "resource_test.Author", Table: "authors"},
+		{Name: "Book", Model: "resource_test.Book", Table: "books"},
+		{Name: "Review", Model: "resource_test.Review", Table: "reviews"},
+	}, graph.Nodes)
+	assert.ElementsMatch(t, []resource.ResourceEdge{
+		{From: "Author", To: "Book", Association: "Books", Kind: "has_many", Cardinality: "one-to-many"},
+		{From: "Book", To: "Author", Association: "Author", Kind: "belongs_to", Cardinality: "many-to-one"},
+		{From: "Book", To: "Review", Association: "Reviews", Kind: "has_many", Cardinality: "one-to-many"},
+	}, graph.Edges)
+
+	// resources which aren't readable are left out
+	reviews.Permission = roles.Deny(roles.Read, roles.Anyone)
+	graph = introspector.Graph(context)
+	assert.Len(t, graph.Nodes, 2)
+	assert.Len(t, graph.Edges, 2)
+}
+
+func TestIntrospectorNeighborhood(t *testing.T) {
+	introspector, reviews := newIntrospector(t)
+	context := &appsvr.Context{Config: introspector.Config}
+	nodeIDs := func(graph *resource.RecordGraph) map[string]int {
+		ids := map[string]int{}
+		for _, node := range graph.Nodes {
+			ids[node.ID] = node.Depth
+		}
+		return ids
+	}
+
+	graph, err := introspector.Neighborhood(context, "Author", "1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Author:1", graph.Root)
+	assert.Equal(t, map[string]int{"Author:1": 0, "Book:1": 1, "Book:2": 1}, nodeIDs(graph))
+	assert.ElementsMatch(t, []resource.RecordEdge{
+		{From: "Author:1", To: "Book:1", Association: "Books"},
+		{From: "Author:1", To: "Book:2", Association: "Books"},
+	}, graph.Edges)
+
+	graph, err = introspector.Neighborhood(context, "Author", "1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"Author:1": 0, "Book:1": 1, "Book:2": 1, "Review:1": 2, "Review:2": 2}, nodeIDs(graph))
+	assert.Contains(t, graph.Edges, resource.RecordEdge{From: "Book:2", To: "Author:1", Association: "Author"}, "visited records are linked")
+
+	reviews.Permission = roles.Deny(roles.Read, roles.Anyone)
+	graph, err = introspector.Neighborhood(context, "Author", "1", 2)
+	require.NoError(t, err)
+	assert.Len(t, graph.Nodes, 3)
+
+	_, err = introspector.Neighborhood(context, "Publisher", "1", 1)
+	assert.ErrorIs(t, err, resource.ErrUnknownResource)
+	_, err = introspector.Neighborhood(context, "Author", "9", 1)
+	assert.True(t, orm.IsRecordNotFoundError(err))
+}
+
+func TestIntrospectorGraphHandler(t *testing.T) {
+	introspector, _ := newIntrospector(t)
+	serve := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		introspector.GraphHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/graph?"+query, nil))
+		return w
+	}
+
+	w := serve("")
+	require.Equal(t, http.StatusOK, w.Code)
+	var graph resource.ResourceGraph
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&graph))
+	assert.Len(t, graph.Nodes, 3)
+
+	w = serve("resource=Author&id=2")
+	require.Equal(t, http.StatusOK, w.Code)
+	var neighborhood resource.RecordGraph
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&neighborhood))
+	assert.Equal(t, "Author:2", neighborhood.Root)
+	assert.Len(t, neighborhood.Nodes, 1)
+
+	assert.Equal(t, http.StatusBadRequest, serve("resource=Author&id=1&depth=x").Code)
+	assert.Equal(t, http.StatusNotFound, serve("resource=Author&id=9").Code)
+	assert.Equal(t, http.StatusNotFound, serve("resource=Publisher&id=1").Code)
+}
@@ -0,0 +1,398 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	stdcontext "context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	resourcev1 "github.com/bhojpur/application/pkg/api/v1/resource"
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/roles"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// GRPCServer serves resources with the ResourceService of pkg/api/v1/resource. A call is handled like an HTTP
+// request: its metadata are the headers the roles are matched against, and records go through the find, save and
+// delete handlers, validators and processors of the resource
+type GRPCServer struct {
+	Config *appsvr.Config
+	// CurrentUser returns the user of a call, from its metadata, calls are anonymous if it isn't set
+	CurrentUser func(ctx stdcontext.Context) (appsvr.CurrentUser, error)
+	resources   map[string]Resourcer
+	mutex       sync.RWMutex
+}
+
+var _ resourcev1.ResourceServiceServer = (*GRPCServer)(nil)
+
+// NewGRPCServer initialize a gRPC server of the resources
+func NewGRPCServer(config *appsvr.Config, resources ...Resourcer) *GRPCServer {
+	server := &GRPCServer{Config: config, resources: map[string]Resourcer{}}
+	server.Add(resources...)
+	return server
+}
+
+// Add adds resources to the server, they are called by name
+func (server *GRPCServer) Add(resources ...Resourcer) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	for _, res := range resources {
+		server.resources[res.GetResource().Name] = res
+	}
+}
+
+// Register registers the ResourceService to s
+func (server *GRPCServer) Register(s *grpc.Server) {
+	resourcev1.RegisterResourceServiceServer(s, server)
+}
+
+// List returns the records of a resource, its scopes, filters and search are applied like the query of an HTTP request
+func (server *GRPCServer) List(ctx stdcontext.Context, req *resourcev1.ListRequest) (*resourcev1.ListResponse, error) {
+	res, err := server.resource(req.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if req.Keyword != "" {
+		query.Set("keyword", req.Keyword)
+	}
+	if len(req.Scopes) > 0 {
+		query["scopes"] = req.Scopes
+	}
+	for _, filter := range req.Filters {
+		key := "filters[" + filter.Name + "]"
+		if filter.Bound != "" {
+			key += "[" + filter.Bound + "]"
+		}
+		query[key] = append(query[key], filter.Values...)
+	}
+
+	var offset int
+	if req.PageToken != "" {
+		if offset, err = strconv.Atoi(req.PageToken); err != nil || offset < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token %q", req.PageToken)
+		}
+	}
+
+	context, err := server.newContext(ctx, "List", query)
+	if err != nil {
+		return nil, err
+	}
+	if req.PageSize > 0 {
+		// one more record tells whether there is a next page
+		context.SetDB(context.GetReadDB().Offset(offset).Limit(int(req.PageSize) + 1))
+	}
+
+	results := res.NewSlice()
+	if err := res.CallFindMany(results, context); err != nil {
+		return nil, statusOf(err)
+	}
+
+	response := &resourcev1.ListResponse{}
+	values := reflect.Indirect(reflect.ValueOf(results))
+	for idx := 0; idx < values.Len(); idx++ {
+		if req.PageSize > 0 && idx == int(req.PageSize) {
+			response.NextPageToken = strconv.Itoa(offset + int(req.PageSize))
+			break
+		}
+
+		record, err := toRecord(res, values.Index(idx).Interface(), req.ReadMask, context)
+		if err != nil {
+			return nil, err
+		}
+		response.Records = append(response.Records, record)
+	}
+	return response, nil
+}
+
+// Get returns a record of a resource by its primary key
+func (server *GRPCServer) Get(ctx stdcontext.Context, req *resourcev1.GetRequest) (*resourcev1.Record, error) {
+	res, err := server.resource(req.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	context, err := server.newContext(ctx, "Get", nil)
+	if err != nil {
+		return nil, err
+	}
+	context.ResourceID = req.Id
+
+	result := res.NewStruct()
+	if err := res.CallFindOne(result, nil, context); err != nil {
+		return nil, statusOf(err)
+	}
+	return toRecord(res, result, req.ReadMask, context)
+}
+
+// Create decodes the fields to a new record with the validators and processors of the resource, and saves it
+func (server *GRPCServer) Create(ctx stdcontext.Context, req *resourcev1.CreateRequest) (*resourcev1.Record, error) {
+	res, err := server.resource(req.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	context, err := server.newContext(ctx, "Create", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	metaValues, err := convertMapToMetaValues(req.Fields.AsMap(), res.GetMetas([]string{}))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result := res.NewStruct()
+	if err := saveRecord(res, result, metaValues, context); err != nil {
+		return nil, err
+	}
+	return toRecord(res, result, req.ReadMask, context)
+}
+
+// Update decodes the fields of the update mask, or all fields if it is empty, to a record with the validators and
+// processors of the resource, and saves it. Its primary key can't be updated
+func (server *GRPCServer) Update(ctx stdcontext.Context, req *resourcev1.UpdateRequest) (*resourcev1.Record, error) {
+	res, err := server.resource(req.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	context, err := server.newContext(ctx, "Update", nil)
+	if err != nil {
+		return nil, err
+	}
+	context.ResourceID = req.Id
+
+	result := res.NewStruct()
+	if err := res.CallFindOne(result, nil, context); err != nil {
+		return nil, statusOf(err)
+	}
+
+	fields := req.Fields.AsMap()
+	if paths := req.UpdateMask.GetPaths(); len(paths) > 0 {
+		for _, path := range paths {
+			if _, ok := lookupField(fields, path); !ok {
+				return nil, status.Errorf(codes.InvalidArgument, "field %q of the update mask is not set", path)
+			}
+		}
+		fields = maskFields(fields, paths)
+	}
+	for _, field := range res.GetResource().PrimaryFields {
+		delete(fields, field.Name)
+	}
+
+	metaValues, err := convertMapToMetaValues(fields, res.GetMetas([]string{}))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := saveRecord(res, result, metaValues, context); err != nil {
+		return nil, err
+	}
+	return toRecord(res, result, req.ReadMask, context)
+}
+
+// Delete deletes a record of a resource by its primary key
+func (server *GRPCServer) Delete(ctx stdcontext.Context, req *resourcev1.DeleteRequest) (*emptypb.Empty, error) {
+	res, err := server.resource(req.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	context, err := server.newContext(ctx, "Delete", nil)
+	if err != nil {
+		return nil, err
+	}
+	context.ResourceID = req.Id
+
+	if err := res.CallDelete(res.NewStruct(), context); err != nil {
+		return nil, statusOf(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (server *GRPCServer) resource(name string) (Resourcer, error) {
+	server.mutex.RLock()
+	defer server.mutex.RUnlock()
+
+	if res, ok := server.resources[name]; ok {
+		return res, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "%v %q", ErrUnknownResource, name)
+}
+
+// newContext returns the context of a call, its request carries the metadata of the call as headers, and the query
+// of List as URL query, so that roles, scopes and filters are resolved like the ones of HTTP requests
+func (server *GRPCServer) newContext(ctx stdcontext.Context, method string, query url.Values) (*appsvr.Context, error) {
+	header := http.Header{}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			if !strings.HasPrefix(key, ":") {
+				header[http.CanonicalHeaderKey(key)] = values
+			}
+		}
+	}
+
+	req := (&http.Request{
+		Method: http.MethodPost,
+		URL:    &url.URL{Path: "/" + resourcev1.ResourceService_ServiceDesc.ServiceName + "/" + method, RawQuery: query.Encode()},
+		Header: header,
+	}).WithContext(ctx)
+
+	context := &appsvr.Context{Request: req, Config: server.Config}
+	var user interface{}
+	if server.CurrentUser != nil {
+		currentUser, err := server.CurrentUser(ctx)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if currentUser != nil {
+			context.CurrentUser, user = currentUser, currentUser
+		}
+	}
+	context.Roles = roles.MatchedRoles(req, user)
+	return context, nil
+}
+
+// saveRecord decodes the meta values to result and saves it in a transaction, which is rolled back if a validator
+// or processor fails
+func saveRecord(res Resourcer, result interface{}, metaValues *MetaValues, context *appsvr.Context) error {
+	var decodeErr error
+	err := RunInTransaction(context, func(tx *Txn) error {
+		if decodeErr = tx.Decode(res, result, metaValues); decodeErr != nil {
+			return decodeErr
+		}
+		return tx.Save(res, result)
+	})
+
+	switch {
+	case err == nil:
+		return nil
+	case decodeErr != nil && !hasError(decodeErr, roles.ErrPermissionDenied):
+		return status.Error(codes.InvalidArgument, decodeErr.Error())
+	default:
+		return statusOf(err)
+	}
+}
+
+// statusOf returns the status of a handler error
+func statusOf(err error) error {
+	switch {
+	case hasError(err, roles.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case orm.IsRecordNotFoundError(err):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func hasError(err error, target error) bool {
+	if errs, ok := err.(interface{ GetErrors() []error }); ok {
+		for _, e := range errs.GetErrors() {
+			if errors.Is(e, target) {
+				return true
+			}
+		}
+		return false
+	}
+	return errors.Is(err, target)
+}
+
+// toRecord converts a record to its message, with the fields of the read mask, or all fields if it is empty
+func toRecord(res Resourcer, record interface{}, readMask *fieldmaskpb.FieldMask, context *appsvr.Context) (*resourcev1.Record, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if paths := readMask.GetPaths(); len(paths) > 0 {
+		fields = maskFields(fields, paths)
+	}
+
+	values, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &resourcev1.Record{
+		Resource: res.GetResource().Name,
+		Id:       res.GetResource().primaryKeyOf(record, context),
+		Fields:   values,
+	}, nil
+}
+
+// maskFields returns the fields of the paths, nested fields are separated by dots, like `Address.City`
+func maskFields(fields map[string]interface{}, paths []string) map[string]interface{} {
+	masked := map[string]interface{}{}
+	for _, path := range paths {
+		value, ok := lookupField(fields, path)
+		if !ok {
+			continue
+		}
+
+		names := strings.Split(path, ".")
+		parent := masked
+		for _, name := range names[:len(names)-1] {
+			child, ok := parent[name].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				parent[name] = child
+			}
+			parent = child
+		}
+		parent[names[len(names)-1]] = value
+	}
+	return masked
+}
+
+func lookupField(fields map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = fields
+	for _, name := range strings.Split(path, ".") {
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = values[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,187 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	resourcev1 "github.com/bhojpur/application/pkg/api/v1/resource"
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/pkg/validations"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGRPCServer(t *testing.T) (*resource.GRPCServer, *orm.DB) {
+	roles.Reset()
+	roles.Register("admin", func(req *http.Request, user interface{}) bool {
+		return req.Header.Get("X-Role") == "admin"
+	})
+	t.Cleanup(roles.Reset)
+
+	db := utils.SQLiteTestDB(t, `CREATE TABLE tickets (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT, state TEXT)`)
+	for _, ticket := range []Ticket{{Title: "Login", State: "open"}, {Title: "Logout", State: "closed"}, {Title: "Signup", State: "open"}} {
+		require.NoError(t, db.Create(&ticket).Error)
+	}
+
+	res := ticketResource{Resource: resource.New(&Ticket{})}
+	for _, name := range []string{"Title", "State"} {
+		meta := &resource.Meta{Name: name, BaseResource: res.Resource}
+		require.NoError(t, meta.PreInitialize())
+		require.NoError(t, meta.Initialize())
+		res.metas = append(res.metas, metaor{meta})
+	}
+	res.SearchAttrs("Title")
+	res.Scope(&resource.Scope{Name: "Open", Handler: func(db *orm.DB, context *appsvr.Context) *orm.DB {
+		return db.Where("state = ?", "open")
+	}})
+	res.Filter(&resource.Filter{Name: "State"})
+	res.AddValidator(&resource.Validator{Name: "title", Handler: func(record interface{}, metaValues *resource.MetaValues, context *appsvr.Context) error {
+		title := record.(*Ticket).Title
+		if metaValue := metaValues.Get("Title"); metaValue != nil {
+			title = fmt.Sprint(metaValue.Value)
+		}
+		if title == "" {
+			return validations.NewError(record, "Title", "Title can't be blank")
+		}
+		return nil
+	}})
+	res.Permission = roles.Allow(roles.Read, roles.Anyone).Allow(roles.Create, roles.Anyone).Allow(roles.Update, roles.Anyone).Allow(roles.Delete, "admin")
+
+	return resource.NewGRPCServer(&appsvr.Config{DB: db}, res), db
+}
+
+func recordIDs(records []*resourcev1.Record) []string {
+	var ids []string
+	for _, record := range records {
+		ids = append(ids, record.Id)
+	}
+	return ids
+}
+
+func TestGRPCServerList(t *testing.T) {
+	server, _ := newGRPCServer(t)
+	ctx := stdcontext.Background()
+
+	list := func(req *resourcev1.ListRequest) *resourcev1.ListResponse {
+		req.Resource = "Ticket"
+		response, err := server.List(ctx, req)
+		require.NoError(t, err)
+		return response
+	}
+
+	response := list(&resourcev1.ListRequest{PageSize: 2})
+	assert.Equal(t, []string{"3", "2"}, recordIDs(response.Records))
+	assert.Equal(t, "2", response.NextPageToken)
+	response = list(&resourcev1.ListRequest{PageSize: 2, PageToken: response.NextPageToken})
+	assert.Equal(t, []string{"1"}, recordIDs(response.Records))
+	assert.Empty(t, response.NextPageToken)
+
+	assert.Equal(t, []string{"2", "1"}, recordIDs(list(&resourcev1.ListRequest{Keyword: "Log"}).Records))
+	assert.Equal(t, []string{"3", "1"}, recordIDs(list(&resourcev1.ListRequest{Scopes: []string{"Open"}}).Records))
+	assert.Equal(t, []string{"2"}, recordIDs(list(&resourcev1.ListRequest{Filters: []*resourcev1.Filter{{Name: "State", Values: []string{"closed"}}}}).Records))
+
+	response = list(&resourcev1.ListRequest{Filters: []*resourcev1.Filter{{Name: "State", Values: []string{"closed"}}}, ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"Title"}}})
+	require.Len(t, response.Records, 1)
+	assert.Equal(t, "Ticket", response.Records[0].Resource)
+	assert.Equal(t, "2", response.Records[0].Id)
+	assert.Equal(t, map[string]interface{}{"Title": "Logout"}, response.Records[0].Fields.AsMap())
+
+	for _, token := range []string{"x", "-1"} {
+		_, err := server.List(ctx, &resourcev1.ListRequest{Resource: "Ticket", PageToken: token})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), token)
+	}
+	_, err := server.List(ctx, &resourcev1.ListRequest{Resource: "Unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCServerCRUD(t *testing.T) {
+	server, db := newGRPCServer(t)
+	ctx := stdcontext.Background()
+
+	record, err := server.Get(ctx, &resourcev1.GetRequest{Resource: "Ticket", Id: "2"})
+	require.NoError(t, err)
+	assert.Equal(t, "2", record.Id)
+	assert.Equal(t, map[string]interface{}{"ID": 2.0, "Title": "Logout", "State": "closed"}, record.Fields.AsMap())
+	_, err = server.Get(ctx, &resourcev1.GetRequest{Resource: "Ticket", Id: "9"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	fields, err := structpb.NewStruct(map[string]interface{}{"Title": "Reset", "State": "open"})
+	require.NoError(t, err)
+	record, err = server.Create(ctx, &resourcev1.CreateRequest{Resource: "Ticket", Fields: fields})
+	require.NoError(t, err)
+	assert.Equal(t, "4", record.Id)
+	var ticket Ticket
+	require.NoError(t, db.First(&ticket, 4).Error)
+	assert.Equal(t, Ticket{ID: 4, Title: "Reset", State: "open"}, ticket)
+
+	// records failing a validator are not saved
+	fields, err = structpb.NewStruct(map[string]interface{}{"State": "open"})
+	require.NoError(t, err)
+	_, err = server.Create(ctx, &resourcev1.CreateRequest{Resource: "Ticket", Fields: fields})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	var count int
+	require.NoError(t, db.Model(&Ticket{}).Count(&count).Error)
+	assert.Equal(t, 4, count)
+
+	// only the fields of the update mask are updated, and never the primary key
+	fields, err = structpb.NewStruct(map[string]interface{}{"ID": 9, "Title": "Sign in", "State": "closed"})
+	require.NoError(t, err)
+	record, err = server.Update(ctx, &resourcev1.UpdateRequest{Resource: "Ticket", Id: "1", Fields: fields, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"ID", "Title"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "1", record.Id)
+	ticket = Ticket{}
+	require.NoError(t, db.First(&ticket, 1).Error)
+	assert.Equal(t, Ticket{ID: 1, Title: "Sign in", State: "open"}, ticket)
+
+	_, err = server.Update(ctx, &resourcev1.UpdateRequest{Resource: "Ticket", Id: "1", Fields: fields, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"Owner"}}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.Update(ctx, &resourcev1.UpdateRequest{Resource: "Ticket", Id: "9", Fields: fields})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// the metadata of calls are the headers roles are matched against
+	_, err = server.Delete(ctx, &resourcev1.DeleteRequest{Resource: "Ticket", Id: "1"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.Delete(metadata.NewIncomingContext(ctx, metadata.Pairs("x-role", "admin")), &resourcev1.DeleteRequest{Resource: "Ticket", Id: "1"})
+	require.NoError(t, err)
+	_, err = server.Get(ctx, &resourcev1.GetRequest{Resource: "Ticket", Id: "1"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	server.CurrentUser = func(stdcontext.Context) (appsvr.CurrentUser, error) {
+		return nil, errors.New("invalid token")
+	}
+	_, err = server.Get(ctx, &resourcev1.GetRequest{Resource: "Ticket", Id: "2"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,396 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/validations"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// ImportMode mode of validation of an import
+type ImportMode int
+
+const (
+	// ImportRowByRow decodes, validates and saves rows one by one, and stops at the first failing row
+	ImportRowByRow ImportMode = iota
+	// ImportDeferred decodes and validates every row, then checks the constraints across records in a final
+	// pass; rows are saved in a transaction only if no row failed, otherwise the report lists every error
+	ImportDeferred
+)
+
+// Constraints of import errors
+const (
+	ImportConstraintValidation = "validation"
+	ImportConstraintUnique     = "unique"
+	ImportConstraintForeignKey = "foreign_key"
+	ImportConstraintSave       = "save"
+)
+
+// importBatchSize max values of the IN conditions of constraint checks
+var importBatchSize = 500
+
+var errImportFailed = errors.New("import failed")
+
+// ImportRow row of an import source, Line maps errors back to the source
+type ImportRow struct {
+	Line       int
+	MetaValues *MetaValues
+}
+
+// ImportError error of a row of an import
+type ImportError struct {
+	Line       int    `json:"line"`
+	Column     string `json:"column,omitempty"`
+	Constraint string `json:"constraint"`
+	Message    string `json:"message"`
+}
+
+// Error returns the message with its line
+func (err ImportError) Error() string {
+	if err.Column != "" {
+		return fmt.Sprintf("line %d: %s: %s", err.Line, err.Column, err.Message)
+	}
+	return fmt.Sprintf("line %d: %s", err.Line, err.Message)
+}
+
+// ImportReport report of an import, errors are sorted by line
+type ImportReport struct {
+	Total    int           `json:"total"`
+	Imported int           `json:"imported"`
+	Errors   []ImportError `json:"errors,omitempty"`
+}
+
+// HasError returns true if a row failed
+func (report *ImportReport) HasError() bool {
+	return len(report.Errors) > 0
+}
+
+// FailedLines returns the lines of the failing rows
+func (report *ImportReport) FailedLines() []int {
+	var (
+		lines []int
+		seen  = map[int]bool{}
+	)
+	for _, err := range report.Errors {
+		if !seen[err.Line] {
+			seen[err.Line] = true
+			lines = append(lines, err.Line)
+		}
+	}
+	return lines
+}
+
+func (report *ImportReport) addError(line int, constraint string, err error) {
+	var errs appsvr.Errors
+	errs.AddError(err)
+	for _, err := range errs.GetErrors() {
+		importError := ImportError{Line: line, Constraint: constraint, Message: err.Error()}
+		if validationError, ok := err.(*validations.Error); ok {
+			importError.Column = validationError.Column
+		}
+		report.Errors = append(report.Errors, importError)
+	}
+}
+
+// ImportConfig import config
+type ImportConfig struct {
+	Mode ImportMode
+	// UniqueKeys columns unique across the records, by default the columns tagged unique and the unique indexes.
+	// Only checked by deferred imports
+	UniqueKeys [][]string
+	// SkipForeignKeys disables the checks of the foreign keys of belongs to associations of deferred imports
+	SkipForeignKeys bool
+}
+
+// Import imports rows with the resource, see ImportMode. The returned error is for failures of the import itself,
+// failures of rows are in the report
+//
+//	report, err := res.Import(context, rows, resource.ImportConfig{Mode: resource.ImportDeferred})
+func (res *Resource) Import(context *appsvr.Context, rows []ImportRow, config ImportConfig) (*ImportReport, error) {
+	report := &ImportReport{Total: len(rows)}
+
+	if config.Mode == ImportRowByRow {
+		for _, row := range rows {
+			record := res.NewStruct()
+			if err := DecodeToResource(res, record, row.MetaValues, context).Start(); err != nil {
+				report.addError(row.Line, ImportConstraintValidation, err)
+				return report, nil
+			}
+			if err := res.CallSave(record, context); err != nil {
+				report.addError(row.Line, ImportConstraintSave, err)
+				return report, nil
+			}
+			report.Imported++
+		}
+		return report, nil
+	}
+
+	records := make([]interface{}, len(rows))
+	for idx, row := range rows {
+		record := res.NewStruct()
+		if err := DecodeToResource(res, record, row.MetaValues, context).Start(); err != nil {
+			report.addError(row.Line, ImportConstraintValidation, err)
+			continue
+		}
+		records[idx] = record
+	}
+
+	if err := res.checkUniqueKeys(context, rows, records, config, report); err != nil {
+		return report, err
+	}
+	if !config.SkipForeignKeys {
+		if err := res.checkForeignKeys(context, rows, records, report); err != nil {
+			return report, err
+		}
+	}
+
+	if !report.HasError() {
+		err := RunInTransaction(context, func(tx *Txn) error {
+			for idx, record := range records {
+				if err := res.CallSave(record, tx.Context); err != nil {
+					report.addError(rows[idx].Line, ImportConstraintSave, err)
+					return errImportFailed
+				}
+			}
+			return nil
+		})
+
+		if err == nil {
+			report.Imported = len(records)
+		} else if !report.HasError() {
+			return report, err
+		}
+	}
+
+	sort.SliceStable(report.Errors, func(i, j int) bool { return report.Errors[i].Line < report.Errors[j].Line })
+	return report, nil
+}
+
+// importUniqueKeys returns the unique keys of config, or the ones of the model
+func (res *Resource) importUniqueKeys(scope *orm.Scope, config ImportConfig) [][]string {
+	if len(config.UniqueKeys) > 0 {
+		return config.UniqueKeys
+	}
+
+	var (
+		keys    [][]string
+		indexes = map[string][]string{}
+		names   []string
+	)
+	for _, field := range scope.GetStructFields() {
+		if !field.IsNormal || field.IsIgnored {
+			continue
+		}
+		if _, ok := field.TagSettingsGet("UNIQUE"); ok {
+			keys = append(keys, []string{field.DBName})
+		}
+		if name, ok := field.TagSettingsGet("UNIQUE_INDEX"); ok {
+			for _, name := range strings.Split(name, ",") {
+				if name == "" || name == "UNIQUE_INDEX" {
+					name = "idx_" + field.DBName
+				}
+				if _, ok := indexes[name]; !ok {
+					names = append(names, name)
+				}
+				indexes[name] = append(indexes[name], field.DBName)
+			}
+		}
+	}
+
+	for _, name := range names {
+		keys = append(keys, indexes[name])
+	}
+	return keys
+}
+
+// checkUniqueKeys reports the rows duplicating a key of a previous row or of an existing record
+func (res *Resource) checkUniqueKeys(context *appsvr.Context, rows []ImportRow, records []interface{}, config ImportConfig, report *ImportReport) error {
+	db := context.GetDB()
+	resScope := db.NewScope(res.Value)
+	for _, key := range res.importUniqueKeys(resScope, config) {
+		var (
+			lines   = map[string]int{}
+			values  = map[string][]interface{}{}
+			indexes []int
+		)
+
+		for idx, record := range records {
+			if record == nil {
+				continue
+			}
+
+			keyValues, ok := fieldValues(db.NewScope(record), key)
+			if !ok {
+				continue
+			}
+
+			value := fmt.Sprint(keyValues...)
+			if line, ok := lines[value]; ok {
+				report.Errors = append(report.Errors, ImportError{
+					Line: rows[idx].Line, Column: strings.Join(key, ","), Constraint: ImportConstraintUnique,
+					Message: fmt.Sprintf("duplicates line %d", line),
+				})
+				continue
+			}
+			lines[value], values[value] = rows[idx].Line, keyValues
+			indexes = append(indexes, idx)
+		}
+
+		// existing records with the keys, other than the imported records themselves
+		for start := 0; start < len(indexes); start += importBatchSize {
+			end := start + importBatchSize
+			if end > len(indexes) {
+				end = len(indexes)
+			}
+
+			var (
+				conditions []string
+				params     []interface{}
+				columns    = append([]string{}, key...)
+			)
+			for _, field := range res.PrimaryFields {
+				columns = append(columns, field.DBName)
+			}
+			for _, idx := range indexes[start:end] {
+				var parts []string
+				for _, column := range key {
+					parts = append(parts, fmt.Sprintf("%v = ?", resScope.Quote(column)))
+				}
+				conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+				keyValues, _ := fieldValues(db.NewScope(records[idx]), key)
+				params = append(params, keyValues...)
+			}
+
+			existing := res.NewSlice()
+			if err := db.Select(columns).Where(strings.Join(conditions, " OR "), params...).Find(existing).Error; err != nil {
+				return err
+			}
+
+			existingValues := reflect.Indirect(reflect.ValueOf(existing))
+			for i := 0; i < existingValues.Len(); i++ {
+				existingScope := db.NewScope(existingValues.Index(i).Interface())
+				keyValues, _ := fieldValues(existingScope, key)
+				value := fmt.Sprint(keyValues...)
+
+				for _, idx := range indexes[start:end] {
+					recordScope := db.NewScope(records[idx])
+					if recordValues, _ := fieldValues(recordScope, key); fmt.Sprint(recordValues...) != value {
+						continue
+					}
+					if !recordScope.PrimaryKeyZero() && fmt.Sprint(recordScope.PrimaryKeyValue()) == fmt.Sprint(existingScope.PrimaryKeyValue()) {
+						continue
+					}
+					report.Errors = append(report.Errors, ImportError{
+						Line: rows[idx].Line, Column: strings.Join(key, ","), Constraint: ImportConstraintUnique,
+						Message: "already exists",
+					})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkForeignKeys reports the rows referencing missing records by the foreign keys of belongs to associations
+func (res *Resource) checkForeignKeys(context *appsvr.Context, rows []ImportRow, records []interface{}, report *ImportReport) error {
+	db := context.GetDB()
+	for _, field := range db.NewScope(res.Value).GetStructFields() {
+		relationship := field.Relationship
+		if field.IsIgnored || relationship == nil || relationship.Kind != "belongs_to" ||
+			len(relationship.ForeignFieldNames) != 1 || len(relationship.AssociationForeignDBNames) != 1 {
+			continue
+		}
+
+		var (
+			foreignKey = relationship.ForeignFieldNames[0]
+			column     = relationship.AssociationForeignDBNames[0]
+			values     []interface{}
+			seen       = map[string]bool{}
+		)
+		for _, record := range records {
+			if record == nil {
+				continue
+			}
+			if keyValues, ok := fieldValues(db.NewScope(record), []string{foreignKey}); ok && !seen[fmt.Sprint(keyValues[0])] {
+				seen[fmt.Sprint(keyValues[0])] = true
+				values = append(values, keyValues[0])
+			}
+		}
+
+		associated := db.NewScope(reflect.New(indirectType(field.Struct.Type)).Interface())
+		found := map[string]bool{}
+		for start := 0; start < len(values); start += importBatchSize {
+			end := start + importBatchSize
+			if end > len(values) {
+				end = len(values)
+			}
+
+			var existing []string
+			if err := db.Table(associated.TableName()).Where(fmt.Sprintf("%v IN (?)", associated.Quote(column)), values[start:end]).Pluck(column, &existing).Error; err != nil {
+				return err
+			}
+			for _, value := range existing {
+				found[value] = true
+			}
+		}
+
+		for idx, record := range records {
+			if record == nil {
+				continue
+			}
+			if keyValues, ok := fieldValues(db.NewScope(record), []string{foreignKey}); ok && !found[fmt.Sprint(keyValues[0])] {
+				report.Errors = append(report.Errors, ImportError{
+					Line: rows[idx].Line, Column: foreignKey, Constraint: ImportConstraintForeignKey,
+					Message: fmt.Sprintf("%v %v not found", field.Name, keyValues[0]),
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// fieldValues returns the values of the columns of a record, false if one of them is blank
+func fieldValues(scope *orm.Scope, columns []string) ([]interface{}, bool) {
+	var values []interface{}
+	for _, column := range columns {
+		field, ok := scope.FieldByName(column)
+		if !ok || field.IsBlank {
+			return nil, false
+		}
+		values = append(values, reflect.Indirect(field.Field).Interface())
+	}
+	return values, true
+}
+
+// indirectType returns the element type of pointers and slices
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	return typ
+}
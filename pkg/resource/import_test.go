@@ -0,0 +1,119 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/validations"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Crew struct {
+	ID   uint
+	Name string
+}
+
+type Sailor struct {
+	ID     uint
+	Email  string `orm:"unique"`
+	CrewID uint
+	Crew   *Crew
+}
+
+func TestImport(t *testing.T) {
+	db := utils.SQLiteTestDB(t,
+		`CREATE TABLE crews (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`,
+		`CREATE TABLE sailors (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT UNIQUE, crew_id INTEGER)`,
+	)
+	require.NoError(t, db.Create(&Crew{Name: "Blue"}).Error)
+	require.NoError(t, db.Create(&Sailor{Email: "old@sea.org", CrewID: 1}).Error)
+
+	res := resource.New(&Sailor{})
+	var metas []*resource.Meta
+	for _, name := range []string{"Email", "CrewID"} {
+		meta := &resource.Meta{Name: name, BaseResource: res}
+		require.NoError(t, meta.PreInitialize())
+		require.NoError(t, meta.Initialize())
+		metas = append(metas, meta)
+	}
+	res.AddValidator(&resource.Validator{Name: "email", Handler: func(record interface{}, metaValues *resource.MetaValues, context *appsvr.Context) error {
+		if email := metaValues.Get("Email"); email == nil || email.Value == "" {
+			return validations.NewError(record, "Email", "Email can't be blank")
+		}
+		return nil
+	}})
+
+	row := func(line int, email string, crewID uint) resource.ImportRow {
+		return resource.ImportRow{Line: line, MetaValues: &resource.MetaValues{Values: []*resource.MetaValue{
+			{Name: "Email", Value: email, Meta: metaor{metas[0]}},
+			{Name: "CrewID", Value: fmt.Sprint(crewID), Meta: metaor{metas[1]}},
+		}}}
+	}
+	emails := func() []string {
+		var emails []string
+		require.NoError(t, db.Model(&Sailor{}).Order("id").Pluck("email", &emails).Error)
+		return emails
+	}
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+
+	t.Run("deferred imports report every failing row and save none", func(t *testing.T) {
+		report, err := res.Import(context, []resource.ImportRow{
+			row(6, "sam@sea.org", 9),
+			row(2, "ann@sea.org", 1),
+			row(3, "", 1),
+			row(4, "ann@sea.org", 1),
+			row(5, "old@sea.org", 1),
+		}, resource.ImportConfig{Mode: resource.ImportDeferred})
+		require.NoError(t, err)
+		assert.Equal(t, 5, report.Total)
+		assert.Zero(t, report.Imported)
+		assert.Equal(t, []resource.ImportError{
+			{Line: 3, Column: "Email", Constraint: resource.ImportConstraintValidation, Message: "Email can't be blank"},
+			{Line: 4, Column: "email", Constraint: resource.ImportConstraintUnique, Message: "duplicates line 2"},
+			{Line: 5, Column: "email", Constraint: resource.ImportConstraintUnique, Message: "already exists"},
+			{Line: 6, Column: "CrewID", Constraint: resource.ImportConstraintForeignKey, Message: "Crew 9 not found"},
+		}, report.Errors)
+		assert.Equal(t, []int{3, 4, 5, 6}, report.FailedLines())
+		assert.Equal(t, "line 4: email: duplicates line 2", report.Errors[1].Error())
+		assert.Equal(t, []string{"old@sea.org"}, emails())
+
+		report, err = res.Import(context, []resource.ImportRow{row(2, "ann@sea.org", 1), row(3, "bob@sea.org", 1)}, resource.ImportConfig{Mode: resource.ImportDeferred})
+		require.NoError(t, err)
+		assert.False(t, report.HasError())
+		assert.Equal(t, 2, report.Imported)
+		assert.Equal(t, []string{"old@sea.org", "ann@sea.org", "bob@sea.org"}, emails())
+	})
+
+	t.Run("row by row imports stop at the first failing row", func(t *testing.T) {
+		report, err := res.Import(context, []resource.ImportRow{row(2, "eve@sea.org", 1), row(3, "", 1), row(4, "joe@sea.org", 1)}, resource.ImportConfig{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Imported)
+		assert.Equal(t, []int{3}, report.FailedLines())
+		assert.Equal(t, "eve@sea.org", emails()[3])
+		assert.Len(t, emails(), 4)
+	})
+}
@@ -0,0 +1,298 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/pkg/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// ResourceSchema machine-readable description of a resource, for external tools like ETL and BI
+type ResourceSchema struct {
+	Name         string              `json:"name"`
+	Model        string              `json:"model"`
+	Table        string              `json:"table"`
+	PrimaryKeys  []string            `json:"primaryKeys"`
+	Fields       []FieldSchema       `json:"fields"`
+	Associations []AssociationSchema `json:"associations,omitempty"`
+	Validators   []string            `json:"validators,omitempty"`
+	Processors   []string            `json:"processors,omitempty"`
+	Permissions  *PermissionSchema   `json:"permissions,omitempty"`
+	SearchAttrs  []string            `json:"searchAttrs,omitempty"`
+	Scopes       []string            `json:"scopes,omitempty"`
+	Filters      []FilterSchema      `json:"filters,omitempty"`
+	EventSourced bool                `json:"eventSourced,omitempty"`
+}
+
+// FieldSchema description of a field of a resource
+type FieldSchema struct {
+	Name        string   `json:"name"`
+	Column      string   `json:"column"`
+	Label       string   `json:"label"`
+	Type        string   `json:"type"`
+	GoType      string   `json:"goType"`
+	SQLType     string   `json:"sqlType,omitempty"`
+	PrimaryKey  bool     `json:"primaryKey,omitempty"`
+	ForeignKey  bool     `json:"foreignKey,omitempty"`
+	Nullable    bool     `json:"nullable"`
+	Default     bool     `json:"default,omitempty"`
+	Validations []string `json:"validations,omitempty"`
+}
+
+// AssociationSchema description of an association of a resource
+type AssociationSchema struct {
+	Name                   string   `json:"name"`
+	Kind                   string   `json:"kind"`
+	Model                  string   `json:"model"`
+	ForeignKeys            []string `json:"foreignKeys"`
+	AssociationForeignKeys []string `json:"associationForeignKeys"`
+}
+
+// PermissionSchema summary of the permission of a resource, roles by permission mode
+type PermissionSchema struct {
+	Allowed     map[roles.PermissionMode][]string `json:"allowed,omitempty"`
+	Denied      map[roles.PermissionMode][]string `json:"denied,omitempty"`
+	Conditional map[roles.PermissionMode][]string `json:"conditional,omitempty"`
+}
+
+// FilterSchema description of a filter of a resource
+type FilterSchema struct {
+	Name    string         `json:"name"`
+	Type    FilterType     `json:"type"`
+	Options []FilterOption `json:"options,omitempty"`
+}
+
+// Describe returns the schema of the resource
+func (res *Resource) Describe(context *appsvr.Context) *ResourceSchema {
+	var (
+		scope  = context.GetDB().NewScope(res.Value)
+		schema = &ResourceSchema{
+			Name:         res.Name,
+			Model:        utils.ModelType(res.Value).String(),
+			Table:        scope.TableName(),
+			SearchAttrs:  res.searchAttrs,
+			EventSourced: res.IsEventSourced(),
+		}
+		defaults = map[string]bool{}
+	)
+
+	res.defaultMutex.RLock()
+	for _, meta := range res.defaultValues {
+		defaults[meta.FieldName] = true
+	}
+	res.defaultMutex.RUnlock()
+
+	for _, field := range res.PrimaryFields {
+		schema.PrimaryKeys = append(schema.PrimaryKeys, field.DBName)
+	}
+
+	for _, field := range scope.GetStructFields() {
+		if field.IsIgnored {
+			continue
+		}
+
+		if relationship := field.Relationship; relationship != nil {
+			schema.Associations = append(schema.Associations, AssociationSchema{
+				Name:                   field.Name,
+				Kind:                   relationship.Kind,
+				Model:                  utils.ModelType(reflect.New(field.Struct.Type).Interface()).String(),
+				ForeignKeys:            relationship.ForeignDBNames,
+				AssociationForeignKeys: relationship.AssociationForeignDBNames,
+			})
+			continue
+		}
+
+		if !field.IsNormal {
+			continue
+		}
+
+		fieldSchema := FieldSchema{
+			Name:        field.Name,
+			Column:      field.DBName,
+			Label:       res.GetLabel(field.Name),
+			Type:        fieldType(field.Struct.Type),
+			GoType:      field.Struct.Type.String(),
+			PrimaryKey:  field.IsPrimaryKey,
+			ForeignKey:  field.IsForeignKey,
+			Nullable:    isNullable(field),
+			Default:     defaults[field.Name] || field.HasDefaultValue,
+			Validations: fieldValidations(field),
+		}
+		fieldSchema.SQLType, _ = field.TagSettingsGet("TYPE")
+		schema.Fields = append(schema.Fields, fieldSchema)
+	}
+
+	for _, validator := range res.Validators {
+		schema.Validators = append(schema.Validators, validator.Name)
+	}
+
+	for _, processor := range res.Processors {
+		schema.Processors = append(schema.Processors, processor.Name)
+	}
+
+	if permission := res.Permission; permission != nil {
+		schema.Permissions = &PermissionSchema{
+			Allowed:     permission.AllowedRoles,
+			Denied:      permission.DeniedRoles,
+			Conditional: permission.ConditionalRoles(),
+		}
+	}
+
+	for _, scope := range res.scopes {
+		schema.Scopes = append(schema.Scopes, scope.Name)
+	}
+
+	for _, filter := range res.filters {
+		schema.Filters = append(schema.Filters, FilterSchema{Name: filter.Name, Type: filter.Type, Options: filter.Options})
+	}
+	return schema
+}
+
+// Introspector describes a set of resources, its Handler serves their schemas for external tools
+type Introspector struct {
+	Config    *appsvr.Config
+	resources []*Resource
+	mutex     sync.RWMutex
+}
+
+// NewIntrospector initialize an introspector of the resources
+func NewIntrospector(config *appsvr.Config, resources ...*Resource) *Introspector {
+	introspector := &Introspector{Config: config}
+	introspector.Add(resources...)
+	return introspector
+}
+
+// Add adds resources to the introspector
+func (introspector *Introspector) Add(resources ...*Resource) {
+	introspector.mutex.Lock()
+	defer introspector.mutex.Unlock()
+	introspector.resources = append(introspector.resources, resources...)
+}
+
+// Describe returns the schemas of the resources, sorted by name
+func (introspector *Introspector) Describe(context *appsvr.Context) []*ResourceSchema {
+	introspector.mutex.RLock()
+	defer introspector.mutex.RUnlock()
+
+	schemas := make([]*ResourceSchema, 0, len(introspector.resources))
+	for _, res := range introspector.resources {
+		schemas = append(schemas, res.Describe(context))
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Name < schemas[j].Name })
+	return schemas
+}
+
+// Handler serves the schemas of all resources as JSON, or the one of a resource with `?resource=Name`
+func (introspector *Introspector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		context := &appsvr.Context{Request: req, Writer: w, Config: introspector.Config}
+		schemas := introspector.Describe(context)
+
+		var result interface{} = schemas
+		if name := req.URL.Query().Get("resource"); name != "" {
+			result = nil
+			for _, schema := range schemas {
+				if schema.Name == name {
+					result = schema
+				}
+			}
+
+			if result == nil {
+				http.NotFound(w, req)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	})
+}
+
+var nullableType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// fieldType returns the portable type of a field: string, integer, float, boolean, time or json
+func fieldType(typ reflect.Type) string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ == reflect.TypeOf(time.Time{}) {
+		return "time"
+	}
+
+	// nullable types like sql.NullString have the type of their value
+	if typ.Kind() == reflect.Struct && isNullType(typ) && typ.NumField() > 0 {
+		return fieldType(typ.Field(0).Type)
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return "binary"
+		}
+	}
+	return "json"
+}
+
+func isNullable(field *orm.StructField) bool {
+	if _, ok := field.TagSettingsGet("NOT NULL"); ok || field.IsPrimaryKey {
+		return false
+	}
+
+	typ := field.Struct.Type
+	return typ.Kind() == reflect.Ptr || (typ.Kind() != reflect.Struct && typ.Implements(nullableType)) ||
+		(typ.Kind() == reflect.Struct && isNullType(typ))
+}
+
+func isNullType(typ reflect.Type) bool {
+	return reflect.PtrTo(typ).Implements(nullableType) && strings.HasPrefix(typ.Name(), "Null")
+}
+
+// fieldValidations returns the validations of the `valid` tag of a field, without their custom messages
+func fieldValidations(field *orm.StructField) []string {
+	var validations []string
+	for _, validation := range strings.Split(field.Tag.Get("valid"), ",") {
+		if validation = strings.TrimSpace(strings.SplitN(validation, "~", 2)[0]); validation != "" {
+			validations = append(validations, validation)
+		}
+	}
+	return validations
+}
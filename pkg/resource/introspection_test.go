@@ -0,0 +1,146 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Listing struct {
+	ID          uint
+	SKU         string  `orm:"not null" valid:"required,length(2|8)~SKU is too long"`
+	Price       float64 `orm:"type:decimal(10,2)"`
+	Stock       int
+	Active      bool
+	Note        sql.NullString
+	Photo       []byte
+	PublishedAt *time.Time
+	AuthorID    uint
+	Author      Author
+}
+
+func TestResourceDescribe(t *testing.T) {
+	introspector, _ := newIntrospector(t)
+	context := &appsvr.Context{Config: introspector.Config}
+
+	res := resource.New(&Listing{})
+	res.SetLabel("SKU", "Stock Keeping Unit")
+	res.AddValidator(&resource.Validator{Name: "price", Handler: func(interface{}, *resource.MetaValues, *appsvr.Context) error { return nil }})
+	res.Permission = roles.Allow(roles.Read, roles.Anyone).Deny(roles.Delete, "guest").
+		AllowIf(roles.Update, "seller", func(interface{}, *appsvr.Context) bool { return true })
+
+	schema := res.Describe(context)
+	assert.Equal(t, "Listing", schema.Name)
+	assert.Equal(t, "resource_test.Listing", schema.Model)
+	assert.Equal(t, "listings", schema.Table)
+	assert.Equal(t, []string{"id"}, schema.PrimaryKeys)
+	assert.Equal(t, []string{"price"}, schema.Validators)
+
+	fields := map[string]resource.FieldSchema{}
+	for _, field := range schema.Fields {
+		fields[field.Name] = field
+	}
+	assert.Len(t, fields, 9, "the association isn't a field")
+	assert.Equal(t, resource.FieldSchema{
+		Name: "SKU", Column: "sku", Label: "Stock Keeping Unit", Type: "string", GoType: "string",
+		Validations: []string{"required", "length(2|8)"},
+	}, fields["SKU"])
+	assert.Equal(t, resource.FieldSchema{
+		Name: "ID", Column: "id", Label: "ID", Type: "integer", GoType: "uint", PrimaryKey: true,
+	}, fields["ID"])
+	assert.Equal(t, "decimal(10,2)", fields["Price"].SQLType)
+
+	types := map[string]string{}
+	nullable := map[string]bool{}
+	for name, field := range fields {
+		types[name], nullable[name] = field.Type, field.Nullable
+	}
+	assert.Equal(t, map[string]string{
+		"ID": "integer", "SKU": "string", "Price": "float", "Stock": "integer", "Active": "boolean",
+		"Note": "string", "Photo": "binary", "PublishedAt": "time", "AuthorID": "integer",
+	}, types)
+	assert.Equal(t, map[string]bool{
+		"ID": false, "SKU": false, "Price": false, "Stock": false, "Active": false,
+		"Note": true, "Photo": false, "PublishedAt": true, "AuthorID": false,
+	}, nullable)
+
+	assert.Equal(t, []resource.AssociationSchema{{
+		Name: "Author", Kind: "belongs_to", Model: "resource_test.Author",
+		ForeignKeys: []string{"author_id"}, AssociationForeignKeys: []string{"id"},
+	}}, schema.Associations)
+
+	require.NotNil(t, schema.Permissions)
+	assert.Equal(t, []string{roles.Anyone}, schema.Permissions.Allowed[roles.Read])
+	assert.Equal(t, []string{"guest"}, schema.Permissions.Denied[roles.Delete])
+	assert.Equal(t, []string{"seller"}, schema.Permissions.Conditional[roles.Update])
+
+	res.Permission = nil
+	assert.Nil(t, res.Describe(context).Permissions)
+}
+
+func TestIntrospectorDescribe(t *testing.T) {
+	introspector, _ := newIntrospector(t)
+	context := &appsvr.Context{Config: introspector.Config}
+
+	introspector.Add(resource.New(&Listing{}))
+	var names []string
+	for _, schema := range introspector.Describe(context) {
+		names = append(names, schema.Name)
+	}
+	assert.Equal(t, []string{"Author", "Book", "Listing", "Review"}, names, "schemas are sorted by name")
+}
+
+func TestIntrospectorHandler(t *testing.T) {
+	introspector, _ := newIntrospector(t)
+	serve := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		introspector.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schema?"+query, nil))
+		return w
+	}
+
+	w := serve("")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var schemas []resource.ResourceSchema
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&schemas))
+	assert.Len(t, schemas, 3)
+
+	w = serve("resource=Book")
+	require.Equal(t, http.StatusOK, w.Code)
+	var schema resource.ResourceSchema
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&schema))
+	assert.Equal(t, "Book", schema.Name)
+	assert.Equal(t, "books", schema.Table)
+	assert.Len(t, schema.Associations, 2)
+
+	assert.Equal(t, http.StatusNotFound, serve("resource=Publisher").Code)
+}
@@ -0,0 +1,230 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/pkg/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// L10n is embedded into the models of localized resources, see EnableL10n. The language code is part of the primary
+// key, so a record has a copy per locale sharing its other primary keys
+//
+//	type Product struct {
+//		ID   uint `orm:"primary_key"`
+//		resource.L10n
+//		Name string
+//	}
+type L10n struct {
+	LanguageCode string `orm:"primary_key;size:20"`
+}
+
+// GetLanguageCode get the locale of the record
+func (l L10n) GetLanguageCode() string {
+	return l.LanguageCode
+}
+
+// SetLanguageCode set the locale of the record
+func (l *L10n) SetLanguageCode(code string) {
+	l.LanguageCode = code
+}
+
+type l10nInterface interface {
+	GetLanguageCode() string
+	SetLanguageCode(string)
+}
+
+type l10nConfig struct {
+	locales []string
+}
+
+// EnableL10n localizes the records of the resource to locales, its model should embed L10n, the first locale is the
+// default one. The locale of a context is its locale, see utils.GetLocale, locales not enabled use the default one.
+//
+// FindOne and FindMany return the copies of records in the locale of the context, or the ones in the default locale
+// if they aren't localized yet. Save writes the copy in the locale of the context, new records are created in the
+// default locale first. Deleting the copy in the default locale deletes all copies of the record. The primary fields
+// of the resource don't include the language code, so records keep their primary key in all locales
+//
+//	products.EnableL10n("en", "de", "fr")
+func (res *Resource) EnableL10n(locales ...string) {
+	if len(locales) == 0 {
+		utils.ExitWithMsg("L10n of resource %v should have locales", res.Name)
+	}
+	if _, ok := res.Value.(l10nInterface); !ok {
+		utils.ExitWithMsg("L10n is not supported for resource %v, its model should embed resource.L10n", res.Name)
+	}
+
+	var primaryFields []*orm.StructField
+	for _, field := range res.PrimaryFields {
+		if field.Name != "LanguageCode" {
+			primaryFields = append(primaryFields, field)
+		}
+	}
+	res.PrimaryFields = primaryFields
+
+	res.l10n = &l10nConfig{locales: locales}
+	findOneHandler, findManyHandler, saveHandler, deleteHandler := res.FindOneHandler, res.FindManyHandler, res.SaveHandler, res.DeleteHandler
+
+	res.FindOneHandler = func(result interface{}, metaValues *MetaValues, context *appsvr.Context) error {
+		locale := res.GetL10nLocale(context)
+		err := findOneHandler(result, metaValues, res.l10nContext(context, locale))
+		if orm.IsRecordNotFoundError(err) && locale != locales[0] {
+			err = findOneHandler(result, metaValues, res.l10nContext(context, locales[0]))
+		}
+		return err
+	}
+
+	res.FindManyHandler = func(result interface{}, context *appsvr.Context) error {
+		var (
+			db     = context.GetDB()
+			scope  = db.NewScope(res.Value)
+			locale = res.GetL10nLocale(context)
+			column = fmt.Sprintf("%v.%v", scope.QuotedTableName(), scope.Quote("language_code"))
+		)
+
+		if locale == locales[0] {
+			db = db.Where(column+" = ?", locale)
+		} else {
+			// records in the default locale are listed unless they have a copy in the locale
+			var conditions []string
+			for _, field := range res.PrimaryFields {
+				conditions = append(conditions, fmt.Sprintf("l10n.%v = %v.%v", scope.Quote(field.DBName), scope.QuotedTableName(), scope.Quote(field.DBName)))
+			}
+			db = db.Where(fmt.Sprintf("%v = ? OR (%v = ? AND NOT EXISTS (SELECT 1 FROM %v l10n WHERE %v AND l10n.%v = ?))",
+				column, column, scope.QuotedTableName(), strings.Join(conditions, " AND "), scope.Quote("language_code")), locale, locales[0], locale)
+		}
+
+		l10nContext := context.Clone()
+		l10nContext.SetDB(db)
+		return findManyHandler(result, l10nContext)
+	}
+
+	res.SaveHandler = func(result interface{}, context *appsvr.Context) error {
+		record, ok := result.(l10nInterface)
+		if !ok {
+			return fmt.Errorf("%T is not a localized record of resource %v", result, res.Name)
+		}
+
+		locale, db := res.GetL10nLocale(context), context.GetDB()
+		if db.NewScope(result).PrimaryKeyZero() {
+			if !res.HasRecordPermission(roles.Create, result, context) {
+				return roles.ErrPermissionDenied
+			}
+
+			// the database doesn't assign primary keys that are part of a composite one
+			if err := res.assignL10nPrimaryKey(result, context); err != nil {
+				return err
+			}
+			record.SetLanguageCode(locales[0])
+			if locale == locales[0] {
+				return db.Create(result).Error
+			}
+
+			return db.Transaction(func(tx *orm.DB) error {
+				if err := tx.Create(result).Error; err != nil {
+					return err
+				}
+				record.SetLanguageCode(locale)
+				return tx.Create(result).Error
+			})
+		}
+
+		record.SetLanguageCode(locale)
+		return saveHandler(result, context)
+	}
+
+	res.DeleteHandler = func(result interface{}, context *appsvr.Context) error {
+		locale := res.GetL10nLocale(context)
+		if err := deleteHandler(result, res.l10nContext(context, locale)); err != nil || locale != locales[0] {
+			return err
+		}
+
+		// deleting the copy in the default locale deletes the localized ones
+		primaryQuerySQL, primaryParams := res.ToPrimaryQueryParams(context.ResourceID, context)
+		return context.GetDB().Delete(res.NewStruct(), append([]interface{}{primaryQuerySQL}, primaryParams...)...).Error
+	}
+}
+
+// IsLocalized returns true if l10n is enabled for the resource
+func (res *Resource) IsLocalized() bool {
+	return res.l10n != nil
+}
+
+// GetL10nLocale returns the locale of the context among the ones of the resource, e.g. "de" for "de-CH", the default
+// one if it isn't enabled
+func (res *Resource) GetL10nLocale(context *appsvr.Context) string {
+	if res.l10n == nil {
+		return ""
+	}
+
+	var locale = context.GetLocale()
+	if locale == "" && context.Request != nil {
+		locale = utils.GetLocale(context)
+	}
+
+	for _, candidate := range []string{locale, strings.SplitN(locale, "-", 2)[0]} {
+		for _, l := range res.l10n.locales {
+			if strings.EqualFold(l, candidate) {
+				return l
+			}
+		}
+	}
+	return res.l10n.locales[0]
+}
+
+// l10nContext returns a clone of the context querying the copies of records in the locale
+func (res *Resource) l10nContext(context *appsvr.Context, locale string) *appsvr.Context {
+	scope := context.GetDB().NewScope(res.Value)
+	l10nContext := context.Clone()
+	l10nContext.SetDB(context.GetDB().Where(fmt.Sprintf("%v.%v = ?", scope.QuotedTableName(), scope.Quote("language_code")), locale))
+	return l10nContext
+}
+
+// assignL10nPrimaryKey assigns the next integer primary key of the table to a new record
+func (res *Resource) assignL10nPrimaryKey(record interface{}, context *appsvr.Context) error {
+	scope := context.GetDB().NewScope(record)
+	for _, primaryField := range res.PrimaryFields {
+		field, ok := scope.FieldByName(primaryField.Name)
+		if !ok || !field.IsBlank {
+			continue
+		}
+
+		switch field.Field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			var max int64
+			row := context.GetDB().Table(scope.TableName()).Select(fmt.Sprintf("COALESCE(MAX(%v), 0)", scope.Quote(field.DBName))).Row()
+			if err := row.Scan(&max); err != nil {
+				return err
+			}
+			if err := field.Set(max + 1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
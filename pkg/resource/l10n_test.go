@@ -0,0 +1,104 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Brand struct {
+	ID uint `orm:"primary_key"`
+	resource.L10n
+	Name string
+}
+
+func TestL10n(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE brands (id INTEGER, language_code VARCHAR(20), name TEXT, PRIMARY KEY (id, language_code))`)
+
+	res := resource.New(&Brand{})
+	res.EnableL10n("en", "de")
+	assert.Len(t, res.PrimaryFields, 1)
+
+	contextIn := func(locale string) *appsvr.Context {
+		context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+		context.SetLocale(locale)
+		return context
+	}
+	en, de := contextIn("en"), contextIn("de-CH")
+	assert.Equal(t, "de", res.GetL10nLocale(de))
+	assert.Equal(t, "en", res.GetL10nLocale(contextIn("fr")))
+
+	// records created in a locale are created in the default one too
+	require.NoError(t, res.CallSave(&Brand{Name: "Blue"}, en))
+	require.NoError(t, res.CallSave(&Brand{Name: "Rot"}, de))
+	var count int
+	require.NoError(t, db.Model(&Brand{}).Count(&count).Error)
+	assert.Equal(t, 3, count)
+
+	findOne := func(context *appsvr.Context, id string) Brand {
+		var brand Brand
+		context = context.Clone()
+		context.ResourceID = id
+		require.NoError(t, res.CallFindOne(&brand, nil, context))
+		return brand
+	}
+	names := func(context *appsvr.Context) []string {
+		var brands []Brand
+		require.NoError(t, res.CallFindMany(&brands, context))
+		var names []string
+		for _, brand := range brands {
+			names = append(names, brand.Name)
+		}
+		return names
+	}
+
+	// records are served in the default locale until they are localized
+	assert.Equal(t, Brand{ID: 1, L10n: resource.L10n{LanguageCode: "en"}, Name: "Blue"}, findOne(de, "1"))
+	assert.ElementsMatch(t, []string{"Blue", "Rot"}, names(de))
+	assert.ElementsMatch(t, []string{"Blue", "Rot"}, names(en))
+
+	brand := findOne(de, "1")
+	brand.Name = "Blau"
+	require.NoError(t, res.CallSave(&brand, de))
+	assert.Equal(t, "Blau", findOne(de, "1").Name)
+	assert.Equal(t, "Blue", findOne(en, "1").Name)
+	assert.ElementsMatch(t, []string{"Blau", "Rot"}, names(de))
+	assert.ElementsMatch(t, []string{"Blue", "Rot"}, names(en))
+
+	// deleting a localized copy falls back to the default locale, deleting the default one deletes all copies
+	deleteIn := func(context *appsvr.Context, id string) {
+		context = context.Clone()
+		context.ResourceID = id
+		require.NoError(t, res.CallDelete(&Brand{}, context))
+	}
+	deleteIn(de, "1")
+	assert.Equal(t, "Blue", findOne(de, "1").Name)
+	deleteIn(en, "2")
+	assert.Equal(t, []string{"Blue"}, names(de))
+	require.NoError(t, db.Model(&Brand{}).Count(&count).Error)
+	assert.Equal(t, 1, count)
+}
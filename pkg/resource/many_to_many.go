@@ -0,0 +1,213 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"reflect"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/pkg/utils"
+	"github.com/bhojpur/application/pkg/validations"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// setManyToMany sets the records of the primary keys of the meta value to the many-to-many field of the meta. They
+// have to be found and readable by the resource of the meta, if any. The changes of the association are applied when
+// the record is saved, in the transaction of the save, see CallSave
+func setManyToMany(meta *Meta, record interface{}, metaValue *MetaValue, field reflect.Value, context *appsvr.Context) {
+	var (
+		values      []string
+		primaryKeys []string
+		seen        = map[string]bool{}
+	)
+	// no value removes all associated records
+	if metaValue.Value != nil {
+		values = utils.ToArray(metaValue.Value)
+	}
+	for _, primaryKey := range values {
+		if primaryKey != "" && !seen[primaryKey] {
+			seen[primaryKey] = true
+			primaryKeys = append(primaryKeys, primaryKey)
+		}
+	}
+
+	field.Set(reflect.Zero(field.Type()))
+	if len(primaryKeys) > 0 {
+		if err := context.GetDB().Where(primaryKeys).Find(field.Addr().Interface()).Error; err != nil {
+			context.AddError(err)
+			return
+		}
+		if field.Len() != len(primaryKeys) {
+			context.AddError(validations.NewFieldError(record, meta.Name, validations.CodeNotFound, fmt.Sprintf("%v has records which are not found", meta.Name), nil))
+			return
+		}
+	}
+
+	if meta.Resource != nil {
+		res := meta.Resource.GetResource()
+		for idx := 0; idx < field.Len(); idx++ {
+			if !res.HasRecordPermission(roles.Read, addressOf(field.Index(idx)), context) {
+				context.AddError(validations.NewFieldError(record, meta.Name, validations.CodeNotFound, fmt.Sprintf("%v has records which are not readable", meta.Name), nil))
+				return
+			}
+		}
+	}
+
+	addPendingAssociation(context, &pendingAssociation{record: record, fieldName: meta.FieldName, records: field.Interface()})
+}
+
+// addressOf returns a pointer to the value of an element of a slice of structs or of pointers
+func addressOf(value reflect.Value) interface{} {
+	if value.Kind() == reflect.Ptr {
+		return value.Interface()
+	}
+	return value.Addr().Interface()
+}
+
+type contextKey int
+
+// pendingAssociationsKey is the context value of the association changes of the records to save
+const pendingAssociationsKey contextKey = iota
+
+// pendingAssociation is a change of a many-to-many association of a record, records are the associated records after
+// the change
+type pendingAssociation struct {
+	record    interface{}
+	fieldName string
+	records   interface{}
+}
+
+// pendingAssociations are shared by the clones of a context, so that records are saved with the changes decoded by
+// another clone
+type pendingAssociations struct {
+	changes []*pendingAssociation
+}
+
+func addPendingAssociation(context *appsvr.Context, change *pendingAssociation) {
+	pending, ok := context.Get(pendingAssociationsKey)
+	if !ok {
+		pending = &pendingAssociations{}
+		context.Set(pendingAssociationsKey, pending)
+	}
+
+	changes := pending.(*pendingAssociations).changes[:0]
+	for _, c := range pending.(*pendingAssociations).changes {
+		// the association is changed again by decoding another meta value
+		if c.record != change.record || c.fieldName != change.fieldName {
+			changes = append(changes, c)
+		}
+	}
+	pending.(*pendingAssociations).changes = append(changes, change)
+}
+
+// takePendingAssociations removes the association changes of record from the context and returns them
+func takePendingAssociations(context *appsvr.Context, record interface{}) []*pendingAssociation {
+	pending, ok := context.Get(pendingAssociationsKey)
+	if !ok {
+		return nil
+	}
+
+	var taken, left []*pendingAssociation
+	for _, change := range pending.(*pendingAssociations).changes {
+		if change.record == record {
+			taken = append(taken, change)
+		} else {
+			left = append(left, change)
+		}
+	}
+	pending.(*pendingAssociations).changes = left
+	return taken
+}
+
+// saveWithAssociations saves result with the save handler and applies the changes of its many-to-many associations,
+// in a transaction. The associations are not saved with the record, so that only their join table is changed
+func (res *Resource) saveWithAssociations(result interface{}, changes []*pendingAssociation, context *appsvr.Context) error {
+	return context.GetDB().Transaction(func(tx *orm.DB) error {
+		txContext := context.Clone()
+		txContext.SetDB(tx)
+
+		record := utils.Indirect(reflect.ValueOf(result))
+		for _, change := range changes {
+			field := record.FieldByName(change.fieldName)
+			field.Set(reflect.Zero(field.Type()))
+		}
+		defer func() {
+			for _, change := range changes {
+				record.FieldByName(change.fieldName).Set(reflect.ValueOf(change.records))
+			}
+		}()
+
+		if err := res.SaveHandler(result, txContext); err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if err := change.apply(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// apply adds the associated records which are not associated yet and removes the associated records which are not
+// in the records of the change, the join table is changed only
+func (change *pendingAssociation) apply(db *orm.DB) error {
+	records := reflect.ValueOf(change.records)
+	current := reflect.New(records.Type())
+	association := db.Model(change.record).Association(change.fieldName)
+	if err := association.Find(current.Interface()).Error; err != nil {
+		return err
+	}
+
+	keys := func(records reflect.Value) map[string]interface{} {
+		values := map[string]interface{}{}
+		for idx := 0; idx < records.Len(); idx++ {
+			record := addressOf(records.Index(idx))
+			values[fmt.Sprint(db.NewScope(record).PrimaryKeyValue())] = record
+		}
+		return values
+	}
+
+	var (
+		wanted, associated  = keys(records), keys(current.Elem())
+		additions, removals []interface{}
+	)
+	for key, record := range wanted {
+		if _, ok := associated[key]; !ok {
+			additions = append(additions, record)
+		}
+	}
+	for key, record := range associated {
+		if _, ok := wanted[key]; !ok {
+			removals = append(removals, record)
+		}
+	}
+
+	if len(removals) > 0 {
+		association.Delete(removals...)
+	}
+	if len(additions) > 0 {
+		association.Append(additions...)
+	}
+	return association.Error
+}
@@ -0,0 +1,98 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Tag struct {
+	ID   uint
+	Name string
+}
+
+type Post struct {
+	ID    uint
+	Title string
+	Tags  []Tag `orm:"many2many:post_tags"`
+}
+
+func TestManyToManyMeta(t *testing.T) {
+	db := utils.SQLiteTestDB(t,
+		`CREATE TABLE posts (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT)`,
+		`CREATE TABLE tags (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`,
+		`CREATE TABLE post_tags (post_id INTEGER, tag_id INTEGER, PRIMARY KEY (post_id, tag_id))`,
+	)
+	for _, name := range []string{"go", "sql", "web"} {
+		require.NoError(t, db.Create(&Tag{Name: name}).Error)
+	}
+
+	posts, tags := resource.New(&Post{}), resource.New(&Tag{})
+	meta := &resource.Meta{Name: "Tags", BaseResource: posts, Resource: tags}
+	require.NoError(t, meta.PreInitialize())
+	require.NoError(t, meta.Initialize())
+
+	save := func(post *Post, value interface{}, roleNames ...string) *appsvr.Context {
+		context := &appsvr.Context{Config: &appsvr.Config{DB: db}, Roles: roleNames}
+		meta.GetSetter()(post, &resource.MetaValue{Name: "Tags", Value: value}, context)
+		if !context.HasError() {
+			require.NoError(t, posts.CallSave(post, context))
+		}
+		return context
+	}
+	associated := func() []uint {
+		var ids []uint
+		require.NoError(t, db.Table("post_tags").Order("tag_id").Pluck("tag_id", &ids).Error)
+		return ids
+	}
+
+	post := Post{Title: "Hello"}
+	require.Empty(t, save(&post, []string{"1", "2", "2"}).GetErrors())
+	assert.NotZero(t, post.ID)
+	assert.Equal(t, []uint{1, 2}, associated())
+	assert.Len(t, post.Tags, 2)
+
+	require.Empty(t, save(&post, []interface{}{2.0, 3.0}).GetErrors())
+	assert.Equal(t, []uint{2, 3}, associated())
+	var stored []Tag
+	require.NoError(t, db.Find(&stored, []uint{2, 3}).Error)
+	assert.Equal(t, []Tag{{2, "sql"}, {3, "web"}}, stored, "associated records are not saved")
+
+	// records which are not found or not readable are errors, the association is kept
+	assert.True(t, save(&post, []string{"1", "9"}).HasError())
+	tags.Permission = roles.Allow(roles.Read, "admin")
+	assert.True(t, save(&post, []string{"1"}).HasError())
+	assert.Equal(t, []uint{2, 3}, associated())
+
+	require.Empty(t, save(&post, []string{"1"}, "admin").GetErrors())
+	assert.Equal(t, []uint{1}, associated())
+
+	require.Empty(t, save(&post, nil, "admin").GetErrors())
+	assert.Empty(t, associated())
+	assert.Empty(t, post.Tags)
+}
@@ -24,7 +24,6 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
 	"reflect"
 	"runtime/debug"
 	"strconv"
@@ -32,6 +31,7 @@ import (
 	"time"
 
 	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
 	"github.com/bhojpur/application/pkg/roles"
 	"github.com/bhojpur/application/pkg/utils"
 	"github.com/bhojpur/application/pkg/validations"
@@ -106,10 +106,18 @@ type Meta struct {
 	Setter          func(resource interface{}, metaValue *MetaValue, context *appsvr.Context)
 	Valuer          func(interface{}, *appsvr.Context) interface{}
 	FormattedValuer func(interface{}, *appsvr.Context) interface{}
+	DefaultValue    func() interface{}
+	Computed        func(interface{}, *appsvr.Context) interface{}
+	Polymorphic     *PolymorphicConfig
+	SelectOne       *SelectConfig
+	SelectMany      *SelectConfig
 	Config          MetaConfigInterface
 	BaseResource    Resourcer
 	Resource        Resourcer
 	Permission      *roles.Permission
+	// ShowCondition and RequiredCondition are the conditions of ShowIf and RequiredIf
+	ShowCondition     func(record interface{}, context *appsvr.Context) bool
+	RequiredCondition func(record interface{}, context *appsvr.Context) bool
 }
 
 // GetBaseResource gets base resource from meta
@@ -165,6 +173,21 @@ func (meta *Meta) SetFormattedValuer(fc func(interface{}, *appsvr.Context) inter
 	meta.FormattedValuer = fc
 }
 
+// SetDefaultValue sets default value for meta, new structs from NewStruct of its base resource are pre-populated with it
+func (meta *Meta) SetDefaultValue(fc func() interface{}) {
+	meta.DefaultValue = fc
+}
+
+// SetComputed sets meta as a read-only computed field, its value is evaluated from the record when serializing it and never stored
+func (meta *Meta) SetComputed(fc func(interface{}, *appsvr.Context) interface{}) {
+	meta.Computed = fc
+}
+
+// IsComputed returns true if meta is a computed field
+func (meta Meta) IsComputed() bool {
+	return meta.Computed != nil
+}
+
 // HasPermission checks, has permission or not
 func (meta Meta) HasPermission(mode roles.PermissionMode, context *appsvr.Context) bool {
 	if meta.Permission == nil {
@@ -177,6 +200,40 @@ func (meta Meta) HasPermission(mode roles.PermissionMode, context *appsvr.Contex
 	return meta.Permission.HasPermission(mode, roles...)
 }
 
+// ShowIf shows the meta for the records for which fc returns true only. Hidden metas aren't serialized, and their
+// meta values aren't decoded, after the ones of the other metas, so that fc gets their values, e.g.
+//
+//	meta.ShowIf(func(record interface{}, context *appsvr.Context) bool {
+//		return record.(*Order).Status == "shipped"
+//	})
+func (meta *Meta) ShowIf(fc func(record interface{}, context *appsvr.Context) bool) {
+	meta.ShowCondition = fc
+}
+
+// GetShowCondition gets the condition of ShowIf from meta
+func (meta Meta) GetShowCondition() func(record interface{}, context *appsvr.Context) bool {
+	return meta.ShowCondition
+}
+
+// IsShown returns true if the meta is shown for record, see ShowIf
+func (meta Meta) IsShown(record interface{}, context *appsvr.Context) bool {
+	return meta.ShowCondition == nil || meta.ShowCondition(record, context)
+}
+
+// RequiredIf requires a value of the meta for the records for which fc returns true, when the meta is shown. It is
+// checked once the meta values are decoded, a blank value is a validation error of the meta
+func (meta *Meta) RequiredIf(fc func(record interface{}, context *appsvr.Context) bool) {
+	meta.RequiredCondition = fc
+	if meta.BaseResource != nil {
+		meta.BaseResource.GetResource().addRequiredMeta(meta)
+	}
+}
+
+// IsRequired returns true if a value of the meta is required for record, see RequiredIf
+func (meta Meta) IsRequired(record interface{}, context *appsvr.Context) bool {
+	return meta.RequiredCondition != nil && meta.IsShown(record, context) && meta.RequiredCondition(record, context)
+}
+
 // SetPermission set permission for meta
 func (meta *Meta) SetPermission(permission *roles.Permission) {
 	meta.Permission = permission
@@ -223,6 +280,22 @@ func (meta *Meta) PreInitialize() error {
 
 // Initialize initializes meta, will set valuer, setter if haven't configure it
 func (meta *Meta) Initialize() error {
+	// Computed meta is read-only, it is valued from the record and ignored when decoding
+	if meta.Computed != nil {
+		meta.Valuer = meta.Computed
+		meta.Setter = func(interface{}, *MetaValue, *appsvr.Context) {}
+		return nil
+	}
+
+	if meta.RequiredCondition != nil {
+		meta.GetBaseResource().GetResource().addRequiredMeta(meta)
+	}
+
+	// Polymorphic meta references a record of one of several resources, by the fields of its type and primary key
+	if meta.Polymorphic != nil {
+		return meta.Polymorphic.configure(meta)
+	}
+
 	// Set Valuer for Meta
 	if meta.Valuer == nil {
 		setupValuer(meta, meta.FieldName, meta.GetBaseResource().NewStruct())
@@ -236,9 +309,106 @@ func (meta *Meta) Initialize() error {
 	if meta.Setter == nil {
 		setupSetter(meta, meta.FieldName, meta.GetBaseResource().NewStruct())
 	}
+
+	// Select meta's values have to be the values of its options, one or several of them
+	if meta.SelectOne != nil && meta.SelectMany != nil {
+		return fmt.Errorf("meta %v can't be both select one and select many", meta.Name)
+	} else if meta.SelectOne != nil {
+		if err := meta.SelectOne.configure(meta, false); err != nil {
+			return err
+		}
+	} else if meta.SelectMany != nil {
+		if err := meta.SelectMany.configure(meta, true); err != nil {
+			return err
+		}
+	}
+
+	if meta.DefaultValue != nil {
+		if err := meta.checkDefaultValue(); err != nil {
+			return err
+		}
+		meta.GetBaseResource().GetResource().addDefaultValue(meta)
+	}
 	return nil
 }
 
+// checkDefaultValue returns an error if the default value of meta is not assignable to its field
+func (meta *Meta) checkDefaultValue() error {
+	fieldType := utils.ModelType(meta.GetBaseResource().GetResource().Value)
+	for _, name := range strings.Split(meta.FieldName, ".") {
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		var field reflect.StructField
+		if fieldType.Kind() == reflect.Struct {
+			field, _ = fieldType.FieldByName(name)
+		}
+		if field.Type == nil || field.PkgPath != "" {
+			return fmt.Errorf("meta %v has a default value, but no field %v to set it to", meta.Name, meta.FieldName)
+		}
+		fieldType = field.Type
+	}
+
+	if value := reflect.ValueOf(meta.DefaultValue()); value.IsValid() {
+		if _, ok := convertDefaultValue(value, fieldType); !ok {
+			return fmt.Errorf("default value %v of meta %v is not assignable to %v", value.Interface(), meta.Name, fieldType)
+		}
+	}
+	return nil
+}
+
+// setDefaultValue sets the default value to the field of record if it is blank
+func (meta *Meta) setDefaultValue(record interface{}) {
+	field := reflect.ValueOf(record)
+	for _, name := range strings.Split(meta.FieldName, ".") {
+		for field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				if !field.CanSet() {
+					return
+				}
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			field = field.Elem()
+		}
+
+		if field.Kind() != reflect.Struct {
+			return
+		}
+		field = field.FieldByName(name)
+	}
+
+	if !field.IsValid() || !field.CanSet() || !field.IsZero() {
+		return
+	}
+
+	value := reflect.ValueOf(meta.DefaultValue())
+	if !value.IsValid() {
+		return
+	}
+
+	// default values are checked by Initialize
+	if value, ok := convertDefaultValue(value, field.Type()); ok {
+		field.Set(value)
+	}
+}
+
+// convertDefaultValue converts a default value to typ, it returns false if it is not assignable
+func convertDefaultValue(value reflect.Value, typ reflect.Type) (reflect.Value, bool) {
+	switch {
+	case typ.Kind() == reflect.String && value.Kind() != reflect.String:
+		// converting numbers to string would get runes
+		return reflect.ValueOf(utils.ToString(value.Interface())).Convert(typ), true
+	case value.Type().ConvertibleTo(typ):
+		return value.Convert(typ), true
+	case typ.Kind() == reflect.Ptr && value.Type().ConvertibleTo(typ.Elem()):
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(value.Convert(typ.Elem()))
+		return ptr, true
+	}
+	return reflect.Value{}, false
+}
+
 // setCompositePrimaryKey if the association has CompositePrimaryKey integrated, generates value for it by our conventional format
 // the PrimaryKeyOf function will return the value from CompositePrimaryKey instead of ID, so that frontend could find correct version
 func setCompositePrimaryKey(f *orm.Field) {
@@ -340,7 +510,7 @@ func switchRecordToNewVersionIfNeeded(context *appsvr.Context, record interface{
 			fn := recordPtr.MethodByName("AssignVersionName")
 
 			if !fn.IsValid() {
-				log.Printf("Struct %v must has function 'AssignVersionName' defined, with *Pointer* receiver to create associations on new version", reflect.TypeOf(record).Name())
+				log.Warn("struct must have function AssignVersionName defined, with pointer receiver, to create associations on new version", applog.String("struct", reflect.TypeOf(record).Name()))
 				return record
 			}
 			fn.Call(arguments)
@@ -352,7 +522,7 @@ func switchRecordToNewVersionIfNeeded(context *appsvr.Context, record interface{
 		// When the record is a pointer
 		fn := reflect.ValueOf(record).MethodByName("AssignVersionName")
 		if !fn.IsValid() {
-			log.Printf("Struct %v must has function 'AssignVersionName' defined, with *Pointer* receiver to create associations on new version", reflect.TypeOf(record).Name())
+			log.Warn("struct must have function AssignVersionName defined, with pointer receiver, to create associations on new version", applog.String("struct", reflect.TypeOf(record).Name()))
 			return record
 		}
 
@@ -489,7 +659,7 @@ func HandleManyToMany(context *appsvr.Context, scope *orm.Scope, meta *Meta, rec
 // HandleNormalManyToMany not only handle normal many_to_many relationship, it also handled the situation that user set the association to blank
 func HandleNormalManyToMany(context *appsvr.Context, field reflect.Value, metaValue *MetaValue, fieldHasVersion bool, compositePKeyConvertErr error) {
 	if fieldHasVersion && metaValue.Value != nil && compositePKeyConvertErr != nil {
-		fmt.Println("given meta value contains no version name, this might cause the association is incorrect")
+		log.WithContext(context).Warn("given meta value contains no version name, this might cause the association is incorrect", applog.String("meta", metaValue.Name))
 	}
 
 	primaryKeys := utils.ToArray(metaValue.Value)
@@ -551,8 +721,7 @@ func setupSetter(meta *Meta, fieldName string, record interface{}) {
 
 			defer func() {
 				if r := recover(); r != nil {
-					fmt.Println(r)
-					debug.PrintStack()
+					log.WithContext(context).Error("failed to set meta value", applog.String("meta", meta.Name), applog.Any("panic", r), applog.String("stack", string(debug.Stack())))
 					context.AddError(validations.NewError(record, meta.Name, fmt.Sprintf("Failed to set Meta %v's value with %v, got %v", meta.Name, metaValue.Value, r)))
 				}
 			}()
@@ -584,6 +753,12 @@ func setupSetter(meta *Meta, fieldName string, record interface{}) {
 		if relationship := meta.FieldStruct.Relationship; relationship != nil {
 			if relationship.Kind == "belongs_to" || relationship.Kind == "many_to_many" {
 				meta.Setter = commonSetter(func(field reflect.Value, metaValue *MetaValue, context *appsvr.Context, record interface{}) {
+					// many-to-many associations without versions are changed when the record is saved
+					if relationship.Kind == "many_to_many" && !fieldIsStructAndHasVersion(field) {
+						setManyToMany(meta, record, metaValue, field, context)
+						return
+					}
+
 					var (
 						scope         = context.GetDB().NewScope(record)
 						recordAsValue = reflect.Indirect(reflect.ValueOf(record))
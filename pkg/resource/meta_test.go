@@ -0,0 +1,96 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Invoice struct {
+	ID       uint
+	State    string
+	Code     string
+	Priority *int
+}
+
+func initializeMeta(res resource.Resourcer, meta *resource.Meta) error {
+	meta.BaseResource = res
+	if err := meta.PreInitialize(); err != nil {
+		return err
+	}
+	return meta.Initialize()
+}
+
+func TestMetaDefaultValue(t *testing.T) {
+	res := resource.New(&Invoice{})
+	require.NoError(t, initializeMeta(res, &resource.Meta{Name: "State", DefaultValue: func() interface{} { return "draft" }}))
+	// numbers are formatted to strings, and values are set to pointers
+	require.NoError(t, initializeMeta(res, &resource.Meta{Name: "Code", DefaultValue: func() interface{} { return 42 }}))
+	require.NoError(t, initializeMeta(res, &resource.Meta{Name: "Priority", DefaultValue: func() interface{} { return 3 }}))
+
+	invoice := res.NewStruct().(*Invoice)
+	assert.Equal(t, "draft", invoice.State)
+	assert.Equal(t, "42", invoice.Code)
+	require.NotNil(t, invoice.Priority)
+	assert.Equal(t, 3, *invoice.Priority)
+	assert.NotSame(t, invoice.Priority, res.NewStruct().(*Invoice).Priority)
+
+	err := initializeMeta(res, &resource.Meta{Name: "ID", DefaultValue: func() interface{} { return []string{"x"} }})
+	assert.EqualError(t, err, "default value [x] of meta ID is not assignable to uint")
+	err = initializeMeta(res, &resource.Meta{
+		Name:         "Total",
+		Valuer:       func(interface{}, *appsvr.Context) interface{} { return 0 },
+		Setter:       func(interface{}, *resource.MetaValue, *appsvr.Context) {},
+		DefaultValue: func() interface{} { return 0 },
+	})
+	assert.EqualError(t, err, "meta Total has a default value, but no field Total to set it to")
+	assert.Zero(t, res.NewStruct().(*Invoice).ID, "invalid default values are not registered")
+}
+
+func TestMetaComputed(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE invoices (id INTEGER PRIMARY KEY AUTOINCREMENT, state TEXT, code TEXT, priority INTEGER)`)
+
+	res := resource.New(&Invoice{})
+	state, code := &resource.Meta{Name: "State"}, &resource.Meta{Name: "Code"}
+	code.SetComputed(func(record interface{}, context *appsvr.Context) interface{} {
+		return fmt.Sprintf("INV-%v", record.(*Invoice).State)
+	})
+	require.NoError(t, initializeMeta(res, state))
+	require.NoError(t, initializeMeta(res, code))
+	assert.True(t, code.IsComputed())
+
+	invoice := &Invoice{}
+	metaValues := &resource.MetaValues{Values: []*resource.MetaValue{
+		{Name: "State", Value: "paid", Meta: metaor{state}},
+		{Name: "Code", Value: "X-1", Meta: metaor{code}},
+	}}
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+	require.NoError(t, resource.DecodeToResource(res, invoice, metaValues, context).Start())
+	assert.Equal(t, &Invoice{State: "paid"}, invoice, "computed metas are ignored on decode")
+	assert.Equal(t, "INV-paid", code.GetValuer()(invoice, context))
+}
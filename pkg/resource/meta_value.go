@@ -21,9 +21,11 @@ package resource
 // THE SOFTWARE.
 
 import (
+	"fmt"
 	"reflect"
 
 	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/validations"
 )
 
 // MetaValues is slice of MetaValue
@@ -58,7 +60,7 @@ func decodeMetaValuesToField(res Resourcer, field reflect.Value, metaValue *Meta
 	if field.Kind() == reflect.Struct {
 		value := reflect.New(field.Type())
 		associationProcessor := DecodeToResource(res, value.Interface(), metaValue.MetaValues, context)
-		associationProcessor.Start()
+		addNestedErrors(metaValue.Name, associationProcessor.Start(), context)
 		if !associationProcessor.SkipLeft {
 			field.Set(value.Elem())
 		}
@@ -76,7 +78,7 @@ func decodeMetaValuesToField(res Resourcer, field reflect.Value, metaValue *Meta
 
 		value := reflect.New(fieldType)
 		associationProcessor := DecodeToResource(res, value.Interface(), metaValue.MetaValues, context)
-		associationProcessor.Start()
+		addNestedErrors(fmt.Sprintf("%v.%v", metaValue.Name, metaValue.Index), associationProcessor.Start(), context)
 		if !associationProcessor.SkipLeft {
 			if !reflect.DeepEqual(reflect.Zero(fieldType).Interface(), value.Elem().Interface()) {
 				if isPtr {
@@ -88,3 +90,10 @@ func decodeMetaValuesToField(res Resourcer, field reflect.Value, metaValue *Meta
 		}
 	}
 }
+
+// addNestedErrors adds the validation errors of a nested record to the context, as errors of the fields of its path
+func addNestedErrors(path string, err error, context *appsvr.Context) {
+	if validationErrs, _ := validations.FromError(err); len(validationErrs) > 0 {
+		context.AddError(validationErrs.Prefix(path))
+	}
+}
@@ -0,0 +1,144 @@
+package monitoring
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	diag_utils "github.com/bhojpur/application/pkg/diagnostics/utils"
+)
+
+const (
+	// Resource operations.
+	FindOne        = "find_one"
+	FindMany       = "find_many"
+	FindManyStream = "find_many_stream"
+	Save           = "save"
+	Delete         = "delete"
+)
+
+var (
+	operationTotal = stats.Int64(
+		"resource/operation_total",
+		"The total number of resource operations.",
+		stats.UnitDimensionless)
+	operationLatency = stats.Float64(
+		"resource/operation_latency",
+		"The latency of resource operations.",
+		stats.UnitMilliseconds)
+	saveDuration = stats.Float64(
+		"resource/save_duration_seconds",
+		"The duration of saving records of resources.",
+		stats.UnitSeconds)
+	findTotal = stats.Int64(
+		"resource/find_total",
+		"The total number of finding records of resources, one or many.",
+		stats.UnitDimensionless)
+
+	queryLatency = stats.Float64(
+		"resource/query_latency",
+		"The latency of the SQL queries of resource operations.",
+		stats.UnitMilliseconds)
+	slowQueryTotal = stats.Int64(
+		"resource/slow_query_total",
+		"The total number of slow SQL queries of resource operations.",
+		stats.UnitDimensionless)
+
+	resourceKey  = tag.MustNewKey("resource")
+	operationKey = tag.MustNewKey("operation")
+	successKey   = tag.MustNewKey("success")
+	// recordIDKey is a high cardinality tag key, only used if high cardinality labels are enabled.
+	recordIDKey = tag.MustNewKey("record_id")
+
+	defaultLatencyDistribution = view.Distribution(1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000)
+	// saveDurationDistribution is the buckets of prometheus client, in seconds.
+	saveDurationDistribution = view.Distribution(.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10)
+)
+
+// RecordOperation records a resource operation and its latency, the duration of saves and the number of finds.
+func RecordOperation(resource, operation, recordID string, err error, elapsed time.Duration) {
+	if !diag_utils.IsMetricsModuleEnabled(diag_utils.ResourceMetricsModule) {
+		return
+	}
+
+	measurements := []stats.Measurement{
+		operationTotal.M(1),
+		operationLatency.M(float64(elapsed) / float64(time.Millisecond)),
+	}
+	switch operation {
+	case Save:
+		measurements = append(measurements, saveDuration.M(elapsed.Seconds()))
+	case FindOne, FindMany, FindManyStream:
+		measurements = append(measurements, findTotal.M(1))
+	}
+
+	stats.RecordWithTags(
+		context.Background(),
+		diag_utils.WithTags(
+			resourceKey, resource,
+			operationKey, operation,
+			successKey, strconv.FormatBool(err == nil),
+			recordIDKey, diag_utils.HighCardinalityLabel(recordID, "")),
+		measurements...)
+}
+
+// RecordQuery records the latency of a SQL query of a resource operation, and counts the slow ones.
+func RecordQuery(resource, operation string, slow bool, elapsed time.Duration) {
+	if !diag_utils.IsMetricsModuleEnabled(diag_utils.ResourceMetricsModule) {
+		return
+	}
+
+	measurements := []stats.Measurement{queryLatency.M(float64(elapsed) / float64(time.Millisecond))}
+	if slow {
+		measurements = append(measurements, slowQueryTotal.M(1))
+	}
+	stats.RecordWithTags(
+		context.Background(),
+		diag_utils.WithTags(resourceKey, resource, operationKey, operation),
+		measurements...)
+}
+
+// InitMetrics initialize the resource metrics, unless the resource metric group is disabled.
+func InitMetrics() error {
+	if !diag_utils.IsMetricsModuleEnabled(diag_utils.ResourceMetricsModule) {
+		return nil
+	}
+
+	keys := []tag.Key{resourceKey, operationKey, successKey}
+	if diag_utils.IsHighCardinalityLabelsEnabled() {
+		keys = append(keys, recordIDKey)
+	}
+
+	return view.Register(
+		diag_utils.NewMeasureView(operationTotal, keys, view.Count()),
+		diag_utils.NewMeasureView(operationLatency, keys, defaultLatencyDistribution),
+		diag_utils.NewMeasureView(saveDuration, []tag.Key{resourceKey, successKey}, saveDurationDistribution),
+		diag_utils.NewMeasureView(findTotal, keys, view.Count()),
+		diag_utils.NewMeasureView(queryLatency, []tag.Key{resourceKey, operationKey}, defaultLatencyDistribution),
+		diag_utils.NewMeasureView(slowQueryTotal, []tag.Key{resourceKey, operationKey}, view.Count()),
+	)
+}
@@ -0,0 +1,166 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"reflect"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
+	"github.com/bhojpur/application/pkg/utils"
+	"github.com/bhojpur/application/pkg/validations"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// PolymorphicConfig is the config of polymorphic metas, which reference a record of one of several resources by the
+// fields of its type and its primary key, like the commentable of comments, a product or an article. The value of
+// the meta is the referenced record, found by its resource. It is set from the nested meta values Type, a key of
+// Resources, and ID, the primary key of the record, which has to be found
+//
+//	comments.Meta(&resource.Meta{Name: "Commentable", Polymorphic: &resource.PolymorphicConfig{
+//		Resources: map[string]resource.Resourcer{"products": products, "articles": articles},
+//	}})
+type PolymorphicConfig struct {
+	// TypeField is the string field of the type of the referenced record, the name of the meta followed by "Type"
+	// by default, like CommentableType
+	TypeField string
+	// IDField is the field of the primary key of the referenced record, the name of the meta followed by "ID" by
+	// default, like CommentableID
+	IDField string
+	// Resources are the resources of the records which can be referenced, by type
+	Resources map[string]Resourcer
+}
+
+// configure sets the valuer and the setter of the meta
+func (config *PolymorphicConfig) configure(meta *Meta) error {
+	if config.TypeField == "" {
+		config.TypeField = meta.FieldName + "Type"
+	}
+	if config.IDField == "" {
+		config.IDField = meta.FieldName + "ID"
+	}
+
+	scope := &orm.Scope{Value: meta.BaseResource.GetResource().Value}
+	for _, name := range []string{config.TypeField, config.IDField} {
+		if _, ok := scope.FieldByName(name); !ok {
+			return fmt.Errorf("%v is not a valid field of polymorphic meta %v", name, meta.Name)
+		}
+	}
+
+	meta.Valuer = func(record interface{}, context *appsvr.Context) interface{} {
+		res, primaryKey := config.Reference(record)
+		if res == nil {
+			return nil
+		}
+		result := res.NewStruct()
+		if err := config.find(res, result, primaryKey, context); err != nil {
+			if !orm.IsRecordNotFoundError(err) {
+				log.WithContext(context).Warn("failed to find polymorphic association", applog.String("meta", meta.Name), applog.String("primary_key", primaryKey), applog.Err(err))
+			}
+			return nil
+		}
+		return result
+	}
+
+	meta.Setter = func(record interface{}, metaValue *MetaValue, context *appsvr.Context) {
+		if metaValue == nil {
+			return
+		}
+
+		var typ, primaryKey string
+		if metaValue.MetaValues != nil {
+			if value := metaValue.MetaValues.Get("Type"); value != nil {
+				typ = utils.ToString(value.Value)
+			}
+			if value := metaValue.MetaValues.Get("ID"); value != nil {
+				primaryKey = utils.ToString(value.Value)
+			}
+		}
+
+		// the association is removed without a type
+		if typ == "" {
+			config.set(record, "", reflect.Value{}, context)
+			return
+		}
+
+		res := config.Resources[typ]
+		if res == nil {
+			context.AddError(validations.NewFieldError(record, meta.Name, validations.CodeInclusion, fmt.Sprintf("%v is not a valid type of %v", typ, meta.Name), map[string]interface{}{"value": typ}))
+			return
+		}
+		result := res.NewStruct()
+		if err := config.find(res, result, primaryKey, context); err != nil {
+			context.AddError(validations.NewFieldError(record, meta.Name, validations.CodeNotFound, fmt.Sprintf("%v %v is not found", typ, primaryKey), nil))
+			return
+		}
+
+		id := reflect.ValueOf(res.GetResource().GetPrimaryKey(result, context))
+		if primaryFields := res.GetResource().PrimaryFields; len(primaryFields) == 1 {
+			if field, ok := context.GetDB().NewScope(result).FieldByName(primaryFields[0].Name); ok {
+				id = field.Field
+			}
+		}
+		config.set(record, typ, id, context)
+	}
+	return nil
+}
+
+// Reference returns the resource and the primary key of the record referenced by record, a nil resource if its type
+// is not one of Resources or its primary key is blank
+func (config *PolymorphicConfig) Reference(record interface{}) (Resourcer, string) {
+	value := utils.Indirect(reflect.ValueOf(record))
+	typ, id := value.FieldByName(config.TypeField), value.FieldByName(config.IDField)
+	if !typ.IsValid() || !id.IsValid() || id.IsZero() {
+		return nil, ""
+	}
+	res := config.Resources[typ.String()]
+	if res == nil {
+		return nil, ""
+	}
+	return res, fmt.Sprint(reflect.Indirect(id).Interface())
+}
+
+// find finds the record of the resource by its primary key, with the roles of the context
+func (config *PolymorphicConfig) find(res Resourcer, result interface{}, primaryKey string, context *appsvr.Context) error {
+	if primaryKey == "" {
+		return orm.ErrRecordNotFound
+	}
+	findContext := context.Clone()
+	findContext.ResourceID = primaryKey
+	// the projection of the context is the one of the fields of the record referencing it
+	findContext.SetFields()
+	return res.CallFindOne(result, nil, findContext)
+}
+
+// set sets the type and the primary key of the referenced record to record, zero values if id is not valid
+func (config *PolymorphicConfig) set(record interface{}, typ string, id reflect.Value, context *appsvr.Context) {
+	scope := context.GetDB().NewScope(record)
+	if field, ok := scope.FieldByName(config.TypeField); ok {
+		context.AddError(field.Set(typ))
+	}
+	if field, ok := scope.FieldByName(config.IDField); ok {
+		if !id.IsValid() {
+			id = reflect.Zero(field.Field.Type())
+		}
+		context.AddError(field.Set(id))
+	}
+}
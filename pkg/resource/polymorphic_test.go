@@ -0,0 +1,93 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Article struct {
+	ID    uint
+	Title string
+}
+
+type Comment struct {
+	ID              uint
+	Body            string
+	CommentableType string
+	CommentableID   uint
+}
+
+func TestPolymorphicMeta(t *testing.T) {
+	db := utils.SQLiteTestDB(t,
+		`CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`,
+		`CREATE TABLE articles (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT)`,
+	)
+	require.NoError(t, db.Create(&Product{Name: "Blue"}).Error)
+	require.NoError(t, db.Create(&Article{Title: "News"}).Error)
+	require.NoError(t, db.Create(&Article{Title: "Review"}).Error)
+
+	comments := resource.New(&Comment{})
+	meta := &resource.Meta{Name: "Commentable", BaseResource: comments, Polymorphic: &resource.PolymorphicConfig{
+		Resources: map[string]resource.Resourcer{"products": resource.New(&Product{}), "articles": resource.New(&Article{})},
+	}}
+	require.NoError(t, meta.PreInitialize())
+	require.NoError(t, meta.Initialize())
+
+	set := func(comment *Comment, typ, id string) *appsvr.Context {
+		context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+		selectors := &resource.MetaValues{Values: []*resource.MetaValue{{Name: "Type", Value: typ}, {Name: "ID", Value: id}}}
+		meta.GetSetter()(comment, &resource.MetaValue{Name: "Commentable", MetaValues: selectors}, context)
+		return context
+	}
+
+	comment := Comment{Body: "Nice"}
+	context := set(&comment, "articles", "2")
+	assert.Empty(t, context.GetErrors())
+	assert.Equal(t, Comment{Body: "Nice", CommentableType: "articles", CommentableID: 2}, comment)
+	assert.Equal(t, &Article{ID: 2, Title: "Review"}, meta.GetValuer()(&comment, context))
+
+	context = set(&comment, "products", "1")
+	assert.Empty(t, context.GetErrors())
+	assert.Equal(t, &Product{ID: 1, Name: "Blue"}, meta.GetValuer()(&comment, context))
+
+	// types and records which can't be referenced are errors, the reference is kept
+	for _, selector := range [][2]string{{"users", "1"}, {"articles", "3"}, {"articles", ""}} {
+		context = set(&comment, selector[0], selector[1])
+		assert.True(t, context.HasError(), selector)
+		assert.Equal(t, "products", comment.CommentableType)
+	}
+
+	context = set(&comment, "", "")
+	assert.Empty(t, context.GetErrors())
+	assert.Equal(t, Comment{Body: "Nice"}, comment)
+	assert.Nil(t, meta.GetValuer()(&comment, context))
+
+	invalid := &resource.Meta{Name: "Owner", BaseResource: comments, Polymorphic: &resource.PolymorphicConfig{}}
+	require.NoError(t, invalid.PreInitialize())
+	assert.EqualError(t, invalid.Initialize(), "OwnerType is not a valid field of polymorphic meta Owner")
+}
@@ -0,0 +1,145 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// PrimaryKeyStrategy is how the primary keys of new records of a resource are generated, see SetPrimaryKeyStrategy
+type PrimaryKeyStrategy int
+
+const (
+	// AutoIncrement leaves the primary keys of new records to the database, it is the default
+	AutoIncrement PrimaryKeyStrategy = iota
+	// UUIDv4 generates random UUIDs, see utils.NewUUIDv4
+	UUIDv4
+	// UUIDv7 generates UUIDs sorted by their creation time, see utils.NewUUIDv7
+	UUIDv7
+	// ULID generates ULIDs sorted by their creation time, see utils.NewULID
+	ULID
+)
+
+func (strategy PrimaryKeyStrategy) String() string {
+	switch strategy {
+	case AutoIncrement:
+		return "auto_increment"
+	case UUIDv4:
+		return "uuid_v4"
+	case UUIDv7:
+		return "uuid_v7"
+	case ULID:
+		return "ulid"
+	}
+	return fmt.Sprintf("PrimaryKeyStrategy(%d)", int(strategy))
+}
+
+// generate returns a new primary key
+func (strategy PrimaryKeyStrategy) generate() (string, error) {
+	switch strategy {
+	case UUIDv4:
+		return utils.NewUUIDv4()
+	case UUIDv7:
+		return utils.NewUUIDv7()
+	case ULID:
+		return utils.NewULID()
+	}
+	return "", fmt.Errorf("primary keys of strategy %v are not generated", strategy)
+}
+
+// normalize returns the primary key in the form records are stored with, and false if it isn't a key of the
+// strategy. UUIDs may be in their compact URL-safe form, see utils.EncodeUUID, and ULIDs in lower case
+func (strategy PrimaryKeyStrategy) normalize(primaryKey string) (string, bool) {
+	switch strategy {
+	case UUIDv4, UUIDv7:
+		id, err := utils.DecodeUUID(primaryKey)
+		if err != nil {
+			return "", false
+		}
+		return id.String(), true
+	case ULID:
+		if !utils.IsULID(primaryKey) {
+			return "", false
+		}
+		return strings.ToUpper(primaryKey), true
+	}
+	return primaryKey, true
+}
+
+// SetPrimaryKeyStrategy sets how the primary keys of new records of the resource are generated, before they are
+// created, unless they are set. Keys of the finders are validated by the strategy, invalid ones find nothing. Keys are
+// generated for the first primary field, a string or a type scanning strings like uuid.UUID
+//
+//	products.SetPrimaryKeyStrategy(resource.UUIDv7)
+func (res *Resource) SetPrimaryKeyStrategy(strategy PrimaryKeyStrategy) {
+	res.primaryKeyStrategy = strategy
+}
+
+// GetPrimaryKeyStrategy returns how the primary keys of new records of the resource are generated
+func (res *Resource) GetPrimaryKeyStrategy() PrimaryKeyStrategy {
+	return res.primaryKeyStrategy
+}
+
+// primaryKeyResourceKey is the DB setting of the resource whose primary keys are generated on create
+const primaryKeyResourceKey = "bhojpur:primary_key_resource"
+
+func init() {
+	orm.DefaultCallback.Create().Before("orm:before_create").Register("bhojpur:generate_primary_key", generatePrimaryKey)
+}
+
+// generatePrimaryKeys returns a clone of the context whose database generates the primary keys of the records of the
+// resource it creates
+func (res *Resource) generatePrimaryKeys(context *appsvr.Context) *appsvr.Context {
+	if res.primaryKeyStrategy == AutoIncrement || context == nil || context.GetDB() == nil {
+		return context
+	}
+	generatingContext := context.Clone()
+	generatingContext.SetDB(context.GetDB().Set(primaryKeyResourceKey, res))
+	return generatingContext
+}
+
+// generatePrimaryKey is the create callback generating the primary key of records of the resource of the DB setting,
+// before the BeforeCreate methods of the records are called
+func generatePrimaryKey(scope *orm.Scope) {
+	value, ok := scope.Get(primaryKeyResourceKey)
+	if !ok || scope.HasError() {
+		return
+	}
+	res := value.(*Resource)
+	// associations of other models are created by the same database
+	if len(res.PrimaryFields) == 0 || scope.GetModelStruct().ModelType != utils.ModelType(res.Value) {
+		return
+	}
+
+	field, ok := scope.FieldByName(res.PrimaryFields[0].Name)
+	if !ok || !field.IsBlank {
+		return
+	}
+	primaryKey, err := res.primaryKeyStrategy.generate()
+	if scope.Err(err) == nil {
+		scope.Err(field.Set(primaryKey))
+	}
+}
@@ -0,0 +1,148 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/utils"
+	testutils "github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinPrimaryKey(t *testing.T) {
+	key := resource.JoinPrimaryKey("C++", "100%", "a/b")
+	assert.Equal(t, "C%2B%2B++100%25++a/b", key)
+
+	values, err := resource.SplitPrimaryKey(key)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"C++", "100%", "a/b"}, values)
+
+	_, err = resource.SplitPrimaryKey("%zz++x")
+	assert.Error(t, err)
+}
+
+func TestCompositePrimaryKey(t *testing.T) {
+	db := testutils.SQLiteTestDB(t, `CREATE TABLE stocks (warehouse TEXT, sku TEXT, quantity INTEGER, PRIMARY KEY (warehouse, sku))`)
+
+	res := resource.New(&Stock{})
+	require.NoError(t, res.SetPrimaryFields("Warehouse", "SKU"))
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+	for _, stock := range []Stock{{"north", "C++", 1}, {"north", "Go", 2}, {"south", "C++", 3}} {
+		stock := stock
+		require.NoError(t, res.CallSave(&stock, context))
+	}
+
+	find := func(id string) (Stock, error) {
+		var stock Stock
+		findContext := context.Clone()
+		findContext.ResourceID = id
+		err := res.CallFindOne(&stock, nil, findContext)
+		return stock, err
+	}
+
+	stock, err := find(resource.JoinPrimaryKey("north", "C++"))
+	require.NoError(t, err)
+	assert.Equal(t, Stock{"north", "C++", 1}, stock)
+	assert.Equal(t, "north++C%2B%2B", res.GetPrimaryKey(&stock, context))
+
+	// keys of another number of values find nothing, instead of the records of their first value
+	for _, id := range []string{"north", "north++C%2B%2B++x"} {
+		_, err = find(id)
+		assert.Error(t, err, id)
+	}
+
+	// meta values find records by all primary fields
+	metaValues := &resource.MetaValues{Values: []*resource.MetaValue{{Name: "Warehouse", Value: "south"}, {Name: "SKU", Value: "C++"}}}
+	stock = Stock{}
+	require.NoError(t, res.CallFindOne(&stock, metaValues, context))
+	assert.Equal(t, 3, stock.Quantity)
+	metaValues.Values = metaValues.Values[:1]
+	assert.Error(t, res.CallFindOne(&Stock{}, metaValues, context))
+
+	stock = Stock{"north", "C++", 10}
+	require.NoError(t, res.CallSave(&stock, context))
+	stock, err = find("north++C%2B%2B")
+	require.NoError(t, err)
+	assert.Equal(t, 10, stock.Quantity)
+
+	deleteContext := context.Clone()
+	deleteContext.ResourceID = resource.JoinPrimaryKey("north", "C++")
+	require.NoError(t, res.CallDelete(&Stock{}, deleteContext))
+	var stocks []Stock
+	require.NoError(t, res.CallFindMany(&stocks, context))
+	assert.Equal(t, []Stock{{"south", "C++", 3}, {"north", "Go", 2}}, stocks)
+}
+
+type Note struct {
+	ID    string `orm:"primary_key"`
+	Title string
+}
+
+func TestPrimaryKeyStrategy(t *testing.T) {
+	db := testutils.SQLiteTestDB(t, `CREATE TABLE notes (id TEXT PRIMARY KEY, title TEXT)`)
+
+	res := resource.New(&Note{})
+	res.SetPrimaryKeyStrategy(resource.UUIDv7)
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+
+	find := func(id string) (Note, error) {
+		var note Note
+		findContext := context.Clone()
+		findContext.ResourceID = id
+		err := res.CallFindOne(&note, nil, findContext)
+		return note, err
+	}
+
+	note := Note{Title: "Draft"}
+	require.NoError(t, res.CallSave(&note, context))
+	id, err := uuid.Parse(note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(7), id.Version())
+	assert.Equal(t, note.ID, res.GetPrimaryKey(&note, context))
+
+	// UUIDs are found by their canonical and compact forms
+	for _, key := range []string{note.ID, utils.EncodeUUID(id)} {
+		found, err := find(key)
+		require.NoError(t, err, key)
+		assert.Equal(t, note, found)
+	}
+	_, err = find("1")
+	assert.True(t, orm.IsRecordNotFoundError(err))
+
+	// keys of new records aren't replaced
+	require.NoError(t, res.CallSave(&Note{ID: "01ARZ3NDEKTSV4RRFFQ69G5FAV", Title: "Given"}, context))
+	res.SetPrimaryKeyStrategy(resource.ULID)
+	found, err := find("01arz3ndektsv4rrffq69g5fav")
+	require.NoError(t, err)
+	assert.Equal(t, "Given", found.Title)
+
+	note = Note{Title: "Final"}
+	require.NoError(t, res.CallSave(&note, context))
+	assert.True(t, utils.IsULID(note.ID), note.ID)
+	_, err = find(note.ID)
+	assert.NoError(t, err)
+}
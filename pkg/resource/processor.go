@@ -112,36 +112,57 @@ func (processor *processor) decode() (errs []error) {
 		}
 	}
 
+	// metas shown conditionally are decoded last, so that their conditions get the values of the other metas
+	var conditional []*MetaValue
 	for _, metaValue := range processor.MetaValues.Values {
-		meta := metaValue.Meta
-		if meta == nil {
+		if meta, ok := metaValue.Meta.(conditionalMeta); ok && meta.GetShowCondition() != nil {
+			conditional = append(conditional, metaValue)
 			continue
 		}
-
-		if newRecord && !meta.HasPermission(roles.Create, processor.Context) {
-			continue
-		} else if !newRecord && !meta.HasPermission(roles.Update, processor.Context) {
-			continue
+		processor.decodeMetaValue(metaValue, newRecord)
+	}
+	for _, metaValue := range conditional {
+		if metaValue.Meta.(conditionalMeta).IsShown(processor.Result, processor.Context) {
+			processor.decodeMetaValue(metaValue, newRecord)
 		}
+	}
 
-		if setter := meta.GetSetter(); setter != nil {
-			setter(processor.Result, metaValue, processor.Context)
-		}
+	return
+}
 
-		if metaValue.MetaValues != nil && len(metaValue.MetaValues.Values) > 0 {
-			if res := metaValue.Meta.GetResource(); res != nil && !reflect.ValueOf(res).IsNil() {
-				field := reflect.Indirect(reflect.ValueOf(processor.Result)).FieldByName(meta.GetFieldName())
-				// Only decode nested meta value into struct if no Setter defined
-				if meta.GetSetter() == nil || reflect.Indirect(field).Type() == utils.ModelType(res.NewStruct()) {
-					if _, ok := field.Addr().Interface().(sql.Scanner); !ok {
-						decodeMetaValuesToField(res, field, metaValue, processor.Context)
-					}
+// conditionalMeta is a meta shown conditionally, see Meta.ShowIf
+type conditionalMeta interface {
+	GetShowCondition() func(record interface{}, context *appsvr.Context) bool
+	IsShown(record interface{}, context *appsvr.Context) bool
+}
+
+func (processor *processor) decodeMetaValue(metaValue *MetaValue, newRecord bool) {
+	meta := metaValue.Meta
+	if meta == nil {
+		return
+	}
+
+	if newRecord && !meta.HasPermission(roles.Create, processor.Context) {
+		return
+	} else if !newRecord && !meta.HasPermission(roles.Update, processor.Context) {
+		return
+	}
+
+	if setter := meta.GetSetter(); setter != nil {
+		setter(processor.Result, metaValue, processor.Context)
+	}
+
+	if metaValue.MetaValues != nil && len(metaValue.MetaValues.Values) > 0 {
+		if res := metaValue.Meta.GetResource(); res != nil && !reflect.ValueOf(res).IsNil() {
+			field := reflect.Indirect(reflect.ValueOf(processor.Result)).FieldByName(meta.GetFieldName())
+			// Only decode nested meta value into struct if no Setter defined
+			if meta.GetSetter() == nil || reflect.Indirect(field).Type() == utils.ModelType(res.NewStruct()) {
+				if _, ok := field.Addr().Interface().(sql.Scanner); !ok {
+					decodeMetaValuesToField(res, field, metaValue, processor.Context)
 				}
 			}
 		}
 	}
-
-	return
 }
 
 // Start start processor
@@ -0,0 +1,61 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// selectFields returns db selecting only the columns of the fields of the projection of the context, see
+// appsvr.Context.SetFields, and of the primary fields, which identify the records. It returns db if the context has no
+// projection
+func (res *Resource) selectFields(db *orm.DB, context *appsvr.Context) (*orm.DB, error) {
+	fields := context.GetFields()
+	if len(fields) == 0 {
+		return db, nil
+	}
+
+	var (
+		scope    = db.NewScope(res.Value)
+		columns  []string
+		selected = map[string]bool{}
+	)
+	add := func(field *orm.StructField) {
+		if !selected[field.DBName] {
+			selected[field.DBName] = true
+			columns = append(columns, scope.QuotedTableName()+"."+scope.Quote(field.DBName))
+		}
+	}
+	for _, field := range res.PrimaryFields {
+		add(field)
+	}
+	for _, name := range fields {
+		field, ok := scope.FieldByName(name)
+		if !ok || !field.IsNormal {
+			return nil, fmt.Errorf("%v is not a valid field for resource %v", name, res.Name)
+		}
+		add(field.StructField)
+	}
+	return db.Select(columns), nil
+}
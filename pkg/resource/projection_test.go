@@ -0,0 +1,76 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Widget struct {
+	ID    uint
+	Name  string
+	Code  string
+	Notes string
+}
+
+func TestFieldProjection(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE widgets (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, code TEXT, notes TEXT)`)
+	require.NoError(t, db.Create(&Widget{Name: "Blue", Code: "B", Notes: "long"}).Error)
+
+	res := resource.New(&Widget{})
+	res.EnableCache(resource.NewMemoryCacheStore(), time.Minute)
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}, ResourceID: "1"}
+	projected := context.Clone()
+	projected.SetFields("Name", "code")
+
+	// primary fields are always selected
+	var widget Widget
+	require.NoError(t, res.CallFindOne(&widget, nil, projected))
+	assert.Equal(t, Widget{ID: 1, Name: "Blue", Code: "B"}, widget)
+
+	var widgets []Widget
+	require.NoError(t, res.CallFindMany(&widgets, projected))
+	assert.Equal(t, []Widget{{ID: 1, Name: "Blue", Code: "B"}}, widgets)
+
+	// projected records are not served to reads of all fields
+	widget, widgets = Widget{}, nil
+	require.NoError(t, res.CallFindOne(&widget, nil, context))
+	assert.Equal(t, "long", widget.Notes)
+	require.NoError(t, res.CallFindMany(&widgets, context))
+	assert.Equal(t, []Widget{{ID: 1, Name: "Blue", Code: "B", Notes: "long"}}, widgets)
+
+	var count int
+	countContext := projected.Clone()
+	countContext.SetDB(db.Model(res.Value).Set("bhojpur:getting_total_count", true))
+	require.NoError(t, res.CallFindMany(&count, countContext))
+	assert.Equal(t, 1, count)
+
+	unknown := context.Clone()
+	unknown.SetFields("Color")
+	assert.EqualError(t, res.CallFindMany(&widgets, unknown), "Color is not a valid field for resource Widget")
+}
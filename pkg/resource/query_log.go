@@ -0,0 +1,116 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
+	"github.com/bhojpur/application/pkg/resource/monitoring"
+)
+
+// DefaultSlowQueryThreshold is the duration from which SQL queries of resources are slow, see SetSlowQueryThreshold
+var DefaultSlowQueryThreshold = 500 * time.Millisecond
+
+// SetSlowQueryThreshold sets the duration from which SQL queries of the operations of the resource are logged as
+// warnings, DefaultSlowQueryThreshold by default, slow queries aren't detected if it is negative. Other queries are
+// logged at debug level, with their parameters redacted
+func (res *Resource) SetSlowQueryThreshold(threshold time.Duration) {
+	res.slowQueryThreshold = threshold
+}
+
+// logQueries returns a clone of the context whose database logs and measures its SQL queries, as the queries of an
+// operation of the resource. The logger of the database is replaced, its queries are logged by applog
+func (res *Resource) logQueries(operation string, context *appsvr.Context) *appsvr.Context {
+	if context == nil || context.GetDB() == nil {
+		return context
+	}
+
+	// the database is cloned by setting the operation, so that the logger is set to the clone only
+	db := context.GetDB().Set(queryOperationKey, operation)
+	db.SetLogger(queryLogger{res: res, operation: operation, context: context})
+	loggedContext := context.Clone()
+	loggedContext.SetDB(db.LogMode(true))
+	return loggedContext
+}
+
+const queryOperationKey = "bhojpur:query_operation"
+
+// queryLogger logs the queries of an operation of a resource, it is the logger of the database of the operation
+type queryLogger struct {
+	res       *Resource
+	operation string
+	context   *appsvr.Context
+}
+
+// Print is called by orm with "sql", the caller, the duration, the SQL, the parameters and the number of affected
+// rows of queries, other entries are ignored
+func (logger queryLogger) Print(values ...interface{}) {
+	if len(values) < 6 || values[0] != "sql" {
+		return
+	}
+	duration, _ := values[2].(time.Duration)
+	query, _ := values[3].(string)
+	params, _ := values[4].([]interface{})
+
+	threshold := logger.res.slowQueryThreshold
+	if threshold == 0 {
+		threshold = DefaultSlowQueryThreshold
+	}
+	slow := threshold > 0 && duration >= threshold
+	monitoring.RecordQuery(logger.res.Name, logger.operation, slow, duration)
+	if !slow && !log.Enabled(applog.DebugLevel) {
+		return
+	}
+
+	entry := log.WithContext(logger.context).With(
+		applog.Resource(logger.res.Name),
+		applog.String("operation", logger.operation),
+		applog.Duration(duration),
+		applog.String("sql", query),
+		applog.Any("params", redactParams(params)),
+		applog.Any("rows", values[5]),
+		applog.Any("caller", values[1]),
+	)
+	if logger.context.ResourceID != "" {
+		entry = entry.With(applog.String("record_id", logger.context.ResourceID))
+	}
+	if slow {
+		entry.Warn("slow query")
+	} else {
+		entry.Debug("query")
+	}
+}
+
+// redactParams returns the types of the bind parameters of a query, so that their values aren't logged
+func redactParams(params []interface{}) []string {
+	redacted := make([]string, len(params))
+	for idx, param := range params {
+		if param == nil {
+			redacted[idx] = "NULL"
+		} else {
+			redacted[idx] = fmt.Sprintf("<%T>", param)
+		}
+	}
+	return redacted
+}
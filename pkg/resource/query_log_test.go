@@ -0,0 +1,73 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	stdlog "log"
+	"testing"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLog(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`)
+	require.NoError(t, db.Create(&Product{Name: "Blue"}).Error)
+
+	var buf bytes.Buffer
+	applog.SetBackend(applog.NewStdBackend(stdlog.New(&buf, "", 0)))
+	t.Cleanup(func() {
+		applog.SetBackend(applog.NewServiceBackend())
+		applog.SetLevel(applog.InfoLevel)
+	})
+
+	// every query is slow
+	res := resource.New(&Product{})
+	res.SetSlowQueryThreshold(time.Nanosecond)
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}, ResourceID: "1"}
+	var product Product
+	require.NoError(t, res.CallFindOne(&product, nil, context))
+	assert.Equal(t, "Blue", product.Name)
+	assert.Regexp(t, `^level=warn scope=app.resource msg="slow query" resource=Product operation=find_one duration=\S+ sql="SELECT \* FROM \\"products\\" .+" params=\[<\w+>\] rows=\d+ caller=\S+ record_id=1\n$`, buf.String())
+
+	// parameters are redacted
+	buf.Reset()
+	require.NoError(t, res.CallSave(&Product{ID: 1, Name: "Secret"}, context))
+	assert.Contains(t, buf.String(), `operation=save`)
+	assert.Contains(t, buf.String(), `UPDATE \"products\" SET`)
+	assert.NotContains(t, buf.String(), "Secret")
+
+	// queries which aren't slow are logged at debug level
+	buf.Reset()
+	res.SetSlowQueryThreshold(-1)
+	require.NoError(t, res.CallFindOne(&product, nil, context))
+	assert.Empty(t, buf.String())
+	applog.SetLevel(applog.DebugLevel)
+	require.NoError(t, res.CallFindMany(&[]Product{}, context))
+	assert.Contains(t, buf.String(), `level=debug scope=app.resource msg=query resource=Product operation=find_many`)
+	assert.NotContains(t, buf.String(), "slow query")
+}
@@ -0,0 +1,56 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// QueryScope restricts the records of a resource a context can access, e.g. to the ones of its tenant or its user
+type QueryScope func(db *orm.DB, context *appsvr.Context) *orm.DB
+
+// AddQueryScope adds a query scope to the resource, it is applied to the database of the context of FindOne,
+// FindMany and Delete, before their handlers, so that the restriction is enforced in SQL, e.g.
+//
+//	res.AddQueryScope(func(db *orm.DB, context *appsvr.Context) *orm.DB {
+//		return db.Where("tenant_id = ?", context.CurrentUser.(*User).TenantID)
+//	})
+//
+// Records found with query scopes are not cached by their primary key, as they differ for each context
+func (res *Resource) AddQueryScope(scope QueryScope) {
+	res.queryScopes = append(res.queryScopes, scope)
+}
+
+// applyQueryScopes returns a clone of the context with its database restricted by the query scopes of the resource
+func (res *Resource) applyQueryScopes(context *appsvr.Context) *appsvr.Context {
+	if len(res.queryScopes) == 0 || context == nil {
+		return context
+	}
+
+	db := context.GetDB()
+	for _, scope := range res.queryScopes {
+		db = scope(db, context)
+	}
+	scopedContext := context.Clone()
+	scopedContext.SetDB(db)
+	return scopedContext
+}
@@ -0,0 +1,72 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Document struct {
+	ID       uint
+	TenantID uint
+	Title    string
+}
+
+type tenantUser uint
+
+func (user tenantUser) DisplayName() string { return "user" }
+
+func TestQueryScope(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE documents (id INTEGER PRIMARY KEY AUTOINCREMENT, tenant_id INTEGER, title TEXT)`)
+	require.NoError(t, db.Create(&Document{TenantID: 1, Title: "Plan"}).Error)
+	require.NoError(t, db.Create(&Document{TenantID: 2, Title: "Budget"}).Error)
+
+	res := resource.New(&Document{})
+	res.AddQueryScope(func(db *orm.DB, context *appsvr.Context) *orm.DB {
+		return db.Where("tenant_id = ?", uint(context.CurrentUser.(tenantUser)))
+	})
+	newContext := func(tenant uint, id string) *appsvr.Context {
+		return &appsvr.Context{Config: &appsvr.Config{DB: db}, CurrentUser: tenantUser(tenant), ResourceID: id}
+	}
+
+	var documents []Document
+	require.NoError(t, res.CallFindMany(&documents, newContext(1, "")))
+	assert.Equal(t, []Document{{ID: 1, TenantID: 1, Title: "Plan"}}, documents)
+
+	var document Document
+	require.NoError(t, res.CallFindOne(&document, nil, newContext(2, "2")))
+	assert.Equal(t, "Budget", document.Title)
+	assert.True(t, orm.IsRecordNotFoundError(res.CallFindOne(&Document{}, nil, newContext(1, "2"))))
+
+	// records of other tenants can't be deleted
+	assert.True(t, orm.IsRecordNotFoundError(res.CallDelete(&Document{}, newContext(1, "2"))))
+	require.NoError(t, res.CallDelete(&Document{}, newContext(2, "2")))
+	var count int
+	require.NoError(t, db.Model(&Document{}).Count(&count).Error)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,83 @@
+package rediscache
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/bhojpur/application/pkg/resource"
+)
+
+// Store is a resource.CacheStore in Redis, so that the caches of resources are shared by instances
+//
+//	products.EnableCache(rediscache.New(redis.NewClient(&redis.Options{Addr: "localhost:6379"})), 5*time.Minute)
+type Store struct {
+	client redis.UniversalClient
+	// Timeout limits the duration of each command, 1 second by default
+	Timeout time.Duration
+}
+
+var _ resource.CacheStore = &Store{}
+
+// New initialize a cache store of a Redis client
+func New(client redis.UniversalClient) *Store {
+	return &Store{client: client, Timeout: time.Second}
+}
+
+// Get returns the value of key, false if it isn't set or expired
+func (store *Store) Get(key string) ([]byte, bool, error) {
+	ctx, cancel := store.context()
+	defer cancel()
+
+	value, err := store.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set sets the value of key for ttl
+func (store *Store) Set(key string, value []byte, ttl time.Duration) error {
+	ctx, cancel := store.context()
+	defer cancel()
+	return store.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Delete deletes keys
+func (store *Store) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	ctx, cancel := store.context()
+	defer cancel()
+	return store.client.Del(ctx, keys...).Err()
+}
+
+func (store *Store) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), store.Timeout)
+}
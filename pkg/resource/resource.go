@@ -23,10 +23,15 @@ package resource
 import (
 	"fmt"
 	"reflect"
+	"sync"
+	"time"
 
 	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/events"
 	"github.com/bhojpur/application/pkg/roles"
+	roles_monitoring "github.com/bhojpur/application/pkg/roles/monitoring"
 	"github.com/bhojpur/application/pkg/utils"
+	"github.com/bhojpur/application/pkg/validations"
 	orm "github.com/bhojpur/orm/pkg/engine"
 )
 
@@ -61,10 +66,31 @@ type Resource struct {
 	FindOneHandler  func(interface{}, *MetaValues, *appsvr.Context) error
 	SaveHandler     func(interface{}, *appsvr.Context) error
 	DeleteHandler   func(interface{}, *appsvr.Context) error
+	SearchHandler   func(string, *appsvr.Context) (*orm.DB, error)
 	Permission      *roles.Permission
+	Authorizer      roles.Authorizer
 	Validators      []*Validator
 	Processors      []*Processor
 	primaryField    *orm.Field
+	labels          sync.Map
+	defaultValues   []*Meta
+	defaultMutex    sync.RWMutex
+	requiredMetas   []*Meta
+	requiredMutex   sync.RWMutex
+	eventSourcing   *EventSourcingConfig
+	searchAttrs     []string
+	searchMatchers  sync.Map
+	searchEngine    SearchEngine
+	scopes          []*Scope
+	queryScopes     []QueryScope
+	filters         []*Filter
+	eventBus        *events.Bus
+	cache           *cacheConfig
+	l10n            *l10nConfig
+	// primaryKeyStrategy generates the primary keys of new records, see SetPrimaryKeyStrategy
+	primaryKeyStrategy PrimaryKeyStrategy
+	// slowQueryThreshold is the duration from which queries are slow, DefaultSlowQueryThreshold if zero
+	slowQueryThreshold time.Duration
 }
 
 // New initialize Bhojpur Application resource
@@ -78,6 +104,7 @@ func New(value interface{}) *Resource {
 	res.FindManyHandler = res.findManyHandler
 	res.SaveHandler = res.saveHandler
 	res.DeleteHandler = res.deleteHandler
+	res.SearchHandler = res.searchHandler
 	res.SetPrimaryFields()
 	return res
 }
@@ -87,6 +114,40 @@ func (res *Resource) GetResource() *Resource {
 	return res
 }
 
+// SetLabel overrides the label of a field, e.g. res.SetLabel("SKU", "Stock Keeping Unit")
+func (res *Resource) SetLabel(name, label string) {
+	res.labels.Store(name, label)
+}
+
+// GetLabel get label of a field, the overridden one if any, otherwise its humanized name
+func (res *Resource) GetLabel(name string) string {
+	if label, ok := res.labels.Load(name); ok {
+		return label.(string)
+	}
+	return utils.HumanizeString(name)
+}
+
+// GetTranslatedName get name of the resource translated to the locale of the context, by the translator of its
+// config, e.g. "resources.product_variation.name", its name otherwise
+func (res *Resource) GetTranslatedName(context *appsvr.Context) string {
+	return context.TDefault(fmt.Sprintf("resources.%v.name", utils.ToParamString(res.Name)), res.Name)
+}
+
+// GetTranslatedLabel get label of a field translated to the locale of the context, by the translator of its config,
+// e.g. "resources.product_variation.attributes.SKU", otherwise the overridden label if any, or its name humanized
+// for the locale, see utils.HumanizeStringWithLocale
+func (res *Resource) GetTranslatedLabel(name string, context *appsvr.Context) string {
+	label, ok := res.labels.Load(name)
+	if !ok {
+		var locale = context.GetLocale()
+		if locale == "" && context.Request != nil {
+			locale = utils.GetLocale(context)
+		}
+		label = utils.HumanizeStringWithLocale(name, locale)
+	}
+	return context.TDefault(fmt.Sprintf("resources.%v.attributes.%v", utils.ToParamString(res.Name), name), label.(string))
+}
+
 // SetPrimaryFields set primary fields
 func (res *Resource) SetPrimaryFields(fields ...string) error {
 	scope := orm.Scope{Value: res.Value}
@@ -151,7 +212,63 @@ func (res *Resource) NewStruct() interface{} {
 	if res.Value == nil {
 		return nil
 	}
-	return reflect.New(utils.Indirect(reflect.ValueOf(res.Value)).Type()).Interface()
+	value := reflect.New(utils.Indirect(reflect.ValueOf(res.Value)).Type()).Interface()
+	res.setDefaultValues(value)
+	return value
+}
+
+// addDefaultValue registers meta's default value for new structs, it replaces the one of a meta with the same name
+func (res *Resource) addDefaultValue(meta *Meta) {
+	res.defaultMutex.Lock()
+	defer res.defaultMutex.Unlock()
+
+	for idx, m := range res.defaultValues {
+		if m.Name == meta.Name {
+			res.defaultValues[idx] = meta
+			return
+		}
+	}
+	res.defaultValues = append(res.defaultValues, meta)
+}
+
+func (res *Resource) setDefaultValues(value interface{}) {
+	res.defaultMutex.RLock()
+	defer res.defaultMutex.RUnlock()
+
+	for _, meta := range res.defaultValues {
+		meta.setDefaultValue(value)
+	}
+}
+
+// addRequiredMeta registers meta's condition of requiredness, it replaces the one of a meta with the same name. The
+// required metas are checked by a processor, once the meta values are decoded
+func (res *Resource) addRequiredMeta(meta *Meta) {
+	res.requiredMutex.Lock()
+	defer res.requiredMutex.Unlock()
+
+	for idx, m := range res.requiredMetas {
+		if m.Name == meta.Name {
+			res.requiredMetas[idx] = meta
+			return
+		}
+	}
+	if len(res.requiredMetas) == 0 {
+		res.AddProcessor(&Processor{Name: RequiredMetasProcessor, Handler: res.validateRequiredMetas})
+	}
+	res.requiredMetas = append(res.requiredMetas, meta)
+}
+
+func (res *Resource) validateRequiredMetas(record interface{}, metaValues *MetaValues, context *appsvr.Context) error {
+	res.requiredMutex.RLock()
+	defer res.requiredMutex.RUnlock()
+
+	var errs validations.Errors
+	for _, meta := range res.requiredMetas {
+		if meta.IsRequired(record, context) && validations.IsBlank(meta.GetValuer()(record, context)) {
+			errs.Add(validations.NewFieldError(record, meta.Name, validations.CodeRequired, fmt.Sprintf("%v can't be blank", meta.Name), nil))
+		}
+	}
+	return errs.ErrorOrNil()
 }
 
 // NewSlice initialize a slice of struct for the Resource
@@ -171,15 +288,30 @@ func (res *Resource) GetMetas([]string) []Metaor {
 	panic("not defined")
 }
 
-// HasPermission check permission of resource
+// HasPermission check permission of resource, decided by its Authorizer if set
 func (res *Resource) HasPermission(mode roles.PermissionMode, context *appsvr.Context) bool {
-	if res == nil || res.Permission == nil {
+	return res.HasRecordPermission(mode, nil, context)
+}
+
+// HasRecordPermission check permission of resource for a record, conditional permissions are evaluated against the record
+func (res *Resource) HasRecordPermission(mode roles.PermissionMode, record interface{}, context *appsvr.Context) bool {
+	if res == nil || (res.Authorizer == nil && res.Permission == nil) {
 		return true
 	}
 
-	var roles = []interface{}{}
-	for _, role := range context.Roles {
-		roles = append(roles, role)
+	var allowed bool
+	if res.Authorizer != nil {
+		allowed = res.Authorizer.Authorize(res.Name, mode, record, context, context.Roles)
+	} else {
+		var roles = []interface{}{}
+		for _, role := range context.Roles {
+			roles = append(roles, role)
+		}
+		allowed = res.Permission.HasRecordPermission(mode, record, context, roles...)
+	}
+
+	if !allowed {
+		roles_monitoring.RecordPermissionDenied(res.Name, string(mode), context.Roles)
 	}
-	return res.Permission.HasPermission(mode, roles...)
+	return allowed
 }
@@ -21,12 +21,17 @@ package resource
 // THE SOFTWARE.
 
 import (
+	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/bhojpur/application/pkg/csrf"
 	appsvr "github.com/bhojpur/application/pkg/engine"
 	"github.com/bhojpur/application/pkg/utils"
 )
@@ -52,6 +57,10 @@ func convertMapToMetaValues(values map[string]interface{}, metaors []Metaor) (*M
 				metaValue = &MetaValue{Name: key, Meta: metaor, MetaValues: children}
 			}
 		case []interface{}:
+			// empty arrays clear the values, like the associated records of a many-to-many meta
+			if len(result) == 0 {
+				metaValue = &MetaValue{Name: key, Value: result, Meta: metaor}
+			}
 			for idx, r := range result {
 				if mr, ok := r.(map[string]interface{}); ok {
 					if children, err := convertMapToMetaValues(mr, childMeta); err == nil {
@@ -89,6 +98,136 @@ func ConvertJSONToMetaValues(reader io.Reader, metaors []Metaor) (*MetaValues, e
 	return nil, err
 }
 
+// ConvertObjectToMetaValues convert a struct, or a pointer to it, to meta values. It is the inverse of decoding,
+// nested structs and slices of structs are converted to nested meta values like the ones of a form submission
+func ConvertObjectToMetaValues(object interface{}, metaors []Metaor) (*MetaValues, error) {
+	value := reflect.Indirect(reflect.ValueOf(object))
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("resource: can't convert %T to meta values, expect a struct", object)
+	}
+
+	metaValues := &MetaValues{}
+	metaorMap := make(map[string]Metaor)
+	for _, metaor := range metaors {
+		metaorMap[metaor.GetFieldName()] = metaor
+	}
+
+	if err := convertStructToMetaValues(value, metaorMap, metaValues); err != nil {
+		return nil, err
+	}
+	return metaValues, nil
+}
+
+func convertStructToMetaValues(value reflect.Value, metaorMap map[string]Metaor, metaValues *MetaValues) error {
+	for i := 0; i < value.NumField(); i++ {
+		fieldStruct := value.Type().Field(i)
+		if fieldStruct.PkgPath != "" && !fieldStruct.Anonymous {
+			continue
+		}
+
+		field := value.Field(i)
+		// fields of embedded structs, like orm.Model, are promoted to the current level
+		if fieldStruct.Anonymous {
+			if field = reflect.Indirect(field); field.Kind() == reflect.Struct && !isMetaValueScalar(field.Type()) {
+				if err := convertStructToMetaValues(field, metaorMap, metaValues); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if !field.CanInterface() {
+			continue
+		}
+
+		name := fieldStruct.Name
+		metaor := metaorMap[name]
+		var childMeta []Metaor
+		if metaor != nil {
+			name = metaor.GetName()
+			childMeta = metaor.GetMetas()
+		}
+
+		if field.Kind() == reflect.Ptr && field.IsNil() {
+			metaValues.Values = append(metaValues.Values, &MetaValue{Name: name, Value: "", Meta: metaor})
+			continue
+		}
+		field = reflect.Indirect(field)
+
+		switch {
+		case field.Kind() == reflect.Struct && !isMetaValueScalar(field.Type()):
+			children, err := ConvertObjectToMetaValues(field.Interface(), childMeta)
+			if err != nil {
+				return err
+			}
+			metaValues.Values = append(metaValues.Values, &MetaValue{Name: name, Meta: metaor, MetaValues: children})
+		case field.Kind() == reflect.Slice && isStructSlice(field.Type()):
+			var index int
+			for idx := 0; idx < field.Len(); idx++ {
+				elem := field.Index(idx)
+				if elem.Kind() == reflect.Ptr && elem.IsNil() {
+					continue
+				}
+
+				children, err := ConvertObjectToMetaValues(elem.Interface(), childMeta)
+				if err != nil {
+					return err
+				}
+				metaValues.Values = append(metaValues.Values, &MetaValue{Name: name, Meta: metaor, MetaValues: children, Index: index})
+				index++
+			}
+		default:
+			metaValues.Values = append(metaValues.Values, &MetaValue{Name: name, Value: convertFieldToMetaValue(field), Meta: metaor})
+		}
+	}
+	return nil
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// isMetaValueScalar returns true for structs which are set from a single value, like time.Time or sql.NullString
+func isMetaValueScalar(typ reflect.Type) bool {
+	return typ == timeType || typ.Implements(valuerType)
+}
+
+func isStructSlice(typ reflect.Type) bool {
+	elemType := typ.Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	return elemType.Kind() == reflect.Struct && !isMetaValueScalar(elemType)
+}
+
+// convertFieldToMetaValue converts a field to a value which setters accept, like the ones from a form
+func convertFieldToMetaValue(field reflect.Value) interface{} {
+	switch value := field.Interface().(type) {
+	case time.Time:
+		if value.IsZero() {
+			return ""
+		}
+		return utils.FormatTime(value, "2006-01-02 15:04:05", nil)
+	case driver.Valuer:
+		if v, err := value.Value(); err == nil {
+			return v
+		}
+		return ""
+	case []byte:
+		return string(value)
+	}
+
+	if field.Kind() == reflect.Slice || field.Kind() == reflect.Array {
+		values := []string{}
+		for idx := 0; idx < field.Len(); idx++ {
+			values = append(values, utils.ToString(convertFieldToMetaValue(reflect.Indirect(field.Index(idx)))))
+		}
+		return values
+	}
+	return field.Interface()
+}
+
 var (
 	isCurrentLevel = regexp.MustCompile("^[^.]+$")
 	isNextLevel    = regexp.MustCompile(`^(([^.\[\]]+)(\[\d+\])?)(?:(\.[^.]+)+)$`)
@@ -178,13 +317,18 @@ func ConvertFormToMetaValues(request *http.Request, metaors []Metaor, prefix str
 	return metaValues, nil
 }
 
-// Decode decodes context to result according to resource definition
+// Decode decodes context to result according to resource definition. The CSRF token of form posts and requests of
+// scripts is verified, unless the CSRF middleware verified it already, see csrf.Verify
 func Decode(context *appsvr.Context, result interface{}, res Resourcer) error {
 	var errors appsvr.Errors
 	var err error
 	var metaValues *MetaValues
 	metaors := res.GetMetas([]string{})
 
+	if err := csrf.Verify(context.Request); err != nil {
+		return err
+	}
+
 	if strings.Contains(context.Request.Header.Get("Content-Type"), "json") {
 		metaValues, err = ConvertJSONToMetaValues(context.Request.Body, metaors)
 		context.Request.Body.Close()
@@ -196,3 +340,13 @@ func Decode(context *appsvr.Context, result interface{}, res Resourcer) error {
 	errors.AddError(DecodeToResource(res, result, metaValues, context).Start())
 	return errors
 }
+
+// DecodeObject decodes object to result according to resource definition, it goes
+// through the same validators and processors as a form submission
+func DecodeObject(context *appsvr.Context, object interface{}, result interface{}, res Resourcer) error {
+	metaValues, err := ConvertObjectToMetaValues(object, res.GetMetas([]string{}))
+	if err != nil {
+		return err
+	}
+	return DecodeToResource(res, result, metaValues, context).Start()
+}
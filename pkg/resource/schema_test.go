@@ -0,0 +1,152 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metaor is a meta to match interface `Metaor`, without nested metas
+type metaor struct {
+	*resource.Meta
+}
+
+func (metaor) GetMetas() []resource.Metaor {
+	return nil
+}
+
+func (metaor) GetResource() resource.Resourcer {
+	return nil
+}
+
+type Timestamps struct {
+	CreatedAt time.Time
+}
+
+type Address struct {
+	City string
+}
+
+type OrderLine struct {
+	SKU string
+}
+
+type Shipping struct {
+	Timestamps
+	Name      string
+	secret    string
+	Note      sql.NullString
+	Address   Address
+	Billing   *Address
+	Lines     []*OrderLine
+	Tags      []string
+	Raw       []byte
+	ShippedAt time.Time
+}
+
+func TestConvertObjectToMetaValues(t *testing.T) {
+	shipping := Shipping{
+		Timestamps: Timestamps{CreatedAt: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)},
+		Name:       "ann",
+		secret:     "hidden",
+		Note:       sql.NullString{String: "fragile", Valid: true},
+		Address:    Address{City: "Pune"},
+		Lines:      []*OrderLine{{SKU: "A"}, nil, {SKU: "B"}},
+		Tags:       []string{"gift", "express"},
+		Raw:        []byte("raw"),
+	}
+	name := &resource.Meta{Name: "FullName", FieldName: "Name"}
+	metaValues, err := resource.ConvertObjectToMetaValues(&shipping, []resource.Metaor{metaor{name}})
+	require.NoError(t, err)
+
+	var names []string
+	for _, metaValue := range metaValues.Values {
+		names = append(names, metaValue.Name)
+	}
+	assert.Equal(t, []string{"CreatedAt", "FullName", "Note", "Address", "Billing", "Lines", "Lines", "Tags", "Raw", "ShippedAt"}, names,
+		"fields of embedded structs are promoted, unexported fields and nil elements are left out")
+
+	assert.Equal(t, "2021-01-02 03:04:05", metaValues.Get("CreatedAt").Value)
+	assert.Equal(t, "ann", metaValues.Get("FullName").Value)
+	assert.Equal(t, metaor{name}, metaValues.Get("FullName").Meta)
+	assert.Equal(t, "fragile", metaValues.Get("Note").Value)
+	assert.Equal(t, "Pune", metaValues.Get("Address").MetaValues.Get("City").Value)
+	assert.Equal(t, "", metaValues.Get("Billing").Value)
+	assert.Equal(t, []string{"gift", "express"}, metaValues.Get("Tags").Value)
+	assert.Equal(t, "raw", metaValues.Get("Raw").Value)
+	assert.Equal(t, "", metaValues.Get("ShippedAt").Value)
+	for idx, sku := range []string{"A", "B"} {
+		line := metaValues.Values[5+idx]
+		assert.Equal(t, idx, line.Index)
+		assert.Equal(t, sku, line.MetaValues.Get("SKU").Value)
+	}
+
+	_, err = resource.ConvertObjectToMetaValues("ann", nil)
+	assert.EqualError(t, err, "resource: can't convert string to meta values, expect a struct")
+}
+
+type Ticket struct {
+	ID    uint
+	Title string
+	State string
+}
+
+type ticketResource struct {
+	*resource.Resource
+	metas []resource.Metaor
+}
+
+func (res ticketResource) GetMetas([]string) []resource.Metaor {
+	return res.metas
+}
+
+func TestDecodeObject(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE tickets (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT, state TEXT)`)
+
+	res := ticketResource{Resource: resource.New(&Ticket{})}
+	for _, name := range []string{"Title", "State"} {
+		meta := &resource.Meta{Name: name, BaseResource: res.Resource}
+		require.NoError(t, meta.PreInitialize())
+		require.NoError(t, meta.Initialize())
+		res.metas = append(res.metas, metaor{meta})
+	}
+	res.AddProcessor(&resource.Processor{Name: "state", Handler: func(record interface{}, metaValues *resource.MetaValues, context *appsvr.Context) error {
+		if ticket := record.(*Ticket); ticket.State == "" {
+			ticket.State = "open"
+		}
+		return nil
+	}})
+
+	var ticket Ticket
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+	require.NoError(t, resource.DecodeObject(context, struct{ Title string }{Title: "Login"}, &ticket, res))
+	assert.Equal(t, Ticket{Title: "Login", State: "open"}, ticket, "objects go through the processors of the resource")
+
+	assert.Error(t, resource.DecodeObject(context, []string{"Login"}, &ticket, res))
+}
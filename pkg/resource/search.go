@@ -0,0 +1,308 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// SearchQuery is a parsed search keyword, like `acme code:A-1`, terms are matched against all search attributes,
+// and field terms, written as `attr:value`, against the attribute only
+type SearchQuery struct {
+	Keyword string
+	Terms   []string
+	Fields  map[string]string
+}
+
+// SearchMatcher returns the SQL condition matching a search attribute with a term, an empty condition matches nothing
+type SearchMatcher func(term string, context *appsvr.Context) (string, []interface{})
+
+// SearchEngine searches the records of a resource, it returns the db scoped to the matching records, like
+// `db.Where("id IN (?)", ids)` for an external index. The default engine is SQLSearchEngine
+type SearchEngine interface {
+	Search(res *Resource, query *SearchQuery, context *appsvr.Context) (*orm.DB, error)
+}
+
+// SearchIndexer is implemented by search engines with their own index, like Elasticsearch, to (re)index records
+type SearchIndexer interface {
+	Index(res *Resource, records interface{}, context *appsvr.Context) error
+}
+
+// SearchAttrs set search attributes, nested attributes of associations are separated with dots, like
+// res.SearchAttrs("Name", "Code", "Customer.Email"), it returns the search attributes
+func (res *Resource) SearchAttrs(attrs ...string) []string {
+	if len(attrs) > 0 {
+		scope := &orm.Scope{Value: res.Value}
+		for _, attr := range attrs {
+			if err := validateSearchAttr(scope, strings.Split(attr, ".")); err != nil {
+				utils.ExitWithMsg(err)
+				return res.searchAttrs
+			}
+		}
+		res.searchAttrs = attrs
+	}
+	return res.searchAttrs
+}
+
+// SetSearchMatcher overrides how a search attribute is matched
+func (res *Resource) SetSearchMatcher(attr string, matcher SearchMatcher) {
+	res.searchMatchers.Store(attr, matcher)
+}
+
+// SetSearchEngine replaces the SQL search engine of the resource, like with an Elasticsearch or Bleve backend
+func (res *Resource) SetSearchEngine(engine SearchEngine) {
+	res.searchEngine = engine
+}
+
+// GetSearchEngine get search engine of the resource, nil for the SQL search engine
+func (res *Resource) GetSearchEngine() SearchEngine {
+	return res.searchEngine
+}
+
+// CallSearch call search method
+func (res *Resource) CallSearch(keyword string, context *appsvr.Context) (*orm.DB, error) {
+	return res.SearchHandler(keyword, context)
+}
+
+func (res *Resource) searchHandler(keyword string, context *appsvr.Context) (*orm.DB, error) {
+	query := ParseSearchQuery(keyword, res.searchAttrs)
+	if len(query.Terms) == 0 && len(query.Fields) == 0 {
+		return context.GetDB(), nil
+	}
+
+	if res.searchEngine != nil {
+		return res.searchEngine.Search(res, query, context)
+	}
+	return SQLSearchEngine{}.Search(res, query, context)
+}
+
+// ParseSearchQuery parses a search keyword, `attr:value` terms are field terms if attr is one of attrs, case insensitively
+func ParseSearchQuery(keyword string, attrs []string) *SearchQuery {
+	query := &SearchQuery{Keyword: keyword, Fields: map[string]string{}}
+	for _, term := range strings.Fields(keyword) {
+		if idx := strings.Index(term, ":"); idx > 0 && idx < len(term)-1 {
+			var matched bool
+			for _, attr := range attrs {
+				if strings.EqualFold(attr, term[:idx]) {
+					query.Fields[attr], matched = term[idx+1:], true
+					break
+				}
+			}
+
+			if matched {
+				continue
+			}
+		}
+		query.Terms = append(query.Terms, term)
+	}
+	return query
+}
+
+// SQLSearchEngine is the default search engine, it matches text attributes with ILIKE, or LIKE on lower cased values
+// for databases other than postgres, numeric attributes by equality, and JSON attributes on their text. Attributes
+// of associations are matched with sub queries
+type SQLSearchEngine struct{}
+
+// Search implements SearchEngine, every term has to match one of the attributes, and field terms their attribute
+func (SQLSearchEngine) Search(res *Resource, query *SearchQuery, context *appsvr.Context) (*orm.DB, error) {
+	db := context.GetDB()
+	for _, term := range query.Terms {
+		var (
+			conditions []string
+			args       []interface{}
+		)
+
+		for _, attr := range res.searchAttrs {
+			sql, attrArgs, err := res.searchCondition(attr, term, context)
+			if err != nil {
+				return nil, err
+			}
+
+			if sql != "" {
+				conditions = append(conditions, "("+sql+")")
+				args = append(args, attrArgs...)
+			}
+		}
+
+		if len(conditions) == 0 {
+			return db.Where("1 = 0"), nil
+		}
+		db = db.Where(strings.Join(conditions, " OR "), args...)
+	}
+
+	for attr, term := range query.Fields {
+		sql, args, err := res.searchCondition(attr, term, context)
+		if err != nil {
+			return nil, err
+		}
+
+		if sql == "" {
+			return db.Where("1 = 0"), nil
+		}
+		db = db.Where(sql, args...)
+	}
+	return db, nil
+}
+
+func (res *Resource) searchCondition(attr, term string, context *appsvr.Context) (string, []interface{}, error) {
+	if matcher, ok := res.searchMatchers.Load(attr); ok {
+		sql, args := matcher.(SearchMatcher)(term, context)
+		return sql, args, nil
+	}
+
+	scope := context.GetDB().NewScope(res.Value)
+	return searchCondition(scope, strings.Split(attr, "."), term)
+}
+
+// searchCondition builds the condition of a search attribute path on the table of the scope, associations
+// are matched with sub queries on their tables
+func searchCondition(scope *orm.Scope, names []string, term string) (string, []interface{}, error) {
+	field, assocScope, err := getSearchField(scope, names)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if assocScope == nil {
+		sql, args := matchSearchField(scope, field, term)
+		return sql, args, nil
+	}
+
+	relationship := field.Relationship
+	sql, args, err := searchCondition(assocScope, names[1:], term)
+	if err != nil || sql == "" {
+		return sql, args, err
+	}
+
+	switch relationship.Kind {
+	case "belongs_to":
+		return fmt.Sprintf("%v.%v IN (SELECT %v.%v FROM %v WHERE %v)",
+			scope.QuotedTableName(), scope.Quote(relationship.ForeignDBNames[0]),
+			assocScope.QuotedTableName(), scope.Quote(relationship.AssociationForeignDBNames[0]),
+			assocScope.QuotedTableName(), sql), args, nil
+	case "has_one", "has_many":
+		if relationship.PolymorphicDBName != "" {
+			sql = fmt.Sprintf("%v.%v = ? AND (%v)", assocScope.QuotedTableName(), scope.Quote(relationship.PolymorphicDBName), sql)
+			args = append([]interface{}{relationship.PolymorphicValue}, args...)
+		}
+
+		return fmt.Sprintf("%v.%v IN (SELECT %v.%v FROM %v WHERE %v)",
+			scope.QuotedTableName(), scope.Quote(relationship.AssociationForeignDBNames[0]),
+			assocScope.QuotedTableName(), scope.Quote(relationship.ForeignDBNames[0]),
+			assocScope.QuotedTableName(), sql), args, nil
+	}
+	return "", nil, nil
+}
+
+func validateSearchAttr(scope *orm.Scope, names []string) error {
+	_, assocScope, err := getSearchField(scope, names)
+	if err == nil && assocScope != nil {
+		return validateSearchAttr(assocScope, names[1:])
+	}
+	return err
+}
+
+// getSearchField returns the field of the first name of a search attribute path, and the scope of the
+// association if the path is nested
+func getSearchField(scope *orm.Scope, names []string) (*orm.StructField, *orm.Scope, error) {
+	var field *orm.StructField
+	for _, f := range scope.GetStructFields() {
+		if f.Name == names[0] || f.DBName == names[0] {
+			field = f
+			break
+		}
+	}
+
+	if field == nil {
+		return nil, nil, fmt.Errorf("%v is not a valid search attribute of %v", names[0], utils.ModelType(scope.Value).Name())
+	}
+
+	if len(names) == 1 {
+		return field, nil, nil
+	}
+
+	relationship := field.Relationship
+	if relationship == nil || len(relationship.ForeignDBNames) == 0 || len(relationship.AssociationForeignDBNames) == 0 {
+		return nil, nil, fmt.Errorf("%v of %v is not an association", field.Name, utils.ModelType(scope.Value).Name())
+	}
+
+	if relationship.Kind != "belongs_to" && relationship.Kind != "has_one" && relationship.Kind != "has_many" {
+		return nil, nil, fmt.Errorf("searching %v associations like %v of %v is not supported", relationship.Kind, field.Name, utils.ModelType(scope.Value).Name())
+	}
+	return field, scope.New(reflect.New(utils.ModelType(reflect.New(field.Struct.Type).Interface())).Interface()), nil
+}
+
+// matchSearchField returns the condition matching a column with a term, or an empty condition if the term
+// can't match the column, like a word for a numeric column
+func matchSearchField(scope *orm.Scope, field *orm.StructField, term string) (string, []interface{}) {
+	var (
+		column    = fmt.Sprintf("%v.%v", scope.QuotedTableName(), scope.Quote(field.DBName))
+		isPostgre = scope.Dialect().GetName() == "postgres"
+		fieldType = field.Struct.Type
+	)
+
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	if sqlType, ok := field.TagSettingsGet("TYPE"); ok && strings.Contains(strings.ToLower(sqlType), "json") {
+		textType := "TEXT"
+		if scope.Dialect().GetName() == "mysql" {
+			textType = "CHAR"
+		}
+		column = fmt.Sprintf("CAST(%v AS %v)", column, textType)
+		fieldType = reflect.TypeOf("")
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		if isPostgre {
+			return fmt.Sprintf("%v ILIKE ?", column), []interface{}{"%" + term + "%"}
+		}
+		return fmt.Sprintf("LOWER(%v) LIKE ?", column), []interface{}{"%" + strings.ToLower(term) + "%"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value, err := strconv.ParseInt(term, 10, 64); err == nil {
+			return fmt.Sprintf("%v = ?", column), []interface{}{value}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value, err := strconv.ParseUint(term, 10, 64); err == nil {
+			return fmt.Sprintf("%v = ?", column), []interface{}{value}
+		}
+	case reflect.Float32, reflect.Float64:
+		if value, err := strconv.ParseFloat(term, 64); err == nil {
+			return fmt.Sprintf("%v = ?", column), []interface{}{value}
+		}
+	case reflect.Struct:
+		if fieldType == reflect.TypeOf(time.Time{}) {
+			if value, err := time.Parse("2006-01-02", term); err == nil {
+				return fmt.Sprintf("%v >= ? AND %v < ?", column, column), []interface{}{value, value.AddDate(0, 0, 1)}
+			}
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,120 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Customer struct {
+	ID    uint
+	Email string
+}
+
+type Purchase struct {
+	ID         uint
+	Code       string
+	Total      int
+	CustomerID uint
+	Customer   Customer
+}
+
+type searchEngine struct {
+	query *resource.SearchQuery
+}
+
+func (engine *searchEngine) Search(res *resource.Resource, query *resource.SearchQuery, context *appsvr.Context) (*orm.DB, error) {
+	engine.query = query
+	return context.GetDB().Where("id IN (?)", []uint{2}), nil
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	for _, test := range []struct {
+		keyword string
+		terms   []string
+		fields  map[string]string
+	}{
+		{keyword: "", fields: map[string]string{}},
+		{keyword: "acme  A-1", terms: []string{"acme", "A-1"}, fields: map[string]string{}},
+		{keyword: "acme code:A-1", terms: []string{"acme"}, fields: map[string]string{"Code": "A-1"}},
+		// only search attributes are field terms
+		{keyword: "time:10:30 code:", terms: []string{"time:10:30", "code:"}, fields: map[string]string{}},
+	} {
+		query := resource.ParseSearchQuery(test.keyword, []string{"Code", "Customer.Email"})
+		assert.Equal(t, test.keyword, query.Keyword)
+		assert.Equal(t, test.terms, query.Terms, test.keyword)
+		assert.Equal(t, test.fields, query.Fields, test.keyword)
+	}
+}
+
+func TestCallSearch(t *testing.T) {
+	db := utils.SQLiteTestDB(t,
+		`CREATE TABLE customers (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT)`,
+		`CREATE TABLE purchases (id INTEGER PRIMARY KEY AUTOINCREMENT, code TEXT, total INTEGER, customer_id INTEGER)`,
+	)
+	for _, purchase := range []Purchase{
+		{Code: "A-1", Total: 42, Customer: Customer{Email: "ann@acme.com"}},
+		{Code: "B-2", Total: 7, Customer: Customer{Email: "bob@example.com"}},
+		{Code: "B-3", Total: 42, Customer: Customer{Email: "eve@acme.com"}},
+	} {
+		require.NoError(t, db.Create(&purchase).Error)
+	}
+
+	res := resource.New(&Purchase{})
+	assert.Equal(t, []string{"Code", "Total", "Customer.Email"}, res.SearchAttrs("Code", "Total", "Customer.Email"))
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+	search := func(keyword string) []uint {
+		searchDB, err := res.CallSearch(keyword, context)
+		require.NoError(t, err)
+		var ids []uint
+		require.NoError(t, searchDB.Model(&Purchase{}).Order("id").Pluck("id", &ids).Error)
+		return ids
+	}
+
+	assert.Equal(t, []uint{1, 2, 3}, search(""))
+	assert.Equal(t, []uint{2, 3}, search("b-"))
+	assert.Equal(t, []uint{1, 3}, search("42"))
+	assert.Equal(t, []uint{1, 3}, search("ACME"), "attributes of associations are searched")
+	assert.Equal(t, []uint{3}, search("acme b-"), "every term has to match")
+	assert.Equal(t, []uint{3}, search("code:B-3"))
+	assert.Empty(t, search("total:x"), "words never match numeric attributes")
+	assert.Empty(t, search("zzz"))
+
+	res.SetSearchMatcher("Code", func(term string, context *appsvr.Context) (string, []interface{}) {
+		return "code = ?", []interface{}{term}
+	})
+	assert.Equal(t, []uint{2}, search("code:B-2"))
+	assert.Empty(t, search("code:B-"))
+
+	engine := &searchEngine{}
+	res.SetSearchEngine(engine)
+	assert.Equal(t, engine, res.GetSearchEngine())
+	assert.Equal(t, []uint{2}, search("bob code:B-2"))
+	assert.Equal(t, []string{"bob"}, engine.query.Terms)
+	assert.Equal(t, map[string]string{"Code": "B-2"}, engine.query.Fields)
+}
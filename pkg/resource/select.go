@@ -0,0 +1,203 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/utils"
+	"github.com/bhojpur/application/pkg/validations"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// SelectOption is an option of a select meta, Value is the value of the meta
+type SelectOption struct {
+	Label string
+	Value string
+}
+
+// SelectConfig is the config of select-one and select-many metas, whose values have to be the values of their
+// options, or blank. The options come from one source, the static Collection, the records of Resource or
+// RemoteDataSource
+//
+//	products.Meta(&resource.Meta{Name: "Size", SelectOne: &resource.SelectConfig{
+//		Collection: []resource.SelectOption{{Label: "Small", Value: "S"}, {Label: "Large", Value: "L"}},
+//	}})
+//	products.Meta(&resource.Meta{Name: "Categories", SelectMany: &resource.SelectConfig{
+//		Resource: categories,
+//		Scope:    func(db *orm.DB, context *appsvr.Context) *orm.DB { return db.Where("active = ?", true) },
+//	}})
+type SelectConfig struct {
+	// Collection are the static options
+	Collection []SelectOption
+	// Resource is the resource of the records which are the options, those found by it in the context, valued by
+	// their primary key
+	Resource Resourcer
+	// LabelField is the field of the labels of the records of Resource, "Name" by default, their primary key if they
+	// don't have one
+	LabelField string
+	// Scope restricts the records of Resource which are options, if not nil
+	Scope func(db *orm.DB, context *appsvr.Context) *orm.DB
+	// RemoteDataSource returns the options for a record, like the ones of a remote service
+	RemoteDataSource func(record interface{}, context *appsvr.Context) ([]SelectOption, error)
+}
+
+// configure wraps the setter of the meta, so that values which aren't the values of options are validation errors
+// of the meta, and are not set
+func (config *SelectConfig) configure(meta *Meta, many bool) error {
+	var sources int
+	for _, set := range []bool{len(config.Collection) > 0, config.Resource != nil, config.RemoteDataSource != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("select meta %v should have one source of options, got %v", meta.Name, sources)
+	}
+	if config.LabelField == "" {
+		config.LabelField = "Name"
+	}
+
+	setter := meta.Setter
+	if setter == nil {
+		return fmt.Errorf("select meta %v has no setter", meta.Name)
+	}
+	meta.Setter = func(record interface{}, metaValue *MetaValue, context *appsvr.Context) {
+		if metaValue == nil {
+			return
+		}
+
+		var values []string
+		if many {
+			if metaValue.Value != nil {
+				values = utils.ToArray(metaValue.Value)
+			}
+		} else {
+			values = []string{utils.ToString(metaValue.Value)}
+		}
+
+		invalid, err := config.invalidValues(record, values, context)
+		if err != nil {
+			context.AddError(err)
+			return
+		}
+		if len(invalid) > 0 {
+			value := strings.Join(invalid, ", ")
+			context.AddError(validations.NewFieldError(record, meta.Name, validations.CodeInclusion, fmt.Sprintf("%v is not a valid value of %v", value, meta.Name), map[string]interface{}{"value": value}))
+			return
+		}
+		setter(record, metaValue, context)
+	}
+	return nil
+}
+
+// Options returns the options of the record, the ones of the source of the config. The records of Resource are
+// found with the context, like its keyword
+func (config *SelectConfig) Options(record interface{}, context *appsvr.Context) ([]SelectOption, error) {
+	switch {
+	case config.RemoteDataSource != nil:
+		return config.RemoteDataSource(record, context)
+	case config.Resource != nil:
+		records, err := config.findRecords(context.GetReadDB(), context)
+		if err != nil {
+			return nil, err
+		}
+		options := make([]SelectOption, 0, records.Len())
+		for idx := 0; idx < records.Len(); idx++ {
+			options = append(options, config.recordOption(addressOf(records.Index(idx)), context))
+		}
+		return options, nil
+	}
+	return config.Collection, nil
+}
+
+// invalidValues returns the values which aren't the values of options, blank values are valid. The records of
+// Resource are found by the values, to not find all of them
+func (config *SelectConfig) invalidValues(record interface{}, values []string, context *appsvr.Context) ([]string, error) {
+	var invalid []string
+	if config.Resource != nil {
+		res := config.Resource.GetResource()
+		for _, value := range values {
+			if value == "" {
+				continue
+			}
+			primaryQuerySQL, primaryParams := res.ToPrimaryQueryParams(value, context)
+			if primaryQuerySQL == "" {
+				invalid = append(invalid, value)
+				continue
+			}
+			records, err := config.findRecords(context.GetReadDB().Where(primaryQuerySQL, primaryParams...), context)
+			if err != nil {
+				return nil, err
+			}
+			if records.Len() == 0 {
+				invalid = append(invalid, value)
+			}
+		}
+		return invalid, nil
+	}
+
+	options, err := config.Options(record, context)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make(map[string]bool, len(options))
+	for _, option := range options {
+		allowed[option.Value] = true
+	}
+	for _, value := range values {
+		if value != "" && !allowed[value] {
+			invalid = append(invalid, value)
+		}
+	}
+	return invalid, nil
+}
+
+// findRecords finds the records of Resource in db, restricted by Scope
+func (config *SelectConfig) findRecords(db *orm.DB, context *appsvr.Context) (reflect.Value, error) {
+	if config.Scope != nil {
+		db = config.Scope(db, context)
+	}
+	findContext := context.Clone()
+	findContext.SetDB(db)
+	// the projection of the context is the one of the fields of the record of the meta
+	findContext.SetFields()
+
+	records := config.Resource.NewSlice()
+	if err := config.Resource.CallFindMany(records, findContext); err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.Indirect(reflect.ValueOf(records)), nil
+}
+
+// recordOption returns the option of a record of Resource
+func (config *SelectConfig) recordOption(record interface{}, context *appsvr.Context) SelectOption {
+	option := SelectOption{Value: config.Resource.GetResource().GetPrimaryKey(record, context)}
+	if field := utils.Indirect(reflect.ValueOf(record)).FieldByName(config.LabelField); field.IsValid() {
+		option.Label = utils.ToString(field.Interface())
+	} else {
+		option.Label = option.Value
+	}
+	return option
+}
@@ -0,0 +1,116 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/validations"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Department struct {
+	ID     uint
+	Name   string
+	Active bool
+}
+
+type Employee struct {
+	ID           uint
+	Size         string
+	DepartmentID uint
+	Skills       []string `orm:"-"`
+}
+
+func TestSelectMetas(t *testing.T) {
+	db := utils.SQLiteTestDB(t,
+		`CREATE TABLE departments (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, active BOOLEAN)`,
+		`CREATE TABLE employees (id INTEGER PRIMARY KEY AUTOINCREMENT, size TEXT, department_id INTEGER)`,
+	)
+	require.NoError(t, db.Create(&Department{Name: "Sales", Active: true}).Error)
+	require.NoError(t, db.Create(&Department{Name: "Archive"}).Error)
+
+	departments := resource.New(&Department{})
+	res := resource.New(&Employee{})
+	size := &resource.Meta{Name: "Size", BaseResource: res, SelectOne: &resource.SelectConfig{
+		Collection: []resource.SelectOption{{Label: "Small", Value: "S"}, {Label: "Large", Value: "L"}},
+	}}
+	department := &resource.Meta{Name: "DepartmentID", BaseResource: res, SelectOne: &resource.SelectConfig{
+		Resource: departments,
+		Scope:    func(db *orm.DB, context *appsvr.Context) *orm.DB { return db.Where("active = ?", true) },
+	}}
+	skills := &resource.Meta{Name: "Skills", BaseResource: res, SelectMany: &resource.SelectConfig{
+		RemoteDataSource: func(record interface{}, context *appsvr.Context) ([]resource.SelectOption, error) {
+			return []resource.SelectOption{{Label: "Go", Value: "go"}, {Label: "SQL", Value: "sql"}}, nil
+		},
+	}}
+	for _, meta := range []*resource.Meta{size, department, skills} {
+		require.NoError(t, meta.PreInitialize())
+		require.NoError(t, meta.Initialize())
+	}
+
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+	validate := func(employee *Employee, values map[string]interface{}) validations.Errors {
+		metaValues := &resource.MetaValues{}
+		for _, meta := range []*resource.Meta{size, department, skills} {
+			if value, ok := values[meta.Name]; ok {
+				metaValues.Values = append(metaValues.Values, &resource.MetaValue{Name: meta.Name, Value: value, Meta: metaor{meta}})
+			}
+		}
+		errs, err := res.CallValidate(employee, metaValues, context)
+		require.NoError(t, err)
+		return errs
+	}
+
+	var employee Employee
+	assert.Empty(t, validate(&employee, map[string]interface{}{"Size": "L", "DepartmentID": "1", "Skills": []string{"go", "sql"}}))
+	assert.Equal(t, Employee{Size: "L", DepartmentID: 1, Skills: []string{"go", "sql"}}, employee)
+
+	// blank values are valid
+	employee = Employee{}
+	assert.Empty(t, validate(&employee, map[string]interface{}{"Size": "", "DepartmentID": "", "Skills": nil}))
+
+	employee = Employee{}
+	errs := validate(&employee, map[string]interface{}{"Size": "XL", "DepartmentID": "2", "Skills": []string{"go", "rust"}})
+	require.Len(t, errs, 3)
+	for idx, name := range []string{"Size", "DepartmentID", "Skills"} {
+		assert.Equal(t, name, errs[idx].Column)
+		assert.Equal(t, validations.CodeInclusion, errs[idx].Code)
+	}
+	assert.Equal(t, "rust", errs[2].Params["value"])
+	assert.Equal(t, Employee{}, employee)
+
+	options, err := department.SelectOne.Options(&employee, context)
+	require.NoError(t, err)
+	assert.Equal(t, []resource.SelectOption{{Label: "Sales", Value: "1"}}, options)
+	options, err = size.SelectOne.Options(&employee, context)
+	require.NoError(t, err)
+	assert.Len(t, options, 2)
+
+	invalid := &resource.Meta{Name: "Size", BaseResource: res, SelectOne: &resource.SelectConfig{}}
+	require.NoError(t, invalid.PreInitialize())
+	assert.Error(t, invalid.Initialize())
+}
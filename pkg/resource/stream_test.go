@@ -0,0 +1,79 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallFindManyStream(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`)
+	for _, name := range []string{"Blue", "Green", "Red"} {
+		require.NoError(t, db.Create(&Product{Name: name}).Error)
+	}
+
+	res := resource.New(&Product{})
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+
+	var products []Product
+	err := res.CallFindManyStream(context, func(record interface{}) error {
+		products = append(products, *record.(*Product))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Product{{ID: 3, Name: "Red"}, {ID: 2, Name: "Green"}, {ID: 1, Name: "Blue"}}, products)
+
+	// scopes of the context apply to the stream
+	scoped := context.Clone()
+	scoped.SetDB(db.Where("name <> ?", "Green"))
+	products = nil
+	err = res.CallFindManyStream(scoped, func(record interface{}) error {
+		products = append(products, *record.(*Product))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Product{{ID: 3, Name: "Red"}, {ID: 1, Name: "Blue"}}, products)
+
+	// the stream stops at the first error of the handler
+	stop := errors.New("stop")
+	var count int
+	err = res.CallFindManyStream(context, func(record interface{}) error {
+		count++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, count)
+
+	res.Permission = roles.Deny(roles.Read, roles.Anyone)
+	err = res.CallFindManyStream(context, func(record interface{}) error {
+		t.Fatal("record streamed without permission")
+		return nil
+	})
+	assert.ErrorIs(t, err, roles.ErrPermissionDenied)
+}
@@ -0,0 +1,87 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+
+	"go.opencensus.io/trace"
+
+	diag_utils "github.com/bhojpur/application/pkg/diagnostics/utils"
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/roles"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// span attribute keys of resource operations
+const (
+	resourceSpanAttributeKey  = "app.resource"
+	operationSpanAttributeKey = "app.resource.operation"
+	recordIDSpanAttributeKey  = "app.resource.record_id"
+)
+
+// startSpan starts the span of an operation of the resource, as a child of the trace context of context, and sets it
+// as the trace context so nested operations are its children. Operations out of a trace start one if the tracing
+// spec of the config samples them. The returned func ends the span with the status of the error of the operation
+func (res *Resource) startSpan(operation string, context *appsvr.Context) func(err error) {
+	if context == nil {
+		return func(error) {}
+	}
+
+	parent := context.GetTraceContext()
+	var options []trace.StartOption
+	if trace.FromContext(parent) == nil {
+		if context.Config == nil || context.Config.Tracing == nil || !diag_utils.IsTracingEnabled(context.Config.Tracing.SamplingRate) {
+			return func(error) {}
+		}
+		options = append(options, diag_utils.TraceSampler(context.Config.Tracing.SamplingRate))
+	}
+
+	ctx, span := trace.StartSpan(parent, "resource/"+res.Name+"/"+operation, options...)
+	attributes := []trace.Attribute{
+		trace.StringAttribute(resourceSpanAttributeKey, res.Name),
+		trace.StringAttribute(operationSpanAttributeKey, operation),
+	}
+	if context.ResourceID != "" {
+		attributes = append(attributes, trace.StringAttribute(recordIDSpanAttributeKey, context.ResourceID))
+	}
+	span.AddAttributes(attributes...)
+	context.SetTraceContext(ctx)
+
+	return func(err error) {
+		if err != nil {
+			span.SetStatus(spanStatus(err))
+		}
+		span.End()
+		context.SetTraceContext(parent)
+	}
+}
+
+// spanStatus returns the status of a span of an operation failed with err
+func spanStatus(err error) trace.Status {
+	switch {
+	case errors.Is(err, roles.ErrPermissionDenied):
+		return trace.Status{Code: trace.StatusCodePermissionDenied, Message: err.Error()}
+	case orm.IsRecordNotFoundError(err):
+		return trace.Status{Code: trace.StatusCodeNotFound, Message: err.Error()}
+	}
+	return trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()}
+}
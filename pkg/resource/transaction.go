@@ -0,0 +1,143 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// Txn is a database transaction shared by the operations of multiple resources, see RunInTransaction
+type Txn struct {
+	// Context is the transactional context, its DB is the transaction, and handlers, validators
+	// and processors of the resources get it as their context
+	Context *appsvr.Context
+	errors  appsvr.Errors
+}
+
+// Decode decodes meta values to result with the validators and processors of the resource
+func (tx *Txn) Decode(res Resourcer, result interface{}, metaValues *MetaValues) error {
+	return tx.addError(DecodeToResource(res, result, metaValues, tx.Context).Start())
+}
+
+// Save saves result with the resource
+func (tx *Txn) Save(res Resourcer, result interface{}) error {
+	return tx.addError(res.CallSave(result, tx.Context))
+}
+
+// Delete deletes result with the resource
+func (tx *Txn) Delete(res Resourcer, result interface{}) error {
+	return tx.addError(res.CallDelete(result, tx.Context))
+}
+
+// HasError returns true if an operation of the transaction failed, the transaction will be rolled back
+func (tx *Txn) HasError() bool {
+	return tx.errors.HasError() || tx.Context.HasError()
+}
+
+func (tx *Txn) addError(err error) error {
+	tx.errors.AddError(err)
+	return err
+}
+
+// RunInTransaction runs fc in a database transaction, which is committed if fc returns nil, and rolled back
+// if it returns an error or if any operation of the transaction failed, like a validator of one of the resources
+//
+//	err := resource.RunInTransaction(context, func(tx *resource.Txn) error {
+//	    if err := tx.Save(orderResource, &order); err != nil {
+//	        return err
+//	    }
+//	    return tx.Save(stockResource, &stock)
+//	})
+//
+// Transactions run in a transaction run in a savepoint of it, if they fail their operations and events are
+// rolled back, and the outer transaction goes on
+func RunInTransaction(context *appsvr.Context, fc func(tx *Txn) error) error {
+	// events of the operations are published once the outermost transaction is committed
+	if outer, nested := context.GetDB().Get(pendingEventsKey); nested {
+		return runInSavepoint(context, outer.(*[]pendingEvent), fc)
+	}
+
+	pending := &[]pendingEvent{}
+	err := context.GetDB().Transaction(func(db *orm.DB) error {
+		return runTxn(context, db, pending, fc)
+	})
+	if err == nil {
+		publishEvents(context, *pending...)
+	}
+	return err
+}
+
+// savepointSeq numbers savepoints, so nested savepoints have their own names
+var savepointSeq uint64
+
+// runInSavepoint runs fc in a savepoint of the transaction of the context, its events are added to the outer ones
+// once it is released
+func runInSavepoint(context *appsvr.Context, outer *[]pendingEvent, fc func(tx *Txn) error) (err error) {
+	db := context.GetDB()
+	savepoint := fmt.Sprintf("resource_txn_%d", atomic.AddUint64(&savepointSeq, 1))
+	if err := db.Exec("SAVEPOINT " + savepoint).Error; err != nil {
+		return err
+	}
+
+	panicked := true
+	defer func() {
+		// roll back when fc panics or fails, or the savepoint can't be released
+		if panicked || err != nil {
+			db.Exec("ROLLBACK TO SAVEPOINT " + savepoint)
+		}
+	}()
+
+	pending := &[]pendingEvent{}
+	err = runTxn(context, db, pending, fc)
+	if err == nil {
+		err = db.Exec("RELEASE SAVEPOINT " + savepoint).Error
+	}
+	panicked = false
+
+	if err == nil {
+		*outer = append(*outer, *pending...)
+	}
+	return err
+}
+
+// runTxn runs fc with a transactional context of db, which queues the events of its operations to pending
+func runTxn(context *appsvr.Context, db *orm.DB, pending *[]pendingEvent, fc func(tx *Txn) error) error {
+	txContext := context.Clone()
+	txContext.SetDB(db.Set(pendingEventsKey, pending))
+	txContext.Errors = appsvr.Errors{}
+
+	tx := &Txn{Context: txContext}
+	if err := fc(tx); err != nil {
+		return err
+	}
+
+	if tx.HasError() {
+		var errs appsvr.Errors
+		errs.AddError(tx.errors.GetErrors()...)
+		errs.AddError(txContext.GetErrors()...)
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	stdcontext "context"
+	"errors"
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/events"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Product struct {
+	ID   uint
+	Name string
+}
+
+func TestRunInTransaction(t *testing.T) {
+	db := utils.SQLiteTestDB(t,
+		`CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`,
+		`CREATE TABLE tickets (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT, state TEXT)`,
+	)
+
+	var (
+		bus       = events.NewBus()
+		published []string
+		products  = resource.New(&Product{})
+		tickets   = resource.New(&Ticket{})
+		context   = &appsvr.Context{Config: &appsvr.Config{DB: db}}
+	)
+	bus.Subscribe(func(ctx stdcontext.Context, event events.Event) error {
+		published = append(published, event.Resource+" "+string(event.Action))
+		return nil
+	})
+	products.SetEventBus(bus)
+	tickets.SetEventBus(bus)
+
+	count := func(value interface{}) int {
+		var count int
+		require.NoError(t, db.Model(value).Count(&count).Error)
+		return count
+	}
+
+	t.Run("committed operations publish their events after the outermost commit", func(t *testing.T) {
+		published = nil
+		err := resource.RunInTransaction(context, func(tx *resource.Txn) error {
+			if err := tx.Save(products, &Product{Name: "Pen"}); err != nil {
+				return err
+			}
+			// nested transactions join the outer one
+			err := resource.RunInTransaction(tx.Context, func(nested *resource.Txn) error {
+				return nested.Save(tickets, &Ticket{Title: "Refill", State: "open"})
+			})
+			assert.Empty(t, published)
+			return err
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Product create", "Ticket create"}, published)
+		assert.Equal(t, 1, count(&Product{}))
+		assert.Equal(t, 1, count(&Ticket{}))
+	})
+
+	t.Run("the operations of nested transactions are rolled back with the outer one", func(t *testing.T) {
+		published = nil
+		err := resource.RunInTransaction(context, func(tx *resource.Txn) error {
+			if err := tx.Save(products, &Product{Name: "Ink"}); err != nil {
+				return err
+			}
+			if err := resource.RunInTransaction(tx.Context, func(nested *resource.Txn) error {
+				return nested.Save(tickets, &Ticket{Title: "Ink", State: "open"})
+			}); err != nil {
+				return err
+			}
+			return errors.New("out of stock")
+		})
+		assert.EqualError(t, err, "out of stock")
+		assert.Empty(t, published)
+		assert.Equal(t, 1, count(&Product{}))
+		assert.Equal(t, 1, count(&Ticket{}))
+	})
+
+	t.Run("failed nested transactions are rolled back alone", func(t *testing.T) {
+		published = nil
+		err := resource.RunInTransaction(context, func(tx *resource.Txn) error {
+			if err := tx.Save(products, &Product{Name: "Eraser"}); err != nil {
+				return err
+			}
+			// the outer transaction goes on without the operations and events of the nested one
+			err := resource.RunInTransaction(tx.Context, func(nested *resource.Txn) error {
+				if err := nested.Save(tickets, &Ticket{Title: "Eraser", State: "open"}); err != nil {
+					return err
+				}
+				return errors.New("no eraser")
+			})
+			assert.EqualError(t, err, "no eraser")
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Product create"}, published)
+		assert.Equal(t, 2, count(&Product{}))
+		assert.Equal(t, 1, count(&Ticket{}))
+	})
+
+	t.Run("failed operations roll back the transaction", func(t *testing.T) {
+		published = nil
+		tickets.Permission = roles.Deny(roles.Create, roles.Anyone).Deny(roles.Update, roles.Anyone)
+		t.Cleanup(func() { tickets.Permission = nil })
+
+		// even if the error is ignored
+		err := resource.RunInTransaction(context, func(tx *resource.Txn) error {
+			require.NoError(t, tx.Save(products, &Product{Name: "Paper"}))
+			tx.Save(tickets, &Ticket{Title: "Paper", State: "open"})
+			return nil
+		})
+		assert.EqualError(t, err, roles.ErrPermissionDenied.Error())
+		assert.Empty(t, published)
+		assert.Equal(t, 2, count(&Product{}))
+	})
+}
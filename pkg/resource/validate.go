@@ -0,0 +1,108 @@
+package resource
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"database/sql"
+	"errors"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/validations"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// CallValidate decodes meta values to record with the validators and processors of the resource, like before saving
+// it, but nothing is persisted: their changes to the database are rolled back and record isn't saved. It returns the
+// validation errors, addressed by the path of their field, and an error if the validation couldn't complete, like a
+// failing query or a processor returning another error than a validation one
+func (res *Resource) CallValidate(record interface{}, metaValues *MetaValues, context *appsvr.Context) (validations.Errors, error) {
+	var errs appsvr.Errors
+	err := rollBack(context.GetDB(), func(db *orm.DB) {
+		validateContext := context.Clone()
+		validateContext.SetDB(db)
+		validateContext.Errors = appsvr.Errors{}
+
+		errs.AddError(DecodeToResource(res, record, metaValues, validateContext).Start())
+		errs.AddError(validateContext.GetErrors()...)
+		// many-to-many changes are applied by saves only
+		takePendingAssociations(validateContext, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	validationErrs, others := validations.FromError(errs)
+	if validationErrs == nil {
+		validationErrs = validations.Errors{}
+	}
+	if len(others) > 0 {
+		var otherErrs appsvr.Errors
+		otherErrs.AddError(others...)
+		return validationErrs, otherErrs
+	}
+	return validationErrs, nil
+}
+
+// ValidTagsProcessor is the name of the processor of resources validating the fields of their records by their
+// `valid` tags, like `valid:"required,max=255,email"`, see EnableValidTags
+const ValidTagsProcessor = "valid_tags"
+
+// RequiredMetasProcessor is the name of the processor of resources checking the values of the metas required by
+// their conditions, see Meta.RequiredIf
+const RequiredMetasProcessor = "required_metas"
+
+// EnableValidTags validates the fields of the records of the resource by their `valid` tags once the meta values are
+// decoded, see validations.ValidateTags. It is a processor, so that the errors are the ones of the decoded values,
+// replace it with AddProcessor to change it. Resources of databases with the callbacks of
+// validations.RegisterCallbacks validate the tags when records are saved already, with the same rules
+func (res *Resource) EnableValidTags() {
+	res.AddProcessor(&Processor{Name: ValidTagsProcessor, Handler: validateTags})
+}
+
+func validateTags(record interface{}, metaValues *MetaValues, context *appsvr.Context) error {
+	return validations.ValidateTags(record).ErrorOrNil()
+}
+
+const validateSavepoint = "bhojpur_validate"
+
+// rollBack runs fc in a transaction which is rolled back, in a savepoint of db if it is a transaction already
+func rollBack(db *orm.DB, fc func(db *orm.DB)) error {
+	if db == nil {
+		return errors.New("no database to validate in")
+	}
+
+	if _, ok := db.CommonDB().(*sql.Tx); ok {
+		if err := db.Exec("SAVEPOINT " + validateSavepoint).Error; err != nil {
+			return err
+		}
+		defer db.Exec("ROLLBACK TO SAVEPOINT " + validateSavepoint)
+		fc(db)
+		return nil
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	defer tx.Rollback()
+	fc(tx)
+	return nil
+}
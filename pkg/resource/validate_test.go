@@ -0,0 +1,122 @@
+package resource_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/validations"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallValidate(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`)
+
+	res := resource.New(&Product{})
+	meta := &resource.Meta{Name: "Name", BaseResource: res}
+	require.NoError(t, meta.PreInitialize())
+	require.NoError(t, meta.Initialize())
+	res.AddValidator(&resource.Validator{Name: "name", Handler: func(record interface{}, metaValues *resource.MetaValues, context *appsvr.Context) error {
+		if metaValues.Get("Name") == nil || metaValues.Get("Name").Value == "" {
+			return validations.NewError(record, "Name", "Name can't be blank")
+		}
+		return nil
+	}})
+	// changes of processors to the database are rolled back
+	res.AddProcessor(&resource.Processor{Name: "log", Handler: func(record interface{}, metaValues *resource.MetaValues, context *appsvr.Context) error {
+		return context.GetDB().Create(&Product{Name: "Log"}).Error
+	}})
+
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+	validate := func(name string) (validations.Errors, error) {
+		var product Product
+		return res.CallValidate(&product, &resource.MetaValues{Values: []*resource.MetaValue{{Name: "Name", Value: name, Meta: metaor{meta}}}}, context)
+	}
+
+	errs, err := validate("")
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "Name", errs[0].Column)
+
+	var product Product
+	errs, err = res.CallValidate(&product, &resource.MetaValues{Values: []*resource.MetaValue{{Name: "Name", Value: "Blue", Meta: metaor{meta}}}}, context)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+	assert.Equal(t, "Blue", product.Name)
+
+	var count int
+	require.NoError(t, db.Model(&Product{}).Count(&count).Error)
+	assert.Zero(t, count)
+
+	// in a transaction, the changes are rolled back to a savepoint
+	err = resource.RunInTransaction(context, func(tx *resource.Txn) error {
+		if err := tx.Context.GetDB().Create(&Product{Name: "Red"}).Error; err != nil {
+			return err
+		}
+		_, err := res.CallValidate(&Product{}, &resource.MetaValues{Values: []*resource.MetaValue{{Name: "Name", Value: "Blue", Meta: metaor{meta}}}}, tx.Context)
+		return err
+	})
+	require.NoError(t, err)
+	var names []string
+	require.NoError(t, db.Model(&Product{}).Pluck("name", &names).Error)
+	assert.Equal(t, []string{"Red"}, names)
+
+	// other errors fail the validation
+	res.AddProcessor(&resource.Processor{Name: "log", Handler: func(interface{}, *resource.MetaValues, *appsvr.Context) error {
+		return errors.New("unavailable")
+	}})
+	_, err = validate("Blue")
+	assert.EqualError(t, err, "unavailable")
+}
+
+type Gadget struct {
+	ID   uint
+	Name string `valid:"required,max=5"`
+}
+
+func TestValidTags(t *testing.T) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE gadgets (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`)
+
+	res := resource.New(&Gadget{})
+	assert.Empty(t, res.Processors, "tags are validated once enabled")
+	res.EnableValidTags()
+	meta := &resource.Meta{Name: "Name", BaseResource: res}
+	require.NoError(t, meta.PreInitialize())
+	require.NoError(t, meta.Initialize())
+
+	// the decoded values are validated
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}}
+	errs, err := res.CallValidate(&Gadget{Name: "Box"}, &resource.MetaValues{Values: []*resource.MetaValue{{Name: "Name", Value: "Toolbox", Meta: metaor{meta}}}}, context)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "Name", errs[0].Column)
+	assert.Equal(t, "max", errs[0].Code)
+	assert.Equal(t, "Name should be at most 5", errs[0].Message)
+
+	errs, err = res.CallValidate(&Gadget{}, &resource.MetaValues{Values: []*resource.MetaValue{{Name: "Name", Value: "Box", Meta: metaor{meta}}}}, context)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
@@ -0,0 +1,60 @@
+package roles
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	appsvr "github.com/bhojpur/application/pkg/engine"
+)
+
+// Condition check if a conditional permission applies to the record in current context
+type Condition func(record interface{}, context *appsvr.Context) bool
+
+type conditionalRole struct {
+	role      string
+	condition Condition
+}
+
+// AllowIf allows permission mode for role only when condition returns true for the checked record
+func (permission *Permission) AllowIf(mode PermissionMode, role string, condition Condition) *Permission {
+	if modes, ok := permission.Role.ModeGroup(mode); ok {
+		for _, m := range modes {
+			permission.AllowIf(m, role, condition)
+		}
+		return permission
+	}
+
+	if permission.conditions == nil {
+		permission.conditions = map[PermissionMode][]conditionalRole{}
+	}
+	permission.conditions[mode] = append(permission.conditions[mode], conditionalRole{role: role, condition: condition})
+	return permission
+}
+
+// ConditionalRoles returns the roles allowed under conditions, by permission mode
+func (permission *Permission) ConditionalRoles() map[PermissionMode][]string {
+	roles := map[PermissionMode][]string{}
+	for mode, conditionalRoles := range permission.conditions {
+		for _, conditionalRole := range conditionalRoles {
+			roles[mode] = append(roles[mode], conditionalRole.role)
+		}
+	}
+	return roles
+}
@@ -20,7 +20,10 @@ package roles
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
-import "net/http"
+import (
+	"io"
+	"net/http"
+)
 
 // Global global role instance
 var Global = &Role{}
@@ -30,6 +33,11 @@ func Register(name string, fc Checker) {
 	Global.Register(name, fc)
 }
 
+// Inherit declare role `name` includes all permissions of `parents`
+func Inherit(name string, parents ...string) {
+	Global.Inherit(name, parents...)
+}
+
 // Allow allows permission mode for roles
 func Allow(mode PermissionMode, roles ...string) *Permission {
 	return Global.Allow(mode, roles...)
@@ -40,6 +48,36 @@ func Deny(mode PermissionMode, roles ...string) *Permission {
 	return Global.Deny(mode, roles...)
 }
 
+// RegisterMode register customized permission modes for global role instance
+func RegisterMode(modes ...PermissionMode) {
+	Global.RegisterMode(modes...)
+}
+
+// RegisterModeGroup register a composite permission mode for global role instance
+func RegisterModeGroup(group PermissionMode, modes ...PermissionMode) {
+	Global.RegisterModeGroup(group, modes...)
+}
+
+// RegisterPolicy register permission with name for global role instance
+func RegisterPolicy(name string, permission *Permission) {
+	Global.RegisterPolicy(name, permission)
+}
+
+// Policy get registered policy from global role instance
+func Policy(name string) (*Permission, bool) {
+	return Global.Policy(name)
+}
+
+// DumpPolicies dump registered policies of global role instance as YAML
+func DumpPolicies() ([]byte, error) {
+	return Global.DumpPolicies()
+}
+
+// LoadPolicies load policies from JSON or YAML into global role instance
+func LoadPolicies(r io.Reader) error {
+	return Global.LoadPolicies(r)
+}
+
 // Get role defination
 func Get(name string) (Checker, bool) {
 	return Global.Get(name)
@@ -62,7 +100,7 @@ func MatchedRoles(req *http.Request, user interface{}) []string {
 
 // HasRole check if current user has role
 func HasRole(req *http.Request, user interface{}, roles ...string) bool {
-	return Global.HasRole(req, user)
+	return Global.HasRole(req, user, roles...)
 }
 
 // NewPermission initialize a new permission for default role
@@ -0,0 +1,144 @@
+package middleware
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/bhojpur/application/pkg/roles"
+)
+
+// ErrorCode error code of responses for denied requests
+const ErrorCode = "ERR_PERMISSION_DENIED"
+
+// RoleExtractor extract roles of current request, e.g. from a session or a token
+type RoleExtractor func(req *http.Request) []string
+
+// Routes declarative map of routes to the permission mode they require, keys are in format `METHOD /path` like `GET /products/*`,
+// method `*` matches any method, and a path ending with `*` matches all paths with that prefix
+type Routes map[string]roles.PermissionMode
+
+// ErrorResponse response body for denied requests
+type ErrorResponse struct {
+	ErrorCode string   `json:"errorCode"`
+	Message   string   `json:"message"`
+	Mode      string   `json:"mode"`
+	Roles     []string `json:"roles"`
+}
+
+type route struct {
+	method string
+	path   string
+	prefix bool
+	mode   roles.PermissionMode
+}
+
+// New return a middleware checking requests against permission, requests of routes not in routes are passed through
+func New(permission roles.Permissioner, extractor RoleExtractor, routes Routes) func(http.Handler) http.Handler {
+	compiled := compileRoutes(routes)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mode, ok := matchRoute(compiled, req)
+			if !ok {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			var matchedRoles []interface{}
+			requestRoles := extractor(req)
+			for _, role := range requestRoles {
+				matchedRoles = append(matchedRoles, role)
+			}
+
+			if permission.HasPermission(mode, matchedRoles...) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			WriteDenied(w, req, mode, requestRoles)
+		})
+	}
+}
+
+func compileRoutes(routes Routes) []route {
+	compiled := make([]route, 0, len(routes))
+	for key, mode := range routes {
+		r := route{method: "*", mode: mode}
+		if parts := strings.SplitN(strings.TrimSpace(key), " ", 2); len(parts) == 2 {
+			r.method, r.path = strings.ToUpper(parts[0]), strings.TrimSpace(parts[1])
+		} else {
+			r.path = parts[0]
+		}
+
+		if strings.HasSuffix(r.path, "*") {
+			r.prefix = true
+			r.path = strings.TrimSuffix(r.path, "*")
+		}
+		compiled = append(compiled, r)
+	}
+
+	// the most specific route wins: exact paths first, then longer prefixes, then routes with an explicit method
+	sort.SliceStable(compiled, func(i, j int) bool {
+		if compiled[i].prefix != compiled[j].prefix {
+			return !compiled[i].prefix
+		}
+		if len(compiled[i].path) != len(compiled[j].path) {
+			return len(compiled[i].path) > len(compiled[j].path)
+		}
+		return compiled[i].method != "*" && compiled[j].method == "*"
+	})
+	return compiled
+}
+
+func matchRoute(routes []route, req *http.Request) (roles.PermissionMode, bool) {
+	for _, r := range routes {
+		if r.method != "*" && r.method != req.Method {
+			continue
+		}
+
+		if (r.prefix && strings.HasPrefix(req.URL.Path, r.path)) || (!r.prefix && req.URL.Path == r.path) {
+			return r.mode, true
+		}
+	}
+	return "", false
+}
+
+func WriteDenied(w http.ResponseWriter, req *http.Request, mode roles.PermissionMode, requestRoles []string) {
+	if requestRoles == nil {
+		requestRoles = []string{}
+	}
+
+	body, _ := json.Marshal(ErrorResponse{
+		ErrorCode: ErrorCode,
+		Message:   fmt.Sprintf("%v: %v %v requires %v permission", roles.ErrPermissionDenied, req.Method, req.URL.Path, mode),
+		Mode:      string(mode),
+		Roles:     requestRoles,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	w.Write(body)
+}
@@ -0,0 +1,85 @@
+package middleware_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/pkg/roles/middleware"
+)
+
+func TestMiddleware(t *testing.T) {
+	permission := roles.Allow(roles.Read, "visitor", "admin").Allow(roles.Delete, "admin")
+	extractor := func(req *http.Request) []string {
+		if role := req.Header.Get("X-Role"); role != "" {
+			return strings.Split(role, ",")
+		}
+		return nil
+	}
+	handler := middleware.New(permission, extractor, middleware.Routes{
+		"GET /products/*":    roles.Read,
+		"DELETE /products/*": roles.Delete,
+		"* /admin":           roles.Delete,
+	})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		method string
+		path   string
+		role   string
+		status int
+	}{
+		{"GET", "/products/1", "visitor", http.StatusOK},
+		{"DELETE", "/products/1", "visitor", http.StatusForbidden},
+		{"DELETE", "/products/1", "admin", http.StatusOK},
+		{"POST", "/admin", "visitor", http.StatusForbidden},
+		{"POST", "/admin/users", "", http.StatusOK},
+		{"GET", "/health", "", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("X-Role", tc.role)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tc.status {
+			t.Errorf("%v %v as %v should return %v, got %v", tc.method, tc.path, tc.role, tc.status, w.Code)
+		}
+
+		if w.Code == http.StatusForbidden {
+			var resp middleware.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode error response: %v", err)
+			}
+
+			if resp.ErrorCode != middleware.ErrorCode || !strings.HasPrefix(resp.Message, roles.ErrPermissionDenied.Error()) {
+				t.Errorf("unexpected error response %#v", resp)
+			}
+		}
+	}
+}
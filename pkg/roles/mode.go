@@ -0,0 +1,82 @@
+package roles
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// crudModes predefined modes of mode group CRUD
+var crudModes = []PermissionMode{Create, Update, Read, Delete}
+
+// RegisterMode register customized permission modes, e.g. RegisterMode("export", "approve", "publish")
+func (role *Role) RegisterMode(modes ...PermissionMode) {
+	role.mutex.Lock()
+	defer role.mutex.Unlock()
+
+	if role.modes == nil {
+		role.modes = map[PermissionMode]bool{}
+	}
+
+	for _, mode := range modes {
+		role.modes[mode] = true
+	}
+}
+
+// RegisterModeGroup register a composite permission mode like CRUD, allowing or denying the group applies to all its modes
+func (role *Role) RegisterModeGroup(group PermissionMode, modes ...PermissionMode) {
+	role.mutex.Lock()
+	defer role.mutex.Unlock()
+
+	if role.modeGroups == nil {
+		role.modeGroups = map[PermissionMode][]PermissionMode{}
+	}
+	role.modeGroups[group] = modes
+}
+
+// ModeGroup return modes of a mode group, predefined mode CRUD is always a mode group
+func (role *Role) ModeGroup(group PermissionMode) ([]PermissionMode, bool) {
+	if role != nil {
+		role.mutex.RLock()
+		modes, ok := role.modeGroups[group]
+		role.mutex.RUnlock()
+
+		if ok {
+			return modes, true
+		}
+	}
+
+	if group == CRUD {
+		return crudModes, true
+	}
+	return nil, false
+}
+
+func (role *Role) isKnownMode(mode PermissionMode) bool {
+	switch mode {
+	case Create, Read, Update, Delete, CRUD, AnyMode:
+		return true
+	}
+
+	role.mutex.RLock()
+	defer role.mutex.RUnlock()
+
+	if _, ok := role.modeGroups[mode]; ok {
+		return true
+	}
+	return role.modes[mode]
+}
@@ -0,0 +1,93 @@
+package monitoring
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"strconv"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	diag_utils "github.com/bhojpur/application/pkg/diagnostics/utils"
+)
+
+var (
+	permissionCheckTotal = stats.Int64(
+		"roles/permission_check_total",
+		"The total number of permission checks.",
+		stats.UnitDimensionless)
+
+	permissionDeniedTotal = stats.Int64(
+		"roles/permission_denied_total",
+		"The total number of permissions denied to roles on resources.",
+		stats.UnitDimensionless)
+
+	modeKey     = tag.MustNewKey("mode")
+	allowedKey  = tag.MustNewKey("allowed")
+	resourceKey = tag.MustNewKey("resource")
+	roleKey     = tag.MustNewKey("role")
+)
+
+// noRole is the role label of permissions denied to requests matching no role.
+const noRole = "none"
+
+// RecordPermissionCheck records the result of a permission check.
+func RecordPermissionCheck(mode string, allowed bool) {
+	if !diag_utils.IsMetricsModuleEnabled(diag_utils.RolesMetricsModule) {
+		return
+	}
+
+	stats.RecordWithTags(
+		context.Background(),
+		diag_utils.WithTags(modeKey, mode, allowedKey, strconv.FormatBool(allowed)),
+		permissionCheckTotal.M(1))
+}
+
+// RecordPermissionDenied records a permission denied on a resource, once for each role of the request.
+func RecordPermissionDenied(resource, mode string, roles []string) {
+	if !diag_utils.IsMetricsModuleEnabled(diag_utils.RolesMetricsModule) {
+		return
+	}
+
+	if len(roles) == 0 {
+		roles = []string{noRole}
+	}
+	for _, role := range roles {
+		stats.RecordWithTags(
+			context.Background(),
+			diag_utils.WithTags(resourceKey, resource, modeKey, mode, roleKey, role),
+			permissionDeniedTotal.M(1))
+	}
+}
+
+// InitMetrics initialize the roles metrics, unless the roles metric group is disabled.
+func InitMetrics() error {
+	if !diag_utils.IsMetricsModuleEnabled(diag_utils.RolesMetricsModule) {
+		return nil
+	}
+
+	return view.Register(
+		diag_utils.NewMeasureView(permissionCheckTotal, []tag.Key{modeKey, allowedKey}, view.Count()),
+		diag_utils.NewMeasureView(permissionDeniedTotal, []tag.Key{resourceKey, modeKey, roleKey}, view.Count()),
+	)
+}
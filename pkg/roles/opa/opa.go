@@ -0,0 +1,226 @@
+package opa
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/open-policy-agent/opa/rego"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.roles.opa")
+
+// DefaultQuery is the query of the decisions, the policies allow a request by defining `allow` as true
+//
+//	package bhojpur.authz
+//
+//	default allow = false
+//
+//	allow {
+//	    input.resource == "Order"
+//	    input.mode == "read"
+//	    input.roles[_] == "sales"
+//	}
+const DefaultQuery = "data.bhojpur.authz.allow"
+
+// Input is the input of the policies
+type Input struct {
+	Resource string               `json:"resource"`
+	Mode     roles.PermissionMode `json:"mode"`
+	Roles    []string             `json:"roles"`
+	// Record is the record the permission is checked for, nil for the permission of the resource itself
+	Record interface{} `json:"record,omitempty"`
+	// Request is the HTTP request of the context, if any
+	Request *Request `json:"request,omitempty"`
+	// Context are the values of the context returned by the ContextInput func of the config
+	Context map[string]interface{} `json:"context,omitempty"`
+}
+
+// Request is the HTTP request exposed to the policies
+type Request struct {
+	Method  string              `json:"method"`
+	Path    string              `json:"path"`
+	Query   map[string][]string `json:"query,omitempty"`
+	Headers map[string][]string `json:"headers,omitempty"`
+}
+
+// Config configures the policy engine
+type Config struct {
+	// Query is the query of the decisions, DefaultQuery if empty
+	Query string
+	// Modules are the Rego policies by file name, like the ones returned by LoadDir or stored in a ConfigMap
+	Modules map[string]string
+	// ContextInput returns additional input of a context, like the tenant of the current user
+	ContextInput func(context *appsvr.Context) map[string]interface{}
+}
+
+// Engine is an embedded OPA engine deciding permissions with Rego policies, it implements roles.Authorizer,
+// set it as the Authorizer of resources to delegate their permission decisions to the policies
+type Engine struct {
+	config Config
+	query  rego.PreparedEvalQuery
+	lock   sync.RWMutex
+}
+
+// New compiles the policies of config and returns the engine
+func New(ctx context.Context, config Config) (*Engine, error) {
+	if config.Query == "" {
+		config.Query = DefaultQuery
+	}
+
+	engine := &Engine{config: config}
+	if err := engine.Load(ctx, config.Modules); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// Load compiles modules and replaces the policies of the engine, the current policies are kept if they don't compile
+func (engine *Engine) Load(ctx context.Context, modules map[string]string) error {
+	if len(modules) == 0 {
+		return errors.New("no policy to load")
+	}
+
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	options := []func(*rego.Rego){rego.Query(engine.config.Query)}
+	for _, name := range names {
+		options = append(options, rego.Module(name, modules[name]))
+	}
+
+	query, err := rego.New(options...).PrepareForEval(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to compile policies: %w", err)
+	}
+
+	engine.lock.Lock()
+	engine.query = query
+	engine.config.Modules = modules
+	engine.lock.Unlock()
+	return nil
+}
+
+// Allow evaluates the policies for input, the request is denied unless the query is true
+func (engine *Engine) Allow(ctx context.Context, input Input) (bool, error) {
+	engine.lock.RLock()
+	query := engine.query
+	engine.lock.RUnlock()
+
+	results, err := query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return false, err
+	}
+	return results.Allowed(), nil
+}
+
+// Authorize implements roles.Authorizer, evaluation errors deny the request
+func (engine *Engine) Authorize(resource string, mode roles.PermissionMode, record interface{}, appContext *appsvr.Context, roleNames []string) bool {
+	input := Input{Resource: resource, Mode: mode, Roles: roleNames, Record: record}
+	if input.Roles == nil {
+		input.Roles = []string{}
+	}
+
+	ctx := context.Background()
+	if appContext != nil {
+		if req := appContext.Request; req != nil {
+			ctx = req.Context()
+			input.Request = &Request{Method: req.Method, Path: req.URL.Path, Query: req.URL.Query(), Headers: req.Header}
+		}
+		if engine.config.ContextInput != nil {
+			input.Context = engine.config.ContextInput(appContext)
+		}
+	}
+
+	allowed, err := engine.Allow(ctx, input)
+	if err != nil {
+		log.Errorf("failed to evaluate policies for %s of %s: %s", mode, resource, err)
+		return false
+	}
+	return allowed
+}
+
+// Permissioner returns a roles.Permissioner of the resource deciding with the policies, for the APIs
+// checking a Permissioner, like the route annotations
+func (engine *Engine) Permissioner(resource string) roles.Permissioner {
+	return permissioner{engine: engine, resource: resource}
+}
+
+type permissioner struct {
+	engine   *Engine
+	resource string
+}
+
+func (p permissioner) HasPermission(mode roles.PermissionMode, values ...interface{}) bool {
+	var names []string
+	for _, value := range values {
+		switch role := value.(type) {
+		case string:
+			names = append(names, role)
+		case []string:
+			names = append(names, role...)
+		case []interface{}:
+			for _, r := range role {
+				names = append(names, fmt.Sprint(r))
+			}
+		default:
+			names = append(names, fmt.Sprint(role))
+		}
+	}
+	return p.engine.Authorize(p.resource, mode, nil, nil, names)
+}
+
+// LoadDir reads the Rego policies of dir, the files with the .rego extension
+func LoadDir(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := map[string]string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".rego") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		modules[path] = string(b)
+	}
+	return modules, nil
+}
@@ -0,0 +1,100 @@
+package opa_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/pkg/roles/opa"
+)
+
+const policy = `
+package bhojpur.authz
+
+default allow = false
+
+allow {
+	input.resource == "Order"
+	input.mode == "read"
+	input.roles[_] == "sales"
+}
+
+allow {
+	input.resource == "Order"
+	input.mode == "update"
+	input.record.Owner == input.context.user
+}
+`
+
+func TestEngine(t *testing.T) {
+	engine, err := opa.New(context.Background(), opa.Config{
+		Modules: map[string]string{"authz.rego": policy},
+		ContextInput: func(context *appsvr.Context) map[string]interface{} {
+			return map[string]interface{}{"user": context.Request.Header.Get("X-User")}
+		},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, engine.Authorize("Order", roles.Read, nil, nil, []string{"sales"}))
+	assert.False(t, engine.Authorize("Order", roles.Read, nil, nil, []string{"support"}))
+	assert.False(t, engine.Authorize("Product", roles.Read, nil, nil, []string{"sales"}))
+
+	req := httptest.NewRequest("PUT", "/orders/1", nil)
+	req.Header.Set("X-User", "jane")
+	appContext := &appsvr.Context{Request: req}
+	assert.True(t, engine.Authorize("Order", roles.Update, map[string]interface{}{"Owner": "jane"}, appContext, nil))
+	assert.False(t, engine.Authorize("Order", roles.Update, map[string]interface{}{"Owner": "john"}, appContext, nil))
+
+	permissioner := engine.Permissioner("Order")
+	assert.True(t, permissioner.HasPermission(roles.Read, "sales"))
+	assert.False(t, permissioner.HasPermission(roles.Read, "support"))
+}
+
+func TestLoad(t *testing.T) {
+	engine, err := opa.New(context.Background(), opa.Config{Modules: map[string]string{"authz.rego": policy}})
+	require.NoError(t, err)
+
+	assert.Error(t, engine.Load(context.Background(), map[string]string{"broken.rego": "package bhojpur.authz\nallow {"}))
+	assert.True(t, engine.Authorize("Order", roles.Read, nil, nil, []string{"sales"}), "the current policies are kept")
+
+	_, err = opa.New(context.Background(), opa.Config{})
+	assert.Error(t, err)
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "authz.rego"), []byte(policy), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("policies"), 0o600))
+
+	modules, err := opa.LoadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, modules, 1)
+	assert.Equal(t, policy, modules[filepath.Join(dir, "authz.rego")])
+}
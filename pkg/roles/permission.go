@@ -23,6 +23,10 @@ package roles
 import (
 	"errors"
 	"fmt"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
+	"github.com/bhojpur/application/pkg/roles/monitoring"
 )
 
 // PermissionMode permission mode
@@ -39,6 +43,8 @@ const (
 	Delete PermissionMode = "delete"
 	// CRUD predefined permission mode, create+read+update+delete permission
 	CRUD PermissionMode = "crud"
+	// AnyMode wildcard permission mode, matches all permission modes
+	AnyMode PermissionMode = "*"
 )
 
 // ErrPermissionDenied no permission error
@@ -49,9 +55,12 @@ type Permission struct {
 	Role         *Role
 	AllowedRoles map[PermissionMode][]string
 	DeniedRoles  map[PermissionMode][]string
+	conditions   map[PermissionMode][]conditionalRole
 }
 
-func includeRoles(roles []string, values []string) bool {
+func includeRoles(r *Role, roles []string, values []string) bool {
+	values = r.InheritedRoles(values...)
+
 	for _, role := range roles {
 		if role == Anyone {
 			return true
@@ -85,6 +94,13 @@ func (permission *Permission) Concat(newPermission *Permission) *Permission {
 			for mode, roles := range p.AllowedRoles {
 				result.AllowedRoles[mode] = append(result.AllowedRoles[mode], roles...)
 			}
+
+			for mode, conditions := range p.conditions {
+				if result.conditions == nil {
+					result.conditions = map[PermissionMode][]conditionalRole{}
+				}
+				result.conditions[mode] = append(result.conditions[mode], conditions...)
+			}
 		}
 	}
 
@@ -95,8 +111,11 @@ func (permission *Permission) Concat(newPermission *Permission) *Permission {
 
 // Allow allows permission mode for roles
 func (permission *Permission) Allow(mode PermissionMode, roles ...string) *Permission {
-	if mode == CRUD {
-		return permission.Allow(Create, roles...).Allow(Update, roles...).Allow(Read, roles...).Allow(Delete, roles...)
+	if modes, ok := permission.Role.ModeGroup(mode); ok {
+		for _, m := range modes {
+			permission.Allow(m, roles...)
+		}
+		return permission
 	}
 
 	if permission.AllowedRoles[mode] == nil {
@@ -108,8 +127,11 @@ func (permission *Permission) Allow(mode PermissionMode, roles ...string) *Permi
 
 // Deny deny permission mode for roles
 func (permission *Permission) Deny(mode PermissionMode, roles ...string) *Permission {
-	if mode == CRUD {
-		return permission.Deny(Create, roles...).Deny(Update, roles...).Deny(Read, roles...).Deny(Delete, roles...)
+	if modes, ok := permission.Role.ModeGroup(mode); ok {
+		for _, m := range modes {
+			permission.Deny(m, roles...)
+		}
+		return permission
 	}
 
 	if permission.DeniedRoles[mode] == nil {
@@ -119,8 +141,22 @@ func (permission *Permission) Deny(mode PermissionMode, roles ...string) *Permis
 	return permission
 }
 
-// HasPermission check roles has permission for mode or not
+// HasPermission check roles has permission for mode or not, conditional permissions defined with `AllowIf` never match without a record
 func (permission Permission) HasPermission(mode PermissionMode, roles ...interface{}) bool {
+	return permission.HasRecordPermission(mode, nil, nil, roles...)
+}
+
+// HasRecordPermission check roles has permission for mode on record or not, conditions defined with `AllowIf` are evaluated against the record
+func (permission Permission) HasRecordPermission(mode PermissionMode, record interface{}, context *appsvr.Context, roles ...interface{}) bool {
+	allowed := permission.hasRecordPermission(mode, record, context, roles...)
+	monitoring.RecordPermissionCheck(string(mode), allowed)
+	if log.Enabled(applog.DebugLevel) {
+		log.WithContext(context).Debug("permission checked", applog.String("mode", string(mode)), applog.Any("roles", roles), applog.Bool("allowed", allowed))
+	}
+	return allowed
+}
+
+func (permission Permission) hasRecordPermission(mode PermissionMode, record interface{}, context *appsvr.Context, roles ...interface{}) bool {
 	var roleNames []string
 	for _, role := range roles {
 		if r, ok := role.(string); ok {
@@ -128,27 +164,39 @@ func (permission Permission) HasPermission(mode PermissionMode, roles ...interfa
 		} else if roler, ok := role.(Roler); ok {
 			roleNames = append(roleNames, roler.GetRoles()...)
 		} else {
-			fmt.Printf("invalid role %#v\n", role)
+			log.WithContext(context).Warn("invalid role", applog.String("role", fmt.Sprintf("%#v", role)))
 			return false
 		}
 	}
 
 	if len(permission.DeniedRoles) != 0 {
-		if DeniedRoles := permission.DeniedRoles[mode]; DeniedRoles != nil {
-			if includeRoles(DeniedRoles, roleNames) {
-				return false
+		for _, m := range []PermissionMode{mode, AnyMode} {
+			if DeniedRoles := permission.DeniedRoles[m]; DeniedRoles != nil {
+				if includeRoles(permission.Role, DeniedRoles, roleNames) {
+					return false
+				}
 			}
 		}
 	}
 
 	// return true if haven't define allowed roles
-	if len(permission.AllowedRoles) == 0 {
+	if len(permission.AllowedRoles) == 0 && len(permission.conditions) == 0 {
 		return true
 	}
 
-	if AllowedRoles := permission.AllowedRoles[mode]; AllowedRoles != nil {
-		if includeRoles(AllowedRoles, roleNames) {
-			return true
+	for _, m := range []PermissionMode{mode, AnyMode} {
+		if AllowedRoles := permission.AllowedRoles[m]; AllowedRoles != nil {
+			if includeRoles(permission.Role, AllowedRoles, roleNames) {
+				return true
+			}
+		}
+
+		if record != nil {
+			for _, c := range permission.conditions[m] {
+				if includeRoles(permission.Role, []string{c.role}, roleNames) && c.condition(record, context) {
+					return true
+				}
+			}
 		}
 	}
 
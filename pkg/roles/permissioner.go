@@ -20,6 +20,14 @@ package roles
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
+import appsvr "github.com/bhojpur/application/pkg/engine"
+
+// Authorizer delegates the permission decisions of a resource to an external backend, like a policy engine,
+// record is nil when checking the permission of the resource itself
+type Authorizer interface {
+	Authorize(resource string, mode PermissionMode, record interface{}, context *appsvr.Context, roles []string) bool
+}
+
 // Permissioner permissioner interface
 type Permissioner interface {
 	HasPermission(mode PermissionMode, roles ...interface{}) bool
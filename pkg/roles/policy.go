@@ -0,0 +1,176 @@
+package roles
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// policy is the serialized form of a permission
+type policy struct {
+	Allow map[PermissionMode][]string `json:"allow,omitempty"`
+	Deny  map[PermissionMode][]string `json:"deny,omitempty"`
+}
+
+// MarshalJSON marshal permission's allowed and denied roles
+func (permission Permission) MarshalJSON() ([]byte, error) {
+	return json.Marshal(policy{Allow: permission.AllowedRoles, Deny: permission.DeniedRoles})
+}
+
+// UnmarshalJSON unmarshal allowed and denied roles into permission, mode groups like CRUD will be expanded
+func (permission *Permission) UnmarshalJSON(data []byte) error {
+	var p policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+
+	if permission.Role == nil {
+		permission.Role = Global
+	}
+	permission.AllowedRoles = map[PermissionMode][]string{}
+	permission.DeniedRoles = map[PermissionMode][]string{}
+	p.applyTo(permission)
+	return nil
+}
+
+func (p policy) applyTo(permission *Permission) {
+	for mode, roles := range p.Allow {
+		permission.Allow(mode, roles...)
+	}
+
+	for mode, roles := range p.Deny {
+		permission.Deny(mode, roles...)
+	}
+}
+
+// RegisterPolicy register permission with name, registered policies could be dumped and loaded
+func (role *Role) RegisterPolicy(name string, permission *Permission) {
+	role.mutex.Lock()
+	defer role.mutex.Unlock()
+
+	if role.policies == nil {
+		role.policies = map[string]*Permission{}
+	}
+	permission.Role = role
+	role.policies[name] = permission
+}
+
+// Policy get registered policy
+func (role *Role) Policy(name string) (*Permission, bool) {
+	role.mutex.RLock()
+	defer role.mutex.RUnlock()
+
+	permission, ok := role.policies[name]
+	return permission, ok
+}
+
+// DumpPolicies dump all registered policies as YAML
+func (role *Role) DumpPolicies() ([]byte, error) {
+	role.mutex.RLock()
+	defer role.mutex.RUnlock()
+
+	policies := role.policies
+	if policies == nil {
+		policies = map[string]*Permission{}
+	}
+	return yaml.Marshal(policies)
+}
+
+// LoadPolicies load policies from JSON or YAML and register them, existing policies with the same name will be overwritten.
+// Nothing is registered if any policy refers to an unknown permission mode or role
+func (role *Role) LoadPolicies(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var policies map[string]policy
+	if err := yaml.Unmarshal(data, &policies); err != nil {
+		return fmt.Errorf("failed to parse policies: %w", err)
+	}
+
+	var invalid []string
+	for name, p := range policies {
+		invalid = append(invalid, role.validatePolicy(name, p)...)
+	}
+
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return fmt.Errorf("invalid policies: %v", strings.Join(invalid, "; "))
+	}
+
+	for name, p := range policies {
+		permission := role.NewPermission()
+		p.applyTo(permission)
+		role.RegisterPolicy(name, permission)
+	}
+	return nil
+}
+
+func (role *Role) validatePolicy(name string, p policy) (invalid []string) {
+	for _, modeRoles := range []map[PermissionMode][]string{p.Allow, p.Deny} {
+		for mode, roles := range modeRoles {
+			if !role.isKnownMode(mode) {
+				invalid = append(invalid, fmt.Sprintf("policy `%v` has unknown permission mode `%v`", name, mode))
+			}
+
+			for _, r := range roles {
+				if !role.isKnownRole(r) {
+					invalid = append(invalid, fmt.Sprintf("policy `%v` has unknown role `%v`", name, r))
+				}
+			}
+		}
+	}
+	return
+}
+
+func (role *Role) isKnownRole(name string) bool {
+	if name == Anyone {
+		return true
+	}
+
+	role.mutex.RLock()
+	defer role.mutex.RUnlock()
+
+	if _, ok := role.definitions[name]; ok {
+		return true
+	}
+
+	if _, ok := role.inherits[name]; ok {
+		return true
+	}
+
+	for _, parents := range role.inherits {
+		for _, parent := range parents {
+			if parent == name {
+				return true
+			}
+		}
+	}
+	return false
+}
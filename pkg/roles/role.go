@@ -21,10 +21,15 @@ package roles
 // THE SOFTWARE.
 
 import (
-	"fmt"
 	"net/http"
+	"sort"
+	"sync"
+
+	applog "github.com/bhojpur/application/pkg/log"
 )
 
+var log = applog.New("app.roles")
+
 const (
 	// Anyone is a role for any one
 	Anyone = "*"
@@ -38,24 +43,94 @@ func New() *Role {
 	return &Role{}
 }
 
+// Registry is an isolated set of role definitions, it is safe for concurrent registration and removal
+type Registry = Role
+
+// NewRegistry initialize a new isolated role registry, e.g. for tests or tenants of a multi-tenant app
+func NewRegistry() *Registry {
+	return New()
+}
+
 // Role is a struct contains all roles definitions
 type Role struct {
+	mutex       sync.RWMutex
 	definitions map[string]Checker
+	inherits    map[string][]string
+	modes       map[PermissionMode]bool
+	modeGroups  map[PermissionMode][]PermissionMode
+	policies    map[string]*Permission
 }
 
 // Register register role with conditions
 func (role *Role) Register(name string, fc Checker) {
+	role.mutex.Lock()
+	defer role.mutex.Unlock()
+
 	if role.definitions == nil {
 		role.definitions = map[string]Checker{}
 	}
 
 	definition := role.definitions[name]
 	if definition != nil {
-		fmt.Printf("Role `%v` already defined, overwrited it!\n", name)
+		log.Warn("role already defined, overwrote it", applog.String("role", name))
 	}
 	role.definitions[name] = fc
 }
 
+// Inherit declare role `name` includes all permissions of `parents`, e.g. Inherit("admin", "editor")
+func (role *Role) Inherit(name string, parents ...string) {
+	role.mutex.Lock()
+	defer role.mutex.Unlock()
+
+	if role.inherits == nil {
+		role.inherits = map[string][]string{}
+	}
+
+	for _, parent := range parents {
+		if parent == name {
+			continue
+		}
+		role.inherits[name] = append(role.inherits[name], parent)
+	}
+}
+
+// InheritedRoles return roles and all roles they inherit, transitively
+func (role *Role) InheritedRoles(names ...string) []string {
+	if role == nil {
+		return names
+	}
+
+	role.mutex.RLock()
+	defer role.mutex.RUnlock()
+
+	if len(role.inherits) == 0 {
+		return names
+	}
+
+	var (
+		results []string
+		visited = map[string]bool{}
+		visit   func(name string)
+	)
+
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		results = append(results, name)
+
+		for _, parent := range role.inherits[name] {
+			visit(parent)
+		}
+	}
+
+	for _, name := range names {
+		visit(name)
+	}
+	return results
+}
+
 // NewPermission initialize permission
 func (role *Role) NewPermission() *Permission {
 	return &Permission{
@@ -77,27 +152,40 @@ func (role *Role) Deny(mode PermissionMode, roles ...string) *Permission {
 
 // Get role defination
 func (role *Role) Get(name string) (Checker, bool) {
+	role.mutex.RLock()
+	defer role.mutex.RUnlock()
+
 	fc, ok := role.definitions[name]
 	return fc, ok
 }
 
 // Remove role definition
 func (role *Role) Remove(name string) {
+	role.mutex.Lock()
+	defer role.mutex.Unlock()
+
 	delete(role.definitions, name)
+	delete(role.inherits, name)
 }
 
 // Reset role definitions
 func (role *Role) Reset() {
+	role.mutex.Lock()
+	defer role.mutex.Unlock()
+
 	role.definitions = map[string]Checker{}
+	role.inherits = map[string][]string{}
+	role.modes = map[PermissionMode]bool{}
+	role.modeGroups = map[PermissionMode][]PermissionMode{}
+	role.policies = map[string]*Permission{}
 }
 
 // MatchedRoles return defined roles from user
 func (role *Role) MatchedRoles(req *http.Request, user interface{}) (roles []string) {
-	if definitions := role.definitions; definitions != nil {
-		for name, definition := range definitions {
-			if definition(req, user) {
-				roles = append(roles, name)
-			}
+	// checkers are called without holding the lock, so they could use the registry too
+	for name, definition := range role.copyDefinitions() {
+		if definition(req, user) {
+			roles = append(roles, name)
 		}
 	}
 	return
@@ -105,14 +193,37 @@ func (role *Role) MatchedRoles(req *http.Request, user interface{}) (roles []str
 
 // HasRole check if current user has role
 func (role *Role) HasRole(req *http.Request, user interface{}, roles ...string) bool {
-	if definitions := role.definitions; definitions != nil {
-		for _, name := range roles {
-			if definition, ok := definitions[name]; ok {
-				if definition(req, user) {
-					return true
-				}
+	definitions := role.copyDefinitions()
+	for _, name := range roles {
+		if definition, ok := definitions[name]; ok {
+			if definition(req, user) {
+				return true
 			}
 		}
 	}
 	return false
 }
+
+// Names return names of defined roles
+func (role *Role) Names() []string {
+	role.mutex.RLock()
+	defer role.mutex.RUnlock()
+
+	names := make([]string, 0, len(role.definitions))
+	for name := range role.definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (role *Role) copyDefinitions() map[string]Checker {
+	role.mutex.RLock()
+	defer role.mutex.RUnlock()
+
+	definitions := make(map[string]Checker, len(role.definitions))
+	for name, definition := range role.definitions {
+		definitions[name] = definition
+	}
+	return definitions
+}
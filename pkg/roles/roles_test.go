@@ -21,8 +21,14 @@ package roles_test
 // THE SOFTWARE.
 
 import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 	"testing"
 
+	appsvr "github.com/bhojpur/application/pkg/engine"
 	"github.com/bhojpur/application/pkg/roles"
 )
 
@@ -133,3 +139,188 @@ func TestCustomizePermission(t *testing.T) {
 		t.Errorf("Admin should has no permission to Read")
 	}
 }
+
+func TestInherit(t *testing.T) {
+	defer roles.Reset()
+	roles.Inherit("admin", "editor")
+	roles.Inherit("editor", "viewer")
+
+	permission := roles.Allow(roles.Read, "viewer").Allow(roles.Update, "editor")
+
+	if !permission.HasPermission(roles.Read, "admin") {
+		t.Errorf("Admin should inherit Read permission from viewer")
+	}
+
+	if !permission.HasPermission(roles.Update, "admin") {
+		t.Errorf("Admin should inherit Update permission from editor")
+	}
+
+	if permission.HasPermission(roles.Update, "viewer") {
+		t.Errorf("Viewer should has no permission to Update")
+	}
+
+	permission2 := roles.Deny(roles.Delete, "viewer")
+
+	if permission2.HasPermission(roles.Delete, "admin") {
+		t.Errorf("Admin should inherit denied Delete permission from viewer")
+	}
+}
+
+func TestDumpAndLoadPolicies(t *testing.T) {
+	defer roles.Reset()
+	roles.Register("admin", func(req *http.Request, user interface{}) bool { return true })
+	roles.Register("visitor", func(req *http.Request, user interface{}) bool { return true })
+	roles.RegisterPolicy("product", roles.Allow(roles.Read, "visitor").Deny(roles.Delete, "visitor"))
+
+	data, err := roles.DumpPolicies()
+	if err != nil {
+		t.Fatalf("failed to dump policies: %v", err)
+	}
+
+	roles.Reset()
+	roles.Register("admin", func(req *http.Request, user interface{}) bool { return true })
+	roles.Register("visitor", func(req *http.Request, user interface{}) bool { return true })
+	if err := roles.LoadPolicies(bytes.NewReader(data)); err != nil {
+		t.Fatalf("failed to load policies: %v", err)
+	}
+
+	permission, ok := roles.Policy("product")
+	if !ok {
+		t.Fatalf("policy product should be loaded")
+	}
+
+	if !permission.HasPermission(roles.Read, "visitor") {
+		t.Errorf("Visitor should has permission to Read")
+	}
+
+	if permission.HasPermission(roles.Delete, "visitor") {
+		t.Errorf("Visitor should has no permission to Delete")
+	}
+
+	if err := roles.LoadPolicies(strings.NewReader(`{"order": {"allow": {"crud": ["admin"]}}}`)); err != nil {
+		t.Fatalf("failed to load JSON policies: %v", err)
+	}
+
+	if permission, _ := roles.Policy("order"); !permission.HasPermission(roles.Update, "admin") {
+		t.Errorf("Admin should has permission to Update")
+	}
+}
+
+func TestLoadInvalidPolicies(t *testing.T) {
+	defer roles.Reset()
+	roles.Register("admin", func(req *http.Request, user interface{}) bool { return true })
+
+	if err := roles.LoadPolicies(strings.NewReader("product:\n  allow:\n    publish: [admin]\n")); err == nil {
+		t.Errorf("unknown permission mode should be rejected")
+	}
+
+	if err := roles.LoadPolicies(strings.NewReader("product:\n  allow:\n    read: [manager]\n")); err == nil {
+		t.Errorf("unknown role should be rejected")
+	}
+
+	if _, ok := roles.Policy("product"); ok {
+		t.Errorf("invalid policy should not be registered")
+	}
+
+	roles.RegisterMode("publish")
+	if err := roles.LoadPolicies(strings.NewReader("product:\n  allow:\n    publish: [admin]\n")); err != nil {
+		t.Errorf("registered permission mode should be accepted, got %v", err)
+	}
+}
+
+type product struct {
+	Department string
+}
+
+func TestAllowIf(t *testing.T) {
+	sameDepartment := func(record interface{}, context *appsvr.Context) bool {
+		return record.(*product).Department == "books"
+	}
+	permission := roles.Allow(roles.Read, "editor").AllowIf(roles.Update, "editor", sameDepartment)
+
+	if !permission.HasRecordPermission(roles.Update, &product{Department: "books"}, &appsvr.Context{}, "editor") {
+		t.Errorf("Editor should has permission to Update records of own department")
+	}
+
+	if permission.HasRecordPermission(roles.Update, &product{Department: "music"}, &appsvr.Context{}, "editor") {
+		t.Errorf("Editor should has no permission to Update records of other departments")
+	}
+
+	if permission.HasPermission(roles.Update, "editor") {
+		t.Errorf("Editor should has no permission to Update without a record")
+	}
+
+	if !permission.HasRecordPermission(roles.Read, &product{Department: "music"}, &appsvr.Context{}, "editor") {
+		t.Errorf("Editor should has permission to Read")
+	}
+
+	if permission.HasRecordPermission(roles.Update, &product{Department: "books"}, &appsvr.Context{}, "viewer") {
+		t.Errorf("Viewer should has no permission to Update")
+	}
+
+	if conditional := permission.ConditionalRoles(); len(conditional) != 1 || len(conditional[roles.Update]) != 1 || conditional[roles.Update][0] != "editor" {
+		t.Errorf("Editor should be the conditional role of Update, got %v", conditional)
+	}
+}
+
+func TestWildcardAndModeGroups(t *testing.T) {
+	defer roles.Reset()
+	var (
+		approve roles.PermissionMode = "approve"
+		publish roles.PermissionMode = "publish"
+		review  roles.PermissionMode = "review"
+	)
+	roles.RegisterMode(approve, publish)
+	roles.RegisterModeGroup(review, roles.Read, approve)
+
+	permission := roles.Allow(roles.AnyMode, "admin").Allow(review, "editor").Deny(roles.AnyMode, "banned")
+
+	if !permission.HasPermission(publish, "admin") {
+		t.Errorf("Admin should has permission to Publish with wildcard grant")
+	}
+
+	if !permission.HasPermission(approve, "editor") || !permission.HasPermission(roles.Read, "editor") {
+		t.Errorf("Editor should has permission to Read and Approve with review group")
+	}
+
+	if permission.HasPermission(publish, "editor") {
+		t.Errorf("Editor should has no permission to Publish")
+	}
+
+	if permission.HasPermission(roles.Read, "banned") {
+		t.Errorf("Banned should has no permission to Read with wildcard deny")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := roles.NewRegistry()
+	anyone := func(req *http.Request, user interface{}) bool { return true }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("role%v", i)
+			registry.Register(name, anyone)
+			registry.MatchedRoles(&http.Request{}, nil)
+			if i%2 == 0 {
+				registry.Remove(name)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if names := registry.Names(); len(names) != 25 {
+		t.Errorf("registry should has 25 roles, got %v", len(names))
+	}
+
+	if _, ok := roles.Get("role1"); ok {
+		t.Errorf("roles of a registry should not be registered globally")
+	}
+
+	registry.Reset()
+	if names := registry.Names(); len(names) != 0 {
+		t.Errorf("registry should has no roles after reset, got %v", names)
+	}
+}
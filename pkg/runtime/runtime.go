@@ -64,9 +64,10 @@ import (
 	"github.com/bhojpur/service/pkg/state"
 	"github.com/bhojpur/service/pkg/utils/logger"
 
-	"github.com/bhojpur/application/pkg/actors"
 	operatorv1pb "github.com/bhojpur/api/pkg/core/v1/operator"
 	runtimev1pb "github.com/bhojpur/api/pkg/core/v1/runtime"
+	"github.com/bhojpur/application/pkg/acl"
+	"github.com/bhojpur/application/pkg/actors"
 	"github.com/bhojpur/application/pkg/channel"
 	http_channel "github.com/bhojpur/application/pkg/channel/http"
 	"github.com/bhojpur/application/pkg/components"
@@ -83,6 +84,7 @@ import (
 	"github.com/bhojpur/application/pkg/grpc"
 	"github.com/bhojpur/application/pkg/http"
 	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+	applog "github.com/bhojpur/application/pkg/log"
 	"github.com/bhojpur/application/pkg/messaging"
 	invokev1 "github.com/bhojpur/application/pkg/messaging/v1"
 	http_middleware "github.com/bhojpur/application/pkg/middleware/http"
@@ -113,7 +115,9 @@ const (
 	configurationComponent          ComponentCategory = "configuration"
 	defaultComponentInitTimeout                       = time.Second * 5
 	defaultGracefulShutdownDuration                   = time.Second * 5
+	defaultShutdownHooksTimeout                       = time.Second * 10
 	kubernetesSecretStore                             = "kubernetes"
+	configurationWatchInterval                        = time.Second * 30
 )
 
 var componentCategoriesNeedProcess = []ComponentCategory{
@@ -127,6 +131,9 @@ var componentCategoriesNeedProcess = []ComponentCategory{
 
 var log = logger.NewLogger("app.runtime")
 
+// componentLog is the structured log of loading components
+var componentLog = applog.New("app.runtime.components")
+
 // ErrUnexpectedEnvelopeData denotes that an unexpected data type
 // was encountered when processing a cloud event's data property.
 var ErrUnexpectedEnvelopeData = errors.New("unexpected data type encountered in envelope")
@@ -181,6 +188,8 @@ type AppRuntime struct {
 	inputBindingRoutes     map[string]string
 	shutdownC              chan error
 	apiClosers             []io.Closer
+	shutdownHooks          []shutdownHook
+	shutdownHooksLock      sync.Mutex
 
 	secretsConfiguration map[string]config.SecretsScope
 
@@ -189,6 +198,7 @@ type AppRuntime struct {
 
 	pendingComponents          chan components_v1alpha1.Component
 	pendingComponentDependents map[string][]components_v1alpha1.Component
+	initializedComponents      map[string]bool
 
 	proxy messaging.Proxy
 
@@ -254,6 +264,7 @@ func NewAppRuntime(runtimeConfig *Config, globalConfig *config.Configuration, ac
 
 		pendingComponents:          make(chan components_v1alpha1.Component),
 		pendingComponentDependents: map[string][]components_v1alpha1.Component{},
+		initializedComponents:      map[string]bool{},
 		shutdownC:                  make(chan error, 1),
 	}
 }
@@ -329,6 +340,12 @@ func (a *AppRuntime) setupTracing(hostAddress string, exporters traceExporterSto
 func (a *AppRuntime) initRuntime(opts *runtimeOpts) error {
 	// Initialize metrics only if MetricSpec is enabled.
 	if a.globalConfig.Spec.MetricSpec.Enabled {
+		if a.globalConfig.Spec.MetricSpec.HighCardinalityLabels {
+			diag_utils.SetHighCardinalityLabels(true)
+		}
+		for module, enabled := range a.globalConfig.Spec.MetricSpec.Modules {
+			diag_utils.SetMetricsModuleEnabled(module, enabled)
+		}
 		if err := diag.InitMetrics(a.runtimeConfig.ID); err != nil {
 			log.Errorf("failed to initialize Bhojpur Application runtime metrics: %v", err)
 		}
@@ -373,7 +390,10 @@ func (a *AppRuntime) initRuntime(opts *runtimeOpts) error {
 	}
 	a.appendBuiltinSecretStore()
 	err = a.loadComponents(opts)
-	if err != nil {
+	var report *config.ValidationReport
+	if errors.As(err, &report) {
+		return err
+	} else if err != nil {
 		log.Warnf("failed to load Bhojpur Application runtime components: %s", err)
 	}
 
@@ -435,6 +455,7 @@ func (a *AppRuntime) initRuntime(opts *runtimeOpts) error {
 	grpcAPI.SetAppChannel(a.appChannel)
 
 	a.loadAppConfiguration()
+	a.watchConfiguration(grpcAPI)
 
 	a.initDirectMessaging(a.nameResolver)
 
@@ -685,7 +706,11 @@ func (a *AppRuntime) initProxy() {
 }
 
 func (a *AppRuntime) beginComponentsUpdates() error {
-	if a.runtimeConfig.Mode != utils.KubernetesMode {
+	switch a.runtimeConfig.Mode {
+	case utils.StandaloneMode:
+		return a.beginStandaloneComponentsUpdates()
+	case utils.KubernetesMode:
+	default:
 		return nil
 	}
 
@@ -697,16 +722,7 @@ func (a *AppRuntime) beginComponentsUpdates() error {
 				return
 			}
 
-			if !a.isComponentAuthorized(component) {
-				log.Debugf("received unauthorized component update, ignored. name: %s, type: %s/%s", component.ObjectMeta.Name, component.Spec.Type, component.Spec.Version)
-				return
-			}
-
-			log.Debugf("received component update. name: %s, type: %s/%s", component.ObjectMeta.Name, component.Spec.Type, component.Spec.Version)
-			updated := a.onComponentUpdated(component)
-			if !updated {
-				log.Info("component update skipped: .spec field unchanged")
-			}
+			a.receiveComponentUpdate(component)
 		}
 
 		needList := false
@@ -766,6 +782,80 @@ func (a *AppRuntime) beginComponentsUpdates() error {
 	return nil
 }
 
+// beginStandaloneComponentsUpdates hot-reloads the component manifests of the standalone components directory.
+func (a *AppRuntime) beginStandaloneComponentsUpdates() error {
+	if a.runtimeConfig.Standalone.ComponentsPath == "" {
+		return nil
+	}
+
+	loader := components.NewStandaloneComponents(a.runtimeConfig.Standalone)
+	go func() {
+		err := loader.WatchComponents(context.Background(), components.ComponentHandlerFunc(a.receiveComponentUpdate))
+		if err != nil {
+			log.Warnf("stopped watching Bhojpur Application runtime components in %s: %s", a.runtimeConfig.Standalone.ComponentsPath, err)
+		}
+	}()
+	return nil
+}
+
+func (a *AppRuntime) receiveComponentUpdate(component components_v1alpha1.Component) {
+	if !a.isComponentAuthorized(component) {
+		log.Debugf("received unauthorized component update, ignored. name: %s, type: %s/%s", component.ObjectMeta.Name, component.Spec.Type, component.Spec.Version)
+		return
+	}
+
+	log.Debugf("received component update. name: %s, type: %s/%s", component.ObjectMeta.Name, component.Spec.Type, component.Spec.Version)
+	updated := a.onComponentUpdated(component)
+	if !updated {
+		log.Info("component update skipped: .spec field unchanged")
+	}
+}
+
+// watchConfiguration applies the updates of the global configuration. Access control policies are applied live,
+// the other settings take effect on the next restart.
+func (a *AppRuntime) watchConfiguration(api grpc.API) {
+	if a.runtimeConfig.GlobalConfig == "" {
+		return
+	}
+
+	var load config.ConfigurationLoader
+	switch a.runtimeConfig.Mode {
+	case utils.KubernetesMode:
+		load = config.KubernetesConfigurationLoader(a.runtimeConfig.GlobalConfig, a.namespace, a.podName, a.operatorClient)
+	case utils.StandaloneMode:
+		load = config.StandaloneConfigurationLoader(a.runtimeConfig.GlobalConfig)
+	default:
+		return
+	}
+
+	go config.WatchConfiguration(context.Background(), a.globalConfig, load, configurationWatchInterval,
+		func(conf *config.Configuration) {
+			a.onConfigurationUpdated(conf, api)
+		},
+		func(err error) {
+			log.Warnf("failed to reload configuration %s: %s", a.runtimeConfig.GlobalConfig, err)
+		})
+}
+
+func (a *AppRuntime) onConfigurationUpdated(conf *config.Configuration, api grpc.API) {
+	if !reflect.DeepEqual(a.globalConfig.Spec.AccessControlSpec, conf.Spec.AccessControlSpec) {
+		accessControlList, err := acl.ParseAccessControlSpec(conf.Spec.AccessControlSpec, string(a.runtimeConfig.ApplicationProtocol))
+		if err != nil {
+			log.Errorf("failed to apply access control policies of configuration %s: %s", a.runtimeConfig.GlobalConfig, err)
+		} else {
+			api.SetAccessControlList(accessControlList)
+			a.proxy.SetAccessControlList(accessControlList)
+			log.Infof("applied access control policies of configuration %s", a.runtimeConfig.GlobalConfig)
+		}
+	}
+
+	spec, updated := a.globalConfig.Spec, conf.Spec
+	spec.AccessControlSpec, updated.AccessControlSpec = config.AccessControlSpec{}, config.AccessControlSpec{}
+	if !reflect.DeepEqual(spec, updated) {
+		log.Warnf("configuration %s was updated, restart to apply the changes other than access control policies", a.runtimeConfig.GlobalConfig)
+	}
+}
+
 func (a *AppRuntime) onComponentUpdated(component components_v1alpha1.Component) bool {
 	oldComp, exists := a.getComponent(component.Spec.Type, component.Name)
 	newComp, _ := a.processComponentSecrets(component)
@@ -1656,10 +1746,16 @@ func (a *AppRuntime) initActors() error {
 	if a.actorStateStoreName == "" {
 		return errors.New("no Bhojpur Application runtime actor state store defined")
 	}
+	if max := a.globalConfig.Spec.QuotaSpec.MaxActorTypes; max > 0 && len(a.appConfig.Entities) > max {
+		return errors.Errorf("actor type quota exceeded: app hosts %d actor types, the maximum is %d", len(a.appConfig.Entities), max)
+	}
 	actorConfig := actors.NewConfig(a.hostAddress, a.runtimeConfig.ID, a.runtimeConfig.PlacementAddresses, a.runtimeConfig.InternalGRPCPort, a.namespace, a.appConfig)
 	act := actors.NewActors(a.stateStores[a.actorStateStoreName], a.appChannel, a.grpc.GetGRPCConnection, actorConfig, a.runtimeConfig.CertChain, a.globalConfig.Spec.TracingSpec, a.globalConfig.Spec.Features)
 	err = act.Init()
 	a.actor = act
+	if err == nil {
+		a.RegisterShutdownHook("actors", act.Checkpoint)
+	}
 	return err
 }
 
@@ -1712,7 +1808,17 @@ func (a *AppRuntime) loadComponents(opts *runtimeOpts) error {
 		log.Debugf("found Bhojpur Application runtime component. name: %s, type: %s/%s", comp.ObjectMeta.Name, comp.Spec.Type, comp.Spec.Version)
 	}
 
-	authorizedComps := a.getAuthorizedComponents(comps)
+	authorizedComps, err := components.SortByDependencies(a.getAuthorizedComponents(comps))
+	if err != nil {
+		return err
+	}
+
+	report := a.validateConfiguration(authorizedComps)
+	if err = report.Err(); err != nil {
+		return err
+	} else if len(report.Issues) > 0 {
+		log.Warn(report.String())
+	}
 
 	a.componentsLock.Lock()
 	a.components = make([]components_v1alpha1.Component, len(authorizedComps))
@@ -1761,6 +1867,7 @@ func (a *AppRuntime) processComponents() {
 
 		err := a.processComponentAndDependents(comp)
 		if err != nil {
+			diag.DefaultMonitoring.ComponentLoadFailed(comp.Spec.Type)
 			e := fmt.Sprintf("process Bhojpur Application runtime component %s error: %s", comp.Name, err.Error())
 			if !comp.Spec.IgnoreErrors {
 				log.Warnf("process component error Bhojpur Application runtime process will exited, gracefully to stop")
@@ -1781,7 +1888,9 @@ func (a *AppRuntime) flushOutstandingComponents() {
 }
 
 func (a *AppRuntime) processComponentAndDependents(comp components_v1alpha1.Component) error {
-	log.Debugf("loading Bhojpur Application runtime component. name: %s, type: %s/%s", comp.ObjectMeta.Name, comp.Spec.Type, comp.Spec.Version)
+	start := time.Now()
+	compLog := componentLog.With(applog.String("component", comp.ObjectMeta.Name), applog.String("type", comp.Spec.Type+"/"+comp.Spec.Version))
+	compLog.Debug("loading Bhojpur Application runtime component")
 	res := a.preprocessOneComponent(&comp)
 	if res.unreadyDependency != "" {
 		a.pendingComponentDependents[res.unreadyDependency] = append(a.pendingComponentDependents[res.unreadyDependency], comp)
@@ -1794,6 +1903,23 @@ func (a *AppRuntime) processComponentAndDependents(comp components_v1alpha1.Comp
 		return errors.Errorf("incorrect type %s", comp.Spec.Type)
 	}
 
+	// a component updated after it was initialized is reloaded: the running instance is closed
+	// and a new one is initialized with the updated metadata
+	_, reload := a.getComponent(comp.Spec.Type, comp.Name)
+	reload = reload && a.isComponentInitialized(comp.Name)
+	if reload && compCategory == stateComponent && a.isActorStateStore(comp.Name) {
+		// the actors keep the store they were created with, they would use the closed instance after a reload
+		compLog.Warn("the actor state store is not reloaded, restart the runtime to apply its update")
+		diag.DefaultMonitoring.ComponentReloadFailed(comp.Spec.Type, "actorStateStore")
+		return nil
+	}
+	if reload {
+		compLog.Info("reloading Bhojpur Application runtime component")
+		if err := a.closeComponent(compCategory, comp.Name); err != nil {
+			compLog.Warn("error closing Bhojpur Application runtime component before reload", applog.Err(err))
+		}
+	}
+
 	ch := make(chan error, 1)
 
 	timeout, err := time.ParseDuration(comp.Spec.InitTimeout)
@@ -1808,22 +1934,40 @@ func (a *AppRuntime) processComponentAndDependents(comp components_v1alpha1.Comp
 	select {
 	case err := <-ch:
 		if err != nil {
+			if reload {
+				return a.onComponentReloadFailed(comp, "init", err)
+			}
 			return err
 		}
 	case <-time.After(timeout):
-		return fmt.Errorf("init timeout for Bhojpur Application runtime component %s exceeded after %s", comp.Name, timeout.String())
+		err := fmt.Errorf("init timeout for Bhojpur Application runtime component %s exceeded after %s", comp.Name, timeout.String())
+		if reload {
+			return a.onComponentReloadFailed(comp, "timeout", err)
+		}
+		return err
+	}
+
+	if reload {
+		a.restartComponentConsumers(compCategory, comp.Name)
+		compLog.Info("Bhojpur Application runtime component reloaded", applog.Duration(time.Since(start)))
+		diag.DefaultMonitoring.ComponentReloaded(comp.Spec.Type)
 	}
 
-	log.Infof("Bhojpur Application runtime component loaded. name: %s, type: %s/%s", comp.ObjectMeta.Name, comp.Spec.Type, comp.Spec.Version)
+	compLog.Info("Bhojpur Application runtime component loaded", applog.Duration(time.Since(start)))
 	a.appendOrReplaceComponents(comp)
+	a.componentsLock.Lock()
+	a.initializedComponents[comp.Name] = true
+	a.componentsLock.Unlock()
 	diag.DefaultMonitoring.ComponentLoaded()
 
-	dependency := componentDependency(compCategory, comp.Name)
-	if deps, ok := a.pendingComponentDependents[dependency]; ok {
-		delete(a.pendingComponentDependents, dependency)
-		for _, dependent := range deps {
-			if err := a.processComponentAndDependents(dependent); err != nil {
-				return err
+	// dependents wait either on the category of a secret store or on the name declared in dependsOn
+	for _, dependency := range []string{componentDependency(compCategory, comp.Name), comp.Name} {
+		if deps, ok := a.pendingComponentDependents[dependency]; ok {
+			delete(a.pendingComponentDependents, dependency)
+			for _, dependent := range deps {
+				if err := a.processComponentAndDependents(dependent); err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -1831,6 +1975,110 @@ func (a *AppRuntime) processComponentAndDependents(comp components_v1alpha1.Comp
 	return nil
 }
 
+// onComponentReloadFailed reports a failed reload. The component stays unloaded until its next update,
+// the runtime keeps running since the failure is caused by an update of a running application.
+func (a *AppRuntime) onComponentReloadFailed(comp components_v1alpha1.Component, reason string, err error) error {
+	componentLog.Error("failed to reload Bhojpur Application runtime component", applog.String("component", comp.ObjectMeta.Name),
+		applog.String("type", comp.Spec.Type+"/"+comp.Spec.Version), applog.String("reason", reason), applog.Err(err))
+	diag.DefaultMonitoring.ComponentReloadFailed(comp.Spec.Type, reason)
+	a.componentsLock.Lock()
+	delete(a.initializedComponents, comp.Name)
+	a.componentsLock.Unlock()
+	return nil
+}
+
+func (a *AppRuntime) isComponentInitialized(name string) bool {
+	a.componentsLock.RLock()
+	defer a.componentsLock.RUnlock()
+	return a.initializedComponents[name]
+}
+
+func (a *AppRuntime) isActorStateStore(name string) bool {
+	a.actorStateStoreLock.RLock()
+	defer a.actorStateStoreLock.RUnlock()
+	return a.actorStateStoreName == name
+}
+
+// closeComponent closes and removes the running instance of a component.
+func (a *AppRuntime) closeComponent(category ComponentCategory, name string) error {
+	// the instances are removed under the lock and closed after it is released, closing may block
+	var instances []interface{}
+	a.componentsLock.Lock()
+	switch category {
+	case bindingsComponent:
+		if binding, ok := a.inputBindings[name]; ok {
+			instances = append(instances, binding)
+			delete(a.inputBindings, name)
+		}
+		if binding, ok := a.outputBindings[name]; ok {
+			instances = append(instances, binding)
+			delete(a.outputBindings, name)
+		}
+	case pubsubComponent:
+		if pubSub, ok := a.pubSubs[name]; ok {
+			instances = append(instances, pubSub)
+			delete(a.pubSubs, name)
+		}
+	case secretStoreComponent:
+		if store, ok := a.secretStores[name]; ok {
+			instances = append(instances, store)
+			delete(a.secretStores, name)
+		}
+	case stateComponent:
+		if store, ok := a.stateStores[name]; ok {
+			instances = append(instances, store)
+			delete(a.stateStores, name)
+		}
+	case configurationComponent:
+		if store, ok := a.configurationStores[name]; ok {
+			instances = append(instances, store)
+			delete(a.configurationStores, name)
+		}
+	}
+	a.componentsLock.Unlock()
+
+	var merr error
+	for _, instance := range instances {
+		if closer, ok := instance.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				merr = multierror.Append(merr, err)
+			}
+		}
+	}
+	return merr
+}
+
+// restartComponentConsumers resumes the subscriptions and binding reads of a reloaded component,
+// they are only started once the app channel is ready.
+func (a *AppRuntime) restartComponentConsumers(category ComponentCategory, name string) {
+	if a.appChannel == nil {
+		return
+	}
+
+	switch category {
+	case pubsubComponent:
+		a.componentsLock.RLock()
+		pubSub, ok := a.pubSubs[name]
+		a.componentsLock.RUnlock()
+		if ok {
+			if err := a.beginPubSub(name, pubSub); err != nil {
+				log.Errorf("error occurred while beginning pubsub %s: %s", name, err)
+			}
+		}
+	case bindingsComponent:
+		a.componentsLock.RLock()
+		binding, ok := a.inputBindings[name]
+		a.componentsLock.RUnlock()
+		if ok && a.isAppSubscribedToBinding(name) {
+			go func() {
+				if err := a.readFromBinding(name, binding); err != nil {
+					log.Errorf("error reading from input binding %s: %s", name, err)
+				}
+			}()
+		}
+	}
+}
+
 func (a *AppRuntime) doProcessOneComponent(category ComponentCategory, comp components_v1alpha1.Component) error {
 	switch category {
 	case bindingsComponent:
@@ -1848,6 +2096,15 @@ func (a *AppRuntime) doProcessOneComponent(category ComponentCategory, comp comp
 }
 
 func (a *AppRuntime) preprocessOneComponent(comp *components_v1alpha1.Component) componentPreprocessRes {
+	for _, dependency := range comp.Spec.DependsOn {
+		if !a.isComponentInitialized(dependency) {
+			log.Infof("Bhojpur Application runtime component %s is waiting for dependency %s to be ready", comp.Name, dependency)
+			return componentPreprocessRes{
+				unreadyDependency: dependency,
+			}
+		}
+	}
+
 	var unreadySecretsStore string
 	*comp, unreadySecretsStore = a.processComponentSecrets(*comp)
 	if unreadySecretsStore != "" {
@@ -1953,6 +2210,7 @@ func (a *AppRuntime) Shutdown(duration time.Duration) {
 	}
 	log.Infof("Waiting %s to finish outstanding operations", duration)
 	<-time.After(duration)
+	a.runShutdownHooks(defaultShutdownHooksTimeout)
 	a.shutdownComponents()
 	a.shutdownC <- nil
 }
@@ -1964,6 +2222,15 @@ func (a *AppRuntime) WaitUntilShutdown() error {
 func (a *AppRuntime) processComponentSecrets(component components_v1alpha1.Component) (components_v1alpha1.Component, string) {
 	cache := map[string]secretstores.GetSecretResponse{}
 
+	if a.runtimeConfig.Mode != utils.KubernetesMode && components.UsesKubernetesSecrets(component) && a.getSecretStore(a.authSecretStoreOrDefault(component)) == nil {
+		// Outside of a cluster, references to Kubernetes Secrets are resolved from environment variables.
+		err := components.ResolveSecretKeyRefs(context.TODO(), []*components_v1alpha1.Component{&component}, a.namespace, components.EnvSecretResolver{}, components.PlainSecretValue)
+		if err != nil {
+			log.Errorf("error resolving secrets of component %s from environment variables: %s", component.Name, err)
+		}
+		return component, ""
+	}
+
 	for i, m := range component.Spec.Metadata {
 		if m.SecretKeyRef.Name == "" {
 			continue
@@ -868,6 +868,85 @@ func TestMetadataUUID(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestReloadComponent(t *testing.T) {
+	rt := NewTestAppRuntime(utils.StandaloneMode)
+	defer stopRuntime(t, rt)
+
+	instances := []*appt.MockPubSub{new(appt.MockPubSub), new(appt.MockPubSub), new(appt.MockPubSub)}
+	created := 0
+	rt.pubSubRegistry.Register(
+		pubsub_loader.New("mockPubSub", func() pubsub.PubSub {
+			created++
+			return instances[created-1]
+		}),
+	)
+	instances[0].On("Init", mock.Anything).Return(nil)
+	instances[1].On("Init", mock.Anything).Return(nil)
+	instances[2].On("Init", mock.Anything).Return(assert.AnError)
+
+	comp := components_v1alpha1.Component{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: TestPubsubName,
+		},
+		Spec: components_v1alpha1.ComponentSpec{
+			Type:     "pubsub.mockPubSub",
+			Version:  "v1",
+			Metadata: getFakeMetadataItems(),
+		},
+	}
+
+	require.NoError(t, rt.processComponentAndDependents(comp))
+	assert.Equal(t, instances[0], rt.pubSubs[TestPubsubName])
+
+	t.Run("updated component is re-initialized", func(t *testing.T) {
+		require.NoError(t, rt.processComponentAndDependents(comp))
+		assert.Equal(t, instances[1], rt.pubSubs[TestPubsubName])
+		instances[1].AssertNumberOfCalls(t, "Init", 1)
+	})
+
+	t.Run("failed reload keeps the runtime running", func(t *testing.T) {
+		assert.NoError(t, rt.processComponentAndDependents(comp))
+		assert.NotContains(t, rt.pubSubs, TestPubsubName)
+		assert.False(t, rt.initializedComponents[TestPubsubName])
+	})
+
+	t.Run("actor state store is not reloaded", func(t *testing.T) {
+		store := new(appt.MockStateStore)
+		store.On("Init", mock.Anything).Return(nil)
+		rt.stateStoreRegistry.Register(
+			state_loader.New("mockState", func() state.Store {
+				return store
+			}),
+		)
+
+		stateComp := components_v1alpha1.Component{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name: "actorStore",
+			},
+			Spec: components_v1alpha1.ComponentSpec{
+				Type:    "state.mockState",
+				Version: "v1",
+				Metadata: []components_v1alpha1.MetadataItem{
+					{
+						Name: actorStateStore,
+						Value: components_v1alpha1.DynamicValue{
+							JSON: v1.JSON{Raw: []byte("true")},
+						},
+					},
+				},
+			},
+		}
+
+		require.NoError(t, rt.processComponentAndDependents(stateComp))
+		require.Equal(t, "actorStore", rt.actorStateStoreName)
+
+		require.NoError(t, rt.processComponentAndDependents(stateComp))
+		assert.Equal(t, store, rt.stateStores["actorStore"])
+		assert.True(t, rt.initializedComponents["actorStore"])
+		store.AssertNumberOfCalls(t, "Init", 1)
+	})
+}
+
 func TestOnComponentUpdated(t *testing.T) {
 	t.Run("component spec changed, component is updated", func(t *testing.T) {
 		rt := NewTestAppRuntime(utils.KubernetesMode)
@@ -2762,6 +2841,26 @@ func TestGracefulShutdown(t *testing.T) {
 	assert.Equal(t, time.Second, r.runtimeConfig.GracefulShutdownDuration)
 }
 
+func TestShutdownHooks(t *testing.T) {
+	r := NewTestAppRuntime(utils.StandaloneMode)
+	defer stopRuntime(t, r)
+
+	var order []string
+	r.RegisterShutdownHook("failing", func(ctx context.Context) error {
+		order = append(order, "failing")
+		return errors.New("checkpoint failed")
+	})
+	r.RegisterShutdownHook("deadline", func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		order = append(order, "deadline")
+		return nil
+	})
+
+	r.runShutdownHooks(time.Second)
+	assert.Equal(t, []string{"failing", "deadline"}, order)
+}
+
 func TestMTLS(t *testing.T) {
 	t.Run("with mTLS enabled", func(t *testing.T) {
 		rt := NewTestAppRuntime(utils.StandaloneMode)
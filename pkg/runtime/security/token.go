@@ -21,7 +21,10 @@ package security
 // THE SOFTWARE.
 
 import (
+	"crypto/subtle"
+	"net/http"
 	"os"
+	"regexp"
 	"strings"
 )
 
@@ -34,7 +37,11 @@ const (
 	APITokenHeader = "app-api-token"
 )
 
-var excludedRoutes = []string{"/healthz"}
+var (
+	excludedRoutes = []string{"healthz"}
+	// apiVersionRegexp matches the API version a route starts with, like v1.0 or v1.0-alpha1
+	apiVersionRegexp = regexp.MustCompile(`^v[0-9]+(\.[0-9]+)?(-[a-z0-9]+)?/`)
+)
 
 // GetAPIToken returns the value of the api token from an environment variable.
 func GetAPIToken() string {
@@ -46,12 +53,53 @@ func GetAppToken() string {
 	return os.Getenv(AppAPITokenEnvVar)
 }
 
-// ExcludedRoute returns whether a given route should be excluded from a token check.
+// ExcludedRoute returns whether a given route should be excluded from a token check. The path of the route, with
+// or without its API version, must be an excluded route or one of its sub routes, like /v1.0/healthz/outbound.
 func ExcludedRoute(route string) bool {
+	if i := strings.IndexAny(route, "?#"); i >= 0 {
+		route = route[:i]
+	}
+	route = strings.TrimPrefix(route, "/")
+	unversioned := apiVersionRegexp.ReplaceAllString(route, "")
+
 	for _, r := range excludedRoutes {
-		if strings.Contains(route, r) {
-			return true
+		for _, path := range []string{route, unversioned} {
+			if path == r || strings.HasPrefix(path, r+"/") {
+				return true
+			}
 		}
 	}
 	return false
 }
+
+// TokenMatches compares the token of a request with the expected token in constant time.
+func TokenMatches(expected, actual string) bool {
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) == 1
+}
+
+// SetAPITokenHeader sets the api token on the header of a call from the application to
+// its sidecar, if one is configured.
+func SetAPITokenHeader(header http.Header) {
+	if token := GetAPIToken(); token != "" {
+		header.Set(APITokenHeader, token)
+	}
+}
+
+// AppTokenHandler validates the app api token of the calls an application receives from
+// its sidecar. It is a no-op when no app api token is configured.
+func AppTokenHandler(next http.Handler) http.Handler {
+	token := GetAppToken()
+	if token == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ExcludedRoute(r.URL.Path) && !TokenMatches(token, r.Header.Get(APITokenHeader)) {
+			http.Error(w, "invalid app api token", http.StatusUnauthorized)
+			return
+		}
+
+		r.Header.Del(APITokenHeader)
+		next.ServeHTTP(w, r)
+	})
+}
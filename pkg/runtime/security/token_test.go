@@ -21,6 +21,8 @@ package security
 // THE SOFTWARE.
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -73,4 +75,76 @@ func TestExcludedRoute(t *testing.T) {
 		excluded := ExcludedRoute(route)
 		assert.False(t, excluded)
 	})
+
+	t.Run("only healthz paths are excluded", func(t *testing.T) {
+		for route, excluded := range map[string]bool{
+			"/healthz":                            true,
+			"/v1.0/healthz":                       true,
+			"/v1.0/healthz/outbound":              true,
+			"/v1.0-alpha1/healthz?probe=liveness": true,
+			"/v1.0/healthzz":                      false,
+			"/v1.0/state/healthz":                 false,
+			"/v1.0/invoke/app/method/healthz":     false,
+			"/v1.0/state/store?key=/healthz":      false,
+			"http://localhost:3500/v1.0/healthz":  false,
+		} {
+			assert.Equal(t, excluded, ExcludedRoute(route), route)
+		}
+	})
+}
+
+func TestAppTokenHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(APITokenHeader))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("no token configured", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		AppTokenHandler(next).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	os.Setenv(AppAPITokenEnvVar, "1234")
+	defer os.Unsetenv(AppAPITokenEnvVar)
+	handler := AppTokenHandler(next)
+
+	t.Run("valid token", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		r.Header.Set(APITokenHeader, "1234")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		r.Header.Set(APITokenHeader, "4567")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("excluded route", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1.0/healthz", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestSetAPITokenHeader(t *testing.T) {
+	header := http.Header{}
+	SetAPITokenHeader(header)
+	assert.Empty(t, header.Get(APITokenHeader))
+
+	os.Setenv(APITokenEnvVar, "1234")
+	defer os.Unsetenv(APITokenEnvVar)
+	SetAPITokenHeader(header)
+	assert.Equal(t, "1234", header.Get(APITokenHeader))
 }
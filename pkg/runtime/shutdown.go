@@ -0,0 +1,64 @@
+package runtime
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"time"
+)
+
+// ShutdownHook persists in-flight work before the runtime shuts down, such as timers or buffered messages, so that
+// it is resumed by the next instance instead of being lost during rolling updates.
+type ShutdownHook func(ctx context.Context) error
+
+type shutdownHook struct {
+	name string
+	hook ShutdownHook
+}
+
+// RegisterShutdownHook registers a hook run on shutdown, once the outstanding operations are finished and before
+// the components are closed, so hooks can still use the state stores and pub/subs. Hooks run in registration order.
+func (a *AppRuntime) RegisterShutdownHook(name string, hook ShutdownHook) {
+	a.shutdownHooksLock.Lock()
+	defer a.shutdownHooksLock.Unlock()
+	a.shutdownHooks = append(a.shutdownHooks, shutdownHook{name: name, hook: hook})
+}
+
+// runShutdownHooks runs the shutdown hooks within timeout, a failed hook doesn't prevent the next ones from running.
+func (a *AppRuntime) runShutdownHooks(timeout time.Duration) {
+	a.shutdownHooksLock.Lock()
+	hooks := append([]shutdownHook(nil), a.shutdownHooks...)
+	a.shutdownHooksLock.Unlock()
+
+	if len(hooks) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	log.Infof("Running %d shutdown hook(s)", len(hooks))
+	for _, h := range hooks {
+		if err := h.hook(ctx); err != nil {
+			log.Warnf("error running shutdown hook %s: %s", h.name, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package runtime
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bhojpur/application/pkg/components"
+	"github.com/bhojpur/application/pkg/config"
+	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+	"github.com/bhojpur/application/pkg/utils"
+)
+
+// validateConfiguration validates the global configuration, the loaded components and the HTTP pipeline
+// middleware plugins together, so every problem can be reported at startup at once.
+func (a *AppRuntime) validateConfiguration(comps []components_v1alpha1.Component) *config.ValidationReport {
+	report := &config.ValidationReport{}
+	if a.globalConfig != nil {
+		report.Merge(a.globalConfig.Validate())
+	}
+
+	// Invalid files are skipped by the standalone loader, report them as well.
+	if a.runtimeConfig.Mode == utils.StandaloneMode {
+		_, errs := components.NewStandaloneComponents(a.runtimeConfig.Standalone).ValidateComponents()
+		for _, err := range errs {
+			report.AddError(fmt.Sprintf("components path %s", a.runtimeConfig.Standalone.ComponentsPath), "", err.Error(), "")
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, comp := range comps {
+		source := fmt.Sprintf("component %s", comp.Name)
+		if comp.Name == "" {
+			report.AddError("component", "metadata.name", "name is empty", "")
+		}
+		if comp.Spec.Type == "" {
+			report.AddError(source, "spec.type", "type is empty", "use a component type such as state.redis")
+		}
+
+		key := comp.Spec.Type + "/" + comp.Name
+		if seen[key] {
+			report.AddError(source, "metadata.name", fmt.Sprintf("component of type %s is declared more than once", comp.Spec.Type), "rename or remove one of them")
+		}
+		seen[key] = true
+
+		var validationErr *components.ValidationError
+		if err := components.ValidateComponent(comp); errors.As(err, &validationErr) {
+			for _, fieldErr := range validationErr.Errors {
+				report.AddError(source, fmt.Sprintf("spec.metadata[%s]", fieldErr.Field), fieldErr.Message, "")
+			}
+		} else if err != nil {
+			report.AddError(source, "spec.metadata", err.Error(), "")
+		}
+	}
+
+	if a.globalConfig != nil {
+		for i, handler := range a.globalConfig.Spec.HTTPPipelineSpec.Handlers {
+			if !hasComponent(comps, handler.Type, handler.Name) {
+				report.AddError(fmt.Sprintf("configuration %s", a.globalConfig.Name), fmt.Sprintf("spec.httpPipeline.handlers[%d]", i),
+					fmt.Sprintf("couldn't find middleware component with name %s and type %s/%s", handler.Name, handler.Type, handler.Version),
+					suggestMiddleware(comps))
+			}
+		}
+	}
+
+	return report
+}
+
+func hasComponent(comps []components_v1alpha1.Component, componentType, name string) bool {
+	for _, comp := range comps {
+		if comp.Spec.Type == componentType && comp.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func suggestMiddleware(comps []components_v1alpha1.Component) string {
+	names := []string{}
+	for _, comp := range comps {
+		if strings.HasPrefix(comp.Spec.Type, "middleware.") {
+			names = append(names, fmt.Sprintf("%s (%s)", comp.Name, comp.Spec.Type))
+		}
+	}
+	if len(names) == 0 {
+		return "no middleware component is loaded"
+	}
+	return fmt.Sprintf("loaded middleware components are %s", strings.Join(names, ", "))
+}
@@ -632,14 +632,6 @@ func makeExecutable(filepath string) error {
 	return nil
 }
 
-func sanitizeExtractPath(destination string, filePath string) (string, error) {
-	destpath := path_filepath.Join(destination, filePath)
-	if !strings.HasPrefix(destpath, path_filepath.Clean(destination)+string(os.PathSeparator)) {
-		return "", fmt.Errorf("%s: illegal file path", filePath)
-	}
-	return destpath, nil
-}
-
 func unzip(filepath, targetDir, binaryFilePrefix string) (string, error) {
 	r, err := zip.OpenReader(filepath)
 	if err != nil {
@@ -649,7 +641,7 @@ func unzip(filepath, targetDir, binaryFilePrefix string) (string, error) {
 
 	foundBinary := ""
 	for _, f := range r.File {
-		fpath, err := sanitizeExtractPath(targetDir, f.Name)
+		fpath, err := utils.ValidateArchivePath(targetDir, f.Name)
 		if err != nil {
 			return "", err
 		}
@@ -718,7 +710,7 @@ func untar(filepath, targetDir, binaryFilePrefix string) (string, error) {
 		}
 
 		// untar all files in archive
-		path, err := sanitizeExtractPath(targetDir, header.Name)
+		path, err := utils.ValidateArchivePath(targetDir, header.Name)
 		if err != nil {
 			return "", err
 		}
@@ -0,0 +1,268 @@
+package tenancy
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+var log = applog.New("app.tenancy")
+
+// Name is the name of the tenancy middleware
+const Name = "tenancy"
+
+const (
+	tenantSetting      = "bhojpur:tenant"
+	tablePrefixSetting = "bhojpur:tenant_table_prefix"
+)
+
+var (
+	// ErrNoTenant is returned when a request has no tenant, but one is required
+	ErrNoTenant = errors.New("tenancy: no tenant")
+	// ErrUnknownTenant is returned when the tenant of a request is neither registered nor found by the lookup
+	ErrUnknownTenant = errors.New("tenancy: unknown tenant")
+)
+
+// Tenant is a tenant, with a database of its own, or tables of its own in the default database
+type Tenant struct {
+	ID string
+	// DB is the database of the tenant, the default database of the router if nil
+	DB *orm.DB
+	// TablePrefix prefixes the tables of the models of the tenant, e.g. "acme_" for "acme_products". Tables named
+	// explicitly, with db.Table, a TableName method or raw SQL, aren't prefixed
+	TablePrefix string
+}
+
+// Resolver resolves the ID of the tenant of a request, it is empty if the request has none
+type Resolver func(req *http.Request) (string, error)
+
+// BySubdomain resolves tenants by the subdomain of domain of requests, e.g. "acme" for "acme.example.com", requests to
+// the domain itself or to nested subdomains have no tenant
+func BySubdomain(domain string) Resolver {
+	suffix := "." + strings.ToLower(strings.TrimSuffix(domain, "."))
+	return func(req *http.Request) (string, error) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		if !strings.HasSuffix(host, suffix) {
+			return "", nil
+		}
+		subdomain := strings.TrimSuffix(host, suffix)
+		if strings.Contains(subdomain, ".") {
+			return "", nil
+		}
+		return subdomain, nil
+	}
+}
+
+// ByHeader resolves tenants by a header of requests, e.g. "X-Tenant-ID"
+func ByHeader(name string) Resolver {
+	return func(req *http.Request) (string, error) {
+		return strings.TrimSpace(req.Header.Get(name)), nil
+	}
+}
+
+// FirstOf resolves tenants by the first resolver finding one
+func FirstOf(resolvers ...Resolver) Resolver {
+	return func(req *http.Request) (string, error) {
+		for _, resolver := range resolvers {
+			id, err := resolver(req)
+			if err != nil || id != "" {
+				return id, err
+			}
+		}
+		return "", nil
+	}
+}
+
+// Config configures tenancy
+type Config struct {
+	// DB is the default database, of requests without tenant and of tenants without their own
+	DB *orm.DB
+	// Resolver resolves the tenants of requests, by the X-Tenant-ID header by default
+	Resolver Resolver
+	// Lookup finds tenants not registered, e.g. in a table of tenants, it returns ErrUnknownTenant if there is none.
+	// Tenants found are registered
+	Lookup func(ctx context.Context, id string) (*Tenant, error)
+	// Required rejects requests without tenant, otherwise they use the default database
+	Required bool
+}
+
+// Router routes requests to the databases of their tenants, resource handlers of their contexts use them
+//
+//	router := tenancy.New(tenancy.Config{DB: db, Resolver: tenancy.BySubdomain("example.com")})
+//	router.Register(&tenancy.Tenant{ID: "acme", DB: acmeDB}, &tenancy.Tenant{ID: "globex", TablePrefix: "globex_"})
+//	engine.Use(router.Middleware())
+type Router struct {
+	config  Config
+	mutex   sync.RWMutex
+	tenants map[string]*Tenant
+}
+
+// New initialize a router
+func New(config Config) *Router {
+	if config.Resolver == nil {
+		config.Resolver = ByHeader("X-Tenant-ID")
+	}
+	installTableNameHandler()
+	return &Router{config: config, tenants: map[string]*Tenant{}}
+}
+
+// Register registers tenants, replacing registered ones with the same ID
+func (router *Router) Register(tenants ...*Tenant) {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	for _, tenant := range tenants {
+		router.tenants[tenant.ID] = tenant
+	}
+}
+
+// Unregister unregisters tenants, e.g. so that they are looked up again
+func (router *Router) Unregister(ids ...string) {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	for _, id := range ids {
+		delete(router.tenants, id)
+	}
+}
+
+// Tenant returns the tenant of ID, registered or found by the lookup
+func (router *Router) Tenant(ctx context.Context, id string) (*Tenant, error) {
+	router.mutex.RLock()
+	tenant, ok := router.tenants[id]
+	router.mutex.RUnlock()
+	if ok {
+		return tenant, nil
+	}
+	if router.config.Lookup == nil {
+		return nil, ErrUnknownTenant
+	}
+
+	tenant, err := router.config.Lookup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if tenant == nil {
+		return nil, ErrUnknownTenant
+	}
+	router.Register(tenant)
+	return tenant, nil
+}
+
+// DB returns the database of a tenant, with its table prefix
+func (router *Router) DB(tenant *Tenant) *orm.DB {
+	db := tenant.DB
+	if db == nil {
+		db = router.config.DB
+	}
+	db = db.Set(tenantSetting, tenant.ID)
+	if tenant.TablePrefix != "" {
+		db = db.Set(tablePrefixSetting, tenant.TablePrefix)
+	}
+	return db
+}
+
+// Middleware returns the middleware resolving the tenants of requests, contexts of requests with a tenant use its
+// database, see appsvr.WithDB. Requests of unknown tenants are rejected with 404
+func (router *Router) Middleware() *appsvr.Middleware {
+	return &appsvr.Middleware{
+		Name: Name,
+		Handler: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				id, err := router.config.Resolver(req)
+				if err != nil {
+					log.Warn("failed to resolve tenant", applog.Err(err))
+					http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+					return
+				}
+				if id == "" {
+					if router.config.Required {
+						http.Error(w, ErrNoTenant.Error(), http.StatusBadRequest)
+						return
+					}
+					next.ServeHTTP(w, req)
+					return
+				}
+
+				tenant, err := router.Tenant(req.Context(), id)
+				if err != nil {
+					if !errors.Is(err, ErrUnknownTenant) {
+						log.Error("failed to look up tenant", applog.String("tenant", id), applog.Err(err))
+						http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+						return
+					}
+					http.NotFound(w, req)
+					return
+				}
+
+				req = appsvr.WithDB(req, router.DB(tenant))
+				next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), tenantKey, tenant)))
+			})
+		},
+	}
+}
+
+type contextKey int
+
+const tenantKey contextKey = iota
+
+// FromRequest returns the tenant of a request, resolved by the middleware, nil if it has none
+func FromRequest(req *http.Request) *Tenant {
+	tenant, _ := req.Context().Value(tenantKey).(*Tenant)
+	return tenant
+}
+
+// FromDB returns the ID of the tenant of a database returned by Router.DB, empty for other databases
+func FromDB(db *orm.DB) string {
+	id, _ := db.Get(tenantSetting)
+	tenant, _ := id.(string)
+	return tenant
+}
+
+var tableNameOnce sync.Once
+
+// installTableNameHandler prefixes the tables of the databases of tenants with their prefix, it wraps the handler
+// installed before
+func installTableNameHandler() {
+	tableNameOnce.Do(func() {
+		handler := orm.DefaultTableNameHandler
+		orm.DefaultTableNameHandler = func(db *orm.DB, defaultTableName string) string {
+			tableName := handler(db, defaultTableName)
+			if db != nil {
+				if prefix, ok := db.Get(tablePrefixSetting); ok {
+					return prefix.(string) + tableName
+				}
+			}
+			return tableName
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package tenancy_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/tenancy"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Product struct {
+	ID   uint
+	Name string
+}
+
+func openDB(t *testing.T, tables map[string]string) *orm.DB {
+	db := utils.SQLiteTestDB(t)
+	for table, name := range tables {
+		require.NoError(t, db.Exec(`CREATE TABLE `+table+` (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`).Error)
+		require.NoError(t, db.Exec(`INSERT INTO `+table+` (name) VALUES (?)`, name).Error)
+	}
+	return db
+}
+
+func TestRouter(t *testing.T) {
+	db := openDB(t, map[string]string{"products": "Default", "globex_products": "Globex"})
+	acme := openDB(t, map[string]string{"products": "Acme"})
+
+	router := tenancy.New(tenancy.Config{
+		DB:       db,
+		Resolver: tenancy.FirstOf(tenancy.BySubdomain("example.com"), tenancy.ByHeader("X-Tenant-ID")),
+	})
+	router.Register(&tenancy.Tenant{ID: "acme", DB: acme}, &tenancy.Tenant{ID: "globex", TablePrefix: "globex_"})
+
+	res := resource.New(&Product{})
+	res.EnableCache(resource.NewMemoryCacheStore(), time.Minute)
+	handler := router.Middleware().Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var products []Product
+		context := &appsvr.Context{Request: req, Writer: w, Config: &appsvr.Config{DB: db}}
+		require.NoError(t, res.CallFindMany(&products, context))
+		require.Len(t, products, 1)
+		w.Write([]byte(products[0].Name))
+	}))
+
+	get := func(host, tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "http://"+host+"/products", nil)
+		if tenant != "" {
+			req.Header.Set("X-Tenant-ID", tenant)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// records of tenants are cached apart, so every request is served twice
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, "Acme", get("acme.example.com", "").Body.String())
+		assert.Equal(t, "Acme", get("localhost:7000", "acme").Body.String())
+		assert.Equal(t, "Globex", get("globex.example.com:7000", "").Body.String())
+		assert.Equal(t, "Default", get("example.com", "").Body.String())
+		assert.Equal(t, "Default", get("a.acme.example.com", "").Body.String())
+	}
+	assert.Equal(t, http.StatusNotFound, get("initech.example.com", "").Code)
+
+	router.Unregister("acme")
+	assert.Equal(t, http.StatusNotFound, get("acme.example.com", "").Code)
+}
+
+func TestLookup(t *testing.T) {
+	db := openDB(t, map[string]string{"initech_products": "Initech"})
+	lookups := 0
+	router := tenancy.New(tenancy.Config{
+		DB:       db,
+		Required: true,
+		Lookup: func(ctx context.Context, id string) (*tenancy.Tenant, error) {
+			lookups++
+			if id != "initech" {
+				return nil, tenancy.ErrUnknownTenant
+			}
+			return &tenancy.Tenant{ID: id, TablePrefix: id + "_"}, nil
+		},
+	})
+
+	var tenant *tenancy.Tenant
+	handler := router.Middleware().Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		tenant = tenancy.FromRequest(req)
+		var product Product
+		context := &appsvr.Context{Request: req}
+		require.NoError(t, context.GetDB().First(&product).Error)
+		assert.Equal(t, "initech", tenancy.FromDB(context.GetDB()))
+		w.Write([]byte(product.Name))
+	}))
+
+	get := func(tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/products", nil)
+		req.Header.Set("X-Tenant-ID", tenant)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		w := get("initech")
+		assert.Equal(t, "Initech", w.Body.String())
+		assert.Equal(t, "initech", tenant.ID)
+	}
+	assert.Equal(t, 1, lookups)
+	assert.Equal(t, http.StatusNotFound, get("acme").Code)
+	assert.Equal(t, http.StatusBadRequest, get("").Code)
+	assert.Equal(t, "", tenancy.FromDB(db))
+}
@@ -236,3 +236,17 @@ func (_m *MockActors) GetActiveActorsCount(ctx context.Context) []actors.ActiveA
 		},
 	}
 }
+
+// Checkpoint provides a mock function
+func (_m *MockActors) Checkpoint(ctx context.Context) error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,181 @@
+package fixtures
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/bhojpur/application/pkg/client/clientset/versioned/fake"
+	informers "github.com/bhojpur/application/pkg/client/informers/externalversions"
+	components_v1alpha1 "github.com/bhojpur/application/pkg/kubernetes/components/v1alpha1"
+)
+
+// DefaultNamespace is the namespace of the components built without one.
+const DefaultNamespace = "default"
+
+// ComponentBuilder builds Component objects for tests, like
+// `fixtures.Component("statestore").Type("state.redis", "v1").Metadata("host", "localhost").Build()`.
+type ComponentBuilder struct {
+	component components_v1alpha1.Component
+}
+
+// Component returns a builder of a component named name, in the default namespace.
+func Component(name string) *ComponentBuilder {
+	return &ComponentBuilder{component: components_v1alpha1.Component{
+		TypeMeta: meta_v1.TypeMeta{
+			Kind:       "Component",
+			APIVersion: components_v1alpha1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:       name,
+			Namespace:  DefaultNamespace,
+			Generation: 1,
+		},
+		Spec: components_v1alpha1.ComponentSpec{
+			Type:    "state.in-memory",
+			Version: "v1",
+		},
+	}}
+}
+
+// Namespace sets the namespace of the component.
+func (b *ComponentBuilder) Namespace(namespace string) *ComponentBuilder {
+	b.component.Namespace = namespace
+	return b
+}
+
+// Type sets the type and version of the component.
+func (b *ComponentBuilder) Type(componentType, version string) *ComponentBuilder {
+	b.component.Spec.Type = componentType
+	b.component.Spec.Version = version
+	return b
+}
+
+// Metadata adds a metadata item, the value is serialized to JSON unless it is a string.
+func (b *ComponentBuilder) Metadata(name string, value interface{}) *ComponentBuilder {
+	raw, ok := value.(string)
+	if !ok {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			panic(err)
+		}
+		raw = string(encoded)
+	}
+
+	b.component.Spec.Metadata = append(b.component.Spec.Metadata, components_v1alpha1.MetadataItem{
+		Name:  name,
+		Value: components_v1alpha1.DynamicValue{JSON: v1.JSON{Raw: []byte(raw)}},
+	})
+	return b
+}
+
+// SecretRef adds a metadata item referencing the key of a secret.
+func (b *ComponentBuilder) SecretRef(name, secret, key string) *ComponentBuilder {
+	b.component.Spec.Metadata = append(b.component.Spec.Metadata, components_v1alpha1.MetadataItem{
+		Name:         name,
+		SecretKeyRef: components_v1alpha1.SecretKeyRef{Name: secret, Key: key},
+	})
+	return b
+}
+
+// SecretStore sets the secret store the secret references are resolved with.
+func (b *ComponentBuilder) SecretStore(store string) *ComponentBuilder {
+	b.component.Auth.SecretStore = store
+	return b
+}
+
+// DependsOn adds dependencies of the component.
+func (b *ComponentBuilder) DependsOn(names ...string) *ComponentBuilder {
+	b.component.Spec.DependsOn = append(b.component.Spec.DependsOn, names...)
+	return b
+}
+
+// Scopes adds the app IDs the component is scoped to.
+func (b *ComponentBuilder) Scopes(appIDs ...string) *ComponentBuilder {
+	b.component.Scopes = append(b.component.Scopes, appIDs...)
+	return b
+}
+
+// Label sets a label of the component.
+func (b *ComponentBuilder) Label(key, value string) *ComponentBuilder {
+	if b.component.Labels == nil {
+		b.component.Labels = map[string]string{}
+	}
+	b.component.Labels[key] = value
+	return b
+}
+
+// Generation sets the generation of the component.
+func (b *ComponentBuilder) Generation(generation int64) *ComponentBuilder {
+	b.component.Generation = generation
+	return b
+}
+
+// IgnoreErrors sets whether the errors of the component are ignored.
+func (b *ComponentBuilder) IgnoreErrors(ignore bool) *ComponentBuilder {
+	b.component.Spec.IgnoreErrors = ignore
+	return b
+}
+
+// InitTimeout sets the initialization timeout of the component.
+func (b *ComponentBuilder) InitTimeout(timeout time.Duration) *ComponentBuilder {
+	b.component.Spec.InitTimeout = timeout.String()
+	return b
+}
+
+// Build returns a copy of the built component.
+func (b *ComponentBuilder) Build() *components_v1alpha1.Component {
+	return b.component.DeepCopy()
+}
+
+// Clientset returns a fake clientset serving objects, which records its actions like the
+// k8s.io/client-go/testing fakes.
+func Clientset(objects ...runtime.Object) *fake.Clientset {
+	return fake.NewSimpleClientset(objects...)
+}
+
+// Informers returns a started informer factory of client with the component informer registered,
+// whose caches are synced. The informers are stopped when the test ends.
+func Informers(t *testing.T, client *fake.Clientset, options ...informers.SharedInformerOption) informers.SharedInformerFactory {
+	t.Helper()
+
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0, options...)
+	factory.Components().V1alpha1().Components().Informer()
+
+	stopCh := make(chan struct{})
+	t.Cleanup(func() {
+		close(stopCh)
+	})
+
+	factory.Start(stopCh)
+	for informerType, synced := range factory.WaitForCacheSync(stopCh) {
+		if !synced {
+			t.Fatalf("failed to sync the cache of informer %v", informerType)
+		}
+	}
+	return factory
+}
@@ -0,0 +1,79 @@
+package fixtures_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/bhojpur/application/pkg/testing/fixtures"
+)
+
+func TestComponentBuilder(t *testing.T) {
+	component := fixtures.Component("statestore").
+		Namespace("production").
+		Type("state.redis", "v1").
+		Metadata("host", "localhost:6379").
+		Metadata("maxRetries", 3).
+		SecretRef("password", "redis", "password").
+		DependsOn("secretstore").
+		Scopes("app1").
+		Label("team", "payments").
+		InitTimeout(time.Minute).
+		Build()
+
+	assert.Equal(t, "statestore", component.Name)
+	assert.Equal(t, "production", component.Namespace)
+	assert.Equal(t, "state.redis", component.Spec.Type)
+	require.Len(t, component.Spec.Metadata, 3)
+	assert.Equal(t, "localhost:6379", component.Spec.Metadata[0].Value.String())
+	assert.Equal(t, "3", component.Spec.Metadata[1].Value.String())
+	assert.Equal(t, "redis", component.Spec.Metadata[2].SecretKeyRef.Name)
+	assert.Equal(t, []string{"secretstore"}, component.Spec.DependsOn)
+	assert.Equal(t, []string{"app1"}, component.Scopes)
+	assert.Equal(t, "payments", component.Labels["team"])
+	assert.Equal(t, "1m0s", component.Spec.InitTimeout)
+}
+
+func TestInformers(t *testing.T) {
+	client := fixtures.Clientset(fixtures.Component("statestore").Build())
+	factory := fixtures.Informers(t, client)
+	lister := factory.Components().V1alpha1().Components().Lister()
+
+	components, err := lister.List(labels.Everything())
+	require.NoError(t, err)
+	assert.Len(t, components, 1)
+
+	_, err = client.ComponentsV1alpha1().Components(fixtures.DefaultNamespace).Create(context.Background(), fixtures.Component("pubsub").Type("pubsub.redis", "v1").Build(), meta_v1.CreateOptions{})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, err := lister.Components(fixtures.DefaultNamespace).Get("pubsub")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NotEmpty(t, client.Actions())
+}
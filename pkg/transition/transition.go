@@ -0,0 +1,313 @@
+package transition
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/roles"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// ErrUnknownEvent is returned when triggering an event not defined in the state machine
+var ErrUnknownEvent = errors.New("transition: unknown event")
+
+// ErrInvalidTransition is returned when triggering an event without a transition from the current state
+var ErrInvalidTransition = errors.New("transition: invalid transition")
+
+// Stater is a model with a state, embed Transition to implement it
+type Stater interface {
+	SetState(name string)
+	GetState() string
+}
+
+// Transition is embedded into the models of state machines
+type Transition struct {
+	State string `orm:"size:64;index"`
+}
+
+// SetState set the state of the record
+func (transition *Transition) SetState(name string) {
+	transition.State = name
+}
+
+// GetState get the state of the record
+func (transition Transition) GetState() string {
+	return transition.State
+}
+
+// StateChangeLog is the history of the transitions of records
+type StateChangeLog struct {
+	ID         uint   `orm:"primary_key"`
+	ReferTable string `orm:"size:128;index:idx_state_change_log_refer"`
+	ReferID    string `orm:"size:128;index:idx_state_change_log_refer"`
+	From       string `orm:"size:64"`
+	To         string `orm:"size:64"`
+	Event      string `orm:"size:64"`
+	Note       string `orm:"type:text"`
+	Actor      string `orm:"size:256"`
+	CreatedAt  time.Time
+}
+
+// TableName table name of state change logs
+func (StateChangeLog) TableName() string {
+	return "state_change_logs"
+}
+
+// GetStateChangeLogs returns the transitions of a record, in order
+func GetStateChangeLogs(value interface{}, db *orm.DB) ([]StateChangeLog, error) {
+	var (
+		logs  []StateChangeLog
+		scope = db.NewScope(value)
+	)
+	err := db.Where("refer_table = ? AND refer_id = ?", scope.TableName(), fmt.Sprint(scope.PrimaryKeyValue())).Order("id").Find(&logs).Error
+	return logs, err
+}
+
+// Hook is run during transitions, with the database of the transaction in the context
+type Hook func(value interface{}, context *appsvr.Context) error
+
+// StateMachine defines the states of records and the events transitioning them, e.g.
+//
+//	approval := transition.New().Initial("draft")
+//	approval.Event("submit").To("pending").From("draft")
+//	approval.Event("approve").To("approved").From("pending")
+//	approval.Event("approve").Permission = roles.Allow("approve", "editor")
+type StateMachine struct {
+	initial string
+	states  map[string]*State
+	events  map[string]*Event
+	order   []string
+}
+
+// New initialize a state machine
+func New() *StateMachine {
+	return &StateMachine{states: map[string]*State{}, events: map[string]*Event{}}
+}
+
+// Initial set the state of records without one
+func (sm *StateMachine) Initial(name string) *StateMachine {
+	sm.initial = name
+	return sm
+}
+
+// State get or define a state
+func (sm *StateMachine) State(name string) *State {
+	state, ok := sm.states[name]
+	if !ok {
+		state = &State{Name: name}
+		sm.states[name] = state
+	}
+	return state
+}
+
+// Event get or define an event
+func (sm *StateMachine) Event(name string) *Event {
+	event, ok := sm.events[name]
+	if !ok {
+		event = &Event{Name: name}
+		sm.events[name] = event
+		sm.order = append(sm.order, name)
+	}
+	return event
+}
+
+// State is a state of a state machine, with hooks run when records enter or exit it
+type State struct {
+	Name   string
+	enters []Hook
+	exits  []Hook
+}
+
+// Enter adds a hook run when records enter the state
+func (state *State) Enter(hook Hook) *State {
+	state.enters = append(state.enters, hook)
+	return state
+}
+
+// Exit adds a hook run when records exit the state
+func (state *State) Exit(hook Hook) *State {
+	state.exits = append(state.exits, hook)
+	return state
+}
+
+// Event is an event of a state machine. Its Permission guards it, it is checked with the name of the event as the
+// mode, e.g. roles.Allow("approve", "editor"), which allows it if nil
+type Event struct {
+	Name        string
+	Permission  *roles.Permission
+	transitions []*EventTransition
+}
+
+// To adds a transition of the event to a state
+func (event *Event) To(name string) *EventTransition {
+	transition := &EventTransition{to: name}
+	event.transitions = append(event.transitions, transition)
+	return transition
+}
+
+// HasPermission returns true if the roles of the context are allowed to trigger the event for the record
+func (event *Event) HasPermission(value interface{}, context *appsvr.Context) bool {
+	if event.Permission == nil {
+		return true
+	}
+
+	var roleNames []interface{}
+	for _, role := range context.Roles {
+		roleNames = append(roleNames, role)
+	}
+	return event.Permission.HasRecordPermission(roles.PermissionMode(event.Name), value, context, roleNames...)
+}
+
+func (event *Event) transitionFrom(state string) *EventTransition {
+	for _, transition := range event.transitions {
+		if len(transition.froms) == 0 {
+			return transition
+		}
+		for _, from := range transition.froms {
+			if from == state {
+				return transition
+			}
+		}
+	}
+	return nil
+}
+
+// EventTransition is a transition of an event from states to a state
+type EventTransition struct {
+	to      string
+	froms   []string
+	befores []Hook
+	afters  []Hook
+}
+
+// From set the states the transition applies to, all states if not set
+func (transition *EventTransition) From(states ...string) *EventTransition {
+	transition.froms = states
+	return transition
+}
+
+// Before adds a hook run before records exit their state, returning an error cancels the transition
+func (transition *EventTransition) Before(hook Hook) *EventTransition {
+	transition.befores = append(transition.befores, hook)
+	return transition
+}
+
+// After adds a hook run after records entered the state, returning an error cancels the transition
+func (transition *EventTransition) After(hook Hook) *EventTransition {
+	transition.afters = append(transition.afters, hook)
+	return transition
+}
+
+// Trigger triggers an event on a record: it runs the hooks of the transition and the states, saves the record and
+// logs the transition with the notes, in a transaction. The state of the record is restored if it fails
+func (sm *StateMachine) Trigger(name string, value Stater, context *appsvr.Context, notes ...string) error {
+	event, ok := sm.events[name]
+	if !ok {
+		return ErrUnknownEvent
+	}
+
+	state, from := value.GetState(), sm.stateOf(value)
+	transition := event.transitionFrom(from)
+	if transition == nil {
+		return ErrInvalidTransition
+	}
+	if !event.HasPermission(value, context) {
+		return roles.ErrPermissionDenied
+	}
+
+	err := context.GetDB().Transaction(func(tx *orm.DB) error {
+		txContext := context.Clone()
+		txContext.SetDB(tx)
+
+		var hooks []Hook
+		hooks = append(hooks, transition.befores...)
+		if state, ok := sm.states[from]; ok {
+			hooks = append(hooks, state.exits...)
+		}
+		for _, hook := range hooks {
+			if err := hook(value, txContext); err != nil {
+				return err
+			}
+		}
+
+		value.SetState(transition.to)
+		hooks = nil
+		if state, ok := sm.states[transition.to]; ok {
+			hooks = append(hooks, state.enters...)
+		}
+		hooks = append(hooks, transition.afters...)
+		for _, hook := range hooks {
+			if err := hook(value, txContext); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Save(value).Error; err != nil {
+			return err
+		}
+
+		scope := tx.NewScope(value)
+		changeLog := StateChangeLog{
+			ReferTable: scope.TableName(),
+			ReferID:    fmt.Sprint(scope.PrimaryKeyValue()),
+			From:       from,
+			To:         transition.to,
+			Event:      name,
+			Note:       strings.Join(notes, "\n"),
+		}
+		if context.CurrentUser != nil {
+			changeLog.Actor = context.CurrentUser.DisplayName()
+		}
+		return tx.Create(&changeLog).Error
+	})
+	// the state of the record is restored, blank if it was in the initial state
+	if err != nil {
+		value.SetState(state)
+	}
+	return err
+}
+
+// AvailableEvents returns the events the roles of the context can trigger on the record from its state, in the
+// order they were defined, e.g. to render the transition buttons of the current user
+func (sm *StateMachine) AvailableEvents(value Stater, context *appsvr.Context) []*Event {
+	var (
+		events []*Event
+		state  = sm.stateOf(value)
+	)
+	for _, name := range sm.order {
+		if event := sm.events[name]; event.transitionFrom(state) != nil && event.HasPermission(value, context) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func (sm *StateMachine) stateOf(value Stater) string {
+	if state := value.GetState(); state != "" {
+		return state
+	}
+	return sm.initial
+}
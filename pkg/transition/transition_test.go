@@ -0,0 +1,109 @@
+package transition_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/roles"
+	"github.com/bhojpur/application/pkg/transition"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Article struct {
+	ID uint
+	transition.Transition
+	Title string
+}
+
+type user string
+
+func (u user) DisplayName() string {
+	return string(u)
+}
+
+func TestStateMachine(t *testing.T) {
+	db := utils.SQLiteTestDB(t,
+		`CREATE TABLE articles (id INTEGER PRIMARY KEY AUTOINCREMENT, state TEXT, title TEXT)`,
+		`CREATE TABLE state_change_logs (id INTEGER PRIMARY KEY AUTOINCREMENT, refer_table TEXT, refer_id TEXT,
+		"from" TEXT, "to" TEXT, event TEXT, note TEXT, actor TEXT, created_at DATETIME)`,
+	)
+
+	var entered []string
+	approval := transition.New().Initial("draft")
+	approval.State("approved").Enter(func(value interface{}, context *appsvr.Context) error {
+		entered = append(entered, value.(*Article).Title)
+		return nil
+	})
+	approval.Event("submit").To("pending").From("draft").Before(func(value interface{}, context *appsvr.Context) error {
+		if value.(*Article).Title == "" {
+			return errors.New("title is required")
+		}
+		return nil
+	})
+	approval.Event("approve").To("approved").From("pending")
+	approval.Event("approve").Permission = roles.NewPermission().Allow("approve", "editor")
+	approval.Event("reject").To("draft").From("pending")
+
+	writer := &appsvr.Context{Config: &appsvr.Config{DB: db}, CurrentUser: user("alice"), Roles: []string{"writer"}}
+	editor := &appsvr.Context{Config: &appsvr.Config{DB: db}, CurrentUser: user("bob"), Roles: []string{"editor"}}
+
+	article := &Article{}
+	require.NoError(t, db.Create(article).Error)
+	assert.EqualError(t, approval.Trigger("submit", article, writer), "title is required")
+	assert.Equal(t, "", article.State)
+
+	article.Title = "Hello"
+	assert.Equal(t, transition.ErrInvalidTransition, approval.Trigger("approve", article, editor))
+	require.NoError(t, approval.Trigger("submit", article, writer, "ready"))
+	assert.Equal(t, "pending", article.State)
+
+	names := func(events []*transition.Event) (names []string) {
+		for _, event := range events {
+			names = append(names, event.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"reject"}, names(approval.AvailableEvents(article, writer)))
+	assert.Equal(t, []string{"approve", "reject"}, names(approval.AvailableEvents(article, editor)))
+
+	assert.Equal(t, roles.ErrPermissionDenied, approval.Trigger("approve", article, writer))
+	require.NoError(t, approval.Trigger("approve", article, editor))
+	assert.Equal(t, []string{"Hello"}, entered)
+	assert.Equal(t, transition.ErrUnknownEvent, approval.Trigger("publish", article, editor))
+
+	var saved Article
+	require.NoError(t, db.First(&saved, article.ID).Error)
+	assert.Equal(t, "approved", saved.State)
+
+	logs, err := transition.GetStateChangeLogs(article, db)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "draft", logs[0].From)
+	assert.Equal(t, "ready", logs[0].Note)
+	assert.Equal(t, "alice", logs[0].Actor)
+	assert.Equal(t, "approved", logs[1].To)
+	assert.Equal(t, "bob", logs[1].Actor)
+}
@@ -0,0 +1,119 @@
+package utils
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	humanizeLock            sync.RWMutex
+	humanizeAcronyms        []string
+	humanizeOverrides       = map[string]string{}
+	humanizeLocaleOverrides = map[string]map[string]string{}
+)
+
+// RegisterAcronyms registers acronyms which HumanizeString keeps as single words, whatever the case
+// they are written in, e.g. after RegisterAcronyms("SKU", "OAuth2"), "ProductSku" -> "Product SKU"
+// and "OAuth2Token" -> "OAuth2 Token"
+func RegisterAcronyms(acronyms ...string) {
+	humanizeLock.Lock()
+	defer humanizeLock.Unlock()
+
+	for _, acronym := range acronyms {
+		if acronym != "" && !containsAcronym(acronym) {
+			humanizeAcronyms = append(humanizeAcronyms, acronym)
+		}
+	}
+
+	// longest acronyms first, so "OAuth2" wins over "OAuth"
+	sort.SliceStable(humanizeAcronyms, func(i, j int) bool {
+		return len(humanizeAcronyms[i]) > len(humanizeAcronyms[j])
+	})
+}
+
+// RegisterHumanizeOverride registers the label HumanizeString returns for str, e.g. "Qty" -> "Quantity"
+func RegisterHumanizeOverride(str, label string) {
+	humanizeLock.Lock()
+	defer humanizeLock.Unlock()
+
+	humanizeOverrides[str] = label
+}
+
+// RegisterLocaleHumanizeOverride registers the label HumanizeStringWithLocale returns for str in a locale,
+// e.g. "Qty" -> "Menge" for "de"
+func RegisterLocaleHumanizeOverride(locale, str, label string) {
+	humanizeLock.Lock()
+	defer humanizeLock.Unlock()
+
+	locale = strings.ToLower(strings.Replace(locale, "_", "-", -1))
+	if humanizeLocaleOverrides[locale] == nil {
+		humanizeLocaleOverrides[locale] = map[string]string{}
+	}
+	humanizeLocaleOverrides[locale][str] = label
+}
+
+// HumanizeStringWithLocale returns the label registered for str in the locale, eg. "de-CH" falls back to
+// the labels of "de", or HumanizeString(str) if it has none
+func HumanizeStringWithLocale(str, locale string) string {
+	humanizeLock.RLock()
+	locale = strings.ToLower(strings.Replace(locale, "_", "-", -1))
+	label, ok := humanizeLocaleOverrides[locale][str]
+	if !ok {
+		label, ok = humanizeLocaleOverrides[strings.SplitN(locale, "-", 2)[0]][str]
+	}
+	humanizeLock.RUnlock()
+
+	if ok {
+		return label
+	}
+	return HumanizeString(str)
+}
+
+func containsAcronym(acronym string) bool {
+	for _, a := range humanizeAcronyms {
+		if a == acronym {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAcronym returns the registered acronym starting at position i of str, if it is a whole word
+func matchAcronym(str string, i int, wordStart bool) string {
+	if !wordStart {
+		return ""
+	}
+
+	for _, acronym := range humanizeAcronyms {
+		end := i + len(acronym)
+		if end > len(str) || !strings.EqualFold(str[i:end], acronym) {
+			continue
+		}
+
+		if end == len(str) || str[end] == ' ' || isUppercase(str[end]) {
+			return acronym
+		}
+	}
+	return ""
+}
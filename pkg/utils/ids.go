@@ -0,0 +1,115 @@
+package utils
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NewUUIDv4 returns a random UUID in its canonical form
+func NewUUIDv4() (string, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// NewUUIDv7 returns a UUID in its canonical form, whose first 48 bits are the Unix time in milliseconds and the
+// others random, so that UUIDs sort by their creation time like auto-increment keys
+func NewUUIDv7() (string, error) {
+	var id uuid.UUID
+	if _, err := rand.Read(id[6:]); err != nil {
+		return "", err
+	}
+	putMilliseconds(id[:], time.Now())
+	id[6] = id[6]&0x0f | 0x70 // version 7
+	id[8] = id[8]&0x3f | 0x80 // variant RFC 4122
+	return id.String(), nil
+}
+
+// crockford is the alphabet of ULIDs, Crockford's base32
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a ULID, its first 48 bits are the Unix time in milliseconds and the others random, encoded in 26
+// characters of Crockford's base32, so that ULIDs sort by their creation time, as bytes and as strings
+func NewULID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[6:]); err != nil {
+		return "", err
+	}
+	putMilliseconds(id[:], time.Now())
+
+	// 26 characters of 5 bits encode 130 bits, the first 2 are zero
+	var encoded [26]byte
+	for idx := range encoded {
+		var value byte
+		for bit := 0; bit < 5; bit++ {
+			if pos := idx*5 + bit - 2; pos >= 0 && id[pos/8]&(0x80>>(pos%8)) != 0 {
+				value |= 0x10 >> bit
+			}
+		}
+		encoded[idx] = crockford[value]
+	}
+	return string(encoded[:]), nil
+}
+
+// IsULID reports whether s is a ULID, in upper or lower case
+func IsULID(s string) bool {
+	if len(s) != 26 || s[0] > '7' {
+		return false
+	}
+	for _, char := range strings.ToUpper(s) {
+		if !strings.ContainsRune(crockford, char) {
+			return false
+		}
+	}
+	return true
+}
+
+// putMilliseconds puts the Unix time of t in milliseconds into the first 48 bits of id
+func putMilliseconds(id []byte, t time.Time) {
+	var milliseconds [8]byte
+	binary.BigEndian.PutUint64(milliseconds[:], uint64(t.UnixNano()/int64(time.Millisecond)))
+	copy(id[:6], milliseconds[2:])
+}
+
+// EncodeUUID returns the compact URL-safe form of a UUID, its 16 bytes in 22 characters of unpadded base64url
+func EncodeUUID(id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
+
+// DecodeUUID parses a UUID in its compact form, see EncodeUUID, or in the forms of uuid.Parse, like the canonical one
+func DecodeUUID(s string) (uuid.UUID, error) {
+	if len(s) == 22 {
+		var id uuid.UUID
+		if n, err := base64.RawURLEncoding.Decode(id[:], []byte(s)); err == nil && n == len(id) {
+			return id, nil
+		}
+	}
+	return uuid.Parse(s)
+}
@@ -0,0 +1,76 @@
+package utils
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewULID(t *testing.T) {
+	var ids []string
+	for i := 0; i < 3; i++ {
+		id, err := NewULID()
+		require.NoError(t, err)
+		assert.True(t, IsULID(id), id)
+		ids = append(ids, id)
+		time.Sleep(2 * time.Millisecond)
+	}
+	assert.True(t, sort.StringsAreSorted(ids), "ULIDs sort by their creation time")
+
+	assert.True(t, IsULID("01arz3ndektsv4rrffq69g5fav"))
+	assert.False(t, IsULID("81ARZ3NDEKTSV4RRFFQ69G5FAV"))
+	assert.False(t, IsULID("01ARZ3NDEKTSV4RRFFQ69G5FAU"))
+	assert.False(t, IsULID("01ARZ3NDEK"))
+}
+
+func TestNewUUIDv7(t *testing.T) {
+	first, err := NewUUIDv7()
+	require.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	second, err := NewUUIDv7()
+	require.NoError(t, err)
+	assert.Less(t, first, second)
+
+	id, err := uuid.Parse(first)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(7), id.Version())
+	assert.Equal(t, uuid.RFC4122, id.Variant())
+}
+
+func TestEncodeUUID(t *testing.T) {
+	id := uuid.MustParse("0f8fad5b-d9cb-469f-a165-70867728950e")
+	encoded := EncodeUUID(id)
+	assert.Len(t, encoded, 22)
+
+	for _, s := range []string{encoded, id.String()} {
+		decoded, err := DecodeUUID(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, id, decoded)
+	}
+	_, err := DecodeUUID("not-a-uuid")
+	assert.Error(t, err)
+}
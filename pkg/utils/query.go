@@ -0,0 +1,170 @@
+package utils
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// AppendURL appends values to the query part of the request url, keeping the existing values of the key.
+// Unlike PatchURL, the order and encoding of the other parameters are preserved.
+//
+//	AppendURL("google.com?tag=a","tag","b") => "google.com?tag=a&tag=b"
+func AppendURL(originalURL string, params ...interface{}) (patchedURL string, err error) {
+	return patchQuery(originalURL, func(query []queryParam) []queryParam {
+		for i := 0; i < len(params)/2; i++ {
+			key := fmt.Sprintf("%v", params[i*2])
+			for _, value := range queryValues(params[i*2+1]) {
+				if value != "" && !hasQueryParam(query, key, value) {
+					query = append(query, newQueryParam(key, value))
+				}
+			}
+		}
+		return query
+	})
+}
+
+// RemoveURLValue removes one value of a key from the query part of the request url, keeping its other values.
+//
+//	RemoveURLValue("google.com?tag=a&tag=b","tag","a") => "google.com?tag=b"
+func RemoveURLValue(originalURL string, key string, value interface{}) (patchedURL string, err error) {
+	values := queryValues(value)
+	return patchQuery(originalURL, func(query []queryParam) []queryParam {
+		result := []queryParam{}
+		for _, param := range query {
+			if param.key != key || !StringSliceContains(param.value, values) {
+				result = append(result, param)
+			}
+		}
+		return result
+	})
+}
+
+// SetURLValues replaces all values of a key in the query part of the request url. The new values take the
+// position of the first existing value, an empty slice removes the key.
+//
+//	SetURLValues("google.com?tag=a&q=b","tag",[]string{"c","d"}) => "google.com?tag=c&tag=d&q=b"
+func SetURLValues(originalURL string, key string, values []string) (patchedURL string, err error) {
+	return patchQuery(originalURL, func(query []queryParam) []queryParam {
+		result := []queryParam{}
+		replaced := false
+		for _, param := range query {
+			if param.key != key {
+				result = append(result, param)
+				continue
+			}
+
+			if !replaced {
+				result = appendQueryValues(result, key, values)
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			result = appendQueryValues(result, key, values)
+		}
+		return result
+	})
+}
+
+// queryParam is a query parameter with its original encoding.
+type queryParam struct {
+	raw   string
+	key   string
+	value string
+}
+
+func newQueryParam(key, value string) queryParam {
+	return queryParam{raw: url.QueryEscape(key) + "=" + url.QueryEscape(value), key: key, value: value}
+}
+
+func parseQueryParams(rawQuery string) []queryParam {
+	query := []queryParam{}
+	for _, raw := range strings.Split(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+
+		rawKey, rawValue := raw, ""
+		if i := strings.Index(raw, "="); i >= 0 {
+			rawKey, rawValue = raw[:i], raw[i+1:]
+		}
+
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			value = rawValue
+		}
+		query = append(query, queryParam{raw: raw, key: key, value: value})
+	}
+	return query
+}
+
+func patchQuery(originalURL string, patch func([]queryParam) []queryParam) (string, error) {
+	u, err := url.Parse(originalURL)
+	if err != nil {
+		return "", err
+	}
+
+	raws := []string{}
+	for _, param := range patch(parseQueryParams(u.RawQuery)) {
+		raws = append(raws, param.raw)
+	}
+	u.RawQuery = strings.Join(raws, "&")
+	return u.String(), nil
+}
+
+func hasQueryParam(query []queryParam, key, value string) bool {
+	for _, param := range query {
+		if param.key == key && param.value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func appendQueryValues(query []queryParam, key string, values []string) []queryParam {
+	for _, value := range values {
+		query = append(query, newQueryParam(key, value))
+	}
+	return query
+}
+
+// queryValues converts a parameter value, either a single value or a slice, to query values.
+func queryValues(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprintf("%v", item))
+		}
+		return values
+	default:
+		return []string{fmt.Sprintf("%v", v)}
+	}
+}
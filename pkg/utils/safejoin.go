@@ -0,0 +1,147 @@
+package utils
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrInvalidFilepath is returned when a path would escape its root directory.
+var ErrInvalidFilepath = errors.New("invalid filepath")
+
+// windowsReservedNames are the device names which can't be used as file names on Windows, with or without extension.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SafeJoinOptions are the options of SafeJoinWithOptions.
+type SafeJoinOptions struct {
+	// ResolveSymlinks evaluates the symlinks of the joined path, and rejects it if they point outside of the root.
+	ResolveSymlinks bool
+	// AllowAbsolute allows absolute path elements, they are joined to the root like relative ones.
+	AllowAbsolute bool
+}
+
+// SafeJoinWithOptions joins paths to root, and returns an error if the result is not inside root.
+// Unless allowed, absolute elements are rejected, as are NUL bytes and, on Windows, device names like CON or NUL.
+func SafeJoinWithOptions(opts SafeJoinOptions, root string, paths ...string) (string, error) {
+	for _, p := range paths {
+		if err := validatePathElement(p, opts.AllowAbsolute); err != nil {
+			return "", err
+		}
+	}
+
+	root = filepath.Clean(root)
+	result := filepath.Join(append([]string{root}, paths...)...)
+	if !isWithinRoot(root, result) {
+		return "", ErrInvalidFilepath
+	}
+
+	if opts.ResolveSymlinks {
+		return resolveSymlinks(root, result)
+	}
+	return result, nil
+}
+
+// ValidateArchivePath validates the name of a zip or tar entry, and returns the path it should be extracted to in root.
+// It is meant to be called for each entry while extracting, so symlinks written by previous entries are taken into account.
+func ValidateArchivePath(root, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%s: %w", name, ErrInvalidFilepath)
+	}
+
+	// entry names always use slashes, but some archivers write backslashes on Windows.
+	result, err := SafeJoinWithOptions(SafeJoinOptions{ResolveSymlinks: true}, root, filepath.FromSlash(strings.ReplaceAll(name, `\`, "/")))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return result, nil
+}
+
+func validatePathElement(p string, allowAbsolute bool) error {
+	if strings.ContainsRune(p, 0) {
+		return ErrInvalidFilepath
+	}
+
+	if !allowAbsolute && (filepath.IsAbs(p) || filepath.VolumeName(p) != "" || strings.HasPrefix(p, "/") || strings.HasPrefix(p, `\`)) {
+		return ErrInvalidFilepath
+	}
+
+	if runtime.GOOS == "windows" {
+		for _, name := range strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }) {
+			if isWindowsReservedName(name) {
+				return ErrInvalidFilepath
+			}
+		}
+	}
+	return nil
+}
+
+// isWindowsReservedName reports whether name is a device name on Windows, such as "nul" or "COM1.txt".
+func isWindowsReservedName(name string) bool {
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+	return windowsReservedNames[strings.ToUpper(strings.TrimRight(name, " "))]
+}
+
+func isWithinRoot(root, path string) bool {
+	return path == root || strings.HasPrefix(path, strings.TrimSuffix(root, string(os.PathSeparator))+string(os.PathSeparator))
+}
+
+// resolveSymlinks evaluates the symlinks of path, whose missing elements can't be links yet.
+func resolveSymlinks(root, path string) (string, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if os.IsNotExist(err) {
+		return path, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	existing, missing := path, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		missing = filepath.Join(filepath.Base(existing), missing)
+		existing = filepath.Dir(existing)
+	}
+
+	realPath, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		// dangling links could still be written through
+		return "", ErrInvalidFilepath
+	}
+
+	if !isWithinRoot(filepath.Clean(realRoot), realPath) {
+		return "", ErrInvalidFilepath
+	}
+	return filepath.Join(realPath, missing), nil
+}
@@ -0,0 +1,182 @@
+package utils
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/gosimple/unidecode"
+)
+
+// Transliterator converts a string to its ASCII representation, using the rules of a locale.
+type Transliterator interface {
+	Transliterate(str, locale string) string
+}
+
+// TransliteratorFunc is an adapter to use ordinary functions as a Transliterator.
+type TransliteratorFunc func(str, locale string) string
+
+// Transliterate calls f(str, locale).
+func (f TransliteratorFunc) Transliterate(str, locale string) string {
+	return f(str, locale)
+}
+
+// DefaultTransliterator applies the rules registered for the locale, then transliterates the
+// remaining characters phonetically, eg. "语言" to "Yu Yan".
+var DefaultTransliterator Transliterator = TransliteratorFunc(func(str, locale string) string {
+	return unidecode.Unidecode(SubstituteLocaleRules(str, locale))
+})
+
+// ParamStringOptions customizes the conversion of ToParamStringWithOptions.
+type ParamStringOptions struct {
+	// Locale selects the transliteration rules, eg. "de" or "ru".
+	Locale string
+	// Separator separates the words, it defaults to "_" for ASCII strings and "-" for transliterated strings.
+	Separator string
+	// MaxLength shortens the result after a full word, if possible. Zero means no limit.
+	MaxLength int
+	// Transliterator overrides the DefaultTransliterator.
+	Transliterator Transliterator
+}
+
+var (
+	localeRulesMutex sync.RWMutex
+	localeRules      = map[string]map[rune]string{}
+
+	nonParamCharsRegexp = regexp.MustCompile("[^a-z0-9_]+")
+)
+
+func init() {
+	RegisterLocaleRules("en", map[rune]string{'&': "and", '@': "at"})
+	RegisterLocaleRules("de", map[rune]string{
+		'&': "und", '@': "an",
+		'ä': "ae", 'ö': "oe", 'ü': "ue", 'ß': "ss",
+		'Ä': "Ae", 'Ö': "Oe", 'Ü': "Ue",
+	})
+	RegisterLocaleRules("ru", withUppercaseRules(map[rune]string{
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+		'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+		'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+		'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+		'я': "ya",
+	}))
+	RegisterLocaleRules("uk", withUppercaseRules(map[rune]string{
+		'а': "a", 'б': "b", 'в': "v", 'г': "h", 'ґ': "g", 'д': "d", 'е': "e", 'є': "ye",
+		'ж': "zh", 'з': "z", 'и': "y", 'і': "i", 'ї': "yi", 'й': "y", 'к': "k", 'л': "l",
+		'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+		'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ь': "", 'ю': "yu",
+		'я': "ya",
+	}))
+}
+
+// RegisterLocaleRules registers the character replacements of a locale, which are applied before
+// the phonetic transliteration. Rules of an existing locale are replaced.
+func RegisterLocaleRules(locale string, rules map[rune]string) {
+	localeRulesMutex.Lock()
+	defer localeRulesMutex.Unlock()
+
+	localeRules[strings.ToLower(locale)] = rules
+}
+
+// SubstituteLocaleRules replaces the characters of str by the rules of a locale, eg. "de-CH"
+// falls back to the rules of "de". Locales without rules use the "en" rules.
+func SubstituteLocaleRules(str, locale string) string {
+	localeRulesMutex.RLock()
+	defer localeRulesMutex.RUnlock()
+
+	locale = strings.ToLower(strings.Replace(locale, "_", "-", -1))
+	rules, ok := localeRules[locale]
+	if !ok {
+		rules, ok = localeRules[strings.SplitN(locale, "-", 2)[0]]
+	}
+	if !ok {
+		rules = localeRules["en"]
+	}
+
+	var b strings.Builder
+	for _, r := range str {
+		if replacement, ok := rules[r]; ok {
+			b.WriteString(replacement)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ToParamStringWithOptions works like ToParamString, with a configurable locale, separator,
+// maximum length and transliterator.
+func ToParamStringWithOptions(str string, options ParamStringOptions) string {
+	separator := options.Separator
+
+	var param string
+	if asicsiiRegexp.MatchString(str) {
+		param = orm.ToDBName(strings.Replace(str, " ", "_", -1))
+		if separator == "" {
+			separator = "_"
+		} else {
+			param = strings.Replace(param, "_", separator, -1)
+		}
+	} else {
+		if separator == "" {
+			separator = "-"
+		}
+
+		transliterator := options.Transliterator
+		if transliterator == nil {
+			transliterator = DefaultTransliterator
+		}
+		param = strings.ToLower(transliterator.Transliterate(strings.TrimSpace(str), options.Locale))
+		param = strings.Trim(nonParamCharsRegexp.ReplaceAllString(param, separator), separator+"_")
+	}
+
+	return truncateParamString(param, separator, options.MaxLength)
+}
+
+// truncateParamString shortens param to maxLength after a full word, or in the first word if it is too long.
+func truncateParamString(param, separator string, maxLength int) string {
+	if maxLength <= 0 || len(param) <= maxLength {
+		return param
+	}
+
+	truncated := param[:maxLength]
+	if !strings.HasPrefix(param[maxLength:], separator) {
+		if i := strings.LastIndex(truncated, separator); i > 0 {
+			truncated = truncated[:i]
+		}
+	}
+	return strings.TrimRight(truncated, separator)
+}
+
+func withUppercaseRules(rules map[rune]string) map[rune]string {
+	result := make(map[rune]string, len(rules)*2)
+	for r, replacement := range rules {
+		result[r] = replacement
+		if upper := unicode.ToUpper(r); upper != r {
+			result[upper] = strings.Title(replacement)
+		}
+	}
+	return result
+}
@@ -44,11 +44,11 @@ import (
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	appsvr "github.com/bhojpur/application/pkg/engine"
 	orm "github.com/bhojpur/orm/pkg/engine"
 	now "github.com/bhojpur/orm/pkg/now"
-	"github.com/gosimple/slug"
 	"github.com/microcosm-cc/bluemonday"
 
 	"github.com/docker/docker/client"
@@ -113,16 +113,45 @@ var GetDBFromRequest = func(req *http.Request) *orm.DB {
 }
 
 // HumanizeString Humanize separates string based on capitalizd letters
-// e.g. "OrderItem" -> "Order Item"
+// e.g. "OrderItem" -> "Order Item", registered acronyms and overrides are
+// respected, see RegisterAcronyms and RegisterHumanizeOverride
 func HumanizeString(str string) string {
-	var human []rune
-	for i, l := range str {
-		if i > 0 && isUppercase(byte(l)) {
+	humanizeLock.RLock()
+	defer humanizeLock.RUnlock()
+
+	if label, ok := humanizeOverrides[str]; ok {
+		return label
+	}
+
+	var (
+		human        []rune
+		afterAcronym bool
+	)
+	for i := 0; i < len(str); {
+		wordStart := i == 0 || afterAcronym || str[i-1] == ' ' || (!isUppercase(str[i-1]) && isUppercase(str[i]))
+		if acronym := matchAcronym(str, i, wordStart); acronym != "" {
+			if len(human) > 0 && human[len(human)-1] != ' ' {
+				human = append(human, ' ')
+			}
+			human = append(human, []rune(acronym)...)
+			i += len(acronym)
+			afterAcronym = true
+			continue
+		}
+
+		l, size := utf8.DecodeRuneInString(str[i:])
+		if afterAcronym {
+			if l != ' ' {
+				human = append(human, ' ')
+			}
+		} else if i > 0 && isUppercase(byte(l)) {
 			if (!isUppercase(str[i-1]) && str[i-1] != ' ') || (i+1 < len(str) && !isUppercase(str[i+1]) && str[i+1] != ' ' && str[i-1] != ' ') {
 				human = append(human, rune(' '))
 			}
 		}
 		human = append(human, l)
+		i += size
+		afterAcronym = false
 	}
 	return strings.Title(string(human))
 }
@@ -134,16 +163,13 @@ func isUppercase(char byte) bool {
 var asicsiiRegexp = regexp.MustCompile("^(\\w|\\s|-|!)*$")
 
 // ToParamString replaces spaces and separates words (by uppercase letters) with
-// underscores in a string, also downcase it
+// underscores in a string, also downcase it, non ASCII strings are transliterated
 // e.g. ToParamString -> to_param_string, To ParamString -> to_param_string
 func ToParamString(str string) string {
-	if asicsiiRegexp.MatchString(str) {
-		return orm.ToDBName(strings.Replace(str, " ", "_", -1))
-	}
-	return slug.Make(str)
+	return ToParamStringWithOptions(str, ParamStringOptions{})
 }
 
-// PatchURL updates the query part of the request url.
+// PatchURL updates the query part of the request url, a []string value sets all values of the key.
 //     PatchURL("google.com","key","value") => "google.com?key=value"
 func PatchURL(originalURL string, params ...interface{}) (patchedURL string, err error) {
 	url, err := url.Parse(originalURL)
@@ -155,6 +181,10 @@ func PatchURL(originalURL string, params ...interface{}) (patchedURL string, err
 	for i := 0; i < len(params)/2; i++ {
 		// Check if params is key&value pair
 		key := fmt.Sprintf("%v", params[i*2])
+		if values, ok := params[i*2+1].([]string); ok {
+			query[key] = values
+			continue
+		}
 		value := fmt.Sprintf("%v", params[i*2+1])
 
 		if value == "" {
@@ -308,12 +338,16 @@ func filenameWithLineNum() string {
 	return ""
 }
 
-// GetLocale get locale from request, cookie, after get the locale, will write the locale to the cookie if possible
+// GetLocale get locale from context, request, cookie, after get the locale, will write the locale to the cookie if possible
 // Overwrite the default logic with
 //     utils.GetLocale = func(context *appsvr.Context) string {
 //         // ....
 //     }
 var GetLocale = func(context *appsvr.Context) string {
+	if locale := context.GetLocale(); locale != "" {
+		return locale
+	}
+
 	if locale := context.Request.Header.Get("Locale"); locale != "" {
 		return locale
 	}
@@ -440,7 +474,7 @@ func SafeJoin(paths ...string) (string, error) {
 	result := path.Join(paths...)
 	// check filepath
 	if !strings.HasPrefix(result, filepath.Clean(paths[0])+string(os.PathSeparator)) {
-		return "", errors.New("invalid filepath")
+		return "", ErrInvalidFilepath
 	}
 
 	return result, nil
@@ -539,15 +573,7 @@ func PrintTable(csvContent string) {
 
 // WriteTable writes the csv table to writer.
 func WriteTable(writer io.Writer, csvContent string) {
-	table := tablewriter.NewWriter(writer)
-	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-	table.SetBorder(false)
-	table.SetHeaderLine(false)
-	table.SetRowLine(false)
-	table.SetCenterSeparator("")
-	table.SetRowSeparator("")
-	table.SetColumnSeparator("")
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table := newTable(writer)
 	scanner := bufio.NewScanner(strings.NewReader(csvContent))
 	header := true
 
@@ -565,6 +591,28 @@ func WriteTable(writer io.Writer, csvContent string) {
 	table.Render()
 }
 
+// WriteRows writes the header and rows as a table to writer. Unlike WriteTable,
+// cells may contain commas.
+func WriteRows(writer io.Writer, header []string, rows [][]string) {
+	table := newTable(writer)
+	table.SetHeader(header)
+	table.AppendBulk(rows)
+	table.Render()
+}
+
+func newTable(writer io.Writer) *tablewriter.Table {
+	table := tablewriter.NewWriter(writer)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetBorder(false)
+	table.SetHeaderLine(false)
+	table.SetRowLine(false)
+	table.SetCenterSeparator("")
+	table.SetRowSeparator("")
+	table.SetColumnSeparator("")
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	return table
+}
+
 func TruncateString(str string, maxLength int) string {
 	strLength := len(str)
 	if strLength <= maxLength {
@@ -22,6 +22,9 @@ package utils
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
@@ -52,6 +55,50 @@ func TestHumanizeString(t *testing.T) {
 	}
 }
 
+func TestHumanizeStringWithAcronyms(t *testing.T) {
+	RegisterAcronyms("SKU", "OAuth", "OAuth2")
+	RegisterHumanizeOverride("Qty", "Quantity")
+
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{"ProductSku", "Product SKU"},
+		{"ProductSKUCode", "Product SKU Code"},
+		{"OAuth2Token", "OAuth2 Token"},
+		{"Skull", "Skull"},
+		{"Qty", "Quantity"},
+		{"OrderIDItem", "Order ID Item"},
+	}
+	for _, c := range cases {
+		if got := HumanizeString(c.input); got != c.want {
+			t.Errorf("HumanizeString(%q) = %q; want %q", c.input, got, c.want)
+		}
+	}
+}
+
+func TestHumanizeStringWithLocale(t *testing.T) {
+	RegisterLocaleHumanizeOverride("de", "OrderItem", "Bestellposten")
+	RegisterLocaleHumanizeOverride("de_CH", "OrderItem", "Bestellposition")
+
+	cases := []struct {
+		input  string
+		locale string
+		want   string
+	}{
+		{"OrderItem", "de", "Bestellposten"},
+		{"OrderItem", "de-AT", "Bestellposten"},
+		{"OrderItem", "de-CH", "Bestellposition"},
+		{"OrderItem", "fr", "Order Item"},
+		{"OrderID", "de", "Order ID"},
+	}
+	for _, c := range cases {
+		if got := HumanizeStringWithLocale(c.input, c.locale); got != c.want {
+			t.Errorf("HumanizeStringWithLocale(%q, %q) = %q; want %q", c.input, c.locale, got, c.want)
+		}
+	}
+}
+
 func TestToParamString(t *testing.T) {
 	results := map[string]string{
 		"OrderItem":  "order_item",
@@ -71,6 +118,33 @@ func TestToParamString(t *testing.T) {
 	}
 }
 
+func TestToParamStringWithOptions(t *testing.T) {
+	cases := []struct {
+		input   string
+		options ParamStringOptions
+		want    string
+	}{
+		{"Über Größe", ParamStringOptions{}, "uber-grosse"},
+		{"Über Größe", ParamStringOptions{Locale: "de"}, "ueber-groesse"},
+		{"Über Größe", ParamStringOptions{Locale: "de_CH"}, "ueber-groesse"},
+		{"Щука и ёж", ParamStringOptions{Locale: "ru"}, "shchuka-i-yozh"},
+		{"Гора", ParamStringOptions{Locale: "uk"}, "hora"},
+		{"语言", ParamStringOptions{Separator: "_"}, "yu_yan"},
+		{"OrderItem", ParamStringOptions{Separator: "-"}, "order-item"},
+		{"Order Item Price", ParamStringOptions{MaxLength: 12}, "order_item"},
+		{"OrderItemPrice", ParamStringOptions{MaxLength: 5}, "order"},
+		{"Verylongword", ParamStringOptions{MaxLength: 4}, "very"},
+		{"语言", ParamStringOptions{Transliterator: TransliteratorFunc(func(str, locale string) string {
+			return "custom " + locale
+		}), Locale: "zh"}, "custom-zh"},
+	}
+	for _, c := range cases {
+		if got := ToParamStringWithOptions(c.input, c.options); got != c.want {
+			t.Errorf("ToParamStringWithOptions(%q, %+v) = %q; want %q", c.input, c.options, got, c.want)
+		}
+	}
+}
+
 func TestPatchURL(t *testing.T) {
 	var cases = []struct {
 		original string
@@ -88,6 +162,11 @@ func TestPatchURL(t *testing.T) {
 			input:    []interface{}{"locale", ""},
 			want:     "http://app.bhojpur.net/admin/orders?q=dotnet&test=1#test",
 		},
+		{
+			original: "http://app.bhojpur.net/admin/orders?locale=global&tag=a",
+			input:    []interface{}{"tag", []string{"b", "c"}},
+			want:     "http://app.bhojpur.net/admin/orders?locale=global&tag=b&tag=c",
+		},
 	}
 	for _, c := range cases {
 		// u, _ := url.Parse(c.original)
@@ -108,6 +187,59 @@ func TestPatchURL(t *testing.T) {
 	}
 }
 
+func TestMultiValueURL(t *testing.T) {
+	const original = "http://app.bhojpur.net/admin/products?tag=a&q=dot%20net&tag=b#test"
+	var cases = []struct {
+		name  string
+		patch func() (string, error)
+		want  string
+	}{
+		{
+			name:  "append",
+			patch: func() (string, error) { return AppendURL(original, "tag", "c") },
+			want:  "http://app.bhojpur.net/admin/products?tag=a&q=dot%20net&tag=b&tag=c#test",
+		},
+		{
+			name:  "append existing",
+			patch: func() (string, error) { return AppendURL(original, "tag", []string{"a", "c d"}) },
+			want:  "http://app.bhojpur.net/admin/products?tag=a&q=dot%20net&tag=b&tag=c+d#test",
+		},
+		{
+			name:  "remove one value",
+			patch: func() (string, error) { return RemoveURLValue(original, "tag", "a") },
+			want:  "http://app.bhojpur.net/admin/products?q=dot%20net&tag=b#test",
+		},
+		{
+			name:  "remove decoded value",
+			patch: func() (string, error) { return RemoveURLValue(original, "q", "dot net") },
+			want:  "http://app.bhojpur.net/admin/products?tag=a&tag=b#test",
+		},
+		{
+			name:  "set values",
+			patch: func() (string, error) { return SetURLValues(original, "tag", []string{"c", "d"}) },
+			want:  "http://app.bhojpur.net/admin/products?tag=c&tag=d&q=dot%20net#test",
+		},
+		{
+			name:  "set new key",
+			patch: func() (string, error) { return SetURLValues(original, "page", []string{"2"}) },
+			want:  "http://app.bhojpur.net/admin/products?tag=a&q=dot%20net&tag=b&page=2#test",
+		},
+		{
+			name:  "set no values",
+			patch: func() (string, error) { return SetURLValues(original, "tag", nil) },
+			want:  "http://app.bhojpur.net/admin/products?q=dot%20net#test",
+		},
+	}
+	for _, c := range cases {
+		got, err := c.patch()
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		} else if got != c.want {
+			t.Errorf("%s: got %s; want %s", c.name, got, c.want)
+		}
+	}
+}
+
 func TestJoinURL(t *testing.T) {
 	var cases = []struct {
 		original string
@@ -190,6 +322,64 @@ func TestSafeJoin(t *testing.T) {
 	}
 }
 
+func TestSafeJoinWithOptions(t *testing.T) {
+	root := t.TempDir()
+
+	t.Run("reject absolute paths", func(t *testing.T) {
+		_, err := SafeJoinWithOptions(SafeJoinOptions{}, root, "/etc/passwd")
+		assert.ErrorIs(t, err, ErrInvalidFilepath)
+
+		pth, err := SafeJoinWithOptions(SafeJoinOptions{AllowAbsolute: true}, root, "/etc/passwd")
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, "etc", "passwd"), pth)
+	})
+
+	t.Run("reject NUL bytes", func(t *testing.T) {
+		_, err := SafeJoinWithOptions(SafeJoinOptions{}, root, "hello\x00world")
+		assert.ErrorIs(t, err, ErrInvalidFilepath)
+	})
+
+	t.Run("reject Windows device names", func(t *testing.T) {
+		assert.True(t, isWindowsReservedName("nul"))
+		assert.True(t, isWindowsReservedName("COM1.txt"))
+		assert.False(t, isWindowsReservedName("console"))
+	})
+
+	t.Run("resolve symlinks", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("symlinks require privileges on Windows")
+		}
+		outside := t.TempDir()
+		assert.NoError(t, os.Symlink(outside, filepath.Join(root, "outside")))
+		assert.NoError(t, os.Mkdir(filepath.Join(root, "inside"), os.ModePerm))
+		assert.NoError(t, os.Symlink("inside", filepath.Join(root, "link")))
+
+		_, err := SafeJoinWithOptions(SafeJoinOptions{}, root, "outside", "file")
+		assert.NoError(t, err)
+		_, err = SafeJoinWithOptions(SafeJoinOptions{ResolveSymlinks: true}, root, "outside", "file")
+		assert.ErrorIs(t, err, ErrInvalidFilepath)
+
+		pth, err := SafeJoinWithOptions(SafeJoinOptions{ResolveSymlinks: true}, root, "link", "file")
+		assert.NoError(t, err)
+		realRoot, _ := filepath.EvalSymlinks(root)
+		assert.Equal(t, filepath.Join(realRoot, "inside", "file"), pth)
+	})
+}
+
+func TestValidateArchivePath(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NoError(t, err)
+
+	pth, err := ValidateArchivePath(root, "bin/appsvr")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "bin", "appsvr"), pth)
+
+	for _, name := range []string{"", "../appsvr", "bin/../../appsvr", "/bin/appsvr", "..\\appsvr"} {
+		_, err := ValidateArchivePath(root, name)
+		assert.ErrorIs(t, err, ErrInvalidFilepath, name)
+	}
+}
+
 func TestToISO8601DateTimeString(t *testing.T) {
 	t.Run("succeed to convert time.Time to ISO8601 datetime string", func(t *testing.T) {
 		testDateTime, err := time.Parse(time.RFC3339, "2020-01-02T15:04:05.123Z")
@@ -21,11 +21,6 @@ package validations
 // THE SOFTWARE.
 
 import (
-	"fmt"
-	"regexp"
-	"strings"
-
-	errsvr "github.com/bhojpur/errors/pkg/validation"
 	orm "github.com/bhojpur/orm/pkg/engine"
 )
 
@@ -38,15 +33,8 @@ func validate(scope *orm.Scope) {
 				scope.CallMethod("Validate")
 				if scope.Value != nil {
 					resource := scope.IndirectValue().Interface()
-					_, validatorErrors := errsvr.ValidateStruct(resource)
-					if validatorErrors != nil {
-						if errors, ok := validatorErrors.(errsvr.Errors); ok {
-							for _, err := range flatValidatorErrors(errors) {
-								scope.DB().AddError(formattedError(err, resource))
-							}
-						} else {
-							scope.DB().AddError(validatorErrors)
-						}
+					for _, err := range ValidateTags(resource) {
+						scope.DB().AddError(err)
 					}
 				}
 			}
@@ -54,39 +42,6 @@ func validate(scope *orm.Scope) {
 	}
 }
 
-func flatValidatorErrors(validatorErrors errsvr.Errors) []errsvr.Error {
-	resultErrors := []errsvr.Error{}
-	for _, validatorError := range validatorErrors.Errors() {
-		if errors, ok := validatorError.(errsvr.Errors); ok {
-			for _, e := range errors {
-				resultErrors = append(resultErrors, e.(errsvr.Error))
-			}
-		}
-		if e, ok := validatorError.(errsvr.Error); ok {
-			resultErrors = append(resultErrors, e)
-		}
-	}
-	return resultErrors
-}
-
-func formattedError(err errsvr.Error, resource interface{}) error {
-	message := err.Error()
-	attrName := err.Name
-	if strings.Index(message, "non zero value required") >= 0 {
-		message = fmt.Sprintf("%v can't be blank", attrName)
-	} else if strings.Index(message, "as length") >= 0 {
-		reg, _ := regexp.Compile(`\(([0-9]+)\|([0-9]+)\)`)
-		submatch := reg.FindSubmatch([]byte(err.Error()))
-		message = fmt.Sprintf("%v is the wrong length (should be %v~%v characters)", attrName, string(submatch[1]), string(submatch[2]))
-	} else if strings.Index(message, "as numeric") >= 0 {
-		message = fmt.Sprintf("%v is not a number", attrName)
-	} else if strings.Index(message, "as email") >= 0 {
-		message = fmt.Sprintf("%v is not a valid email address", attrName)
-	}
-	return NewError(resource, attrName, message)
-
-}
-
 // RegisterCallbacks register callback into Bhojpur ORM DB
 func RegisterCallbacks(db *orm.DB) {
 	callback := db.Callback()
@@ -0,0 +1,318 @@
+package validations
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	errsvr "github.com/bhojpur/errors/pkg/validation"
+)
+
+// Rule is a rule of the `valid` tags of fields, like `valid:"required,max=255,email"`. Check returns false if the
+// value of a field fails the rule with the params of its tag, like ["255"], the value isn't a pointer. Message is the
+// message of the errors, the names of Params are the ones of the params of the tag, "{field}" and "{<param>}" are
+// replaced in it, see Error.Translate. Rules except "required" aren't checked for blank values. The validators of
+// Bhojpur Errors, like "alphanum" or "range(min|max)", are rules too, unless a rule of the same name is registered
+type Rule struct {
+	Check   func(value interface{}, params []string) bool
+	Params  []string
+	Message string
+}
+
+var (
+	rules      = map[string]*Rule{}
+	rulesMutex sync.RWMutex
+)
+
+// RegisterRule registers a rule of the `valid` tags of fields, it replaces the rule of the same name, e.g.
+//
+//	validations.RegisterRule("sku", &validations.Rule{
+//		Check:   func(value interface{}, params []string) bool { return skuRegexp.MatchString(fmt.Sprint(value)) },
+//		Message: "{field} is not a valid SKU",
+//	})
+func RegisterRule(name string, rule *Rule) {
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+	rules[name] = rule
+}
+
+// GetRule returns a registered rule of the `valid` tags of fields
+func GetRule(name string) (*Rule, bool) {
+	rulesMutex.RLock()
+	defer rulesMutex.RUnlock()
+	rule, ok := rules[name]
+	return rule, ok
+}
+
+// ruleOf returns the registered rule of the name, or the rule of the validator of Bhojpur Errors of the name, whose
+// custom type validators get the record
+func ruleOf(name string, record interface{}) (*Rule, bool) {
+	if rule, ok := GetRule(name); ok {
+		return rule, true
+	}
+
+	if validator, ok := errsvr.TagMap[name]; ok {
+		return &Rule{Message: "{field} is invalid", Check: func(value interface{}, params []string) bool {
+			return validator(fmt.Sprint(value))
+		}}, true
+	}
+	if validator, ok := errsvr.ParamTagMap[name]; ok {
+		return &Rule{Message: "{field} is invalid", Check: func(value interface{}, params []string) bool {
+			// the params are the ones the tag parser of Bhojpur Errors would pass, like the list of in as one param
+			if pattern, ok := errsvr.ParamTagRegexMap[name]; ok {
+				submatches := pattern.FindStringSubmatch(name + "(" + strings.Join(params, "|") + ")")
+				if submatches == nil {
+					return false
+				}
+				params = submatches[1:]
+			}
+			return validator(fmt.Sprint(value), params...)
+		}}, true
+	}
+	if validator, ok := errsvr.CustomTypeTagMap.Get(name); ok {
+		return &Rule{Message: "{field} is invalid", Check: func(value interface{}, params []string) bool {
+			return validator(value, record)
+		}}, true
+	}
+	return nil, false
+}
+
+func init() {
+	RegisterRule(CodeRequired, &Rule{Message: "{field} can't be blank", Check: func(value interface{}, params []string) bool {
+		return !IsBlank(value)
+	}})
+	// the rules of the validators of Bhojpur Errors check the same, with messages of their own
+	RegisterRule(CodeEmail, &Rule{Message: "{field} is not a valid email address", Check: func(value interface{}, params []string) bool {
+		return errsvr.IsEmail(fmt.Sprint(value))
+	}})
+	RegisterRule(CodeNumeric, &Rule{Message: "{field} is not a number", Check: func(value interface{}, params []string) bool {
+		return errsvr.IsNumeric(fmt.Sprint(value))
+	}})
+	RegisterRule("url", &Rule{Message: "{field} is not a valid URL", Check: func(value interface{}, params []string) bool {
+		return errsvr.IsURL(fmt.Sprint(value))
+	}})
+	RegisterRule("min", &Rule{Params: []string{"min"}, Message: "{field} should be at least {min}", Check: func(value interface{}, params []string) bool {
+		size, min, ok := sizeOf(value, params, 0)
+		return ok && size >= min
+	}})
+	RegisterRule("max", &Rule{Params: []string{"max"}, Message: "{field} should be at most {max}", Check: func(value interface{}, params []string) bool {
+		size, max, ok := sizeOf(value, params, 0)
+		return ok && size <= max
+	}})
+	// the length of strings is their number of bytes, like the length validator of Bhojpur Errors
+	RegisterRule(CodeLength, &Rule{Params: []string{"min", "max"}, Message: "{field} is the wrong length (should be {min}~{max} characters)", Check: func(value interface{}, params []string) bool {
+		switch reflect.ValueOf(value).Kind() {
+		case reflect.Slice, reflect.Map, reflect.Array:
+			length, min, ok := sizeOf(value, params, 0)
+			_, max, maxOK := sizeOf(value, params, 1)
+			return ok && maxOK && length >= min && length <= max
+		}
+		return errsvr.ByteLength(fmt.Sprint(value), params...)
+	}})
+	RegisterRule("in", &Rule{Message: "{field} is not included in the list", Check: func(value interface{}, params []string) bool {
+		return errsvr.IsIn(fmt.Sprint(value), params...)
+	}})
+}
+
+// sizeOf returns the size of a value, the length of strings, slices and maps, or the value of numbers, and its param
+// at idx, false if it can't be compared
+func sizeOf(value interface{}, params []string, idx int) (float64, float64, bool) {
+	if idx >= len(params) {
+		return 0, 0, false
+	}
+	param, err := strconv.ParseFloat(params[idx], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), param, true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), param, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), param, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), param, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), param, true
+	}
+	return 0, 0, false
+}
+
+// IsBlank returns true if value is blank, nil, a blank string, an empty slice or map, or the zero value of its type
+func IsBlank(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	return isBlank(v)
+}
+
+func isBlank(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+// tagRule is a rule of the `valid` tag of a field
+type tagRule struct {
+	name    string
+	params  []string
+	message string
+}
+
+// fieldRules are the rules of the `valid` tag of a field, index is the index path of the field in its struct
+type fieldRules struct {
+	name  string
+	index []int
+	rules []tagRule
+}
+
+// structRules caches the rules of the fields of struct types
+var structRules sync.Map
+
+// parseTag parses the rules of a `valid` tag, like "required,max=255,length(6|20),in(a|b),email~Email is invalid"
+func parseTag(tag string) []tagRule {
+	var result []tagRule
+	for _, part := range strings.Split(tag, ",") {
+		var rule tagRule
+		part = strings.TrimSpace(part)
+		if idx := strings.Index(part, "~"); idx >= 0 {
+			part, rule.message = strings.TrimSpace(part[:idx]), strings.TrimSpace(part[idx+1:])
+		}
+
+		if idx := strings.Index(part, "="); idx >= 0 {
+			rule.name, rule.params = part[:idx], strings.Split(part[idx+1:], "|")
+		} else if idx := strings.Index(part, "("); idx >= 0 && strings.HasSuffix(part, ")") {
+			rule.name, rule.params = part[:idx], strings.Split(part[idx+1:len(part)-1], "|")
+		} else {
+			rule.name = part
+		}
+
+		if rule.name != "" && rule.name != "-" {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
+
+// rulesOf returns the rules of the `valid` tags of the fields of a struct type, and of its embedded structs
+func rulesOf(typ reflect.Type) []fieldRules {
+	if cached, ok := structRules.Load(typ); ok {
+		return cached.([]fieldRules)
+	}
+
+	var result []fieldRules
+	for idx := 0; idx < typ.NumField(); idx++ {
+		field := typ.Field(idx)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.PkgPath == "" {
+			for _, embedded := range rulesOf(field.Type) {
+				embedded.index = append([]int{idx}, embedded.index...)
+				result = append(result, embedded)
+			}
+			continue
+		}
+		if tag := field.Tag.Get("valid"); field.PkgPath == "" && tag != "" {
+			if tagRules := parseTag(tag); len(tagRules) > 0 {
+				result = append(result, fieldRules{name: field.Name, index: []int{idx}, rules: tagRules})
+			}
+		}
+	}
+	structRules.Store(typ, result)
+	return result
+}
+
+// ValidateTags validates the fields of record by the rules of their `valid` tags, see Rule. The first failing rule
+// of a field is its error, unknown rules are errors too, with the code CodeInvalid. The callbacks of RegisterCallbacks
+// validate records with it
+func ValidateTags(record interface{}) Errors {
+	value := reflect.ValueOf(record)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs Errors
+	for _, field := range rulesOf(value.Type()) {
+		fieldValue := value.FieldByIndex(field.index)
+		for fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+			fieldValue = fieldValue.Elem()
+		}
+
+		blank := isBlank(fieldValue)
+		for _, tagRule := range field.rules {
+			rule, ok := ruleOf(tagRule.name, record)
+			if !ok {
+				errs.Add(NewFieldError(record, field.name, CodeInvalid, fmt.Sprintf("%v has an unknown rule %v", field.name, tagRule.name), nil))
+				break
+			}
+			if blank && tagRule.name != CodeRequired {
+				continue
+			}
+
+			var fieldInterface interface{}
+			if fieldValue.Kind() != reflect.Ptr {
+				fieldInterface = fieldValue.Interface()
+			}
+			if rule.Check(fieldInterface, tagRule.params) {
+				continue
+			}
+
+			var params map[string]interface{}
+			for idx, name := range rule.Params {
+				if idx < len(tagRule.params) {
+					if params == nil {
+						params = map[string]interface{}{}
+					}
+					params[name] = tagRule.params[idx]
+				}
+			}
+			message := tagRule.message
+			if message == "" {
+				message = rule.Message
+			}
+			errs.Add(NewFieldError(record, field.name, tagRule.name, interpolate(message, field.name, params), params))
+			break
+		}
+	}
+	return errs
+}
@@ -21,21 +21,45 @@ package validations
 // THE SOFTWARE.
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
+	appsvr "github.com/bhojpur/application/pkg/engine"
 	orm "github.com/bhojpur/orm/pkg/engine"
 )
 
+// Codes of common validation errors, the translation of the message of an error with a code is keyed
+// "validations.<code>", see Error.Translate
+const (
+	CodeRequired  = "required"
+	CodeInvalid   = "invalid"
+	CodeLength    = "length"
+	CodeNumeric   = "numeric"
+	CodeEmail     = "email"
+	CodeInclusion = "inclusion"
+	CodeNotFound  = "not_found"
+)
+
 // NewError generate a new error for a model's field
 func NewError(resource interface{}, column, err string) error {
 	return &Error{Resource: resource, Column: column, Message: err}
 }
 
-// Error is a validation error struct that hold model, column and error message
+// NewFieldError generate a new error for a model's field with the code of the failure and the params of its message,
+// e.g. NewFieldError(user, "Name", CodeLength, "Name is too long", map[string]interface{}{"max": 255})
+func NewFieldError(resource interface{}, column, code, message string, params map[string]interface{}) *Error {
+	return &Error{Resource: resource, Column: column, Code: code, Message: message, Params: params}
+}
+
+// Error is a validation error struct that hold model, column and error message. Column is the path of the field,
+// the fields of nested records are joined by ".", e.g. "Addresses.0.City", it is empty for errors of the record
 type Error struct {
-	Resource interface{}
-	Column   string
-	Message  string
+	Resource interface{}            `json:"-"`
+	Column   string                 `json:"field,omitempty"`
+	Code     string                 `json:"code,omitempty"`
+	Message  string                 `json:"message"`
+	Params   map[string]interface{} `json:"params,omitempty"`
 }
 
 // Label is a label including model type, primary key and column name
@@ -48,3 +72,139 @@ func (err Error) Label() string {
 func (err Error) Error() string {
 	return fmt.Sprintf("%v", err.Message)
 }
+
+// Translate returns the message translated to the locale of the context, the message is the key of its translation,
+// e.g. "Name can't be blank". Errors with a code are translated by the key of their code first, e.g.
+// "validations.length", their params replace their "{name}" in the translation, and {field} their column, like
+// "{field} is too long (maximum is {max} characters)"
+func (err Error) Translate(context *appsvr.Context) string {
+	message := context.T(err.Message)
+	if err.Code == "" {
+		return message
+	}
+
+	return interpolate(context.TDefault("validations."+err.Code, message), err.Column, err.Params)
+}
+
+// interpolate replaces "{field}" by column and "{<name>}" by the params in message
+func interpolate(message, column string, params map[string]interface{}) string {
+	replacements := []string{"{field}", column}
+	for name, value := range params {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(replacements...).Replace(message)
+}
+
+// Errors is a collection of the validation errors of a record, validators aggregate their failures in it
+//
+//	var errs validations.Errors
+//	if user.Name == "" {
+//		errs.Add(validations.NewFieldError(user, "Name", validations.CodeRequired, "Name can't be blank", nil))
+//	}
+//	return errs.ErrorOrNil()
+type Errors []*Error
+
+// Add adds errors to the collection
+func (errs *Errors) Add(validationErrs ...*Error) {
+	for _, err := range validationErrs {
+		if err != nil {
+			*errs = append(*errs, err)
+		}
+	}
+}
+
+// Error joins the messages of the errors
+func (errs Errors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// GetErrors returns the errors of the collection, so that they are added one by one to appsvr.Errors
+func (errs Errors) GetErrors() []error {
+	result := make([]error, 0, len(errs))
+	for _, err := range errs {
+		result = append(result, err)
+	}
+	return result
+}
+
+// ErrorOrNil returns the collection, or nil if it has no error
+func (errs Errors) ErrorOrNil() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Field returns the errors of a field, and of the fields of its nested records
+func (errs Errors) Field(column string) Errors {
+	var result Errors
+	for _, err := range errs {
+		if err.Column == column || strings.HasPrefix(err.Column, column+".") {
+			result = append(result, err)
+		}
+	}
+	return result
+}
+
+// Prefix returns copies of the errors with path prefixed to their fields, like the errors of a nested record
+func (errs Errors) Prefix(path string) Errors {
+	result := make(Errors, 0, len(errs))
+	for _, err := range errs {
+		prefixed := *err
+		if prefixed.Column == "" {
+			prefixed.Column = path
+		} else {
+			prefixed.Column = path + "." + prefixed.Column
+		}
+		result = append(result, &prefixed)
+	}
+	return result
+}
+
+// Translate returns copies of the errors with their messages translated to the locale of the context, see
+// Error.Translate
+func (errs Errors) Translate(context *appsvr.Context) Errors {
+	result := make(Errors, 0, len(errs))
+	for _, err := range errs {
+		translated := *err
+		translated.Message = err.Translate(context)
+		result = append(result, &translated)
+	}
+	return result
+}
+
+// FromError returns the validation errors of err, and the other ones, err may hold several errors like appsvr.Errors
+func FromError(err error) (Errors, []error) {
+	if err == nil {
+		return nil, nil
+	}
+
+	errs := []error{err}
+	if multiple, ok := err.(interface{ GetErrors() []error }); ok {
+		errs = multiple.GetErrors()
+	}
+
+	var (
+		validationErrs Errors
+		others         []error
+	)
+	for _, e := range errs {
+		if _, ok := e.(interface{ GetErrors() []error }); ok {
+			nestedValidationErrs, nestedOthers := FromError(e)
+			validationErrs, others = append(validationErrs, nestedValidationErrs...), append(others, nestedOthers...)
+			continue
+		}
+
+		var validationErr *Error
+		if errors.As(e, &validationErr) {
+			validationErrs = append(validationErrs, validationErr)
+		} else {
+			others = append(others, e)
+		}
+	}
+	return validationErrs, others
+}
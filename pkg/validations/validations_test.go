@@ -21,11 +21,13 @@ package validations_test
 // THE SOFTWARE.
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
 	"testing"
 
+	appsvr "github.com/bhojpur/application/pkg/engine"
 	validations "github.com/bhojpur/application/pkg/validations"
 	"github.com/bhojpur/application/test/utils"
 	errsvr "github.com/bhojpur/errors/pkg/validation"
@@ -223,3 +225,112 @@ func TestSaveAllValidData(t *testing.T) {
 		t.Errorf("Should get no error when save valid data, but got: %v", result.Error)
 	}
 }
+
+type translator map[string]string
+
+func (t translator) Translate(locale, key string) (string, bool) {
+	translation, ok := t[key]
+	return translation, ok
+}
+
+func TestErrors(t *testing.T) {
+	var errs validations.Errors
+	if errs.ErrorOrNil() != nil {
+		t.Errorf("Should get no error without validation errors")
+	}
+
+	user := &User{}
+	errs.Add(
+		validations.NewFieldError(user, "Name", validations.CodeLength, "Name is too long", map[string]interface{}{"max": 8}),
+		nil,
+		validations.NewFieldError(user, "City", validations.CodeRequired, "City can't be blank", nil),
+	)
+	errs = append(errs[:1], errs.Prefix("Addresses.0")[1:]...)
+	if errs.Error() != "Name is too long; City can't be blank" {
+		t.Errorf("Should join the messages of the errors, but got %v", errs.Error())
+	}
+	if fields := errs.Field("Addresses"); len(fields) != 1 || fields[0].Column != "Addresses.0.City" {
+		t.Errorf("Should address the errors of nested fields by their path, but got %v", fields)
+	}
+
+	var wrapped appsvr.Errors
+	wrapped.AddError(errs, errors.New("connection refused"))
+	validationErrs, others := validations.FromError(wrapped)
+	if len(validationErrs) != 2 || len(others) != 1 {
+		t.Errorf("Should split validation errors from the other ones, but got %v and %v", validationErrs, others)
+	}
+
+	encoded, _ := json.Marshal(errs[0])
+	if string(encoded) != `{"field":"Name","code":"length","message":"Name is too long","params":{"max":8}}` {
+		t.Errorf("Should encode the field, code, message and params of errors, but got %s", encoded)
+	}
+
+	context := &appsvr.Context{Config: &appsvr.Config{Translator: translator{"validations.length": "{field} ist zu lang (maximal {max} Zeichen)"}}}
+	if translated := errs.Translate(context); translated[0].Message != "Name ist zu lang (maximal 8 Zeichen)" || translated[1].Message != "City can't be blank" {
+		t.Errorf("Should translate the messages by the codes of the errors, but got %v", translated)
+	}
+}
+
+type Account struct {
+	Name    string  `valid:"required,max=8"`
+	Email   string  `valid:"email~Email is invalid"`
+	Plan    string  `valid:"in(free|pro)"`
+	Seats   int     `valid:"min=1"`
+	Website *string `valid:"url"`
+	Code    string  `valid:"sku"`
+	Handle  string  `valid:"alphanum,runelength(2|8)"`
+}
+
+type Legacy struct {
+	Name string `valid:"unknown"`
+}
+
+func TestValidateTags(t *testing.T) {
+	validations.RegisterRule("sku", &validations.Rule{
+		Check: func(value interface{}, params []string) bool {
+			return regexp.MustCompile(`^[A-Z]{3}$`).MatchString(fmt.Sprint(value))
+		},
+		Message: "{field} is not a valid SKU",
+	})
+
+	website := "not a url"
+	errs := validations.ValidateTags(&Account{Name: "Jane Doe-Smith", Email: "jane", Plan: "gold", Website: &website, Code: "abc", Handle: "jane-doe"})
+	messages := map[string]string{}
+	for _, err := range errs {
+		messages[err.Column] = err.Message
+	}
+	expected := map[string]string{
+		"Name":    "Name should be at most 8",
+		"Email":   "Email is invalid",
+		"Plan":    "Plan is not included in the list",
+		"Seats":   "",
+		"Website": "Website is not a valid URL",
+		"Code":    "Code is not a valid SKU",
+		"Handle":  "Handle is invalid",
+	}
+	for column, message := range expected {
+		if messages[column] != message {
+			t.Errorf("Should get %q for %v, but got %q", message, column, messages[column])
+		}
+	}
+	if errs.Field("Name")[0].Code != "max" || errs.Field("Name")[0].Params["max"] != "8" {
+		t.Errorf("Should get the code and params of the rule of the error, but got %v", errs.Field("Name")[0])
+	}
+
+	// rules other than required aren't checked for blank values
+	errs = validations.ValidateTags(&Account{Seats: 2})
+	if len(errs) != 1 || errs[0].Column != "Name" || errs[0].Code != validations.CodeRequired {
+		t.Errorf("Should only get the error of the required name, but got %v", errs)
+	}
+	if validations.ValidateTags(&Account{Name: "Jane", Email: "jane@example.com", Plan: "pro", Seats: 1, Code: "ABC", Handle: "jane"}) != nil {
+		t.Errorf("Should get no error for valid fields")
+	}
+
+	// the validators of Bhojpur Errors are rules, with their params
+	if errs := validations.ValidateTags(&Account{Name: "Jane", Seats: 1, Handle: "janedoesmith"}); len(errs) != 1 || errs[0].Code != "runelength" {
+		t.Errorf("Should get the error of the runelength validator, but got %v", errs)
+	}
+	if errs := validations.ValidateTags(&Legacy{Name: "Jane"}); len(errs) != 1 || errs[0].Code != validations.CodeInvalid {
+		t.Errorf("Should get an error for unknown rules, but got %v", errs)
+	}
+}
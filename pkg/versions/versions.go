@@ -0,0 +1,177 @@
+package versions
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// ErrVersionNotFound is returned when a record has no version with the number
+var ErrVersionNotFound = errors.New("versions: version not found")
+
+// Version is a snapshot of a record, stored every time it is saved. Versions of a record are numbered from 1
+type Version struct {
+	ID         uint   `orm:"primary_key" json:"-"`
+	Resource   string `orm:"size:128;unique_index:idx_resource_version" json:"resource"`
+	PrimaryKey string `orm:"size:128;unique_index:idx_resource_version" json:"primaryKey"`
+	Number     uint64 `orm:"unique_index:idx_resource_version" json:"number"`
+	// Actor is the user who saved the version, as in the audit log
+	Actor     string    `orm:"size:256" json:"actor,omitempty"`
+	Data      string    `orm:"type:text" json:"data"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TableName table name of versions
+func (Version) TableName() string {
+	return "resource_versions"
+}
+
+// Decode decodes the snapshot of the version to a new record of the resource
+func (version *Version) Decode(res *resource.Resource) (interface{}, error) {
+	record := res.NewStruct()
+	if err := json.Unmarshal([]byte(version.Data), record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Change is a field of a record changed between two versions
+type Change struct {
+	Name string      `json:"name"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Enable stores a version of the records of the resource every time they are saved, in the same transaction. The
+// table of Version has to be migrated
+func Enable(res *resource.Resource) {
+	saveHandler := res.SaveHandler
+	res.SaveHandler = func(result interface{}, context *appsvr.Context) error {
+		return context.GetDB().Transaction(func(tx *orm.DB) error {
+			txContext := context.Clone()
+			txContext.SetDB(tx)
+			if err := saveHandler(result, txContext); err != nil {
+				return err
+			}
+			return store(res, result, txContext)
+		})
+	}
+}
+
+func store(res *resource.Resource, record interface{}, context *appsvr.Context) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	var (
+		db      = context.GetDB()
+		version = Version{Resource: res.Name, PrimaryKey: res.GetPrimaryKey(record, context), Data: string(data)}
+		last    Version
+	)
+	if err := db.Where("resource = ? AND primary_key = ?", version.Resource, version.PrimaryKey).Order("number DESC").First(&last).Error; err != nil && !errors.Is(err, orm.ErrRecordNotFound) {
+		return err
+	}
+
+	version.Number = last.Number + 1
+	if context.CurrentUser != nil {
+		version.Actor = context.CurrentUser.DisplayName()
+	}
+	return db.Create(&version).Error
+}
+
+// List returns the versions of a record, in the format of resource.ToPrimaryQueryParams, in order
+func List(res *resource.Resource, primaryKey string, context *appsvr.Context) ([]Version, error) {
+	var versions []Version
+	err := context.GetDB().Where("resource = ? AND primary_key = ?", res.Name, primaryKey).Order("number").Find(&versions).Error
+	return versions, err
+}
+
+// Get returns the version of a record with the number
+func Get(res *resource.Resource, primaryKey string, number uint64, context *appsvr.Context) (*Version, error) {
+	var version Version
+	err := context.GetDB().Where("resource = ? AND primary_key = ? AND number = ?", res.Name, primaryKey, number).First(&version).Error
+	if errors.Is(err, orm.ErrRecordNotFound) {
+		return nil, ErrVersionNotFound
+	}
+	return &version, err
+}
+
+// Diff returns the fields of the resource changed between two versions of a record, in the order of the model.
+// Associations are compared as a whole
+func Diff(res *resource.Resource, from, to *Version) ([]Change, error) {
+	fromRecord, err := from.Decode(res)
+	if err != nil {
+		return nil, err
+	}
+	toRecord, err := to.Decode(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		changes    []Change
+		fromFields = (&orm.Scope{Value: fromRecord}).Fields()
+		toFields   = (&orm.Scope{Value: toRecord}).Fields()
+	)
+	for idx, field := range fromFields {
+		if field.IsIgnored {
+			continue
+		}
+
+		fromField, toField := field.Field.Interface(), toFields[idx].Field.Interface()
+		if !reflect.DeepEqual(fromField, toField) {
+			changes = append(changes, Change{Name: field.Name, From: fromField, To: toField})
+		}
+	}
+	return changes, nil
+}
+
+// Rollback restores a record to the version with the number, the restored record is validated by the validators of
+// the resource again before it is saved, which stores a new version
+func Rollback(res *resource.Resource, primaryKey string, number uint64, context *appsvr.Context) (interface{}, error) {
+	version, err := Get(res, primaryKey, number, context)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := version.Decode(res)
+	if err != nil {
+		return nil, err
+	}
+	if got := res.GetPrimaryKey(record, context); got != primaryKey {
+		return nil, fmt.Errorf("versions: version %v of %v has primary key %v", number, primaryKey, got)
+	}
+
+	var errs appsvr.Errors
+	if errs.AddError(resource.DecodeToResource(res, record, &resource.MetaValues{}, context).Validate()); errs.HasError() {
+		return nil, errs
+	}
+	return record, res.CallSave(record, context)
+}
@@ -0,0 +1,100 @@
+package versions_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"testing"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/versions"
+	"github.com/bhojpur/application/test/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Product struct {
+	ID    uint
+	Name  string
+	Price int
+}
+
+type user string
+
+func (u user) DisplayName() string {
+	return string(u)
+}
+
+func TestVersions(t *testing.T) {
+	db := utils.SQLiteTestDB(t,
+		`CREATE TABLE products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, price INTEGER)`,
+		`CREATE TABLE resource_versions (id INTEGER PRIMARY KEY AUTOINCREMENT, resource VARCHAR(128), primary_key VARCHAR(128), number INTEGER,
+			actor VARCHAR(256), data TEXT, created_at DATETIME, UNIQUE (resource, primary_key, number))`,
+	)
+
+	res := resource.New(&Product{})
+	versions.Enable(res)
+	res.AddValidator(&resource.Validator{
+		Name: "price",
+		Handler: func(record interface{}, _ *resource.MetaValues, _ *appsvr.Context) error {
+			if record.(*Product).Price < 0 {
+				return errors.New("price should be positive")
+			}
+			return nil
+		},
+	})
+	context := &appsvr.Context{Config: &appsvr.Config{DB: db}, CurrentUser: user("alice")}
+
+	product := &Product{Name: "Pen", Price: -1}
+	require.NoError(t, res.CallSave(product, context))
+	product.Price = 2
+	require.NoError(t, res.CallSave(product, context))
+	product.Name, product.Price = "Pencil", 3
+	require.NoError(t, res.CallSave(product, context))
+
+	list, err := versions.List(res, "1", context)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, uint64(3), list[2].Number)
+	assert.Equal(t, "alice", list[2].Actor)
+
+	changes, err := versions.Diff(res, &list[1], &list[2])
+	require.NoError(t, err)
+	assert.Equal(t, []versions.Change{{Name: "Name", From: "Pen", To: "Pencil"}, {Name: "Price", From: 2, To: 3}}, changes)
+
+	restored, err := versions.Rollback(res, "1", 2, context)
+	require.NoError(t, err)
+	assert.Equal(t, &Product{ID: 1, Name: "Pen", Price: 2}, restored)
+	var saved Product
+	require.NoError(t, db.First(&saved, 1).Error)
+	assert.Equal(t, "Pen", saved.Name)
+
+	// rolled back records are validated again
+	_, err = versions.Rollback(res, "1", 1, context)
+	assert.EqualError(t, err, "price should be positive")
+	_, err = versions.Rollback(res, "1", 9, context)
+	assert.Equal(t, versions.ErrVersionNotFound, err)
+
+	list, err = versions.List(res, "1", context)
+	require.NoError(t, err)
+	assert.Len(t, list, 4)
+}
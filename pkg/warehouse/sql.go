@@ -0,0 +1,175 @@
+package warehouse
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Dialect is the SQL dialect of a warehouse.
+type Dialect struct {
+	Name string
+	// Types are the column types of the warehouse.
+	Types map[ColumnType]string
+	// Placeholder returns the placeholder of the nth argument, starting from 1.
+	Placeholder func(n int) string
+}
+
+// Dialects of the SQL warehouses, BigQuery isn't reachable through database/sql, it implements Driver with its
+// client library.
+var (
+	Redshift = Dialect{
+		Name: "redshift",
+		Types: map[ColumnType]string{
+			TypeString:    "VARCHAR(65535)",
+			TypeInteger:   "BIGINT",
+			TypeFloat:     "DOUBLE PRECISION",
+			TypeBoolean:   "BOOLEAN",
+			TypeTimestamp: "TIMESTAMP",
+			TypeJSON:      "SUPER",
+		},
+		Placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	}
+
+	Snowflake = Dialect{
+		Name: "snowflake",
+		Types: map[ColumnType]string{
+			TypeString:    "VARCHAR",
+			TypeInteger:   "NUMBER(38,0)",
+			TypeFloat:     "FLOAT",
+			TypeBoolean:   "BOOLEAN",
+			TypeTimestamp: "TIMESTAMP_NTZ",
+			TypeJSON:      "VARIANT",
+		},
+		Placeholder: func(int) string { return "?" },
+	}
+)
+
+// SQLDriver is the Driver of the warehouses reachable through database/sql, like Redshift and Snowflake.
+type SQLDriver struct {
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+// NewSQLDriver creates a driver of a database/sql warehouse.
+func NewSQLDriver(db *sql.DB, dialect Dialect) *SQLDriver {
+	return &SQLDriver{DB: db, Dialect: dialect}
+}
+
+// EnsureTable implements Driver.
+func (d *SQLDriver) EnsureTable(ctx context.Context, table string, columns []Column) error {
+	rows, err := d.DB.QueryContext(ctx,
+		fmt.Sprintf("SELECT column_name FROM information_schema.columns WHERE LOWER(table_name) = LOWER(%s)", d.Dialect.Placeholder(1)), table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	existing := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		existing[strings.ToLower(name)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, statement := range d.Dialect.SchemaStatements(table, columns, existing) {
+		if _, err := d.DB.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load implements Driver, replacing the rows with the same primary key in a transaction.
+func (d *SQLDriver) Load(ctx context.Context, table string, primaryKey string, columns []Column, rows [][]interface{}) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	deleteStatement, insertStatement := d.Dialect.LoadStatements(table, primaryKey, columns)
+	keyIndex := 0
+	for idx, column := range columns {
+		if column.Name == primaryKey {
+			keyIndex = idx
+		}
+	}
+
+	for _, row := range rows {
+		if _, err := tx.ExecContext(ctx, deleteStatement, row[keyIndex]); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx, insertStatement, row...); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int64(len(rows)), nil
+}
+
+// SchemaStatements returns the statements creating the table if no columns exist, or adding the missing columns.
+func (dialect Dialect) SchemaStatements(table string, columns []Column, existing map[string]bool) []string {
+	if len(existing) == 0 {
+		definitions := make([]string, len(columns))
+		for idx, column := range columns {
+			definitions[idx] = fmt.Sprintf("%s %s", column.Name, dialect.Types[column.Type])
+		}
+		return []string{fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", table, strings.Join(definitions, ", "))}
+	}
+
+	var statements []string
+	for _, column := range columns {
+		if !existing[strings.ToLower(column.Name)] {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column.Name, dialect.Types[column.Type]))
+		}
+	}
+	return statements
+}
+
+// LoadStatements returns the statements deleting a row by primary key, and inserting a row.
+func (dialect Dialect) LoadStatements(table string, primaryKey string, columns []Column) (string, string) {
+	names := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for idx, column := range columns {
+		names[idx] = column.Name
+		placeholders[idx] = dialect.Placeholder(idx + 1)
+	}
+
+	return fmt.Sprintf("DELETE FROM %s WHERE %s = %s", table, primaryKey, dialect.Placeholder(1)),
+		fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(names, ", "), strings.Join(placeholders, ", "))
+}
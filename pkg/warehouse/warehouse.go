@@ -0,0 +1,378 @@
+package warehouse
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/bhojpur/application/pkg/resource"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.warehouse")
+
+// ColumnType is the warehouse independent type of a column.
+type ColumnType string
+
+// Types of columns
+const (
+	TypeString    ColumnType = "string"
+	TypeInteger   ColumnType = "integer"
+	TypeFloat     ColumnType = "float"
+	TypeBoolean   ColumnType = "boolean"
+	TypeTimestamp ColumnType = "timestamp"
+	TypeJSON      ColumnType = "json"
+)
+
+// Column is a column of an exported table.
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Driver loads rows into a warehouse, like BigQuery, Snowflake or Redshift.
+type Driver interface {
+	// EnsureTable creates the table if it doesn't exist, and adds the columns missing from it. Columns are never
+	// dropped or retyped, so the rows exported before a schema change stay valid.
+	EnsureTable(ctx context.Context, table string, columns []Column) error
+	// Load upserts the rows into the table, by the primary key column, and returns the number of loaded rows.
+	Load(ctx context.Context, table string, primaryKey string, columns []Column, rows [][]interface{}) (int64, error)
+}
+
+// Watermark is the position of the last exported row of a resource.
+type Watermark struct {
+	ID        uint   `orm:"primary_key"`
+	Connector string `orm:"size:128;unique_index:idx_warehouse_watermark"`
+	Resource  string `orm:"size:128;unique_index:idx_warehouse_watermark"`
+	UpdatedAt time.Time
+	LastKey   string `orm:"size:255"`
+}
+
+// TableName table name of watermarks
+func (Watermark) TableName() string {
+	return "warehouse_watermarks"
+}
+
+// Run is the report of an export of a resource.
+type Run struct {
+	ID         uint   `orm:"primary_key"`
+	Connector  string `orm:"size:128;index"`
+	Resource   string `orm:"size:128"`
+	Rows       int64
+	Error      string `orm:"type:text"`
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// TableName table name of runs
+func (Run) TableName() string {
+	return "warehouse_runs"
+}
+
+// Export configures the export of a resource.
+type Export struct {
+	Resource *resource.Resource
+	// Table is the warehouse table, the table of the resource by default.
+	Table string
+	// Columns are the exported fields, by field or column name, all columns by default.
+	Columns []string
+	// WatermarkColumn is the column of the watermark, "updated_at" by default.
+	WatermarkColumn string
+}
+
+// Config configures a Connector.
+type Config struct {
+	// Name identifies the connector in watermarks and runs.
+	Name   string
+	Driver Driver
+	// Interval is the interval between exports, 1 hour by default.
+	Interval time.Duration
+	// BatchSize is the number of rows loaded at once, 1000 by default.
+	BatchSize int
+}
+
+// Connector exports resources to a warehouse incrementally, exporting the rows updated since the watermark of the
+// previous run. The tables of Watermark and Run have to be migrated.
+type Connector struct {
+	db      *orm.DB
+	config  Config
+	mutex   sync.Mutex
+	exports []*Export
+}
+
+// New creates a connector exporting from the database.
+func New(db *orm.DB, config Config) *Connector {
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1000
+	}
+	return &Connector{db: db, config: config}
+}
+
+// Add adds the export of a resource.
+func (c *Connector) Add(export *Export) error {
+	if export.Resource == nil {
+		return fmt.Errorf("warehouse: export without resource")
+	}
+	if export.WatermarkColumn == "" {
+		export.WatermarkColumn = "updated_at"
+	}
+	if export.Table == "" {
+		export.Table = c.db.NewScope(export.Resource.Value).TableName()
+	}
+
+	scope := c.db.NewScope(export.Resource.NewStruct())
+	if _, ok := scope.FieldByName(export.WatermarkColumn); !ok {
+		return fmt.Errorf("warehouse: resource %s has no watermark column %s", export.Resource.Name, export.WatermarkColumn)
+	}
+	if _, err := exportColumns(scope, export.Columns); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.exports = append(c.exports, export)
+	c.mutex.Unlock()
+	return nil
+}
+
+// Run exports the resources on every interval until the context is done.
+func (c *Connector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.ExportAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExportAll exports all resources and returns the reports of the runs.
+func (c *Connector) ExportAll(ctx context.Context) []*Run {
+	c.mutex.Lock()
+	exports := append([]*Export{}, c.exports...)
+	c.mutex.Unlock()
+
+	var runs []*Run
+	for _, export := range exports {
+		if ctx.Err() != nil {
+			break
+		}
+
+		run := c.Export(ctx, export)
+		if run.Error != "" {
+			log.Errorf("failed to export resource %s to %s: %s", run.Resource, c.config.Name, run.Error)
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// Export exports the rows of a resource updated since its watermark, and saves the report of the run. Rows loaded
+// before a failure are kept and the watermark advanced past them, the next run resumes from there.
+func (c *Connector) Export(ctx context.Context, export *Export) *Run {
+	run := &Run{Connector: c.config.Name, Resource: export.Resource.Name, StartedAt: time.Now()}
+	rows, err := c.export(ctx, export)
+	run.Rows, run.FinishedAt = rows, time.Now()
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	if err := c.db.Create(run).Error; err != nil {
+		log.Errorf("failed to save export run of resource %s: %s", run.Resource, err)
+	}
+	return run
+}
+
+func (c *Connector) export(ctx context.Context, export *Export) (int64, error) {
+	scope := c.db.NewScope(export.Resource.NewStruct())
+	fields, err := exportColumns(scope, export.Columns)
+	if err != nil {
+		return 0, err
+	}
+
+	primaryField := scope.PrimaryField()
+	if primaryField == nil {
+		return 0, fmt.Errorf("warehouse: resource %s has no primary key", export.Resource.Name)
+	}
+	watermarkField, _ := scope.FieldByName(export.WatermarkColumn)
+
+	columns := make([]Column, len(fields))
+	for idx, field := range fields {
+		columns[idx] = Column{Name: field.DBName, Type: TypeOf(field.Struct.Type)}
+	}
+	if err := c.config.Driver.EnsureTable(ctx, export.Table, columns); err != nil {
+		return 0, err
+	}
+
+	watermark := Watermark{Connector: c.config.Name, Resource: export.Resource.Name}
+	if err := c.db.Where(watermark).FirstOrInit(&watermark).Error; err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for ctx.Err() == nil {
+		records := export.Resource.NewSlice()
+		// rows updated at the watermark time are ordered by primary key, to resume after the last exported one
+		err := c.db.Where(
+			fmt.Sprintf("%[1]v > ? OR (%[1]v = ? AND %[2]v > ?)", scope.Quote(watermarkField.DBName), scope.Quote(primaryField.DBName)),
+			watermark.UpdatedAt, watermark.UpdatedAt, watermark.LastKey,
+		).Order(fmt.Sprintf("%v, %v", scope.Quote(watermarkField.DBName), scope.Quote(primaryField.DBName))).
+			Limit(c.config.BatchSize).Find(records).Error
+		if err != nil {
+			return total, err
+		}
+
+		values := reflect.Indirect(reflect.ValueOf(records))
+		if values.Len() == 0 {
+			break
+		}
+
+		rows := make([][]interface{}, values.Len())
+		for idx := 0; idx < values.Len(); idx++ {
+			recordScope := c.db.NewScope(values.Index(idx).Interface())
+			row := make([]interface{}, len(fields))
+			for i, field := range fields {
+				recordField, _ := recordScope.FieldByName(field.Name)
+				if row[i], err = columnValue(recordField.Field); err != nil {
+					return total, err
+				}
+			}
+			rows[idx] = row
+		}
+
+		loaded, err := c.config.Driver.Load(ctx, export.Table, primaryField.DBName, columns, rows)
+		total += loaded
+		if err != nil {
+			return total, err
+		}
+
+		last := c.db.NewScope(values.Index(values.Len() - 1).Interface())
+		lastWatermark, _ := last.FieldByName(watermarkField.Name)
+		watermark.UpdatedAt, _ = reflect.Indirect(lastWatermark.Field).Interface().(time.Time)
+		watermark.LastKey = fmt.Sprint(last.PrimaryKeyValue())
+		if err := c.db.Save(&watermark).Error; err != nil {
+			return total, err
+		}
+
+		if values.Len() < c.config.BatchSize {
+			break
+		}
+	}
+	return total, nil
+}
+
+// exportColumns returns the exported fields, all columns of the scope if no columns given
+func exportColumns(scope *orm.Scope, columns []string) ([]*orm.StructField, error) {
+	var fields []*orm.StructField
+	if len(columns) == 0 {
+		for _, field := range scope.GetModelStruct().StructFields {
+			if field.IsNormal && !field.IsIgnored {
+				fields = append(fields, field)
+			}
+		}
+		return fields, nil
+	}
+
+	for _, column := range columns {
+		field, ok := scope.FieldByName(column)
+		if !ok || !field.IsNormal {
+			return nil, fmt.Errorf("warehouse: %s is not a column of %s", column, scope.GetModelStruct().ModelType.Name())
+		}
+		fields = append(fields, field.StructField)
+	}
+	return fields, nil
+}
+
+// TypeOf returns the column type of a field type.
+func TypeOf(typ reflect.Type) ColumnType {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch typ {
+	case reflect.TypeOf(time.Time{}):
+		return TypeTimestamp
+	}
+	if reflect.PtrTo(typ).Implements(reflect.TypeOf((*driver.Valuer)(nil)).Elem()) && typ.Kind() == reflect.Struct {
+		// sql.NullString and alike
+		switch typ.Name() {
+		case "NullInt64", "NullInt32", "NullInt16", "NullByte":
+			return TypeInteger
+		case "NullFloat64":
+			return TypeFloat
+		case "NullBool":
+			return TypeBoolean
+		case "NullTime":
+			return TypeTimestamp
+		}
+		return TypeString
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return TypeString
+	case reflect.Bool:
+		return TypeBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return TypeInteger
+	case reflect.Float32, reflect.Float64:
+		return TypeFloat
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return TypeString
+		}
+	}
+	return TypeJSON
+}
+
+// columnValue returns the value of a field as loaded into the warehouse
+func columnValue(value reflect.Value) (interface{}, error) {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+
+	if valuer, ok := value.Interface().(driver.Valuer); ok {
+		return valuer.Value()
+	}
+	if TypeOf(value.Type()) == TypeJSON {
+		data, err := json.Marshal(value.Interface())
+		return string(data), err
+	}
+	return value.Interface(), nil
+}
@@ -0,0 +1,90 @@
+package warehouse_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bhojpur/application/pkg/warehouse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeOf(t *testing.T) {
+	var (
+		name    *string
+		tags    []string
+		payload []byte
+	)
+
+	for _, test := range []struct {
+		value    interface{}
+		expected warehouse.ColumnType
+	}{
+		{"", warehouse.TypeString},
+		{uint(1), warehouse.TypeInteger},
+		{1.5, warehouse.TypeFloat},
+		{true, warehouse.TypeBoolean},
+		{time.Time{}, warehouse.TypeTimestamp},
+		{sql.NullInt64{}, warehouse.TypeInteger},
+		{sql.NullString{}, warehouse.TypeString},
+		{struct{ A int }{}, warehouse.TypeJSON},
+		{map[string]string{}, warehouse.TypeJSON},
+	} {
+		assert.Equal(t, test.expected, warehouse.TypeOf(reflect.TypeOf(test.value)), "%T", test.value)
+	}
+
+	assert.Equal(t, warehouse.TypeString, warehouse.TypeOf(reflect.TypeOf(name)))
+	assert.Equal(t, warehouse.TypeJSON, warehouse.TypeOf(reflect.TypeOf(tags)))
+	assert.Equal(t, warehouse.TypeString, warehouse.TypeOf(reflect.TypeOf(payload)))
+}
+
+func TestSchemaStatements(t *testing.T) {
+	columns := []warehouse.Column{{Name: "id", Type: warehouse.TypeInteger}, {Name: "name", Type: warehouse.TypeString}}
+
+	t.Run("create", func(t *testing.T) {
+		assert.Equal(t, []string{"CREATE TABLE IF NOT EXISTS products (id BIGINT, name VARCHAR(65535))"},
+			warehouse.Redshift.SchemaStatements("products", columns, nil))
+	})
+
+	t.Run("evolve", func(t *testing.T) {
+		assert.Equal(t, []string{"ALTER TABLE products ADD COLUMN name VARCHAR"},
+			warehouse.Snowflake.SchemaStatements("products", columns, map[string]bool{"id": true}))
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		assert.Empty(t, warehouse.Snowflake.SchemaStatements("products", columns, map[string]bool{"id": true, "name": true}))
+	})
+}
+
+func TestLoadStatements(t *testing.T) {
+	columns := []warehouse.Column{{Name: "id", Type: warehouse.TypeInteger}, {Name: "name", Type: warehouse.TypeString}}
+
+	deleteStatement, insertStatement := warehouse.Redshift.LoadStatements("products", "id", columns)
+	assert.Equal(t, "DELETE FROM products WHERE id = $1", deleteStatement)
+	assert.Equal(t, "INSERT INTO products (id, name) VALUES ($1, $2)", insertStatement)
+
+	deleteStatement, insertStatement = warehouse.Snowflake.LoadStatements("products", "id", columns)
+	assert.Equal(t, "DELETE FROM products WHERE id = ?", deleteStatement)
+	assert.Equal(t, "INSERT INTO products (id, name) VALUES (?, ?)", insertStatement)
+}
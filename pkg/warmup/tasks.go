@@ -0,0 +1,162 @@
+package warmup
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// RecordsTask returns a task which handles the records of a resource in batches, ordered by primary key.
+func RecordsTask(name string, priority int, res *resource.Resource, appContext *appsvr.Context, handle func(records interface{}, appContext *appsvr.Context) error) Task {
+	return Task{
+		Name:     name,
+		Priority: priority,
+		Count: func(ctx context.Context) (int, error) {
+			var count int
+			err := appContext.GetDB().Model(res.Value).Count(&count).Error
+			return count, err
+		},
+		Batch: func(ctx context.Context, offset, limit int) (int, error) {
+			db := appContext.GetDB()
+			records := res.NewSlice()
+			if err := db.Order(db.NewScope(res.Value).PrimaryKey()).Offset(offset).Limit(limit).Find(records).Error; err != nil {
+				return 0, err
+			}
+
+			count := reflect.Indirect(reflect.ValueOf(records)).Len()
+			if count == 0 {
+				return 0, nil
+			}
+			return count, handle(records, appContext)
+		},
+	}
+}
+
+// ReindexTask returns a task which indexes the records of a resource with its search engine, which has to
+// implement resource.SearchIndexer.
+func ReindexTask(priority int, res *resource.Resource, appContext *appsvr.Context) (Task, error) {
+	indexer, ok := res.GetSearchEngine().(resource.SearchIndexer)
+	if !ok {
+		return Task{}, fmt.Errorf("warmup: search engine of resource %s has no index", res.Name)
+	}
+
+	return RecordsTask("reindex:"+res.Name, priority, res, appContext, func(records interface{}, appContext *appsvr.Context) error {
+		return indexer.Index(res, records, appContext)
+	}), nil
+}
+
+// CounterCache is a cached count of a record, like the number of orders of a customer.
+type CounterCache struct {
+	// Column is the column of the cached count.
+	Column string
+	// Count counts the value of the record.
+	Count func(db *orm.DB, record interface{}) (int64, error)
+}
+
+// CounterCacheTask returns a task which recomputes the counter caches of the records of a resource.
+func CounterCacheTask(priority int, res *resource.Resource, appContext *appsvr.Context, counters ...CounterCache) Task {
+	return RecordsTask("counters:"+res.Name, priority, res, appContext, func(records interface{}, appContext *appsvr.Context) error {
+		db := appContext.GetDB()
+		values := reflect.Indirect(reflect.ValueOf(records))
+		for i := 0; i < values.Len(); i++ {
+			record := values.Index(i).Addr().Interface()
+			if values.Index(i).Kind() == reflect.Ptr {
+				record = values.Index(i).Interface()
+			}
+
+			for _, counter := range counters {
+				count, err := counter.Count(db, record)
+				if err != nil {
+					return err
+				}
+
+				if err := db.Model(record).UpdateColumn(counter.Column, count).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// CacheTask returns a task which requests the paths from the handler, like a router with the response cache
+// middleware of routes, so the responses are cached before clients request them. newRequest could add headers,
+// like the credentials the cache is keyed on, it is optional.
+func CacheTask(name string, priority int, handler http.Handler, paths []string, newRequest func(ctx context.Context, path string) (*http.Request, error)) Task {
+	if newRequest == nil {
+		newRequest = func(ctx context.Context, path string) (*http.Request, error) {
+			return http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+		}
+	}
+
+	return Task{
+		Name:     name,
+		Priority: priority,
+		Count: func(ctx context.Context) (int, error) {
+			return len(paths), nil
+		},
+		Batch: func(ctx context.Context, offset, limit int) (int, error) {
+			var processed int
+			for ; processed < limit && offset+processed < len(paths); processed++ {
+				req, err := newRequest(ctx, paths[offset+processed])
+				if err != nil {
+					return processed, err
+				}
+
+				w := &discardResponseWriter{header: http.Header{}}
+				handler.ServeHTTP(w, req)
+				if w.status >= http.StatusBadRequest {
+					return processed, fmt.Errorf("warmup: %s responded %d", paths[offset+processed], w.status)
+				}
+			}
+			return processed, nil
+		},
+	}
+}
+
+type discardResponseWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
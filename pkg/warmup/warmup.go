@@ -0,0 +1,305 @@
+package warmup
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.warmup")
+
+// DefaultBatchSize is the default number of items warmed up per batch.
+const DefaultBatchSize = 100
+
+// ErrRunning is returned when starting a warm-up which is already running.
+var ErrRunning = errors.New("warmup: already running")
+
+// Status is the status of a task.
+type Status string
+
+// Task statuses
+const (
+	StatusPending Status = "pending"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+	StatusAborted Status = "aborted"
+)
+
+// Task warms up a set of items in batches, like the records of a resource.
+type Task struct {
+	Name string
+	// Priority orders the tasks, higher first, tasks with the same priority run concurrently.
+	Priority int
+	// Count returns the number of items, it is optional and only used for the progress.
+	Count func(ctx context.Context) (int, error)
+	// Batch warms up at most limit items from offset, and returns how many it warmed up, less than limit when done.
+	Batch func(ctx context.Context, offset, limit int) (int, error)
+}
+
+// Progress is the progress of a task.
+type Progress struct {
+	Name      string    `json:"name"`
+	Priority  int       `json:"priority"`
+	Status    Status    `json:"status"`
+	Total     int       `json:"total,omitempty"`
+	Processed int       `json:"processed"`
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"startedAt,omitempty"`
+	EndedAt   time.Time `json:"endedAt,omitempty"`
+}
+
+// Orchestrator runs the warm-up tasks, after deploys or cache flushes, in prioritized batches.
+type Orchestrator struct {
+	// BatchSize is the number of items warmed up per batch, DefaultBatchSize if zero.
+	BatchSize int
+
+	tasks    []Task
+	progress map[string]*Progress
+	cancel   context.CancelFunc
+	done     chan struct{}
+	err      error
+	lock     sync.RWMutex
+}
+
+// New returns an orchestrator of the tasks.
+func New(tasks ...Task) *Orchestrator {
+	o := &Orchestrator{progress: map[string]*Progress{}}
+	for _, task := range tasks {
+		o.Add(task)
+	}
+	return o
+}
+
+// Add adds a task, a task with the same name is replaced.
+func (o *Orchestrator) Add(task Task) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for idx, t := range o.tasks {
+		if t.Name == task.Name {
+			o.tasks[idx] = task
+			return
+		}
+	}
+	o.tasks = append(o.tasks, task)
+}
+
+// Start starts the warm-up in the background, Wait waits for it and Abort aborts it.
+func (o *Orchestrator) Start(ctx context.Context) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.isRunning() {
+		return ErrRunning
+	}
+
+	tasks := append([]Task{}, o.tasks...)
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Priority > tasks[j].Priority })
+
+	o.progress = map[string]*Progress{}
+	for _, task := range tasks {
+		o.progress[task.Name] = &Progress{Name: task.Name, Priority: task.Priority, Status: StatusPending}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	o.cancel, o.done, o.err = cancel, make(chan struct{}), nil
+	go o.run(ctx, cancel, tasks, o.done)
+	return nil
+}
+
+// Run runs the warm-up and waits for it.
+func (o *Orchestrator) Run(ctx context.Context) error {
+	if err := o.Start(ctx); err != nil {
+		return err
+	}
+	return o.Wait()
+}
+
+// Wait waits for the running warm-up, and returns the errors of its tasks.
+func (o *Orchestrator) Wait() error {
+	o.lock.RLock()
+	done := o.done
+	o.lock.RUnlock()
+
+	if done != nil {
+		<-done
+	}
+
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.err
+}
+
+// Abort aborts the running warm-up, the batches in progress are completed.
+func (o *Orchestrator) Abort() {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+
+	if o.cancel != nil {
+		o.cancel()
+	}
+}
+
+// Running returns true if a warm-up is running.
+func (o *Orchestrator) Running() bool {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.isRunning()
+}
+
+// Progress returns the progress of the tasks of the last warm-up, by priority.
+func (o *Orchestrator) Progress() []Progress {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+
+	progress := make([]Progress, 0, len(o.progress))
+	for _, p := range o.progress {
+		progress = append(progress, *p)
+	}
+	sort.Slice(progress, func(i, j int) bool {
+		if progress[i].Priority != progress[j].Priority {
+			return progress[i].Priority > progress[j].Priority
+		}
+		return progress[i].Name < progress[j].Name
+	})
+	return progress
+}
+
+func (o *Orchestrator) isRunning() bool {
+	if o.done == nil {
+		return false
+	}
+
+	select {
+	case <-o.done:
+		return false
+	default:
+		return true
+	}
+}
+
+func (o *Orchestrator) run(ctx context.Context, cancel context.CancelFunc, tasks []Task, done chan struct{}) {
+	defer close(done)
+	defer cancel()
+
+	var errs []string
+	for start := 0; start < len(tasks); {
+		end := start + 1
+		for end < len(tasks) && tasks[end].Priority == tasks[start].Priority {
+			end++
+		}
+
+		var (
+			wg   sync.WaitGroup
+			lock sync.Mutex
+		)
+		for _, task := range tasks[start:end] {
+			wg.Add(1)
+			go func(task Task) {
+				defer wg.Done()
+				if err := o.runTask(ctx, task); err != nil {
+					log.Warnf("warm-up task %s failed: %s", task.Name, err)
+					lock.Lock()
+					errs = append(errs, fmt.Sprintf("%s: %s", task.Name, err))
+					lock.Unlock()
+				}
+			}(task)
+		}
+		wg.Wait()
+		start = end
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		o.lock.Lock()
+		o.err = fmt.Errorf("warmup: %d tasks failed: %v", len(errs), errs)
+		o.lock.Unlock()
+	}
+}
+
+func (o *Orchestrator) runTask(ctx context.Context, task Task) error {
+	o.update(task.Name, func(p *Progress) {
+		p.Status, p.StartedAt = StatusRunning, time.Now()
+	})
+
+	err := o.warmUp(ctx, task)
+	o.update(task.Name, func(p *Progress) {
+		p.EndedAt = time.Now()
+		switch {
+		case errors.Is(err, context.Canceled):
+			p.Status = StatusAborted
+		case err != nil:
+			p.Status, p.Error = StatusFailed, err.Error()
+		default:
+			p.Status = StatusDone
+		}
+	})
+
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func (o *Orchestrator) warmUp(ctx context.Context, task Task) error {
+	if task.Count != nil {
+		total, err := task.Count(ctx)
+		if err != nil {
+			return err
+		}
+		o.update(task.Name, func(p *Progress) { p.Total = total })
+	}
+
+	batchSize := o.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	for offset := 0; ; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		processed, err := task.Batch(ctx, offset, batchSize)
+		offset += processed
+		o.update(task.Name, func(p *Progress) { p.Processed = offset })
+		if err != nil || processed < batchSize {
+			return err
+		}
+	}
+}
+
+func (o *Orchestrator) update(name string, fc func(*Progress)) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if p, ok := o.progress[name]; ok {
+		fc(p)
+	}
+}
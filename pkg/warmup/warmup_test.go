@@ -0,0 +1,129 @@
+package warmup_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bhojpur/application/pkg/warmup"
+)
+
+func countingTask(name string, priority, total int, order *[]string, lock *sync.Mutex) warmup.Task {
+	return warmup.Task{
+		Name:     name,
+		Priority: priority,
+		Count: func(ctx context.Context) (int, error) {
+			return total, nil
+		},
+		Batch: func(ctx context.Context, offset, limit int) (int, error) {
+			lock.Lock()
+			*order = append(*order, name)
+			lock.Unlock()
+
+			if remaining := total - offset; remaining < limit {
+				return remaining, nil
+			}
+			return limit, nil
+		},
+	}
+}
+
+func TestOrchestrator(t *testing.T) {
+	var (
+		order []string
+		lock  sync.Mutex
+	)
+
+	o := warmup.New(
+		countingTask("counters", 1, 5, &order, &lock),
+		countingTask("search", 10, 25, &order, &lock),
+		warmup.Task{Name: "broken", Priority: 0, Batch: func(ctx context.Context, offset, limit int) (int, error) {
+			return 0, errors.New("boom")
+		}},
+	)
+	o.BatchSize = 10
+
+	err := o.Run(context.Background())
+	assert.EqualError(t, err, "warmup: 1 tasks failed: [broken: boom]")
+	assert.Equal(t, []string{"search", "search", "search", "counters"}, order)
+
+	progress := o.Progress()
+	if assert.Len(t, progress, 3) {
+		assert.Equal(t, warmup.Progress{Name: "search", Priority: 10, Status: warmup.StatusDone, Total: 25, Processed: 25}, withoutTimes(progress[0]))
+		assert.Equal(t, warmup.StatusDone, progress[1].Status)
+		assert.Equal(t, 5, progress[1].Processed)
+		assert.Equal(t, warmup.StatusFailed, progress[2].Status)
+		assert.Equal(t, "boom", progress[2].Error)
+	}
+}
+
+func TestOrchestratorAbort(t *testing.T) {
+	started := make(chan struct{})
+	var once sync.Once
+
+	o := warmup.New(warmup.Task{Name: "slow", Batch: func(ctx context.Context, offset, limit int) (int, error) {
+		once.Do(func() { close(started) })
+		time.Sleep(10 * time.Millisecond)
+		return limit, nil
+	}})
+
+	assert.NoError(t, o.Start(context.Background()))
+	<-started
+	assert.True(t, o.Running())
+	assert.Equal(t, warmup.ErrRunning, o.Start(context.Background()))
+
+	o.Abort()
+	assert.NoError(t, o.Wait())
+	assert.False(t, o.Running())
+	assert.Equal(t, warmup.StatusAborted, o.Progress()[0].Status)
+}
+
+func TestCacheTask(t *testing.T) {
+	var requested []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requested = append(requested, req.URL.Path)
+		if req.URL.Path == "/missing" {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	o := warmup.New(warmup.CacheTask("cache", 0, handler, []string{"/products", "/categories", "/missing"}, nil))
+	o.BatchSize = 2
+
+	err := o.Run(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, []string{"/products", "/categories", "/missing"}, requested)
+	assert.Equal(t, 2, o.Progress()[0].Processed)
+}
+
+func withoutTimes(progress warmup.Progress) warmup.Progress {
+	progress.StartedAt, progress.EndedAt = time.Time{}, time.Time{}
+	return progress
+}
@@ -0,0 +1,67 @@
+package webhooks
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"net/url"
+	"strings"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/events"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/validations"
+)
+
+// NewResource returns the Webhook resource, to manage webhooks at runtime, like from the admin.
+// Its records are validated: the name is required, the URL has to be an absolute http(s) URL,
+// and the actions known ones.
+func NewResource() *resource.Resource {
+	res := resource.New(&Webhook{})
+
+	res.AddValidator(&resource.Validator{
+		Name: "webhook",
+		Handler: func(record interface{}, metaValues *resource.MetaValues, context *appsvr.Context) error {
+			var (
+				webhook = record.(*Webhook)
+				errs    validations.Errors
+			)
+
+			if strings.TrimSpace(webhook.Name) == "" {
+				errs.Add(validations.NewFieldError(webhook, "Name", validations.CodeRequired, "Name can't be blank", nil))
+			}
+
+			if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs.Add(validations.NewFieldError(webhook, "URL", validations.CodeInvalid, "URL should be an absolute http or https URL", nil))
+			}
+
+			for _, action := range strings.Split(webhook.Actions, ",") {
+				switch events.Action(strings.TrimSpace(action)) {
+				case "", events.ActionCreate, events.ActionUpdate, events.ActionDelete:
+				default:
+					errs.Add(validations.NewFieldError(webhook, "Actions", validations.CodeInclusion, "Unknown action "+strings.TrimSpace(action), map[string]interface{}{"value": strings.TrimSpace(action)}))
+				}
+			}
+
+			return errs.ErrorOrNil()
+		},
+	})
+	return res
+}
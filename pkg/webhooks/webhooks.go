@@ -0,0 +1,384 @@
+package webhooks
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/application/pkg/events"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/bhojpur/service/pkg/utils/logger"
+)
+
+var log = logger.NewLogger("app.webhooks")
+
+// Headers of webhook requests
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// maxResponseBody is the length of the response bodies kept in the delivery logs
+const maxResponseBody = 1024
+
+// Webhook is an endpoint notified of the changes of a resource, they are managed at runtime with NewResource.
+type Webhook struct {
+	ID   uint   `orm:"primary_key"`
+	Name string `orm:"size:128"`
+	URL  string `orm:"size:1024"`
+	// Resource is the name of the resource whose events are delivered, the events of all resources if empty.
+	Resource string `orm:"size:128"`
+	// Actions are the comma separated actions delivered, like "create,update", all actions if empty.
+	Actions string `orm:"size:128"`
+	// Secret signs the payloads.
+	Secret    string `orm:"size:256"`
+	Enabled   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName table name of webhooks
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Matches returns true if the event is delivered to the webhook.
+func (webhook *Webhook) Matches(event events.Event) bool {
+	if !webhook.Enabled || (webhook.Resource != "" && webhook.Resource != event.Resource) {
+		return false
+	}
+
+	if strings.TrimSpace(webhook.Actions) == "" {
+		return true
+	}
+
+	for _, action := range strings.Split(webhook.Actions, ",") {
+		if events.Action(strings.TrimSpace(action)) == event.Action {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is the delivery log of an event to a webhook.
+type Delivery struct {
+	ID             uint   `orm:"primary_key"`
+	WebhookID      uint   `orm:"index"`
+	Resource       string `orm:"size:128"`
+	Action         string `orm:"size:64"`
+	PrimaryKey     string `orm:"size:128"`
+	Payload        string `orm:"type:text"`
+	Status         string `orm:"size:32;index"`
+	Attempts       int
+	ResponseStatus int
+	ResponseBody   string    `orm:"type:text"`
+	Error          string    `orm:"type:text"`
+	NextAttemptAt  time.Time `orm:"index"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// TableName table name of deliveries
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// Payload is the JSON body of a webhook request.
+type Payload struct {
+	Delivery uint         `json:"delivery"`
+	Webhook  string       `json:"webhook"`
+	Event    events.Event `json:"event"`
+}
+
+// Config configures the delivery of webhooks.
+type Config struct {
+	// MaxAttempts is the number of attempts of a delivery before it fails, 8 by default.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled for every retry, 30 seconds by default.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries, 1 hour by default.
+	MaxBackoff time.Duration
+	// PollInterval is the interval the due deliveries are polled at, 5 seconds by default.
+	PollInterval time.Duration
+	// Client sends the requests, a client with a 10 seconds timeout by default.
+	Client *http.Client
+}
+
+// Dispatcher records the deliveries of the events to the matching webhooks, and delivers them with retries.
+// The tables of Webhook and Delivery have to be migrated.
+type Dispatcher struct {
+	db     *orm.DB
+	config Config
+	nudge  chan struct{}
+}
+
+// New returns a dispatcher storing webhooks and deliveries in db.
+func New(db *orm.DB, config Config) *Dispatcher {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 8
+	}
+
+	if config.Backoff <= 0 {
+		config.Backoff = 30 * time.Second
+	}
+
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = time.Hour
+	}
+
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Dispatcher{db: db, config: config, nudge: make(chan struct{}, 1)}
+}
+
+// Subscribe enqueues the deliveries of the events of the bus, it returns a function to unsubscribe.
+func (d *Dispatcher) Subscribe(bus *events.Bus) func() {
+	return bus.Subscribe(d.Enqueue)
+}
+
+// Enqueue records a pending delivery of the event for every matching webhook.
+func (d *Dispatcher) Enqueue(ctx context.Context, event events.Event) error {
+	var webhooks []Webhook
+	if err := d.db.Where("enabled = ?", true).Find(&webhooks).Error; err != nil {
+		return err
+	}
+
+	var enqueued bool
+	for idx := range webhooks {
+		webhook := &webhooks[idx]
+		if !webhook.Matches(event) {
+			continue
+		}
+
+		delivery := &Delivery{
+			WebhookID:     webhook.ID,
+			Resource:      event.Resource,
+			Action:        string(event.Action),
+			PrimaryKey:    event.PrimaryKey,
+			Status:        StatusPending,
+			NextAttemptAt: time.Now(),
+		}
+		if err := d.db.Create(delivery).Error; err != nil {
+			return err
+		}
+
+		payload, err := json.Marshal(Payload{Delivery: delivery.ID, Webhook: webhook.Name, Event: event})
+		if err != nil {
+			return err
+		}
+
+		if err := d.db.Model(delivery).UpdateColumn("payload", string(payload)).Error; err != nil {
+			return err
+		}
+		enqueued = true
+	}
+
+	if enqueued {
+		select {
+		case d.nudge <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Run delivers the due deliveries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.DeliverDue(ctx); err != nil {
+			log.Errorf("failed to deliver webhooks: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.nudge:
+		}
+	}
+}
+
+// DeliverDue attempts the pending deliveries whose next attempt is due.
+func (d *Dispatcher) DeliverDue(ctx context.Context) error {
+	var deliveries []Delivery
+	if err := d.db.Where("status = ? AND next_attempt_at <= ?", StatusPending, time.Now()).Order("id").Find(&deliveries).Error; err != nil {
+		return err
+	}
+
+	for idx := range deliveries {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err := d.Deliver(ctx, &deliveries[idx]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deliver attempts a delivery, and records the result of the attempt. A failed attempt is retried with
+// an exponential backoff, until the maximum number of attempts. Redelivering a failed delivery retries it.
+func (d *Dispatcher) Deliver(ctx context.Context, delivery *Delivery) error {
+	var webhook Webhook
+	if err := d.db.First(&webhook, delivery.WebhookID).Error; err != nil {
+		if !errors.Is(err, orm.ErrRecordNotFound) {
+			return err
+		}
+		delivery.Status, delivery.Error = StatusFailed, "webhook was deleted"
+		return d.db.Save(delivery).Error
+	}
+
+	status, body, err := d.send(ctx, &webhook, delivery)
+	delivery.Attempts++
+	delivery.ResponseStatus, delivery.ResponseBody, delivery.Error = status, body, ""
+
+	switch {
+	case err == nil:
+		delivery.Status = StatusSucceeded
+	case delivery.Attempts >= d.config.MaxAttempts:
+		delivery.Status, delivery.Error = StatusFailed, err.Error()
+	default:
+		delivery.Status, delivery.Error = StatusPending, err.Error()
+		delivery.NextAttemptAt = time.Now().Add(Backoff(delivery.Attempts, d.config.Backoff, d.config.MaxBackoff))
+	}
+
+	if err != nil {
+		log.Warnf("attempt %d of webhook delivery %d to %s failed: %s", delivery.Attempts, delivery.ID, webhook.URL, err)
+	}
+	return d.db.Save(delivery).Error
+}
+
+// Redeliver schedules a delivery again, like a failed one, with a new set of attempts.
+func (d *Dispatcher) Redeliver(deliveryID uint) error {
+	err := d.db.Model(&Delivery{}).Where("id = ?", deliveryID).Updates(map[string]interface{}{
+		"status":          StatusPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	}).Error
+
+	if err == nil {
+		select {
+		case d.nudge <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
+
+func (d *Dispatcher) send(ctx context.Context, webhook *Webhook, delivery *Delivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Resource+"."+delivery.Action)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	if webhook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, body))
+	}
+
+	resp, err := d.config.Client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(responseBody), fmt.Errorf("endpoint responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(responseBody), nil
+}
+
+// Sign returns the signature of a payload, `sha256=` and the hex HMAC-SHA256 of the timestamp, a dot and the body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify verifies the signature of a webhook request, for receivers. Requests older than tolerance are rejected
+// to prevent replays, unless it is zero.
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	timestamp, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return errors.New("invalid webhook timestamp")
+	}
+
+	if tolerance > 0 && time.Since(time.Unix(timestamp, 0)) > tolerance {
+		return errors.New("webhook timestamp is too old")
+	}
+
+	if !hmac.Equal([]byte(header.Get(SignatureHeader)), []byte(Sign(secret, timestamp, body))) {
+		return errors.New("invalid webhook signature")
+	}
+	return nil
+}
+
+// Backoff returns the delay before the retry following the attempt, base doubled for every attempt, up to max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		if delay *= 2; delay >= max {
+			return max
+		}
+	}
+
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,73 @@
+package webhooks_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bhojpur/application/pkg/events"
+	"github.com/bhojpur/application/pkg/webhooks"
+)
+
+func TestWebhookMatches(t *testing.T) {
+	webhook := &webhooks.Webhook{Resource: "Order", Actions: "create, delete", Enabled: true}
+
+	assert.True(t, webhook.Matches(events.Event{Resource: "Order", Action: events.ActionCreate}))
+	assert.True(t, webhook.Matches(events.Event{Resource: "Order", Action: events.ActionDelete}))
+	assert.False(t, webhook.Matches(events.Event{Resource: "Order", Action: events.ActionUpdate}))
+	assert.False(t, webhook.Matches(events.Event{Resource: "Product", Action: events.ActionCreate}))
+
+	all := &webhooks.Webhook{Enabled: true}
+	assert.True(t, all.Matches(events.Event{Resource: "Product", Action: events.ActionUpdate}))
+
+	all.Enabled = false
+	assert.False(t, all.Matches(events.Event{Resource: "Product", Action: events.ActionUpdate}))
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"delivery":1}`)
+	timestamp := time.Now().Unix()
+
+	header := http.Header{}
+	header.Set(webhooks.TimestampHeader, strconv.FormatInt(timestamp, 10))
+	header.Set(webhooks.SignatureHeader, webhooks.Sign("secret", timestamp, body))
+
+	assert.NoError(t, webhooks.Verify("secret", header, body, time.Minute))
+	assert.Error(t, webhooks.Verify("other", header, body, time.Minute))
+	assert.Error(t, webhooks.Verify("secret", header, []byte(`{"delivery":2}`), time.Minute))
+
+	header.Set(webhooks.TimestampHeader, strconv.FormatInt(timestamp-3600, 10))
+	header.Set(webhooks.SignatureHeader, webhooks.Sign("secret", timestamp-3600, body))
+	assert.Error(t, webhooks.Verify("secret", header, body, time.Minute))
+	assert.NoError(t, webhooks.Verify("secret", header, body, 0))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhooks.Backoff(1, 30*time.Second, time.Hour))
+	assert.Equal(t, 60*time.Second, webhooks.Backoff(2, 30*time.Second, time.Hour))
+	assert.Equal(t, 240*time.Second, webhooks.Backoff(4, 30*time.Second, time.Hour))
+	assert.Equal(t, time.Hour, webhooks.Backoff(20, 30*time.Second, time.Hour))
+}
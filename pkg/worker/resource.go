@@ -0,0 +1,122 @@
+package worker
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"strings"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	"github.com/bhojpur/application/pkg/resource"
+	"github.com/bhojpur/application/pkg/validations"
+)
+
+// NewResource returns the Run resource, to see and manage the history of runs like any other model, like from the
+// admin. Its records are validated: the job has to be registered to the worker, and the arguments valid for it.
+// Creating a run enqueues it, and setting the status of a run to pending retries it.
+func NewResource(w *Worker) *resource.Resource {
+	res := resource.New(&Run{})
+
+	res.AddValidator(&resource.Validator{
+		Name: "run",
+		Handler: func(record interface{}, metaValues *resource.MetaValues, context *appsvr.Context) error {
+			var (
+				run  = record.(*Run)
+				errs validations.Errors
+			)
+
+			if job := w.GetJob(strings.TrimSpace(run.Job)); job == nil {
+				errs.Add(validations.NewFieldError(run, "Job", validations.CodeInclusion, "Unknown job "+run.Job, map[string]interface{}{"value": run.Job}))
+			} else if _, err := decodeArgs(job, run.Args); err != nil {
+				errs.Add(validations.NewFieldError(run, "Args", validations.CodeInvalid, err.Error(), nil))
+			}
+
+			switch run.Status {
+			case "":
+				run.Status = StatusPending
+			case StatusPending, StatusCancelled:
+			default:
+				if context.GetDB().NewScope(run).PrimaryKeyZero() {
+					errs.Add(validations.NewFieldError(run, "Status", validations.CodeInvalid, "Status of new runs should be pending", nil))
+				}
+			}
+
+			return errs.ErrorOrNil()
+		},
+	})
+
+	save := res.SaveHandler
+	res.SaveHandler = func(record interface{}, context *appsvr.Context) error {
+		run := record.(*Run)
+		if run.Status == StatusPending && run.RunAt.IsZero() {
+			run.RunAt = time.Now()
+		}
+		if err := save(record, context); err != nil {
+			return err
+		}
+		if run.Status == StatusPending {
+			w.wake()
+		}
+		return nil
+	}
+	return res
+}
+
+// NewScheduleResource returns the Schedule resource, to see the last and next runs of the recurring jobs, and pause
+// them. The next run of a schedule is computed again when it is saved, a paused schedule has none.
+func NewScheduleResource(w *Worker) *resource.Resource {
+	res := resource.New(&Schedule{})
+
+	res.AddValidator(&resource.Validator{
+		Name: "schedule",
+		Handler: func(record interface{}, metaValues *resource.MetaValues, context *appsvr.Context) error {
+			var (
+				schedule = record.(*Schedule)
+				errs     validations.Errors
+			)
+
+			if strings.TrimSpace(schedule.Name) == "" {
+				errs.Add(validations.NewFieldError(schedule, "Name", validations.CodeRequired, "Name can't be blank", nil))
+			}
+
+			if job := w.GetJob(strings.TrimSpace(schedule.Job)); job == nil {
+				errs.Add(validations.NewFieldError(schedule, "Job", validations.CodeInclusion, "Unknown job "+schedule.Job, map[string]interface{}{"value": schedule.Job}))
+			} else if _, err := decodeArgs(job, schedule.Args); err != nil {
+				errs.Add(validations.NewFieldError(schedule, "Args", validations.CodeInvalid, err.Error(), nil))
+			}
+
+			if _, err := parseSpec(schedule.Spec); err != nil {
+				errs.Add(validations.NewFieldError(schedule, "Spec", validations.CodeInvalid, err.Error(), nil))
+			}
+
+			return errs.ErrorOrNil()
+		},
+	})
+
+	save := res.SaveHandler
+	res.SaveHandler = func(record interface{}, context *appsvr.Context) error {
+		if err := record.(*Schedule).next(time.Now()); err != nil {
+			return err
+		}
+		return save(record, context)
+	}
+	return res
+}
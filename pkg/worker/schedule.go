@@ -0,0 +1,202 @@
+package worker
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/bhojpur/application/pkg/client/leaderelection"
+	applog "github.com/bhojpur/application/pkg/log"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+// Schedule is a recurring job, a run of it is enqueued at every time of its cron expression, the schedules are
+// managed with NewScheduleResource, so that they can be paused.
+type Schedule struct {
+	ID        uint   `orm:"primary_key"`
+	Name      string `orm:"size:128;unique_index"`
+	Job       string `orm:"size:128"`
+	Args      string `orm:"type:text"`
+	Spec      string `orm:"size:128"`
+	Paused    bool
+	LastRunID uint
+	LastRunAt *time.Time
+	NextRunAt *time.Time `orm:"index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName table name of schedules
+func (Schedule) TableName() string {
+	return "worker_schedules"
+}
+
+// errSkipped rollbacks the enqueue of a schedule already enqueued by another replica
+var errSkipped = errors.New("schedule enqueued by another replica")
+
+// parseSpec parses a standard cron expression of five fields, or a descriptor like @hourly or @every 10m
+func parseSpec(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	return schedule, nil
+}
+
+// next sets the next run of the schedule after the time, none if it is paused
+func (schedule *Schedule) next(after time.Time) error {
+	if schedule.Paused {
+		schedule.NextRunAt = nil
+		return nil
+	}
+
+	parsed, err := parseSpec(schedule.Spec)
+	if err != nil {
+		return err
+	}
+	next := parsed.Next(after)
+	schedule.NextRunAt = &next
+	return nil
+}
+
+// Schedule registers a recurring run of a job with args at the times of spec, a cron expression like "0 3 * * *".
+// A schedule with the name of a stored one updates it, but keeps it paused if it is.
+//
+//	worker.Schedule("nightly_report", "0 3 * * *", "send_report", &SendReportArgs{Recipients: "ops"})
+func (w *Worker) Schedule(name, spec, job string, args interface{}) error {
+	encoded, err := w.encodeArgs(job, args)
+	if err != nil {
+		return err
+	}
+
+	var schedule Schedule
+	if err := w.db.Where("name = ?", name).First(&schedule).Error; err != nil && !orm.IsRecordNotFoundError(err) {
+		return err
+	}
+
+	if schedule.ID != 0 && schedule.Spec == spec && schedule.Job == job && schedule.Args == encoded {
+		return nil
+	}
+
+	schedule.Name, schedule.Job, schedule.Args, schedule.Spec = name, job, encoded, spec
+	if err := schedule.next(time.Now()); err != nil {
+		return err
+	}
+	return w.db.Save(&schedule).Error
+}
+
+// Unschedule removes a schedule, its enqueued runs are kept.
+func (w *Worker) Unschedule(name string) error {
+	return w.db.Where("name = ?", name).Delete(&Schedule{}).Error
+}
+
+// RunScheduler enqueues the runs of the due schedules until ctx is done. Replicas sharing a db enqueue a time of a
+// schedule once, but RunSchedulerWithLeaderElection saves the polling of the followers.
+func (w *Worker) RunScheduler(ctx context.Context) {
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.EnqueueDue(); err != nil {
+			log.Error("failed to enqueue scheduled jobs", applog.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunSchedulerWithLeaderElection runs the scheduler on the replica elected leader of the Lease of config only, until
+// ctx is done. The Lease is named worker-scheduler by default.
+func (w *Worker) RunSchedulerWithLeaderElection(ctx context.Context, client kubernetes.Interface, config leaderelection.Config) error {
+	if config.Name == "" {
+		config.Name = "worker-scheduler"
+	}
+	config.OnStartedLeading = w.RunScheduler
+	return leaderelection.Run(ctx, client, config)
+}
+
+// EnqueueDue enqueues a run of every schedule which is due and not paused, and sets its next run. The times missed
+// while no scheduler was running are enqueued once.
+func (w *Worker) EnqueueDue() error {
+	now := time.Now()
+
+	var schedules []Schedule
+	if err := w.db.Where("paused = ? AND next_run_at <= ?", false, now).Order("next_run_at, id").Find(&schedules).Error; err != nil {
+		return err
+	}
+
+	var enqueued bool
+	for idx := range schedules {
+		schedule := &schedules[idx]
+		logger := log.With(applog.String("schedule", schedule.Name), applog.String("job", schedule.Job))
+
+		err := w.db.Transaction(func(tx *orm.DB) error {
+			run := &Run{Job: schedule.Job, Args: schedule.Args, Status: StatusPending, RunAt: now}
+			if err := tx.Create(run).Error; err != nil {
+				return err
+			}
+
+			lastRunID := schedule.LastRunID
+			schedule.LastRunID, schedule.LastRunAt = run.ID, &now
+			if err := schedule.next(now); err != nil {
+				return err
+			}
+
+			// the run of the schedule is enqueued by the first replica updating its last run
+			claim := tx.Model(&Schedule{}).Where("id = ? AND last_run_id = ?", schedule.ID, lastRunID).Updates(map[string]interface{}{
+				"last_run_id": schedule.LastRunID,
+				"last_run_at": schedule.LastRunAt,
+				"next_run_at": schedule.NextRunAt,
+			})
+			if claim.Error != nil {
+				return claim.Error
+			}
+			if claim.RowsAffected == 0 {
+				return errSkipped
+			}
+			return nil
+		})
+
+		switch {
+		case err == nil:
+			enqueued = true
+			logger.Debug("scheduled job enqueued", applog.Int("run", int(schedule.LastRunID)))
+		case errors.Is(err, errSkipped):
+		default:
+			logger.Error("failed to enqueue scheduled job", applog.Err(err))
+		}
+	}
+
+	if enqueued {
+		w.wake()
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+package worker
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	appsvr "github.com/bhojpur/application/pkg/engine"
+	applog "github.com/bhojpur/application/pkg/log"
+	orm "github.com/bhojpur/orm/pkg/engine"
+)
+
+var log = applog.New("app.worker")
+
+// Run statuses
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Job is a kind of background work, runs of a job are enqueued with its arguments.
+//
+//	worker.Register(&worker.Job{
+//	    Name: "send_invoice",
+//	    Args: &SendInvoiceArgs{},
+//	    Handler: func(ctx context.Context, args interface{}) error {
+//	        return sendInvoice(ctx, args.(*SendInvoiceArgs).OrderID)
+//	    },
+//	})
+type Job struct {
+	Name string
+	// Args is a value of the type of the arguments of the job, runs are enqueued with values of the same type, and
+	// the handler gets a pointer to a new value decoded from the JSON of the run. It is nil for jobs without arguments.
+	Args interface{}
+	// Handler runs the job, the run is retried if it returns an error or panics.
+	Handler func(ctx context.Context, args interface{}) error
+	// MaxAttempts is the number of attempts of a run before it fails, the one of the worker if zero.
+	MaxAttempts int
+	// Timeout cancels the context of an attempt after it, no timeout if zero.
+	Timeout time.Duration
+}
+
+// Run is the state of a run of a job, the history of runs is managed with NewResource.
+type Run struct {
+	ID         uint   `orm:"primary_key"`
+	Job        string `orm:"size:128;index"`
+	Args       string `orm:"type:text"`
+	Status     string `orm:"size:32;index"`
+	Attempts   int
+	Error      string    `orm:"type:text"`
+	RunAt      time.Time `orm:"index"`
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// TableName table name of runs
+func (Run) TableName() string {
+	return "worker_runs"
+}
+
+// Config configures the runs of jobs.
+type Config struct {
+	// Concurrency is the number of runs attempted at the same time, 4 by default.
+	Concurrency int
+	// MaxAttempts is the number of attempts of a run before it fails, 3 by default.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled for every retry, 10 seconds by default.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries, 1 hour by default.
+	MaxBackoff time.Duration
+	// PollInterval is the interval the due runs are polled at, 5 seconds by default.
+	PollInterval time.Duration
+}
+
+// Worker runs the jobs registered to it with a pool of goroutines, runs are stored in db, so they are run by any
+// worker of the same db, and retried after a restart. The table of Run has to be migrated.
+type Worker struct {
+	db     *orm.DB
+	config Config
+	jobs   map[string]*Job
+	mutex  sync.RWMutex
+	nudge  chan struct{}
+}
+
+// New returns a worker storing runs in db.
+func New(db *orm.DB, config Config) *Worker {
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
+
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+
+	if config.Backoff <= 0 {
+		config.Backoff = 10 * time.Second
+	}
+
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = time.Hour
+	}
+
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	return &Worker{db: db, config: config, jobs: map[string]*Job{}, nudge: make(chan struct{}, 1)}
+}
+
+// Register registers jobs, a job with the name of a registered one replaces it.
+func (w *Worker) Register(jobs ...*Job) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for _, job := range jobs {
+		w.jobs[job.Name] = job
+	}
+}
+
+// GetJob returns a registered job by name.
+func (w *Worker) GetJob(name string) *Job {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.jobs[name]
+}
+
+// Enqueue enqueues a run of a job with args, it runs as soon as a worker is available.
+func (w *Worker) Enqueue(name string, args interface{}) (*Run, error) {
+	return w.enqueue(w.db, name, args, time.Now())
+}
+
+// EnqueueAt enqueues a run of a job with args, it runs at the time at the earliest.
+func (w *Worker) EnqueueAt(name string, args interface{}, at time.Time) (*Run, error) {
+	return w.enqueue(w.db, name, args, at)
+}
+
+// EnqueueWithContext enqueues a run of a job with the DB of an Apps context, so that runs enqueued by the processors
+// and handlers of resources in a transaction are enqueued if it is committed only.
+func (w *Worker) EnqueueWithContext(context *appsvr.Context, name string, args interface{}) (*Run, error) {
+	return w.enqueue(context.GetDB(), name, args, time.Now())
+}
+
+func (w *Worker) enqueue(db *orm.DB, name string, args interface{}, at time.Time) (*Run, error) {
+	encoded, err := w.encodeArgs(name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &Run{Job: name, Args: encoded, Status: StatusPending, RunAt: at}
+	if err := db.Create(run).Error; err != nil {
+		return nil, err
+	}
+	w.wake()
+	return run, nil
+}
+
+// encodeArgs returns the JSON of the arguments of a run of a registered job, args has to be of the type of the
+// arguments of the job
+func (w *Worker) encodeArgs(name string, args interface{}) (string, error) {
+	job := w.GetJob(name)
+	if job == nil {
+		return "", fmt.Errorf("unknown job %s", name)
+	}
+
+	if job.Args != nil && args != nil && reflect.Indirect(reflect.ValueOf(args)).Type() != reflect.Indirect(reflect.ValueOf(job.Args)).Type() {
+		return "", fmt.Errorf("arguments of job %s should be %T, got %T", name, job.Args, args)
+	}
+
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode arguments of job %s: %w", name, err)
+	}
+	return string(encoded), nil
+}
+
+// Run runs the due runs until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.RunDue(ctx); err != nil {
+			log.Error("failed to run jobs", applog.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.nudge:
+		}
+	}
+}
+
+// RunDue attempts the pending runs which are due with the pool of the worker, and waits for them.
+func (w *Worker) RunDue(ctx context.Context) error {
+	var runs []Run
+	if err := w.db.Where("status = ? AND run_at <= ?", StatusPending, time.Now()).Order("run_at, id").Find(&runs).Error; err != nil {
+		return err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		pool     = make(chan struct{}, w.config.Concurrency)
+		errMutex sync.Mutex
+		firstErr error
+	)
+	for idx := range runs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		pool <- struct{}{}
+		wg.Add(1)
+		go func(run *Run) {
+			defer func() { <-pool; wg.Done() }()
+			if err := w.attempt(ctx, run); err != nil {
+				errMutex.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errMutex.Unlock()
+			}
+		}(&runs[idx])
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// attempt claims a run, so that no other worker attempts it, runs its job, and records the result. A failed attempt
+// is retried with an exponential backoff, until the maximum number of attempts.
+func (w *Worker) attempt(ctx context.Context, run *Run) error {
+	now := time.Now()
+	claim := w.db.Model(&Run{}).Where("id = ? AND status = ?", run.ID, StatusPending).
+		Updates(map[string]interface{}{"status": StatusRunning, "started_at": now})
+	if claim.Error != nil {
+		return claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		// claimed by another worker, or cancelled
+		return nil
+	}
+	run.Status, run.StartedAt = StatusRunning, &now
+
+	job := w.GetJob(run.Job)
+	maxAttempts := w.config.MaxAttempts
+	var err error
+	if job == nil {
+		err, maxAttempts = fmt.Errorf("unknown job %s", run.Job), 0
+	} else {
+		if job.MaxAttempts > 0 {
+			maxAttempts = job.MaxAttempts
+		}
+		err = w.perform(ctx, job, run)
+	}
+
+	run.Attempts++
+	finished := time.Now()
+	logger := log.With(applog.String("job", run.Job), applog.Int("run", int(run.ID)), applog.Int("attempt", run.Attempts), applog.Duration(finished.Sub(now)))
+	switch {
+	case err == nil:
+		run.Status, run.Error, run.FinishedAt = StatusSucceeded, "", &finished
+		logger.Info("job succeeded")
+	case run.Attempts >= maxAttempts:
+		run.Status, run.Error, run.FinishedAt = StatusFailed, err.Error(), &finished
+		logger.Error("job failed", applog.Err(err))
+	default:
+		run.Status, run.Error = StatusPending, err.Error()
+		run.RunAt = finished.Add(backoff(run.Attempts, w.config.Backoff, w.config.MaxBackoff))
+		logger.Warn("job attempt failed, it will be retried", applog.Err(err), applog.Any("retry_at", run.RunAt))
+	}
+	return w.db.Save(run).Error
+}
+
+// perform runs the handler of the job with the arguments of the run, a panic is an error
+func (w *Worker) perform(ctx context.Context, job *Job, run *Run) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job %s panicked: %v", job.Name, r)
+		}
+	}()
+
+	args, err := decodeArgs(job, run.Args)
+	if err != nil {
+		return err
+	}
+
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+	return job.Handler(ctx, args)
+}
+
+// decodeArgs returns a pointer to a new value of the type of the arguments of the job, decoded from JSON
+func decodeArgs(job *Job, encoded string) (interface{}, error) {
+	if job.Args == nil {
+		return nil, nil
+	}
+
+	args := reflect.New(reflect.Indirect(reflect.ValueOf(job.Args)).Type()).Interface()
+	if encoded != "" && encoded != "null" {
+		if err := json.Unmarshal([]byte(encoded), args); err != nil {
+			return nil, fmt.Errorf("invalid arguments of job %s: %w", job.Name, err)
+		}
+	}
+	return args, nil
+}
+
+// Retry schedules a run again, like a failed one, with a new set of attempts.
+func (w *Worker) Retry(runID uint) error {
+	err := w.db.Model(&Run{}).Where("id = ? AND status <> ?", runID, StatusRunning).Updates(map[string]interface{}{
+		"status":   StatusPending,
+		"attempts": 0,
+		"run_at":   time.Now(),
+	}).Error
+
+	if err == nil {
+		w.wake()
+	}
+	return err
+}
+
+// Cancel cancels a pending run, running runs complete.
+func (w *Worker) Cancel(runID uint) error {
+	return w.db.Model(&Run{}).Where("id = ? AND status = ?", runID, StatusPending).UpdateColumn("status", StatusCancelled).Error
+}
+
+func (w *Worker) wake() {
+	select {
+	case w.nudge <- struct{}{}:
+	default:
+	}
+}
+
+// backoff returns the delay before the retry following the attempt, base doubled for every attempt, up to max.
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		if delay *= 2; delay >= max {
+			return max
+		}
+	}
+
+	if delay > max {
+		return max
+	}
+	return delay
+}
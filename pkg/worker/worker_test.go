@@ -0,0 +1,167 @@
+package worker_test
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bhojpur/application/pkg/worker"
+	"github.com/bhojpur/application/test/utils"
+	orm "github.com/bhojpur/orm/pkg/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type greetArgs struct {
+	Name string
+}
+
+func newWorker(t *testing.T) (*worker.Worker, *orm.DB) {
+	db := utils.SQLiteTestDB(t, `CREATE TABLE worker_runs (id INTEGER PRIMARY KEY AUTOINCREMENT, job TEXT, args TEXT, status TEXT,
+		attempts INTEGER, error TEXT, run_at DATETIME, started_at DATETIME, finished_at DATETIME, created_at DATETIME, updated_at DATETIME)`)
+	return worker.New(db, worker.Config{MaxAttempts: 2, Backoff: time.Millisecond}), db
+}
+
+func TestWorker(t *testing.T) {
+	w, db := newWorker(t)
+
+	var greeted []string
+	w.Register(&worker.Job{
+		Name: "greet",
+		Args: &greetArgs{},
+		Handler: func(ctx context.Context, args interface{}) error {
+			greeted = append(greeted, args.(*greetArgs).Name)
+			return nil
+		},
+	})
+
+	_, err := w.Enqueue("greet", 42)
+	assert.Error(t, err)
+	_, err = w.Enqueue("unknown", nil)
+	assert.Error(t, err)
+
+	run, err := w.Enqueue("greet", &greetArgs{Name: "Ada"})
+	require.NoError(t, err)
+	_, err = w.EnqueueAt("greet", greetArgs{Name: "Later"}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	require.NoError(t, w.RunDue(context.Background()))
+	assert.Equal(t, []string{"Ada"}, greeted)
+
+	var stored worker.Run
+	require.NoError(t, db.First(&stored, run.ID).Error)
+	assert.Equal(t, worker.StatusSucceeded, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.NotNil(t, stored.FinishedAt)
+}
+
+func TestWorkerRetries(t *testing.T) {
+	w, db := newWorker(t)
+
+	attempts := 0
+	w.Register(&worker.Job{
+		Name: "flaky",
+		Handler: func(ctx context.Context, args interface{}) error {
+			if attempts++; attempts == 1 {
+				return errors.New("unavailable")
+			}
+			panic("broken")
+		},
+	})
+
+	run, err := w.Enqueue("flaky", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, w.RunDue(context.Background()))
+	var stored worker.Run
+	require.NoError(t, db.First(&stored, run.ID).Error)
+	assert.Equal(t, worker.StatusPending, stored.Status)
+	assert.Equal(t, "unavailable", stored.Error)
+	assert.True(t, stored.RunAt.After(*stored.StartedAt))
+
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, w.RunDue(context.Background()))
+	require.NoError(t, db.First(&stored, run.ID).Error)
+	assert.Equal(t, worker.StatusFailed, stored.Status)
+	assert.Equal(t, 2, stored.Attempts)
+	assert.Contains(t, stored.Error, "panicked")
+
+	// a retried run gets a new set of attempts
+	require.NoError(t, w.Retry(run.ID))
+	require.NoError(t, db.First(&stored, run.ID).Error)
+	assert.Equal(t, worker.StatusPending, stored.Status)
+	assert.Equal(t, 0, stored.Attempts)
+
+	require.NoError(t, w.Cancel(run.ID))
+	require.NoError(t, w.RunDue(context.Background()))
+	assert.Equal(t, 2, attempts)
+}
+
+func TestSchedule(t *testing.T) {
+	w, db := newWorker(t)
+	require.NoError(t, db.Exec(`CREATE TABLE worker_schedules (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE, job TEXT, args TEXT,
+		spec TEXT, paused BOOLEAN, last_run_id INTEGER, last_run_at DATETIME, next_run_at DATETIME, created_at DATETIME, updated_at DATETIME)`).Error)
+	w.Register(&worker.Job{Name: "greet", Args: &greetArgs{}, Handler: func(context.Context, interface{}) error { return nil }})
+
+	assert.Error(t, w.Schedule("hourly", "every hour", "greet", nil))
+	require.NoError(t, w.Schedule("hourly", "0 * * * *", "greet", &greetArgs{Name: "Ada"}))
+
+	var schedule worker.Schedule
+	require.NoError(t, db.Where("name = ?", "hourly").First(&schedule).Error)
+	require.NotNil(t, schedule.NextRunAt)
+	assert.Equal(t, 0, schedule.NextRunAt.Minute())
+	assert.True(t, schedule.NextRunAt.After(time.Now()))
+
+	// not due yet
+	require.NoError(t, w.EnqueueDue())
+	count := 0
+	db.Model(&worker.Run{}).Count(&count)
+	assert.Equal(t, 0, count)
+
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, db.Model(&schedule).UpdateColumn("next_run_at", past).Error)
+	require.NoError(t, w.EnqueueDue())
+	require.NoError(t, w.EnqueueDue())
+
+	var runs []worker.Run
+	require.NoError(t, db.Find(&runs).Error)
+	require.Len(t, runs, 1)
+	assert.Equal(t, `{"Name":"Ada"}`, runs[0].Args)
+
+	require.NoError(t, db.First(&schedule, schedule.ID).Error)
+	assert.Equal(t, runs[0].ID, schedule.LastRunID)
+	assert.NotNil(t, schedule.LastRunAt)
+	assert.True(t, schedule.NextRunAt.After(time.Now()))
+
+	// paused schedules are not enqueued, and registering them again keeps them paused
+	require.NoError(t, db.Model(&schedule).UpdateColumns(map[string]interface{}{"paused": true, "next_run_at": past}).Error)
+	require.NoError(t, w.Schedule("hourly", "30 * * * *", "greet", &greetArgs{Name: "Ada"}))
+	require.NoError(t, w.EnqueueDue())
+	db.Model(&worker.Run{}).Count(&count)
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, db.First(&schedule, schedule.ID).Error)
+	assert.True(t, schedule.Paused)
+	assert.Nil(t, schedule.NextRunAt)
+}
@@ -23,10 +23,12 @@ package utils
 import (
 	"fmt"
 	"os"
+	"testing"
 
 	orm "github.com/bhojpur/orm/pkg/engine"
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 // TestDB initialize a db for testing
@@ -71,6 +73,28 @@ func TestDB() *orm.DB {
 	return db
 }
 
+// SQLiteTestDB initialize an in-memory sqlite db for a test, closed when the test ends, and runs the statements
+// creating its tables. Tables are created by statements as the ones migrated with sqlite have no auto-increment
+// primary key
+func SQLiteTestDB(t testing.TB, statements ...string) *orm.DB {
+	t.Helper()
+
+	db, err := orm.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// every connection has its own in-memory database
+	db.DB().SetMaxOpenConns(1)
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
 var db *orm.DB
 
 func GetTestDB() *orm.DB {